@@ -21,6 +21,7 @@ import (
 const (
 	pricingOP = "pricingOP"
 	depositOP = "depositOP"
+	alerterOP = "alerterOP"
 
 	// feeToWalletEvent is the topic of event AssignFeeToWallet(address reserve, address wallet, uint walletFee).
 	feeToWalletEvent = "0x366bc34352215bf0bd3b527cfd6718605e1f5938777e42bcd8ed92f578368f52"
@@ -139,6 +140,13 @@ func (self *Blockchain) RegisterDepositOperator(signer blockchain.Signer, nonceC
 	self.MustRegisterOperator(depositOP, blockchain.NewOperator(signer, nonceCorpus))
 }
 
+// RegisterAlerterOperator registers the alerter of the reserve and pricing
+// contracts, which disables trade in emergency.
+func (self *Blockchain) RegisterAlerterOperator(signer blockchain.Signer, nonceCorpus blockchain.NonceCorpus) {
+	log.Printf("reserve alerter address: %s", signer.GetAddress().Hex())
+	self.MustRegisterOperator(alerterOP, blockchain.NewOperator(signer, nonceCorpus))
+}
+
 func readablePrint(data map[ethereum.Address]byte) string {
 	result := ""
 	for addr, b := range data {
@@ -153,7 +161,8 @@ func readablePrint(data map[ethereum.Address]byte) string {
 // we got a bug when compact is not set to old compact
 // or when one of buy/sell got overflowed, it discards
 // the other's compact
-func (self *Blockchain) SetRates(
+// buildSetRatesTx builds the unsigned set base rate or set compact data tx.
+func (self *Blockchain) buildSetRatesTx(
 	tokens []ethereum.Address,
 	buys []*big.Int,
 	sells []*big.Int,
@@ -245,11 +254,38 @@ func (self *Blockchain) SetRates(
 			// 	tx.Hash().Hex(), err, baseTokens, buys, sells, block.Text(10), indices,
 			// )
 		}
-		if err != nil {
-			return nil, err
-		}
-		return self.SignAndBroadcast(tx, pricingOP)
+		return tx, err
+	}
+}
+
+func (self *Blockchain) SetRates(
+	tokens []ethereum.Address,
+	buys []*big.Int,
+	sells []*big.Int,
+	block *big.Int,
+	nonce *big.Int,
+	gasPrice *big.Int) (*types.Transaction, error) {
+	tx, err := self.buildSetRatesTx(tokens, buys, sells, block, nonce, gasPrice)
+	if err != nil {
+		return nil, err
+	}
+	return self.SignAndBroadcast(tx, pricingOP)
+}
+
+// BuildSetRates builds and signs the set rates tx like SetRates, without
+// broadcasting it.
+func (self *Blockchain) BuildSetRates(
+	tokens []ethereum.Address,
+	buys []*big.Int,
+	sells []*big.Int,
+	block *big.Int,
+	nonce *big.Int,
+	gasPrice *big.Int) (*types.Transaction, error) {
+	tx, err := self.buildSetRatesTx(tokens, buys, sells, block, nonce, gasPrice)
+	if err != nil {
+		return nil, err
 	}
+	return self.SignTx(tx, pricingOP)
 }
 
 func (self *Blockchain) Send(
@@ -271,6 +307,59 @@ func (self *Blockchain) Send(
 	return self.SignAndBroadcast(tx, depositOP)
 }
 
+// BuildSend builds and signs the deposit tx like Send, without broadcasting
+// it. The tx uses the pending nonce of the deposit operator from node, which
+// does not take the nonces reserved by Send into account.
+func (self *Blockchain) BuildSend(
+	token common.Token,
+	amount *big.Int,
+	dest ethereum.Address) (*types.Transaction, error) {
+
+	nonce, err := self.GetPendingNonce(depositOP)
+	if err != nil {
+		return nil, err
+	}
+	opts, err := self.GetTxOpts(depositOP, nonce, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	tx, err := self.GeneratedWithdraw(
+		opts,
+		ethereum.HexToAddress(token.Address),
+		amount, dest)
+	if err != nil {
+		return nil, err
+	}
+	return self.SignTx(tx, depositOP)
+}
+
+// DepositMinedNonce returns the mined nonce of the deposit operator.
+func (self *Blockchain) DepositMinedNonce() (uint64, error) {
+	return self.GetMinedNonce(depositOP)
+}
+
+// ReplaceDepositTx re-broadcasts the pending deposit transaction with a higher gas price.
+func (self *Blockchain) ReplaceDepositTx(tx *types.Transaction, gasPrice *big.Int) (*types.Transaction, error) {
+	return self.ReplaceTransaction(tx, gasPrice, depositOP)
+}
+
+// CancelDepositTx cancels the pending deposit transaction of the given nonce.
+func (self *Blockchain) CancelDepositTx(nonce uint64, gasPrice *big.Int) (*types.Transaction, error) {
+	return self.CancelTransaction(nonce, gasPrice, depositOP)
+}
+
+// IntermediatorMinedNonce returns the mined nonce of the intermediate
+// account, which forwards deposits to Huobi.
+func (self *Blockchain) IntermediatorMinedNonce() (uint64, error) {
+	return self.GetMinedNonce(huobiblockchain.HuobiOP)
+}
+
+// ReplaceIntermediatorTx re-broadcasts the pending transaction of the
+// intermediate account with a higher gas price.
+func (self *Blockchain) ReplaceIntermediatorTx(tx *types.Transaction, gasPrice *big.Int) (*types.Transaction, error) {
+	return self.ReplaceTransaction(tx, gasPrice, huobiblockchain.HuobiOP)
+}
+
 func (self *Blockchain) SetImbalanceStepFunction(token ethereum.Address, xBuy []*big.Int, yBuy []*big.Int, xSell []*big.Int, ySell []*big.Int) (*types.Transaction, error) {
 	opts, err := self.GetTxOpts(pricingOP, nil, nil, nil)
 	if err != nil {
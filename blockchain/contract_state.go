@@ -0,0 +1,233 @@
+package blockchain
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/KyberNetwork/reserve-data/common"
+	"github.com/KyberNetwork/reserve-data/common/blockchain"
+	ethereum "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+func (self *Blockchain) GeneratedAdmin(opts blockchain.CallOpts, contract *blockchain.Contract) (ethereum.Address, error) {
+	out := new(ethereum.Address)
+	timeOut := 2 * time.Second
+	err := self.Call(timeOut, opts, contract, out, "admin")
+	return *out, err
+}
+
+func (self *Blockchain) GeneratedGetOperators(opts blockchain.CallOpts, contract *blockchain.Contract) ([]ethereum.Address, error) {
+	out := new([]ethereum.Address)
+	timeOut := 2 * time.Second
+	err := self.Call(timeOut, opts, contract, out, "getOperators")
+	return *out, err
+}
+
+func (self *Blockchain) GeneratedGetAlerters(opts blockchain.CallOpts, contract *blockchain.Contract) ([]ethereum.Address, error) {
+	out := new([]ethereum.Address)
+	timeOut := 2 * time.Second
+	err := self.Call(timeOut, opts, contract, out, "getAlerters")
+	return *out, err
+}
+
+// withdrawAddressKey is the key of approvedWithdrawAddresses of the reserve,
+// keccak256(token, addr).
+func withdrawAddressKey(token, addr ethereum.Address) [32]byte {
+	return crypto.Keccak256Hash(token.Bytes(), addr.Bytes())
+}
+
+func hexAddresses(addrs []ethereum.Address) []string {
+	result := []string{}
+	for _, addr := range addrs {
+		result = append(result, addr.Hex())
+	}
+	return result
+}
+
+func containsAddress(addrs []string, addr ethereum.Address) bool {
+	for _, a := range addrs {
+		if ethereum.HexToAddress(a) == addr {
+			return true
+		}
+	}
+	return false
+}
+
+func (self *Blockchain) getPermissions(opts blockchain.CallOpts, contract *blockchain.Contract) (common.ContractPermissions, error) {
+	result := common.ContractPermissions{Address: contract.Address.Hex()}
+	admin, err := self.GeneratedAdmin(opts, contract)
+	if err != nil {
+		return result, err
+	}
+	result.Admin = admin.Hex()
+	operators, err := self.GeneratedGetOperators(opts, contract)
+	if err != nil {
+		return result, err
+	}
+	result.Operators = hexAddresses(operators)
+	alerters, err := self.GeneratedGetAlerters(opts, contract)
+	if err != nil {
+		return result, err
+	}
+	result.Alerters = hexAddresses(alerters)
+	return result, nil
+}
+
+func (self *Blockchain) getTokenContractState(opts blockchain.CallOpts, id string, token ethereum.Address) (common.TokenContractState, error) {
+	result := common.TokenContractState{
+		ID:                id,
+		Address:           token.Hex(),
+		WithdrawAddresses: map[string]common.WithdrawAddressState{},
+	}
+	var err error
+	if result.Listed, result.Enabled, err = self.GeneratedGetTokenBasicData(opts, token); err != nil {
+		return result, err
+	}
+	result.MinimalRecordResolution, result.MaxPerBlockImbalance, result.MaxTotalImbalance, err = self.GeneratedGetTokenControlInfo(opts, token)
+	return result, err
+}
+
+// GetContractAdminState returns the admin state of the reserve and pricing
+// contracts: permission groups, trade enabled, valid rate duration, and the
+// basic data, control info and approved withdraw addresses of the internal
+// tokens and the tokens listed on the pricing contract. withdrawAddresses are
+// the addresses the reserve withdraws tokens to by token id and exchange,
+// which must be approved. Mismatches against settings are flagged.
+func (self *Blockchain) GetContractAdminState(withdrawAddresses map[string]map[string]ethereum.Address) (common.ContractAdminState, error) {
+	result := common.ContractAdminState{
+		Tokens:     map[string]common.TokenContractState{},
+		Mismatches: []string{},
+	}
+	block, err := self.CurrentBlock()
+	if err != nil {
+		return result, err
+	}
+	result.BlockNumber = block
+	opts := self.GetCallOpts(block)
+
+	if result.Reserve.ContractPermissions, err = self.getPermissions(opts, self.reserve); err != nil {
+		return result, err
+	}
+	if result.Reserve.TradeEnabled, err = self.GeneratedTradeEnabled(opts); err != nil {
+		return result, err
+	}
+	ratesContract, err := self.GeneratedConversionRatesContract(opts)
+	if err != nil {
+		return result, err
+	}
+	result.Reserve.ConversionRatesContract = ratesContract.Hex()
+	if result.Pricing.ContractPermissions, err = self.getPermissions(opts, self.pricing); err != nil {
+		return result, err
+	}
+	if result.Pricing.ValidRateDurationInBlocks, err = self.GeneratedValidRateDurationInBlocks(opts); err != nil {
+		return result, err
+	}
+	reserveContract, err := self.GeneratedReserveContract(opts)
+	if err != nil {
+		return result, err
+	}
+	result.Pricing.ReserveContract = reserveContract.Hex()
+
+	internalTokens, err := self.setting.GetInternalTokens()
+	if err != nil {
+		return result, err
+	}
+	listedTokens, err := self.GeneratedGetListedTokens(opts)
+	if err != nil {
+		return result, err
+	}
+	ids := map[ethereum.Address]string{}
+	for _, token := range internalTokens {
+		ids[ethereum.HexToAddress(token.Address)] = token.ID
+	}
+	for _, token := range internalTokens {
+		addr := ethereum.HexToAddress(token.Address)
+		state := common.TokenContractState{
+			ID:                token.ID,
+			Address:           addr.Hex(),
+			WithdrawAddresses: map[string]common.WithdrawAddressState{},
+		}
+		// ETH is withdrawn from the reserve but not priced by the pricing contract
+		if !token.IsETH() {
+			if state, err = self.getTokenContractState(opts, token.ID, addr); err != nil {
+				return result, err
+			}
+		}
+		for exchangeID, withdrawAddr := range withdrawAddresses[token.ID] {
+			approved, aErr := self.GeneratedApprovedWithdrawAddresses(opts, withdrawAddressKey(addr, withdrawAddr))
+			if aErr != nil {
+				return result, aErr
+			}
+			state.WithdrawAddresses[exchangeID] = common.WithdrawAddressState{
+				Address:  withdrawAddr.Hex(),
+				Approved: approved,
+			}
+		}
+		result.Tokens[token.ID] = state
+	}
+	for _, addr := range listedTokens {
+		if _, internal := ids[addr]; internal {
+			continue
+		}
+		state, sErr := self.getTokenContractState(opts, "", addr)
+		if sErr != nil {
+			return result, sErr
+		}
+		result.Tokens[addr.Hex()] = state
+	}
+	result.Mismatches = contractStateMismatches(result, self.OperatorAddresses())
+	return result, nil
+}
+
+// contractStateMismatches returns the differences between the admin state of
+// the contracts and settings, sorted.
+func contractStateMismatches(state common.ContractAdminState, operators map[string]ethereum.Address) []string {
+	result := []string{}
+	if !state.Reserve.TradeEnabled {
+		result = append(result, "trade is disabled on the reserve")
+	}
+	reserveAddr := ethereum.HexToAddress(state.Reserve.Address)
+	pricingAddr := ethereum.HexToAddress(state.Pricing.Address)
+	if ethereum.HexToAddress(state.Reserve.ConversionRatesContract) != pricingAddr {
+		result = append(result, fmt.Sprintf("conversion rates contract of the reserve is %s, not pricing %s", state.Reserve.ConversionRatesContract, state.Pricing.Address))
+	}
+	if ethereum.HexToAddress(state.Pricing.ReserveContract) != reserveAddr {
+		result = append(result, fmt.Sprintf("reserve contract of pricing is %s, not reserve %s", state.Pricing.ReserveContract, state.Reserve.Address))
+	}
+	if addr, ok := operators[pricingOP]; ok && !containsAddress(state.Pricing.Operators, addr) {
+		result = append(result, fmt.Sprintf("pricing operator %s is not an operator of pricing", addr.Hex()))
+	}
+	if addr, ok := operators[depositOP]; ok && !containsAddress(state.Reserve.Operators, addr) {
+		result = append(result, fmt.Sprintf("deposit operator %s is not an operator of the reserve", addr.Hex()))
+	}
+	if addr, ok := operators[alerterOP]; ok {
+		if !containsAddress(state.Reserve.Alerters, addr) {
+			result = append(result, fmt.Sprintf("alerter operator %s is not an alerter of the reserve", addr.Hex()))
+		}
+		if !containsAddress(state.Pricing.Alerters, addr) {
+			result = append(result, fmt.Sprintf("alerter operator %s is not an alerter of pricing", addr.Hex()))
+		}
+	}
+	for key, token := range state.Tokens {
+		if token.ID == "" {
+			result = append(result, fmt.Sprintf("token %s is listed on pricing but not an active internal token", key))
+			continue
+		}
+		if token.ID != "ETH" {
+			if !token.Listed {
+				result = append(result, fmt.Sprintf("internal token %s is not listed on pricing", token.ID))
+			} else if !token.Enabled {
+				result = append(result, fmt.Sprintf("internal token %s is not enabled on pricing", token.ID))
+			}
+		}
+		for exchangeID, withdrawAddr := range token.WithdrawAddresses {
+			if !withdrawAddr.Approved {
+				result = append(result, fmt.Sprintf("deposit address %s of %s on %s is not an approved withdraw address of the reserve", withdrawAddr.Address, token.ID, exchangeID))
+			}
+		}
+	}
+	sort.Strings(result)
+	return result
+}
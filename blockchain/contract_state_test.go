@@ -0,0 +1,73 @@
+package blockchain
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/KyberNetwork/reserve-data/common"
+	"github.com/KyberNetwork/reserve-data/common/blockchain"
+	ethereum "github.com/ethereum/go-ethereum/common"
+)
+
+func TestContractStateMismatches(t *testing.T) {
+	reserveAddr := "0x1111111111111111111111111111111111111111"
+	pricingAddr := "0x2222222222222222222222222222222222222222"
+	operator := ethereum.HexToAddress("0x3333333333333333333333333333333333333333")
+	alerter := ethereum.HexToAddress("0x8888888888888888888888888888888888888888")
+	state := common.ContractAdminState{
+		Reserve: common.ReserveContractState{
+			ContractPermissions:     common.ContractPermissions{Address: reserveAddr, Operators: []string{operator.Hex()}, Alerters: []string{alerter.Hex()}},
+			TradeEnabled:            true,
+			ConversionRatesContract: pricingAddr,
+		},
+		Pricing: common.PricingContractState{
+			ContractPermissions: common.ContractPermissions{Address: pricingAddr, Operators: []string{operator.Hex()}, Alerters: []string{alerter.Hex()}},
+			ReserveContract:     reserveAddr,
+		},
+		Tokens: map[string]common.TokenContractState{
+			"ETH": {ID: "ETH", WithdrawAddresses: map[string]common.WithdrawAddressState{
+				"binance": {Address: "0x4444444444444444444444444444444444444444", Approved: true},
+			}},
+			"KNC": {ID: "KNC", Listed: true, Enabled: true, WithdrawAddresses: map[string]common.WithdrawAddressState{}},
+		},
+	}
+	operators := map[string]ethereum.Address{pricingOP: operator, depositOP: operator, alerterOP: alerter}
+	if mismatches := contractStateMismatches(state, operators); len(mismatches) != 0 {
+		t.Fatalf("Expected no mismatch, got %v", mismatches)
+	}
+
+	state.Reserve.TradeEnabled = false
+	state.Pricing.ReserveContract = pricingAddr
+	state.Pricing.Alerters = []string{}
+	state.Tokens["OMG"] = common.TokenContractState{ID: "OMG", Listed: true}
+	state.Tokens["KNC"] = common.TokenContractState{ID: "KNC", Listed: true, Enabled: true, WithdrawAddresses: map[string]common.WithdrawAddressState{
+		"binance": {Address: "0x5555555555555555555555555555555555555555"},
+	}}
+	state.Tokens["0x6666666666666666666666666666666666666666"] = common.TokenContractState{Listed: true}
+	operators[depositOP] = ethereum.HexToAddress("0x7777777777777777777777777777777777777777")
+	expected := []string{
+		"alerter operator 0x8888888888888888888888888888888888888888 is not an alerter of pricing",
+		"deposit address 0x5555555555555555555555555555555555555555 of KNC on binance is not an approved withdraw address of the reserve",
+		"deposit operator 0x7777777777777777777777777777777777777777 is not an operator of the reserve",
+		"internal token OMG is not enabled on pricing",
+		"reserve contract of pricing is " + pricingAddr + ", not reserve " + reserveAddr,
+		"token 0x6666666666666666666666666666666666666666 is listed on pricing but not an active internal token",
+		"trade is disabled on the reserve",
+	}
+	if mismatches := contractStateMismatches(state, operators); !reflect.DeepEqual(mismatches, expected) {
+		t.Errorf("Expected mismatches %v, got %v", expected, mismatches)
+	}
+}
+
+func TestWithdrawAddressKey(t *testing.T) {
+	reserve := blockchain.NewContract(ethereum.HexToAddress("0x1111111111111111111111111111111111111111"), "reserve.abi")
+	token := ethereum.HexToAddress("0x2222222222222222222222222222222222222222")
+	addr := ethereum.HexToAddress("0x3333333333333333333333333333333333333333")
+	key := withdrawAddressKey(token, addr)
+	if key == withdrawAddressKey(addr, token) {
+		t.Error("Expected withdraw address key depends on the order of token and address")
+	}
+	if _, err := reserve.ABI.Pack("approvedWithdrawAddresses", key); err != nil {
+		t.Fatal(err)
+	}
+}
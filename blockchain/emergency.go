@@ -0,0 +1,94 @@
+package blockchain
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/KyberNetwork/reserve-data/common"
+	ethereum "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// enableTradeTxGas is the gas limit of enabling trade, built unsigned for the
+// admin wallet.
+const enableTradeTxGas uint64 = 100000
+
+var errNoAlerter = errors.New("alerter operator is not registered")
+
+func (self *Blockchain) hasAlerter() bool {
+	_, ok := self.OperatorAddresses()[alerterOP]
+	return ok
+}
+
+// AlerterMinedNonce returns the mined nonce of the alerter operator.
+func (self *Blockchain) AlerterMinedNonce() (uint64, error) {
+	if !self.hasAlerter() {
+		return 0, errNoAlerter
+	}
+	return self.GetMinedNonce(alerterOP)
+}
+
+// DisableTrade disables trade on the reserve by the alerter operator, with
+// the given nonce and gas price so a pending one can be replaced.
+func (self *Blockchain) DisableTrade(nonce *big.Int, gasPrice *big.Int) (*types.Transaction, error) {
+	if !self.hasAlerter() {
+		return nil, errNoAlerter
+	}
+	opts, err := self.GetTxOpts(alerterOP, nonce, gasPrice, nil)
+	if err != nil {
+		return nil, err
+	}
+	tx, err := self.GeneratedDisableTrade(opts)
+	if err != nil {
+		return nil, err
+	}
+	return self.SignAndBroadcast(tx, alerterOP)
+}
+
+// DisableTokenTrade disables trade of a token on the pricing contract by the
+// alerter operator, with the given nonce and gas price.
+func (self *Blockchain) DisableTokenTrade(token ethereum.Address, nonce *big.Int, gasPrice *big.Int) (*types.Transaction, error) {
+	if !self.hasAlerter() {
+		return nil, errNoAlerter
+	}
+	opts, err := self.GetTxOpts(alerterOP, nonce, gasPrice, nil)
+	if err != nil {
+		return nil, err
+	}
+	tx, err := self.GeneratedDisableTokenTrade(opts, token)
+	if err != nil {
+		return nil, err
+	}
+	return self.SignAndBroadcast(tx, alerterOP)
+}
+
+// BuildEnableTradeTxs builds the unsigned transactions enabling trade on the
+// reserve and of tokens on the pricing contract. Only the admin of the
+// contracts can enable trade, so they must be sent by the admin wallet.
+func (self *Blockchain) BuildEnableTradeTxs(tokens []common.Token) ([]common.EmergencyTx, error) {
+	data, err := self.reserve.ABI.Pack("enableTrade")
+	if err != nil {
+		return nil, err
+	}
+	result := []common.EmergencyTx{{
+		Method:   "enableTrade",
+		To:       self.reserve.Address.Hex(),
+		Data:     hexutil.Encode(data),
+		GasLimit: enableTradeTxGas,
+	}}
+	for _, token := range tokens {
+		if data, err = self.pricing.ABI.Pack("enableTokenTrade", ethereum.HexToAddress(token.Address)); err != nil {
+			return nil, fmt.Errorf("packing enableTokenTrade of token %s failed: %s", token.ID, err)
+		}
+		result = append(result, common.EmergencyTx{
+			Method:   "enableTokenTrade",
+			Token:    token.ID,
+			To:       self.pricing.Address.Hex(),
+			Data:     hexutil.Encode(data),
+			GasLimit: enableTradeTxGas,
+		})
+	}
+	return result, nil
+}
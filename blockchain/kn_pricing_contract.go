@@ -51,3 +51,60 @@ func (bc *Blockchain) GeneratedGetStepFunctionData(opts blockchain.CallOpts, tok
 	err := bc.Call(timeOut, opts, bc.pricing, out, "getStepFunctionData", token, command, param)
 	return *ret0, err
 }
+
+func (self *Blockchain) GeneratedValidRateDurationInBlocks(opts blockchain.CallOpts) (*big.Int, error) {
+	out := big.NewInt(0)
+	timeOut := 2 * time.Second
+	err := self.Call(timeOut, opts, self.pricing, out, "validRateDurationInBlocks")
+	return out, err
+}
+
+func (self *Blockchain) GeneratedReserveContract(opts blockchain.CallOpts) (ethereum.Address, error) {
+	out := new(ethereum.Address)
+	timeOut := 2 * time.Second
+	err := self.Call(timeOut, opts, self.pricing, out, "reserveContract")
+	return *out, err
+}
+
+func (self *Blockchain) GeneratedGetListedTokens(opts blockchain.CallOpts) ([]ethereum.Address, error) {
+	out := new([]ethereum.Address)
+	timeOut := 2 * time.Second
+	err := self.Call(timeOut, opts, self.pricing, out, "getListedTokens")
+	return *out, err
+}
+
+func (self *Blockchain) GeneratedGetTokenBasicData(opts blockchain.CallOpts, token ethereum.Address) (bool, bool, error) {
+	var (
+		ret0 = new(bool)
+		ret1 = new(bool)
+	)
+	out := &[]interface{}{
+		ret0,
+		ret1,
+	}
+	timeOut := 2 * time.Second
+	err := self.Call(timeOut, opts, self.pricing, out, "getTokenBasicData", token)
+	return *ret0, *ret1, err
+}
+
+func (self *Blockchain) GeneratedGetTokenControlInfo(opts blockchain.CallOpts, token ethereum.Address) (*big.Int, *big.Int, *big.Int, error) {
+	var (
+		ret0 = new(*big.Int)
+		ret1 = new(*big.Int)
+		ret2 = new(*big.Int)
+	)
+	out := &[]interface{}{
+		ret0,
+		ret1,
+		ret2,
+	}
+	timeOut := 2 * time.Second
+	err := self.Call(timeOut, opts, self.pricing, out, "getTokenControlInfo", token)
+	return *ret0, *ret1, *ret2, err
+}
+
+func (self *Blockchain) GeneratedDisableTokenTrade(opts blockchain.TxOpts, token ethereum.Address) (*types.Transaction, error) {
+	timeout, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+	return self.BuildTx(timeout, opts, self.pricing, "disableTokenTrade", token)
+}
@@ -15,3 +15,30 @@ func (self *Blockchain) GeneratedWithdraw(opts blockchain.TxOpts, token ethereum
 	defer cancel()
 	return self.BuildTx(timeout, opts, self.reserve, "withdraw", token, amount, destination)
 }
+
+func (self *Blockchain) GeneratedTradeEnabled(opts blockchain.CallOpts) (bool, error) {
+	out := new(bool)
+	timeOut := 2 * time.Second
+	err := self.Call(timeOut, opts, self.reserve, out, "tradeEnabled")
+	return *out, err
+}
+
+func (self *Blockchain) GeneratedConversionRatesContract(opts blockchain.CallOpts) (ethereum.Address, error) {
+	out := new(ethereum.Address)
+	timeOut := 2 * time.Second
+	err := self.Call(timeOut, opts, self.reserve, out, "conversionRatesContract")
+	return *out, err
+}
+
+func (self *Blockchain) GeneratedApprovedWithdrawAddresses(opts blockchain.CallOpts, key [32]byte) (bool, error) {
+	out := new(bool)
+	timeOut := 2 * time.Second
+	err := self.Call(timeOut, opts, self.reserve, out, "approvedWithdrawAddresses", key)
+	return *out, err
+}
+
+func (self *Blockchain) GeneratedDisableTrade(opts blockchain.TxOpts) (*types.Transaction, error) {
+	timeout, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+	return self.BuildTx(timeout, opts, self.reserve, "disableTrade")
+}
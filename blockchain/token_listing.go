@@ -0,0 +1,125 @@
+package blockchain
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/KyberNetwork/reserve-data/common"
+	"github.com/KyberNetwork/reserve-data/common/blockchain"
+	ethereum "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+const (
+	// listingTxGas is the gas limit of token listing transactions, except
+	// setting step functions. Listing transactions depend on the previous
+	// ones being mined, so their gas can not be estimated in advance.
+	listingTxGas uint64 = 200000
+	// stepFunctionBaseGas and stepFunctionStepGas are the gas limit of
+	// setting a step function and of each of its steps.
+	stepFunctionBaseGas uint64 = 100000
+	stepFunctionStepGas uint64 = 25000
+)
+
+// listingCall is a contract call of listing a token.
+type listingCall struct {
+	contract *blockchain.Contract
+	method   string
+	gas      uint64
+	params   []interface{}
+}
+
+func stepFunctionGas(steps ...[]*big.Int) uint64 {
+	gas := stepFunctionBaseGas
+	for _, step := range steps {
+		gas += stepFunctionStepGas * uint64(len(step))
+	}
+	return gas
+}
+
+// BuildTokenListingTxs builds the unsigned transactions listing token on
+// chain with params, in the order they must be mined: adding it to the
+// pricing contract, setting its control info and step functions, enabling
+// its trade and approving the reserve to withdraw it to withdrawAddresses.
+// They must be sent by the admin of the pricing and reserve contracts.
+func (self *Blockchain) BuildTokenListingTxs(token common.Token, params common.TokenListingParams, withdrawAddresses []ethereum.Address) ([]common.TokenListingTx, error) {
+	if err := params.Validate(); err != nil {
+		return nil, err
+	}
+	tokenAddr := ethereum.HexToAddress(token.Address)
+	qty := params.QtyStepFunction
+	imbalance := params.ImbalanceStepFunction
+	calls := []listingCall{
+		{self.pricing, "addToken", listingTxGas, []interface{}{tokenAddr}},
+		{self.pricing, "setTokenControlInfo", listingTxGas, []interface{}{
+			tokenAddr, params.MinimalRecordResolution, params.MaxPerBlockImbalance, params.MaxTotalImbalance,
+		}},
+		{self.pricing, "setQtyStepFunction", stepFunctionGas(qty.XBuy, qty.XSell), []interface{}{
+			tokenAddr, qty.XBuy, qty.YBuy, qty.XSell, qty.YSell,
+		}},
+		{self.pricing, "setImbalanceStepFunction", stepFunctionGas(imbalance.XBuy, imbalance.XSell), []interface{}{
+			tokenAddr, imbalance.XBuy, imbalance.YBuy, imbalance.XSell, imbalance.YSell,
+		}},
+		{self.pricing, "enableTokenTrade", listingTxGas, []interface{}{tokenAddr}},
+	}
+	for _, addr := range withdrawAddresses {
+		calls = append(calls, listingCall{self.reserve, "approveWithdrawAddress", listingTxGas, []interface{}{tokenAddr, addr, true}})
+	}
+	result := []common.TokenListingTx{}
+	for _, call := range calls {
+		data, err := call.contract.ABI.Pack(call.method, call.params...)
+		if err != nil {
+			return nil, fmt.Errorf("packing %s of token %s failed: %s", call.method, token.ID, err)
+		}
+		result = append(result, common.TokenListingTx{
+			Method:   call.method,
+			To:       call.contract.Address.Hex(),
+			Data:     hexutil.Encode(data),
+			GasLimit: call.gas,
+		})
+	}
+	return result, nil
+}
+
+// CheckTokenListingAdmin returns error if the pricing operator, which signs
+// submitted token listing transactions, is not admin of the pricing and
+// reserve contracts.
+func (self *Blockchain) CheckTokenListingAdmin() error {
+	opts := self.GetCallOpts(0)
+	operator := self.GetPricingOPAddress()
+	for _, contract := range []*blockchain.Contract{self.pricing, self.reserve} {
+		admin, err := self.GeneratedAdmin(opts, contract)
+		if err != nil {
+			return err
+		}
+		if admin != operator {
+			return fmt.Errorf("pricing operator %s is not admin %s of contract %s, list tokens in bundle mode instead", operator.Hex(), admin.Hex(), contract.Address.Hex())
+		}
+	}
+	return nil
+}
+
+// SubmitTokenListingTx signs and broadcasts a token listing transaction with
+// the pricing operator, which must be admin of the contracts, see
+// CheckTokenListingAdmin. Like setting step functions, it takes the next
+// nonce of the operator.
+func (self *Blockchain) SubmitTokenListingTx(listingTx common.TokenListingTx) (*types.Transaction, error) {
+	data, err := hexutil.Decode(listingTx.Data)
+	if err != nil {
+		return nil, err
+	}
+	opts, err := self.GetTxOpts(pricingOP, nil, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	tx := types.NewTransaction(
+		opts.Nonce.Uint64(),
+		ethereum.HexToAddress(listingTx.To),
+		nil,
+		listingTx.GasLimit,
+		opts.GasPrice,
+		data,
+	)
+	return self.SignAndBroadcast(tx, pricingOP)
+}
@@ -0,0 +1,77 @@
+package blockchain
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/KyberNetwork/reserve-data/common"
+	"github.com/KyberNetwork/reserve-data/common/blockchain"
+	ethereum "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+func TestBuildTokenListingTxs(t *testing.T) {
+	pricingAddr := ethereum.HexToAddress("0x1111111111111111111111111111111111111111")
+	reserveAddr := ethereum.HexToAddress("0x2222222222222222222222222222222222222222")
+	bc := &Blockchain{
+		pricing: blockchain.NewContract(pricingAddr, "pricing.abi"),
+		reserve: blockchain.NewContract(reserveAddr, "reserve.abi"),
+	}
+	token := common.NewToken("KNC", "Kyber-coin", "0x3333333333333333333333333333333333333333", 18, false, true, 0)
+	params := common.TokenListingParams{
+		MinimalRecordResolution: big.NewInt(1e14),
+		MaxPerBlockImbalance:    big.NewInt(1e18),
+		MaxTotalImbalance:       big.NewInt(5e18),
+		QtyStepFunction: common.QuantityStepFunction{
+			XBuy: []*big.Int{big.NewInt(100)}, YBuy: []*big.Int{big.NewInt(0)},
+			XSell: []*big.Int{big.NewInt(100)}, YSell: []*big.Int{big.NewInt(0)},
+		},
+	}
+	withdrawAddrs := []ethereum.Address{
+		ethereum.HexToAddress("0x4444444444444444444444444444444444444444"),
+		ethereum.HexToAddress("0x5555555555555555555555555555555555555555"),
+	}
+	txs, err := bc.BuildTokenListingTxs(token, params, withdrawAddrs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []struct {
+		method string
+		to     ethereum.Address
+		gas    uint64
+	}{
+		{"addToken", pricingAddr, listingTxGas},
+		{"setTokenControlInfo", pricingAddr, listingTxGas},
+		{"setQtyStepFunction", pricingAddr, stepFunctionBaseGas + 2*stepFunctionStepGas},
+		{"setImbalanceStepFunction", pricingAddr, stepFunctionBaseGas},
+		{"enableTokenTrade", pricingAddr, listingTxGas},
+		{"approveWithdrawAddress", reserveAddr, listingTxGas},
+		{"approveWithdrawAddress", reserveAddr, listingTxGas},
+	}
+	if len(txs) != len(expected) {
+		t.Fatalf("Expected %d listing txs, got %d", len(expected), len(txs))
+	}
+	for i, tx := range txs {
+		contract := bc.pricing
+		if expected[i].to == reserveAddr {
+			contract = bc.reserve
+		}
+		data, dErr := hexutil.Decode(tx.Data)
+		if dErr != nil {
+			t.Fatal(dErr)
+		}
+		method, mErr := contract.ABI.MethodById(data[:4])
+		if mErr != nil {
+			t.Fatal(mErr)
+		}
+		if tx.Method != expected[i].method || method.Name != expected[i].method ||
+			tx.To != expected[i].to.Hex() || tx.GasLimit != expected[i].gas {
+			t.Errorf("Expected tx %d calling %s of %s with gas %d, got %+v", i, expected[i].method, expected[i].to.Hex(), expected[i].gas, tx)
+		}
+	}
+
+	params.MaxTotalImbalance = nil
+	if _, err = bc.BuildTokenListingTxs(token, params, withdrawAddrs); err == nil {
+		t.Error("Expected error of missing max total imbalance")
+	}
+}
@@ -0,0 +1,37 @@
+package cmd
+
+import (
+	"log"
+
+	"github.com/KyberNetwork/reserve-data/exchange/simulator"
+	"github.com/spf13/cobra"
+)
+
+var simulatorConfigPath string
+var simulatorHost string
+
+func runSimulator(_ *cobra.Command, _ []string) {
+	config, err := simulator.LoadConfig(simulatorConfigPath)
+	if err != nil {
+		log.Fatalf("Reading simulator config failed: %s", err.Error())
+	}
+	if err := simulator.NewServer(config).Run(simulatorHost); err != nil {
+		log.Fatalf("Simulator stopped: %s", err.Error())
+	}
+}
+
+var simulatorCmd = &cobra.Command{
+	Use:   "simulator",
+	Short: "serve simulated Binance, Huobi and Bittrex APIs for simulation mode",
+	Long: `Serve the REST APIs of Binance, Huobi and Bittrex used by core from an in-memory
+matching engine, at the ports simulation mode expects (5100, 5200 and 5300). Balances, order books
+and injected faults are read from the config file and can be changed at runtime via /simulator APIs.`,
+	Example: "./cmd simulator --config simulator.json",
+	Run:     runSimulator,
+}
+
+func init() {
+	simulatorCmd.Flags().StringVar(&simulatorConfigPath, "config", "simulator.json", "simulator config file")
+	simulatorCmd.Flags().StringVar(&simulatorHost, "host", "127.0.0.1", "host to listen on")
+	RootCmd.AddCommand(simulatorCmd)
+}
@@ -9,6 +9,7 @@ import (
 	"github.com/KyberNetwork/reserve-data"
 	"github.com/KyberNetwork/reserve-data/blockchain"
 	"github.com/KyberNetwork/reserve-data/common"
+	baseblockchain "github.com/KyberNetwork/reserve-data/common/blockchain"
 	"github.com/KyberNetwork/reserve-data/http"
 	"github.com/spf13/cobra"
 )
@@ -17,6 +18,8 @@ const (
 	remoteLogPath  string = "core-log"
 	defaultBaseURL        = "http://127.0.0.1"
 	coreDefaultURL string = "http://127.0.0.1:8000"
+	// defaultMetricsAddr only listens on loopback as the metrics are not signed.
+	defaultMetricsAddr = "127.0.0.1:8001"
 )
 
 // logDir is located at base of this repository.
@@ -30,6 +33,9 @@ var noCore bool
 var stdoutLog bool
 var dryrun bool
 var coreURL string
+var feeAutoApply bool
+var recordDir string
+var metricsAddr string
 
 func serverStart(_ *cobra.Command, _ []string) {
 	numCPU := runtime.NumCPU()
@@ -68,11 +74,21 @@ func serverStart(_ *cobra.Command, _ []string) {
 			if err = rData.Run(); err != nil {
 				log.Panic(err)
 			}
+			go bc.RunOperatorNonceObserver(baseblockchain.OperatorNonceObserveInterval)
 		}
 		//set static field supportExchange from common...
 		for _, ex := range config.Exchanges {
 			common.SupportedExchanges[ex.ID()] = ex
 		}
+		if !dryrun {
+			// executions look up their exchanges in the supported ones
+			if err = rCore.RunExecutionEngine(); err != nil {
+				log.Panic(err)
+			}
+			if err = rCore.RunTokenListing(); err != nil {
+				log.Panic(err)
+			}
+		}
 	}
 
 	//Create Stat, run if not in dry mode
@@ -105,8 +121,21 @@ func serverStart(_ *cobra.Command, _ []string) {
 		kyberENV,
 		bc, config.Setting,
 	)
+	if !noCore {
+		server.SetEventStream(config.EventBroker)
+		server.SetAPIKeys(config.APIKeyStorage)
+		server.SetAuditLog(config.AuditLogStorage)
+		server.SetNonceStorage(config.NonceStorage)
+	}
 
 	if !dryrun {
+		if metricsAddr != "" {
+			go func() {
+				if err := http.RunMetricsServer(metricsAddr); err != nil {
+					log.Panic(err)
+				}
+			}()
+		}
 		server.Run()
 	} else {
 		log.Printf("Dry run finished. All configs are corrected")
@@ -133,5 +162,8 @@ func init() {
 	startServer.Flags().BoolVarP(&stdoutLog, "log-to-stdout", "", false, "send log to both log file and stdout terminal")
 	startServer.Flags().BoolVarP(&dryrun, "dryrun", "", false, "only test if all the configs are set correctly, will not actually run core")
 	startServer.Flags().StringVar(&coreURL, "core-url", coreDefaultURL, "core url from which stat can call for setting APis")
+	startServer.Flags().BoolVarP(&feeAutoApply, "fee-auto-apply", "", false, "apply exchange fees synced from exchange APIs without confirmation")
+	startServer.Flags().StringVar(&recordDir, "record-dir", "", "directory to record fetched prices, rates and auth data to for replaying, disabled if empty")
+	startServer.Flags().StringVar(&metricsAddr, "metrics-addr", defaultMetricsAddr, "address to serve the internal metrics on, disabled if empty")
 	RootCmd.AddCommand(startServer)
 }
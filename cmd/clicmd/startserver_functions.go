@@ -18,6 +18,7 @@ import (
 	"github.com/KyberNetwork/reserve-data/core"
 	"github.com/KyberNetwork/reserve-data/data"
 	"github.com/KyberNetwork/reserve-data/data/fetcher"
+	"github.com/KyberNetwork/reserve-data/data/fetcher/replay"
 	"github.com/KyberNetwork/reserve-data/settings"
 	"github.com/KyberNetwork/reserve-data/stat"
 	ethereum "github.com/ethereum/go-ethereum/common"
@@ -200,7 +201,30 @@ func CreateDataCore(config *configuration.Config, kyberENV string, bc *blockchai
 	nonceDeposit := nonce.NewTimeWindow(config.DepositSigner.GetAddress(), 10000)
 	bc.RegisterPricingOperator(config.BlockchainSigner, nonceCorpus)
 	bc.RegisterDepositOperator(config.DepositSigner, nonceDeposit)
-	dataFetcher.SetBlockchain(bc)
+	if config.AlerterSigner != nil {
+		nonceAlerter := nonce.NewTimeWindow(config.AlerterSigner.GetAddress(), 10000)
+		bc.RegisterAlerterOperator(config.AlerterSigner, nonceAlerter)
+	}
+	if config.FetcherBlockchain != nil {
+		// replaying recorded data, see KYBER_FETCHER_RUNNER
+		dataFetcher.SetBlockchain(config.FetcherBlockchain)
+	} else {
+		dataFetcher.SetBlockchain(bc)
+	}
+	var publisher fetcher.Publisher = config.EventBroker
+	if recordDir != "" {
+		recorder, err := replay.NewRecorder(recordDir)
+		if err != nil {
+			log.Panicf("Can not create recorder: %s", err)
+		}
+		publisher = fetcher.Publishers{config.EventBroker, recorder}
+	}
+	dataFetcher.SetPublisher(publisher)
+	if kyberENV != common.SimulationMode && config.FetcherBlockchain == nil {
+		dataFetcher.SetTxSupervisor(fetcher.NewTxSupervisor(bc, config.FetcherStorage))
+		dataFetcher.SetFeeSync(fetcher.NewFeeSync(config.Setting, fetcher.WithFeeAutoApply(feeAutoApply)))
+		dataFetcher.SetStatusMonitor(fetcher.NewStatusMonitor(config.Setting, fetcher.DefaultStatusMonitorInterval))
+	}
 	rData := data.NewReserveData(
 		config.DataStorage,
 		config.StepFunctionDataStorage,
@@ -212,7 +236,15 @@ func CreateDataCore(config *configuration.Config, kyberENV string, bc *blockchai
 		config.Setting,
 	)
 
-	rCore := core.NewReserveCore(bc, config.ActivityStorage, config.Setting)
+	rCore := core.NewReserveCore(
+		bc,
+		config.ActivityStorage,
+		config.Setting,
+		core.WithWithdrawalApproval(config.WithdrawalApprovalStorage, core.DefaultWithdrawalApprovalExpiry),
+		core.WithOrderRouting(rData),
+		core.WithExecutionEngine(config.ExecutionStorage, core.DefaultExecutionInterval),
+		core.WithTokenListing(config.Setting, core.DefaultTokenListingInterval),
+	)
 	return rData, rCore
 }
 
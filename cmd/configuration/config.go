@@ -13,10 +13,12 @@ import (
 	"github.com/KyberNetwork/reserve-data/data/datapruner"
 	"github.com/KyberNetwork/reserve-data/data/fetcher"
 	"github.com/KyberNetwork/reserve-data/data/fetcher/http_runner"
-	"github.com/KyberNetwork/reserve-data/data/storage"
+	"github.com/KyberNetwork/reserve-data/data/fetcher/replay"
+	"github.com/KyberNetwork/reserve-data/data/stream"
 	"github.com/KyberNetwork/reserve-data/exchange/binance"
 	"github.com/KyberNetwork/reserve-data/exchange/bittrex"
 	"github.com/KyberNetwork/reserve-data/exchange/huobi"
+	"github.com/KyberNetwork/reserve-data/exchange/kraken"
 	"github.com/KyberNetwork/reserve-data/http"
 	"github.com/KyberNetwork/reserve-data/metric"
 	"github.com/KyberNetwork/reserve-data/settings"
@@ -79,23 +81,26 @@ func NewSettingPaths(
 }
 
 type Config struct {
-	ActivityStorage         core.ActivityStorage
-	StepFunctionDataStorage data.StepFunctionDataStorage
-	DataStorage             data.Storage
-	DataGlobalStorage       data.GlobalStorage
-	StatStorage             stat.StatStorage
-	AnalyticStorage         stat.AnalyticStorage
-	UserStorage             stat.UserStorage
-	LogStorage              stat.LogStorage
-	RateStorage             stat.RateStorage
-	FeeSetRateStorage       stat.FeeSetRateStorage
-	FetcherStorage          fetcher.Storage
-	FetcherGlobalStorage    fetcher.GlobalStorage
-	MetricStorage           metric.MetricStorage
-	Archive                 archive.Archive
+	ActivityStorage           core.ActivityStorage
+	WithdrawalApprovalStorage core.WithdrawalApprovalStorage
+	ExecutionStorage          core.ExecutionStorage
+	StepFunctionDataStorage   data.StepFunctionDataStorage
+	DataStorage               data.Storage
+	DataGlobalStorage         data.GlobalStorage
+	StatStorage               stat.StatStorage
+	AnalyticStorage           stat.AnalyticStorage
+	UserStorage               stat.UserStorage
+	LogStorage                stat.LogStorage
+	RateStorage               stat.RateStorage
+	FeeSetRateStorage         stat.FeeSetRateStorage
+	FetcherStorage            fetcher.Storage
+	FetcherGlobalStorage      fetcher.GlobalStorage
+	MetricStorage             metric.MetricStorage
+	Archive                   archive.Archive
 
 	World                *world.TheWorld
 	FetcherRunner        fetcher.FetcherRunner
+	EventBroker          *stream.Broker
 	DataControllerRunner datapruner.StorageControllerRunner
 	StatFetcherRunner    stat.FetcherRunner
 	StatControllerRunner statpruner.ControllerRunner
@@ -103,10 +108,20 @@ type Config struct {
 	Exchanges            []common.Exchange
 	BlockchainSigner     blockchain.Signer
 	DepositSigner        blockchain.Signer
+	// AlerterSigner is the signer disabling trade in emergency, nil if it
+	// is not configured.
+	AlerterSigner blockchain.Signer
 	//IntermediatorSigner blockchain.Signer
 
+	// FetcherBlockchain is the blockchain of fetcher when it is not the
+	// blockchain of core, as when replaying recorded data.
+	FetcherBlockchain fetcher.Blockchain
+
 	EnableAuthentication bool
 	AuthEngine           http.Authentication
+	APIKeyStorage        http.APIKeyStorage
+	AuditLogStorage      http.AuditLogStorage
+	NonceStorage         http.NonceStorage
 
 	EthereumEndpoint        string
 	BackupEthereumEndpoints []string
@@ -189,12 +204,15 @@ func (self *Config) AddStatConfig(settingPath SettingPaths) {
 }
 
 func (self *Config) AddCoreConfig(settingPath SettingPaths, kyberENV string) {
-	setting, err := GetSetting(settingPath, kyberENV, self.AddressSetting)
+	setting, settingStorage, err := GetSetting(settingPath, kyberENV, self.AddressSetting)
 	if err != nil {
 		log.Panicf("Failed to create setting: %s", err.Error())
 	}
 	self.Setting = setting
-	dataStorage, err := storage.NewBoltStorage(settingPath.dataStoragePath)
+	self.APIKeyStorage = settingStorage
+	self.AuditLogStorage = settingStorage
+	self.NonceStorage = settingStorage
+	dataStorage, err := newDataStorage(settingPath)
 	if err != nil {
 		panic(err)
 	}
@@ -202,24 +220,30 @@ func (self *Config) AddCoreConfig(settingPath SettingPaths, kyberENV string) {
 	var fetcherRunner fetcher.FetcherRunner
 	var dataControllerRunner datapruner.StorageControllerRunner
 	if common.RunningMode() == common.SimulationMode {
-		if fetcherRunner, err = http_runner.NewHttpRunner(http_runner.WithHttpRunnerPort(8001)); err != nil {
+		if isReplayFetcherRunner() {
+			if fetcherRunner, err = newReplayRunner(); err != nil {
+				log.Fatalf("failed to create replay runner: %s", err.Error())
+			}
+			if err = dataStorage.MarkReplay(); err != nil {
+				log.Fatalf("can not replay to data storage: %s", err.Error())
+			}
+		} else if fetcherRunner, err = http_runner.NewHttpRunner(http_runner.WithHttpRunnerPort(8001)); err != nil {
 			log.Fatalf("failed to create HTTP runner: %s", err.Error())
 		}
 	} else {
-		fetcherRunner = fetcher.NewTickerRunner(
-			7*time.Second,  // orderbook fetching interval
-			5*time.Second,  // authdata fetching interval
-			3*time.Second,  // rate fetching interval
-			5*time.Second,  // block fetching interval
-			10*time.Second, // global data fetching interval
-		)
+		if fetcherRunner, err = newFetcherRunner(self.EthereumEndpoint); err != nil {
+			log.Fatalf("failed to create fetcher runner: %s", err.Error())
+		}
 		dataControllerRunner = datapruner.NewStorageControllerTickerRunner(24 * time.Hour)
 	}
 
 	pricingSigner := PricingSignerFromConfigFile(settingPath.secretPath)
 	depositSigner := DepositSignerFromConfigFile(settingPath.secretPath)
+	alerterSigner := AlerterSignerFromConfigFile(settingPath.secretPath)
 
 	self.ActivityStorage = dataStorage
+	self.WithdrawalApprovalStorage = dataStorage
+	self.ExecutionStorage = dataStorage
 	self.StepFunctionDataStorage = dataStorage
 	self.DataStorage = dataStorage
 	self.DataGlobalStorage = dataStorage
@@ -227,10 +251,14 @@ func (self *Config) AddCoreConfig(settingPath SettingPaths, kyberENV string) {
 	self.FetcherGlobalStorage = dataStorage
 	self.MetricStorage = dataStorage
 	self.FetcherRunner = fetcherRunner
+	self.EventBroker = stream.NewBroker(stream.DefaultHistorySize)
 	self.DataControllerRunner = dataControllerRunner
 	self.BlockchainSigner = pricingSigner
 	//self.IntermediatorSigner = huoBiintermediatorSigner
 	self.DepositSigner = depositSigner
+	if alerterSigner != nil {
+		self.AlerterSigner = alerterSigner
+	}
 	//self.ExchangeStorage = exsStorage
 	// var huobiConfig common.HuobiConfig
 	// exchangesIDs := os.Getenv("KYBER_EXCHANGES")
@@ -253,6 +281,10 @@ func (self *Config) AddCoreConfig(settingPath SettingPaths, kyberENV string) {
 		log.Panicf("cannot Create fetcher exchanges : (%s)", err.Error())
 	}
 	self.FetcherExchanges = fetcherExchanges
+	if replayRunner, ok := fetcherRunner.(*replay.Runner); ok {
+		self.FetcherExchanges = replayRunner.Exchanges(fetcherExchanges)
+		self.FetcherBlockchain = replayRunner.Blockchain()
+	}
 	coreExchanges, err := exchangePool.CoreExchanges()
 	if err != nil {
 		log.Panicf("cannot Create core exchanges : (%s)", err.Error())
@@ -406,6 +438,7 @@ var ConfigPaths = map[string]SettingPaths{
 var BinanceInterfaces = make(map[string]binance.Interface)
 var HuobiInterfaces = make(map[string]huobi.Interface)
 var BittrexInterfaces = make(map[string]bittrex.Interface)
+var KrakenInterfaces = make(map[string]kraken.Interface)
 
 func SetInterface(base_url string) {
 	BittrexInterfaces[common.DevMode] = bittrex.NewDevInterface()
@@ -431,4 +464,12 @@ func SetInterface(base_url string) {
 	BinanceInterfaces[common.SimulationMode] = binance.NewSimulatedInterface(base_url)
 	BinanceInterfaces[common.RopstenMode] = binance.NewRopstenInterface(base_url)
 	BinanceInterfaces[common.AnalyticDevMode] = binance.NewRopstenInterface(base_url)
+
+	KrakenInterfaces[common.DevMode] = kraken.NewDevInterface()
+	KrakenInterfaces[common.KovanMode] = kraken.NewRopstenInterface(base_url)
+	KrakenInterfaces[common.MainnetMode] = kraken.NewRealInterface()
+	KrakenInterfaces[common.StagingMode] = kraken.NewRealInterface()
+	KrakenInterfaces[common.SimulationMode] = kraken.NewSimulatedInterface(base_url)
+	KrakenInterfaces[common.RopstenMode] = kraken.NewRopstenInterface(base_url)
+	KrakenInterfaces[common.AnalyticDevMode] = kraken.NewRopstenInterface(base_url)
 }
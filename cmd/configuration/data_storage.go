@@ -0,0 +1,123 @@
+package configuration
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+
+	"github.com/KyberNetwork/reserve-data/core"
+	"github.com/KyberNetwork/reserve-data/data"
+	"github.com/KyberNetwork/reserve-data/data/fetcher"
+	"github.com/KyberNetwork/reserve-data/data/fetcher/replay"
+	"github.com/KyberNetwork/reserve-data/data/storage"
+	"github.com/KyberNetwork/reserve-data/metric"
+)
+
+const (
+	// dataStorageEnv is the name of environment variable that selects the
+	// storage engine of core data. See below constants for list of
+	// available engines.
+	dataStorageEnv = "KYBER_DATA_STORAGE"
+
+	// boltDataStorage stores core data in a local BoltDB file, it is the default engine.
+	boltDataStorage = "bolt"
+	// postgresDataStorage stores core data in PostgreSQL, the data source
+	// name is read from postgres_data_source field of config file.
+	postgresDataStorage = "postgres"
+)
+
+// dataStorage is the set of interfaces a core data storage must implement.
+type dataStorage interface {
+	core.ActivityStorage
+	core.WithdrawalApprovalStorage
+	core.ExecutionStorage
+	data.StepFunctionDataStorage
+	data.Storage
+	data.GlobalStorage
+	fetcher.Storage
+	fetcher.GlobalStorage
+	metric.MetricStorage
+	replay.Storage
+}
+
+type jsonPostgresDetail struct {
+	DataSource string `json:"postgres_data_source"`
+}
+
+// PostgresDataSourceFromConfigFile returns the data source name of postgres
+// data storage in config file.
+func PostgresDataSourceFromConfigFile(secretPath string) (string, error) {
+	raw, err := ioutil.ReadFile(secretPath)
+	if err != nil {
+		return "", err
+	}
+	detail := jsonPostgresDetail{}
+	if err = json.Unmarshal(raw, &detail); err != nil {
+		return "", err
+	}
+	if detail.DataSource == "" {
+		return "", errors.New("postgres_data_source is not set in config file")
+	}
+	return detail.DataSource, nil
+}
+
+// newDataStorage creates the core data storage selected by KYBER_DATA_STORAGE.
+func newDataStorage(settingPath SettingPaths) (dataStorage, error) {
+	engine, ok := os.LookupEnv(dataStorageEnv)
+	if !ok || engine == "" {
+		engine = boltDataStorage
+	}
+	switch engine {
+	case boltDataStorage:
+		return storage.NewBoltStorage(settingPath.dataStoragePath)
+	case postgresDataStorage:
+		dataSource, err := PostgresDataSourceFromConfigFile(settingPath.secretPath)
+		if err != nil {
+			return nil, err
+		}
+		return storage.NewPostgresStorage(dataSource)
+	default:
+		return nil, fmt.Errorf("unsupported data storage: %s", engine)
+	}
+}
+
+// MigrateDataStorage copies all core data from the bolt database at boltPath
+// to the postgres database of dataSource. Empty boltPath or dataSource
+// defaults to the ones of given running mode.
+func MigrateDataStorage(kyberENV, boltPath, dataSource string) error {
+	settingPath := GetConfigPaths(kyberENV)
+	if boltPath == "" {
+		boltPath = settingPath.dataStoragePath
+	}
+	if dataSource == "" {
+		var err error
+		if dataSource, err = PostgresDataSourceFromConfigFile(settingPath.secretPath); err != nil {
+			return err
+		}
+	}
+	boltStorage, err := storage.NewBoltStorage(boltPath)
+	if err != nil {
+		return err
+	}
+	pgStorage, err := storage.NewPostgresStorage(dataSource)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if cErr := pgStorage.Close(); cErr != nil {
+			log.Printf("Close postgres storage error: %s", cErr.Error())
+		}
+	}()
+	log.Printf("Migrating data storage from %s to postgres", boltPath)
+	migrated, err := storage.MigrateBoltToPostgres(boltStorage, pgStorage)
+	if err != nil {
+		return err
+	}
+	for bucket, n := range migrated {
+		log.Printf("Migrated %d records of bucket %s", n, bucket)
+	}
+	return nil
+}
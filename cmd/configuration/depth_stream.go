@@ -0,0 +1,45 @@
+package configuration
+
+import (
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/KyberNetwork/reserve-data/common"
+)
+
+const (
+	// orderbookSourceEnv is the name of environment variable that selects how
+	// Binance and Huobi order books are fetched.
+	// See below constants for list of available sources.
+	orderbookSourceEnv = "KYBER_ORDERBOOK_SOURCE"
+
+	// restOrderbookSource polls order books with REST requests on every
+	// orderbook tick, it is the default source.
+	restOrderbookSource = "rest"
+	// streamOrderbookSource maintains order books locally from websocket
+	// depth streams, REST polling is used while a stream is not available.
+	streamOrderbookSource = "stream"
+)
+
+// depthStreamEnabled returns true if order books should be streamed, as
+// selected by KYBER_ORDERBOOK_SOURCE. Streams always connect to the real
+// exchanges, so they are disabled in simulation mode.
+func depthStreamEnabled(kyberENV string) (bool, error) {
+	source, ok := os.LookupEnv(orderbookSourceEnv)
+	if !ok || source == "" {
+		source = restOrderbookSource
+	}
+	switch source {
+	case restOrderbookSource:
+		return false, nil
+	case streamOrderbookSource:
+		if kyberENV == common.SimulationMode {
+			log.Printf("Order book streaming is not supported in simulation mode, polling with REST instead")
+			return false, nil
+		}
+		return true, nil
+	default:
+		return false, fmt.Errorf("unsupported order book source: %s", source)
+	}
+}
@@ -0,0 +1,98 @@
+package configuration
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"sync"
+
+	"github.com/KyberNetwork/reserve-data/common"
+	"github.com/KyberNetwork/reserve-data/common/blockchain"
+	"github.com/KyberNetwork/reserve-data/settings"
+)
+
+// ExchangeContext holds the dependencies shared by all exchanges of a run.
+type ExchangeContext struct {
+	// SecretPath is the secret file of the deployment, used by exchanges
+	// which don't have their own.
+	SecretPath  string
+	Blockchain  *blockchain.BaseBlockchain
+	KyberENV    string
+	Setting     *settings.Settings
+	DepthStream bool
+}
+
+// secretPath returns the secret file of the exchange account.
+func (self ExchangeContext) secretPath(config settings.ExchangeConfig) string {
+	if len(config.SecretPath) != 0 {
+		return config.SecretPath
+	}
+	return self.SecretPath
+}
+
+// storagePath returns the database file of the exchange account.
+func (self ExchangeContext) storagePath(config settings.ExchangeConfig) string {
+	if len(config.StoragePath) != 0 {
+		return config.StoragePath
+	}
+	return filepath.Join(common.CmdDirLocation(), fmt.Sprintf("%s.db", config.ID))
+}
+
+// ExchangeFactory creates the exchange of the given config.
+type ExchangeFactory func(config settings.ExchangeConfig, ctx ExchangeContext) (common.Exchange, error)
+
+var (
+	exchangeFactoriesMu sync.RWMutex
+	exchangeFactories   = make(map[string]ExchangeFactory)
+)
+
+// RegisterExchange makes the exchange factory available to run exchange
+// accounts of the given type. It panics if the type is registered twice.
+func RegisterExchange(exchangeType string, factory ExchangeFactory) {
+	exchangeFactoriesMu.Lock()
+	defer exchangeFactoriesMu.Unlock()
+	if _, ok := exchangeFactories[exchangeType]; ok {
+		panic(fmt.Sprintf("exchange %s is registered twice", exchangeType))
+	}
+	exchangeFactories[exchangeType] = factory
+}
+
+// ExchangeTypes returns the sorted list of registered exchange types.
+func ExchangeTypes() []string {
+	exchangeFactoriesMu.RLock()
+	defer exchangeFactoriesMu.RUnlock()
+	var result []string
+	for exchangeType := range exchangeFactories {
+		result = append(result, exchangeType)
+	}
+	sort.Strings(result)
+	return result
+}
+
+func exchangeFactory(exchangeType string) (ExchangeFactory, bool) {
+	exchangeFactoriesMu.RLock()
+	defer exchangeFactoriesMu.RUnlock()
+	factory, ok := exchangeFactories[exchangeType]
+	return factory, ok
+}
+
+// newExchanges creates the exchanges of the given configs with their
+// registered factories.
+func newExchanges(configs []settings.ExchangeConfig, ctx ExchangeContext) (map[common.ExchangeID]interface{}, error) {
+	exchanges := map[common.ExchangeID]interface{}{}
+	for _, config := range configs {
+		factory, ok := exchangeFactory(config.Type)
+		if !ok {
+			return nil, fmt.Errorf("Exchange %s has type %s, but it is not avail in current deployment (%v)", config.ID, config.Type, ExchangeTypes())
+		}
+		ex, err := factory(config, ctx)
+		if err != nil {
+			return nil, fmt.Errorf("Can not create exchange %s: (%s)", config.ID, err.Error())
+		}
+		if ex.ID() != common.ExchangeID(config.ID) {
+			return nil, fmt.Errorf("Exchange %s was created with ID %s", config.ID, ex.ID())
+		}
+		exchanges[ex.ID()] = ex
+	}
+	return exchanges, nil
+}
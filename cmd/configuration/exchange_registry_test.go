@@ -0,0 +1,53 @@
+package configuration
+
+import (
+	"testing"
+
+	"github.com/KyberNetwork/reserve-data/common"
+	"github.com/KyberNetwork/reserve-data/settings"
+)
+
+// testAccountExchange is an exchange of which ID is set on creation.
+type testAccountExchange struct {
+	common.TestExchange
+	id common.ExchangeID
+}
+
+func (self testAccountExchange) ID() common.ExchangeID {
+	return self.id
+}
+
+func TestNewExchangesFromRegistry(t *testing.T) {
+	var created []settings.ExchangeConfig
+	RegisterExchange("test_exchange", func(config settings.ExchangeConfig, ctx ExchangeContext) (common.Exchange, error) {
+		created = append(created, config)
+		return testAccountExchange{id: common.ExchangeID(config.ID)}, nil
+	})
+	ctx := ExchangeContext{SecretPath: "config.json"}
+	configs := []settings.ExchangeConfig{
+		{ID: "test_1", Type: "test_exchange"},
+		{ID: "test_2", Type: "test_exchange", SecretPath: "test_2.json"},
+	}
+	exchanges, err := newExchanges(configs, ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(exchanges) != 2 || len(created) != 2 {
+		t.Fatalf("expected 2 exchanges created, got %v", exchanges)
+	}
+	for _, config := range configs {
+		if _, ok := exchanges[common.ExchangeID(config.ID)]; !ok {
+			t.Errorf("expected exchange %s created", config.ID)
+		}
+	}
+	if path := ctx.secretPath(created[0]); path != "config.json" {
+		t.Errorf("expected default secret path, got %s", path)
+	}
+	if path := ctx.secretPath(created[1]); path != "test_2.json" {
+		t.Errorf("expected secret path of the account, got %s", path)
+	}
+
+	if _, err = newExchanges([]settings.ExchangeConfig{{ID: "test_3", Type: "unknown"}}, ctx); err == nil {
+		t.Error("expected error creating exchange of unregistered type")
+	}
+}
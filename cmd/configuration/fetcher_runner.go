@@ -0,0 +1,125 @@
+package configuration
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/KyberNetwork/reserve-data/common"
+	"github.com/KyberNetwork/reserve-data/data/fetcher"
+	"github.com/KyberNetwork/reserve-data/data/fetcher/block_runner"
+	"github.com/KyberNetwork/reserve-data/data/fetcher/replay"
+	"github.com/ethereum/go-ethereum/ethclient"
+)
+
+const (
+	// fetcherRunnerEnv is the name of environment variable that selects the
+	// runner triggering fetcher jobs outside of simulation mode.
+	// See below constants for list of available runners.
+	fetcherRunnerEnv = "KYBER_FETCHER_RUNNER"
+	// blockEndpointEnv is the name of environment variable of the websocket or
+	// IPC node endpoint used to subscribe to new blocks. Main ethereum
+	// endpoint is used if it is not set.
+	blockEndpointEnv = "KYBER_BLOCK_ENDPOINT"
+	// replayDirEnv is the name of environment variable of the directory of
+	// files recorded with --record-dir to replay, it is required by the replay
+	// runner.
+	replayDirEnv = "KYBER_REPLAY_DIR"
+	// replaySpeedEnv is the name of environment variable of the replay speed,
+	// relative to the time records were recorded in.
+	replaySpeedEnv = "KYBER_REPLAY_SPEED"
+	// replayFromEnv and replayToEnv are the names of environment variables of
+	// the timepoints, in milliseconds, of the first and last records to replay.
+	replayFromEnv = "KYBER_REPLAY_FROM"
+	replayToEnv   = "KYBER_REPLAY_TO"
+
+	// tickerFetcherRunner triggers fetcher jobs on fixed intervals, it is the default runner.
+	tickerFetcherRunner = "ticker"
+	// blockFetcherRunner triggers rate, block and auth data jobs once per new block.
+	blockFetcherRunner = "block"
+	// replayFetcherRunner feeds recorded prices, rates and auth data back
+	// through fetcher, in place of exchanges and blockchain. It is only
+	// available in simulation mode, so core acts on simulated exchanges and
+	// blockchain.
+	replayFetcherRunner = "replay"
+)
+
+// isReplayFetcherRunner returns true if KYBER_FETCHER_RUNNER selects the
+// replay runner.
+func isReplayFetcherRunner() bool {
+	return os.Getenv(fetcherRunnerEnv) == replayFetcherRunner
+}
+
+// newFetcherRunner creates the fetcher runner selected by KYBER_FETCHER_RUNNER
+// outside of simulation mode.
+func newFetcherRunner(endpoint string) (fetcher.FetcherRunner, error) {
+	runner, ok := os.LookupEnv(fetcherRunnerEnv)
+	if !ok || runner == "" {
+		runner = tickerFetcherRunner
+	}
+	switch runner {
+	case tickerFetcherRunner:
+		return fetcher.NewTickerRunner(
+			7*time.Second,  // orderbook fetching interval
+			5*time.Second,  // authdata fetching interval
+			3*time.Second,  // rate fetching interval
+			5*time.Second,  // block fetching interval
+			10*time.Second, // global data fetching interval
+		), nil
+	case blockFetcherRunner:
+		if blockEndpoint, ok := os.LookupEnv(blockEndpointEnv); ok && blockEndpoint != "" {
+			endpoint = blockEndpoint
+		}
+		log.Printf("Fetcher runner is subscribing to new blocks from %s", endpoint)
+		client, err := ethclient.Dial(endpoint)
+		if err != nil {
+			return nil, err
+		}
+		return block_runner.NewBlockRunner(
+			client,
+			block_runner.WithOrderbookDuration(7*time.Second),
+			block_runner.WithGlobalDataDuration(10*time.Second),
+		)
+	case replayFetcherRunner:
+		return nil, fmt.Errorf("%s fetcher runner requires KYBER_ENV=%s, core would act on live exchanges and blockchain", runner, common.SimulationMode)
+	default:
+		return nil, fmt.Errorf("unsupported fetcher runner: %s", runner)
+	}
+}
+
+// newReplayRunner creates the replay runner configured by KYBER_REPLAY_*
+// environment variables.
+func newReplayRunner() (*replay.Runner, error) {
+	dir, ok := os.LookupEnv(replayDirEnv)
+	if !ok || dir == "" {
+		return nil, fmt.Errorf("%s is required by replay runner", replayDirEnv)
+	}
+	var (
+		speed    float64 = replay.DefaultSpeed
+		from, to uint64
+		err      error
+	)
+	if value, ok := os.LookupEnv(replaySpeedEnv); ok && value != "" {
+		if speed, err = strconv.ParseFloat(value, 64); err != nil {
+			return nil, fmt.Errorf("invalid %s: %s", replaySpeedEnv, err)
+		}
+	}
+	if value, ok := os.LookupEnv(replayFromEnv); ok && value != "" {
+		if from, err = strconv.ParseUint(value, 10, 64); err != nil {
+			return nil, fmt.Errorf("invalid %s: %s", replayFromEnv, err)
+		}
+	}
+	if value, ok := os.LookupEnv(replayToEnv); ok && value != "" {
+		if to, err = strconv.ParseUint(value, 10, 64); err != nil {
+			return nil, fmt.Errorf("invalid %s: %s", replayToEnv, err)
+		}
+	}
+	reader, err := replay.NewReader(dir, from, to)
+	if err != nil {
+		return nil, err
+	}
+	log.Printf("Fetcher runner is replaying records in %s from %d to %d at speed %f", dir, from, to, speed)
+	return replay.NewRunner(reader, speed)
+}
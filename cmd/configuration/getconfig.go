@@ -66,18 +66,20 @@ func GetConfigPaths(kyberENV string) SettingPaths {
 	return ConfigPaths[common.DevMode]
 }
 
-func GetSetting(setPath SettingPaths, kyberENV string, addressSetting *settings.AddressSetting) (*settings.Settings, error) {
+// GetSetting returns the settings and the settings database, which also
+// stores API keys and the audit log.
+func GetSetting(setPath SettingPaths, kyberENV string, addressSetting *settings.AddressSetting) (*settings.Settings, *settingstorage.BoltSettingStorage, error) {
 	boltSettingStorage, err := settingstorage.NewBoltSettingStorage(filepath.Join(common.CmdDirLocation(), GetSettingDBName(kyberENV)))
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 	tokenSetting, err := settings.NewTokenSetting(boltSettingStorage)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 	exchangeSetting, err := settings.NewExchangeSetting(boltSettingStorage)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 	setting, err := settings.NewSetting(
 		tokenSetting,
@@ -88,7 +90,7 @@ func GetSetting(setPath SettingPaths, kyberENV string, addressSetting *settings.
 		settings.WithHandleEmptyMinDeposit(filepath.Join(common.CmdDirLocation(), "min_deposit.json")),
 		settings.WithHandleEmptyDepositAddress(setPath.settingPath),
 		settings.WithHandleEmptyExchangeInfo())
-	return setting, err
+	return setting, boltSettingStorage, err
 }
 
 func GetConfig(kyberENV string, authEnbl bool, endpointOW string, noCore, enableStat bool) *Config {
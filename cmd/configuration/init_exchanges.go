@@ -4,7 +4,6 @@ import (
 	"errors"
 	"fmt"
 	"log"
-	"path/filepath"
 	"sync"
 
 	"github.com/KyberNetwork/reserve-data/common"
@@ -15,6 +14,7 @@ import (
 	"github.com/KyberNetwork/reserve-data/exchange/binance"
 	"github.com/KyberNetwork/reserve-data/exchange/bittrex"
 	"github.com/KyberNetwork/reserve-data/exchange/huobi"
+	"github.com/KyberNetwork/reserve-data/exchange/kraken"
 	"github.com/KyberNetwork/reserve-data/settings"
 )
 
@@ -57,116 +57,190 @@ func getHuobiInterface(kyberENV string) huobi.Interface {
 	return envInterface
 }
 
+func getKrakenInterface(kyberENV string) kraken.Interface {
+	envInterface, ok := KrakenInterfaces[kyberENV]
+	if !ok {
+		envInterface = KrakenInterfaces[common.DevMode]
+	}
+	return envInterface
+}
+
+func init() {
+	RegisterExchange("stable_exchange", newStableEx)
+	RegisterExchange("bittrex", newBittrex)
+	RegisterExchange("binance", newBinance)
+	RegisterExchange("huobi", newHuobi)
+	RegisterExchange("kraken", newKraken)
+}
+
+// updateDepositAddresses updates the exchange with its deposit addresses in setting.
+func updateDepositAddresses(ex common.Exchange, setting *settings.Settings) {
+	addrs, err := setting.GetDepositAddresses(settings.ExchangeName(ex.ID()))
+	if err != nil {
+		log.Printf("INFO: Can't get %s Deposit Addresses from Storage (%s)", ex.ID(), err.Error())
+		addrs = make(common.ExchangeAddresses)
+	}
+	wait := sync.WaitGroup{}
+	for tokenID, addr := range addrs {
+		wait.Add(1)
+		go AsyncUpdateDepositAddress(ex, tokenID, addr.Hex(), &wait, setting)
+	}
+	wait.Wait()
+}
+
+func newStableEx(config settings.ExchangeConfig, ctx ExchangeContext) (common.Exchange, error) {
+	return exchange.NewStableEx(
+		ctx.Setting,
+		exchange.WithStableExName(config.ID),
+	)
+}
+
+func newBittrex(config settings.ExchangeConfig, ctx ExchangeContext) (common.Exchange, error) {
+	bittrexSigner := bittrex.NewSignerFromFile(ctx.secretPath(config))
+	envInterface := getBittrexInterface(ctx.KyberENV)
+	if ctx.KyberENV == common.SimulationMode && len(config.SimulationURL) != 0 {
+		envInterface = bittrex.NewSimulatedEndpointInterface(config.SimulationURL)
+	}
+	endpoint := bittrex.NewBittrexEndpoint(bittrexSigner, envInterface, bittrex.WithEndpointName(string(config.ID)))
+	bittrexStorage, err := bittrex.NewBoltStorage(ctx.storagePath(config))
+	if err != nil {
+		return nil, fmt.Errorf("Can not create Bittrex storage: (%s)", err.Error())
+	}
+	bit, err := exchange.NewBittrex(
+		endpoint,
+		bittrexStorage,
+		ctx.Setting,
+		exchange.WithBittrexName(config.ID))
+	if err != nil {
+		return nil, err
+	}
+	updateDepositAddresses(bit, ctx.Setting)
+	if err = bit.UpdatePairsPrecision(); err != nil {
+		return nil, fmt.Errorf("Can not Update Bittrex Pairs Precision : (%s)", err.Error())
+	}
+	return bit, nil
+}
+
+func newKraken(config settings.ExchangeConfig, ctx ExchangeContext) (common.Exchange, error) {
+	krakenSigner := kraken.NewSignerFromFile(ctx.secretPath(config))
+	envInterface := getKrakenInterface(ctx.KyberENV)
+	if ctx.KyberENV == common.SimulationMode && len(config.SimulationURL) != 0 {
+		envInterface = kraken.NewSimulatedEndpointInterface(config.SimulationURL)
+	}
+	endpoint := kraken.NewKrakenEndpoint(krakenSigner, envInterface, kraken.WithEndpointName(string(config.ID)))
+	krakenStorage, err := kraken.NewBoltStorage(ctx.storagePath(config))
+	if err != nil {
+		return nil, fmt.Errorf("Can not create Kraken storage: (%s)", err.Error())
+	}
+	kra, err := exchange.NewKraken(
+		endpoint,
+		krakenStorage,
+		ctx.Setting,
+		exchange.WithKrakenName(config.ID))
+	if err != nil {
+		return nil, err
+	}
+	updateDepositAddresses(kra, ctx.Setting)
+	if err = kra.UpdatePairsPrecision(); err != nil {
+		return nil, fmt.Errorf("Can not Update Kraken Pairs Precision : (%s)", err.Error())
+	}
+	return kra, nil
+}
+
+func newBinance(config settings.ExchangeConfig, ctx ExchangeContext) (common.Exchange, error) {
+	binanceSigner := binance.NewSignerFromFile(ctx.secretPath(config))
+	envInterface := getBinanceInterface(ctx.KyberENV)
+	if ctx.KyberENV == common.SimulationMode && len(config.SimulationURL) != 0 {
+		envInterface = binance.NewSimulatedEndpointInterface(config.SimulationURL)
+	}
+	endpoint := binance.NewBinanceEndpoint(binanceSigner, envInterface, binance.WithEndpointName(string(config.ID)))
+	storage, err := binance.NewBoltStorage(ctx.storagePath(config))
+	if err != nil {
+		return nil, fmt.Errorf("Can not create Binance storage: (%s)", err.Error())
+	}
+	options := []exchange.BinanceOption{
+		exchange.WithBinanceName(config.ID),
+		exchange.WithBinanceSubAccounts(config.SubAccounts),
+	}
+	if ctx.DepthStream {
+		stream := binance.NewDepthStream(binance.StreamEndpoint, endpoint)
+		options = append(options, exchange.WithBinanceDepthStream(stream, exchange.DefaultDepthStaleDuration))
+	}
+	bin, err := exchange.NewBinance(
+		endpoint,
+		storage,
+		ctx.Setting,
+		options...)
+	if err != nil {
+		return nil, err
+	}
+	updateDepositAddresses(bin, ctx.Setting)
+	if err = bin.UpdatePairsPrecision(); err != nil {
+		return nil, fmt.Errorf("Can not Update Binance Pairs Precision: (%s)", err.Error())
+	}
+	return bin, nil
+}
+
+func newHuobi(config settings.ExchangeConfig, ctx ExchangeContext) (common.Exchange, error) {
+	huobiSigner := huobi.NewSignerFromFile(ctx.secretPath(config))
+	envInterface := getHuobiInterface(ctx.KyberENV)
+	if ctx.KyberENV == common.SimulationMode && len(config.SimulationURL) != 0 {
+		envInterface = huobi.NewSimulatedEndpointInterface(config.SimulationURL)
+	}
+	endpoint := huobi.NewHuobiEndpoint(huobiSigner, envInterface, huobi.WithEndpointName(string(config.ID)))
+	storage, err := huobi.NewBoltStorage(ctx.storagePath(config))
+	if err != nil {
+		return nil, fmt.Errorf("Can not create Huobi storage: (%s)", err.Error())
+	}
+	intermediatorSigner := HuobiIntermediatorSignerFromFile(ctx.secretPath(config))
+	intermediatorNonce := nonce.NewTimeWindow(intermediatorSigner.GetAddress(), 10000)
+	options := []exchange.HuobiOption{exchange.WithHuobiName(config.ID)}
+	if ctx.DepthStream {
+		stream := huobi.NewDepthStream(huobi.StreamEndpoint)
+		options = append(options, exchange.WithHuobiDepthStream(stream, exchange.DefaultDepthStaleDuration))
+	}
+	huobi, err := exchange.NewHuobi(
+		endpoint,
+		ctx.Blockchain,
+		intermediatorSigner,
+		intermediatorNonce,
+		storage,
+		ctx.Setting,
+		options...,
+	)
+	if err != nil {
+		return nil, err
+	}
+	updateDepositAddresses(huobi, ctx.Setting)
+	if err = huobi.UpdatePairsPrecision(); err != nil {
+		return nil, fmt.Errorf("Can not Update Huobi Pairs Precision: (%s)", err.Error())
+	}
+	return huobi, nil
+}
+
+// NewExchangePool creates the running exchanges, as configured by
+// KYBER_EXCHANGES_CONFIG or KYBER_EXCHANGES, with their registered factories.
 func NewExchangePool(
 	settingPaths SettingPaths,
 	blockchain *blockchain.BaseBlockchain,
 	kyberENV string, setting *settings.Settings) (*ExchangePool, error) {
-	exchanges := map[common.ExchangeID]interface{}{}
-	exparams := settings.RunningExchanges()
-	for _, exparam := range exparams {
-		switch exparam {
-		case "stable_exchange":
-			stableEx, err := exchange.NewStableEx(
-				setting,
-			)
-			if err != nil {
-				return nil, fmt.Errorf("Can not create exchange stable_exchange: (%s)", err.Error())
-			}
-			exchanges[stableEx.ID()] = stableEx
-		case "bittrex":
-			bittrexSigner := bittrex.NewSignerFromFile(settingPaths.secretPath)
-			endpoint := bittrex.NewBittrexEndpoint(bittrexSigner, getBittrexInterface(kyberENV))
-			bittrexStorage, err := bittrex.NewBoltStorage(filepath.Join(common.CmdDirLocation(), "bittrex.db"))
-			if err != nil {
-				return nil, fmt.Errorf("Can not create Bittrex storage: (%s)", err.Error())
-			}
-			bit, err := exchange.NewBittrex(
-				endpoint,
-				bittrexStorage,
-				setting)
-			if err != nil {
-				return nil, fmt.Errorf("Can not create exchange Bittrex: (%s)", err.Error())
-			}
-			addrs, err := setting.GetDepositAddresses(settings.Bittrex)
-			if err != nil {
-				log.Printf("INFO: Can't get Bittrex Deposit Addresses from Storage (%s).", err.Error())
-				addrs = make(common.ExchangeAddresses)
-			}
-			wait := sync.WaitGroup{}
-			for tokenID, addr := range addrs {
-				wait.Add(1)
-				go AsyncUpdateDepositAddress(bit, tokenID, addr.Hex(), &wait, setting)
-			}
-			wait.Wait()
-			if err = bit.UpdatePairsPrecision(); err != nil {
-				return nil, fmt.Errorf("Can not Update Bittrex Pairs Precision : (%s)", err.Error())
-			}
-			exchanges[bit.ID()] = bit
-		case "binance":
-			binanceSigner := binance.NewSignerFromFile(settingPaths.secretPath)
-			endpoint := binance.NewBinanceEndpoint(binanceSigner, getBinanceInterface(kyberENV))
-			storage, err := binance.NewBoltStorage(filepath.Join(common.CmdDirLocation(), "binance.db"))
-			if err != nil {
-				return nil, fmt.Errorf("Can not create Binance storage: (%s)", err.Error())
-			}
-			bin, err := exchange.NewBinance(
-				endpoint,
-				storage,
-				setting)
-			if err != nil {
-				return nil, fmt.Errorf("Can not create exchange Binance: (%s)", err.Error())
-			}
-			addrs, err := setting.GetDepositAddresses(settings.Binance)
-			if err != nil {
-				log.Printf("INFO: Can't get Binance Deposit Addresses from Storage (%s)", err.Error())
-				addrs = make(common.ExchangeAddresses)
-			}
-			wait := sync.WaitGroup{}
-			for tokenID, addr := range addrs {
-				wait.Add(1)
-				go AsyncUpdateDepositAddress(bin, tokenID, addr.Hex(), &wait, setting)
-			}
-			wait.Wait()
-			if err = bin.UpdatePairsPrecision(); err != nil {
-				return nil, fmt.Errorf("Can not Update Binance Pairs Precision: (%s)", err.Error())
-			}
-			exchanges[bin.ID()] = bin
-		case "huobi":
-			huobiSigner := huobi.NewSignerFromFile(settingPaths.secretPath)
-			endpoint := huobi.NewHuobiEndpoint(huobiSigner, getHuobiInterface(kyberENV))
-			storage, err := huobi.NewBoltStorage(filepath.Join(common.CmdDirLocation(), "huobi.db"))
-			if err != nil {
-				return nil, fmt.Errorf("Can not create Huobi storage: (%s)", err.Error())
-			}
-			intermediatorSigner := HuobiIntermediatorSignerFromFile(settingPaths.secretPath)
-			intermediatorNonce := nonce.NewTimeWindow(intermediatorSigner.GetAddress(), 10000)
-			huobi, err := exchange.NewHuobi(
-				endpoint,
-				blockchain,
-				intermediatorSigner,
-				intermediatorNonce,
-				storage,
-				setting,
-			)
-			if err != nil {
-				return nil, fmt.Errorf("Can not create exchange Huobi: (%s)", err.Error())
-			}
-			addrs, err := setting.GetDepositAddresses(settings.Huobi)
-			if err != nil {
-				log.Printf("INFO: Can't get Huobi Deposit Addresses from Storage (%s)", err.Error())
-				addrs = make(common.ExchangeAddresses)
-			}
-			wait := sync.WaitGroup{}
-			for tokenID, addr := range addrs {
-				wait.Add(1)
-				go AsyncUpdateDepositAddress(huobi, tokenID, addr.Hex(), &wait, setting)
-			}
-			wait.Wait()
-			if err = huobi.UpdatePairsPrecision(); err != nil {
-				return nil, fmt.Errorf("Can not Update Huobi Pairs Precision: (%s)", err.Error())
-			}
-			exchanges[huobi.ID()] = huobi
-		}
+	configs, err := settings.RunningExchangeConfigs()
+	if err != nil {
+		return nil, err
+	}
+	depthStream, err := depthStreamEnabled(kyberENV)
+	if err != nil {
+		return nil, err
+	}
+	exchanges, err := newExchanges(configs, ExchangeContext{
+		SecretPath:  settingPaths.secretPath,
+		Blockchain:  blockchain,
+		KyberENV:    kyberENV,
+		Setting:     setting,
+		DepthStream: depthStream,
+	})
+	if err != nil {
+		return nil, err
 	}
 	return &ExchangePool{exchanges}, nil
 }
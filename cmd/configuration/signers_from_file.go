@@ -60,3 +60,26 @@ func HuobiIntermediatorSignerFromFile(secretPath string) *blockchain.EthereumSig
 	}
 	return blockchain.NewEthereumSigner(detail.Keystore, detail.Passphrase)
 }
+
+type jsonAlerterDetail struct {
+	Keystore   string `json:"keystore_alerter_path"`
+	Passphrase string `json:"passphrase_alerter"`
+}
+
+// AlerterSignerFromConfigFile returns the signer of the alerter of the
+// reserve and pricing contracts, or nil if it is not configured.
+func AlerterSignerFromConfigFile(secretPath string) *blockchain.EthereumSigner {
+	raw, err := ioutil.ReadFile(secretPath)
+	if err != nil {
+		panic(err)
+	}
+	detail := jsonAlerterDetail{}
+	err = json.Unmarshal(raw, &detail)
+	if err != nil {
+		panic(err)
+	}
+	if detail.Keystore == "" {
+		return nil
+	}
+	return blockchain.NewEthereumSigner(detail.Keystore, detail.Passphrase)
+}
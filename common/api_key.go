@@ -0,0 +1,25 @@
+package common
+
+// APIKey is a named key signing requests to core APIs, with its own secret
+// and permissions.
+type APIKey struct {
+	ID          string   `json:"id"`
+	Secret      string   `json:"secret,omitempty"`
+	Permissions []string `json:"permissions"`
+	CreatedAt   uint64   `json:"created_at"`
+	UpdatedAt   uint64   `json:"updated_at"`
+}
+
+// AuditRecord is a record of a mutating API call in the audit log.
+type AuditRecord struct {
+	Timestamp uint64 `json:"timestamp"`
+	// KeyID is the ID of the key signing the request, it is empty if the
+	// request is not authenticated.
+	KeyID    string              `json:"key_id"`
+	ClientIP string              `json:"client_ip"`
+	Method   string              `json:"method"`
+	Endpoint string              `json:"endpoint"`
+	Params   map[string][]string `json:"params"`
+	Success  bool                `json:"success"`
+	Reason   string              `json:"reason,omitempty"`
+}
@@ -12,6 +12,7 @@ import (
 	"time"
 
 	"github.com/KyberNetwork/reserve-data/common"
+	"github.com/KyberNetwork/reserve-data/common/instrument"
 	ether "github.com/ethereum/go-ethereum"
 	"github.com/ethereum/go-ethereum/accounts/abi"
 	"github.com/ethereum/go-ethereum/accounts/abi/bind"
@@ -23,6 +24,10 @@ import (
 
 const (
 	zeroAddress string = "0x0000000000000000000000000000000000000000"
+
+	// OperatorNonceObserveInterval is the interval of reading operator
+	// nonces from node to update their metrics.
+	OperatorNonceObserveInterval = 30 * time.Second
 )
 
 // BaseBlockchain interact with the blockchain in a way that eases
@@ -98,6 +103,54 @@ func (self *BaseBlockchain) GetNextNonce(operator string) (*big.Int, error) {
 	return nonce, err
 }
 
+// GetPendingNonce returns the nonce of the next transaction of the operator
+// from node, without reserving it.
+func (self *BaseBlockchain) GetPendingNonce(operator string) (*big.Int, error) {
+	timeout, cancel := context.WithTimeout(context.Background(), 7*time.Second)
+	defer cancel()
+	nonce, err := self.client.PendingNonceAt(timeout, self.MustGetOperator(operator).Address)
+	if err != nil {
+		return nil, err
+	}
+	return big.NewInt(0).SetUint64(nonce), nil
+}
+
+// RunOperatorNonceObserver updates the operator nonce metrics every interval,
+// so scraping the metrics doesn't wait for node.
+func (self *BaseBlockchain) RunOperatorNonceObserver(interval time.Duration) {
+	tick := time.NewTicker(interval)
+	for {
+		self.ObserveOperatorNonces()
+		<-tick.C
+	}
+}
+
+// ObserveOperatorNonces updates the mined and pending nonce metrics of all
+// operators from node.
+func (self *BaseBlockchain) ObserveOperatorNonces() {
+	for name := range self.operators {
+		if mined, err := self.GetMinedNonce(name); err != nil {
+			log.Printf("Getting mined nonce of operator %s failed: %s", name, err)
+		} else {
+			instrument.OperatorNonce.WithLabelValues(name, "mined").Set(float64(mined))
+		}
+		if pending, err := self.GetPendingNonce(name); err != nil {
+			log.Printf("Getting pending nonce of operator %s failed: %s", name, err)
+		} else {
+			instrument.OperatorNonce.WithLabelValues(name, "pending").Set(float64(pending.Uint64()))
+		}
+	}
+}
+
+// SignTx signs the transaction with the operator's signer without
+// broadcasting it.
+func (self *BaseBlockchain) SignTx(tx *types.Transaction, from string) (*types.Transaction, error) {
+	if tx == nil {
+		return nil, errors.New("Nil tx is forbidden here")
+	}
+	return self.MustGetOperator(from).Signer.Sign(tx)
+}
+
 func (self *BaseBlockchain) SignAndBroadcast(tx *types.Transaction, from string) (*types.Transaction, error) {
 	signer := self.MustGetOperator(from).Signer
 	if tx == nil {
@@ -376,6 +429,42 @@ func (self *BaseBlockchain) TxStatus(hash ethereum.Hash) (string, uint64, error)
 	return common.MiningStatusFailed, tx.BlockNumber().Uint64(), nil
 }
 
+// PendingTransaction returns the transaction of the given hash if it is
+// still pending, it returns nil if the transaction is mined or not found.
+func (self *BaseBlockchain) PendingTransaction(hash ethereum.Hash) (*types.Transaction, error) {
+	tx, pending, err := self.TransactionByHash(context.Background(), hash)
+	if err == ether.NotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !pending {
+		return nil, nil
+	}
+	return tx.tx, nil
+}
+
+// ReplaceTransaction broadcasts a copy of the pending transaction tx of the
+// operator with the given gas price. As both have the same nonce, only one of
+// them can be mined.
+func (self *BaseBlockchain) ReplaceTransaction(tx *types.Transaction, gasPrice *big.Int, operator string) (*types.Transaction, error) {
+	if tx.To() == nil {
+		return nil, errors.New("replacing contract creation transaction is not supported")
+	}
+	replacement := types.NewTransaction(tx.Nonce(), *tx.To(), tx.Value(), tx.Gas(), gasPrice, tx.Data())
+	return self.SignAndBroadcast(replacement, operator)
+}
+
+// CancelTransaction broadcasts a 0 value transfer from the operator to itself
+// with the given nonce and gas price, so the pending transaction of the same
+// nonce is dropped once it is mined.
+func (self *BaseBlockchain) CancelTransaction(nonce uint64, gasPrice *big.Int, operator string) (*types.Transaction, error) {
+	address := self.MustGetOperator(operator).Address
+	tx := types.NewTransaction(nonce, address, big.NewInt(0), 21000, gasPrice, nil)
+	return self.SignAndBroadcast(tx, operator)
+}
+
 func (self *BaseBlockchain) GetEthRate(timepoint uint64) float64 {
 	rate := self.ethRate.GetUSDRate(timepoint)
 	log.Printf("ETH-USD rate: %f", rate)
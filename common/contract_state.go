@@ -0,0 +1,61 @@
+package common
+
+import "math/big"
+
+// ContractPermissions are the permission groups of a contract.
+type ContractPermissions struct {
+	Address   string   `json:"address"`
+	Admin     string   `json:"admin"`
+	Operators []string `json:"operators"`
+	Alerters  []string `json:"alerters"`
+}
+
+// ReserveContractState is the admin state of the reserve contract.
+type ReserveContractState struct {
+	ContractPermissions
+	TradeEnabled            bool   `json:"trade_enabled"`
+	ConversionRatesContract string `json:"conversion_rates_contract"`
+}
+
+// PricingContractState is the admin state of the pricing contract.
+type PricingContractState struct {
+	ContractPermissions
+	ValidRateDurationInBlocks *big.Int `json:"valid_rate_duration_in_blocks"`
+	ReserveContract           string   `json:"reserve_contract"`
+}
+
+// WithdrawAddressState is whether an exchange deposit address of a token is
+// an approved withdraw address on the reserve.
+type WithdrawAddressState struct {
+	Address  string `json:"address"`
+	Approved bool   `json:"approved"`
+}
+
+// TokenContractState is the admin state of a token on the pricing and
+// reserve contracts.
+type TokenContractState struct {
+	// ID is the id of the token in settings, empty if the token is listed on
+	// the pricing contract but unknown to settings.
+	ID                      string   `json:"id"`
+	Address                 string   `json:"address"`
+	Listed                  bool     `json:"listed"`
+	Enabled                 bool     `json:"enabled"`
+	MinimalRecordResolution *big.Int `json:"minimal_record_resolution"`
+	MaxPerBlockImbalance    *big.Int `json:"max_per_block_imbalance"`
+	MaxTotalImbalance       *big.Int `json:"max_total_imbalance"`
+	// WithdrawAddresses are the approval states of the deposit addresses of
+	// the token by exchange.
+	WithdrawAddresses map[string]WithdrawAddressState `json:"withdraw_addresses"`
+}
+
+// ContractAdminState is the admin state of the reserve and pricing contracts
+// at BlockNumber, along with the mismatches against settings. Tokens are the
+// internal tokens and the tokens listed on the pricing contract, by id, or by
+// address for tokens unknown to settings.
+type ContractAdminState struct {
+	BlockNumber uint64                        `json:"block_number"`
+	Reserve     ReserveContractState          `json:"reserve"`
+	Pricing     PricingContractState          `json:"pricing"`
+	Tokens      map[string]TokenContractState `json:"tokens"`
+	Mismatches  []string                      `json:"mismatches"`
+}
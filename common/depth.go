@@ -0,0 +1,137 @@
+package common
+
+import (
+	"math"
+	"sort"
+)
+
+// depthEpsilon is the tolerance of comparing filled quantities.
+const depthEpsilon = 0.0000000001
+
+// DefaultDepthBands are the default bands, in bps of mid rate, in which the
+// depth of order books is measured.
+var DefaultDepthBands = []uint64{10, 50, 100}
+
+// SideDepth is the liquidity of a side of order books, asks to buy and bids
+// to sell the base token.
+type SideDepth struct {
+	// Best is the best rate of the side.
+	Best float64 `json:"best"`
+	// VWAP is the volume-weighted average rate of executing the quantity, or
+	// the filled part of it.
+	VWAP float64 `json:"vwap"`
+	// Filled is the quantity the side can execute, up to the queried
+	// quantity.
+	Filled float64 `json:"filled"`
+	// Slippage is the difference between VWAP and the best rate in bps.
+	Slippage float64 `json:"slippage_bps"`
+	// Depth is the quantity available within each band, in bps of mid rate.
+	Depth map[uint64]float64 `json:"depth"`
+}
+
+// ExchangeDepth is the liquidity of order books of a pair on an exchange, or
+// across exchanges.
+type ExchangeDepth struct {
+	Mid  float64   `json:"mid"`
+	Buy  SideDepth `json:"buy"`
+	Sell SideDepth `json:"sell"`
+}
+
+// PairDepth is the liquidity of a pair for executing a quantity of the base
+// token, by exchange and across all exchanges combined.
+type PairDepth struct {
+	Version   Version                      `json:"version"`
+	Quantity  float64                      `json:"quantity"`
+	Exchanges map[ExchangeID]ExchangeDepth `json:"exchanges"`
+	Combined  ExchangeDepth                `json:"combined"`
+	// BestBuy and BestSell are the exchanges with the best VWAP among the
+	// ones able to execute the whole quantity, empty if there is none.
+	BestBuy  ExchangeID `json:"best_buy"`
+	BestSell ExchangeID `json:"best_sell"`
+}
+
+// NewPairDepth returns the liquidity of executing quantity of the base token
+// from the order books of a pair. Invalid order books are ignored.
+func NewPairDepth(prices OnePrice, quantity float64, bands []uint64) PairDepth {
+	result := PairDepth{
+		Quantity:  quantity,
+		Exchanges: map[ExchangeID]ExchangeDepth{},
+	}
+	var allBids, allAsks []PriceEntry
+	for exchange, price := range prices {
+		if !price.Valid {
+			continue
+		}
+		depth := newExchangeDepth(price.Bids, price.Asks, quantity, bands)
+		result.Exchanges[exchange] = depth
+		allBids = append(allBids, price.Bids...)
+		allAsks = append(allAsks, price.Asks...)
+		if depth.Buy.fills(quantity) &&
+			(result.BestBuy == "" || depth.Buy.VWAP < result.Exchanges[result.BestBuy].Buy.VWAP) {
+			result.BestBuy = exchange
+		}
+		if depth.Sell.fills(quantity) &&
+			(result.BestSell == "" || depth.Sell.VWAP > result.Exchanges[result.BestSell].Sell.VWAP) {
+			result.BestSell = exchange
+		}
+	}
+	sort.Slice(allBids, func(i, j int) bool { return allBids[i].Rate > allBids[j].Rate })
+	sort.Slice(allAsks, func(i, j int) bool { return allAsks[i].Rate < allAsks[j].Rate })
+	result.Combined = newExchangeDepth(allBids, allAsks, quantity, bands)
+	return result
+}
+
+// newExchangeDepth returns the liquidity of bids sorted by rate descending
+// and asks sorted by rate ascending.
+func newExchangeDepth(bids, asks []PriceEntry, quantity float64, bands []uint64) ExchangeDepth {
+	var result ExchangeDepth
+	switch {
+	case len(bids) != 0 && len(asks) != 0:
+		result.Mid = (bids[0].Rate + asks[0].Rate) / 2
+	case len(bids) != 0:
+		result.Mid = bids[0].Rate
+	case len(asks) != 0:
+		result.Mid = asks[0].Rate
+	}
+	result.Buy = newSideDepth(asks, result.Mid, quantity, bands, func(rate, bps float64) bool {
+		return rate <= result.Mid*(1+bps/10000)
+	})
+	result.Sell = newSideDepth(bids, result.Mid, quantity, bands, func(rate, bps float64) bool {
+		return rate >= result.Mid*(1-bps/10000)
+	})
+	return result
+}
+
+func newSideDepth(levels []PriceEntry, mid, quantity float64, bands []uint64, inBand func(rate, bps float64) bool) SideDepth {
+	result := SideDepth{Depth: map[uint64]float64{}}
+	for _, band := range bands {
+		result.Depth[band] = 0
+	}
+	if len(levels) == 0 {
+		return result
+	}
+	result.Best = levels[0].Rate
+	var cost float64
+	for _, level := range levels {
+		if result.Filled < quantity {
+			amount := math.Min(level.Quantity, quantity-result.Filled)
+			result.Filled += amount
+			cost += amount * level.Rate
+		}
+		for _, band := range bands {
+			if inBand(level.Rate, float64(band)) {
+				result.Depth[band] += level.Quantity
+			}
+		}
+	}
+	if result.Filled > 0 {
+		result.VWAP = cost / result.Filled
+		result.Slippage = math.Abs(result.VWAP-result.Best) / result.Best * 10000
+	}
+	return result
+}
+
+// fills returns true if the side can execute the whole quantity.
+func (self SideDepth) fills(quantity float64) bool {
+	return self.Filled > quantity-depthEpsilon
+}
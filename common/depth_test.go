@@ -0,0 +1,59 @@
+package common
+
+import (
+	"math"
+	"testing"
+)
+
+func assertFloat(t *testing.T, name string, expected, actual float64) {
+	t.Helper()
+	if math.Abs(expected-actual) > 1e-9 {
+		t.Errorf("Expected %s %f, got %f", name, expected, actual)
+	}
+}
+
+func TestNewPairDepth(t *testing.T) {
+	prices := OnePrice{
+		"binance": {
+			Valid: true,
+			Bids:  []PriceEntry{NewPriceEntry(100, 0.0099), NewPriceEntry(200, 0.0098)},
+			Asks:  []PriceEntry{NewPriceEntry(100, 0.0101), NewPriceEntry(200, 0.0102)},
+		},
+		"huobi": {
+			Valid: true,
+			Bids:  []PriceEntry{NewPriceEntry(50, 0.00995)},
+			Asks:  []PriceEntry{NewPriceEntry(50, 0.01005)},
+		},
+		"bittrex": {
+			Valid: false,
+			Asks:  []PriceEntry{NewPriceEntry(1000, 0.001)},
+		},
+	}
+	depth := NewPairDepth(prices, 150, []uint64{100, 200})
+
+	if _, ok := depth.Exchanges["bittrex"]; ok {
+		t.Error("Expected invalid order book to be ignored")
+	}
+	binance := depth.Exchanges["binance"]
+	assertFloat(t, "binance mid", 0.01, binance.Mid)
+	// 100 at 0.0101 and 50 at 0.0102
+	assertFloat(t, "binance buy vwap", (100*0.0101+50*0.0102)/150, binance.Buy.VWAP)
+	assertFloat(t, "binance buy slippage", (binance.Buy.VWAP-0.0101)/0.0101*10000, binance.Buy.Slippage)
+	assertFloat(t, "binance sell vwap", (100*0.0099+50*0.0098)/150, binance.Sell.VWAP)
+	assertFloat(t, "binance buy depth within 100 bps", 100, binance.Buy.Depth[100])
+	assertFloat(t, "binance buy depth within 200 bps", 300, binance.Buy.Depth[200])
+
+	huobi := depth.Exchanges["huobi"]
+	assertFloat(t, "huobi buy filled", 50, huobi.Buy.Filled)
+	assertFloat(t, "huobi buy vwap of the filled part", 0.01005, huobi.Buy.VWAP)
+
+	// huobi can't fill the whole quantity
+	if depth.BestBuy != "binance" || depth.BestSell != "binance" {
+		t.Errorf("Expected binance to be the best exchange, got %s and %s", depth.BestBuy, depth.BestSell)
+	}
+
+	combined := depth.Combined
+	assertFloat(t, "combined mid", 0.01, combined.Mid)
+	assertFloat(t, "combined buy vwap", (50*0.01005+100*0.0101)/150, combined.Buy.VWAP)
+	assertFloat(t, "combined sell depth within 100 bps", 150, combined.Sell.Depth[100])
+}
@@ -0,0 +1,91 @@
+package common
+
+import (
+	"net/url"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+// DryRunTx is the transaction which would have been broadcasted by an
+// action in dry run mode. It only carries the hash of the signed
+// transaction, never its signature or signed bytes, so it can't be
+// broadcasted from a dry run result.
+type DryRunTx struct {
+	Hash     string `json:"hash"`
+	To       string `json:"to"`
+	Nonce    uint64 `json:"nonce"`
+	GasPrice string `json:"gasPrice"`
+	GasLimit uint64 `json:"gasLimit"`
+	Value    string `json:"value"`
+	Data     string `json:"data"`
+	// UnsignedTx is the rlp encoding of the transaction without signature.
+	UnsignedTx string `json:"unsignedTx"`
+}
+
+// NewDryRunTx creates a DryRunTx from a signed transaction.
+func NewDryRunTx(tx *types.Transaction) (*DryRunTx, error) {
+	var unsigned *types.Transaction
+	if to := tx.To(); to != nil {
+		unsigned = types.NewTransaction(tx.Nonce(), *to, tx.Value(), tx.Gas(), tx.GasPrice(), tx.Data())
+	} else {
+		unsigned = types.NewContractCreation(tx.Nonce(), tx.Value(), tx.Gas(), tx.GasPrice(), tx.Data())
+	}
+	raw, err := rlp.EncodeToBytes(unsigned)
+	if err != nil {
+		return nil, err
+	}
+	result := &DryRunTx{
+		Hash:       tx.Hash().Hex(),
+		Nonce:      tx.Nonce(),
+		GasPrice:   tx.GasPrice().Text(10),
+		GasLimit:   tx.Gas(),
+		Value:      tx.Value().Text(10),
+		Data:       hexutil.Encode(tx.Data()),
+		UnsignedTx: hexutil.Encode(raw),
+	}
+	if to := tx.To(); to != nil {
+		result.To = to.Hex()
+	}
+	return result, nil
+}
+
+// DryRunRequest is the exchange request which would have been signed and
+// sent by an action in dry run mode. It is built unsigned, without api key,
+// nonce, timestamp or signature, so it can't be replayed to the exchange.
+type DryRunRequest struct {
+	Method string            `json:"method"`
+	URL    string            `json:"url"`
+	Params map[string]string `json:"params"`
+}
+
+// NewDryRunRequest creates a DryRunRequest to the endpoint with the params.
+func NewDryRunRequest(method string, endpoint *url.URL, params url.Values) DryRunRequest {
+	result := DryRunRequest{
+		Method: method,
+		URL:    endpoint.Scheme + "://" + endpoint.Host + endpoint.Path,
+		Params: map[string]string{},
+	}
+	for k := range params {
+		result.Params[k] = params.Get(k)
+	}
+	return result
+}
+
+// DryRunResult is what an action would have sent in dry run mode, after
+// passing all of its checks. Params is the request sent to the exchange or
+// the parameters of the transaction Tx.
+type DryRunResult struct {
+	Action      string                 `json:"action"`
+	Destination string                 `json:"destination"`
+	Params      map[string]interface{} `json:"params"`
+	Tx          *DryRunTx              `json:"tx,omitempty"`
+	// Request is the unsigned request the action would have sent to the
+	// exchange.
+	Request *DryRunRequest `json:"request,omitempty"`
+	// RequiresApproval is true if the withdrawal is above the withdraw
+	// threshold of its token, so it would be pending approval instead of
+	// requested to the exchange.
+	RequiresApproval bool `json:"requires_approval,omitempty"`
+}
@@ -0,0 +1,18 @@
+package common
+
+// EmergencyTx is a transaction of disabling or enabling trade on chain, of
+// the reserve if Token is empty, of Token on the pricing contract otherwise.
+type EmergencyTx struct {
+	// Method is the contract method the transaction calls.
+	Method string `json:"method"`
+	Token  string `json:"token,omitempty"`
+	To     string `json:"to"`
+	// Data and GasLimit are set for transactions built unsigned, to be sent
+	// by the admin wallet.
+	Data     string `json:"data,omitempty"`
+	GasLimit uint64 `json:"gas_limit,omitempty"`
+	// Hash, Error and ActivityID are set once the transaction is submitted.
+	Hash       string     `json:"hash,omitempty"`
+	Error      string     `json:"error,omitempty"`
+	ActivityID ActivityID `json:"activity_id"`
+}
@@ -0,0 +1,50 @@
+package common
+
+// Actions of the exchange notifications recorded by exchange status
+// monitoring. Notifications of these actions can also be posted by
+// operators, core refuses deposits and withdrawals while they are active.
+const (
+	// ExchangeMaintenance is the action of an exchange under maintenance,
+	// its token is AllTokens.
+	ExchangeMaintenance = "maintenance"
+	// SuspendDeposit is the action of deposits of a token being suspended.
+	SuspendDeposit = "suspendDeposit"
+	// SuspendWithdraw is the action of withdrawals of a token being
+	// suspended.
+	SuspendWithdraw = "suspendWithdraw"
+	// AllTokens is the token of notifications affecting all tokens of an
+	// exchange.
+	AllTokens = "ALL"
+)
+
+// SuspendedAction returns the name of the action suspended by a notification
+// of SuspendDeposit or SuspendWithdraw.
+func SuspendedAction(action string) string {
+	if action == SuspendWithdraw {
+		return "withdrawal"
+	}
+	return "deposit"
+}
+
+// TokenWalletStatus is whether deposits and withdrawals of a token are
+// enabled on an exchange.
+type TokenWalletStatus struct {
+	DepositEnabled  bool `json:"deposit_enabled"`
+	WithdrawEnabled bool `json:"withdraw_enabled"`
+}
+
+// ExchangeSystemStatus is the status of an exchange and the wallets of its
+// tokens reported by its API.
+type ExchangeSystemStatus struct {
+	Maintenance bool   `json:"maintenance"`
+	Message     string `json:"message"`
+	// Wallets are the statuses of known tokens by their IDs, tokens the
+	// exchange doesn't report are left out.
+	Wallets map[string]TokenWalletStatus `json:"wallets"`
+}
+
+// IsActive returns true if timepoint is between the from and to time of the
+// notification. A notification without to time is active until it is closed.
+func (self ExchangeNotiContent) IsActive(timepoint uint64) bool {
+	return self.FromTime <= timepoint && (self.ToTime == 0 || timepoint < self.ToTime)
+}
@@ -0,0 +1,78 @@
+package common
+
+// Strategies of an execution.
+const (
+	// ExecutionTWAP spreads the order evenly over a duration.
+	ExecutionTWAP = "twap"
+	// ExecutionIceberg places the order a visible amount at a time.
+	ExecutionIceberg = "iceberg"
+)
+
+// Statuses of an execution.
+const (
+	ExecutionRunning   = "running"
+	ExecutionDone      = "done"
+	ExecutionCancelled = "cancelled"
+	ExecutionFailed    = "failed"
+)
+
+// ExecutionStrategy is how an execution splits its order into slices.
+type ExecutionStrategy struct {
+	Name string `json:"name"`
+	// Duration, in milliseconds, a TWAP execution is spread over in Slices
+	// slices of equal amount.
+	Duration uint64 `json:"duration,omitempty"`
+	Slices   int    `json:"slices,omitempty"`
+	// VisibleAmount is the amount of each slice of an iceberg execution.
+	VisibleAmount float64 `json:"visible_amount,omitempty"`
+	// RepriceInterval, in milliseconds, after which an unfilled slice is
+	// cancelled and placed again at the latest price. It is the duration of
+	// a slice for TWAP.
+	RepriceInterval uint64 `json:"reprice_interval"`
+}
+
+// ExecutionSlice is an order placed by an execution.
+type ExecutionSlice struct {
+	ID       ActivityID `json:"id"`
+	Rate     float64    `json:"rate"`
+	Amount   float64    `json:"amount"`
+	Done     float64    `json:"done"`
+	PlacedAt uint64     `json:"placed_at"`
+	Finished bool       `json:"finished"`
+	// Errors is the number of consecutive errors checking or cancelling
+	// the slice.
+	Errors int `json:"errors,omitempty"`
+}
+
+// Execution is a large order executed in slices over time by a strategy.
+// Rate is the limit rate, slices are never placed at a worse rate.
+type Execution struct {
+	ID        ActivityID        `json:"id"`
+	Exchange  ExchangeID        `json:"exchange"`
+	Type      string            `json:"type"`
+	Base      string            `json:"base"`
+	Quote     string            `json:"quote"`
+	Rate      float64           `json:"rate"`
+	Amount    float64           `json:"amount"`
+	Strategy  ExecutionStrategy `json:"strategy"`
+	Status    string            `json:"status"`
+	Done      float64           `json:"done"`
+	Slices    []ExecutionSlice  `json:"slices"`
+	Error     string            `json:"error,omitempty"`
+	CreatedAt uint64            `json:"created_at"`
+	UpdatedAt uint64            `json:"updated_at"`
+}
+
+// OpenSlice returns the slice of the execution which is not finished, nil
+// if there is none.
+func (self *Execution) OpenSlice() *ExecutionSlice {
+	if len(self.Slices) == 0 || self.Slices[len(self.Slices)-1].Finished {
+		return nil
+	}
+	return &self.Slices[len(self.Slices)-1]
+}
+
+// Remaining returns the amount of the execution not filled yet.
+func (self Execution) Remaining() float64 {
+	return self.Amount - self.Done
+}
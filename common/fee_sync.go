@@ -0,0 +1,41 @@
+package common
+
+// FeeChange is a fee or min deposit of which the live value reported by the
+// exchange differs from the current setting.
+type FeeChange struct {
+	Current float64 `json:"current"`
+	Live    float64 `json:"live"`
+}
+
+// ExchangeFeeDiff is the difference between the current fees and min
+// deposits of an exchange and the live ones from its API, by fee type then
+// token. Trading fees are keyed by maker and taker instead of token.
+type ExchangeFeeDiff struct {
+	Trading    map[string]FeeChange `json:"trading,omitempty"`
+	Withdraw   map[string]FeeChange `json:"withdraw,omitempty"`
+	MinDeposit map[string]FeeChange `json:"min_deposit,omitempty"`
+	Timestamp  uint64               `json:"timestamp"`
+}
+
+// IsEmpty returns true if the live fees and min deposits are the same as
+// the current ones.
+func (self ExchangeFeeDiff) IsEmpty() bool {
+	return len(self.Trading) == 0 && len(self.Withdraw) == 0 && len(self.MinDeposit) == 0
+}
+
+// LiveFees returns the live values of the changed fees and min deposits, to
+// be merged into the current setting.
+func (self ExchangeFeeDiff) LiveFees() (ExchangeFees, ExchangesMinDeposit) {
+	fee := NewExchangeFee(TradingFee{}, NewFundingFee(map[string]float64{}, map[string]float64{}))
+	minDeposit := ExchangesMinDeposit{}
+	for key, change := range self.Trading {
+		fee.Trading[key] = change.Live
+	}
+	for token, change := range self.Withdraw {
+		fee.Funding.Withdraw[token] = change.Live
+	}
+	for token, change := range self.MinDeposit {
+		minDeposit[token] = change.Live
+	}
+	return fee, minDeposit
+}
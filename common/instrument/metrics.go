@@ -0,0 +1,115 @@
+// Package instrument keeps operational metrics of the reserve, they are
+// registered to the default Prometheus registry.
+package instrument
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Loops of the fetchers.
+const (
+	LoopOrderbook    = "orderbook"
+	LoopAuthData     = "authdata"
+	LoopRate         = "rate"
+	LoopBlock        = "block"
+	LoopGlobalData   = "global_data"
+	LoopStepFunction = "step_function"
+)
+
+// DurationBuckets are the histogram buckets of durations in second, from
+// 5ms to 1 minute.
+var DurationBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10, 30, 60}
+
+var (
+	// FetcherLoopDuration is the duration of each run of a fetcher loop.
+	FetcherLoopDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "reserve_fetcher_loop_duration_seconds",
+		Help:    "Duration of a run of fetcher loop.",
+		Buckets: DurationBuckets,
+	}, []string{"loop"})
+	// FetcherLoopErrors counts the failures of fetcher loops, a run can
+	// fail in more than one way.
+	FetcherLoopErrors = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "reserve_fetcher_loop_errors_total",
+		Help: "Number of errors of fetcher loop.",
+	}, []string{"loop"})
+	// ExchangeRequestDuration is the latency of requests to exchange APIs.
+	ExchangeRequestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "reserve_exchange_request_duration_seconds",
+		Help:    "Latency of requests to exchange APIs.",
+		Buckets: DurationBuckets,
+	}, []string{"exchange", "path"})
+	// ExchangeRequestErrors counts the failed requests to exchange APIs.
+	ExchangeRequestErrors = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "reserve_exchange_request_errors_total",
+		Help: "Number of failed requests to exchange APIs.",
+	}, []string{"exchange", "path"})
+	// SnapshotValid is 1 if the last stored snapshot is valid, 0 otherwise.
+	SnapshotValid = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "reserve_snapshot_valid",
+		Help: "Whether the last stored snapshot is valid.",
+	}, []string{"snapshot"})
+	// ExchangeBalanceValid is 1 if the balances of the exchange in the last
+	// auth data snapshot are valid, 0 otherwise.
+	ExchangeBalanceValid = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "reserve_exchange_balance_valid",
+		Help: "Whether the exchange balances of the last auth data snapshot are valid.",
+	}, []string{"exchange"})
+	// PendingActivities is the number of pending activities of the last
+	// auth data snapshot.
+	PendingActivities = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "reserve_pending_activities",
+		Help: "Number of pending activities by action and status.",
+	}, []string{"action", "exchange_status", "mining_status"})
+	// OperatorNonce is the mined and pending nonce of each operator account.
+	OperatorNonce = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "reserve_operator_nonce",
+		Help: "Nonce of operator account, mined or pending.",
+	}, []string{"operator", "state"})
+	// LogFetcherLag is the number of blocks the stat log fetcher is behind
+	// the current block.
+	LogFetcherLag = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "reserve_stat_log_fetcher_lag_blocks",
+		Help: "Number of blocks the stat log fetcher is behind the current block.",
+	})
+)
+
+func init() {
+	prometheus.MustRegister(
+		FetcherLoopDuration,
+		FetcherLoopErrors,
+		ExchangeRequestDuration,
+		ExchangeRequestErrors,
+		SnapshotValid,
+		ExchangeBalanceValid,
+		PendingActivities,
+		OperatorNonce,
+		LogFetcherLag,
+	)
+}
+
+// SetBool sets gauge to 1 if ok, 0 otherwise.
+func SetBool(gauge prometheus.Gauge, ok bool) {
+	if ok {
+		gauge.Set(1)
+	} else {
+		gauge.Set(0)
+	}
+}
+
+// ObserveFetcherLoop records the duration of a run of loop started at start.
+// It is meant to be deferred at the start of the run.
+func ObserveFetcherLoop(loop string, start time.Time) {
+	FetcherLoopDuration.WithLabelValues(loop).Observe(time.Since(start).Seconds())
+}
+
+// ObserveExchangeRequest records the latency and outcome of a request to
+// exchange API started at start.
+func ObserveExchangeRequest(exchange, path string, start time.Time, err error) {
+	ExchangeRequestDuration.WithLabelValues(exchange, path).Observe(time.Since(start).Seconds())
+	if err != nil {
+		ExchangeRequestErrors.WithLabelValues(exchange, path).Inc()
+	}
+}
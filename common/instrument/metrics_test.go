@@ -0,0 +1,40 @@
+package instrument
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+)
+
+func TestObserveExchangeRequest(t *testing.T) {
+	ObserveExchangeRequest("binance_2", "/api/v3/depth", time.Now(), nil)
+	ObserveExchangeRequest("binance_2", "/api/v3/depth", time.Now(), errors.New("timeout"))
+
+	var m dto.Metric
+	if err := ExchangeRequestDuration.WithLabelValues("binance_2", "/api/v3/depth").(prometheus.Metric).Write(&m); err != nil {
+		t.Fatal(err)
+	}
+	if count := m.GetHistogram().GetSampleCount(); count != 2 {
+		t.Errorf("expected 2 observed requests, got %d", count)
+	}
+	if err := ExchangeRequestErrors.WithLabelValues("binance_2", "/api/v3/depth").Write(&m); err != nil {
+		t.Fatal(err)
+	}
+	if errs := m.GetCounter().GetValue(); errs != 1 {
+		t.Errorf("expected 1 failed request, got %f", errs)
+	}
+}
+
+func TestSetBool(t *testing.T) {
+	SetBool(SnapshotValid.WithLabelValues(LoopOrderbook), true)
+	var m dto.Metric
+	if err := SnapshotValid.WithLabelValues(LoopOrderbook).Write(&m); err != nil {
+		t.Fatal(err)
+	}
+	if m.GetGauge().GetValue() != 1 {
+		t.Errorf("expected valid snapshot gauge 1, got %f", m.GetGauge().GetValue())
+	}
+}
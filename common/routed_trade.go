@@ -0,0 +1,31 @@
+package common
+
+// RoutedOrder is an order on an exchange, placed for a part of a trade
+// routed across exchanges.
+type RoutedOrder struct {
+	Exchange  ExchangeID `json:"exchange"`
+	ID        ActivityID `json:"id"`
+	Rate      float64    `json:"rate"`
+	Amount    float64    `json:"amount"`
+	Done      float64    `json:"done"`
+	Remaining float64    `json:"remaining"`
+	Finished  bool       `json:"finished"`
+	Error     string     `json:"error,omitempty"`
+}
+
+// RoutedTrade is a trade split into orders on exchanges to minimise its
+// cost. Done and Remaining are the sums of the orders.
+type RoutedTrade struct {
+	ID        ActivityID    `json:"id"`
+	Type      string        `json:"type"`
+	Base      string        `json:"base"`
+	Quote     string        `json:"quote"`
+	Amount    float64       `json:"amount"`
+	Orders    []RoutedOrder `json:"orders"`
+	Done      float64       `json:"done"`
+	Remaining float64       `json:"remaining"`
+	Finished  bool          `json:"finished"`
+	// Unrouted is the amount not placed in any order, as order amounts are
+	// rounded down to the precision of exchanges.
+	Unrouted float64 `json:"unrouted"`
+}
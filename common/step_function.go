@@ -0,0 +1,153 @@
+package common
+
+import (
+	"fmt"
+	"math/big"
+)
+
+const (
+	// MaxStepFunctionSteps is the max number of steps of each side of a step
+	// function accepted by the pricing contract.
+	MaxStepFunctionSteps = 10
+	// MinStepFunctionBps and MaxStepFunctionBps are the bounds of the rate
+	// adjustments of steps, in bps. An adjustment of -10000 bps disables
+	// the trade.
+	MinStepFunctionBps = -10000
+	MaxStepFunctionBps = 10000
+)
+
+// validateSteps returns an error if the steps of a step function side are
+// invalid: x must be strictly increasing, y must be in the bps bounds and
+// never increase with x if decreasing is true, never decrease otherwise.
+func validateSteps(side string, x, y []*big.Int, decreasing bool) error {
+	if len(x) != len(y) {
+		return fmt.Errorf("%s: x and y must have the same length", side)
+	}
+	if len(x) > MaxStepFunctionSteps {
+		return fmt.Errorf("%s: at most %d steps are allowed, got %d", side, MaxStepFunctionSteps, len(x))
+	}
+	minBps, maxBps := big.NewInt(MinStepFunctionBps), big.NewInt(MaxStepFunctionBps)
+	for i := range x {
+		if x[i] == nil || y[i] == nil {
+			return fmt.Errorf("%s: step %d is missing", side, i)
+		}
+		if y[i].Cmp(minBps) < 0 || y[i].Cmp(maxBps) > 0 {
+			return fmt.Errorf("%s: y %s is out of [%d, %d] bps", side, y[i], MinStepFunctionBps, MaxStepFunctionBps)
+		}
+		if i == 0 {
+			continue
+		}
+		if x[i].Cmp(x[i-1]) <= 0 {
+			return fmt.Errorf("%s: x must be strictly increasing, got %s after %s", side, x[i], x[i-1])
+		}
+		if cmp := y[i].Cmp(y[i-1]); (decreasing && cmp > 0) || (!decreasing && cmp < 0) {
+			return fmt.Errorf("%s: rate must not improve, got y %s after %s", side, y[i], y[i-1])
+		}
+	}
+	return nil
+}
+
+// Validate returns an error if the quantity step function is invalid.
+// Quantities are not negative, larger quantities of both sides never get
+// better rates.
+func (self QuantityStepFunction) Validate() error {
+	for i, x := range append(append([]*big.Int{}, self.XBuy...), self.XSell...) {
+		if x != nil && x.Sign() < 0 {
+			return fmt.Errorf("qty step function: x %s of step %d is negative", x, i)
+		}
+	}
+	if err := validateSteps("qty step function buy", self.XBuy, self.YBuy, true); err != nil {
+		return err
+	}
+	return validateSteps("qty step function sell", self.XSell, self.YSell, true)
+}
+
+// Validate returns an error if the imbalance step function is invalid.
+// Buying increases the imbalance and selling decreases it, so rates of buy
+// never improve with larger imbalances, and rates of sell never improve with
+// smaller ones.
+func (self ImbalanceStepFunction) Validate() error {
+	if err := validateSteps("imbalance step function buy", self.XBuy, self.YBuy, true); err != nil {
+		return err
+	}
+	return validateSteps("imbalance step function sell", self.XSell, self.YSell, false)
+}
+
+func equalSteps(a, b []*big.Int) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] == nil || b[i] == nil {
+			if a[i] != b[i] {
+				return false
+			}
+			continue
+		}
+		if a[i].Cmp(b[i]) != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// Equal returns true if both quantity step functions have the same steps.
+func (self QuantityStepFunction) Equal(other QuantityStepFunction) bool {
+	return equalSteps(self.XBuy, other.XBuy) && equalSteps(self.YBuy, other.YBuy) &&
+		equalSteps(self.XSell, other.XSell) && equalSteps(self.YSell, other.YSell)
+}
+
+// Equal returns true if both imbalance step functions have the same steps.
+func (self ImbalanceStepFunction) Equal(other ImbalanceStepFunction) bool {
+	return equalSteps(self.XBuy, other.XBuy) && equalSteps(self.YBuy, other.YBuy) &&
+		equalSteps(self.XSell, other.XSell) && equalSteps(self.YSell, other.YSell)
+}
+
+// StepFunctionUpdate is the update of the step functions of a token on the
+// pricing contract, a nil step function is left unchanged.
+type StepFunctionUpdate struct {
+	QtyStepFunction       *QuantityStepFunction  `json:"quantity_step_function,omitempty"`
+	ImbalanceStepFunction *ImbalanceStepFunction `json:"imbalance_step_function,omitempty"`
+}
+
+// Validate returns an error if any step function of the update is invalid.
+func (self StepFunctionUpdate) Validate() error {
+	if self.QtyStepFunction != nil {
+		if err := self.QtyStepFunction.Validate(); err != nil {
+			return err
+		}
+	}
+	if self.ImbalanceStepFunction != nil {
+		if err := self.ImbalanceStepFunction.Validate(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// IsEmpty returns true if the update changes no step function.
+func (self StepFunctionUpdate) IsEmpty() bool {
+	return self.QtyStepFunction == nil && self.ImbalanceStepFunction == nil
+}
+
+// Diff returns the update of the step functions differing from current.
+func (self StepFunctionUpdate) Diff(current StepFunctionResponse) StepFunctionUpdate {
+	result := StepFunctionUpdate{}
+	if self.QtyStepFunction != nil && !self.QtyStepFunction.Equal(current.QuantityStepResponse) {
+		result.QtyStepFunction = self.QtyStepFunction
+	}
+	if self.ImbalanceStepFunction != nil && !self.ImbalanceStepFunction.Equal(current.ImbalanceStepResponse) {
+		result.ImbalanceStepFunction = self.ImbalanceStepFunction
+	}
+	return result
+}
+
+// PendingStepFunctionUpdate is the update of step functions by token waiting
+// for confirmation. Only the step functions differing from the ones on chain
+// at BlockNumber are kept, along with the ones on chain.
+type PendingStepFunctionUpdate struct {
+	Tokens      map[string]StepFunctionUpdate   `json:"tokens"`
+	Current     map[string]StepFunctionResponse `json:"current"`
+	BlockNumber uint64                          `json:"block_number"`
+	Timestamp   uint64                          `json:"timestamp"`
+}
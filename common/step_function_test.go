@@ -0,0 +1,52 @@
+package common
+
+import (
+	"math/big"
+	"testing"
+)
+
+func TestStepFunctionUpdateDiff(t *testing.T) {
+	qty := QuantityStepFunction{
+		XBuy: []*big.Int{big.NewInt(100)}, YBuy: []*big.Int{big.NewInt(-10)},
+		XSell: []*big.Int{big.NewInt(100)}, YSell: []*big.Int{big.NewInt(-10)},
+	}
+	imbalance := ImbalanceStepFunction{
+		XBuy: []*big.Int{big.NewInt(-100), big.NewInt(100)}, YBuy: []*big.Int{big.NewInt(0), big.NewInt(-30)},
+		XSell: []*big.Int{big.NewInt(-100), big.NewInt(100)}, YSell: []*big.Int{big.NewInt(-30), big.NewInt(0)},
+	}
+	current := StepFunctionResponse{
+		QuantityStepResponse: QuantityStepFunction{
+			XBuy: []*big.Int{big.NewInt(100)}, YBuy: []*big.Int{big.NewInt(-10)},
+			XSell: []*big.Int{big.NewInt(100)}, YSell: []*big.Int{big.NewInt(-10)},
+		},
+	}
+	update := StepFunctionUpdate{QtyStepFunction: &qty, ImbalanceStepFunction: &imbalance}
+	if err := update.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	diff := update.Diff(current)
+	if diff.QtyStepFunction != nil || diff.ImbalanceStepFunction == nil {
+		t.Errorf("Expected only imbalance step function in diff, got %+v", diff)
+	}
+
+	invalids := []StepFunctionUpdate{
+		{QtyStepFunction: &QuantityStepFunction{XBuy: []*big.Int{big.NewInt(100)}}},
+		{QtyStepFunction: &QuantityStepFunction{
+			XBuy: []*big.Int{big.NewInt(200), big.NewInt(100)}, YBuy: []*big.Int{big.NewInt(0), big.NewInt(-10)},
+		}},
+		{QtyStepFunction: &QuantityStepFunction{
+			XBuy: []*big.Int{big.NewInt(100), big.NewInt(200)}, YBuy: []*big.Int{big.NewInt(-10), big.NewInt(0)},
+		}},
+		{QtyStepFunction: &QuantityStepFunction{
+			XSell: []*big.Int{big.NewInt(-100)}, YSell: []*big.Int{big.NewInt(0)},
+		}},
+		{ImbalanceStepFunction: &ImbalanceStepFunction{
+			XBuy: []*big.Int{big.NewInt(100)}, YBuy: []*big.Int{big.NewInt(MinStepFunctionBps - 1)},
+		}},
+	}
+	for i, invalid := range invalids {
+		if err := invalid.Validate(); err == nil {
+			t.Errorf("Expected error of invalid update %d", i)
+		}
+	}
+}
@@ -16,6 +16,9 @@ type TokenUpdate struct {
 	PWIEq       PWIEquationTokenV2              `json:"pwis_equation"`
 	TargetQty   TargetQtyV2                     `json:"target_qty"`
 	QuadraticEq RebalanceQuadraticEquation      `json:"rebalance_quadratic"`
+	// Listing is the parameters to list the token on chain, it is only
+	// required for confirming the update with on chain listing.
+	Listing *TokenListingParams `json:"listing,omitempty"`
 }
 
 type TokenFee struct {
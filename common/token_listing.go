@@ -0,0 +1,87 @@
+package common
+
+import (
+	"fmt"
+	"math/big"
+)
+
+const (
+	// TokenListingSubmit lists a token by transactions signed and broadcasted
+	// by the pricing operator, which must be admin of the reserve contracts.
+	TokenListingSubmit = "submit"
+	// TokenListingBundle lists a token by unsigned transactions returned to be
+	// sent by the admin wallet, their hashes are submitted back to core.
+	TokenListingBundle = "bundle"
+
+	// TokenListingUnsigned is the status of a bundle listing which
+	// transactions are not submitted yet.
+	TokenListingUnsigned = "unsigned"
+	// TokenListingPending is the status of a listing which transactions are
+	// not all mined yet.
+	TokenListingPending = "pending"
+	// TokenListingDone is the status of a listing which transactions are all
+	// mined, its token is active.
+	TokenListingDone = "done"
+	// TokenListingFailed is the status of a listing which a transaction
+	// failed to be submitted or mined, its token is left inactive.
+	TokenListingFailed = "failed"
+)
+
+// TokenListingParams are the parameters of an internal token on the pricing
+// contract, required to list it on chain.
+type TokenListingParams struct {
+	MinimalRecordResolution *big.Int              `json:"minimal_record_resolution"`
+	MaxPerBlockImbalance    *big.Int              `json:"max_per_block_imbalance"`
+	MaxTotalImbalance       *big.Int              `json:"max_total_imbalance"`
+	QtyStepFunction         QuantityStepFunction  `json:"qty_step_function"`
+	ImbalanceStepFunction   ImbalanceStepFunction `json:"imbalance_step_function"`
+}
+
+// Validate returns an error if the control info is missing or negative, or
+// a step function is invalid.
+func (self TokenListingParams) Validate() error {
+	for name, value := range map[string]*big.Int{
+		"minimal_record_resolution": self.MinimalRecordResolution,
+		"max_per_block_imbalance":   self.MaxPerBlockImbalance,
+		"max_total_imbalance":       self.MaxTotalImbalance,
+	} {
+		if value == nil || value.Sign() < 0 {
+			return fmt.Errorf("%s must be a non negative number", name)
+		}
+	}
+	if err := self.QtyStepFunction.Validate(); err != nil {
+		return err
+	}
+	return self.ImbalanceStepFunction.Validate()
+}
+
+// TokenListingTx is a transaction of listing a token on chain.
+type TokenListingTx struct {
+	// Method is the contract method the transaction calls.
+	Method   string `json:"method"`
+	To       string `json:"to"`
+	Data     string `json:"data"`
+	GasLimit uint64 `json:"gas_limit"`
+	// Hash and ActivityID are set once the transaction is submitted.
+	Hash       string     `json:"hash,omitempty"`
+	ActivityID ActivityID `json:"activity_id"`
+}
+
+// TokenListing is the on chain listing of a token, by the sequence of
+// transactions to add it to the pricing contract, set its control info and
+// step functions, enable its trade and approve withdrawals to exchange
+// deposit addresses.
+type TokenListing struct {
+	Token     string           `json:"token"`
+	Mode      string           `json:"mode"`
+	Status    string           `json:"status"`
+	Error     string           `json:"error,omitempty"`
+	Txs       []TokenListingTx `json:"txs"`
+	CreatedAt uint64           `json:"created_at"`
+	UpdatedAt uint64           `json:"updated_at"`
+}
+
+// IsFinished returns true if the listing is done or failed.
+func (self TokenListing) IsFinished() bool {
+	return self.Status == TokenListingDone || self.Status == TokenListingFailed
+}
@@ -273,16 +273,24 @@ func (self ActivityRecord) IsExchangePending() bool {
 	case ActionDeposit:
 		return (self.ExchangeStatus == "" || self.ExchangeStatus == ExchangeStatusPending) &&
 			self.MiningStatus != MiningStatusFailed
-	case ActionTrade:
+	case ActionTrade, ActionTransfer:
 		return self.ExchangeStatus == "" || self.ExchangeStatus == ExchangeStatusSubmitted
+	case ActionRouteTrade, ActionExecution:
+		// routed trades and executions are tracked by their orders
+		return false
+	case ActionListToken, ActionSetStepFunction, ActionDisableTrade, ActionEnableTrade:
+		return false
 	}
 	return true
 }
 
 func (self ActivityRecord) IsBlockchainPending() bool {
 	switch self.Action {
-	case ActionWithdraw, ActionDeposit, ActionSetrate:
+	case ActionWithdraw, ActionDeposit, ActionSetrate, ActionListToken, ActionSetStepFunction,
+		ActionDisableTrade, ActionEnableTrade:
 		return (self.MiningStatus == "" || self.MiningStatus == MiningStatusSubmitted) && self.ExchangeStatus != ExchangeStatusFailed
+	case ActionRouteTrade, ActionExecution:
+		return false
 	}
 	return true
 }
@@ -297,12 +305,14 @@ func (self ActivityRecord) IsPending() bool {
 		return (self.ExchangeStatus == "" || self.ExchangeStatus == ExchangeStatusPending ||
 			self.MiningStatus == "" || self.MiningStatus == MiningStatusSubmitted) &&
 			self.MiningStatus != MiningStatusFailed && self.ExchangeStatus != ExchangeStatusFailed
-	case ActionTrade:
+	case ActionTrade, ActionTransfer:
 		return (self.ExchangeStatus == "" || self.ExchangeStatus == ExchangeStatusSubmitted) &&
 			self.ExchangeStatus != ExchangeStatusFailed
-	case ActionSetrate:
+	case ActionSetrate, ActionListToken, ActionSetStepFunction, ActionDisableTrade, ActionEnableTrade:
 		return (self.MiningStatus == "" || self.MiningStatus == MiningStatusSubmitted) &&
 			self.ExchangeStatus != ExchangeStatusFailed
+	case ActionRouteTrade, ActionExecution:
+		return false
 	}
 	return true
 }
@@ -466,6 +476,17 @@ type EBalanceEntry struct {
 	LockedBalance    map[string]float64
 	DepositBalance   map[string]float64
 	Status           bool
+	// SubAccounts are the balances of the sub-accounts of the exchange
+	// account by their names, the balances above are of the master account.
+	SubAccounts map[string]SubAccountBalance `json:",omitempty"`
+}
+
+// SubAccountBalance is the balances of a sub-account of an exchange account.
+type SubAccountBalance struct {
+	Valid            bool
+	Error            string
+	AvailableBalance map[string]float64
+	LockedBalance    map[string]float64
 }
 
 type AllEBalanceResponse struct {
@@ -543,6 +564,9 @@ type TXEntry struct {
 	ExchangeStatus string
 	Amount         float64
 	Timestamp      Timestamp
+	// ReplacedHashes are the hashes of the transactions replaced by Hash, in
+	// the order they were sent.
+	ReplacedHashes []string `json:",omitempty"`
 }
 
 // NewTXEntry creates new instance of TXEntry.
@@ -775,6 +799,9 @@ func NewAllTradeHistory(timestamp Timestamp, data map[ExchangeID]ExchangeTradeHi
 type ExStatus struct {
 	Timestamp uint64 `json:"timestamp"`
 	Status    bool   `json:"status"`
+	// Monitored is true if the exchange is set down by the status monitor,
+	// which sets it up again once its maintenance is over.
+	Monitored bool `json:"monitored,omitempty"`
 }
 
 type ExchangesStatus map[string]ExStatus
@@ -832,6 +859,9 @@ type ExchangeNotiContent struct {
 	ToTime    uint64 `json:"toTime"`
 	IsWarning bool   `json:"isWarning"`
 	Message   string `json:"msg"`
+	// Monitored is true if the notification is recorded by the status
+	// monitor, which closes it once the exchange reports it is over.
+	Monitored bool `json:"monitored,omitempty"`
 }
 
 type ExchangeTokenNoti map[string]ExchangeNotiContent
@@ -14,4 +14,12 @@ const (
 	ActionTrade             = "trade"
 	ActionWithdraw          = "withdraw"
 	ActionSetrate           = "set_rates"
+	ActionCancelOrder       = "cancel_order"
+	ActionTransfer          = "transfer"
+	ActionRouteTrade        = "route_trade"
+	ActionExecution         = "execution"
+	ActionListToken         = "list_token"
+	ActionSetStepFunction   = "set_step_function"
+	ActionDisableTrade      = "disable_trade"
+	ActionEnableTrade       = "enable_trade"
 )
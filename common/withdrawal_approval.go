@@ -0,0 +1,42 @@
+package common
+
+// Statuses of a withdrawal approval.
+const (
+	WithdrawalApprovalPending   = "pending"
+	WithdrawalApprovalConfirmed = "confirmed"
+	WithdrawalApprovalRejected  = "rejected"
+	WithdrawalApprovalExpired   = "expired"
+	// WithdrawalApprovalFailed is the status of a confirmed withdrawal
+	// which is rejected by the exchange.
+	WithdrawalApprovalFailed = "failed"
+)
+
+// WithdrawThresholds maps token IDs to the amount, in token unit, above which
+// a withdrawal has to be confirmed by a second key before being executed.
+type WithdrawThresholds map[string]float64
+
+// WithdrawalApproval is a withdrawal above the threshold of its token. It is
+// kept after being reviewed as the audit trail of who proposed and who
+// confirmed or rejected it.
+type WithdrawalApproval struct {
+	ID       string     `json:"id"`
+	Exchange ExchangeID `json:"exchange"`
+	Token    string     `json:"token"`
+	// Amount is the withdrawal amount in token wei, as a decimal string.
+	Amount     string `json:"amount"`
+	Status     string `json:"status"`
+	ProposedBy string `json:"proposed_by"`
+	ProposedAt uint64 `json:"proposed_at"`
+	ExpiresAt  uint64 `json:"expires_at"`
+	ReviewedBy string `json:"reviewed_by,omitempty"`
+	ReviewedAt uint64 `json:"reviewed_at,omitempty"`
+	// ActivityID is the id of the withdraw activity once it is executed.
+	ActivityID *ActivityID `json:"activity_id,omitempty"`
+	Error      string      `json:"error,omitempty"`
+}
+
+// IsExpired returns true if the withdrawal is still pending at timepoint
+// after its expiry.
+func (self WithdrawalApproval) IsExpired(timepoint uint64) bool {
+	return self.Status == WithdrawalApprovalPending && timepoint > self.ExpiresAt
+}
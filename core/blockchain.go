@@ -24,4 +24,18 @@ type Blockchain interface {
 		nonce *big.Int,
 		gasPrice *big.Int) (*types.Transaction, error)
 	SetRateMinedNonce() (uint64, error)
+
+	// BuildSend and BuildSetRates build and sign the same tx as Send and
+	// SetRates without broadcasting it.
+	BuildSend(
+		token common.Token,
+		amount *big.Int,
+		address ethereum.Address) (*types.Transaction, error)
+	BuildSetRates(
+		tokens []ethereum.Address,
+		buys []*big.Int,
+		sells []*big.Int,
+		block *big.Int,
+		nonce *big.Int,
+		gasPrice *big.Int) (*types.Transaction, error)
 }
@@ -0,0 +1,46 @@
+package core
+
+import (
+	"errors"
+
+	"github.com/KyberNetwork/reserve-data/common"
+	ethereum "github.com/ethereum/go-ethereum/common"
+)
+
+// ContractStateBlockchain is implemented by blockchains inspecting the admin
+// state of the reserve and pricing contracts.
+type ContractStateBlockchain interface {
+	GetContractAdminState(withdrawAddresses map[string]map[string]ethereum.Address) (common.ContractAdminState, error)
+}
+
+// contractWithdrawAddresses returns the deposit addresses of tokens the
+// reserve withdraws to, by token id and exchange.
+func contractWithdrawAddresses(tokens []common.Token) map[string]map[string]ethereum.Address {
+	result := map[string]map[string]ethereum.Address{}
+	for _, token := range tokens {
+		addrs := map[string]ethereum.Address{}
+		for id, exchange := range common.SupportedExchanges {
+			if addr, supported := exchange.Address(token); supported {
+				addrs[string(id)] = addr
+			}
+		}
+		result[token.ID] = addrs
+	}
+	return result
+}
+
+// GetContractAdminState returns the admin state of the reserve and pricing
+// contracts, flagging the mismatches against settings, including the deposit
+// addresses of internal tokens on the supported exchanges which are not
+// approved withdraw addresses of the reserve.
+func (self ReserveCore) GetContractAdminState() (common.ContractAdminState, error) {
+	blockchain, ok := self.blockchain.(ContractStateBlockchain)
+	if !ok {
+		return common.ContractAdminState{}, errors.New("blockchain doesn't support inspecting contract admin state")
+	}
+	tokens, err := self.setting.GetInternalTokens()
+	if err != nil {
+		return common.ContractAdminState{}, err
+	}
+	return blockchain.GetContractAdminState(contractWithdrawAddresses(tokens))
+}
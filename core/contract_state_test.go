@@ -0,0 +1,42 @@
+package core
+
+import (
+	"testing"
+
+	"github.com/KyberNetwork/reserve-data/common"
+	ethereum "github.com/ethereum/go-ethereum/common"
+)
+
+type testContractStateBlockchain struct {
+	testBlockchain
+	withdrawAddresses map[string]map[string]ethereum.Address
+}
+
+func (self *testContractStateBlockchain) GetContractAdminState(withdrawAddresses map[string]map[string]ethereum.Address) (common.ContractAdminState, error) {
+	self.withdrawAddresses = withdrawAddresses
+	return common.ContractAdminState{}, nil
+}
+
+func TestGetContractAdminState(t *testing.T) {
+	exchange := testExchange{}
+	common.SupportedExchanges[exchange.ID()] = exchange
+	defer delete(common.SupportedExchanges, exchange.ID())
+
+	tokens := []common.Token{
+		common.NewToken("ETH", "Ethereum", "0xeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeee", 18, true, true, 0),
+		common.NewToken("KNC", "Kyber-coin", "0x1111111111111111111111111111111111111111", 18, true, true, 0),
+	}
+	blockchain := &testContractStateBlockchain{}
+	core := NewReserveCore(blockchain, testActivityStorage{}, testSetting{tokens: tokens})
+	if _, err := core.GetContractAdminState(); err != nil {
+		t.Fatal(err)
+	}
+	if len(blockchain.withdrawAddresses) != len(tokens) {
+		t.Fatalf("Expected withdraw addresses of %d internal tokens, got %v", len(tokens), blockchain.withdrawAddresses)
+	}
+	for _, token := range tokens {
+		if _, ok := blockchain.withdrawAddresses[token.ID][string(exchange.ID())]; !ok {
+			t.Errorf("Expected withdraw address of %s on %s, got %v", token.ID, exchange.ID(), blockchain.withdrawAddresses[token.ID])
+		}
+	}
+}
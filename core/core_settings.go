@@ -9,4 +9,6 @@ import (
 type Setting interface {
 	GetAddress(settings.AddressName) (ethereum.Address, error)
 	GetInternalTokens() ([]common.Token, error)
+	GetInternalTokenByID(tokenID string) (common.Token, error)
+	GetExchangeNotifications() (common.ExchangeNotifications, error)
 }
@@ -0,0 +1,270 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"strconv"
+
+	"github.com/KyberNetwork/reserve-data/common"
+	"github.com/KyberNetwork/reserve-data/settings"
+	ethereum "github.com/ethereum/go-ethereum/common"
+)
+
+// The DryRun methods run the same checks as their actions and return what the
+// actions would have sent, without sending it or recording any activity.
+// Transactions are built, gas estimated and signed but not broadcasted, only
+// their hash and unsigned fields are returned. Exchange requests are built
+// unsigned, so nothing in a dry run result can be broadcasted or replayed.
+
+// RequestBuilderExchange is implemented by exchanges which can build the
+// unsigned requests of their trades and withdrawals without sending them.
+type RequestBuilderExchange interface {
+	BuildTradeRequest(tradeType string, base, quote common.Token, rate, amount float64) (common.DryRunRequest, error)
+	BuildWithdrawRequest(token common.Token, amount *big.Int, address ethereum.Address) (common.DryRunRequest, error)
+}
+
+// requestBuilder returns the exchange as RequestBuilderExchange, dry runs
+// of exchange actions fail if it can't build their requests.
+func requestBuilder(exchange common.Exchange) (RequestBuilderExchange, error) {
+	builder, ok := exchange.(RequestBuilderExchange)
+	if !ok {
+		return nil, fmt.Errorf("Exchange %s doesn't support building requests in dry run", exchange.ID())
+	}
+	return builder, nil
+}
+
+// DryRunTrade returns the order Trade would place, with its unsigned request.
+func (self ReserveCore) DryRunTrade(
+	exchange common.Exchange,
+	tradeType string,
+	base common.Token,
+	quote common.Token,
+	rate float64,
+	amount float64) (common.DryRunResult, error) {
+	if err := sanityCheckTrading(exchange, base, quote, rate, amount); err != nil {
+		return common.DryRunResult{}, err
+	}
+	builder, err := requestBuilder(exchange)
+	if err != nil {
+		return common.DryRunResult{}, err
+	}
+	request, err := builder.BuildTradeRequest(tradeType, base, quote, rate, amount)
+	if err != nil {
+		return common.DryRunResult{}, err
+	}
+	return common.DryRunResult{
+		Action:      common.ActionTrade,
+		Destination: string(exchange.ID()),
+		Params: map[string]interface{}{
+			"type":   tradeType,
+			"base":   base.ID,
+			"quote":  quote.ID,
+			"rate":   rate,
+			"amount": strconv.FormatFloat(amount, 'f', -1, 64),
+		},
+		Request: &request,
+	}, nil
+}
+
+// DryRunDeposit returns the deposit tx Deposit would broadcast.
+func (self ReserveCore) DryRunDeposit(
+	exchange common.Exchange,
+	token common.Token,
+	amount *big.Int) (common.DryRunResult, error) {
+	address, supported := exchange.Address(token)
+	if !supported {
+		return common.DryRunResult{}, fmt.Errorf("Exchange %s doesn't support token %s", exchange.ID(), token.ID)
+	}
+	if err := self.ensureNotSuspended(exchange, token, common.SuspendDeposit, common.GetTimepoint()); err != nil {
+		return common.DryRunResult{}, err
+	}
+	ok, err := self.activityStorage.HasPendingDeposit(token, exchange)
+	if err != nil {
+		return common.DryRunResult{}, err
+	}
+	if ok {
+		return common.DryRunResult{}, fmt.Errorf("There is a pending %s deposit to %s currently, please try again", token.ID, exchange.ID())
+	}
+	if err = sanityCheckAmount(exchange, token, amount); err != nil {
+		return common.DryRunResult{}, err
+	}
+	tx, err := self.blockchain.BuildSend(token, amount, address)
+	if err != nil {
+		return common.DryRunResult{}, err
+	}
+	dryRunTx, err := common.NewDryRunTx(tx)
+	if err != nil {
+		return common.DryRunResult{}, err
+	}
+	return common.DryRunResult{
+		Action:      common.ActionDeposit,
+		Destination: string(exchange.ID()),
+		Params: map[string]interface{}{
+			"token":   token.ID,
+			"amount":  strconv.FormatFloat(common.BigToFloat(amount, token.Decimals), 'f', -1, 64),
+			"address": address.Hex(),
+		},
+		Tx: dryRunTx,
+	}, nil
+}
+
+// DryRunWithdraw returns the withdrawal Withdraw would request, or propose
+// for approval if it is above the withdraw threshold of the token, with its
+// unsigned request.
+func (self ReserveCore) DryRunWithdraw(
+	exchange common.Exchange,
+	token common.Token,
+	amount *big.Int) (common.DryRunResult, error) {
+	if _, supported := exchange.Address(token); !supported {
+		return common.DryRunResult{}, fmt.Errorf("Exchange %s doesn't support token %s", exchange.ID(), token.ID)
+	}
+	if err := self.ensureNotSuspended(exchange, token, common.SuspendWithdraw, common.GetTimepoint()); err != nil {
+		return common.DryRunResult{}, err
+	}
+	if err := sanityCheckAmount(exchange, token, amount); err != nil {
+		return common.DryRunResult{}, err
+	}
+	reserveAddr, err := self.setting.GetAddress(settings.Reserve)
+	if err != nil {
+		return common.DryRunResult{}, err
+	}
+	required, err := self.requiresWithdrawalApproval(token, amount)
+	if err != nil {
+		return common.DryRunResult{}, err
+	}
+	builder, err := requestBuilder(exchange)
+	if err != nil {
+		return common.DryRunResult{}, err
+	}
+	request, err := builder.BuildWithdrawRequest(token, amount, reserveAddr)
+	if err != nil {
+		return common.DryRunResult{}, err
+	}
+	return common.DryRunResult{
+		Action:      common.ActionWithdraw,
+		Destination: string(exchange.ID()),
+		Params: map[string]interface{}{
+			"token":   token.ID,
+			"amount":  strconv.FormatFloat(common.BigToFloat(amount, token.Decimals), 'f', -1, 64),
+			"address": reserveAddr.Hex(),
+		},
+		Request:          &request,
+		RequiresApproval: required,
+	}, nil
+}
+
+// DryRunTransfer returns the transfer Transfer would request.
+func (self ReserveCore) DryRunTransfer(
+	exchange common.Exchange,
+	token common.Token,
+	amount *big.Int,
+	from, to string) (common.DryRunResult, error) {
+	if _, err := checkTransfer(exchange, amount, from, to); err != nil {
+		return common.DryRunResult{}, err
+	}
+	return common.DryRunResult{
+		Action:      common.ActionTransfer,
+		Destination: string(exchange.ID()),
+		Params: map[string]interface{}{
+			"token":  token.ID,
+			"amount": strconv.FormatFloat(common.BigToFloat(amount, token.Decimals), 'f', -1, 64),
+			"from":   from,
+			"to":     to,
+		},
+	}, nil
+}
+
+// DryRunRouteTrade returns the orders RouteTrade would place.
+func (self ReserveCore) DryRunRouteTrade(
+	tradeType string,
+	base, quote common.Token,
+	amount, maxSlippage float64,
+	exchanges []common.Exchange) (common.DryRunResult, error) {
+	orders, err := self.planRouteTrade(tradeType, base, quote, amount, maxSlippage, exchanges)
+	if err != nil {
+		return common.DryRunResult{}, err
+	}
+	var params []map[string]interface{}
+	for _, order := range orders {
+		params = append(params, map[string]interface{}{
+			"exchange": order.exchange.ID(),
+			"rate":     order.rate,
+			"amount":   strconv.FormatFloat(order.amount, 'f', -1, 64),
+		})
+	}
+	return common.DryRunResult{
+		Action: common.ActionRouteTrade,
+		Params: map[string]interface{}{
+			"type":         tradeType,
+			"base":         base.ID,
+			"quote":        quote.ID,
+			"amount":       strconv.FormatFloat(amount, 'f', -1, 64),
+			"max_slippage": maxSlippage,
+			"orders":       params,
+		},
+	}, nil
+}
+
+// DryRunCancelOrder returns the order CancelOrder would cancel.
+func (self ReserveCore) DryRunCancelOrder(id common.ActivityID, exchange common.Exchange) (common.DryRunResult, error) {
+	activity, err := self.activityStorage.GetActivity(id)
+	if err != nil {
+		return common.DryRunResult{}, err
+	}
+	if activity.Action != common.ActionTrade {
+		return common.DryRunResult{}, errors.New("This is not an order activity so cannot cancel")
+	}
+	base, ok := activity.Params["base"].(string)
+	if !ok {
+		return common.DryRunResult{}, fmt.Errorf("cannot convert params base (value: %v) to tokenID (type string)", activity.Params["base"])
+	}
+	quote, ok := activity.Params["quote"].(string)
+	if !ok {
+		return common.DryRunResult{}, fmt.Errorf("cannot convert params quote (value: %v) to tokenID (type string)", activity.Params["quote"])
+	}
+	return common.DryRunResult{
+		Action:      common.ActionCancelOrder,
+		Destination: string(exchange.ID()),
+		Params: map[string]interface{}{
+			"order_id": id.EID,
+			"base":     base,
+			"quote":    quote,
+		},
+	}, nil
+}
+
+// DryRunSetRates returns the set rates tx SetRates would broadcast, which
+// replaces the pending set rates tx if there is one.
+func (self ReserveCore) DryRunSetRates(
+	tokens []common.Token,
+	buys []*big.Int,
+	sells []*big.Int,
+	block *big.Int,
+	afpMids []*big.Int) (common.DryRunResult, error) {
+	tokenIDs := []string{}
+	for _, token := range tokens {
+		tokenIDs = append(tokenIDs, token.ID)
+	}
+	params := map[string]interface{}{
+		"tokens": tokenIDs,
+		"buys":   buys,
+		"sells":  sells,
+		"block":  big.NewInt(0).Set(block),
+		"afpMid": afpMids,
+	}
+	tx, err := self.setRateResult(self.blockchain.BuildSetRates, tokens, buys, sells, afpMids, block)
+	if err != nil {
+		return common.DryRunResult{}, err
+	}
+	dryRunTx, err := common.NewDryRunTx(tx)
+	if err != nil {
+		return common.DryRunResult{}, err
+	}
+	return common.DryRunResult{
+		Action:      common.ActionSetrate,
+		Destination: "blockchain",
+		Params:      params,
+		Tx:          dryRunTx,
+	}, nil
+}
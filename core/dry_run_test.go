@@ -0,0 +1,110 @@
+package core
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/KyberNetwork/reserve-data/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+type recordingActivityStorage struct {
+	testActivityStorage
+	records int
+}
+
+func (self *recordingActivityStorage) Record(
+	action string,
+	id common.ActivityID,
+	destination string,
+	params map[string]interface{},
+	result map[string]interface{},
+	estatus string,
+	mstatus string,
+	timepoint uint64) error {
+	self.records++
+	return nil
+}
+
+func TestDryRunDeposit(t *testing.T) {
+	core := getTestCore(true)
+	storage := &recordingActivityStorage{testActivityStorage: testActivityStorage{PendingDeposit: true}}
+	core.activityStorage = storage
+
+	omg := common.NewToken("OMG", "omise-go", "0x1111111111111111111111111111111111111111", 18, true, true, 0)
+	if _, err := core.DryRunDeposit(testExchange{}, omg, big.NewInt(10)); err == nil {
+		t.Error("expected dry run to fail when there is another pending deposit")
+	}
+
+	knc := common.NewToken("KNC", "Kyber-coin", "0x1111111111111111111111111111111111111111", 18, true, true, 0)
+	result, err := core.DryRunDeposit(testExchange{}, knc, big.NewInt(10))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Action != common.ActionDeposit || result.Tx == nil || result.Tx.GasLimit != 300000 || result.Tx.UnsignedTx == "" {
+		t.Errorf("expected deposit tx in dry run result, got %+v", result)
+	}
+	var unsigned types.Transaction
+	if err = rlp.DecodeBytes(hexutil.MustDecode(result.Tx.UnsignedTx), &unsigned); err != nil {
+		t.Fatal(err)
+	}
+	if _, r, s := unsigned.RawSignatureValues(); r.Sign() != 0 || s.Sign() != 0 || unsigned.Gas() != 300000 {
+		t.Errorf("expected unsigned deposit tx, got %+v", unsigned)
+	}
+	if storage.records != 0 {
+		t.Errorf("expected no activity is recorded in dry run, got %d", storage.records)
+	}
+}
+
+func TestDryRunSetRates(t *testing.T) {
+	core := getTestCore(false)
+	storage := &recordingActivityStorage{}
+	core.activityStorage = storage
+
+	knc := common.NewToken("KNC", "Kyber-coin", "0x1111111111111111111111111111111111111111", 18, true, true, 0)
+	// rate is zero on only one side
+	_, err := core.DryRunSetRates(
+		[]common.Token{knc},
+		[]*big.Int{big.NewInt(0)}, []*big.Int{big.NewInt(100)},
+		big.NewInt(1), []*big.Int{big.NewInt(100)},
+	)
+	if err == nil {
+		t.Error("expected dry run to fail sanity check on rates")
+	}
+
+	result, err := core.DryRunSetRates(
+		[]common.Token{knc},
+		[]*big.Int{big.NewInt(0)}, []*big.Int{big.NewInt(0)},
+		big.NewInt(1), []*big.Int{big.NewInt(0)},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Action != common.ActionSetrate || result.Tx == nil {
+		t.Errorf("expected set rates tx in dry run result, got %+v", result)
+	}
+	if storage.records != 0 {
+		t.Errorf("expected no activity is recorded in dry run, got %d", storage.records)
+	}
+}
+
+func TestDryRunTrade(t *testing.T) {
+	core := getTestCore(false)
+	storage := &recordingActivityStorage{}
+	core.activityStorage = storage
+
+	knc := common.NewToken("KNC", "Kyber-coin", "0x1111111111111111111111111111111111111111", 18, true, true, 0)
+	eth := common.NewToken("ETH", "Ethereum", "0xeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeee", 18, true, true, 0)
+	result, err := core.DryRunTrade(testExchange{}, "buy", knc, eth, 0.001, 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Request == nil || result.Request.URL != "https://bittrex.com/trade" {
+		t.Errorf("expected trade request in dry run result, got %+v", result)
+	}
+	if storage.records != 0 {
+		t.Errorf("expected no activity is recorded in dry run, got %d", storage.records)
+	}
+}
@@ -0,0 +1,175 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"strconv"
+
+	"github.com/KyberNetwork/reserve-data/common"
+	ethereum "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+const (
+	// emergencyGasPriceMultiplier and minEmergencyGasPrice (gwei) set the gas
+	// price of disabling trade from the recommended one, high enough to be
+	// mined before trades of users.
+	emergencyGasPriceMultiplier float64 = 3
+	minEmergencyGasPrice        float64 = 2 * highBoundGasPrice
+)
+
+// EmergencyBlockchain is implemented by blockchains disabling trade with the
+// alerter operator and building the transactions enabling it again, which
+// the emergency kill switch requires.
+type EmergencyBlockchain interface {
+	AlerterMinedNonce() (uint64, error)
+	DisableTrade(nonce *big.Int, gasPrice *big.Int) (*types.Transaction, error)
+	DisableTokenTrade(token ethereum.Address, nonce *big.Int, gasPrice *big.Int) (*types.Transaction, error)
+	BuildEnableTradeTxs(tokens []common.Token) ([]common.EmergencyTx, error)
+}
+
+func (self ReserveCore) emergencyBlockchain() (EmergencyBlockchain, error) {
+	blockchain, ok := self.blockchain.(EmergencyBlockchain)
+	if !ok {
+		return nil, errors.New("blockchain doesn't support disabling trade")
+	}
+	return blockchain, nil
+}
+
+// emergencyGasPrice returns the gas price of disabling trade given the
+// recommended one in gwei, which is 0 if unknown.
+func emergencyGasPrice(recommended float64) *big.Int {
+	price := recommended * emergencyGasPriceMultiplier
+	if price < minEmergencyGasPrice {
+		price = minEmergencyGasPrice
+	}
+	return common.GweiToWei(price)
+}
+
+// DisableTrade disables trade on the reserve, and of tokens on the pricing
+// contract, by the alerter operator. If gasPrice is nil, an emergency gas
+// price is used. The transactions take nonces from the mined nonce of the
+// alerter, so calling it again with a higher gas price replaces the pending
+// ones. Each transaction is recorded as a disable trade activity, a failed
+// transaction doesn't stop sending the others.
+func (self ReserveCore) DisableTrade(tokens []common.Token, gasPrice *big.Int, timepoint uint64) ([]common.EmergencyTx, error) {
+	blockchain, err := self.emergencyBlockchain()
+	if err != nil {
+		return nil, err
+	}
+	minedNonce, err := blockchain.AlerterMinedNonce()
+	if err != nil {
+		return nil, err
+	}
+	if gasPrice == nil {
+		gasPrice = emergencyGasPrice(self.blockchain.StandardGasPrice())
+	}
+	var (
+		result = []common.EmergencyTx{}
+		failed = 0
+	)
+	nonce := minedNonce
+	// record records a sent transaction, the next one takes the following
+	// nonce only if it is broadcasted
+	record := func(emergencyTx common.EmergencyTx, tx *types.Transaction, sErr error) error {
+		if sErr != nil {
+			failed++
+		} else {
+			nonce++
+		}
+		if rErr := self.recordEmergencyTx(common.ActionDisableTrade, &emergencyTx, tx, sErr, timepoint); rErr != nil {
+			return rErr
+		}
+		result = append(result, emergencyTx)
+		return nil
+	}
+	tx, err := blockchain.DisableTrade(new(big.Int).SetUint64(nonce), gasPrice)
+	if err = record(common.EmergencyTx{Method: "disableTrade"}, tx, err); err != nil {
+		return result, err
+	}
+	for _, token := range tokens {
+		tx, err = blockchain.DisableTokenTrade(ethereum.HexToAddress(token.Address), new(big.Int).SetUint64(nonce), gasPrice)
+		if err = record(common.EmergencyTx{Method: "disableTokenTrade", Token: token.ID}, tx, err); err != nil {
+			return result, err
+		}
+	}
+	if failed > 0 {
+		return result, fmt.Errorf("%d of %d disable trade transactions failed", failed, len(result))
+	}
+	return result, nil
+}
+
+// EnableTrade returns the unsigned transactions enabling trade on the
+// reserve, and of tokens on the pricing contract. Only the admin of the
+// contracts can enable trade, so they must be sent by the admin wallet, then
+// submitted by SubmitEnableTradeTxs to be tracked.
+func (self ReserveCore) EnableTrade(tokens []common.Token) ([]common.EmergencyTx, error) {
+	blockchain, err := self.emergencyBlockchain()
+	if err != nil {
+		return nil, err
+	}
+	return blockchain.BuildEnableTradeTxs(tokens)
+}
+
+// SubmitEnableTradeTxs records the transactions enabling trade sent by the
+// admin wallet as enable trade activities, so they are tracked until mined.
+func (self ReserveCore) SubmitEnableTradeTxs(txs []common.EmergencyTx, timepoint uint64) ([]common.EmergencyTx, error) {
+	for _, tx := range txs {
+		if tx.Method != "enableTrade" && tx.Method != "enableTokenTrade" {
+			return nil, fmt.Errorf("%s is not a method enabling trade", tx.Method)
+		}
+		if !isTxHash(tx.Hash) {
+			return nil, fmt.Errorf("%s is not a valid tx hash", tx.Hash)
+		}
+	}
+	result := []common.EmergencyTx{}
+	for _, tx := range txs {
+		tx.Hash = ethereum.HexToHash(tx.Hash).Hex()
+		if err := self.recordEmergencyTx(common.ActionEnableTrade, &tx, nil, nil, timepoint); err != nil {
+			return result, err
+		}
+		result = append(result, tx)
+	}
+	return result, nil
+}
+
+// recordEmergencyTx records a transaction disabling or enabling trade as an
+// activity of action. The hash of emergencyTx is set to the one of tx if it
+// is sent by core.
+func (self ReserveCore) recordEmergencyTx(action string, emergencyTx *common.EmergencyTx, tx *types.Transaction, err error, timepoint uint64) error {
+	result := map[string]interface{}{
+		"error": common.ErrorToString(err),
+	}
+	if tx != nil {
+		emergencyTx.Hash = tx.Hash().Hex()
+		if tx.To() != nil {
+			emergencyTx.To = tx.To().Hex()
+		}
+		result["nonce"] = strconv.FormatUint(tx.Nonce(), 10)
+		result["gasPrice"] = tx.GasPrice().Text(10)
+	}
+	if emergencyTx.Hash == "" {
+		emergencyTx.Hash = ethereum.Hash{}.Hex()
+	}
+	miningStatus := common.MiningStatusSubmitted
+	if err != nil {
+		emergencyTx.Error = err.Error()
+		miningStatus = common.MiningStatusFailed
+	}
+	result["tx"] = emergencyTx.Hash
+	emergencyTx.ActivityID = timebasedID(emergencyTx.Hash)
+	return self.activityStorage.Record(
+		action,
+		emergencyTx.ActivityID,
+		"blockchain",
+		map[string]interface{}{
+			"method": emergencyTx.Method,
+			"token":  emergencyTx.Token,
+		},
+		result,
+		"",
+		miningStatus,
+		timepoint,
+	)
+}
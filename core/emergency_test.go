@@ -0,0 +1,102 @@
+package core
+
+import (
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/KyberNetwork/reserve-data/common"
+	ethereum "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+type testEmergencyBlockchain struct {
+	testBlockchain
+	minedNonce uint64
+	failToken  string
+	nonces     []uint64
+}
+
+func (self *testEmergencyBlockchain) AlerterMinedNonce() (uint64, error) {
+	return self.minedNonce, nil
+}
+
+func (self *testEmergencyBlockchain) DisableTrade(nonce *big.Int, gasPrice *big.Int) (*types.Transaction, error) {
+	self.nonces = append(self.nonces, nonce.Uint64())
+	return types.NewTransaction(nonce.Uint64(), ethereum.HexToAddress("0x2222222222222222222222222222222222222222"), nil, 100000, gasPrice, nil), nil
+}
+
+func (self *testEmergencyBlockchain) DisableTokenTrade(token ethereum.Address, nonce *big.Int, gasPrice *big.Int) (*types.Transaction, error) {
+	if token == ethereum.HexToAddress(self.failToken) {
+		return nil, errors.New("broadcast failed")
+	}
+	self.nonces = append(self.nonces, nonce.Uint64())
+	return types.NewTransaction(nonce.Uint64(), ethereum.HexToAddress("0x3333333333333333333333333333333333333333"), nil, 100000, gasPrice, nil), nil
+}
+
+func (self *testEmergencyBlockchain) BuildEnableTradeTxs(tokens []common.Token) ([]common.EmergencyTx, error) {
+	return nil, nil
+}
+
+func TestEmergencyGasPrice(t *testing.T) {
+	if price := emergencyGasPrice(0); price.Cmp(common.GweiToWei(minEmergencyGasPrice)) != 0 {
+		t.Errorf("Expected minimum emergency gas price when recommended one is unknown, got %s", price)
+	}
+	if price := emergencyGasPrice(200); price.Cmp(common.GweiToWei(600)) != 0 {
+		t.Errorf("Expected 3 times the recommended gas price, got %s", price)
+	}
+}
+
+func TestDisableTrade(t *testing.T) {
+	activityStorage := &testOrderActivityStorage{records: map[common.ActivityID]common.ActivityRecord{}}
+	blockchain := &testEmergencyBlockchain{minedNonce: 5, failToken: "0x1111111111111111111111111111111111111111"}
+	core := NewReserveCore(blockchain, activityStorage, testSetting{})
+	knc := common.NewToken("KNC", "Kyber-coin", "0x1111111111111111111111111111111111111111", 18, true, true, 0)
+	omg := common.NewToken("OMG", "OmiseGo", "0x4444444444444444444444444444444444444444", 18, true, true, 0)
+
+	txs, err := core.DisableTrade([]common.Token{knc, omg}, nil, 1)
+	if err == nil {
+		t.Fatal("Expected error of failed disable token trade tx")
+	}
+	if len(txs) != 3 {
+		t.Fatalf("Expected 3 transactions, got %d", len(txs))
+	}
+	// the failed transaction doesn't take a nonce
+	if len(blockchain.nonces) != 2 || blockchain.nonces[0] != 5 || blockchain.nonces[1] != 6 {
+		t.Errorf("Expected nonces 5 and 6, got %v", blockchain.nonces)
+	}
+	for _, tx := range txs {
+		activity := activityStorage.records[tx.ActivityID]
+		if activity.Action != common.ActionDisableTrade {
+			t.Errorf("Expected disable trade activity, got %+v", activity)
+		}
+		expected := common.MiningStatusSubmitted
+		if tx.Token == knc.ID {
+			expected = common.MiningStatusFailed
+		}
+		if activity.MiningStatus != expected {
+			t.Errorf("Expected %s activity of %s, got %s", expected, tx.Method, activity.MiningStatus)
+		}
+	}
+}
+
+func TestSubmitEnableTradeTxs(t *testing.T) {
+	activityStorage := &testOrderActivityStorage{records: map[common.ActivityID]common.ActivityRecord{}}
+	core := NewReserveCore(&testEmergencyBlockchain{}, activityStorage, testSetting{})
+	hash := "0x2c1e4fb0b6e4c7b1ad5e0e1c9d3f8a7b6c5d4e3f2a1b0c9d8e7f6a5b4c3d2e1f"
+
+	if _, err := core.SubmitEnableTradeTxs([]common.EmergencyTx{{Method: "disableTrade", Hash: hash}}, 1); err == nil {
+		t.Error("Expected error of method not enabling trade")
+	}
+	if _, err := core.SubmitEnableTradeTxs([]common.EmergencyTx{{Method: "enableTrade", Hash: "0x1234"}}, 1); err == nil {
+		t.Error("Expected error of invalid tx hash")
+	}
+	txs, err := core.SubmitEnableTradeTxs([]common.EmergencyTx{{Method: "enableTrade", Hash: hash}}, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	activity := activityStorage.records[txs[0].ActivityID]
+	if activity.Action != common.ActionEnableTrade || activity.MiningStatus != common.MiningStatusSubmitted {
+		t.Errorf("Expected submitted enable trade activity, got %+v", activity)
+	}
+}
@@ -0,0 +1,472 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/KyberNetwork/reserve-data/common"
+)
+
+// DefaultExecutionInterval is the default interval executions are checked
+// for fills and new slices at.
+const DefaultExecutionInterval = 10 * time.Second
+
+// defaultRepriceInterval is the reprice interval of iceberg executions not
+// given one, in milliseconds.
+const defaultRepriceInterval = 60 * 1000
+
+const (
+	// maxExecutionSlices is the maximum number of slices of a TWAP
+	// execution.
+	maxExecutionSlices = 1000
+	// minSliceInterval is the minimum duration of a TWAP slice, in
+	// milliseconds.
+	minSliceInterval = 1000
+	// maxSliceErrors is the number of consecutive errors checking or
+	// cancelling the open slice after which the execution fails.
+	maxSliceErrors = 10
+)
+
+// ExecutionStorage is the interface contains all database operations of
+// executions.
+type ExecutionStorage interface {
+	// StoreExecution stores the execution, replacing the stored one of the
+	// same id.
+	StoreExecution(execution common.Execution) error
+	GetExecution(id common.ActivityID) (common.Execution, error)
+	// GetRunningExecutions returns executions of running status, ordered by
+	// creation time.
+	GetRunningExecutions() ([]common.Execution, error)
+}
+
+// OrderFillExchange is implemented by exchanges reporting the filled amount
+// of their orders, which executions require.
+type OrderFillExchange interface {
+	OrderFill(id, base, quote string) (done, remaining float64, finished bool, err error)
+}
+
+// WithExecutionEngine enables executing large orders in slices by a
+// strategy. The state of executions is kept in storage, running executions
+// are checked every interval once the engine is run.
+func WithExecutionEngine(storage ExecutionStorage, interval time.Duration) ReserveCoreOption {
+	return func(self *ReserveCore) {
+		self.executionStorage = storage
+		self.executionInterval = interval
+		self.executionMu = &sync.Mutex{}
+	}
+}
+
+var errExecutionDisabled = errors.New("execution engine is not enabled")
+
+func checkExecution(
+	exchange common.Exchange,
+	tradeType string,
+	base, quote common.Token,
+	rate, amount float64,
+	strategy common.ExecutionStrategy) (common.ExecutionStrategy, error) {
+	if _, ok := exchange.(OrderFillExchange); !ok {
+		return strategy, fmt.Errorf("exchange %s doesn't report order fills", exchange.ID())
+	}
+	if tradeType != "buy" && tradeType != "sell" {
+		return strategy, fmt.Errorf("invalid trade type %s", tradeType)
+	}
+	if rate <= 0 || amount <= 0 {
+		return strategy, errors.New("rate and amount must be positive")
+	}
+	switch strategy.Name {
+	case common.ExecutionTWAP:
+		if strategy.Duration == 0 || strategy.Slices <= 0 {
+			return strategy, errors.New("TWAP requires a duration and a number of slices")
+		}
+		if strategy.Slices > maxExecutionSlices {
+			return strategy, fmt.Errorf("TWAP allows at most %d slices", maxExecutionSlices)
+		}
+		strategy.RepriceInterval = strategy.Duration / uint64(strategy.Slices)
+		if strategy.RepriceInterval < minSliceInterval {
+			return strategy, fmt.Errorf("TWAP slices must be at least %d milliseconds apart", minSliceInterval)
+		}
+		strategy.VisibleAmount = 0
+	case common.ExecutionIceberg:
+		if strategy.VisibleAmount <= 0 {
+			return strategy, errors.New("iceberg requires a positive visible amount")
+		}
+		if strategy.RepriceInterval == 0 {
+			strategy.RepriceInterval = defaultRepriceInterval
+		}
+		strategy.Duration, strategy.Slices = 0, 0
+	default:
+		return strategy, fmt.Errorf("execution strategy %s is not supported", strategy.Name)
+	}
+	return strategy, nil
+}
+
+// StartExecution starts executing a trade of amount of base, at rate or
+// better, in slices by the strategy. Each slice is placed with Trade at the
+// best price of the order book capped by rate, rounded to the precision of
+// the exchange and at least its minimums. Unfilled slices are cancelled and
+// placed again every reprice interval. The progress of the execution is
+// recorded as an execution activity.
+func (self ReserveCore) StartExecution(
+	exchange common.Exchange,
+	tradeType string,
+	base, quote common.Token,
+	rate, amount float64,
+	strategy common.ExecutionStrategy,
+	timepoint uint64) (common.Execution, error) {
+	if self.executionStorage == nil {
+		return common.Execution{}, errExecutionDisabled
+	}
+	strategy, err := checkExecution(exchange, tradeType, base, quote, rate, amount, strategy)
+	if err != nil {
+		return common.Execution{}, err
+	}
+	execution := common.Execution{
+		ID:        timebasedID(string(exchange.ID()) + "|" + strategy.Name),
+		Exchange:  exchange.ID(),
+		Type:      tradeType,
+		Base:      base.ID,
+		Quote:     quote.ID,
+		Rate:      rate,
+		Amount:    amount,
+		Strategy:  strategy,
+		Status:    common.ExecutionRunning,
+		CreatedAt: timepoint,
+		UpdatedAt: timepoint,
+	}
+	self.executionMu.Lock()
+	defer self.executionMu.Unlock()
+	self.stepExecution(&execution, exchange, base, quote, timepoint)
+	if err = self.storeExecution(execution); err != nil {
+		return execution, err
+	}
+	return execution, nil
+}
+
+// CancelExecution stops a running execution, cancelling its open slice.
+func (self ReserveCore) CancelExecution(id common.ActivityID) (common.Execution, error) {
+	if self.executionStorage == nil {
+		return common.Execution{}, errExecutionDisabled
+	}
+	self.executionMu.Lock()
+	defer self.executionMu.Unlock()
+	execution, err := self.executionStorage.GetExecution(id)
+	if err != nil {
+		return execution, err
+	}
+	if execution.Status != common.ExecutionRunning {
+		return execution, fmt.Errorf("execution is %s already", execution.Status)
+	}
+	exchange, err := common.GetExchange(string(execution.Exchange))
+	if err != nil {
+		return execution, err
+	}
+	if slice := execution.OpenSlice(); slice != nil {
+		if err = self.closeSlice(&execution, slice, exchange); err != nil {
+			return execution, err
+		}
+	}
+	execution.Status = common.ExecutionCancelled
+	execution.UpdatedAt = common.GetTimepoint()
+	return execution, self.storeExecution(execution)
+}
+
+// GetExecution returns the execution of the id.
+func (self ReserveCore) GetExecution(id common.ActivityID) (common.Execution, error) {
+	if self.executionStorage == nil {
+		return common.Execution{}, errExecutionDisabled
+	}
+	return self.executionStorage.GetExecution(id)
+}
+
+// RunExecutionEngine checks running executions every execution interval in
+// background, including the ones running before a restart.
+func (self ReserveCore) RunExecutionEngine() error {
+	if self.executionStorage == nil {
+		return nil
+	}
+	ticker := time.NewTicker(self.executionInterval)
+	go func() {
+		for range ticker.C {
+			self.StepExecutions(common.GetTimepoint())
+		}
+	}()
+	return nil
+}
+
+// StepExecutions updates the fills of running executions and places their
+// next slices.
+func (self ReserveCore) StepExecutions(timepoint uint64) {
+	if self.executionStorage == nil {
+		return
+	}
+	self.executionMu.Lock()
+	defer self.executionMu.Unlock()
+	executions, err := self.executionStorage.GetRunningExecutions()
+	if err != nil {
+		log.Printf("Execution: cannot get running executions: %s", err)
+		return
+	}
+	for _, execution := range executions {
+		exchange, err := common.GetExchange(string(execution.Exchange))
+		if err != nil {
+			log.Printf("Execution %s: %s", execution.ID, err)
+			continue
+		}
+		base, err := self.setting.GetInternalTokenByID(execution.Base)
+		if err != nil {
+			log.Printf("Execution %s: %s", execution.ID, err)
+			continue
+		}
+		quote, err := self.setting.GetInternalTokenByID(execution.Quote)
+		if err != nil {
+			log.Printf("Execution %s: %s", execution.ID, err)
+			continue
+		}
+		self.stepExecution(&execution, exchange, base, quote, timepoint)
+		if err = self.storeExecution(execution); err != nil {
+			log.Printf("Execution %s: cannot store execution: %s", execution.ID, err)
+		}
+	}
+}
+
+// stepExecution updates the open slice of the execution, cancelling it if it
+// is not filled within the reprice interval, then places the next slice if
+// there is no open one.
+func (self ReserveCore) stepExecution(
+	execution *common.Execution,
+	exchange common.Exchange,
+	base, quote common.Token,
+	timepoint uint64) {
+	execution.UpdatedAt = timepoint
+	if slice := execution.OpenSlice(); slice != nil {
+		if err := self.updateSlice(execution, slice, exchange); err != nil {
+			log.Printf("Execution %s: cannot get fill of slice %s: %s", execution.ID, slice.ID, err)
+			sliceError(execution, slice, err)
+			return
+		}
+		if !slice.Finished && timepoint >= slice.PlacedAt+execution.Strategy.RepriceInterval {
+			if err := self.closeSlice(execution, slice, exchange); err != nil {
+				log.Printf("Execution %s: cannot cancel slice %s: %s", execution.ID, slice.ID, err)
+				sliceError(execution, slice, err)
+				return
+			}
+		}
+		slice.Errors = 0
+		if !slice.Finished {
+			return
+		}
+	}
+	if execution.Remaining() < routeEpsilon {
+		execution.Status = common.ExecutionDone
+		return
+	}
+
+	pair := makeTokenPair(base, quote)
+	info, err := exchange.GetExchangeInfo(pair.PairID())
+	if err != nil {
+		log.Printf("Execution %s: cannot get exchange info: %s", execution.ID, err)
+		return
+	}
+	if roundDown(execution.Remaining(), info.Precision.Amount) <= 0 {
+		execution.Status = common.ExecutionDone
+		return
+	}
+	rate := self.sliceRate(*execution, base, quote, info)
+	amount, err := sliceAmount(*execution, rate, info, timepoint)
+	if err != nil {
+		execution.Status = common.ExecutionDone
+		execution.Error = err.Error()
+		return
+	}
+	if amount == 0 {
+		// waiting for the next TWAP slice
+		return
+	}
+	id, done, _, finished, err := self.Trade(exchange, execution.Type, base, quote, rate, amount, timepoint)
+	if err != nil {
+		execution.Status = common.ExecutionFailed
+		execution.Error = err.Error()
+		return
+	}
+	execution.Slices = append(execution.Slices, common.ExecutionSlice{
+		ID:       id,
+		Rate:     rate,
+		Amount:   amount,
+		Done:     done,
+		PlacedAt: timepoint,
+		Finished: finished,
+	})
+	updateExecutionDone(execution)
+	if execution.Remaining() < routeEpsilon {
+		execution.Status = common.ExecutionDone
+	}
+}
+
+// updateSlice updates the fill of the slice from the exchange.
+func (self ReserveCore) updateSlice(execution *common.Execution, slice *common.ExecutionSlice, exchange common.Exchange) error {
+	fillExchange, ok := exchange.(OrderFillExchange)
+	if !ok {
+		return fmt.Errorf("exchange %s doesn't report order fills", exchange.ID())
+	}
+	done, _, finished, err := fillExchange.OrderFill(slice.ID.EID, execution.Base, execution.Quote)
+	if err != nil {
+		return err
+	}
+	slice.Done = done
+	slice.Finished = finished
+	updateExecutionDone(execution)
+	return nil
+}
+
+// closeSlice cancels the open slice and updates its final fill.
+func (self ReserveCore) closeSlice(execution *common.Execution, slice *common.ExecutionSlice, exchange common.Exchange) error {
+	if err := self.CancelOrder(slice.ID, exchange); err != nil {
+		// the slice might be filled in the meantime
+		if uErr := self.updateSlice(execution, slice, exchange); uErr == nil && slice.Finished {
+			return nil
+		}
+		return err
+	}
+	if err := self.updateSlice(execution, slice, exchange); err != nil {
+		return err
+	}
+	// the cancelled order is not open anymore whatever the exchange reports
+	slice.Finished = true
+	return nil
+}
+
+// sliceError counts an error checking or cancelling the open slice, failing
+// the execution once maxSliceErrors are in a row so a slice stuck on the
+// exchange doesn't keep it running forever.
+func sliceError(execution *common.Execution, slice *common.ExecutionSlice, err error) {
+	slice.Errors++
+	if slice.Errors >= maxSliceErrors {
+		execution.Status = common.ExecutionFailed
+		execution.Error = fmt.Sprintf("giving up slice %s after %d errors: %s", slice.ID, slice.Errors, err)
+	}
+}
+
+func updateExecutionDone(execution *common.Execution) {
+	execution.Done = 0
+	for _, slice := range execution.Slices {
+		execution.Done += slice.Done
+	}
+}
+
+// sliceRate returns the best rate of the order book of the exchange, capped
+// by the rate of the execution. The rate of the execution is used if there
+// is no order book.
+func (self ReserveCore) sliceRate(execution common.Execution, base, quote common.Token, info common.ExchangePrecisionLimit) float64 {
+	rate := execution.Rate
+	if self.marketData != nil {
+		prices, err := self.marketData.GetAllPrices(common.GetTimepoint())
+		if err == nil {
+			price := prices.Data[common.NewTokenPairID(base.ID, quote.ID)][execution.Exchange]
+			if price.Valid && execution.Type == "buy" && len(price.Asks) > 0 {
+				rate = math.Min(rate, price.Asks[0].Rate)
+			} else if price.Valid && execution.Type == "sell" && len(price.Bids) > 0 {
+				rate = math.Max(rate, price.Bids[0].Rate)
+			}
+		}
+	}
+	// rounding never makes the rate worse than the one of the execution
+	if execution.Type == "buy" {
+		return roundDown(rate, info.Precision.Price)
+	}
+	return roundUp(rate, info.Precision.Price)
+}
+
+// sliceAmount returns the amount of the next slice of the execution, zero if
+// it is not time to place it yet. Amounts are rounded down to the precision
+// of the exchange and raised to its minimums, it returns an error if the
+// remaining amount is below them.
+func sliceAmount(execution common.Execution, rate float64, info common.ExchangePrecisionLimit, timepoint uint64) (float64, error) {
+	remaining := roundDown(execution.Remaining(), info.Precision.Amount)
+	var amount float64
+	switch execution.Strategy.Name {
+	case common.ExecutionTWAP:
+		strategy := execution.Strategy
+		// slices due since the start, the unfilled amount of the past ones
+		// is caught up by the next one
+		due := strategy.Slices
+		if timepoint < execution.CreatedAt+strategy.Duration {
+			due = int((timepoint-execution.CreatedAt)/strategy.RepriceInterval) + 1
+		}
+		amount = execution.Amount*float64(due)/float64(strategy.Slices) - execution.Done
+	case common.ExecutionIceberg:
+		amount = execution.Strategy.VisibleAmount
+	}
+	amount = math.Min(roundDown(amount, info.Precision.Amount), remaining)
+	if amount <= 0 {
+		return 0, nil
+	}
+
+	minimum := info.AmountLimit.Min
+	if info.MinNotional != 0 && rate != 0 {
+		minimum = math.Max(minimum, info.MinNotional/rate)
+	}
+	minimum = roundUp(minimum, info.Precision.Amount)
+	if remaining < minimum {
+		return 0, fmt.Errorf("remaining %s is below the minimum order amount %s of the exchange",
+			strconv.FormatFloat(remaining, 'f', -1, 64),
+			strconv.FormatFloat(minimum, 'f', -1, 64))
+	}
+	return math.Max(amount, minimum), nil
+}
+
+// storeExecution stores the execution and records it as an activity.
+func (self ReserveCore) storeExecution(execution common.Execution) error {
+	if err := self.executionStorage.StoreExecution(execution); err != nil {
+		return err
+	}
+	var slices []string
+	for _, slice := range execution.Slices {
+		slices = append(slices, slice.ID.String())
+	}
+	status := statusSubmitted
+	switch execution.Status {
+	case common.ExecutionDone, common.ExecutionCancelled:
+		status = statusDone
+	case common.ExecutionFailed:
+		status = statusFailed
+	}
+	log.Printf(
+		"Core ----------> Execution %s of %s %s on %s: base: %s, quote: %s, rate: %s, amount: %s ==> status: %s, done: %s, slices: %d, error: %s",
+		execution.ID, execution.Strategy.Name, execution.Type, execution.Exchange,
+		execution.Base, execution.Quote,
+		strconv.FormatFloat(execution.Rate, 'f', -1, 64),
+		strconv.FormatFloat(execution.Amount, 'f', -1, 64),
+		execution.Status,
+		strconv.FormatFloat(execution.Done, 'f', -1, 64),
+		len(execution.Slices), execution.Error,
+	)
+	return self.activityStorage.Record(
+		common.ActionExecution,
+		execution.ID,
+		"",
+		map[string]interface{}{
+			"exchange":  execution.Exchange,
+			"type":      execution.Type,
+			"base":      execution.Base,
+			"quote":     execution.Quote,
+			"rate":      execution.Rate,
+			"amount":    strconv.FormatFloat(execution.Amount, 'f', -1, 64),
+			"strategy":  execution.Strategy,
+			"timepoint": execution.CreatedAt,
+		}, map[string]interface{}{
+			"status":    execution.Status,
+			"done":      execution.Done,
+			"remaining": execution.Remaining(),
+			"slices":    slices,
+			"error":     execution.Error,
+		},
+		status,
+		"",
+		execution.CreatedAt,
+	)
+}
@@ -0,0 +1,285 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/KyberNetwork/reserve-data/common"
+)
+
+type testFillExchange struct {
+	testExchange
+	info      common.ExchangePrecisionLimit
+	orders    []float64
+	fills     map[string]float64
+	cancelled map[string]bool
+	failFill  bool
+}
+
+func newTestFillExchange(info common.ExchangePrecisionLimit) *testFillExchange {
+	return &testFillExchange{
+		info:      info,
+		fills:     map[string]float64{},
+		cancelled: map[string]bool{},
+	}
+}
+
+func (self *testFillExchange) ID() common.ExchangeID {
+	return "execution_test"
+}
+
+func (self *testFillExchange) GetExchangeInfo(pair common.TokenPairID) (common.ExchangePrecisionLimit, error) {
+	return self.info, nil
+}
+
+func (self *testFillExchange) Trade(tradeType string, base common.Token, quote common.Token, rate float64, amount float64, timepoint uint64) (string, float64, float64, bool, error) {
+	self.orders = append(self.orders, amount)
+	return fmt.Sprint(len(self.orders)), 0, amount, false, nil
+}
+
+func (self *testFillExchange) CancelOrder(id string, base, quote string) error {
+	self.cancelled[id] = true
+	return nil
+}
+
+// fill fills the last order by amount, finishing it if it is filled.
+func (self *testFillExchange) fill(amount float64) {
+	self.fills[fmt.Sprint(len(self.orders))] += amount
+}
+
+func (self *testFillExchange) OrderFill(id, base, quote string) (float64, float64, bool, error) {
+	if self.failFill {
+		return 0, 0, false, errors.New("order status unavailable")
+	}
+	var index int
+	if _, err := fmt.Sscan(id, &index); err != nil {
+		return 0, 0, false, err
+	}
+	total := self.orders[index-1]
+	done := self.fills[id]
+	return done, total - done, done >= total || self.cancelled[id], nil
+}
+
+type testExecutionStorage struct {
+	executions map[common.ActivityID]common.Execution
+}
+
+func (self *testExecutionStorage) StoreExecution(execution common.Execution) error {
+	self.executions[execution.ID] = execution
+	return nil
+}
+
+func (self *testExecutionStorage) GetExecution(id common.ActivityID) (common.Execution, error) {
+	execution, ok := self.executions[id]
+	if !ok {
+		return execution, errors.New("execution doesn't exist")
+	}
+	return execution, nil
+}
+
+func (self *testExecutionStorage) GetRunningExecutions() ([]common.Execution, error) {
+	var result []common.Execution
+	for _, execution := range self.executions {
+		if execution.Status == common.ExecutionRunning {
+			result = append(result, execution)
+		}
+	}
+	return result, nil
+}
+
+// testOrderActivityStorage returns the orders it recorded, so they can be
+// cancelled.
+type testOrderActivityStorage struct {
+	testActivityStorage
+	records map[common.ActivityID]common.ActivityRecord
+}
+
+func (self *testOrderActivityStorage) Record(
+	action string,
+	id common.ActivityID,
+	destination string,
+	params map[string]interface{},
+	result map[string]interface{},
+	estatus string,
+	mstatus string,
+	timepoint uint64) error {
+	params["base"] = "KNC"
+	params["quote"] = "ETH"
+	self.records[id] = common.NewActivityRecord(action, id, destination, params, result, estatus, mstatus, "")
+	return nil
+}
+
+func (self *testOrderActivityStorage) GetActivity(id common.ActivityID) (common.ActivityRecord, error) {
+	return self.records[id], nil
+}
+
+func newTestExecutionCore(exchange *testFillExchange) (*ReserveCore, *testExecutionStorage) {
+	knc := common.NewToken("KNC", "Kyber-coin", "0x1111111111111111111111111111111111111111", 18, true, true, 0)
+	eth := common.NewToken("ETH", "Ethereum", "0x2222222222222222222222222222222222222222", 18, true, true, 0)
+	storage := &testExecutionStorage{executions: map[common.ActivityID]common.Execution{}}
+	core := NewReserveCore(
+		testBlockchain{},
+		&testOrderActivityStorage{records: map[common.ActivityID]common.ActivityRecord{}},
+		testSetting{tokens: []common.Token{knc, eth}},
+		WithExecutionEngine(storage, DefaultExecutionInterval),
+	)
+	return core, storage
+}
+
+func TestIcebergExecution(t *testing.T) {
+	exchange := newTestFillExchange(common.ExchangePrecisionLimit{
+		Precision: common.TokenPairPrecision{Amount: 0, Price: 6},
+	})
+	core, storage := newTestExecutionCore(exchange)
+	common.SupportedExchanges[exchange.ID()] = exchange
+	defer delete(common.SupportedExchanges, exchange.ID())
+	knc, _ := core.setting.GetInternalTokenByID("KNC")
+	eth, _ := core.setting.GetInternalTokenByID("ETH")
+	strategy := common.ExecutionStrategy{Name: common.ExecutionIceberg, VisibleAmount: 100, RepriceInterval: 60000}
+
+	if _, err := core.StartExecution(testExchange{}, "buy", knc, eth, 0.001, 250, strategy, 0); err == nil {
+		t.Error("Expected error executing on exchange not reporting order fills")
+	}
+	execution, err := core.StartExecution(exchange, "buy", knc, eth, 0.001, 250, strategy, 1000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(exchange.orders) != 1 || exchange.orders[0] != 100 {
+		t.Fatalf("Expected the first slice of 100 placed, got %v", exchange.orders)
+	}
+
+	// a filled slice is followed by the next one
+	exchange.fill(100)
+	core.StepExecutions(2000)
+	if len(exchange.orders) != 2 || exchange.orders[1] != 100 {
+		t.Fatalf("Expected the second slice of 100 placed, got %v", exchange.orders)
+	}
+
+	// an unfilled slice is cancelled after the reprice interval
+	exchange.fill(40)
+	core.StepExecutions(30000)
+	if len(exchange.orders) != 2 {
+		t.Fatalf("Expected the slice to wait for the reprice interval, got %v", exchange.orders)
+	}
+	core.StepExecutions(62000)
+	if !exchange.cancelled["2"] || len(exchange.orders) != 3 || exchange.orders[2] != 100 {
+		t.Fatalf("Expected the second slice cancelled and placed again, got %v", exchange.orders)
+	}
+
+	exchange.fill(100)
+	core.StepExecutions(63000)
+	if exchange.orders[3] != 10 {
+		t.Fatalf("Expected the last slice of the remaining 10, got %v", exchange.orders)
+	}
+	exchange.fill(10)
+	core.StepExecutions(64000)
+	execution = storage.executions[execution.ID]
+	if execution.Status != common.ExecutionDone || execution.Done != 250 || len(execution.Slices) != 4 {
+		t.Errorf("Expected execution done in 4 slices, got %+v", execution)
+	}
+}
+
+func TestTWAPExecution(t *testing.T) {
+	exchange := newTestFillExchange(common.ExchangePrecisionLimit{
+		Precision:   common.TokenPairPrecision{Amount: 0, Price: 6},
+		MinNotional: 0.025,
+	})
+	core, storage := newTestExecutionCore(exchange)
+	common.SupportedExchanges[exchange.ID()] = exchange
+	defer delete(common.SupportedExchanges, exchange.ID())
+	knc, _ := core.setting.GetInternalTokenByID("KNC")
+	eth, _ := core.setting.GetInternalTokenByID("ETH")
+	// 5 slices over 10 minutes
+	strategy := common.ExecutionStrategy{Name: common.ExecutionTWAP, Duration: 600000, Slices: 5}
+
+	execution, err := core.StartExecution(exchange, "sell", knc, eth, 0.001, 100, strategy, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// slices of 20 are below the min notional 0.025 at 0.001
+	if len(exchange.orders) != 1 || exchange.orders[0] != 25 {
+		t.Fatalf("Expected the first slice raised to 25, got %v", exchange.orders)
+	}
+	exchange.fill(25)
+	core.StepExecutions(60000)
+	if len(exchange.orders) != 1 {
+		t.Fatalf("Expected no slice before the second period, got %v", exchange.orders)
+	}
+	core.StepExecutions(120000)
+	if len(exchange.orders) != 2 || exchange.orders[1] != 25 {
+		t.Fatalf("Expected the second slice of 25, got %v", exchange.orders)
+	}
+
+	// the unfilled amount of a slice is caught up by the next one
+	core.StepExecutions(240000)
+	if !exchange.cancelled["2"] || len(exchange.orders) != 3 || exchange.orders[2] != 35 {
+		t.Fatalf("Expected the second slice cancelled and 35 placed, got %v", exchange.orders)
+	}
+
+	execution, err = core.CancelExecution(execution.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !exchange.cancelled["3"] || execution.Status != common.ExecutionCancelled || execution.Done != 25 {
+		t.Errorf("Expected execution cancelled with 25 done, got %+v", execution)
+	}
+	core.StepExecutions(360000)
+	if len(exchange.orders) != 3 || storage.executions[execution.ID].Status != common.ExecutionCancelled {
+		t.Errorf("Expected no slice placed after cancelled, got %v", exchange.orders)
+	}
+}
+
+func TestCheckTWAPExecution(t *testing.T) {
+	exchange := newTestFillExchange(common.ExchangePrecisionLimit{})
+	knc := common.NewToken("KNC", "Kyber-coin", "0x1111111111111111111111111111111111111111", 18, true, true, 0)
+	eth := common.NewToken("ETH", "Ethereum", "0x2222222222222222222222222222222222222222", 18, true, true, 0)
+	for _, strategy := range []common.ExecutionStrategy{
+		{Name: common.ExecutionTWAP, Duration: 1, Slices: 60001},
+		{Name: common.ExecutionTWAP, Duration: 60000, Slices: 61},
+		{Name: common.ExecutionTWAP, Duration: 100000000, Slices: maxExecutionSlices + 1},
+	} {
+		if _, err := checkExecution(exchange, "buy", knc, eth, 0.001, 100, strategy); err == nil {
+			t.Errorf("Expected error of TWAP of %d slices over %d milliseconds", strategy.Slices, strategy.Duration)
+		}
+	}
+	strategy, err := checkExecution(exchange, "buy", knc, eth, 0.001, 100, common.ExecutionStrategy{Name: common.ExecutionTWAP, Duration: 60000, Slices: 60})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strategy.RepriceInterval != 1000 {
+		t.Errorf("Expected slices 1000 milliseconds apart, got %d", strategy.RepriceInterval)
+	}
+}
+
+func TestExecutionStuckSlice(t *testing.T) {
+	exchange := newTestFillExchange(common.ExchangePrecisionLimit{
+		Precision: common.TokenPairPrecision{Amount: 0, Price: 6},
+	})
+	core, storage := newTestExecutionCore(exchange)
+	common.SupportedExchanges[exchange.ID()] = exchange
+	defer delete(common.SupportedExchanges, exchange.ID())
+	knc, _ := core.setting.GetInternalTokenByID("KNC")
+	eth, _ := core.setting.GetInternalTokenByID("ETH")
+	strategy := common.ExecutionStrategy{Name: common.ExecutionIceberg, VisibleAmount: 100, RepriceInterval: 60000}
+
+	execution, err := core.StartExecution(exchange, "buy", knc, eth, 0.001, 250, strategy, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	exchange.failFill = true
+	for i := 1; i < maxSliceErrors; i++ {
+		core.StepExecutions(uint64(i) * 1000)
+	}
+	if status := storage.executions[execution.ID].Status; status != common.ExecutionRunning {
+		t.Fatalf("Expected execution running before %d errors, got %s", maxSliceErrors, status)
+	}
+	core.StepExecutions(uint64(maxSliceErrors) * 1000)
+	execution = storage.executions[execution.ID]
+	if execution.Status != common.ExecutionFailed || execution.Error == "" {
+		t.Errorf("Expected execution failed after %d errors, got %+v", maxSliceErrors, execution)
+	}
+	if len(exchange.orders) != 1 {
+		t.Errorf("Expected no slice placed after the stuck one, got %v", exchange.orders)
+	}
+}
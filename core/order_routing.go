@@ -0,0 +1,485 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/KyberNetwork/reserve-data/common"
+)
+
+// routeEpsilon is the amount below which a trade is considered fully routed.
+const routeEpsilon = 0.00000001 // 1e-8
+
+// MarketData is the source of the latest order books and exchange balances
+// trades are routed on.
+type MarketData interface {
+	GetAllPrices(timestamp uint64) (common.AllPriceResponse, error)
+	GetAuthData(timestamp uint64) (common.AuthDataResponse, error)
+}
+
+// WithOrderRouting enables routing trades across exchanges using the order
+// books and balances of data.
+func WithOrderRouting(data MarketData) ReserveCoreOption {
+	return func(self *ReserveCore) {
+		self.marketData = data
+	}
+}
+
+var errOrderRoutingDisabled = errors.New("order routing is not enabled")
+
+// routeVenue is an exchange a trade can be routed to.
+type routeVenue struct {
+	exchange common.Exchange
+	// levels are the asks to buy from or the bids to sell to, best first
+	levels []common.PriceEntry
+	// fee is the taker fee of the exchange
+	fee float64
+	// balance is the available quote to buy with, or base to sell
+	balance float64
+	info    common.ExchangePrecisionLimit
+}
+
+// routeOrder is an order a trade is routed to.
+type routeOrder struct {
+	exchange common.Exchange
+	rate     float64
+	amount   float64
+}
+
+// routeLevel is an order book level of a venue.
+type routeLevel struct {
+	venue int
+	rate  float64
+	qty   float64
+	// cost is the rate including the fee, what is paid per unit to buy or
+	// received per unit to sell
+	cost float64
+}
+
+// planRoute splits amount of a trade into orders on venues, taking the
+// order book levels of the lowest cost including fees first. Levels of rate
+// worse than the best rate by more than maxSlippage are not taken. Venues of
+// which orders would be below the exchange limits are left out and the
+// trade is planned again on the others. It returns an error if the venues
+// cannot fill the amount.
+func planRoute(tradeType string, amount, maxSlippage float64, venues []routeVenue) ([]routeOrder, error) {
+	buy := tradeType == "buy"
+	best := 0.0
+	for _, venue := range venues {
+		for _, level := range venue.levels {
+			if level.Quantity <= 0 || level.Rate <= 0 {
+				continue
+			}
+			if best == 0 || (buy && level.Rate < best) || (!buy && level.Rate > best) {
+				best = level.Rate
+			}
+		}
+	}
+	if best == 0 {
+		return nil, errors.New("no exchange has orders to trade with")
+	}
+	limit := best * (1 - maxSlippage)
+	if buy {
+		limit = best * (1 + maxSlippage)
+	}
+	for {
+		orders, skipped, err := allocateRoute(tradeType, amount, maxSlippage, limit, venues)
+		if err != nil {
+			return nil, err
+		}
+		if len(skipped) == 0 {
+			return orders, nil
+		}
+		var kept []routeVenue
+		for i, venue := range venues {
+			if !skipped[i] {
+				kept = append(kept, venue)
+			}
+		}
+		if len(kept) == 0 {
+			return nil, errors.New("the trade is too small to be placed on any exchange")
+		}
+		venues = kept
+	}
+}
+
+// allocateRoute allocates amount of a trade to the order book levels of
+// venues of rate within limit, lowest cost first. It returns the orders of
+// the venues and the venues of which orders are below the exchange limits.
+func allocateRoute(tradeType string, amount, maxSlippage, limit float64, venues []routeVenue) ([]routeOrder, map[int]bool, error) {
+	buy := tradeType == "buy"
+	var levels []routeLevel
+	for i, venue := range venues {
+		for _, level := range venue.levels {
+			if level.Quantity <= 0 || level.Rate <= 0 {
+				continue
+			}
+			cost := level.Rate * (1 - venue.fee)
+			if buy {
+				cost = level.Rate * (1 + venue.fee)
+			}
+			levels = append(levels, routeLevel{venue: i, rate: level.Rate, qty: level.Quantity, cost: cost})
+		}
+	}
+	sort.SliceStable(levels, func(i, j int) bool {
+		if buy {
+			return levels[i].cost < levels[j].cost
+		}
+		return levels[i].cost > levels[j].cost
+	})
+
+	balances := make([]float64, len(venues))
+	for i, venue := range venues {
+		balances[i] = venue.balance
+	}
+	amounts := make([]float64, len(venues))
+	rates := make([]float64, len(venues))
+	remaining := amount
+	for _, level := range levels {
+		if remaining < routeEpsilon {
+			break
+		}
+		if (buy && level.rate > limit) || (!buy && level.rate < limit) {
+			continue
+		}
+		qty := math.Min(level.qty, remaining)
+		if buy {
+			qty = math.Min(qty, balances[level.venue]/level.rate)
+			balances[level.venue] -= qty * level.rate
+		} else {
+			qty = math.Min(qty, balances[level.venue])
+			balances[level.venue] -= qty
+		}
+		if qty <= 0 {
+			continue
+		}
+		amounts[level.venue] += qty
+		remaining -= qty
+		// the order is placed at the worst rate taken to fill all levels
+		if rates[level.venue] == 0 || (buy && level.rate > rates[level.venue]) || (!buy && level.rate < rates[level.venue]) {
+			rates[level.venue] = level.rate
+		}
+	}
+	if remaining >= routeEpsilon {
+		return nil, nil, fmt.Errorf("not enough liquidity and balance to %s %s within max slippage %s, %s left",
+			tradeType,
+			strconv.FormatFloat(amount, 'f', -1, 64),
+			strconv.FormatFloat(maxSlippage, 'f', -1, 64),
+			strconv.FormatFloat(remaining, 'f', -1, 64))
+	}
+
+	var orders []routeOrder
+	skipped := map[int]bool{}
+	for i, venue := range venues {
+		if amounts[i] == 0 {
+			continue
+		}
+		orderAmount := roundDown(amounts[i], venue.info.Precision.Amount)
+		rate := roundDown(rates[i], venue.info.Precision.Price)
+		if buy {
+			rate = roundUp(rates[i], venue.info.Precision.Price)
+		}
+		if orderAmount <= 0 || orderAmount < venue.info.AmountLimit.Min ||
+			(venue.info.MinNotional != 0 && orderAmount*rate < venue.info.MinNotional) {
+			log.Printf("Routing %s %f to %s is skipped as it is below the exchange limits", tradeType, amounts[i], venue.exchange.ID())
+			skipped[i] = true
+			continue
+		}
+		orders = append(orders, routeOrder{exchange: venue.exchange, rate: rate, amount: orderAmount})
+	}
+	return orders, skipped, nil
+}
+
+func roundDown(value float64, precision int) float64 {
+	pow := math.Pow10(precision)
+	return math.Floor(value*pow+routeEpsilon) / pow
+}
+
+func roundUp(value float64, precision int) float64 {
+	pow := math.Pow10(precision)
+	return math.Ceil(value*pow-routeEpsilon) / pow
+}
+
+// routeVenues returns the exchanges a trade of the pair can be routed to,
+// with their latest order books and balances. Exchanges without valid data
+// are skipped.
+func (self ReserveCore) routeVenues(
+	tradeType string,
+	base, quote common.Token,
+	exchanges []common.Exchange) ([]routeVenue, error) {
+	pairID := common.NewTokenPairID(base.ID, quote.ID)
+	timepoint := common.GetTimepoint()
+	prices, err := self.marketData.GetAllPrices(timepoint)
+	if err != nil {
+		return nil, err
+	}
+	onePrice, ok := prices.Data[pairID]
+	if !ok {
+		return nil, fmt.Errorf("there is no order book of %s", pairID)
+	}
+	authData, err := self.marketData.GetAuthData(timepoint)
+	if err != nil {
+		return nil, err
+	}
+	var venues []routeVenue
+	for _, exchange := range exchanges {
+		price, ok := onePrice[exchange.ID()]
+		if !ok || !price.Valid {
+			continue
+		}
+		balance, ok := authData.Data.ExchangeBalances[exchange.ID()]
+		if !ok || !balance.Valid {
+			log.Printf("Routing skips %s as its balances are not available", exchange.ID())
+			continue
+		}
+		fees, err := exchange.GetFee()
+		if err != nil {
+			log.Printf("Routing skips %s as its fee is not available: %s", exchange.ID(), err)
+			continue
+		}
+		info, err := exchange.GetExchangeInfo(pairID)
+		if err != nil {
+			log.Printf("Routing skips %s as its precision of %s is not available: %s", exchange.ID(), pairID, err)
+			continue
+		}
+		venue := routeVenue{
+			exchange: exchange,
+			fee:      fees.Trading["taker"],
+			info:     info,
+		}
+		if tradeType == "buy" {
+			venue.levels = price.Asks
+			venue.balance = balance.AvailableBalance[quote.ID]
+		} else {
+			venue.levels = price.Bids
+			venue.balance = balance.AvailableBalance[base.ID]
+		}
+		venues = append(venues, venue)
+	}
+	return venues, nil
+}
+
+func (self ReserveCore) planRouteTrade(
+	tradeType string,
+	base, quote common.Token,
+	amount, maxSlippage float64,
+	exchanges []common.Exchange) ([]routeOrder, error) {
+	if self.marketData == nil {
+		return nil, errOrderRoutingDisabled
+	}
+	if tradeType != "buy" && tradeType != "sell" {
+		return nil, fmt.Errorf("invalid trade type %s", tradeType)
+	}
+	if amount <= 0 {
+		return nil, errors.New("amount must be positive")
+	}
+	if maxSlippage < 0 {
+		return nil, errors.New("max slippage must not be negative")
+	}
+	venues, err := self.routeVenues(tradeType, base, quote, exchanges)
+	if err != nil {
+		return nil, err
+	}
+	return planRoute(tradeType, amount, maxSlippage, venues)
+}
+
+// RouteTrade splits a trade of amount of base across exchanges to minimise
+// its cost including trading fees, based on the latest order books and
+// balances. Order book levels of rate worse than the best one by more than
+// maxSlippage, eg: 0.01 for 1%, are not taken. The orders are placed with
+// Trade and tracked by a route trade activity.
+func (self ReserveCore) RouteTrade(
+	tradeType string,
+	base, quote common.Token,
+	amount, maxSlippage float64,
+	exchanges []common.Exchange,
+	timepoint uint64) (common.RoutedTrade, error) {
+	result := common.RoutedTrade{
+		ID:     timebasedID(string(common.NewTokenPairID(base.ID, quote.ID)) + "|" + tradeType),
+		Type:   tradeType,
+		Base:   base.ID,
+		Quote:  quote.ID,
+		Amount: amount,
+	}
+	orders, err := self.planRouteTrade(tradeType, base, quote, amount, maxSlippage, exchanges)
+	if err != nil {
+		if sErr := self.recordRouteTrade(result, maxSlippage, statusFailed, err, timepoint); sErr != nil {
+			log.Printf("failed to save activity record: %s", sErr)
+		}
+		return result, err
+	}
+
+	var errs []string
+	for _, order := range orders {
+		id, done, remaining, finished, tErr := self.Trade(
+			order.exchange, tradeType, base, quote, order.rate, order.amount, timepoint)
+		routed := common.RoutedOrder{
+			Exchange:  order.exchange.ID(),
+			ID:        id,
+			Rate:      order.rate,
+			Amount:    order.amount,
+			Done:      done,
+			Remaining: remaining,
+			Finished:  finished,
+		}
+		if tErr != nil {
+			routed.Error = tErr.Error()
+			errs = append(errs, fmt.Sprintf("%s: %s", order.exchange.ID(), tErr))
+		}
+		result.Orders = append(result.Orders, routed)
+	}
+	aggregateRoutedTrade(&result)
+
+	status := statusDone
+	if len(errs) != 0 {
+		err = fmt.Errorf("failed to place orders on %s", strings.Join(errs, ", "))
+		if len(errs) == len(orders) {
+			status = statusFailed
+		}
+	}
+	if sErr := self.recordRouteTrade(result, maxSlippage, status, err, timepoint); sErr != nil {
+		log.Printf("failed to save activity record: %s", sErr)
+	}
+	return result, err
+}
+
+// aggregateRoutedTrade sums up the orders of the routed trade, orders failed
+// to be placed are not counted.
+func aggregateRoutedTrade(trade *common.RoutedTrade) {
+	trade.Done, trade.Remaining, trade.Finished = 0, 0, true
+	routed := 0.0
+	for _, order := range trade.Orders {
+		routed += order.Amount
+		if order.Error != "" {
+			continue
+		}
+		trade.Done += order.Done
+		trade.Remaining += order.Remaining
+		trade.Finished = trade.Finished && order.Finished
+	}
+	trade.Unrouted = 0
+	if trade.Amount-routed >= routeEpsilon {
+		trade.Unrouted = trade.Amount - routed
+	}
+}
+
+func (self ReserveCore) recordRouteTrade(trade common.RoutedTrade, maxSlippage float64, status string, err error, timepoint uint64) error {
+	log.Printf(
+		"Core ----------> Route %s: base: %s, quote: %s, amount: %s, max slippage: %s, timestamp: %d ==> Result: orders: %d, done: %s, remaining: %s, unrouted: %s, error: %s",
+		trade.Type, trade.Base, trade.Quote,
+		strconv.FormatFloat(trade.Amount, 'f', -1, 64),
+		strconv.FormatFloat(maxSlippage, 'f', -1, 64),
+		timepoint, len(trade.Orders),
+		strconv.FormatFloat(trade.Done, 'f', -1, 64),
+		strconv.FormatFloat(trade.Remaining, 'f', -1, 64),
+		strconv.FormatFloat(trade.Unrouted, 'f', -1, 64),
+		common.ErrorToString(err),
+	)
+	var orderIDs []string
+	for _, order := range trade.Orders {
+		if order.Error == "" {
+			orderIDs = append(orderIDs, order.ID.String())
+		}
+	}
+	return self.activityStorage.Record(
+		common.ActionRouteTrade,
+		trade.ID,
+		"",
+		map[string]interface{}{
+			"type":         trade.Type,
+			"base":         trade.Base,
+			"quote":        trade.Quote,
+			"amount":       strconv.FormatFloat(trade.Amount, 'f', -1, 64),
+			"max_slippage": maxSlippage,
+			"timepoint":    timepoint,
+		}, map[string]interface{}{
+			"orders":    orderIDs,
+			"done":      trade.Done,
+			"remaining": trade.Remaining,
+			"finished":  trade.Finished,
+			"unrouted":  trade.Unrouted,
+			"error":     common.ErrorToString(err),
+		},
+		status,
+		"",
+		timepoint,
+	)
+}
+
+// GetRoutedTrade returns the routed trade of the route trade activity, with
+// its orders as of their latest activities. An order is finished once its
+// exchange status is done.
+func (self ReserveCore) GetRoutedTrade(id common.ActivityID) (common.RoutedTrade, error) {
+	activity, err := self.activityStorage.GetActivity(id)
+	if err != nil {
+		return common.RoutedTrade{}, err
+	}
+	if activity.Action != common.ActionRouteTrade {
+		return common.RoutedTrade{}, errors.New("This is not a route trade activity")
+	}
+	result := common.RoutedTrade{ID: activity.ID}
+	result.Type, _ = activity.Params["type"].(string)
+	result.Base, _ = activity.Params["base"].(string)
+	result.Quote, _ = activity.Params["quote"].(string)
+	if amount, ok := activity.Params["amount"].(string); ok {
+		result.Amount, _ = strconv.ParseFloat(amount, 64)
+	}
+	for _, orderID := range activityIDs(activity.Result["orders"]) {
+		order, err := self.activityStorage.GetActivity(orderID)
+		if err != nil {
+			return result, fmt.Errorf("cannot get order %s: %s", orderID, err)
+		}
+		routed := common.RoutedOrder{
+			Exchange: common.ExchangeID(order.Destination),
+			ID:       order.ID,
+			Finished: order.ExchangeStatus == common.ExchangeStatusDone,
+		}
+		routed.Rate, _ = order.Params["rate"].(float64)
+		if amount, ok := order.Params["amount"].(string); ok {
+			routed.Amount, _ = strconv.ParseFloat(amount, 64)
+		}
+		routed.Done, _ = order.Result["done"].(float64)
+		routed.Remaining, _ = order.Result["remaining"].(float64)
+		if finished, _ := order.Result["finished"].(bool); finished {
+			routed.Finished = true
+		}
+		// the result of an order is not updated once it is filled
+		if order.ExchangeStatus == common.ExchangeStatusDone {
+			routed.Done, routed.Remaining = routed.Amount, 0
+		}
+		result.Orders = append(result.Orders, routed)
+	}
+	aggregateRoutedTrade(&result)
+	return result, nil
+}
+
+// activityIDs returns the activity IDs of the value stored in an activity,
+// which is a list of strings once loaded from storage.
+func activityIDs(value interface{}) []common.ActivityID {
+	var ids []string
+	switch v := value.(type) {
+	case []string:
+		ids = v
+	case []interface{}:
+		for _, id := range v {
+			if s, ok := id.(string); ok {
+				ids = append(ids, s)
+			}
+		}
+	}
+	var result []common.ActivityID
+	for _, id := range ids {
+		activityID, err := common.StringToActivityID(id)
+		if err != nil {
+			log.Printf("WARNING: invalid activity ID %s: %s", id, err)
+			continue
+		}
+		result = append(result, activityID)
+	}
+	return result
+}
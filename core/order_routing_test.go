@@ -0,0 +1,219 @@
+package core
+
+import (
+	"math"
+	"testing"
+
+	"github.com/KyberNetwork/reserve-data/common"
+)
+
+type testRouteExchange struct {
+	testExchange
+	id     common.ExchangeID
+	fee    float64
+	trades []float64
+}
+
+func (self *testRouteExchange) ID() common.ExchangeID {
+	return self.id
+}
+
+func (self *testRouteExchange) GetFee() (common.ExchangeFees, error) {
+	return common.ExchangeFees{Trading: common.TradingFee{"taker": self.fee, "maker": self.fee}}, nil
+}
+
+func (self *testRouteExchange) GetExchangeInfo(pair common.TokenPairID) (common.ExchangePrecisionLimit, error) {
+	return common.ExchangePrecisionLimit{
+		Precision: common.TokenPairPrecision{Amount: 0, Price: 6},
+	}, nil
+}
+
+func (self *testRouteExchange) Trade(tradeType string, base common.Token, quote common.Token, rate float64, amount float64, timepoint uint64) (string, float64, float64, bool, error) {
+	self.trades = append(self.trades, amount)
+	return "tradeid", 0, amount, false, nil
+}
+
+type testMarketData struct {
+	prices   common.AllPriceResponse
+	authData common.AuthDataResponse
+}
+
+func (self testMarketData) GetAllPrices(timestamp uint64) (common.AllPriceResponse, error) {
+	return self.prices, nil
+}
+
+func (self testMarketData) GetAuthData(timestamp uint64) (common.AuthDataResponse, error) {
+	return self.authData, nil
+}
+
+func assertRouteOrder(t *testing.T, order routeOrder, exchange common.ExchangeID, rate, amount float64) {
+	t.Helper()
+	if order.exchange.ID() != exchange || math.Abs(order.rate-rate) > 1e-12 || math.Abs(order.amount-amount) > 1e-9 {
+		t.Errorf("Expected order of %f at %f on %s, got %f at %f on %s",
+			amount, rate, exchange, order.amount, order.rate, order.exchange.ID())
+	}
+}
+
+func TestPlanRoute(t *testing.T) {
+	exA := &testRouteExchange{id: "a", fee: 0.001}
+	exB := &testRouteExchange{id: "b", fee: 0.0025}
+	info := common.ExchangePrecisionLimit{Precision: common.TokenPairPrecision{Amount: 0, Price: 6}}
+	venues := []routeVenue{
+		{
+			exchange: exA,
+			levels:   []common.PriceEntry{common.NewPriceEntry(100, 0.0010), common.NewPriceEntry(100, 0.0012)},
+			fee:      exA.fee,
+			balance:  10,
+			info:     info,
+		},
+		{
+			exchange: exB,
+			levels:   []common.PriceEntry{common.NewPriceEntry(50, 0.00101)},
+			fee:      exB.fee,
+			balance:  10,
+			info:     info,
+		},
+	}
+
+	// the level of b is cheaper than the second level of a including fees
+	orders, err := planRoute("buy", 180, 0.5, venues)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(orders) != 2 {
+		t.Fatalf("Expected 2 orders, got %d", len(orders))
+	}
+	assertRouteOrder(t, orders[0], "a", 0.0012, 130)
+	assertRouteOrder(t, orders[1], "b", 0.00101, 50)
+
+	// the second level of a is out of max slippage
+	if _, err = planRoute("buy", 180, 0.1, venues); err == nil {
+		t.Error("Expected error routing more than the liquidity within max slippage")
+	}
+
+	// a can only buy 50 with its balance
+	venues[0].balance = 0.05
+	orders, err = planRoute("buy", 100, 0.5, venues)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertRouteOrder(t, orders[0], "a", 0.001, 50)
+	assertRouteOrder(t, orders[1], "b", 0.00101, 50)
+}
+
+func TestPlanRouteBelowLimits(t *testing.T) {
+	exA := &testRouteExchange{id: "a", fee: 0.001}
+	exB := &testRouteExchange{id: "b", fee: 0.0025}
+	info := common.ExchangePrecisionLimit{Precision: common.TokenPairPrecision{Amount: 0, Price: 6}}
+	venues := []routeVenue{
+		{
+			exchange: exA,
+			levels:   []common.PriceEntry{common.NewPriceEntry(100, 0.0010), common.NewPriceEntry(100, 0.00102)},
+			fee:      exA.fee,
+			balance:  10,
+			info:     info,
+		},
+		{
+			exchange: exB,
+			levels:   []common.PriceEntry{common.NewPriceEntry(5, 0.00101)},
+			fee:      exB.fee,
+			balance:  10,
+			info: common.ExchangePrecisionLimit{
+				Precision:   common.TokenPairPrecision{Amount: 0, Price: 6},
+				AmountLimit: common.TokenPairAmountLimit{Min: 10},
+			},
+		},
+	}
+	// the 5 routed to b are below its minimum, so a takes them
+	orders, err := planRoute("buy", 105, 0.5, venues)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(orders) != 1 {
+		t.Fatalf("Expected 1 order, got %d", len(orders))
+	}
+	assertRouteOrder(t, orders[0], "a", 0.00102, 105)
+
+	// a can't fill the amount without b
+	venues[0].levels = venues[0].levels[:1]
+	if _, err = planRoute("buy", 105, 0.5, venues); err == nil {
+		t.Error("Expected error routing more than the liquidity of venues above their limits")
+	}
+}
+
+func TestPlanRouteSell(t *testing.T) {
+	exA := &testRouteExchange{id: "a", fee: 0.001}
+	exB := &testRouteExchange{id: "b", fee: 0.001}
+	info := common.ExchangePrecisionLimit{Precision: common.TokenPairPrecision{Amount: 0, Price: 6}}
+	venues := []routeVenue{
+		{
+			exchange: exA,
+			levels:   []common.PriceEntry{common.NewPriceEntry(100, 0.0010), common.NewPriceEntry(100, 0.0009)},
+			fee:      exA.fee,
+			balance:  1000,
+			info:     info,
+		},
+		{
+			exchange: exB,
+			levels:   []common.PriceEntry{common.NewPriceEntry(100, 0.00095)},
+			fee:      exB.fee,
+			balance:  1000,
+			info:     info,
+		},
+	}
+	orders, err := planRoute("sell", 150.5, 0.5, venues)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// amounts are rounded down to the precision of the exchanges
+	assertRouteOrder(t, orders[0], "a", 0.001, 100)
+	assertRouteOrder(t, orders[1], "b", 0.00095, 50)
+}
+
+func TestRouteTrade(t *testing.T) {
+	core := getTestCore(false)
+	base := common.NewToken("KNC", "Kyber-coin", "0x1111111111111111111111111111111111111111", 18, true, true, 0)
+	quote := common.NewToken("ETH", "Ethereum", "0x2222222222222222222222222222222222222222", 18, true, true, 0)
+	exA := &testRouteExchange{id: "a", fee: 0.001}
+	exB := &testRouteExchange{id: "b", fee: 0.001}
+	exchanges := []common.Exchange{exA, exB}
+	timepoint := common.GetTimepoint()
+
+	if _, err := core.RouteTrade("buy", base, quote, 100, 0.01, exchanges, timepoint); err != errOrderRoutingDisabled {
+		t.Fatalf("Expected routing to be disabled, got %v", err)
+	}
+
+	data := testMarketData{
+		prices: common.AllPriceResponse{
+			Data: map[common.TokenPairID]common.OnePrice{
+				"KNC-ETH": {
+					"a": {Valid: true, Asks: []common.PriceEntry{common.NewPriceEntry(60, 0.001)}},
+					"b": {Valid: true, Asks: []common.PriceEntry{common.NewPriceEntry(60, 0.001)}},
+				},
+			},
+		},
+	}
+	data.authData.Data.ExchangeBalances = map[common.ExchangeID]common.EBalanceEntry{
+		"a": {Valid: true, AvailableBalance: map[string]float64{"ETH": 1}},
+		"b": {Valid: true, AvailableBalance: map[string]float64{"ETH": 1}},
+	}
+	WithOrderRouting(data)(core)
+
+	if _, err := core.RouteTrade("buy", base, quote, 200, 0.01, exchanges, timepoint); err == nil {
+		t.Error("Expected error routing more than the liquidity")
+	}
+	if len(exA.trades) != 0 || len(exB.trades) != 0 {
+		t.Fatal("Expected no order placed if the trade cannot be routed")
+	}
+
+	result, err := core.RouteTrade("buy", base, quote, 100, 0.01, exchanges, timepoint)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Orders) != 2 || len(exA.trades) != 1 || len(exB.trades) != 1 {
+		t.Fatalf("Expected an order placed on each exchange, got %+v", result.Orders)
+	}
+	if exA.trades[0]+exB.trades[0] != 100 || result.Remaining != 100 || result.Finished {
+		t.Errorf("Expected 100 in unfinished orders, got %+v", result)
+	}
+}
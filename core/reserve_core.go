@@ -7,6 +7,7 @@ import (
 	"math"
 	"math/big"
 	"strconv"
+	"sync"
 	"time"
 
 	"github.com/KyberNetwork/reserve-data/common"
@@ -29,17 +30,39 @@ type ReserveCore struct {
 	blockchain      Blockchain
 	activityStorage ActivityStorage
 	setting         Setting
+
+	withdrawalApproval       WithdrawalApprovalStorage
+	withdrawalApprovalExpiry time.Duration
+
+	marketData MarketData
+
+	executionStorage  ExecutionStorage
+	executionInterval time.Duration
+	executionMu       *sync.Mutex
+
+	tokenListingStorage  TokenListingStorage
+	tokenListingInterval time.Duration
+	tokenListingMu       *sync.Mutex
 }
 
+// ReserveCoreOption is the option of ReserveCore constructor.
+type ReserveCoreOption func(*ReserveCore)
+
 func NewReserveCore(
 	blockchain Blockchain,
 	storage ActivityStorage,
-	setting Setting) *ReserveCore {
-	return &ReserveCore{
-		blockchain,
-		storage,
-		setting,
-	}
+	setting Setting,
+	options ...ReserveCoreOption) *ReserveCore {
+	core := &ReserveCore{
+		blockchain:               blockchain,
+		activityStorage:          storage,
+		setting:                  setting,
+		withdrawalApprovalExpiry: DefaultWithdrawalApprovalExpiry,
+	}
+	for _, option := range options {
+		option(core)
+	}
+	return core
 }
 
 func timebasedID(id string) common.ActivityID {
@@ -76,8 +99,7 @@ func (self ReserveCore) Trade(
 	timepoint uint64) (common.ActivityID, float64, float64, bool, error) {
 	var err error
 
-	recordActivity := func(id, status string, done, remaining float64, finished bool, err error) error {
-		uid := timebasedID(id)
+	recordActivity := func(uid common.ActivityID, id, status string, done, remaining float64, finished bool, err error) error {
 		log.Printf(
 			"Core ----------> %s on %s: base: %s, quote: %s, rate: %s, amount: %s, timestamp: %d ==> Result: id: %s, done: %s, remaining: %s, finished: %t, error: %s",
 			tradeType, exchange.ID(), base.ID, quote.ID,
@@ -115,16 +137,17 @@ func (self ReserveCore) Trade(
 	}
 
 	if err = sanityCheckTrading(exchange, base, quote, rate, amount); err != nil {
-		if sErr := recordActivity("", statusFailed, 0, 0, false, err); sErr != nil {
+		if sErr := recordActivity(timebasedID(""), "", statusFailed, 0, 0, false, err); sErr != nil {
 			log.Printf("failed to save activity record: %s", sErr)
 		}
 		return common.ActivityID{}, 0, 0, false, err
 	}
 
 	id, done, remaining, finished, err := exchange.Trade(tradeType, base, quote, rate, amount, timepoint)
+	// the activity is recorded with the returned ID so it can be looked up
 	uid := timebasedID(id)
 	if err != nil {
-		if sErr := recordActivity(id, statusFailed, done, remaining, finished, err); sErr != nil {
+		if sErr := recordActivity(uid, id, statusFailed, done, remaining, finished, err); sErr != nil {
 			log.Printf("failed to save activity record: %s", sErr)
 		}
 		return uid, done, remaining, finished, err
@@ -137,7 +160,7 @@ func (self ReserveCore) Trade(
 		status = statusSubmitted
 	}
 
-	err = recordActivity(id, status, done, remaining, finished, nil)
+	err = recordActivity(uid, id, status, done, remaining, finished, nil)
 	return uid, done, remaining, finished, err
 }
 
@@ -193,6 +216,13 @@ func (self ReserveCore) Deposit(
 		return common.ActivityID{}, err
 	}
 
+	if err = self.ensureNotSuspended(exchange, token, common.SuspendDeposit, timepoint); err != nil {
+		if sErr := recordActivity(statusFailed, "", "", "", err); sErr != nil {
+			log.Printf("failed to save activity record: %s", sErr)
+		}
+		return common.ActivityID{}, err
+	}
+
 	if ok, err = self.activityStorage.HasPendingDeposit(token, exchange); err != nil {
 		if sErr := recordActivity(statusFailed, "", "", "", err); sErr != nil {
 			log.Printf("failed to save activity record: %s", sErr)
@@ -230,7 +260,26 @@ func (self ReserveCore) Deposit(
 	return uidGenerator(tx.Hash().Hex()), err
 }
 
+// Withdraw withdraws amount of token from exchange to the reserve. If the
+// amount is above the withdraw threshold of token, the withdrawal is not
+// executed but stored for approval, see ConfirmWithdrawal.
 func (self ReserveCore) Withdraw(
+	exchange common.Exchange, token common.Token,
+	amount *big.Int, timepoint uint64,
+	proposer string) (common.ActivityID, *common.WithdrawalApproval, error) {
+	required, err := self.requiresWithdrawalApproval(token, amount)
+	if err != nil {
+		return common.ActivityID{}, nil, err
+	}
+	if required {
+		approval, err := self.proposeWithdrawal(exchange, token, amount, proposer)
+		return common.ActivityID{}, approval, err
+	}
+	id, err := self.withdraw(exchange, token, amount, timepoint)
+	return id, nil, err
+}
+
+func (self ReserveCore) withdraw(
 	exchange common.Exchange, token common.Token,
 	amount *big.Int, timepoint uint64) (common.ActivityID, error) {
 	var err error
@@ -273,6 +322,13 @@ func (self ReserveCore) Withdraw(
 
 	}
 
+	if err = self.ensureNotSuspended(exchange, token, common.SuspendWithdraw, timepoint); err != nil {
+		if sErr := activityRecord("", statusFailed, err); sErr != nil {
+			log.Printf("failed to store activiry record: %s", sErr.Error())
+		}
+		return common.ActivityID{}, err
+	}
+
 	if err = sanityCheckAmount(exchange, token, amount); err != nil {
 		if sErr := activityRecord("", statusFailed, err); sErr != nil {
 			log.Printf("failed to store activiry record: %s", sErr.Error())
@@ -292,6 +348,24 @@ func (self ReserveCore) Withdraw(
 	return timebasedID(id), err
 }
 
+// ensureNotSuspended returns error if the exchange is under maintenance or
+// the action, deposit or withdrawal, of token is suspended on the exchange
+// at timepoint, according to exchange notifications.
+func (self ReserveCore) ensureNotSuspended(exchange common.Exchange, token common.Token, action string, timepoint uint64) error {
+	notifications, err := self.setting.GetExchangeNotifications()
+	if err != nil {
+		return err
+	}
+	exchangeNotifications := notifications[string(exchange.ID())]
+	if noti, ok := exchangeNotifications[common.ExchangeMaintenance][common.AllTokens]; ok && noti.IsActive(timepoint) {
+		return fmt.Errorf("Exchange %s is under maintenance since %d: %s", exchange.ID(), noti.FromTime, noti.Message)
+	}
+	if noti, ok := exchangeNotifications[action][token.ID]; ok && noti.IsActive(timepoint) {
+		return fmt.Errorf("Exchange %s suspended %s of %s since %d: %s", exchange.ID(), common.SuspendedAction(action), token.ID, noti.FromTime, noti.Message)
+	}
+	return nil
+}
+
 func calculateNewGasPrice(initPrice *big.Int, count uint64) *big.Int {
 	// in this case after 5 tries the tx is still not mined.
 	// at this point, 100.1 gwei is not enough but it doesn't matter
@@ -341,7 +415,24 @@ func (self ReserveCore) pendingSetrateInfo(minedNonce uint64) (*big.Int, *big.In
 	return big.NewInt(int64(nonce)), big.NewInt(int64(gasPrice)), count, nil
 }
 
+// setRatesFunc is the signature of Blockchain.SetRates and Blockchain.BuildSetRates.
+type setRatesFunc func(
+	tokens []ethereum.Address,
+	buys []*big.Int,
+	sells []*big.Int,
+	block *big.Int,
+	nonce *big.Int,
+	gasPrice *big.Int) (*types.Transaction, error)
+
 func (self ReserveCore) GetSetRateResult(tokens []common.Token,
+	buys, sells, afpMids []*big.Int,
+	block *big.Int) (*types.Transaction, error) {
+	return self.setRateResult(self.blockchain.SetRates, tokens, buys, sells, afpMids, block)
+}
+
+// setRateResult checks the rates and creates the set rates tx with setRates,
+// replacing the pending set rates tx if there is one.
+func (self ReserveCore) setRateResult(setRates setRatesFunc, tokens []common.Token,
 	buys, sells, afpMids []*big.Int,
 	block *big.Int) (*types.Transaction, error) {
 	var (
@@ -383,7 +474,7 @@ func (self ReserveCore) GetSetRateResult(tokens []common.Token,
 	}
 	if oldNonce != nil {
 		newPrice := calculateNewGasPrice(initPrice, count)
-		tx, err = self.blockchain.SetRates(
+		tx, err = setRates(
 			tokenAddrs, buys, sells, block,
 			oldNonce,
 			newPrice,
@@ -407,7 +498,7 @@ func (self ReserveCore) GetSetRateResult(tokens []common.Token,
 			initPrice = common.GweiToWei(recommendedPrice)
 		}
 		log.Printf("initial set rate tx, init price: %s", initPrice.String())
-		tx, err = self.blockchain.SetRates(
+		tx, err = setRates(
 			tokenAddrs, buys, sells, block,
 			big.NewInt(int64(minedNonce)),
 			initPrice,
@@ -6,6 +6,7 @@ import (
 	"math/big"
 	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/KyberNetwork/reserve-data/common"
 	"github.com/KyberNetwork/reserve-data/settings"
@@ -30,6 +31,12 @@ func (self testExchange) Withdraw(token common.Token, amount *big.Int, address e
 func (self testExchange) Trade(tradeType string, base common.Token, quote common.Token, rate float64, amount float64, timepoint uint64) (id string, done float64, remaining float64, finished bool, err error) {
 	return "tradeid", 10, 5, false, nil
 }
+func (self testExchange) BuildTradeRequest(tradeType string, base, quote common.Token, rate, amount float64) (common.DryRunRequest, error) {
+	return common.DryRunRequest{Method: "POST", URL: "https://bittrex.com/trade"}, nil
+}
+func (self testExchange) BuildWithdrawRequest(token common.Token, amount *big.Int, address ethereum.Address) (common.DryRunRequest, error) {
+	return common.DryRunRequest{Method: "POST", URL: "https://bittrex.com/withdraw"}, nil
+}
 func (self testExchange) CancelOrder(id string, base, quote string) error {
 	return nil
 }
@@ -97,6 +104,23 @@ func (self testBlockchain) SetRates(
 	return tx, nil
 }
 
+func (self testBlockchain) BuildSend(
+	token common.Token,
+	amount *big.Int,
+	address ethereum.Address) (*types.Transaction, error) {
+	return self.Send(token, amount, address)
+}
+
+func (self testBlockchain) BuildSetRates(
+	tokens []ethereum.Address,
+	buys []*big.Int,
+	sells []*big.Int,
+	block *big.Int,
+	nonce *big.Int,
+	gasPrice *big.Int) (*types.Transaction, error) {
+	return self.SetRates(tokens, buys, sells, block, nonce, gasPrice)
+}
+
 func (self testBlockchain) StandardGasPrice() float64 {
 	return 0
 }
@@ -189,6 +213,53 @@ func TestNotAllowDeposit(t *testing.T) {
 	}
 }
 
+func TestSuspendedDepositWithdraw(t *testing.T) {
+	core := getTestCore(false)
+	setting := core.setting.(*settings.Settings)
+	knc := common.NewToken("KNC", "Kyber-coin", "0x1111111111111111111111111111111111111111", 18, true, true, 0)
+	omg := common.NewToken("OMG", "omise-go", "0x1111111111111111111111111111111111111111", 18, true, true, 0)
+	if err := setting.UpdateExchangeNotification("bittrex", common.SuspendDeposit, "KNC", 1000, 0, true, "wallet maintenance"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := core.Deposit(testExchange{}, knc, big.NewInt(10), 2000); err == nil {
+		t.Error("Expected error depositing token of which deposits are suspended")
+	}
+	if _, err := core.Deposit(testExchange{}, omg, big.NewInt(10), 2000); err != nil {
+		t.Errorf("Expected to be able to deposit other token, got %s", err)
+	}
+	if _, _, err := core.Withdraw(testExchange{}, knc, big.NewInt(10), 2000, ""); err != nil {
+		t.Errorf("Expected to be able to withdraw token of which only deposits are suspended, got %s", err)
+	}
+
+	if err := setting.UpdateExchangeNotification("bittrex", common.ExchangeMaintenance, common.AllTokens, 1000, 3000, true, "upgrade"); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := core.Withdraw(testExchange{}, omg, big.NewInt(10), 2000, ""); err == nil {
+		t.Error("Expected error withdrawing from exchange under maintenance")
+	}
+	if _, _, err := core.Withdraw(testExchange{}, omg, big.NewInt(10), 4000, ""); err != nil {
+		t.Errorf("Expected to be able to withdraw after maintenance, got %s", err)
+	}
+
+	// dry runs and withdrawals requiring approval are checked the same way
+	if _, err := core.DryRunDeposit(testExchange{}, knc, big.NewInt(10)); err == nil {
+		t.Error("Expected error dry running deposit of token of which deposits are suspended")
+	}
+	if err := setting.UpdateExchangeNotification("bittrex", common.SuspendWithdraw, "OMG", 1000, 0, true, "wallet maintenance"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := core.DryRunWithdraw(testExchange{}, omg, big.NewInt(10)); err == nil {
+		t.Error("Expected error dry running withdrawal of token of which withdrawals are suspended")
+	}
+	WithWithdrawalApproval(&testWithdrawalApprovalStorage{
+		thresholds: common.WithdrawThresholds{"OMG": 0},
+		approvals:  map[string]common.WithdrawalApproval{},
+	}, time.Hour)(core)
+	if _, approval, err := core.Withdraw(testExchange{}, omg, big.NewInt(10), common.GetTimepoint(), ""); err == nil || approval != nil {
+		t.Error("Expected error proposing withdrawal of token of which withdrawals are suspended")
+	}
+}
+
 func TestCalculateNewGasPrice(t *testing.T) {
 	initPrice := common.GweiToWei(1)
 	newPrice := calculateNewGasPrice(initPrice, 0)
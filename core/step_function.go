@@ -0,0 +1,88 @@
+package core
+
+import (
+	"errors"
+	"math/big"
+	"strconv"
+
+	"github.com/KyberNetwork/reserve-data/common"
+	ethereum "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// StepFunctionBlockchain is implemented by blockchains setting the step
+// functions of tokens on the pricing contract, which setting step functions
+// requires.
+type StepFunctionBlockchain interface {
+	SetQtyStepFunction(token ethereum.Address, xBuy []*big.Int, yBuy []*big.Int, xSell []*big.Int, ySell []*big.Int) (*types.Transaction, error)
+	SetImbalanceStepFunction(token ethereum.Address, xBuy []*big.Int, yBuy []*big.Int, xSell []*big.Int, ySell []*big.Int) (*types.Transaction, error)
+}
+
+// SetStepFunctions submits the step functions of the update of a token to
+// the pricing contract by the pricing operator. Each transaction is recorded
+// as a set step function activity, the ids of the recorded activities are
+// returned. Submitting stops at the first failed transaction.
+func (self ReserveCore) SetStepFunctions(token common.Token, update common.StepFunctionUpdate, timepoint uint64) ([]common.ActivityID, error) {
+	blockchain, ok := self.blockchain.(StepFunctionBlockchain)
+	if !ok {
+		return nil, errors.New("blockchain doesn't support setting step functions")
+	}
+	if err := update.Validate(); err != nil {
+		return nil, err
+	}
+	tokenAddr := ethereum.HexToAddress(token.Address)
+	result := []common.ActivityID{}
+	if f := update.QtyStepFunction; f != nil {
+		tx, err := blockchain.SetQtyStepFunction(tokenAddr, f.XBuy, f.YBuy, f.XSell, f.YSell)
+		id, rErr := self.recordStepFunctionTx(token, "setQtyStepFunction", tx, err, timepoint)
+		if rErr != nil {
+			return result, rErr
+		}
+		result = append(result, id)
+		if err != nil {
+			return result, err
+		}
+	}
+	if f := update.ImbalanceStepFunction; f != nil {
+		tx, err := blockchain.SetImbalanceStepFunction(tokenAddr, f.XBuy, f.YBuy, f.XSell, f.YSell)
+		id, rErr := self.recordStepFunctionTx(token, "setImbalanceStepFunction", tx, err, timepoint)
+		if rErr != nil {
+			return result, rErr
+		}
+		result = append(result, id)
+		if err != nil {
+			return result, err
+		}
+	}
+	return result, nil
+}
+
+func (self ReserveCore) recordStepFunctionTx(token common.Token, method string, tx *types.Transaction, err error, timepoint uint64) (common.ActivityID, error) {
+	result := map[string]interface{}{
+		"tx":    ethereum.Hash{}.Hex(),
+		"error": common.ErrorToString(err),
+	}
+	miningStatus := common.MiningStatusSubmitted
+	if tx != nil {
+		result["tx"] = tx.Hash().Hex()
+		result["nonce"] = strconv.FormatUint(tx.Nonce(), 10)
+		result["gasPrice"] = tx.GasPrice().Text(10)
+	}
+	if err != nil {
+		miningStatus = common.MiningStatusFailed
+	}
+	id := timebasedID(result["tx"].(string))
+	return id, self.activityStorage.Record(
+		common.ActionSetStepFunction,
+		id,
+		"blockchain",
+		map[string]interface{}{
+			"token":  token,
+			"method": method,
+		},
+		result,
+		"",
+		miningStatus,
+		timepoint,
+	)
+}
@@ -0,0 +1,78 @@
+package core
+
+import (
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/KyberNetwork/reserve-data/common"
+	ethereum "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+type testStepFunctionBlockchain struct {
+	testBlockchain
+	nonce         uint64
+	failImbalance bool
+}
+
+func (self *testStepFunctionBlockchain) SetQtyStepFunction(token ethereum.Address, xBuy []*big.Int, yBuy []*big.Int, xSell []*big.Int, ySell []*big.Int) (*types.Transaction, error) {
+	self.nonce++
+	return types.NewTransaction(self.nonce, token, nil, 200000, big.NewInt(1000000000), nil), nil
+}
+
+func (self *testStepFunctionBlockchain) SetImbalanceStepFunction(token ethereum.Address, xBuy []*big.Int, yBuy []*big.Int, xSell []*big.Int, ySell []*big.Int) (*types.Transaction, error) {
+	if self.failImbalance {
+		return nil, errors.New("broadcast failed")
+	}
+	self.nonce++
+	return types.NewTransaction(self.nonce, token, nil, 200000, big.NewInt(1000000000), nil), nil
+}
+
+func TestSetStepFunctions(t *testing.T) {
+	activityStorage := &testOrderActivityStorage{records: map[common.ActivityID]common.ActivityRecord{}}
+	blockchain := &testStepFunctionBlockchain{}
+	core := NewReserveCore(blockchain, activityStorage, testSetting{})
+	knc := common.NewToken("KNC", "Kyber-coin", "0x1111111111111111111111111111111111111111", 18, true, true, 0)
+	update := common.StepFunctionUpdate{
+		QtyStepFunction: &common.QuantityStepFunction{
+			XBuy: []*big.Int{big.NewInt(100), big.NewInt(200)}, YBuy: []*big.Int{big.NewInt(0), big.NewInt(-30)},
+			XSell: []*big.Int{big.NewInt(100)}, YSell: []*big.Int{big.NewInt(0)},
+		},
+		ImbalanceStepFunction: &common.ImbalanceStepFunction{
+			XBuy: []*big.Int{big.NewInt(-100), big.NewInt(100)}, YBuy: []*big.Int{big.NewInt(0), big.NewInt(-30)},
+			XSell: []*big.Int{big.NewInt(-100), big.NewInt(100)}, YSell: []*big.Int{big.NewInt(-30), big.NewInt(0)},
+		},
+	}
+	ids, err := core.SetStepFunctions(knc, update, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ids) != 2 {
+		t.Fatalf("Expected 2 activities, got %d", len(ids))
+	}
+	for _, id := range ids {
+		activity := activityStorage.records[id]
+		if activity.Action != common.ActionSetStepFunction || activity.MiningStatus != common.MiningStatusSubmitted {
+			t.Errorf("Expected submitted set step function activity, got %+v", activity)
+		}
+	}
+
+	// the sell side of the imbalance step function must not improve as imbalance grows
+	invalid := update
+	invalid.ImbalanceStepFunction = &common.ImbalanceStepFunction{
+		XSell: []*big.Int{big.NewInt(-100), big.NewInt(100)}, YSell: []*big.Int{big.NewInt(0), big.NewInt(-30)},
+	}
+	if _, err = core.SetStepFunctions(knc, invalid, 2); err == nil {
+		t.Error("Expected error of invalid imbalance step function")
+	}
+
+	blockchain.failImbalance = true
+	ids, err = core.SetStepFunctions(knc, update, 3)
+	if err == nil {
+		t.Fatal("Expected error of failed imbalance step function tx")
+	}
+	if len(ids) != 2 || activityStorage.records[ids[1]].MiningStatus != common.MiningStatusFailed {
+		t.Errorf("Expected failed activity recorded for the imbalance step function, got %v", ids)
+	}
+}
@@ -0,0 +1,349 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/KyberNetwork/reserve-data/common"
+	"github.com/KyberNetwork/reserve-data/settings"
+	ethereum "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// DefaultTokenListingInterval is the default interval pending token listings
+// are checked for mined transactions at.
+const DefaultTokenListingInterval = 30 * time.Second
+
+// TokenListingStorage is the interface contains all database operations of
+// token listings, and the token operations to activate listed tokens.
+type TokenListingStorage interface {
+	// StoreTokenListing stores the listing, replacing the stored one of the
+	// same token.
+	StoreTokenListing(listing common.TokenListing) error
+	GetTokenListing(id string) (common.TokenListing, error)
+	GetTokenListings() ([]common.TokenListing, error)
+	GetTokenByID(id string) (common.Token, error)
+	UpdateToken(t common.Token, timestamp uint64) error
+	GetInternalTokens() ([]common.Token, error)
+}
+
+// TokenListingBlockchain is implemented by blockchains building and
+// submitting the transactions of listing tokens on chain, which token
+// listing requires.
+type TokenListingBlockchain interface {
+	BuildTokenListingTxs(token common.Token, params common.TokenListingParams, withdrawAddresses []ethereum.Address) ([]common.TokenListingTx, error)
+	SubmitTokenListingTx(tx common.TokenListingTx) (*types.Transaction, error)
+	// CheckTokenListingAdmin returns error if the signer of submitted
+	// listing transactions is not admin of the contracts.
+	CheckTokenListingAdmin() error
+	LoadAndSetTokenIndices(tokenAddrs []ethereum.Address) error
+}
+
+// WithTokenListing enables listing tokens on chain. The state of listings is
+// kept in storage, pending listings are checked every interval once token
+// listing is run.
+func WithTokenListing(storage TokenListingStorage, interval time.Duration) ReserveCoreOption {
+	return func(self *ReserveCore) {
+		self.tokenListingStorage = storage
+		self.tokenListingInterval = interval
+		self.tokenListingMu = &sync.Mutex{}
+	}
+}
+
+var errTokenListingDisabled = errors.New("token listing is not enabled")
+
+func (self ReserveCore) tokenListingBlockchain() (TokenListingBlockchain, error) {
+	if self.tokenListingStorage == nil {
+		return nil, errTokenListingDisabled
+	}
+	blockchain, ok := self.blockchain.(TokenListingBlockchain)
+	if !ok {
+		return nil, errors.New("blockchain doesn't support token listing")
+	}
+	return blockchain, nil
+}
+
+// withdrawAddresses returns the addresses the reserve withdraws token to for
+// depositing to exchanges, ordered by exchange.
+func withdrawAddresses(token common.Token, exchanges map[string]common.TokenExchangeSetting) ([]ethereum.Address, error) {
+	ids := []string{}
+	for id := range exchanges {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	result := []ethereum.Address{}
+	for _, id := range ids {
+		exchange, ok := common.SupportedExchanges[common.ExchangeID(id)]
+		if !ok {
+			return nil, fmt.Errorf("exchange %s is not in current deployment", id)
+		}
+		address, supported := exchange.Address(token)
+		if !supported {
+			return nil, fmt.Errorf("exchange %s doesn't support token %s", id, token.ID)
+		}
+		result = append(result, address)
+	}
+	return result, nil
+}
+
+// ListToken lists the token of update on chain with its listing params. The
+// token must be stored inactive, it is activated once all listing
+// transactions are mined. In submit mode, the transactions are signed and
+// broadcasted by the pricing operator, which must be admin of the contracts,
+// and recorded as list token activities.
+// In bundle mode, they are returned unsigned to be sent by the admin wallet,
+// then their hashes are submitted by SubmitTokenListingTxs.
+func (self ReserveCore) ListToken(update common.TokenUpdate, mode string, timepoint uint64) (common.TokenListing, error) {
+	blockchain, err := self.tokenListingBlockchain()
+	if err != nil {
+		return common.TokenListing{}, err
+	}
+	token := update.Token
+	if update.Listing == nil {
+		return common.TokenListing{}, fmt.Errorf("token %s has no listing params", token.ID)
+	}
+	if mode != common.TokenListingSubmit && mode != common.TokenListingBundle {
+		return common.TokenListing{}, fmt.Errorf("token listing mode %s is not supported", mode)
+	}
+	if mode == common.TokenListingSubmit {
+		if err = blockchain.CheckTokenListingAdmin(); err != nil {
+			return common.TokenListing{}, err
+		}
+	}
+	self.tokenListingMu.Lock()
+	defer self.tokenListingMu.Unlock()
+	previous, err := self.tokenListingStorage.GetTokenListing(token.ID)
+	switch {
+	case err == nil && !previous.IsFinished():
+		return common.TokenListing{}, fmt.Errorf("token %s is being listed", token.ID)
+	case err != nil && err != settings.ErrTokenListingNotFound:
+		return common.TokenListing{}, err
+	}
+	addresses, err := withdrawAddresses(token, update.Exchanges)
+	if err != nil {
+		return common.TokenListing{}, err
+	}
+	txs, err := blockchain.BuildTokenListingTxs(token, *update.Listing, addresses)
+	if err != nil {
+		return common.TokenListing{}, err
+	}
+	listing := common.TokenListing{
+		Token:     token.ID,
+		Mode:      mode,
+		Status:    common.TokenListingUnsigned,
+		Txs:       txs,
+		CreatedAt: timepoint,
+		UpdatedAt: timepoint,
+	}
+	if mode == common.TokenListingSubmit {
+		listing.Status = common.TokenListingPending
+		for i := range listing.Txs {
+			tx, sErr := blockchain.SubmitTokenListingTx(listing.Txs[i])
+			if rErr := self.recordListingTx(&listing.Txs[i], token, tx, sErr, timepoint); rErr != nil {
+				log.Printf("Token listing: recording tx %s of token %s failed: %s", listing.Txs[i].Method, token.ID, rErr)
+			}
+			if sErr != nil {
+				// later txs would fail without the previous ones
+				listing.Status = common.TokenListingFailed
+				listing.Error = fmt.Sprintf("submitting %s failed: %s", listing.Txs[i].Method, sErr)
+				break
+			}
+		}
+	}
+	if err = self.tokenListingStorage.StoreTokenListing(listing); err != nil {
+		return listing, err
+	}
+	return listing, nil
+}
+
+// SubmitTokenListingTxs submits the hashes of the transactions of a bundle
+// listing sent by the admin wallet, in the order of the listing
+// transactions, so they are tracked as list token activities.
+func (self ReserveCore) SubmitTokenListingTxs(tokenID string, hashes []string, timepoint uint64) (common.TokenListing, error) {
+	if _, err := self.tokenListingBlockchain(); err != nil {
+		return common.TokenListing{}, err
+	}
+	self.tokenListingMu.Lock()
+	defer self.tokenListingMu.Unlock()
+	listing, err := self.tokenListingStorage.GetTokenListing(tokenID)
+	if err != nil {
+		return listing, err
+	}
+	if listing.Status != common.TokenListingUnsigned {
+		return listing, fmt.Errorf("token listing of %s is %s, not waiting for transactions", tokenID, listing.Status)
+	}
+	if len(hashes) != len(listing.Txs) {
+		return listing, fmt.Errorf("expected %d transactions, got %d", len(listing.Txs), len(hashes))
+	}
+	token, err := self.tokenListingStorage.GetTokenByID(tokenID)
+	if err != nil {
+		return listing, err
+	}
+	for i, hash := range hashes {
+		if !isTxHash(hash) {
+			return listing, fmt.Errorf("invalid transaction hash %s", hash)
+		}
+		listing.Txs[i].Hash = ethereum.HexToHash(hash).Hex()
+	}
+	for i := range listing.Txs {
+		if rErr := self.recordListingTx(&listing.Txs[i], token, nil, nil, timepoint); rErr != nil {
+			return listing, rErr
+		}
+	}
+	listing.Status = common.TokenListingPending
+	listing.UpdatedAt = timepoint
+	if err = self.tokenListingStorage.StoreTokenListing(listing); err != nil {
+		return listing, err
+	}
+	return listing, nil
+}
+
+func isTxHash(hash string) bool {
+	data, err := hexutil.Decode(hash)
+	return err == nil && len(data) == ethereum.HashLength
+}
+
+// recordListingTx records a listing transaction as a list token activity.
+// The hash of listingTx is set to the one of tx if it is submitted by core.
+func (self ReserveCore) recordListingTx(listingTx *common.TokenListingTx, token common.Token, tx *types.Transaction, err error, timepoint uint64) error {
+	result := map[string]interface{}{
+		"error": common.ErrorToString(err),
+	}
+	if tx != nil {
+		listingTx.Hash = tx.Hash().Hex()
+		result["nonce"] = strconv.FormatUint(tx.Nonce(), 10)
+		result["gasPrice"] = tx.GasPrice().Text(10)
+	}
+	miningStatus := common.MiningStatusSubmitted
+	if listingTx.Hash == "" {
+		listingTx.Hash = ethereum.Hash{}.Hex()
+	}
+	if err != nil {
+		miningStatus = common.MiningStatusFailed
+	}
+	result["tx"] = listingTx.Hash
+	listingTx.ActivityID = timebasedID(listingTx.Hash)
+	return self.activityStorage.Record(
+		common.ActionListToken,
+		listingTx.ActivityID,
+		"blockchain",
+		map[string]interface{}{
+			"token":  token,
+			"method": listingTx.Method,
+			"to":     listingTx.To,
+		},
+		result,
+		"",
+		miningStatus,
+		timepoint,
+	)
+}
+
+// GetTokenListings returns the on chain listings of all tokens.
+func (self ReserveCore) GetTokenListings() ([]common.TokenListing, error) {
+	if self.tokenListingStorage == nil {
+		return nil, errTokenListingDisabled
+	}
+	return self.tokenListingStorage.GetTokenListings()
+}
+
+// RunTokenListing checks pending token listings every token listing interval
+// in background, including the ones pending before a restart.
+func (self ReserveCore) RunTokenListing() error {
+	if self.tokenListingStorage == nil {
+		return nil
+	}
+	ticker := time.NewTicker(self.tokenListingInterval)
+	go func() {
+		for range ticker.C {
+			self.CheckTokenListings(common.GetTimepoint())
+		}
+	}()
+	return nil
+}
+
+// CheckTokenListings activates the tokens of pending listings which
+// transactions are all mined, and reloads the token indices. A listing fails
+// if any of its transactions fails, its token is left inactive.
+func (self ReserveCore) CheckTokenListings(timepoint uint64) {
+	blockchain, err := self.tokenListingBlockchain()
+	if err != nil {
+		return
+	}
+	self.tokenListingMu.Lock()
+	defer self.tokenListingMu.Unlock()
+	listings, err := self.tokenListingStorage.GetTokenListings()
+	if err != nil {
+		log.Printf("Token listing: cannot get token listings: %s", err)
+		return
+	}
+	for _, listing := range listings {
+		if listing.Status != common.TokenListingPending {
+			continue
+		}
+		mined, cErr := self.checkTokenListing(&listing)
+		if cErr != nil {
+			log.Printf("Token listing: checking listing of %s failed: %s", listing.Token, cErr)
+			continue
+		}
+		if mined {
+			if aErr := self.activateListedToken(blockchain, listing.Token, timepoint); aErr != nil {
+				log.Printf("Token listing: activating token %s failed: %s", listing.Token, aErr)
+				continue
+			}
+			listing.Status = common.TokenListingDone
+		}
+		if listing.Status == common.TokenListingPending {
+			continue
+		}
+		listing.UpdatedAt = timepoint
+		if sErr := self.tokenListingStorage.StoreTokenListing(listing); sErr != nil {
+			log.Printf("Token listing: storing listing of %s failed: %s", listing.Token, sErr)
+		}
+	}
+}
+
+// checkTokenListing returns true if all transactions of listing are mined.
+// It marks listing failed if any of them fails.
+func (self ReserveCore) checkTokenListing(listing *common.TokenListing) (bool, error) {
+	mined := true
+	for _, tx := range listing.Txs {
+		activity, err := self.activityStorage.GetActivity(tx.ActivityID)
+		if err != nil {
+			return false, err
+		}
+		switch activity.MiningStatus {
+		case common.MiningStatusMined:
+		case common.MiningStatusFailed:
+			listing.Status = common.TokenListingFailed
+			listing.Error = fmt.Sprintf("transaction %s of %s failed", tx.Hash, tx.Method)
+			return false, nil
+		default:
+			mined = false
+		}
+	}
+	return mined, nil
+}
+
+func (self ReserveCore) activateListedToken(blockchain TokenListingBlockchain, tokenID string, timepoint uint64) error {
+	token, err := self.tokenListingStorage.GetTokenByID(tokenID)
+	if err != nil {
+		return err
+	}
+	token.Active = true
+	token.LastActivationChange = timepoint
+	if err = self.tokenListingStorage.UpdateToken(token, timepoint); err != nil {
+		return err
+	}
+	tokens, err := self.tokenListingStorage.GetInternalTokens()
+	if err != nil {
+		return err
+	}
+	return blockchain.LoadAndSetTokenIndices(common.GetTokenAddressesList(tokens))
+}
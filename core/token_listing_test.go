@@ -0,0 +1,199 @@
+package core
+
+import (
+	"errors"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/KyberNetwork/reserve-data/common"
+	"github.com/KyberNetwork/reserve-data/settings"
+	ethereum "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+type testListingBlockchain struct {
+	testBlockchain
+	nonce    uint64
+	indices  []ethereum.Address
+	notAdmin bool
+}
+
+func (self *testListingBlockchain) BuildTokenListingTxs(token common.Token, params common.TokenListingParams, withdrawAddresses []ethereum.Address) ([]common.TokenListingTx, error) {
+	result := []common.TokenListingTx{
+		{Method: "addToken"},
+		{Method: "enableTokenTrade"},
+	}
+	for range withdrawAddresses {
+		result = append(result, common.TokenListingTx{Method: "approveWithdrawAddress"})
+	}
+	return result, nil
+}
+
+func (self *testListingBlockchain) SubmitTokenListingTx(tx common.TokenListingTx) (*types.Transaction, error) {
+	self.nonce++
+	return types.NewTransaction(self.nonce, ethereum.Address{}, nil, 200000, big.NewInt(1000000000), nil), nil
+}
+
+func (self *testListingBlockchain) CheckTokenListingAdmin() error {
+	if self.notAdmin {
+		return errors.New("pricing operator is not admin")
+	}
+	return nil
+}
+
+func (self *testListingBlockchain) LoadAndSetTokenIndices(tokenAddrs []ethereum.Address) error {
+	self.indices = tokenAddrs
+	return nil
+}
+
+type testTokenListingStorage struct {
+	tokens   map[string]common.Token
+	listings map[string]common.TokenListing
+}
+
+func (self *testTokenListingStorage) StoreTokenListing(listing common.TokenListing) error {
+	self.listings[listing.Token] = listing
+	return nil
+}
+
+func (self *testTokenListingStorage) GetTokenListing(id string) (common.TokenListing, error) {
+	listing, ok := self.listings[id]
+	if !ok {
+		return listing, settings.ErrTokenListingNotFound
+	}
+	return listing, nil
+}
+
+func (self *testTokenListingStorage) GetTokenListings() ([]common.TokenListing, error) {
+	result := []common.TokenListing{}
+	for _, listing := range self.listings {
+		result = append(result, listing)
+	}
+	return result, nil
+}
+
+func (self *testTokenListingStorage) GetTokenByID(id string) (common.Token, error) {
+	token, ok := self.tokens[id]
+	if !ok {
+		return token, errors.New("token not found")
+	}
+	return token, nil
+}
+
+func (self *testTokenListingStorage) UpdateToken(t common.Token, timestamp uint64) error {
+	self.tokens[t.ID] = t
+	return nil
+}
+
+func (self *testTokenListingStorage) GetInternalTokens() ([]common.Token, error) {
+	result := []common.Token{}
+	for _, token := range self.tokens {
+		if token.Active && token.Internal {
+			result = append(result, token)
+		}
+	}
+	return result, nil
+}
+
+func setMiningStatus(activityStorage *testOrderActivityStorage, listing common.TokenListing, status string) {
+	for _, tx := range listing.Txs {
+		activity := activityStorage.records[tx.ActivityID]
+		activity.MiningStatus = status
+		activityStorage.records[tx.ActivityID] = activity
+	}
+}
+
+func TestTokenListing(t *testing.T) {
+	exchange := testExchange{}
+	common.SupportedExchanges[exchange.ID()] = exchange
+	defer delete(common.SupportedExchanges, exchange.ID())
+
+	knc := common.NewToken("KNC", "Kyber-coin", "0x1111111111111111111111111111111111111111", 18, false, true, 0)
+	omg := common.NewToken("OMG", "OmiseGo", "0x2222222222222222222222222222222222222222", 18, false, true, 0)
+	storage := &testTokenListingStorage{
+		tokens:   map[string]common.Token{"KNC": knc, "OMG": omg},
+		listings: map[string]common.TokenListing{},
+	}
+	activityStorage := &testOrderActivityStorage{records: map[common.ActivityID]common.ActivityRecord{}}
+	blockchain := &testListingBlockchain{}
+	core := NewReserveCore(
+		blockchain,
+		activityStorage,
+		testSetting{},
+		WithTokenListing(storage, DefaultTokenListingInterval),
+	)
+	exchanges := map[string]common.TokenExchangeSetting{"bittrex": {}}
+
+	// submit mode
+	if _, err := core.ListToken(common.TokenUpdate{Token: knc, Exchanges: exchanges}, common.TokenListingSubmit, 1); err == nil {
+		t.Error("Expected error of missing listing params")
+	}
+	update := common.TokenUpdate{Token: knc, Exchanges: exchanges, Listing: &common.TokenListingParams{}}
+	blockchain.notAdmin = true
+	if _, err := core.ListToken(update, common.TokenListingSubmit, 1); err == nil || blockchain.nonce != 0 {
+		t.Error("Expected error of submitting listing txs without admin")
+	}
+	blockchain.notAdmin = false
+	listing, err := core.ListToken(update, common.TokenListingSubmit, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if listing.Status != common.TokenListingPending || len(listing.Txs) != 3 {
+		t.Fatalf("Expected pending listing of 3 txs, got %+v", listing)
+	}
+	for _, tx := range listing.Txs {
+		activity := activityStorage.records[tx.ActivityID]
+		if activity.Action != common.ActionListToken || activity.MiningStatus != common.MiningStatusSubmitted ||
+			activity.Result["tx"] != tx.Hash {
+			t.Errorf("Expected submitted list token activity of tx %s, got %+v", tx.Hash, activity)
+		}
+	}
+	if _, err = core.ListToken(update, common.TokenListingSubmit, 2); err == nil {
+		t.Error("Expected error of listing token twice")
+	}
+	core.CheckTokenListings(3)
+	if storage.listings["KNC"].Status != common.TokenListingPending || storage.tokens["KNC"].Active {
+		t.Errorf("Expected token inactive before listing txs are mined, got %+v", storage.listings["KNC"])
+	}
+	setMiningStatus(activityStorage, listing, common.MiningStatusMined)
+	core.CheckTokenListings(4)
+	if storage.listings["KNC"].Status != common.TokenListingDone {
+		t.Errorf("Expected listing done, got %+v", storage.listings["KNC"])
+	}
+	if token := storage.tokens["KNC"]; !token.Active || token.LastActivationChange != 4 {
+		t.Errorf("Expected token activated at 4, got %+v", token)
+	}
+	if len(blockchain.indices) != 1 || blockchain.indices[0] != ethereum.HexToAddress(knc.Address) {
+		t.Errorf("Expected token indices reloaded with KNC, got %v", blockchain.indices)
+	}
+
+	// bundle mode
+	update = common.TokenUpdate{Token: omg, Exchanges: exchanges, Listing: &common.TokenListingParams{}}
+	listing, err = core.ListToken(update, common.TokenListingBundle, 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if listing.Status != common.TokenListingUnsigned || listing.Txs[0].Hash != "" {
+		t.Fatalf("Expected unsigned listing, got %+v", listing)
+	}
+	hash := "0x" + strings.Repeat("ab", ethereum.HashLength)
+	if _, err = core.SubmitTokenListingTxs("OMG", []string{hash}, 6); err == nil {
+		t.Error("Expected error of missing listing txs")
+	}
+	if _, err = core.SubmitTokenListingTxs("OMG", []string{hash, hash, "0x12"}, 6); err == nil {
+		t.Error("Expected error of invalid tx hash")
+	}
+	listing, err = core.SubmitTokenListingTxs("OMG", []string{hash, hash, hash}, 6)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if listing.Status != common.TokenListingPending || activityStorage.records[listing.Txs[2].ActivityID].Result["tx"] != hash {
+		t.Fatalf("Expected pending listing tracking submitted txs, got %+v", listing)
+	}
+	setMiningStatus(activityStorage, listing, common.MiningStatusFailed)
+	core.CheckTokenListings(7)
+	if storage.listings["OMG"].Status != common.TokenListingFailed || storage.tokens["OMG"].Active {
+		t.Errorf("Expected listing failed and token inactive, got %+v", storage.listings["OMG"])
+	}
+}
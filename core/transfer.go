@@ -0,0 +1,88 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"math/big"
+	"strconv"
+
+	"github.com/KyberNetwork/reserve-data/common"
+)
+
+// SubAccountExchange is implemented by exchanges supporting internal
+// transfers between their sub-accounts. Sub-accounts are given by their
+// names, the master account by an empty name.
+type SubAccountExchange interface {
+	TransferSubAccount(token common.Token, amount *big.Int, from, to string, timepoint uint64) (string, error)
+}
+
+// checkTransfer returns the exchange as SubAccountExchange if the transfer
+// is valid.
+func checkTransfer(exchange common.Exchange, amount *big.Int, from, to string) (SubAccountExchange, error) {
+	subAccountExchange, ok := exchange.(SubAccountExchange)
+	if !ok {
+		return nil, fmt.Errorf("Exchange %s doesn't support sub-account transfers", exchange.ID())
+	}
+	if from == to {
+		return nil, errors.New("cannot transfer to the same sub-account")
+	}
+	if amount.Sign() <= 0 {
+		return nil, errors.New("Amount is too small!!!")
+	}
+	return subAccountExchange, nil
+}
+
+// Transfer transfers amount of token between sub-accounts of the exchange
+// without moving it on chain. It is tracked as a transfer activity until the
+// exchange reports it done.
+func (self ReserveCore) Transfer(
+	exchange common.Exchange,
+	token common.Token,
+	amount *big.Int,
+	from, to string,
+	timepoint uint64) (common.ActivityID, error) {
+	activityRecord := func(id, status string, err error) error {
+		uid := timebasedID(id)
+		log.Printf(
+			"Core ----------> Transfer on %s: token: %s, amount: %s, from: %q, to: %q, timestamp: %d ==> Result: id: %s, error: %s",
+			exchange.ID(), token.ID, amount.Text(10), from, to, timepoint, id, common.ErrorToString(err),
+		)
+		return self.activityStorage.Record(
+			common.ActionTransfer,
+			uid,
+			string(exchange.ID()),
+			map[string]interface{}{
+				"exchange":  exchange,
+				"token":     token,
+				"amount":    strconv.FormatFloat(common.BigToFloat(amount, token.Decimals), 'f', -1, 64),
+				"from":      from,
+				"to":        to,
+				"timepoint": timepoint,
+			}, map[string]interface{}{
+				"id":    id,
+				"error": common.ErrorToString(err),
+			},
+			status,
+			"",
+			timepoint,
+		)
+	}
+
+	subAccountExchange, err := checkTransfer(exchange, amount, from, to)
+	if err != nil {
+		if sErr := activityRecord("", statusFailed, err); sErr != nil {
+			log.Printf("failed to store activity record: %s", sErr.Error())
+		}
+		return common.ActivityID{}, err
+	}
+	id, err := subAccountExchange.TransferSubAccount(token, amount, from, to, timepoint)
+	if err != nil {
+		if sErr := activityRecord("", statusFailed, err); sErr != nil {
+			log.Printf("failed to store activity record: %s", sErr.Error())
+		}
+		return common.ActivityID{}, err
+	}
+	err = activityRecord(id, statusSubmitted, nil)
+	return timebasedID(id), err
+}
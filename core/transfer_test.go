@@ -0,0 +1,46 @@
+package core
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/KyberNetwork/reserve-data/common"
+)
+
+type testSubAccountExchange struct {
+	testExchange
+	transfers int
+}
+
+func (self *testSubAccountExchange) TransferSubAccount(token common.Token, amount *big.Int, from, to string, timepoint uint64) (string, error) {
+	self.transfers++
+	return "11945860693", nil
+}
+
+func TestTransfer(t *testing.T) {
+	core := getTestCore(false)
+	token := common.NewToken("KNC", "Kyber-coin", "0x1111111111111111111111111111111111111111", 18, true, true, 0)
+	amount := common.FloatToBigInt(10, 18)
+	timepoint := common.GetTimepoint()
+
+	if _, err := core.Transfer(testExchange{}, token, amount, "", "market-making", timepoint); err == nil {
+		t.Error("Expected error transferring on exchange without sub-accounts")
+	}
+	exchange := &testSubAccountExchange{}
+	if _, err := core.Transfer(exchange, token, amount, "market-making", "market-making", timepoint); err == nil {
+		t.Error("Expected error transferring to the same sub-account")
+	}
+	if _, err := core.Transfer(exchange, token, big.NewInt(0), "", "market-making", timepoint); err == nil {
+		t.Error("Expected error transferring zero amount")
+	}
+	if exchange.transfers != 0 {
+		t.Fatalf("Expected invalid transfers not to be sent, got %d", exchange.transfers)
+	}
+	id, err := core.Transfer(exchange, token, amount, "", "market-making", timepoint)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if id.EID != "11945860693" || exchange.transfers != 1 {
+		t.Errorf("Expected transfer 11945860693 sent, got %s", id.EID)
+	}
+}
@@ -0,0 +1,247 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"math/big"
+	"strconv"
+	"time"
+
+	"github.com/KyberNetwork/reserve-data/common"
+)
+
+// DefaultWithdrawalApprovalExpiry is the default duration a withdrawal
+// waits for approval before it expires.
+const DefaultWithdrawalApprovalExpiry = time.Hour
+
+// WithdrawalApprovalStorage is the interface contains all database operations
+// of withdrawal approvals.
+type WithdrawalApprovalStorage interface {
+	GetWithdrawThresholds() (common.WithdrawThresholds, error)
+	StoreWithdrawThresholds(thresholds common.WithdrawThresholds) error
+
+	// StoreWithdrawalApproval stores a new withdrawal approval, it returns
+	// an error if the id exists already.
+	StoreWithdrawalApproval(approval common.WithdrawalApproval) error
+	// UpdateWithdrawalApproval replaces the stored withdrawal approval of
+	// the same id, only if its status is still the given one.
+	UpdateWithdrawalApproval(approval common.WithdrawalApproval, status string) error
+	GetWithdrawalApproval(id string) (common.WithdrawalApproval, error)
+	// GetWithdrawalApprovals returns withdrawal approvals proposed in
+	// [fromTime, toTime], ordered by proposed time.
+	GetWithdrawalApprovals(fromTime, toTime uint64) ([]common.WithdrawalApproval, error)
+	// GetPendingWithdrawalApprovals returns withdrawal approvals of pending
+	// status, ordered by proposed time.
+	GetPendingWithdrawalApprovals() ([]common.WithdrawalApproval, error)
+}
+
+// WithWithdrawalApproval enables approval of withdrawals above the withdraw
+// thresholds stored in storage. Pending withdrawals expire after expiry.
+func WithWithdrawalApproval(storage WithdrawalApprovalStorage, expiry time.Duration) ReserveCoreOption {
+	return func(self *ReserveCore) {
+		self.withdrawalApproval = storage
+		self.withdrawalApprovalExpiry = expiry
+	}
+}
+
+var errWithdrawalApprovalDisabled = errors.New("withdrawal approval is not enabled")
+
+// requiresWithdrawalApproval returns true if amount is above the withdraw
+// threshold of token.
+func (self ReserveCore) requiresWithdrawalApproval(token common.Token, amount *big.Int) (bool, error) {
+	if self.withdrawalApproval == nil {
+		return false, nil
+	}
+	thresholds, err := self.withdrawalApproval.GetWithdrawThresholds()
+	if err != nil {
+		return false, err
+	}
+	threshold, ok := thresholds[token.ID]
+	if !ok {
+		return false, nil
+	}
+	return common.BigToFloat(amount, token.Decimals) > threshold, nil
+}
+
+func (self ReserveCore) proposeWithdrawal(
+	exchange common.Exchange, token common.Token,
+	amount *big.Int, proposer string) (*common.WithdrawalApproval, error) {
+	if _, supported := exchange.Address(token); !supported {
+		return nil, fmt.Errorf("Exchange %s doesn't support token %s", exchange.ID(), token.ID)
+	}
+	now := common.GetTimepoint()
+	if err := self.ensureNotSuspended(exchange, token, common.SuspendWithdraw, now); err != nil {
+		return nil, err
+	}
+	if err := sanityCheckAmount(exchange, token, amount); err != nil {
+		return nil, err
+	}
+	approval := common.WithdrawalApproval{
+		ID:         strconv.FormatInt(time.Now().UnixNano(), 10),
+		Exchange:   exchange.ID(),
+		Token:      token.ID,
+		Amount:     amount.Text(10),
+		Status:     common.WithdrawalApprovalPending,
+		ProposedBy: proposer,
+		ProposedAt: now,
+		ExpiresAt:  now + uint64(self.withdrawalApprovalExpiry/time.Millisecond),
+	}
+	log.Printf(
+		"Core ----------> Withdraw from %s: token: %s, amount: %s requires approval ==> id: %s, proposed by: %s",
+		exchange.ID(), token.ID, amount.Text(10), approval.ID, proposer,
+	)
+	if err := self.withdrawalApproval.StoreWithdrawalApproval(approval); err != nil {
+		return nil, err
+	}
+	return &approval, nil
+}
+
+// GetWithdrawThresholds returns the withdraw thresholds of tokens.
+func (self ReserveCore) GetWithdrawThresholds() (common.WithdrawThresholds, error) {
+	if self.withdrawalApproval == nil {
+		return nil, errWithdrawalApprovalDisabled
+	}
+	return self.withdrawalApproval.GetWithdrawThresholds()
+}
+
+// SetWithdrawThresholds replaces the withdraw thresholds of tokens.
+func (self ReserveCore) SetWithdrawThresholds(thresholds common.WithdrawThresholds) error {
+	if self.withdrawalApproval == nil {
+		return errWithdrawalApprovalDisabled
+	}
+	for tokenID, threshold := range thresholds {
+		if threshold < 0 {
+			return fmt.Errorf("withdraw threshold of %s is negative", tokenID)
+		}
+	}
+	return self.withdrawalApproval.StoreWithdrawThresholds(thresholds)
+}
+
+// GetPendingWithdrawals returns the withdrawals waiting for approval. The
+// expired ones are marked as expired and left out.
+func (self ReserveCore) GetPendingWithdrawals() ([]common.WithdrawalApproval, error) {
+	if self.withdrawalApproval == nil {
+		return nil, errWithdrawalApprovalDisabled
+	}
+	approvals, err := self.withdrawalApproval.GetPendingWithdrawalApprovals()
+	if err != nil {
+		return nil, err
+	}
+	result := []common.WithdrawalApproval{}
+	now := common.GetTimepoint()
+	for _, approval := range approvals {
+		if approval.IsExpired(now) {
+			self.expireWithdrawal(approval)
+			continue
+		}
+		result = append(result, approval)
+	}
+	return result, nil
+}
+
+// GetWithdrawalApprovals returns all withdrawal approvals proposed in
+// [fromTime, toTime] with their reviews.
+func (self ReserveCore) GetWithdrawalApprovals(fromTime, toTime uint64) ([]common.WithdrawalApproval, error) {
+	if self.withdrawalApproval == nil {
+		return nil, errWithdrawalApprovalDisabled
+	}
+	return self.withdrawalApproval.GetWithdrawalApprovals(fromTime, toTime)
+}
+
+func (self ReserveCore) expireWithdrawal(approval common.WithdrawalApproval) {
+	approval.Status = common.WithdrawalApprovalExpired
+	if err := self.withdrawalApproval.UpdateWithdrawalApproval(approval, common.WithdrawalApprovalPending); err != nil {
+		log.Printf("failed to expire withdrawal %s: %s", approval.ID, err)
+	}
+}
+
+// pendingWithdrawal returns the withdrawal of given id if it is still pending.
+func (self ReserveCore) pendingWithdrawal(id string) (common.WithdrawalApproval, error) {
+	if self.withdrawalApproval == nil {
+		return common.WithdrawalApproval{}, errWithdrawalApprovalDisabled
+	}
+	approval, err := self.withdrawalApproval.GetWithdrawalApproval(id)
+	if err != nil {
+		return approval, err
+	}
+	if approval.IsExpired(common.GetTimepoint()) {
+		self.expireWithdrawal(approval)
+		return approval, fmt.Errorf("withdrawal %s is expired", id)
+	}
+	if approval.Status != common.WithdrawalApprovalPending {
+		return approval, fmt.Errorf("withdrawal %s is %s already", id, approval.Status)
+	}
+	return approval, nil
+}
+
+// ConfirmWithdrawal executes the pending withdrawal of given id. The
+// confirmer must be different from the proposer of the withdrawal.
+func (self ReserveCore) ConfirmWithdrawal(id, confirmer string, timepoint uint64) (common.WithdrawalApproval, error) {
+	approval, err := self.pendingWithdrawal(id)
+	if err != nil {
+		return approval, err
+	}
+	if confirmer == approval.ProposedBy {
+		return approval, fmt.Errorf("withdrawal %s must be confirmed by another key than %s", id, confirmer)
+	}
+	exchange, err := common.GetExchange(string(approval.Exchange))
+	if err != nil {
+		return approval, err
+	}
+	tokens, err := self.setting.GetInternalTokens()
+	if err != nil {
+		return approval, err
+	}
+	var (
+		token common.Token
+		found bool
+	)
+	for _, t := range tokens {
+		if t.ID == approval.Token {
+			token, found = t, true
+			break
+		}
+	}
+	if !found {
+		return approval, fmt.Errorf("token %s is not an internal token", approval.Token)
+	}
+	amount, ok := big.NewInt(0).SetString(approval.Amount, 10)
+	if !ok {
+		return approval, fmt.Errorf("invalid withdrawal amount %s", approval.Amount)
+	}
+
+	// the withdrawal is marked as confirmed before it is executed, so it
+	// can't be confirmed twice
+	approval.Status = common.WithdrawalApprovalConfirmed
+	approval.ReviewedBy = confirmer
+	approval.ReviewedAt = common.GetTimepoint()
+	if err = self.withdrawalApproval.UpdateWithdrawalApproval(approval, common.WithdrawalApprovalPending); err != nil {
+		return approval, err
+	}
+	activityID, err := self.withdraw(exchange, token, amount, timepoint)
+	if err != nil {
+		approval.Status = common.WithdrawalApprovalFailed
+		approval.Error = err.Error()
+	} else {
+		approval.ActivityID = &activityID
+	}
+	if uErr := self.withdrawalApproval.UpdateWithdrawalApproval(approval, common.WithdrawalApprovalConfirmed); uErr != nil {
+		log.Printf("failed to store result of withdrawal %s: %s", id, uErr)
+	}
+	return approval, err
+}
+
+// RejectWithdrawal rejects the pending withdrawal of given id, the proposer
+// can reject its own withdrawal.
+func (self ReserveCore) RejectWithdrawal(id, rejecter string) (common.WithdrawalApproval, error) {
+	approval, err := self.pendingWithdrawal(id)
+	if err != nil {
+		return approval, err
+	}
+	approval.Status = common.WithdrawalApprovalRejected
+	approval.ReviewedBy = rejecter
+	approval.ReviewedAt = common.GetTimepoint()
+	err = self.withdrawalApproval.UpdateWithdrawalApproval(approval, common.WithdrawalApprovalPending)
+	return approval, err
+}
@@ -0,0 +1,185 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"testing"
+
+	"github.com/KyberNetwork/reserve-data/common"
+	"github.com/KyberNetwork/reserve-data/settings"
+	ethereum "github.com/ethereum/go-ethereum/common"
+)
+
+type testSetting struct {
+	tokens []common.Token
+}
+
+func (self testSetting) GetAddress(settings.AddressName) (ethereum.Address, error) {
+	return ethereum.Address{}, nil
+}
+
+func (self testSetting) GetInternalTokens() ([]common.Token, error) {
+	return self.tokens, nil
+}
+
+func (self testSetting) GetExchangeNotifications() (common.ExchangeNotifications, error) {
+	return common.ExchangeNotifications{}, nil
+}
+
+func (self testSetting) GetInternalTokenByID(tokenID string) (common.Token, error) {
+	for _, token := range self.tokens {
+		if token.ID == tokenID {
+			return token, nil
+		}
+	}
+	return common.Token{}, fmt.Errorf("token %s is not found", tokenID)
+}
+
+type testWithdrawalApprovalStorage struct {
+	thresholds common.WithdrawThresholds
+	approvals  map[string]common.WithdrawalApproval
+}
+
+func (self *testWithdrawalApprovalStorage) GetWithdrawThresholds() (common.WithdrawThresholds, error) {
+	return self.thresholds, nil
+}
+
+func (self *testWithdrawalApprovalStorage) StoreWithdrawThresholds(thresholds common.WithdrawThresholds) error {
+	self.thresholds = thresholds
+	return nil
+}
+
+func (self *testWithdrawalApprovalStorage) StoreWithdrawalApproval(approval common.WithdrawalApproval) error {
+	self.approvals[approval.ID] = approval
+	return nil
+}
+
+func (self *testWithdrawalApprovalStorage) UpdateWithdrawalApproval(approval common.WithdrawalApproval, status string) error {
+	if self.approvals[approval.ID].Status != status {
+		return errors.New("status mismatched")
+	}
+	self.approvals[approval.ID] = approval
+	return nil
+}
+
+func (self *testWithdrawalApprovalStorage) GetWithdrawalApproval(id string) (common.WithdrawalApproval, error) {
+	approval, ok := self.approvals[id]
+	if !ok {
+		return approval, errors.New("not found")
+	}
+	return approval, nil
+}
+
+func (self *testWithdrawalApprovalStorage) GetWithdrawalApprovals(fromTime, toTime uint64) ([]common.WithdrawalApproval, error) {
+	result := []common.WithdrawalApproval{}
+	for _, approval := range self.approvals {
+		result = append(result, approval)
+	}
+	return result, nil
+}
+
+func (self *testWithdrawalApprovalStorage) GetPendingWithdrawalApprovals() ([]common.WithdrawalApproval, error) {
+	result := []common.WithdrawalApproval{}
+	for _, approval := range self.approvals {
+		if approval.Status == common.WithdrawalApprovalPending {
+			result = append(result, approval)
+		}
+	}
+	return result, nil
+}
+
+func TestWithdrawalApproval(t *testing.T) {
+	exchange := testExchange{}
+	common.SupportedExchanges[exchange.ID()] = exchange
+	defer delete(common.SupportedExchanges, exchange.ID())
+
+	knc := common.NewToken("KNC", "Kyber-coin", "0x1111111111111111111111111111111111111111", 18, true, true, 0)
+	storage := &testWithdrawalApprovalStorage{
+		thresholds: common.WithdrawThresholds{"KNC": 1},
+		approvals:  map[string]common.WithdrawalApproval{},
+	}
+	core := NewReserveCore(
+		testBlockchain{},
+		testActivityStorage{},
+		testSetting{tokens: []common.Token{knc}},
+		WithWithdrawalApproval(storage, DefaultWithdrawalApprovalExpiry),
+	)
+
+	// 0.5 KNC is executed immediately
+	id, pending, err := core.Withdraw(exchange, knc, big.NewInt(5e17), common.GetTimepoint(), "rebalance")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pending != nil || id.EID != "withdrawid" {
+		t.Errorf("expected withdrawal below threshold is executed, got id %s, pending %+v", id, pending)
+	}
+
+	// dry runs report whether approval is required
+	result, err := core.DryRunWithdraw(exchange, knc, big.NewInt(5e17))
+	if err != nil || result.RequiresApproval {
+		t.Errorf("expected dry run withdrawal below threshold not requiring approval, got %+v, err %v", result, err)
+	}
+	if result.Request == nil || result.Request.URL != "https://bittrex.com/withdraw" {
+		t.Errorf("expected signed withdraw request in dry run result, got %+v", result)
+	}
+	result, err = core.DryRunWithdraw(exchange, knc, big.NewInt(2e18))
+	if err != nil || !result.RequiresApproval {
+		t.Errorf("expected dry run withdrawal above threshold requiring approval, got %+v, err %v", result, err)
+	}
+
+	// 2 KNC requires approval
+	_, pending, err = core.Withdraw(exchange, knc, big.NewInt(2e18), common.GetTimepoint(), "rebalance")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pending == nil || pending.Status != common.WithdrawalApprovalPending || pending.Amount != "2000000000000000000" {
+		t.Fatalf("expected pending withdrawal above threshold, got %+v", pending)
+	}
+	if _, err = core.ConfirmWithdrawal(pending.ID, "rebalance", common.GetTimepoint()); err == nil {
+		t.Error("expected error confirming withdrawal by its proposer")
+	}
+	confirmed, err := core.ConfirmWithdrawal(pending.ID, "confirm_configuration", common.GetTimepoint())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if confirmed.Status != common.WithdrawalApprovalConfirmed || confirmed.ReviewedBy != "confirm_configuration" ||
+		confirmed.ActivityID == nil || confirmed.ActivityID.EID != "withdrawid" {
+		t.Errorf("expected withdrawal is executed after confirmation, got %+v", confirmed)
+	}
+	if _, err = core.ConfirmWithdrawal(pending.ID, "confirm_configuration", common.GetTimepoint()); err == nil {
+		t.Error("expected error confirming withdrawal twice")
+	}
+
+	// proposer can reject its own withdrawal
+	_, pending, err = core.Withdraw(exchange, knc, big.NewInt(2e18), common.GetTimepoint(), "rebalance")
+	if err != nil {
+		t.Fatal(err)
+	}
+	rejected, err := core.RejectWithdrawal(pending.ID, "rebalance")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rejected.Status != common.WithdrawalApprovalRejected || rejected.ReviewedBy != "rebalance" {
+		t.Errorf("expected withdrawal is rejected, got %+v", rejected)
+	}
+
+	// expired withdrawal can't be confirmed
+	_, pending, err = core.Withdraw(exchange, knc, big.NewInt(2e18), common.GetTimepoint(), "rebalance")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expired := storage.approvals[pending.ID]
+	expired.ExpiresAt = expired.ProposedAt - 1
+	storage.approvals[pending.ID] = expired
+	pendings, err := core.GetPendingWithdrawals()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pendings) != 0 || storage.approvals[pending.ID].Status != common.WithdrawalApprovalExpired {
+		t.Errorf("expected withdrawal is expired, got %+v", storage.approvals[pending.ID])
+	}
+	if _, err = core.ConfirmWithdrawal(pending.ID, "confirm_configuration", common.GetTimepoint()); err == nil {
+		t.Error("expected error confirming expired withdrawal")
+	}
+}
@@ -0,0 +1,307 @@
+package block_runner
+
+import (
+	"context"
+	"errors"
+	"log"
+	"math/big"
+	"sync"
+	"time"
+
+	ethereum "github.com/ethereum/go-ethereum"
+	ethcommon "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+const (
+	defaultDebounceDuration    = 500 * time.Millisecond
+	defaultResubscribeDuration = 5 * time.Second
+	defaultOrderbookDuration   = 7 * time.Second
+	defaultGlobalDataDuration  = 10 * time.Second
+
+	// maxRememberedBlocks is the number of recent block hashes kept to
+	// detect duplicated notifications and chain reorganizations.
+	maxRememberedBlocks = 128
+
+	requestTimeout = 10 * time.Second
+)
+
+// HeadSubscriber is the source of new chain heads used by BlockRunner.
+// *ethclient.Client satisfies this interface, note that subscription
+// requires a websocket or IPC endpoint.
+type HeadSubscriber interface {
+	SubscribeNewHead(ctx context.Context, ch chan<- *types.Header) (ethereum.Subscription, error)
+	HeaderByNumber(ctx context.Context, number *big.Int) (*types.Header, error)
+}
+
+// BlockRunner is an implementation of FetcherRunner that ticks the rate,
+// block and auth data tickers exactly once per new block instead of on fixed
+// intervals. Heads arriving in quick succession are debounced so only the
+// latest one fires, and a head replacing an already fired block with a
+// different hash (chain reorganization) fires the tickers again.
+// Orderbook and global data do not depend on the chain, so they are still
+// triggered by time tickers.
+type BlockRunner struct {
+	subscriber HeadSubscriber
+
+	debounceDuration    time.Duration
+	resubscribeDuration time.Duration
+	orderbookDuration   time.Duration
+	globalDataDuration  time.Duration
+
+	aticker chan time.Time
+	rticker chan time.Time
+	bticker chan time.Time
+
+	oclock          *time.Ticker
+	globalDataClock *time.Ticker
+
+	mu           sync.RWMutex
+	currentBlock uint64
+	seen         map[uint64]ethcommon.Hash
+
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// GetGlobalDataTicker returns the global data ticker.
+func (self *BlockRunner) GetGlobalDataTicker() <-chan time.Time {
+	return self.globalDataClock.C
+}
+
+// GetOrderbookTicker returns the order book ticker.
+func (self *BlockRunner) GetOrderbookTicker() <-chan time.Time {
+	return self.oclock.C
+}
+
+// GetAuthDataTicker returns the auth data ticker, it ticks once per new block.
+func (self *BlockRunner) GetAuthDataTicker() <-chan time.Time {
+	return self.aticker
+}
+
+// GetRateTicker returns the rate ticker, it ticks once per new block.
+func (self *BlockRunner) GetRateTicker() <-chan time.Time {
+	return self.rticker
+}
+
+// GetBlockTicker returns the block ticker, it ticks once per new block.
+func (self *BlockRunner) GetBlockTicker() <-chan time.Time {
+	return self.bticker
+}
+
+// CurrentBlock returns the number of the latest block the tickers were fired for.
+// It returns 0 if no block is received yet.
+func (self *BlockRunner) CurrentBlock() uint64 {
+	self.mu.RLock()
+	defer self.mu.RUnlock()
+	return self.currentBlock
+}
+
+// Start subscribes to new chain heads and starts the time tickers.
+// It returns an error if the runner is started already.
+func (self *BlockRunner) Start() error {
+	if self.cancel != nil {
+		return errors.New("runner start already")
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	self.cancel = cancel
+	self.done = make(chan struct{})
+	self.oclock = time.NewTicker(self.orderbookDuration)
+	self.globalDataClock = time.NewTicker(self.globalDataDuration)
+	go self.run(ctx)
+	return nil
+}
+
+// Stop unsubscribes from chain heads and stops all tickers.
+// It returns an error if the runner is already stopped.
+func (self *BlockRunner) Stop() error {
+	if self.cancel == nil {
+		return errors.New("runner stop already")
+	}
+	self.cancel()
+	<-self.done
+	self.cancel = nil
+	self.oclock.Stop()
+	self.globalDataClock.Stop()
+	return nil
+}
+
+// run keeps the head subscription alive until ctx is cancelled. Every time a
+// subscription is (re)established, the latest head is fetched to catch up
+// with blocks mined while the runner was not subscribed.
+func (self *BlockRunner) run(ctx context.Context) {
+	defer close(self.done)
+	for {
+		headCh := make(chan *types.Header, maxRememberedBlocks)
+		sub, err := self.subscriber.SubscribeNewHead(ctx, headCh)
+		if err != nil {
+			log.Printf("Block runner: subscribing to new heads failed: %s, retry in %s", err, self.resubscribeDuration)
+		} else {
+			self.catchUp(ctx, headCh)
+			err = self.consume(ctx, sub, headCh)
+			sub.Unsubscribe()
+			if err == nil {
+				return
+			}
+			log.Printf("Block runner: head subscription dropped: %s, resubscribing in %s", err, self.resubscribeDuration)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(self.resubscribeDuration):
+		}
+	}
+}
+
+// catchUp queues the latest known head so missed blocks are accounted for
+// right after (re)subscribing.
+func (self *BlockRunner) catchUp(ctx context.Context, headCh chan *types.Header) {
+	reqCtx, cancel := context.WithTimeout(ctx, requestTimeout)
+	defer cancel()
+	header, err := self.subscriber.HeaderByNumber(reqCtx, nil)
+	if err != nil {
+		log.Printf("Block runner: fetching latest head failed: %s", err)
+		return
+	}
+	select {
+	case headCh <- header:
+	default:
+	}
+}
+
+// consume debounces received heads and fires the tickers with the latest one.
+// It returns nil when ctx is cancelled or the subscription error otherwise.
+func (self *BlockRunner) consume(ctx context.Context, sub ethereum.Subscription, headCh <-chan *types.Header) error {
+	var (
+		pending  *types.Header
+		debounce = time.NewTimer(self.debounceDuration)
+	)
+	debounce.Stop()
+	defer debounce.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case err := <-sub.Err():
+			if err == nil {
+				err = errors.New("subscription closed")
+			}
+			return err
+		case header := <-headCh:
+			if header == nil || header.Number == nil {
+				continue
+			}
+			pending = header
+			debounce.Reset(self.debounceDuration)
+		case <-debounce.C:
+			if pending != nil {
+				self.handleHead(pending)
+				pending = nil
+			}
+		}
+	}
+}
+
+// handleHead fires the block driven tickers if the given head is not fired yet.
+func (self *BlockRunner) handleHead(header *types.Header) {
+	number := header.Number.Uint64()
+	hash := header.Hash()
+
+	self.mu.Lock()
+	if known, ok := self.seen[number]; ok {
+		if known == hash {
+			self.mu.Unlock()
+			return
+		}
+		log.Printf("Block runner: chain reorganization detected at block %d (%s -> %s)", number, known.Hex(), hash.Hex())
+	}
+	// blocks above a reorganized head are no longer canonical
+	for n := range self.seen {
+		if n > number || n+maxRememberedBlocks <= number {
+			delete(self.seen, n)
+		}
+	}
+	self.seen[number] = hash
+	self.currentBlock = number
+	self.mu.Unlock()
+
+	now := time.Now()
+	tick(self.bticker, now)
+	tick(self.rticker, now)
+	tick(self.aticker, now)
+}
+
+// tick sends t to ch without blocking. If the previous tick is not consumed
+// yet, it is replaced by the newer one.
+func tick(ch chan time.Time, t time.Time) {
+	select {
+	case ch <- t:
+		return
+	default:
+	}
+	select {
+	case <-ch:
+	default:
+	}
+	select {
+	case ch <- t:
+	default:
+	}
+}
+
+// BlockRunnerOption is the option to setup the BlockRunner on creation.
+type BlockRunnerOption func(br *BlockRunner)
+
+// WithDebounceDuration setups the BlockRunner to wait for the given duration
+// of silence before firing the tickers for the latest received head.
+func WithDebounceDuration(duration time.Duration) BlockRunnerOption {
+	return func(br *BlockRunner) {
+		br.debounceDuration = duration
+	}
+}
+
+// WithResubscribeDuration setups the waiting duration before resubscribing
+// after the head subscription failed.
+func WithResubscribeDuration(duration time.Duration) BlockRunnerOption {
+	return func(br *BlockRunner) {
+		br.resubscribeDuration = duration
+	}
+}
+
+// WithOrderbookDuration setups the interval of the orderbook ticker.
+func WithOrderbookDuration(duration time.Duration) BlockRunnerOption {
+	return func(br *BlockRunner) {
+		br.orderbookDuration = duration
+	}
+}
+
+// WithGlobalDataDuration setups the interval of the global data ticker.
+func WithGlobalDataDuration(duration time.Duration) BlockRunnerOption {
+	return func(br *BlockRunner) {
+		br.globalDataDuration = duration
+	}
+}
+
+// NewBlockRunner creates a new instance of BlockRunner receiving heads from
+// the given subscriber.
+func NewBlockRunner(subscriber HeadSubscriber, options ...BlockRunnerOption) (*BlockRunner, error) {
+	if subscriber == nil {
+		return nil, errors.New("head subscriber is required")
+	}
+	runner := &BlockRunner{
+		subscriber:          subscriber,
+		debounceDuration:    defaultDebounceDuration,
+		resubscribeDuration: defaultResubscribeDuration,
+		orderbookDuration:   defaultOrderbookDuration,
+		globalDataDuration:  defaultGlobalDataDuration,
+		aticker:             make(chan time.Time, 1),
+		rticker:             make(chan time.Time, 1),
+		bticker:             make(chan time.Time, 1),
+		seen:                map[uint64]ethcommon.Hash{},
+	}
+	for _, option := range options {
+		option(runner)
+	}
+	return runner, nil
+}
@@ -0,0 +1,159 @@
+package block_runner
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"sync"
+	"testing"
+	"time"
+
+	ethereum "github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/event"
+)
+
+// simulatedChain is a minimal chain emitting new heads to its subscribers.
+type simulatedChain struct {
+	mu        sync.Mutex
+	feed      event.Feed
+	head      *types.Header
+	subscribe chan struct{}
+}
+
+func newSimulatedChain() *simulatedChain {
+	return &simulatedChain{subscribe: make(chan struct{}, 10)}
+}
+
+func (self *simulatedChain) SubscribeNewHead(ctx context.Context, ch chan<- *types.Header) (ethereum.Subscription, error) {
+	sub := self.feed.Subscribe(ch)
+	self.subscribe <- struct{}{}
+	return sub, nil
+}
+
+func (self *simulatedChain) HeaderByNumber(ctx context.Context, number *big.Int) (*types.Header, error) {
+	self.mu.Lock()
+	defer self.mu.Unlock()
+	if self.head == nil {
+		return nil, errors.New("no block mined")
+	}
+	return self.head, nil
+}
+
+// mine emits a new head with given number, extra is used to produce a
+// different hash for the same number.
+func (self *simulatedChain) mine(number int64, extra byte) {
+	header := &types.Header{Number: big.NewInt(number), Extra: []byte{extra}, Difficulty: big.NewInt(1)}
+	self.mu.Lock()
+	self.head = header
+	self.mu.Unlock()
+	self.feed.Send(header)
+}
+
+func newTestRunner(t *testing.T, chain *simulatedChain) *BlockRunner {
+	t.Helper()
+	runner, err := NewBlockRunner(chain, WithDebounceDuration(20*time.Millisecond))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = runner.Start(); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-chain.subscribe:
+	case <-time.After(time.Second):
+		t.Fatal("runner did not subscribe to new heads")
+	}
+	return runner
+}
+
+func expectTicks(t *testing.T, runner *BlockRunner, expected int, block uint64) {
+	t.Helper()
+	for _, ch := range []<-chan time.Time{runner.GetRateTicker(), runner.GetBlockTicker(), runner.GetAuthDataTicker()} {
+		got := 0
+		timeout := time.After(80 * time.Millisecond)
+	loop:
+		for {
+			select {
+			case <-ch:
+				got++
+			case <-timeout:
+				break loop
+			}
+		}
+		if got != expected {
+			t.Errorf("expected %d ticks, got %d", expected, got)
+		}
+	}
+	if runner.CurrentBlock() != block {
+		t.Errorf("expected current block %d, got %d", block, runner.CurrentBlock())
+	}
+}
+
+func TestBlockRunnerOncePerBlock(t *testing.T) {
+	chain := newSimulatedChain()
+	runner := newTestRunner(t, chain)
+	defer runner.Stop()
+
+	chain.mine(1, 0)
+	expectTicks(t, runner, 1, 1)
+
+	// duplicated notification of the same block must not fire again
+	chain.mine(1, 0)
+	expectTicks(t, runner, 0, 1)
+
+	chain.mine(2, 0)
+	expectTicks(t, runner, 1, 2)
+}
+
+func TestBlockRunnerDebounce(t *testing.T) {
+	chain := newSimulatedChain()
+	runner := newTestRunner(t, chain)
+	defer runner.Stop()
+
+	for i := int64(1); i <= 5; i++ {
+		chain.mine(i, 0)
+	}
+	expectTicks(t, runner, 1, 5)
+}
+
+func TestBlockRunnerReorg(t *testing.T) {
+	chain := newSimulatedChain()
+	runner := newTestRunner(t, chain)
+	defer runner.Stop()
+
+	chain.mine(10, 0)
+	expectTicks(t, runner, 1, 10)
+	chain.mine(11, 0)
+	expectTicks(t, runner, 1, 11)
+
+	// block 11 is replaced by another block with the same number
+	chain.mine(11, 1)
+	expectTicks(t, runner, 1, 11)
+
+	// chain is reorganized back to a lower block
+	chain.mine(10, 1)
+	expectTicks(t, runner, 1, 10)
+}
+
+func TestBlockRunnerCatchUp(t *testing.T) {
+	chain := newSimulatedChain()
+	runner, err := NewBlockRunner(chain,
+		WithDebounceDuration(20*time.Millisecond),
+		WithResubscribeDuration(10*time.Millisecond))
+	if err != nil {
+		t.Fatal(err)
+	}
+	chain.mine(5, 0)
+	if err = runner.Start(); err != nil {
+		t.Fatal(err)
+	}
+	defer runner.Stop()
+
+	// latest head is fetched right after subscribing
+	expectTicks(t, runner, 1, 5)
+
+	if err = runner.Start(); err == nil {
+		t.Error("expected error when starting runner twice")
+	}
+}
@@ -14,3 +14,9 @@ type Exchange interface {
 	DepositStatus(id common.ActivityID, txHash, currency string, amount float64, timepoint uint64) (string, error)
 	WithdrawStatus(id, currency string, amount float64, timepoint uint64) (string, string, error)
 }
+
+// SubAccountExchange is implemented by exchanges supporting internal
+// transfers between their sub-accounts, like Binance.
+type SubAccountExchange interface {
+	TransferStatus(id string, timepoint uint64) (string, error)
+}
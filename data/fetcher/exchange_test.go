@@ -0,0 +1,50 @@
+package fetcher
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/KyberNetwork/reserve-data/common"
+)
+
+type fakeSubAccountExchange struct {
+	Exchange
+	statuses map[string]string
+}
+
+func (self *fakeSubAccountExchange) ID() common.ExchangeID {
+	return "binance"
+}
+
+func (self *fakeSubAccountExchange) TransferStatus(id string, timepoint uint64) (string, error) {
+	return self.statuses[id], nil
+}
+
+func TestFetchTransferStatusFromExchange(t *testing.T) {
+	exchange := &fakeSubAccountExchange{statuses: map[string]string{
+		"1": common.ExchangeStatusDone,
+		"2": "",
+	}}
+	timepoint := common.GetTimepoint()
+	var pendings []common.ActivityRecord
+	for _, eid := range []string{"1", "2"} {
+		pendings = append(pendings, common.NewActivityRecord(
+			common.ActionTransfer,
+			common.NewActivityID(timepoint, eid),
+			"binance",
+			map[string]interface{}{"token": "KNC", "amount": "10"},
+			map[string]interface{}{"id": eid},
+			common.ExchangeStatusSubmitted,
+			"",
+			common.Timestamp(strconv.FormatUint(timepoint, 10)),
+		))
+	}
+	fetcher := &Fetcher{}
+	statuses := fetcher.FetchStatusFromExchange(exchange, pendings, timepoint)
+	if status := statuses[common.NewActivityID(timepoint, "1")].ExchangeStatus; status != common.ExchangeStatusDone {
+		t.Errorf("Expected transfer 1 done, got %q", status)
+	}
+	if status := statuses[common.NewActivityID(timepoint, "2")].ExchangeStatus; status != "" {
+		t.Errorf("Expected transfer 2 pending, got %q", status)
+	}
+}
@@ -0,0 +1,144 @@
+package fetcher
+
+import (
+	"log"
+	"math"
+	"time"
+
+	"github.com/KyberNetwork/reserve-data/common"
+	"github.com/KyberNetwork/reserve-data/settings"
+)
+
+// DefaultFeeSyncInterval is the default interval between syncs of exchange
+// fees and min deposits.
+const DefaultFeeSyncInterval = time.Hour
+
+// feeEpsilon is the smallest difference between a live fee and its setting
+// which is considered a change.
+const feeEpsilon = 0.0000000001
+
+// FeeSyncExchange is implemented by exchanges reporting their live fees and
+// min deposits through their APIs. Fees or min deposits an exchange doesn't
+// report are left out of the result.
+type FeeSyncExchange interface {
+	FetchLiveFees() (common.ExchangeFees, common.ExchangesMinDeposit, error)
+}
+
+// FeeSyncSetting contains the setting methods to read and update fees and
+// min deposits of exchanges.
+type FeeSyncSetting interface {
+	GetFee(ex settings.ExchangeName) (common.ExchangeFees, error)
+	GetMinDeposit(ex settings.ExchangeName) (common.ExchangesMinDeposit, error)
+	ApplyFeeDiff(ex settings.ExchangeName, diff common.ExchangeFeeDiff, timestamp uint64) error
+	UpdatePendingFeeDiff(ex settings.ExchangeName, diff common.ExchangeFeeDiff) error
+	RemovePendingFeeDiff(ex settings.ExchangeName) error
+}
+
+// FeeSync compares the live fees and min deposits of exchanges with the
+// setting on a schedule. Differences are applied to the setting right away
+// if autoApply is set, or stored as pending fee differences otherwise, to
+// be confirmed or rejected through the setting API.
+type FeeSync struct {
+	setting   FeeSyncSetting
+	interval  time.Duration
+	autoApply bool
+}
+
+// FeeSyncOption is the option of FeeSync constructor.
+type FeeSyncOption func(*FeeSync)
+
+// WithFeeSyncInterval sets the interval between syncs.
+func WithFeeSyncInterval(interval time.Duration) FeeSyncOption {
+	return func(self *FeeSync) {
+		self.interval = interval
+	}
+}
+
+// WithFeeAutoApply makes the differences applied without confirmation.
+func WithFeeAutoApply(autoApply bool) FeeSyncOption {
+	return func(self *FeeSync) {
+		self.autoApply = autoApply
+	}
+}
+
+// NewFeeSync creates a new FeeSync.
+func NewFeeSync(setting FeeSyncSetting, options ...FeeSyncOption) *FeeSync {
+	sync := &FeeSync{
+		setting:  setting,
+		interval: DefaultFeeSyncInterval,
+	}
+	for _, option := range options {
+		option(sync)
+	}
+	return sync
+}
+
+// Sync fetches the live fees of exchanges supporting it and applies or
+// stores their differences with the setting. A pending difference of an
+// exchange is removed once its fees are the same as the setting.
+func (self *FeeSync) Sync(exchanges []Exchange, timestamp uint64) {
+	for _, exchange := range exchanges {
+		feeExchange, ok := exchange.(FeeSyncExchange)
+		if !ok {
+			continue
+		}
+		if err := self.syncExchange(settings.ExchangeName(exchange.ID()), feeExchange, timestamp); err != nil {
+			log.Printf("FeeSync: syncing fees of %s failed: %s", exchange.ID(), err)
+		}
+	}
+}
+
+func (self *FeeSync) syncExchange(exName settings.ExchangeName, exchange FeeSyncExchange, timestamp uint64) error {
+	liveFee, liveMinDeposit, err := exchange.FetchLiveFees()
+	if err != nil {
+		return err
+	}
+	fee, err := self.setting.GetFee(exName)
+	if err != nil && err != settings.ErrExchangeRecordNotFound {
+		return err
+	}
+	minDeposit, err := self.setting.GetMinDeposit(exName)
+	if err != nil && err != settings.ErrExchangeRecordNotFound {
+		return err
+	}
+	diff := diffFees(fee, minDeposit, liveFee, liveMinDeposit)
+	diff.Timestamp = timestamp
+	if diff.IsEmpty() {
+		return self.setting.RemovePendingFeeDiff(exName)
+	}
+	if self.autoApply {
+		log.Printf("FeeSync: applying live fees of %s: %+v", exName, diff)
+		if err = self.setting.ApplyFeeDiff(exName, diff, timestamp); err != nil {
+			return err
+		}
+		return self.setting.RemovePendingFeeDiff(exName)
+	}
+	log.Printf("FeeSync: live fees of %s differ from setting, waiting for confirmation: %+v", exName, diff)
+	return self.setting.UpdatePendingFeeDiff(exName, diff)
+}
+
+// diffFees returns the live fees and min deposits which differ from the
+// current ones.
+func diffFees(
+	fee common.ExchangeFees, minDeposit common.ExchangesMinDeposit,
+	liveFee common.ExchangeFees, liveMinDeposit common.ExchangesMinDeposit) common.ExchangeFeeDiff {
+	return common.ExchangeFeeDiff{
+		Trading:    diffValues(fee.Trading, liveFee.Trading),
+		Withdraw:   diffValues(fee.Funding.Withdraw, liveFee.Funding.Withdraw),
+		MinDeposit: diffValues(minDeposit, liveMinDeposit),
+	}
+}
+
+func diffValues(current, live map[string]float64) map[string]common.FeeChange {
+	var result map[string]common.FeeChange
+	for key, value := range live {
+		if currentValue, ok := current[key]; ok && math.Abs(currentValue-value) < feeEpsilon {
+			continue
+		}
+		if result == nil {
+			result = make(map[string]common.FeeChange)
+		}
+		result[key] = common.FeeChange{Current: current[key], Live: value}
+	}
+	return result
+}
@@ -0,0 +1,127 @@
+package fetcher
+
+import (
+	"testing"
+
+	"github.com/KyberNetwork/reserve-data/common"
+	"github.com/KyberNetwork/reserve-data/settings"
+)
+
+type fakeFeeSyncExchange struct {
+	Exchange
+	fee        common.ExchangeFees
+	minDeposit common.ExchangesMinDeposit
+}
+
+func (self *fakeFeeSyncExchange) ID() common.ExchangeID {
+	return "binance"
+}
+
+func (self *fakeFeeSyncExchange) FetchLiveFees() (common.ExchangeFees, common.ExchangesMinDeposit, error) {
+	return self.fee, self.minDeposit, nil
+}
+
+type fakeFeeSyncSetting struct {
+	fee        common.ExchangeFees
+	minDeposit common.ExchangesMinDeposit
+	pending    map[settings.ExchangeName]common.ExchangeFeeDiff
+}
+
+func (self *fakeFeeSyncSetting) GetFee(ex settings.ExchangeName) (common.ExchangeFees, error) {
+	return self.fee, nil
+}
+
+func (self *fakeFeeSyncSetting) GetMinDeposit(ex settings.ExchangeName) (common.ExchangesMinDeposit, error) {
+	return self.minDeposit, nil
+}
+
+func (self *fakeFeeSyncSetting) ApplyFeeDiff(ex settings.ExchangeName, diff common.ExchangeFeeDiff, timestamp uint64) error {
+	fee, minDeposit := diff.LiveFees()
+	for key, value := range fee.Trading {
+		self.fee.Trading[key] = value
+	}
+	for token, value := range fee.Funding.Withdraw {
+		self.fee.Funding.Withdraw[token] = value
+	}
+	for token, value := range minDeposit {
+		self.minDeposit[token] = value
+	}
+	return nil
+}
+
+func (self *fakeFeeSyncSetting) UpdatePendingFeeDiff(ex settings.ExchangeName, diff common.ExchangeFeeDiff) error {
+	self.pending[ex] = diff
+	return nil
+}
+
+func (self *fakeFeeSyncSetting) RemovePendingFeeDiff(ex settings.ExchangeName) error {
+	delete(self.pending, ex)
+	return nil
+}
+
+func newFakeFeeSyncSetting() *fakeFeeSyncSetting {
+	return &fakeFeeSyncSetting{
+		fee: common.NewExchangeFee(
+			common.TradingFee{"taker": 0.001, "maker": 0.001},
+			common.NewFundingFee(map[string]float64{"ETH": 0.01, "KNC": 3}, map[string]float64{}),
+		),
+		minDeposit: common.ExchangesMinDeposit{"ETH": 0.02},
+		pending:    map[settings.ExchangeName]common.ExchangeFeeDiff{},
+	}
+}
+
+func TestFeeSyncPending(t *testing.T) {
+	setting := newFakeFeeSyncSetting()
+	exchange := &fakeFeeSyncExchange{
+		fee: common.NewExchangeFee(
+			common.TradingFee{"taker": 0.001, "maker": 0.00075},
+			common.NewFundingFee(map[string]float64{"ETH": 0.01, "KNC": 4.5}, map[string]float64{}),
+		),
+		minDeposit: common.ExchangesMinDeposit{},
+	}
+	sync := NewFeeSync(setting)
+	sync.Sync([]Exchange{exchange}, 1000)
+
+	diff, ok := setting.pending["binance"]
+	if !ok {
+		t.Fatal("Expected pending fee diff of binance")
+	}
+	if len(diff.Trading) != 1 || diff.Trading["maker"] != (common.FeeChange{Current: 0.001, Live: 0.00075}) {
+		t.Errorf("Expected maker fee change, got %+v", diff.Trading)
+	}
+	if len(diff.Withdraw) != 1 || diff.Withdraw["KNC"] != (common.FeeChange{Current: 3, Live: 4.5}) {
+		t.Errorf("Expected KNC withdraw fee change, got %+v", diff.Withdraw)
+	}
+	if len(diff.MinDeposit) != 0 || diff.Timestamp != 1000 {
+		t.Errorf("Expected no min deposit change at 1000, got %+v", diff)
+	}
+	if setting.fee.Funding.Withdraw["KNC"] != 3 {
+		t.Error("Expected setting not changed before confirmation")
+	}
+
+	// the pending diff is removed once the setting is up to date
+	exchange.fee = setting.fee
+	sync.Sync([]Exchange{exchange}, 2000)
+	if _, ok = setting.pending["binance"]; ok {
+		t.Error("Expected pending fee diff removed")
+	}
+}
+
+func TestFeeSyncAutoApply(t *testing.T) {
+	setting := newFakeFeeSyncSetting()
+	exchange := &fakeFeeSyncExchange{
+		fee: common.NewExchangeFee(
+			common.TradingFee{},
+			common.NewFundingFee(map[string]float64{"KNC": 4.5}, map[string]float64{}),
+		),
+		minDeposit: common.ExchangesMinDeposit{"ETH": 0.05},
+	}
+	NewFeeSync(setting, WithFeeAutoApply(true)).Sync([]Exchange{exchange}, 1000)
+
+	if len(setting.pending) != 0 {
+		t.Errorf("Expected no pending fee diff, got %+v", setting.pending)
+	}
+	if setting.fee.Funding.Withdraw["KNC"] != 4.5 || setting.fee.Funding.Withdraw["ETH"] != 0.01 || setting.minDeposit["ETH"] != 0.05 {
+		t.Errorf("Expected live fees applied, got %+v, %+v", setting.fee, setting.minDeposit)
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"time"
 
 	"github.com/KyberNetwork/reserve-data/common"
+	"github.com/KyberNetwork/reserve-data/common/instrument"
 	"github.com/KyberNetwork/reserve-data/settings"
 	ethereum "github.com/ethereum/go-ethereum/common"
 )
@@ -28,6 +29,10 @@ type Fetcher struct {
 	currentBlockUpdateTime  uint64
 	simulationMode          bool
 	setting                 Setting
+	publisher               Publisher
+	txSupervisor            *TxSupervisor
+	feeSync                 *FeeSync
+	statusMonitor           *StatusMonitor
 }
 
 func NewFetcher(
@@ -55,6 +60,30 @@ func (self *Fetcher) SetBlockchain(blockchain Blockchain) {
 	self.FetchCurrentBlock(common.GetTimepoint())
 }
 
+// SetPublisher sets the publisher of newly stored prices, rates and auth data.
+func (self *Fetcher) SetPublisher(publisher Publisher) {
+	self.publisher = publisher
+}
+
+// SetTxSupervisor sets the supervisor replacing stuck deposit transactions.
+// It runs in the auth data fetching cycle, before the statuses of pending
+// activities are fetched.
+func (self *Fetcher) SetTxSupervisor(supervisor *TxSupervisor) {
+	self.txSupervisor = supervisor
+}
+
+// SetFeeSync sets the sync of exchange fees and min deposits from exchange
+// APIs. It runs on its own interval as fees rarely change.
+func (self *Fetcher) SetFeeSync(sync *FeeSync) {
+	self.feeSync = sync
+}
+
+// SetStatusMonitor sets the monitor of exchange maintenances and token
+// wallet suspensions. It runs on its own interval.
+func (self *Fetcher) SetStatusMonitor(monitor *StatusMonitor) {
+	self.statusMonitor = monitor
+}
+
 func (self *Fetcher) AddExchange(exchange Exchange) {
 	self.exchanges = append(self.exchanges, exchange)
 	// initiate exchange status as up
@@ -90,6 +119,12 @@ func (self *Fetcher) Run() error {
 	go self.RunBlockFetcher()
 	go self.RunGlobalDataFetcher()
 	go self.RunStepFunctionDataStorage()
+	if self.feeSync != nil {
+		go self.RunFeeSync()
+	}
+	if self.statusMonitor != nil {
+		go self.RunStatusMonitor()
+	}
 	log.Printf("Fetcher runner is running...")
 	return nil
 }
@@ -105,12 +140,34 @@ func (self *Fetcher) RunGlobalDataFetcher() {
 	}
 }
 
+// RunFeeSync syncs the fees and min deposits of exchanges from their APIs
+// every interval of the fee sync.
+func (self *Fetcher) RunFeeSync() {
+	tick := time.NewTicker(self.feeSync.interval)
+	for {
+		self.feeSync.Sync(self.exchanges, common.GetTimepoint())
+		<-tick.C
+	}
+}
+
+// RunStatusMonitor polls the system statuses of exchanges every interval of
+// the status monitor.
+func (self *Fetcher) RunStatusMonitor() {
+	tick := time.NewTicker(self.statusMonitor.interval)
+	for {
+		self.statusMonitor.Monitor(self.exchanges, common.GetTimepoint())
+		<-tick.C
+	}
+}
+
 func (self *Fetcher) FetchGlobalData(timepoint uint64) {
+	defer instrument.ObserveFetcherLoop(instrument.LoopGlobalData, time.Now())
 	data, _ := self.theworld.GetGoldInfo()
 	data.Timestamp = common.GetTimepoint()
 	err := self.globalStorage.StoreGoldInfo(data)
 	if err != nil {
 		log.Printf("Storing gold info failed: %s", err.Error())
+		instrument.FetcherLoopErrors.WithLabelValues(instrument.LoopGlobalData).Inc()
 	}
 }
 
@@ -140,26 +197,40 @@ func (self *Fetcher) FetchRate(timepoint uint64) {
 		err  error
 		data common.AllRateEntry
 	)
-	// only fetch rates 5s after the block number is updated
-	if !self.simulationMode && self.currentBlockUpdateTime-timepoint <= 5000 {
+	currentBlock := self.currentBlock
+	if blockRunner, ok := self.runner.(BlockFetcherRunner); ok {
+		// block runner ticks exactly once per new block, no need to wait
+		// for the block to be propagated
+		if currentBlock = blockRunner.CurrentBlock(); currentBlock == 0 {
+			return
+		}
+	} else if !self.simulationMode && self.currentBlockUpdateTime-timepoint <= 5000 {
+		// only fetch rates 5s after the block number is updated
 		return
 	}
 
-	var atBlock = self.currentBlock - 1
+	defer instrument.ObserveFetcherLoop(instrument.LoopRate, time.Now())
+	var atBlock = currentBlock - 1
 	// in simulation mode, just fetches from latest known block
 	if self.simulationMode {
 		atBlock = 0
 	}
 
-	data, err = self.blockchain.FetchRates(atBlock, self.currentBlock)
+	data, err = self.blockchain.FetchRates(atBlock, currentBlock)
 	if err != nil {
 		log.Printf("Fetching rates from blockchain failed: %s. Will not store it to storage.", err.Error())
+		instrument.FetcherLoopErrors.WithLabelValues(instrument.LoopRate).Inc()
 		return
 	}
 
 	log.Printf("Got rates from blockchain: %+v", data)
 	if err = self.storage.StoreRate(data, timepoint); err != nil {
 		log.Printf("Storing rates failed: %s", err.Error())
+		instrument.FetcherLoopErrors.WithLabelValues(instrument.LoopRate).Inc()
+		return
+	}
+	if self.publisher != nil {
+		self.publisher.PublishRate(data, timepoint)
 	}
 }
 
@@ -174,6 +245,7 @@ func (self *Fetcher) RunAuthDataFetcher() {
 }
 
 func (self *Fetcher) FetchAllAuthData(timepoint uint64) {
+	defer instrument.ObserveFetcherLoop(instrument.LoopAuthData, time.Now())
 	snapshot := common.AuthDataSnapshot{
 		Valid:             true,
 		Timestamp:         common.GetTimestamp(),
@@ -189,8 +261,12 @@ func (self *Fetcher) FetchAllAuthData(timepoint uint64) {
 	pendings, err := self.storage.GetPendingActivities()
 	if err != nil {
 		log.Printf("Getting pending activites failed: %s\n", err)
+		instrument.FetcherLoopErrors.WithLabelValues(instrument.LoopAuthData).Inc()
 		return
 	}
+	if self.txSupervisor != nil {
+		self.txSupervisor.Supervise(pendings, self.exchanges)
+	}
 	wait := sync.WaitGroup{}
 	for _, exchange := range self.exchanges {
 		wait.Add(1)
@@ -235,6 +311,7 @@ func (self *Fetcher) FetchAllAuthData(timepoint uint64) {
 		pendings, &snapshot, timepoint)
 	if err != nil {
 		log.Printf("Storing exchange balances failed: %s\n", err)
+		instrument.FetcherLoopErrors.WithLabelValues(instrument.LoopAuthData).Inc()
 		return
 	}
 }
@@ -274,9 +351,18 @@ func (self *Fetcher) FetchAuthDataFromBlockchain(
 }
 
 func (self *Fetcher) FetchCurrentBlock(timepoint uint64) {
+	defer instrument.ObserveFetcherLoop(instrument.LoopBlock, time.Now())
+	if blockRunner, ok := self.runner.(BlockFetcherRunner); ok {
+		if block := blockRunner.CurrentBlock(); block != 0 {
+			self.currentBlockUpdateTime = common.GetTimepoint()
+			self.currentBlock = block
+			return
+		}
+	}
 	block, err := self.blockchain.CurrentBlock()
 	if err != nil {
 		log.Printf("Fetching current block failed: %v. Ignored.", err)
+		instrument.FetcherLoopErrors.WithLabelValues(instrument.LoopBlock).Inc()
 	} else {
 		// update currentBlockUpdateTime first to avoid race condition
 		// where fetcher is trying to fetch new rate
@@ -327,7 +413,10 @@ func (self *Fetcher) FetchStatusFromBlockchain(pendings []common.ActivityRecord)
 	nonceValidator := self.newNonceValidator()
 
 	for _, activity := range pendings {
-		if activity.IsBlockchainPending() && (activity.Action == common.ActionSetrate || activity.Action == common.ActionDeposit || activity.Action == common.ActionWithdraw) {
+		if activity.IsBlockchainPending() && (activity.Action == common.ActionSetrate || activity.Action == common.ActionDeposit ||
+			activity.Action == common.ActionWithdraw || activity.Action == common.ActionListToken ||
+			activity.Action == common.ActionSetStepFunction || activity.Action == common.ActionDisableTrade ||
+			activity.Action == common.ActionEnableTrade) {
 			var blockNum uint64
 			var status string
 			var err error
@@ -344,6 +433,20 @@ func (self *Fetcher) FetchStatusFromBlockchain(pendings []common.ActivityRecord)
 			if err != nil {
 				log.Printf("Getting tx status failed, tx will be considered as pending: %s", err)
 			}
+			if status == common.MiningStatusLost && activity.Action == common.ActionDeposit {
+				// a replaced tx might be mined before its replacement
+				for _, replaced := range replacedTxs(activity.Result[replacedTxsKey]) {
+					replacedStatus, replacedBlock, rErr := self.blockchain.TxStatus(ethereum.HexToHash(replaced))
+					if rErr == nil && (replacedStatus == common.MiningStatusMined || replacedStatus == common.MiningStatusFailed) {
+						log.Printf("Fetcher tx status: replaced tx(%s) is %s instead of tx(%s)", replaced, replacedStatus, txStr)
+						txStr, status, blockNum = replaced, replacedStatus, replacedBlock
+						break
+					}
+				}
+			} else if status == common.MiningStatusMined && isCancelled(activity) {
+				log.Printf("Fetcher tx status: tx(%s) cancelling deposit %s is mined", txStr, activity.ID)
+				status = common.MiningStatusFailed
+			}
 			switch status {
 			case "":
 				if nonceValidator(activity) {
@@ -384,7 +487,7 @@ func (self *Fetcher) FetchStatusFromBlockchain(pendings []common.ActivityRecord)
 				if nonceValidator(activity) {
 					txFailed = true
 				} else {
-					elapsed := common.GetTimepoint() - activity.Timestamp.MustToUint64()
+					elapsed := common.GetTimepoint() - lastSentAt(activity)
 					if elapsed > uint64(expiredDuration/time.Millisecond) {
 						log.Printf("Fetcher tx status: tx(%s) is lost, elapsed time: %d", txStr, elapsed)
 						txFailed = true
@@ -438,9 +541,16 @@ func updateActivitywithBlockchainStatus(activity *common.ActivityRecord, bstatus
 		return
 	}
 	log.Printf("In PersistSnapshot: blockchain activity status for %+v: %+v", activity.ID, activityStatus)
+	if activity.Result == nil {
+		activity.Result = map[string]interface{}{}
+	}
 	if activity.IsBlockchainPending() {
 		activity.MiningStatus = activityStatus.MiningStatus
 	}
+	if activityStatus.Tx != "" {
+		// the status can be of a tx replaced by the current one
+		activity.Result["tx"] = activityStatus.Tx
+	}
 
 	if activityStatus.ExchangeStatus == common.ExchangeStatusFailed {
 		activity.ExchangeStatus = activityStatus.ExchangeStatus
@@ -572,7 +682,14 @@ func (self *Fetcher) PersistSnapshot(
 	// persist blockchain balances
 	snapshot.ReserveBalances = bbalances
 	snapshot.PendingActivities = pendingActivities
-	return self.storage.StoreAuthSnapshot(snapshot, timepoint)
+	observeAuthSnapshot(snapshot)
+	if err := self.storage.StoreAuthSnapshot(snapshot, timepoint); err != nil {
+		return err
+	}
+	if self.publisher != nil {
+		self.publisher.PublishAuthSnapshot(snapshot, timepoint)
+	}
+	return nil
 }
 
 func (self *Fetcher) FetchAuthDataFromExchange(
@@ -594,6 +711,7 @@ func (self *Fetcher) FetchAuthDataFromExchange(
 		balances, err = exchange.FetchEBalanceData(timepoint)
 		if err != nil {
 			log.Printf("Fetching exchange balances from %s failed: %v\n", exchange.Name(), err)
+			instrument.FetcherLoopErrors.WithLabelValues(instrument.LoopAuthData).Inc()
 			break
 		}
 		statuses = self.FetchStatusFromExchange(exchange, pendings, timepoint)
@@ -601,6 +719,7 @@ func (self *Fetcher) FetchAuthDataFromExchange(
 			break
 		}
 	}
+	instrument.SetBool(instrument.ExchangeBalanceValid.WithLabelValues(string(exchange.ID())), err == nil && balances.Valid)
 	if err == nil {
 		allBalances.Store(exchange.ID(), balances)
 		for id, activityStatus := range statuses {
@@ -681,6 +800,14 @@ func (self *Fetcher) FetchStatusFromExchange(exchange Exchange, pendings []commo
 				}
 				status, tx, err = exchange.WithdrawStatus(id.EID, currency, amount, timepoint)
 				log.Printf("Got withdraw status for %v: (%s), error(%s)", activity, status, common.ErrorToString(err))
+			} else if activity.Action == common.ActionTransfer {
+				subAccountExchange, ok := exchange.(SubAccountExchange)
+				if !ok {
+					log.Printf("WARNING: exchange %s doesn't support sub-account transfers", exchange.ID())
+					continue
+				}
+				status, err = subAccountExchange.TransferStatus(id.EID, timepoint)
+				log.Printf("Got transfer status for %v: (%s), error(%s)", activity, status, common.ErrorToString(err))
 			} else {
 				continue
 			}
@@ -725,6 +852,7 @@ func (self *Fetcher) RunOrderbookFetcher() {
 }
 
 func (self *Fetcher) FetchOrderbook(timepoint uint64) {
+	defer instrument.ObserveFetcherLoop(instrument.LoopOrderbook, time.Now())
 	data := NewConcurrentAllPriceData()
 	// start fetching
 	wait := sync.WaitGroup{}
@@ -734,9 +862,15 @@ func (self *Fetcher) FetchOrderbook(timepoint uint64) {
 	}
 	wait.Wait()
 	data.SetBlockNumber(self.currentBlock)
+	instrument.SetBool(instrument.SnapshotValid.WithLabelValues(instrument.LoopOrderbook), priceDataValid(data.GetData()))
 	err := self.storage.StorePrice(data.GetData(), timepoint)
 	if err != nil {
 		log.Printf("Storing data failed: %s\n", err)
+		instrument.FetcherLoopErrors.WithLabelValues(instrument.LoopOrderbook).Inc()
+		return
+	}
+	if self.publisher != nil {
+		self.publisher.PublishPrice(data.GetData(), timepoint)
 	}
 }
 
@@ -745,6 +879,7 @@ func (self *Fetcher) fetchPriceFromExchange(wg *sync.WaitGroup, exchange Exchang
 	exdata, err := exchange.FetchPriceData(timepoint)
 	if err != nil {
 		log.Printf("Fetching data from %s failed: %v\n", exchange.Name(), err)
+		instrument.FetcherLoopErrors.WithLabelValues(instrument.LoopOrderbook).Inc()
 	}
 	for pair, exchangeData := range exdata {
 		data.SetOnePrice(exchange.ID(), pair, exchangeData)
@@ -754,16 +889,19 @@ func (self *Fetcher) fetchPriceFromExchange(wg *sync.WaitGroup, exchange Exchang
 func (fc *Fetcher) RunStepFunctionDataStorage() {
 	tick := time.NewTicker(1 * time.Minute)
 	for {
+		start := time.Now()
 		data := sync.Map{}
 		// get current blockchain block
 		block, err := fc.blockchain.CurrentBlock()
 		if err != nil {
 			log.Printf("Cannot get current block: %s", err.Error())
+			instrument.FetcherLoopErrors.WithLabelValues(instrument.LoopStepFunction).Inc()
 		}
 
 		// fetch all data from blockchain
 		if err = fc.fetchStepFunctionData(&data, block); err != nil {
 			log.Printf("fetch step function data error: %s", err.Error())
+			instrument.FetcherLoopErrors.WithLabelValues(instrument.LoopStepFunction).Inc()
 		} else {
 			// convert data from sync map to go object
 			result := common.StepFunctionData{}
@@ -780,8 +918,10 @@ func (fc *Fetcher) RunStepFunctionDataStorage() {
 			// save data to storage
 			if err = fc.stepFunctionDataStorage.StoreStepFunctionData(result); err != nil {
 				log.Printf("Store step function data error: %s", err.Error())
+				instrument.FetcherLoopErrors.WithLabelValues(instrument.LoopStepFunction).Inc()
 			}
 		}
+		instrument.ObserveFetcherLoop(instrument.LoopStepFunction, start)
 		<-tick.C
 	}
 }
@@ -197,3 +197,22 @@ func TestExchangeDown(t *testing.T) {
 		t.Fatalf("Snapshot did not save exchange error")
 	}
 }
+
+func TestUpdateActivityWithBlockchainStatusNilResult(t *testing.T) {
+	activity := common.ActivityRecord{
+		Action:       common.ActionDeposit,
+		ID:           common.NewActivityID(1, "deposit"),
+		MiningStatus: common.MiningStatusSubmitted,
+	}
+	bstatuses := sync.Map{}
+	bstatuses.Store(activity.ID, common.NewActivityStatus("", "0xreplacement", 10, common.MiningStatusMined, nil))
+	snapshot := common.AuthDataSnapshot{Valid: true}
+
+	updateActivitywithBlockchainStatus(&activity, &bstatuses, &snapshot)
+	if activity.Result["tx"] != "0xreplacement" {
+		t.Errorf("expected tx of the blockchain status, got %v", activity.Result["tx"])
+	}
+	if activity.MiningStatus != common.MiningStatusMined {
+		t.Errorf("expected mined activity, got %s", activity.MiningStatus)
+	}
+}
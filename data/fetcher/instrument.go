@@ -0,0 +1,32 @@
+package fetcher
+
+import (
+	"github.com/KyberNetwork/reserve-data/common"
+	"github.com/KyberNetwork/reserve-data/common/instrument"
+)
+
+// priceDataValid returns true if the prices of all exchanges are valid.
+func priceDataValid(data common.AllPriceEntry) bool {
+	for _, onePrice := range data.Data {
+		for _, exchangePrice := range onePrice {
+			if !exchangePrice.Valid {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// observeAuthSnapshot updates the metrics of the auth data snapshot before
+// it is stored.
+func observeAuthSnapshot(snapshot *common.AuthDataSnapshot) {
+	instrument.SetBool(instrument.SnapshotValid.WithLabelValues(instrument.LoopAuthData), snapshot.Valid)
+	counts := map[[3]string]int{}
+	for _, activity := range snapshot.PendingActivities {
+		counts[[3]string{activity.Action, activity.ExchangeStatus, activity.MiningStatus}]++
+	}
+	instrument.PendingActivities.Reset()
+	for labels, count := range counts {
+		instrument.PendingActivities.WithLabelValues(labels[0], labels[1], labels[2]).Set(float64(count))
+	}
+}
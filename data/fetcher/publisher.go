@@ -0,0 +1,38 @@
+package fetcher
+
+import (
+	"github.com/KyberNetwork/reserve-data/common"
+)
+
+// Publisher is the interface that publishes data right after it is stored
+// by fetcher, so clients do not have to poll for new versions.
+type Publisher interface {
+	PublishPrice(data common.AllPriceEntry, timepoint uint64)
+	PublishRate(data common.AllRateEntry, timepoint uint64)
+	PublishAuthSnapshot(data *common.AuthDataSnapshot, timepoint uint64)
+}
+
+// Publishers is a Publisher publishing data to all of its publishers in
+// order.
+type Publishers []Publisher
+
+// PublishPrice publishes prices to all publishers.
+func (self Publishers) PublishPrice(data common.AllPriceEntry, timepoint uint64) {
+	for _, publisher := range self {
+		publisher.PublishPrice(data, timepoint)
+	}
+}
+
+// PublishRate publishes rates to all publishers.
+func (self Publishers) PublishRate(data common.AllRateEntry, timepoint uint64) {
+	for _, publisher := range self {
+		publisher.PublishRate(data, timepoint)
+	}
+}
+
+// PublishAuthSnapshot publishes auth data to all publishers.
+func (self Publishers) PublishAuthSnapshot(data *common.AuthDataSnapshot, timepoint uint64) {
+	for _, publisher := range self {
+		publisher.PublishAuthSnapshot(data, timepoint)
+	}
+}
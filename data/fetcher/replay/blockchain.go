@@ -0,0 +1,58 @@
+package replay
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/KyberNetwork/reserve-data/common"
+	"github.com/KyberNetwork/reserve-data/data/fetcher"
+	ethereum "github.com/ethereum/go-ethereum/common"
+)
+
+var _ fetcher.Blockchain = (*Blockchain)(nil)
+
+// Blockchain is a fetcher Blockchain returning the recorded rates and
+// reserve balances. As there is no chain to mine them, transactions are
+// reported mined at the current block of the replay runner.
+type Blockchain struct {
+	runner *Runner
+}
+
+// FetchBalanceData returns the recorded reserve balances of the auth data
+// being fetched.
+func (self *Blockchain) FetchBalanceData(addr ethereum.Address, atBlock uint64) (map[string]common.BalanceEntry, error) {
+	data, ok := self.runner.source.fetchingAuthData()
+	if !ok {
+		return nil, errors.New("no auth data is being fetched")
+	}
+	return data.ReserveBalances, nil
+}
+
+// FetchRates returns the recorded rates at currentBlock.
+func (self *Blockchain) FetchRates(atBlock uint64, currentBlock uint64) (common.AllRateEntry, error) {
+	data, ok := self.runner.source.rate(currentBlock)
+	if !ok {
+		return common.AllRateEntry{}, fmt.Errorf("no rates recorded at block %d", currentBlock)
+	}
+	return data, nil
+}
+
+// TxStatus reports transactions mined at the current block.
+func (self *Blockchain) TxStatus(tx ethereum.Hash) (string, uint64, error) {
+	return common.MiningStatusMined, self.runner.CurrentBlock(), nil
+}
+
+// CurrentBlock returns the current block of the replay runner.
+func (self *Blockchain) CurrentBlock() (uint64, error) {
+	return self.runner.CurrentBlock(), nil
+}
+
+// SetRateMinedNonce returns 0 as set rate transactions are reported mined.
+func (self *Blockchain) SetRateMinedNonce() (uint64, error) {
+	return 0, nil
+}
+
+// GetStepFunctionData returns an error as step functions are not recorded.
+func (self *Blockchain) GetStepFunctionData(block uint64, token ethereum.Address) (common.StepFunctionResponse, error) {
+	return common.StepFunctionResponse{}, errors.New("step functions are not recorded")
+}
@@ -0,0 +1,74 @@
+package replay
+
+import (
+	"fmt"
+
+	"github.com/KyberNetwork/reserve-data/common"
+	"github.com/KyberNetwork/reserve-data/data/fetcher"
+)
+
+var _ fetcher.Exchange = (*Exchange)(nil)
+
+// Exchange is a fetcher Exchange returning the recorded order books and
+// balances of an exchange at the timepoints the replay runner ticks with.
+// As there is no exchange to execute them, activities are reported done.
+type Exchange struct {
+	id     common.ExchangeID
+	name   string
+	source *source
+}
+
+// ID returns the id of the replayed exchange.
+func (self *Exchange) ID() common.ExchangeID {
+	return self.id
+}
+
+// Name returns the name of the replayed exchange.
+func (self *Exchange) Name() string {
+	return self.name
+}
+
+// FetchPriceData returns the recorded order books of the exchange at
+// timepoint.
+func (self *Exchange) FetchPriceData(timepoint uint64) (map[common.TokenPairID]common.ExchangePrice, error) {
+	data, ok := self.source.price(timepoint)
+	if !ok {
+		return nil, fmt.Errorf("no prices recorded at %d", timepoint)
+	}
+	result := map[common.TokenPairID]common.ExchangePrice{}
+	for pair, prices := range data.Data {
+		if price, exist := prices[self.id]; exist {
+			result[pair] = price
+		}
+	}
+	return result, nil
+}
+
+// FetchEBalanceData returns the recorded balances of the exchange at
+// timepoint.
+func (self *Exchange) FetchEBalanceData(timepoint uint64) (common.EBalanceEntry, error) {
+	data, ok := self.source.fetchAuthData(timepoint)
+	if !ok {
+		return common.EBalanceEntry{}, fmt.Errorf("no auth data recorded at %d", timepoint)
+	}
+	balance, ok := data.ExchangeBalances[self.id]
+	if !ok {
+		return common.EBalanceEntry{}, fmt.Errorf("no balances of %s recorded at %d", self.id, timepoint)
+	}
+	return balance, nil
+}
+
+// OrderStatus reports orders done.
+func (self *Exchange) OrderStatus(id string, base, quote string) (string, error) {
+	return common.ExchangeStatusDone, nil
+}
+
+// DepositStatus reports deposits done.
+func (self *Exchange) DepositStatus(id common.ActivityID, txHash, currency string, amount float64, timepoint uint64) (string, error) {
+	return common.ExchangeStatusDone, nil
+}
+
+// WithdrawStatus reports withdrawals done, without transaction.
+func (self *Exchange) WithdrawStatus(id, currency string, amount float64, timepoint uint64) (string, string, error) {
+	return common.ExchangeStatusDone, "", nil
+}
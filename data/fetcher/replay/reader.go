@@ -0,0 +1,135 @@
+package replay
+
+import (
+	"compress/gzip"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// recordFile is a record file and the timepoint of its first record.
+type recordFile struct {
+	path  string
+	start uint64
+}
+
+// Reader reads records from the files written by Recorder, in the order of
+// files then the order they are recorded.
+type Reader struct {
+	files []recordFile
+	from  uint64
+	to    uint64
+
+	index   int
+	file    *os.File
+	decoder *json.Decoder
+}
+
+// NewReader creates a Reader of records in dir between from and to,
+// inclusively. to is ignored if it is 0.
+func NewReader(dir string, from, to uint64) (*Reader, error) {
+	infos, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	var files []recordFile
+	for _, info := range infos {
+		name := info.Name()
+		if info.IsDir() || !strings.HasSuffix(name, fileExtension) {
+			continue
+		}
+		start, pErr := strconv.ParseUint(strings.TrimSuffix(name, fileExtension), 10, 64)
+		if pErr != nil {
+			log.Printf("Replay reader: ignore file %s: %s", name, pErr)
+			continue
+		}
+		files = append(files, recordFile{path: filepath.Join(dir, name), start: start})
+	}
+	sort.Slice(files, func(i, j int) bool { return files[i].start < files[j].start })
+	// skip files followed by a file starting before from
+	for len(files) > 1 && files[1].start <= from {
+		files = files[1:]
+	}
+	return &Reader{files: files, from: from, to: to}, nil
+}
+
+// Next returns the next record between from and to. It returns io.EOF if
+// there is no more record.
+func (self *Reader) Next() (Record, error) {
+	for {
+		if self.decoder == nil {
+			if err := self.open(); err != nil {
+				return Record{}, err
+			}
+		}
+		var record Record
+		err := self.decoder.Decode(&record)
+		if err != nil {
+			if err != io.EOF {
+				// the last records of a crashed core are not complete
+				log.Printf("Replay reader: reading %s stopped: %s", self.file.Name(), err)
+			}
+			if err = self.closeFile(); err != nil {
+				return Record{}, err
+			}
+			continue
+		}
+		if record.Timepoint < self.from {
+			continue
+		}
+		if self.to != 0 && record.Timepoint > self.to {
+			// records of concurrent jobs are not strictly ordered
+			continue
+		}
+		return record, nil
+	}
+}
+
+// Close closes the file being read.
+func (self *Reader) Close() error {
+	return self.closeFile()
+}
+
+// open opens the next file, it returns io.EOF if there is no more file to
+// read. Empty files, of a core crashed before flushing, are skipped.
+func (self *Reader) open() error {
+	for ; self.index < len(self.files); self.index++ {
+		next := self.files[self.index]
+		if self.to != 0 && next.start > self.to {
+			break
+		}
+		file, err := os.Open(next.path)
+		if err != nil {
+			return err
+		}
+		gz, err := gzip.NewReader(file)
+		if err != nil {
+			log.Printf("Replay reader: ignore file %s: %s", next.path, err)
+			if cErr := file.Close(); cErr != nil {
+				return cErr
+			}
+			continue
+		}
+		self.index++
+		self.file, self.decoder = file, json.NewDecoder(gz)
+		return nil
+	}
+	return io.EOF
+}
+
+// closeFile closes the file being read. The gzip reader holds no resource
+// and its errors are already returned by decoder.
+func (self *Reader) closeFile() error {
+	if self.file == nil {
+		return nil
+	}
+	err := self.file.Close()
+	self.file, self.decoder = nil, nil
+	return err
+}
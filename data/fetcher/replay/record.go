@@ -0,0 +1,27 @@
+// Package replay records the data stored by fetcher to compressed files and
+// feeds them back through fetcher, so pricing strategies and rebalance
+// thresholds can be backtested against past market conditions.
+package replay
+
+import (
+	"github.com/KyberNetwork/reserve-data/common"
+)
+
+const (
+	// PriceRecord is the type of records of AllPriceEntry.
+	PriceRecord = "price"
+	// RateRecord is the type of records of AllRateEntry.
+	RateRecord = "rate"
+	// AuthDataRecord is the type of records of AuthDataSnapshot.
+	AuthDataRecord = "auth_data"
+)
+
+// Record is a piece of data stored by fetcher at a timepoint. Only the field
+// of its type is set.
+type Record struct {
+	Type      string                   `json:"type"`
+	Timepoint uint64                   `json:"timepoint"`
+	Price     *common.AllPriceEntry    `json:"price,omitempty"`
+	Rate      *common.AllRateEntry     `json:"rate,omitempty"`
+	AuthData  *common.AuthDataSnapshot `json:"auth_data,omitempty"`
+}
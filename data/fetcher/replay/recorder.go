@@ -0,0 +1,143 @@
+package replay
+
+import (
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/KyberNetwork/reserve-data/common"
+)
+
+const (
+	// DefaultRotation is the default duration of data recorded to a file.
+	DefaultRotation = time.Hour
+
+	// fileExtension is the extension of record files, which are gzip
+	// compressed JSON lines named by the timepoint of their first record.
+	fileExtension = ".json.gz"
+)
+
+// Recorder is a fetcher Publisher archiving every AllPriceEntry,
+// AllRateEntry and AuthDataSnapshot stored by fetcher to gzip compressed
+// files, one file per rotation duration. Unlike storage, the files are
+// never pruned.
+type Recorder struct {
+	dir      string
+	rotation time.Duration
+
+	mu        sync.Mutex
+	file      *os.File
+	writer    *gzip.Writer
+	encoder   *json.Encoder
+	fileStart uint64
+}
+
+// RecorderOption is the option of Recorder constructor.
+type RecorderOption func(*Recorder)
+
+// WithRotation sets the duration of data recorded to a file.
+func WithRotation(rotation time.Duration) RecorderOption {
+	return func(self *Recorder) {
+		self.rotation = rotation
+	}
+}
+
+// NewRecorder creates a Recorder writing files to dir, dir is created if it
+// does not exist.
+func NewRecorder(dir string, options ...RecorderOption) (*Recorder, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	recorder := &Recorder{
+		dir:      dir,
+		rotation: DefaultRotation,
+	}
+	for _, option := range options {
+		option(recorder)
+	}
+	if recorder.rotation < time.Millisecond {
+		return nil, fmt.Errorf("invalid rotation duration: %s", recorder.rotation)
+	}
+	return recorder, nil
+}
+
+// PublishPrice records the order books stored at timepoint.
+func (self *Recorder) PublishPrice(data common.AllPriceEntry, timepoint uint64) {
+	self.record(Record{Type: PriceRecord, Timepoint: timepoint, Price: &data})
+}
+
+// PublishRate records the rates stored at timepoint.
+func (self *Recorder) PublishRate(data common.AllRateEntry, timepoint uint64) {
+	self.record(Record{Type: RateRecord, Timepoint: timepoint, Rate: &data})
+}
+
+// PublishAuthSnapshot records the auth data stored at timepoint.
+func (self *Recorder) PublishAuthSnapshot(data *common.AuthDataSnapshot, timepoint uint64) {
+	self.record(Record{Type: AuthDataRecord, Timepoint: timepoint, AuthData: data})
+}
+
+// Close flushes and closes the current file.
+func (self *Recorder) Close() error {
+	self.mu.Lock()
+	defer self.mu.Unlock()
+	return self.closeFile()
+}
+
+func (self *Recorder) record(record Record) {
+	self.mu.Lock()
+	defer self.mu.Unlock()
+	if err := self.rotate(record.Timepoint); err != nil {
+		log.Printf("Recorder: opening record file failed: %s", err)
+		return
+	}
+	if err := self.encoder.Encode(record); err != nil {
+		log.Printf("Recorder: recording %s at %d failed: %s", record.Type, record.Timepoint, err)
+		return
+	}
+	// flush every record so it is readable even if core crashes
+	if err := self.writer.Flush(); err != nil {
+		log.Printf("Recorder: flushing record file failed: %s", err)
+	}
+}
+
+// rotate opens a new file if timepoint is in a later rotation than the
+// current file. Records a bit older than the current file, as fetcher jobs
+// run concurrently, are written to the current file. Files are never
+// appended to, as the file of a crashed core is not a complete gzip stream.
+func (self *Recorder) rotate(timepoint uint64) error {
+	rotation := uint64(self.rotation / time.Millisecond)
+	start := timepoint - timepoint%rotation
+	if self.file != nil && start <= self.fileStart {
+		return nil
+	}
+	if err := self.closeFile(); err != nil {
+		log.Printf("Recorder: closing record file failed: %s", err)
+	}
+	name := filepath.Join(self.dir, fmt.Sprintf("%d%s", timepoint, fileExtension))
+	file, err := os.OpenFile(name, os.O_CREATE|os.O_WRONLY|os.O_EXCL, 0644)
+	if err != nil {
+		return err
+	}
+	self.file = file
+	self.writer = gzip.NewWriter(file)
+	self.encoder = json.NewEncoder(self.writer)
+	self.fileStart = start
+	return nil
+}
+
+func (self *Recorder) closeFile() error {
+	if self.file == nil {
+		return nil
+	}
+	err := self.writer.Close()
+	if cErr := self.file.Close(); err == nil {
+		err = cErr
+	}
+	self.file, self.writer, self.encoder = nil, nil, nil
+	return err
+}
@@ -0,0 +1,123 @@
+package replay
+
+import (
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/KyberNetwork/reserve-data/common"
+)
+
+func newTestDir(t *testing.T) string {
+	t.Helper()
+	dir, err := ioutil.TempDir("", "replay")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return dir
+}
+
+func readAll(t *testing.T, reader *Reader) []Record {
+	t.Helper()
+	var result []Record
+	for {
+		record, err := reader.Next()
+		if err == io.EOF {
+			return result
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		result = append(result, record)
+	}
+}
+
+func TestRecorderReader(t *testing.T) {
+	dir := newTestDir(t)
+	defer os.RemoveAll(dir)
+	recorder, err := NewRecorder(dir, WithRotation(time.Second))
+	if err != nil {
+		t.Fatal(err)
+	}
+	recorder.PublishPrice(common.AllPriceEntry{Block: 1}, 1000)
+	recorder.PublishRate(common.AllRateEntry{BlockNumber: 2}, 1500)
+	// recorded late by a concurrent job, written to the current file
+	recorder.PublishPrice(common.AllPriceEntry{Block: 3}, 900)
+	recorder.PublishAuthSnapshot(&common.AuthDataSnapshot{Block: 4}, 2100)
+	recorder.PublishPrice(common.AllPriceEntry{Block: 5}, 3000)
+	if err = recorder.Close(); err != nil {
+		t.Fatal(err)
+	}
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 3 {
+		t.Fatalf("Expected 3 record files, got %d", len(files))
+	}
+
+	reader, err := NewReader(dir, 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	records := readAll(t, reader)
+	if len(records) != 5 {
+		t.Fatalf("Expected 5 records, got %d", len(records))
+	}
+	if records[1].Type != RateRecord || records[1].Rate.BlockNumber != 2 {
+		t.Errorf("Expected rate record of block 2, got %+v", records[1])
+	}
+	if records[2].Type != PriceRecord || records[2].Timepoint != 900 {
+		t.Errorf("Expected late price record, got %+v", records[2])
+	}
+	if records[3].Type != AuthDataRecord || records[3].AuthData.Block != 4 {
+		t.Errorf("Expected auth data record of block 4, got %+v", records[3])
+	}
+
+	reader, err = NewReader(dir, 1200, 2100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	records = readAll(t, reader)
+	if len(records) != 2 || records[0].Timepoint != 1500 || records[1].Timepoint != 2100 {
+		t.Errorf("Expected records between 1200 and 2100, got %+v", records)
+	}
+}
+
+func TestReaderIncompleteFiles(t *testing.T) {
+	dir := newTestDir(t)
+	defer os.RemoveAll(dir)
+	recorder, err := NewRecorder(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	recorder.PublishPrice(common.AllPriceEntry{Block: 1}, 1000)
+	recorder.PublishPrice(common.AllPriceEntry{Block: 2}, 2000)
+	// core crashed without closing the file
+	recorder = &Recorder{dir: dir, rotation: DefaultRotation}
+	// and before flushing the next one
+	if err = ioutil.WriteFile(filepath.Join(dir, "2500"+fileExtension), nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	recorder.PublishPrice(common.AllPriceEntry{Block: 3}, 3000)
+	if err = recorder.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	reader, err := NewReader(dir, 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	records := readAll(t, reader)
+	if len(records) != 3 {
+		t.Fatalf("Expected 3 records, got %d", len(records))
+	}
+	for i, record := range records {
+		if record.Price.Block != uint64(i+1) {
+			t.Errorf("Expected record of block %d, got %d", i+1, record.Price.Block)
+		}
+	}
+}
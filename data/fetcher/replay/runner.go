@@ -0,0 +1,223 @@
+package replay
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/KyberNetwork/reserve-data/common"
+	"github.com/KyberNetwork/reserve-data/data/fetcher"
+)
+
+// DefaultSpeed is the default speed of replaying records, relative to the
+// time they were recorded in.
+const DefaultSpeed = 10
+
+// Storage is the data storage of a replay. As replays report activities
+// done, it must not be used by live deployments.
+type Storage interface {
+	// MarkReplay marks the storage used by replays, it returns error if the
+	// storage holds activities and is not marked already.
+	MarkReplay() error
+}
+
+var _ fetcher.BlockFetcherRunner = (*Runner)(nil)
+
+// Runner is an implementation of FetcherRunner that ticks fetcher with the
+// timepoints of recorded data instead of the current time, waiting the
+// recorded durations between them divided by speed. Paired with its
+// Exchanges and Blockchain, fetcher stores the recorded data again, at
+// their recorded timepoints.
+//
+// Ticks are sent without buffer so fetcher jobs of the same type never
+// overlap, and records are looked up by the timepoint or block fetcher is
+// ticked for, so the same records are replayed the same way.
+// The global data ticker never ticks as global data is not recorded.
+type Runner struct {
+	reader *Reader
+	speed  float64
+	source *source
+
+	oticker          chan time.Time
+	aticker          chan time.Time
+	rticker          chan time.Time
+	bticker          chan time.Time
+	globalDataTicker chan time.Time
+
+	mu           sync.RWMutex
+	currentBlock uint64
+
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// NewRunner creates a Runner replaying the records of reader, speed must be
+// positive.
+func NewRunner(reader *Reader, speed float64) (*Runner, error) {
+	if speed <= 0 {
+		return nil, fmt.Errorf("invalid replay speed: %f", speed)
+	}
+	return &Runner{
+		reader:           reader,
+		speed:            speed,
+		source:           newSource(),
+		oticker:          make(chan time.Time),
+		aticker:          make(chan time.Time),
+		rticker:          make(chan time.Time),
+		bticker:          make(chan time.Time),
+		globalDataTicker: make(chan time.Time),
+	}, nil
+}
+
+// Exchanges returns the replay exchanges of given exchanges, with the same
+// ids and names.
+func (self *Runner) Exchanges(exchanges []fetcher.Exchange) []fetcher.Exchange {
+	result := []fetcher.Exchange{}
+	for _, exchange := range exchanges {
+		result = append(result, &Exchange{
+			id:     exchange.ID(),
+			name:   exchange.Name(),
+			source: self.source,
+		})
+	}
+	return result
+}
+
+// Blockchain returns the replay blockchain.
+func (self *Runner) Blockchain() *Blockchain {
+	return &Blockchain{runner: self}
+}
+
+// GetGlobalDataTicker returns the global data ticker, it never ticks.
+func (self *Runner) GetGlobalDataTicker() <-chan time.Time {
+	return self.globalDataTicker
+}
+
+// GetOrderbookTicker returns the order book ticker, it ticks once per
+// recorded AllPriceEntry.
+func (self *Runner) GetOrderbookTicker() <-chan time.Time {
+	return self.oticker
+}
+
+// GetAuthDataTicker returns the auth data ticker, it ticks once per recorded
+// AuthDataSnapshot.
+func (self *Runner) GetAuthDataTicker() <-chan time.Time {
+	return self.aticker
+}
+
+// GetRateTicker returns the rate ticker, it ticks once per recorded
+// AllRateEntry.
+func (self *Runner) GetRateTicker() <-chan time.Time {
+	return self.rticker
+}
+
+// GetBlockTicker returns the block ticker, it ticks right before the rate
+// ticker.
+func (self *Runner) GetBlockTicker() <-chan time.Time {
+	return self.bticker
+}
+
+// CurrentBlock returns the block of the latest replayed rates, or 0 if no
+// rates are replayed yet.
+func (self *Runner) CurrentBlock() uint64 {
+	self.mu.RLock()
+	defer self.mu.RUnlock()
+	return self.currentBlock
+}
+
+// Done returns a channel closed when all records are replayed or the runner
+// is stopped.
+func (self *Runner) Done() <-chan struct{} {
+	return self.done
+}
+
+// Start starts replaying records. It returns an error if the runner is
+// started already.
+func (self *Runner) Start() error {
+	if self.cancel != nil {
+		return errors.New("runner start already")
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	self.cancel = cancel
+	self.done = make(chan struct{})
+	go self.run(ctx)
+	return nil
+}
+
+// Stop stops replaying records. It returns an error if the runner is already
+// stopped.
+func (self *Runner) Stop() error {
+	if self.cancel == nil {
+		return errors.New("runner stop already")
+	}
+	self.cancel()
+	<-self.done
+	self.cancel = nil
+	return self.reader.Close()
+}
+
+func (self *Runner) run(ctx context.Context) {
+	defer close(self.done)
+	var last uint64
+	for {
+		record, err := self.reader.Next()
+		if err == io.EOF {
+			log.Printf("Replay runner: all records are replayed")
+			return
+		}
+		if err != nil {
+			log.Printf("Replay runner: reading records failed: %s", err)
+			return
+		}
+		if last != 0 && record.Timepoint > last {
+			wait := time.Duration(float64(record.Timepoint-last) * float64(time.Millisecond) / self.speed)
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(wait):
+			}
+		}
+		if record.Timepoint > last {
+			last = record.Timepoint
+		}
+		if !self.replay(ctx, record) {
+			return
+		}
+	}
+}
+
+// replay makes record available to fetcher and ticks for it. It returns
+// false if the runner is stopped.
+func (self *Runner) replay(ctx context.Context, record Record) bool {
+	t := common.TimepointToTime(record.Timepoint)
+	switch {
+	case record.Type == PriceRecord && record.Price != nil:
+		self.source.addPrice(record.Timepoint, *record.Price)
+		return self.tick(ctx, self.oticker, t)
+	case record.Type == RateRecord && record.Rate != nil:
+		self.source.addRate(*record.Rate)
+		self.mu.Lock()
+		self.currentBlock = record.Rate.BlockNumber
+		self.mu.Unlock()
+		return self.tick(ctx, self.bticker, t) && self.tick(ctx, self.rticker, t)
+	case record.Type == AuthDataRecord && record.AuthData != nil:
+		self.source.addAuthData(record.Timepoint, *record.AuthData)
+		return self.tick(ctx, self.aticker, t)
+	default:
+		log.Printf("Replay runner: ignore invalid %s record at %d", record.Type, record.Timepoint)
+		return true
+	}
+}
+
+func (self *Runner) tick(ctx context.Context, ticker chan<- time.Time, t time.Time) bool {
+	select {
+	case <-ctx.Done():
+		return false
+	case ticker <- t:
+		return true
+	}
+}
@@ -0,0 +1,119 @@
+package replay
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/KyberNetwork/reserve-data/common"
+	"github.com/KyberNetwork/reserve-data/data/fetcher"
+	ethereum "github.com/ethereum/go-ethereum/common"
+)
+
+type testExchange struct {
+	fetcher.Exchange
+}
+
+func (self testExchange) ID() common.ExchangeID {
+	return "binance"
+}
+
+func (self testExchange) Name() string {
+	return "binance"
+}
+
+func receive(t *testing.T, ticker <-chan time.Time, expected uint64) {
+	t.Helper()
+	select {
+	case tick := <-ticker:
+		if timepoint := common.TimeToTimepoint(tick); timepoint != expected {
+			t.Fatalf("Expected tick at %d, got %d", expected, timepoint)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("Expected tick at %d, got none", expected)
+	}
+}
+
+func TestRunner(t *testing.T) {
+	dir := newTestDir(t)
+	defer os.RemoveAll(dir)
+	recorder, err := NewRecorder(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pair := common.TokenPairID("OMG-ETH")
+	recorder.PublishPrice(common.AllPriceEntry{Data: map[common.TokenPairID]common.OnePrice{
+		pair: {
+			"binance": {Valid: true, Bids: []common.PriceEntry{common.NewPriceEntry(10, 0.01)}},
+			"huobi":   {Valid: true},
+		},
+	}}, 1000)
+	recorder.PublishRate(common.AllRateEntry{BlockNumber: 100}, 1100)
+	recorder.PublishAuthSnapshot(&common.AuthDataSnapshot{
+		ExchangeBalances: map[common.ExchangeID]common.EBalanceEntry{
+			"binance": {Valid: true, AvailableBalance: map[string]float64{"OMG": 5}},
+		},
+		ReserveBalances: map[string]common.BalanceEntry{"OMG": {Valid: true}},
+	}, 1200)
+	if err = recorder.Close(); err != nil {
+		t.Fatal(err)
+	}
+	reader, err := NewReader(dir, 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = NewRunner(reader, 0); err == nil {
+		t.Error("Expected error of invalid speed")
+	}
+	runner, err := NewRunner(reader, 1000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	exchange := runner.Exchanges([]fetcher.Exchange{testExchange{}})[0]
+	blockchain := runner.Blockchain()
+	if err = runner.Start(); err != nil {
+		t.Fatal(err)
+	}
+	defer runner.Stop()
+
+	receive(t, runner.GetOrderbookTicker(), 1000)
+	prices, err := exchange.FetchPriceData(1000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(prices) != 1 || len(prices[pair].Bids) != 1 {
+		t.Errorf("Expected recorded binance order book, got %+v", prices)
+	}
+	if _, err = exchange.FetchPriceData(1001); err == nil {
+		t.Error("Expected error of no prices recorded")
+	}
+
+	receive(t, runner.GetBlockTicker(), 1100)
+	if block := runner.CurrentBlock(); block != 100 {
+		t.Errorf("Expected current block 100, got %d", block)
+	}
+	receive(t, runner.GetRateTicker(), 1100)
+	rates, err := blockchain.FetchRates(99, 100)
+	if err != nil || rates.BlockNumber != 100 {
+		t.Errorf("Expected recorded rates at block 100, got %+v, %v", rates, err)
+	}
+
+	receive(t, runner.GetAuthDataTicker(), 1200)
+	if _, err = blockchain.FetchBalanceData(ethereum.Address{}, 0); err == nil {
+		t.Error("Expected error of no auth data being fetched")
+	}
+	balance, err := exchange.FetchEBalanceData(1200)
+	if err != nil || balance.AvailableBalance["OMG"] != 5 {
+		t.Errorf("Expected recorded binance balances, got %+v, %v", balance, err)
+	}
+	reserveBalances, err := blockchain.FetchBalanceData(ethereum.Address{}, 0)
+	if err != nil || !reserveBalances["OMG"].Valid {
+		t.Errorf("Expected recorded reserve balances, got %+v, %v", reserveBalances, err)
+	}
+
+	select {
+	case <-runner.Done():
+	case <-time.After(time.Second):
+		t.Error("Expected runner to be done after all records are replayed")
+	}
+}
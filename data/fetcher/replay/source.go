@@ -0,0 +1,117 @@
+package replay
+
+import (
+	"sync"
+
+	"github.com/KyberNetwork/reserve-data/common"
+)
+
+// windowSize is the number of latest records of each type kept for replay
+// exchanges and blockchain. Fetcher looks up a record right after it is
+// ticked for, so only a few ones are needed.
+const windowSize = 32
+
+// source holds the latest replayed records, shared by the runner ticking for
+// them and the exchanges and blockchain returning them to fetcher.
+type source struct {
+	mu sync.RWMutex
+	// prices and auth data are looked up by the timepoint fetcher is ticked
+	// with, rates by the block of the runner.
+	prices       map[uint64]common.AllPriceEntry
+	rates        map[uint64]common.AllRateEntry
+	authData     map[uint64]common.AuthDataSnapshot
+	priceKeys    keyWindow
+	rateKeys     keyWindow
+	authKeys     keyWindow
+	authFetching uint64
+}
+
+func newSource() *source {
+	return &source{
+		prices:   map[uint64]common.AllPriceEntry{},
+		rates:    map[uint64]common.AllRateEntry{},
+		authData: map[uint64]common.AuthDataSnapshot{},
+	}
+}
+
+// keyWindow is the keys of records in the order they are added.
+type keyWindow []uint64
+
+// push appends key and returns the evicted oldest key, if any.
+func (self *keyWindow) push(key uint64) (uint64, bool) {
+	*self = append(*self, key)
+	if len(*self) <= windowSize {
+		return 0, false
+	}
+	evicted := (*self)[0]
+	*self = (*self)[1:]
+	return evicted, true
+}
+
+func (self *source) addPrice(timepoint uint64, data common.AllPriceEntry) {
+	self.mu.Lock()
+	defer self.mu.Unlock()
+	if _, exist := self.prices[timepoint]; !exist {
+		if evicted, ok := self.priceKeys.push(timepoint); ok {
+			delete(self.prices, evicted)
+		}
+	}
+	self.prices[timepoint] = data
+}
+
+func (self *source) addRate(data common.AllRateEntry) {
+	self.mu.Lock()
+	defer self.mu.Unlock()
+	if _, exist := self.rates[data.BlockNumber]; !exist {
+		if evicted, ok := self.rateKeys.push(data.BlockNumber); ok {
+			delete(self.rates, evicted)
+		}
+	}
+	self.rates[data.BlockNumber] = data
+}
+
+func (self *source) addAuthData(timepoint uint64, data common.AuthDataSnapshot) {
+	self.mu.Lock()
+	defer self.mu.Unlock()
+	if _, exist := self.authData[timepoint]; !exist {
+		if evicted, ok := self.authKeys.push(timepoint); ok {
+			delete(self.authData, evicted)
+		}
+	}
+	self.authData[timepoint] = data
+}
+
+func (self *source) price(timepoint uint64) (common.AllPriceEntry, bool) {
+	self.mu.RLock()
+	defer self.mu.RUnlock()
+	data, ok := self.prices[timepoint]
+	return data, ok
+}
+
+func (self *source) rate(block uint64) (common.AllRateEntry, bool) {
+	self.mu.RLock()
+	defer self.mu.RUnlock()
+	data, ok := self.rates[block]
+	return data, ok
+}
+
+// fetchAuthData returns the auth data at timepoint and remembers timepoint
+// as the auth data being fetched, as fetcher looks up reserve balances
+// without timepoint after exchange balances.
+func (self *source) fetchAuthData(timepoint uint64) (common.AuthDataSnapshot, bool) {
+	self.mu.Lock()
+	defer self.mu.Unlock()
+	data, ok := self.authData[timepoint]
+	if ok {
+		self.authFetching = timepoint
+	}
+	return data, ok
+}
+
+// fetchingAuthData returns the auth data being fetched.
+func (self *source) fetchingAuthData() (common.AuthDataSnapshot, bool) {
+	self.mu.RLock()
+	defer self.mu.RUnlock()
+	data, ok := self.authData[self.authFetching]
+	return data, ok
+}
@@ -20,6 +20,15 @@ type FetcherRunner interface {
 	GetBlockTicker() <-chan time.Time
 }
 
+// BlockFetcherRunner is a FetcherRunner that triggers rate, block and auth
+// data jobs on every new block instead of on fixed intervals.
+type BlockFetcherRunner interface {
+	FetcherRunner
+	// CurrentBlock returns the latest block the runner has ticked for,
+	// or 0 if no block is received yet.
+	CurrentBlock() uint64
+}
+
 // TickerRunner is an implementation of FetcherRunner that use simple time ticker.
 type TickerRunner struct {
 	oduration          time.Duration
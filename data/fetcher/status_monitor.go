@@ -0,0 +1,156 @@
+package fetcher
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/KyberNetwork/reserve-data/common"
+)
+
+// DefaultStatusMonitorInterval is the default interval between polls of
+// exchange system statuses.
+const DefaultStatusMonitorInterval = 5 * time.Minute
+
+// SystemStatusExchange is implemented by exchanges reporting whether they
+// are under maintenance and whether deposits and withdrawals of their tokens
+// are enabled.
+type SystemStatusExchange interface {
+	SystemStatus() (common.ExchangeSystemStatus, error)
+}
+
+// StatusMonitorSetting contains the setting methods to record exchange
+// notifications and statuses.
+type StatusMonitorSetting interface {
+	GetExchangeNotifications() (common.ExchangeNotifications, error)
+	UpdateExchangeNotificationContent(exchange, action, token string, noti common.ExchangeNotiContent) error
+	GetExchangeStatus() (common.ExchangesStatus, error)
+	UpdateExchangeStatus(common.ExchangesStatus) error
+}
+
+// StatusMonitor polls the system statuses of exchanges and records a
+// notification when an exchange goes under maintenance or deposits or
+// withdrawals of a token are suspended. The notification is open, without
+// to time, until the exchange reports it is over, then its to time is set.
+// An exchange is also marked down while it is under maintenance. Only the
+// notifications and exchange statuses set by the monitor are restored by
+// it, the ones set by operators are left to them.
+type StatusMonitor struct {
+	setting  StatusMonitorSetting
+	interval time.Duration
+}
+
+// NewStatusMonitor creates a new StatusMonitor polling every interval.
+func NewStatusMonitor(setting StatusMonitorSetting, interval time.Duration) *StatusMonitor {
+	return &StatusMonitor{
+		setting:  setting,
+		interval: interval,
+	}
+}
+
+// Monitor polls the system statuses of exchanges supporting it and records
+// their changes.
+func (self *StatusMonitor) Monitor(exchanges []Exchange, timestamp uint64) {
+	notifications, err := self.setting.GetExchangeNotifications()
+	if err != nil {
+		log.Printf("StatusMonitor: getting exchange notifications failed: %s", err)
+		return
+	}
+	for _, exchange := range exchanges {
+		statusExchange, ok := exchange.(SystemStatusExchange)
+		if !ok {
+			continue
+		}
+		status, err := statusExchange.SystemStatus()
+		if err != nil {
+			log.Printf("StatusMonitor: getting system status of %s failed: %s", exchange.ID(), err)
+			continue
+		}
+		if err = self.monitorExchange(string(exchange.ID()), status, notifications[string(exchange.ID())], timestamp); err != nil {
+			log.Printf("StatusMonitor: recording status of %s failed: %s", exchange.ID(), err)
+		}
+	}
+}
+
+func (self *StatusMonitor) monitorExchange(exchange string, status common.ExchangeSystemStatus, notifications common.ExchangeActionNoti, timestamp uint64) error {
+	changed, err := self.record(exchange, common.ExchangeMaintenance, common.AllTokens, status.Maintenance,
+		fmt.Sprintf("%s is under maintenance: %s", exchange, status.Message), notifications, timestamp)
+	if err != nil {
+		return err
+	}
+	if changed {
+		if err = self.updateExchangeStatus(exchange, !status.Maintenance, timestamp); err != nil {
+			return err
+		}
+	}
+	for _, action := range []string{common.SuspendDeposit, common.SuspendWithdraw} {
+		suspended := map[string]bool{}
+		for token, wallet := range status.Wallets {
+			if action == common.SuspendDeposit {
+				suspended[token] = !wallet.DepositEnabled
+			} else {
+				suspended[token] = !wallet.WithdrawEnabled
+			}
+		}
+		// open suspensions of tokens no longer reported are over
+		for token := range notifications[action] {
+			if _, ok := suspended[token]; !ok {
+				suspended[token] = false
+			}
+		}
+		for token, isSuspended := range suspended {
+			msg := fmt.Sprintf("%s of %s is suspended on %s", common.SuspendedAction(action), token, exchange)
+			if _, err = self.record(exchange, action, token, isSuspended, msg, notifications, timestamp); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// record opens a notification of the action if it becomes active, or closes
+// the open one recorded by the monitor if it is over. It returns true if a
+// notification is opened or closed. Notifications with to time, eg: posted
+// by operators, are left untouched, as well as open ones posted by operators.
+func (self *StatusMonitor) record(
+	exchange, action, token string, active bool, msg string,
+	notifications common.ExchangeActionNoti, timestamp uint64) (bool, error) {
+	current, exists := notifications[action][token]
+	open := exists && current.ToTime == 0
+	switch {
+	case active && !open:
+		log.Printf("StatusMonitor: %s", msg)
+		return true, self.setting.UpdateExchangeNotificationContent(exchange, action, token, common.ExchangeNotiContent{
+			FromTime:  timestamp,
+			IsWarning: true,
+			Message:   msg,
+			Monitored: true,
+		})
+	case !active && open && current.Monitored:
+		log.Printf("StatusMonitor: %s of %s on %s is over", action, token, exchange)
+		current.ToTime = timestamp
+		current.IsWarning = false
+		return true, self.setting.UpdateExchangeNotificationContent(exchange, action, token, current)
+	}
+	return false, nil
+}
+
+// updateExchangeStatus sets the exchange down when its maintenance starts,
+// if it is up, and sets it up again when the maintenance is over, if it is
+// still down by the monitor. A status updated by operators in the meantime
+// is kept.
+func (self *StatusMonitor) updateExchangeStatus(exchange string, up bool, timestamp uint64) error {
+	status, err := self.setting.GetExchangeStatus()
+	if err != nil {
+		return err
+	}
+	current, exists := status[exchange]
+	if !up && exists && !current.Status {
+		return nil
+	}
+	if up && (!exists || current.Status || !current.Monitored) {
+		return nil
+	}
+	status[exchange] = common.ExStatus{Timestamp: timestamp, Status: up, Monitored: !up}
+	return self.setting.UpdateExchangeStatus(status)
+}
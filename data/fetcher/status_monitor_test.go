@@ -0,0 +1,146 @@
+package fetcher
+
+import (
+	"testing"
+
+	"github.com/KyberNetwork/reserve-data/common"
+)
+
+type fakeStatusExchange struct {
+	Exchange
+	status common.ExchangeSystemStatus
+}
+
+func (self *fakeStatusExchange) ID() common.ExchangeID {
+	return "binance"
+}
+
+func (self *fakeStatusExchange) SystemStatus() (common.ExchangeSystemStatus, error) {
+	return self.status, nil
+}
+
+type fakeStatusMonitorSetting struct {
+	notifications common.ExchangeNotifications
+	status        common.ExchangesStatus
+}
+
+func (self *fakeStatusMonitorSetting) GetExchangeNotifications() (common.ExchangeNotifications, error) {
+	return self.notifications, nil
+}
+
+func (self *fakeStatusMonitorSetting) UpdateExchangeNotificationContent(exchange, action, token string, noti common.ExchangeNotiContent) error {
+	if self.notifications[exchange] == nil {
+		self.notifications[exchange] = common.ExchangeActionNoti{}
+	}
+	if self.notifications[exchange][action] == nil {
+		self.notifications[exchange][action] = common.ExchangeTokenNoti{}
+	}
+	self.notifications[exchange][action][token] = noti
+	return nil
+}
+
+func (self *fakeStatusMonitorSetting) GetExchangeStatus() (common.ExchangesStatus, error) {
+	return self.status, nil
+}
+
+func (self *fakeStatusMonitorSetting) UpdateExchangeStatus(status common.ExchangesStatus) error {
+	self.status = status
+	return nil
+}
+
+func TestStatusMonitor(t *testing.T) {
+	setting := &fakeStatusMonitorSetting{
+		notifications: common.ExchangeNotifications{},
+		status:        common.ExchangesStatus{"binance": {Status: true}},
+	}
+	exchange := &fakeStatusExchange{
+		status: common.ExchangeSystemStatus{
+			Maintenance: true,
+			Message:     "system maintenance",
+			Wallets: map[string]common.TokenWalletStatus{
+				"KNC": {DepositEnabled: false, WithdrawEnabled: true},
+				"OMG": {DepositEnabled: true, WithdrawEnabled: true},
+			},
+		},
+	}
+	monitor := NewStatusMonitor(setting, DefaultStatusMonitorInterval)
+	monitor.Monitor([]Exchange{exchange}, 1000)
+
+	notifications := setting.notifications["binance"]
+	maintenance := notifications[common.ExchangeMaintenance][common.AllTokens]
+	if maintenance.FromTime != 1000 || maintenance.ToTime != 0 || !maintenance.IsWarning {
+		t.Errorf("Expected open maintenance notification from 1000, got %+v", maintenance)
+	}
+	if setting.status["binance"].Status {
+		t.Error("Expected exchange marked down under maintenance")
+	}
+	if noti, ok := notifications[common.SuspendDeposit]["KNC"]; !ok || noti.FromTime != 1000 || noti.ToTime != 0 {
+		t.Errorf("Expected open KNC deposit suspension, got %+v", notifications[common.SuspendDeposit])
+	}
+	if len(notifications[common.SuspendWithdraw]) != 0 || len(notifications[common.SuspendDeposit]) != 1 {
+		t.Errorf("Expected only KNC deposits suspended, got %+v", notifications)
+	}
+
+	// notifications are kept open until the exchange is back
+	monitor.Monitor([]Exchange{exchange}, 2000)
+	if notifications[common.SuspendDeposit]["KNC"].FromTime != 1000 {
+		t.Error("Expected open notification not to be recorded again")
+	}
+
+	exchange.status = common.ExchangeSystemStatus{
+		Wallets: map[string]common.TokenWalletStatus{
+			"OMG": {DepositEnabled: true, WithdrawEnabled: true},
+		},
+	}
+	monitor.Monitor([]Exchange{exchange}, 3000)
+	maintenance = notifications[common.ExchangeMaintenance][common.AllTokens]
+	if maintenance.FromTime != 1000 || maintenance.ToTime != 3000 || maintenance.IsWarning {
+		t.Errorf("Expected maintenance notification closed at 3000, got %+v", maintenance)
+	}
+	if !setting.status["binance"].Status {
+		t.Error("Expected exchange marked up after maintenance")
+	}
+	// KNC is no longer reported
+	if noti := notifications[common.SuspendDeposit]["KNC"]; noti.ToTime != 3000 || noti.IsActive(3000) {
+		t.Errorf("Expected KNC deposit suspension closed at 3000, got %+v", noti)
+	}
+}
+
+func TestStatusMonitorKeepsOperatorChanges(t *testing.T) {
+	setting := &fakeStatusMonitorSetting{
+		notifications: common.ExchangeNotifications{
+			"binance": {
+				common.SuspendWithdraw: {
+					"OMG": {FromTime: 500, IsWarning: true, Message: "hot wallet rotation"},
+				},
+			},
+		},
+		status: common.ExchangesStatus{"binance": {Status: false, Timestamp: 500}},
+	}
+	exchange := &fakeStatusExchange{
+		status: common.ExchangeSystemStatus{
+			Maintenance: true,
+			Wallets: map[string]common.TokenWalletStatus{
+				"OMG": {DepositEnabled: true, WithdrawEnabled: true},
+			},
+		},
+	}
+	monitor := NewStatusMonitor(setting, DefaultStatusMonitorInterval)
+	monitor.Monitor([]Exchange{exchange}, 1000)
+	if status := setting.status["binance"]; status.Timestamp != 500 || status.Monitored {
+		t.Errorf("Expected exchange disabled by operator to be left untouched, got %+v", status)
+	}
+
+	exchange.status.Maintenance = false
+	monitor.Monitor([]Exchange{exchange}, 2000)
+	if setting.status["binance"].Status {
+		t.Error("Expected exchange disabled by operator to stay down after maintenance")
+	}
+	notifications := setting.notifications["binance"]
+	if noti := notifications[common.SuspendWithdraw]["OMG"]; noti.ToTime != 0 || !noti.IsActive(2000) {
+		t.Errorf("Expected notification posted by operator to stay open, got %+v", noti)
+	}
+	if noti := notifications[common.ExchangeMaintenance][common.AllTokens]; noti.ToTime != 2000 {
+		t.Errorf("Expected maintenance notification of the monitor closed at 2000, got %+v", noti)
+	}
+}
@@ -0,0 +1,277 @@
+package fetcher
+
+import (
+	"log"
+	"math/big"
+	"strconv"
+	"time"
+
+	"github.com/KyberNetwork/reserve-data/common"
+	ethereum "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+const (
+	// DefaultStuckTxDuration is the default duration a transaction has to be
+	// pending before it is considered stuck.
+	DefaultStuckTxDuration = 10 * time.Minute
+	// DefaultMaxTxReplacements is the default number of times a stuck
+	// transaction is replaced with higher gas price. A deposit transaction
+	// still stuck afterward is cancelled.
+	DefaultMaxTxReplacements = 3
+
+	// gasPriceBumpPercent is the gas price increase of a replacement
+	// transaction, nodes require at least 10% to accept it.
+	gasPriceBumpPercent = 20
+
+	// activity result keys of the replacement chain
+	replacedTxsKey             = "replacedTxs"
+	replacedAtKey              = "replacedAt"
+	cancelledKey               = "cancelled"
+	intermediateTxKey          = "intermediateTx"
+	intermediateReplacedTxsKey = "intermediateReplacedTxs"
+)
+
+// TxReplacer contains the blockchain methods to replace pending deposit
+// transactions and the second transactions of intermediate accounts.
+type TxReplacer interface {
+	PendingTransaction(hash ethereum.Hash) (*types.Transaction, error)
+
+	DepositMinedNonce() (uint64, error)
+	ReplaceDepositTx(tx *types.Transaction, gasPrice *big.Int) (*types.Transaction, error)
+	CancelDepositTx(nonce uint64, gasPrice *big.Int) (*types.Transaction, error)
+
+	IntermediatorMinedNonce() (uint64, error)
+	ReplaceIntermediatorTx(tx *types.Transaction, gasPrice *big.Int) (*types.Transaction, error)
+}
+
+// IntermediateTxExchange is implemented by exchanges receiving deposits
+// through an intermediate account, like Huobi.
+type IntermediateTxExchange interface {
+	PendingIntermediateTxs() (map[common.ActivityID]common.TXEntry, error)
+	ReplacePendingIntermediateTx(id common.ActivityID, hash string) error
+}
+
+// TxSupervisor replaces stuck deposit transactions and intermediate
+// transactions. A transaction is stuck if it is pending for longer than
+// stuckDuration while its nonce is the next one to be mined. It is replaced
+// with the same nonce and a higher gas price, up to maxReplacements times,
+// then a deposit transaction is cancelled with a 0 value transfer to the
+// deposit operator itself. Intermediate transactions are never cancelled as
+// it would leave the fund in the intermediate account.
+//
+// The hashes of replaced transactions are recorded in the activity result,
+// so the fetcher can track whichever of them is mined.
+type TxSupervisor struct {
+	blockchain      TxReplacer
+	storage         Storage
+	stuckDuration   time.Duration
+	maxReplacements int
+}
+
+// TxSupervisorOption is the option of TxSupervisor constructor.
+type TxSupervisorOption func(*TxSupervisor)
+
+// WithStuckTxDuration sets the duration a transaction has to be pending
+// before it is replaced.
+func WithStuckTxDuration(duration time.Duration) TxSupervisorOption {
+	return func(self *TxSupervisor) {
+		self.stuckDuration = duration
+	}
+}
+
+// WithMaxTxReplacements sets the number of times a stuck transaction is
+// replaced with higher gas price.
+func WithMaxTxReplacements(max int) TxSupervisorOption {
+	return func(self *TxSupervisor) {
+		self.maxReplacements = max
+	}
+}
+
+// NewTxSupervisor creates a new TxSupervisor.
+func NewTxSupervisor(blockchain TxReplacer, storage Storage, options ...TxSupervisorOption) *TxSupervisor {
+	supervisor := &TxSupervisor{
+		blockchain:      blockchain,
+		storage:         storage,
+		stuckDuration:   DefaultStuckTxDuration,
+		maxReplacements: DefaultMaxTxReplacements,
+	}
+	for _, option := range options {
+		option(supervisor)
+	}
+	return supervisor
+}
+
+// Supervise replaces the stuck transactions of pending deposit activities.
+// It updates the result of replaced activities in place, so the caller keeps
+// tracking the replacement transactions.
+func (self *TxSupervisor) Supervise(pendings []common.ActivityRecord, exchanges []Exchange) {
+	deposits := map[common.ActivityID]common.ActivityRecord{}
+	for _, activity := range pendings {
+		if activity.Action != common.ActionDeposit {
+			continue
+		}
+		deposits[activity.ID] = activity
+		if activity.IsBlockchainPending() {
+			self.superviseDeposit(activity)
+		}
+	}
+	if len(deposits) == 0 {
+		return
+	}
+	for _, exchange := range exchanges {
+		intermediate, ok := exchange.(IntermediateTxExchange)
+		if !ok {
+			continue
+		}
+		txs, err := intermediate.PendingIntermediateTxs()
+		if err != nil {
+			log.Printf("TxSupervisor: getting pending intermediate txs of %s failed: %s", exchange.ID(), err)
+			continue
+		}
+		for id, entry := range txs {
+			activity, found := deposits[id]
+			if !found || entry.MiningStatus != common.MiningStatusSubmitted {
+				continue
+			}
+			self.superviseIntermediateTx(intermediate, activity, entry)
+		}
+	}
+}
+
+// stuckTx returns the pending transaction of hash if it is sent before
+// stuckDuration and its nonce is the next one to be mined.
+func (self *TxSupervisor) stuckTx(hash string, sentAt uint64, minedNonce func() (uint64, error)) *types.Transaction {
+	if common.GetTimepoint()-sentAt < uint64(self.stuckDuration/time.Millisecond) {
+		return nil
+	}
+	tx, err := self.blockchain.PendingTransaction(ethereum.HexToHash(hash))
+	if err != nil {
+		log.Printf("TxSupervisor: getting pending tx %s failed: %s", hash, err)
+		return nil
+	}
+	if tx == nil {
+		// mined or lost txs are handled by fetcher
+		return nil
+	}
+	nonce, err := minedNonce()
+	if err != nil {
+		log.Printf("TxSupervisor: getting mined nonce failed: %s", err)
+		return nil
+	}
+	if tx.Nonce() != nonce {
+		// an earlier tx of the same account is blocking this one
+		return nil
+	}
+	return tx
+}
+
+func (self *TxSupervisor) superviseDeposit(activity common.ActivityRecord) {
+	hash, ok := activity.Result["tx"].(string)
+	if !ok || hash == "" || isCancelled(activity) {
+		return
+	}
+	tx := self.stuckTx(hash, lastSentAt(activity), self.blockchain.DepositMinedNonce)
+	if tx == nil {
+		return
+	}
+	var (
+		replaced    = replacedTxs(activity.Result[replacedTxsKey])
+		gasPrice    = bumpGasPrice(tx.GasPrice())
+		replacement *types.Transaction
+		cancelled   bool
+		err         error
+	)
+	if len(replaced) < self.maxReplacements {
+		log.Printf("TxSupervisor: deposit tx %s is stuck, replacing it with gas price %s", hash, gasPrice.Text(10))
+		replacement, err = self.blockchain.ReplaceDepositTx(tx, gasPrice)
+	} else {
+		log.Printf("TxSupervisor: deposit tx %s is stuck after %d replacements, cancelling it", hash, len(replaced))
+		replacement, err = self.blockchain.CancelDepositTx(tx.Nonce(), gasPrice)
+		cancelled = true
+	}
+	if err != nil {
+		log.Printf("TxSupervisor: replacing deposit tx %s failed: %s", hash, err)
+		return
+	}
+	activity.Result[replacedTxsKey] = append(replaced, hash)
+	activity.Result[replacedAtKey] = strconv.FormatUint(common.GetTimepoint(), 10)
+	activity.Result["tx"] = replacement.Hash().Hex()
+	activity.Result["gasPrice"] = gasPrice.Text(10)
+	if cancelled {
+		activity.Result[cancelledKey] = true
+	}
+	if err = self.storage.UpdateActivity(activity.ID, activity); err != nil {
+		log.Printf("TxSupervisor: updating activity %s failed: %s", activity.ID, err)
+	}
+}
+
+func (self *TxSupervisor) superviseIntermediateTx(exchange IntermediateTxExchange, activity common.ActivityRecord, entry common.TXEntry) {
+	tx := self.stuckTx(entry.Hash, entry.Timestamp.MustToUint64(), self.blockchain.IntermediatorMinedNonce)
+	if tx == nil {
+		return
+	}
+	replaced := replacedTxs(activity.Result[intermediateReplacedTxsKey])
+	if len(replaced) >= self.maxReplacements {
+		log.Printf("TxSupervisor: WARNING: intermediate tx %s is still stuck after %d replacements", entry.Hash, len(replaced))
+		return
+	}
+	gasPrice := bumpGasPrice(tx.GasPrice())
+	log.Printf("TxSupervisor: intermediate tx %s is stuck, replacing it with gas price %s", entry.Hash, gasPrice.Text(10))
+	replacement, err := self.blockchain.ReplaceIntermediatorTx(tx, gasPrice)
+	if err != nil {
+		log.Printf("TxSupervisor: replacing intermediate tx %s failed: %s", entry.Hash, err)
+		return
+	}
+	if err = exchange.ReplacePendingIntermediateTx(activity.ID, replacement.Hash().Hex()); err != nil {
+		log.Printf("TxSupervisor: storing replacement of intermediate tx %s failed: %s", entry.Hash, err)
+	}
+	if activity.Result == nil {
+		activity.Result = map[string]interface{}{}
+	}
+	activity.Result[intermediateReplacedTxsKey] = append(replaced, entry.Hash)
+	activity.Result[intermediateTxKey] = replacement.Hash().Hex()
+	if err = self.storage.UpdateActivity(activity.ID, activity); err != nil {
+		log.Printf("TxSupervisor: updating activity %s failed: %s", activity.ID, err)
+	}
+}
+
+// bumpGasPrice returns the gas price of the replacement of a transaction.
+func bumpGasPrice(gasPrice *big.Int) *big.Int {
+	bumped := big.NewInt(0).Mul(gasPrice, big.NewInt(100+gasPriceBumpPercent))
+	return bumped.Div(bumped, big.NewInt(100))
+}
+
+// replacedTxs returns the replaced tx hashes recorded in activity result. The
+// value is a []interface{} once the activity is read back from storage.
+func replacedTxs(value interface{}) []string {
+	switch hashes := value.(type) {
+	case []string:
+		return hashes
+	case []interface{}:
+		result := []string{}
+		for _, hash := range hashes {
+			if hashStr, ok := hash.(string); ok {
+				result = append(result, hashStr)
+			}
+		}
+		return result
+	}
+	return []string{}
+}
+
+// lastSentAt returns the timepoint the current tx of the activity was sent.
+func lastSentAt(activity common.ActivityRecord) uint64 {
+	if replacedAt, ok := activity.Result[replacedAtKey].(string); ok {
+		if timepoint, err := strconv.ParseUint(replacedAt, 10, 64); err == nil {
+			return timepoint
+		}
+	}
+	return activity.Timestamp.MustToUint64()
+}
+
+// isCancelled returns true if the current tx of the activity is a cancellation.
+func isCancelled(activity common.ActivityRecord) bool {
+	cancelled, _ := activity.Result[cancelledKey].(bool)
+	return cancelled
+}
@@ -0,0 +1,168 @@
+package fetcher
+
+import (
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/KyberNetwork/reserve-data/common"
+	"github.com/KyberNetwork/reserve-data/data/storage"
+	ethereum "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+type fakeTxReplacer struct {
+	pending    map[ethereum.Hash]*types.Transaction
+	minedNonce uint64
+	cancelled  int
+}
+
+func (self *fakeTxReplacer) PendingTransaction(hash ethereum.Hash) (*types.Transaction, error) {
+	return self.pending[hash], nil
+}
+
+func (self *fakeTxReplacer) DepositMinedNonce() (uint64, error) {
+	return self.minedNonce, nil
+}
+
+func (self *fakeTxReplacer) IntermediatorMinedNonce() (uint64, error) {
+	return self.minedNonce, nil
+}
+
+func (self *fakeTxReplacer) replace(tx *types.Transaction, gasPrice *big.Int) (*types.Transaction, error) {
+	replacement := types.NewTransaction(tx.Nonce(), *tx.To(), tx.Value(), tx.Gas(), gasPrice, tx.Data())
+	delete(self.pending, tx.Hash())
+	self.pending[replacement.Hash()] = replacement
+	return replacement, nil
+}
+
+func (self *fakeTxReplacer) ReplaceDepositTx(tx *types.Transaction, gasPrice *big.Int) (*types.Transaction, error) {
+	return self.replace(tx, gasPrice)
+}
+
+func (self *fakeTxReplacer) ReplaceIntermediatorTx(tx *types.Transaction, gasPrice *big.Int) (*types.Transaction, error) {
+	return self.replace(tx, gasPrice)
+}
+
+func (self *fakeTxReplacer) CancelDepositTx(nonce uint64, gasPrice *big.Int) (*types.Transaction, error) {
+	self.cancelled++
+	return types.NewTransaction(nonce, ethereum.Address{}, big.NewInt(0), 21000, gasPrice, nil), nil
+}
+
+type fakeIntermediateExchange struct {
+	Exchange
+	txs map[common.ActivityID]common.TXEntry
+}
+
+func (self *fakeIntermediateExchange) PendingIntermediateTxs() (map[common.ActivityID]common.TXEntry, error) {
+	return self.txs, nil
+}
+
+func (self *fakeIntermediateExchange) ReplacePendingIntermediateTx(id common.ActivityID, hash string) error {
+	entry := self.txs[id]
+	entry.ReplacedHashes = append(entry.ReplacedHashes, entry.Hash)
+	entry.Hash = hash
+	entry.Timestamp = common.GetTimestamp()
+	self.txs[id] = entry
+	return nil
+}
+
+func TestTxSupervisor(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "test_tx_supervisor")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if rErr := os.RemoveAll(tmpDir); rErr != nil {
+			t.Error(rErr)
+		}
+	}()
+	fstorage, err := storage.NewBoltStorage(path.Join(tmpDir, "test_fetcher.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	depositTx := types.NewTransaction(5, ethereum.HexToAddress("0x1"), big.NewInt(0), 100000, big.NewInt(10000000000), nil)
+	intermediateTx := types.NewTransaction(7, ethereum.HexToAddress("0x2"), big.NewInt(1), 50000, big.NewInt(10000000000), nil)
+	replacer := &fakeTxReplacer{
+		pending: map[ethereum.Hash]*types.Transaction{
+			depositTx.Hash():      depositTx,
+			intermediateTx.Hash(): intermediateTx,
+		},
+		minedNonce: 5,
+	}
+	sentAt := common.GetTimepoint() - uint64(time.Hour/time.Millisecond)
+	depositID := common.ActivityID{Timepoint: sentAt, EID: "deposit"}
+	huobiID := common.ActivityID{Timepoint: sentAt + 1, EID: "huobi_deposit"}
+	// tx1 of the huobi deposit is mined
+	for id, tx := range map[common.ActivityID]string{depositID: depositTx.Hash().Hex(), huobiID: "0x3"} {
+		if err = fstorage.Record(
+			common.ActionDeposit, id, "binance",
+			map[string]interface{}{},
+			map[string]interface{}{"tx": tx},
+			"", common.MiningStatusSubmitted, sentAt,
+		); err != nil {
+			t.Fatal(err)
+		}
+	}
+	exchange := &fakeIntermediateExchange{txs: map[common.ActivityID]common.TXEntry{
+		huobiID: {Hash: intermediateTx.Hash().Hex(), MiningStatus: common.MiningStatusSubmitted, Timestamp: common.Timestamp(strconv.FormatUint(sentAt, 10))},
+	}}
+	supervisor := NewTxSupervisor(replacer, fstorage, WithStuckTxDuration(0), WithMaxTxReplacements(2))
+
+	supervise := func() map[common.ActivityID]common.ActivityRecord {
+		pendings, pErr := fstorage.GetPendingActivities()
+		if pErr != nil {
+			t.Fatal(pErr)
+		}
+		supervisor.Supervise(pendings, []Exchange{exchange})
+		// the activities are updated in storage as well as in place
+		stored, pErr := fstorage.GetPendingActivities()
+		if pErr != nil {
+			t.Fatal(pErr)
+		}
+		result := map[common.ActivityID]common.ActivityRecord{}
+		for i, activity := range stored {
+			if activity.Result["tx"] != pendings[i].Result["tx"] {
+				t.Errorf("expected stored tx %v, got %v", pendings[i].Result["tx"], activity.Result["tx"])
+			}
+			result[activity.ID] = activity
+		}
+		return result
+	}
+
+	// the intermediate tx is blocked by the stuck deposit tx
+	activities := supervise()
+	deposit := activities[depositID]
+	if replaced := replacedTxs(deposit.Result[replacedTxsKey]); len(replaced) != 1 || replaced[0] != depositTx.Hash().Hex() {
+		t.Errorf("expected deposit tx is replaced, got %v", deposit.Result)
+	}
+	if deposit.Result["gasPrice"] != "12000000000" {
+		t.Errorf("expected bumped gas price, got %v", deposit.Result["gasPrice"])
+	}
+	if _, found := activities[huobiID].Result[intermediateReplacedTxsKey]; found {
+		t.Errorf("expected blocked intermediate tx is not replaced, got %v", activities[huobiID].Result)
+	}
+
+	replacer.minedNonce = 7
+	activities = supervise()
+	if replaced := replacedTxs(activities[huobiID].Result[intermediateReplacedTxsKey]); len(replaced) != 1 || replaced[0] != intermediateTx.Hash().Hex() {
+		t.Errorf("expected intermediate tx is replaced, got %v", activities[huobiID].Result)
+	}
+	if entry := exchange.txs[huobiID]; entry.Hash != activities[huobiID].Result[intermediateTxKey] {
+		t.Errorf("expected exchange tracks replacement tx %v, got %s", activities[huobiID].Result[intermediateTxKey], entry.Hash)
+	}
+
+	// deposit tx is cancelled after max replacements
+	replacer.minedNonce = 5
+	supervise()
+	activities = supervise()
+	deposit = activities[depositID]
+	if replacer.cancelled != 1 || !isCancelled(deposit) || len(replacedTxs(deposit.Result[replacedTxsKey])) != 3 {
+		t.Errorf("expected deposit tx is cancelled after 2 replacements, got %v", deposit.Result)
+	}
+}
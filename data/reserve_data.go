@@ -75,6 +75,22 @@ func (self ReserveData) GetOnePrice(pairID common.TokenPairID, timepoint uint64)
 	}
 }
 
+// GetPairDepth returns the VWAP and depth of executing quantity of the base
+// token of a pair from the order books stored at timepoint.
+func (self ReserveData) GetPairDepth(pairID common.TokenPairID, quantity float64, bands []uint64, timepoint uint64) (common.PairDepth, error) {
+	version, err := self.storage.CurrentPriceVersion(timepoint)
+	if err != nil {
+		return common.PairDepth{}, err
+	}
+	data, err := self.storage.GetOnePrice(pairID, version)
+	if err != nil {
+		return common.PairDepth{}, err
+	}
+	result := common.NewPairDepth(data, quantity, bands)
+	result.Version = version
+	return result, nil
+}
+
 func (self ReserveData) CurrentAuthDataVersion(timepoint uint64) (common.Version, error) {
 	return self.storage.CurrentAuthDataVersion(timepoint)
 }
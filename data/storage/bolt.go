@@ -9,6 +9,7 @@ import (
 	"math"
 	"os"
 	"reflect"
+	"sort"
 	"strconv"
 	"strings"
 	"sync"
@@ -55,6 +56,15 @@ const (
 	pendingRebalanceQuadratic = "pending_rebalance_quadratic"
 	// rebalanceQuadratic stores rebalance quadratic equation
 	rebalanceQuadratic = "rebalance_quadratic"
+
+	// withdrawThresholds stores the withdraw thresholds of tokens
+	withdrawThresholds = "withdraw_thresholds"
+	// withdrawalApprovals stores withdrawals requiring approval, keyed by id
+	withdrawalApprovals = "withdrawal_approvals"
+	// executions stores executions of large orders, keyed by activity id
+	executions = "executions"
+	// replayBucket marks the storage used by replays
+	replayBucket = "replay"
 )
 
 // BoltStorage is the storage implementation of data.Storage interface
@@ -147,6 +157,18 @@ func NewBoltStorage(path string) (*BoltStorage, error) {
 		if _, cErr := tx.CreateBucketIfNotExists([]byte(stepFunctionBucket)); cErr != nil {
 			return cErr
 		}
+		if _, cErr := tx.CreateBucketIfNotExists([]byte(withdrawThresholds)); cErr != nil {
+			return cErr
+		}
+		if _, cErr := tx.CreateBucketIfNotExists([]byte(withdrawalApprovals)); cErr != nil {
+			return cErr
+		}
+		if _, cErr := tx.CreateBucketIfNotExists([]byte(executions)); cErr != nil {
+			return cErr
+		}
+		if _, cErr := tx.CreateBucketIfNotExists([]byte(replayBucket)); cErr != nil {
+			return cErr
+		}
 		return nil
 	})
 	if err != nil {
@@ -1707,3 +1729,180 @@ func (bs *BoltStorage) GetStepFunctionData() (common.StepFunctionData, error) {
 	})
 	return result, err
 }
+
+//GetWithdrawThresholds return the withdraw thresholds of tokens, it is empty if never stored
+func (self *BoltStorage) GetWithdrawThresholds() (common.WithdrawThresholds, error) {
+	result := common.WithdrawThresholds{}
+	err := self.db.View(func(tx *bolt.Tx) error {
+		_, data := tx.Bucket([]byte(withdrawThresholds)).Cursor().First()
+		if data == nil {
+			return nil
+		}
+		return json.Unmarshal(data, &result)
+	})
+	return result, err
+}
+
+//StoreWithdrawThresholds replace the withdraw thresholds of tokens
+func (self *BoltStorage) StoreWithdrawThresholds(thresholds common.WithdrawThresholds) error {
+	dataJSON, err := json.Marshal(thresholds)
+	if err != nil {
+		return err
+	}
+	return self.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket([]byte(withdrawThresholds))
+		// prune out old data
+		if k, _ := b.Cursor().First(); k != nil {
+			if dErr := b.Delete(k); dErr != nil {
+				return dErr
+			}
+		}
+		return b.Put(boltutil.Uint64ToBytes(common.GetTimepoint()), dataJSON)
+	})
+}
+
+//StoreWithdrawalApproval store a new withdrawal approval
+func (self *BoltStorage) StoreWithdrawalApproval(approval common.WithdrawalApproval) error {
+	dataJSON, err := json.Marshal(approval)
+	if err != nil {
+		return err
+	}
+	return self.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket([]byte(withdrawalApprovals))
+		if b.Get([]byte(approval.ID)) != nil {
+			return fmt.Errorf("withdrawal %s exists already", approval.ID)
+		}
+		return b.Put([]byte(approval.ID), dataJSON)
+	})
+}
+
+//UpdateWithdrawalApproval replace the stored withdrawal approval if its status is the given one
+func (self *BoltStorage) UpdateWithdrawalApproval(approval common.WithdrawalApproval, status string) error {
+	dataJSON, err := json.Marshal(approval)
+	if err != nil {
+		return err
+	}
+	return self.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket([]byte(withdrawalApprovals))
+		v := b.Get([]byte(approval.ID))
+		if v == nil {
+			return fmt.Errorf("withdrawal %s doesn't exist", approval.ID)
+		}
+		var current common.WithdrawalApproval
+		if uErr := json.Unmarshal(v, &current); uErr != nil {
+			return uErr
+		}
+		if current.Status != status {
+			return fmt.Errorf("withdrawal %s is %s already", approval.ID, current.Status)
+		}
+		return b.Put([]byte(approval.ID), dataJSON)
+	})
+}
+
+//GetWithdrawalApproval return the withdrawal approval of given id
+func (self *BoltStorage) GetWithdrawalApproval(id string) (common.WithdrawalApproval, error) {
+	var result common.WithdrawalApproval
+	err := self.db.View(func(tx *bolt.Tx) error {
+		v := tx.Bucket([]byte(withdrawalApprovals)).Get([]byte(id))
+		if v == nil {
+			return fmt.Errorf("withdrawal %s doesn't exist", id)
+		}
+		return json.Unmarshal(v, &result)
+	})
+	return result, err
+}
+
+// filterWithdrawalApprovals returns the withdrawal approvals matching filter
+// ordered by proposed time.
+func (self *BoltStorage) filterWithdrawalApprovals(filter func(common.WithdrawalApproval) bool) ([]common.WithdrawalApproval, error) {
+	result := []common.WithdrawalApproval{}
+	err := self.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket([]byte(withdrawalApprovals)).ForEach(func(k, v []byte) error {
+			var approval common.WithdrawalApproval
+			if uErr := json.Unmarshal(v, &approval); uErr != nil {
+				return uErr
+			}
+			if filter(approval) {
+				result = append(result, approval)
+			}
+			return nil
+		})
+	})
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].ProposedAt < result[j].ProposedAt
+	})
+	return result, err
+}
+
+//GetWithdrawalApprovals return withdrawal approvals proposed in [fromTime, toTime]
+func (self *BoltStorage) GetWithdrawalApprovals(fromTime, toTime uint64) ([]common.WithdrawalApproval, error) {
+	return self.filterWithdrawalApprovals(func(approval common.WithdrawalApproval) bool {
+		return approval.ProposedAt >= fromTime && approval.ProposedAt <= toTime
+	})
+}
+
+//GetPendingWithdrawalApprovals return withdrawal approvals of pending status
+func (self *BoltStorage) GetPendingWithdrawalApprovals() ([]common.WithdrawalApproval, error) {
+	return self.filterWithdrawalApprovals(func(approval common.WithdrawalApproval) bool {
+		return approval.Status == common.WithdrawalApprovalPending
+	})
+}
+
+//StoreExecution store the execution, replacing the stored one of the same id
+func (self *BoltStorage) StoreExecution(execution common.Execution) error {
+	dataJSON, err := json.Marshal(execution)
+	if err != nil {
+		return err
+	}
+	return self.db.Update(func(tx *bolt.Tx) error {
+		idBytes := execution.ID.ToBytes()
+		return tx.Bucket([]byte(executions)).Put(idBytes[:], dataJSON)
+	})
+}
+
+//GetExecution return the execution of given id
+func (self *BoltStorage) GetExecution(id common.ActivityID) (common.Execution, error) {
+	var result common.Execution
+	err := self.db.View(func(tx *bolt.Tx) error {
+		idBytes := id.ToBytes()
+		v := tx.Bucket([]byte(executions)).Get(idBytes[:])
+		if v == nil {
+			return fmt.Errorf("execution %s doesn't exist", id)
+		}
+		return json.Unmarshal(v, &result)
+	})
+	return result, err
+}
+
+//GetRunningExecutions return executions of running status ordered by creation time
+func (self *BoltStorage) GetRunningExecutions() ([]common.Execution, error) {
+	result := []common.Execution{}
+	err := self.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket([]byte(executions)).ForEach(func(k, v []byte) error {
+			var execution common.Execution
+			if uErr := json.Unmarshal(v, &execution); uErr != nil {
+				return uErr
+			}
+			if execution.Status == common.ExecutionRunning {
+				result = append(result, execution)
+			}
+			return nil
+		})
+	})
+	return result, err
+}
+
+// MarkReplay marks the storage used by replays. It returns error if the
+// storage holds activities and is not marked already, as they are live.
+func (self *BoltStorage) MarkReplay() error {
+	return self.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket([]byte(replayBucket))
+		if b.Get([]byte(replayBucket)) != nil {
+			return nil
+		}
+		if k, _ := tx.Bucket([]byte(activityBucket)).Cursor().First(); k != nil {
+			return errors.New("storage holds live activities, replays require a separate storage")
+		}
+		return b.Put([]byte(replayBucket), boltutil.Uint64ToBytes(common.GetTimepoint()))
+	})
+}
@@ -100,3 +100,48 @@ func TestConvertTargetQtyV1toV2(t *testing.T) {
 		}
 	}
 }
+
+func TestBoltStorageConformance(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "test_bolt_storage")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err = os.RemoveAll(tmpDir); err != nil {
+			t.Error(err)
+		}
+	}()
+	storage, err := NewBoltStorage(filepath.Join(tmpDir, "test_bolt.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	testutil.NewStorageTestSuite(t, storage).Run()
+}
+
+func TestMarkReplayBoltStorage(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "test_replay_storage")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err = os.RemoveAll(tmpDir); err != nil {
+			t.Error(err)
+		}
+	}()
+	storage, err := NewBoltStorage(filepath.Join(tmpDir, "test_bolt.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = storage.MarkReplay(); err != nil {
+		t.Fatal(err)
+	}
+	err = storage.Record(common.ActionTrade, common.NewActivityID(1, "1"), "binance",
+		map[string]interface{}{}, map[string]interface{}{}, "", "", 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// activities of a marked storage are replayed ones
+	if err = storage.MarkReplay(); err != nil {
+		t.Errorf("expected replay storage to be marked again, got %s", err)
+	}
+}
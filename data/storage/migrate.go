@@ -0,0 +1,270 @@
+package storage
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+
+	"github.com/KyberNetwork/reserve-data/boltutil"
+	"github.com/KyberNetwork/reserve-data/common"
+	"github.com/boltdb/bolt"
+)
+
+// versionedBuckets maps bolt buckets keyed by timepoint to their postgres tables.
+var versionedBuckets = map[string]string{
+	goldBucket:           pgGoldTable,
+	priceBucket:          pgPriceTable,
+	authDataBucket:       pgAuthDataTable,
+	metricBucket:         pgMetricTable,
+	metricTargetQuantity: pgTargetQuantityTable,
+	pwiEquation:          pgPWIEquationTable,
+}
+
+// MigrateBoltToPostgres copies all data of the given BoltStorage to the given
+// PostgresStorage in a single transaction. Existing records in postgres with
+// the same keys are overwritten, so it is safe to run it again after a
+// failure. It returns the number of copied records of each bolt bucket.
+func MigrateBoltToPostgres(src *BoltStorage, dst *PostgresStorage) (map[string]uint64, error) {
+	result := map[string]uint64{}
+	err := src.db.View(func(btx *bolt.Tx) error {
+		return dst.inTx(func(tx *sql.Tx) error {
+			for bucket, table := range versionedBuckets {
+				n, err := migrateVersionedBucket(btx, tx, bucket, table)
+				if err != nil {
+					return fmt.Errorf("migrate bucket %s failed: %s", bucket, err)
+				}
+				result[bucket] = n
+			}
+
+			steps := []struct {
+				bucket  string
+				migrate func(btx *bolt.Tx, tx *sql.Tx) (uint64, error)
+			}{
+				{rateBucket, migrateRates},
+				{activityBucket, migrateActivities},
+				{enableRebalance, migrateControl(enableRebalance)},
+				{setrateControl, migrateControl(setrateControl)},
+				{pendingStatbleTokenParamsBucket, migratePendingBucket(pendingStatbleTokenParamsBucket)},
+				{pendingTargetQuantityV2, migratePendingBucket(pendingTargetQuantityV2)},
+				{pendingPWIEquationV2, migratePendingBucket(pendingPWIEquationV2)},
+				{pendingRebalanceQuadratic, migratePendingBucket(pendingRebalanceQuadratic)},
+				{stableTokenParamsBucket, migrateSettingBucket(stableTokenParamsBucket, false)},
+				{targetQuantityV2, migrateSettingBucket(targetQuantityV2, false)},
+				{pwiEquationV2, migrateSettingBucket(pwiEquationV2, true)},
+				{rebalanceQuadratic, migrateSettingBucket(rebalanceQuadratic, true)},
+				{stepFunctionBucket, migrateStepFunction},
+				{withdrawThresholds, migrateControl(withdrawThresholds)},
+				{withdrawalApprovals, migrateWithdrawalApprovals},
+				{executions, migrateExecutions},
+			}
+			for _, step := range steps {
+				n, err := step.migrate(btx, tx)
+				if err != nil {
+					return fmt.Errorf("migrate bucket %s failed: %s", step.bucket, err)
+				}
+				result[step.bucket] = n
+			}
+			return nil
+		})
+	})
+	return result, err
+}
+
+func migrateVersionedBucket(btx *bolt.Tx, tx *sql.Tx, bucket, table string) (uint64, error) {
+	var n uint64
+	b := btx.Bucket([]byte(bucket))
+	if b == nil {
+		return 0, nil
+	}
+	err := b.ForEach(func(k, v []byte) error {
+		if err := storeRawVersion(tx, table, boltutil.BytesToUint64(k), v); err != nil {
+			return err
+		}
+		n++
+		return nil
+	})
+	return n, err
+}
+
+func migrateRates(btx *bolt.Tx, tx *sql.Tx) (uint64, error) {
+	var n uint64
+	b := btx.Bucket([]byte(rateBucket))
+	if b == nil {
+		return 0, nil
+	}
+	err := b.ForEach(func(k, v []byte) error {
+		var entry common.AllRateEntry
+		if err := json.Unmarshal(v, &entry); err != nil {
+			return err
+		}
+		_, err := tx.Exec(
+			`INSERT INTO rates (timepoint, block_number, data) VALUES ($1, $2, $3)
+ON CONFLICT (timepoint) DO UPDATE SET block_number = EXCLUDED.block_number, data = EXCLUDED.data`,
+			int64(boltutil.BytesToUint64(k)), int64(entry.BlockNumber), string(v),
+		)
+		if err != nil {
+			return err
+		}
+		n++
+		return nil
+	})
+	return n, err
+}
+
+// migrateActivities copies all activities, the ones in pending activity
+// bucket are marked as pending.
+func migrateActivities(btx *bolt.Tx, tx *sql.Tx) (uint64, error) {
+	var n uint64
+	b := btx.Bucket([]byte(activityBucket))
+	pb := btx.Bucket([]byte(pendingActivityBucket))
+	if b == nil || pb == nil {
+		return 0, nil
+	}
+	err := b.ForEach(func(k, v []byte) error {
+		var record common.ActivityRecord
+		if err := json.Unmarshal(v, &record); err != nil {
+			return err
+		}
+		if err := storeActivity(tx, record, pb.Get(k) != nil); err != nil {
+			return err
+		}
+		n++
+		return nil
+	})
+	return n, err
+}
+
+func migrateControl(bucket string) func(btx *bolt.Tx, tx *sql.Tx) (uint64, error) {
+	return func(btx *bolt.Tx, tx *sql.Tx) (uint64, error) {
+		b := btx.Bucket([]byte(bucket))
+		if b == nil {
+			return 0, nil
+		}
+		k, v := b.Cursor().First()
+		if k == nil {
+			return 0, nil
+		}
+		_, err := tx.Exec(
+			`INSERT INTO controls (name, timepoint, data) VALUES ($1, $2, $3)
+ON CONFLICT (name) DO UPDATE SET timepoint = EXCLUDED.timepoint, data = EXCLUDED.data`,
+			bucket, int64(boltutil.BytesToUint64(k)), string(v),
+		)
+		if err != nil {
+			return 0, err
+		}
+		return 1, nil
+	}
+}
+
+// migratePendingBucket copies the only pending record of given bucket.
+func migratePendingBucket(bucket string) func(btx *bolt.Tx, tx *sql.Tx) (uint64, error) {
+	return func(btx *bolt.Tx, tx *sql.Tx) (uint64, error) {
+		b := btx.Bucket([]byte(bucket))
+		if b == nil {
+			return 0, nil
+		}
+		k, v := b.Cursor().First()
+		if k == nil {
+			return 0, nil
+		}
+		if _, err := removePending(tx, bucket); err != nil {
+			return 0, err
+		}
+		if _, err := storePending(tx, bucket, v); err != nil {
+			return 0, err
+		}
+		return 1, nil
+	}
+}
+
+// migratedSettingTimepoint is the timepoint of the current record of not
+// versioned setting buckets, which don't keep when it was confirmed. It is
+// fixed so migrating again overwrites the same row, and it is older than
+// any setting confirmed in postgres.
+const migratedSettingTimepoint uint64 = 0
+
+// migrateSettingBucket copies confirmed settings of given bucket. If
+// versioned is true, bucket is keyed by timepoint and its whole history is
+// copied, otherwise it only has the current record, which is copied at
+// migratedSettingTimepoint.
+func migrateSettingBucket(bucket string, versioned bool) func(btx *bolt.Tx, tx *sql.Tx) (uint64, error) {
+	return func(btx *bolt.Tx, tx *sql.Tx) (uint64, error) {
+		var n uint64
+		b := btx.Bucket([]byte(bucket))
+		if b == nil {
+			return 0, nil
+		}
+		err := b.ForEach(func(k, v []byte) error {
+			timepoint := migratedSettingTimepoint
+			if versioned {
+				timepoint = boltutil.BytesToUint64(k)
+			}
+			_, err := tx.Exec(
+				`INSERT INTO settings_history (name, timepoint, data) VALUES ($1, $2, $3)
+ON CONFLICT (name, timepoint) DO UPDATE SET data = EXCLUDED.data`,
+				bucket, int64(timepoint), string(v),
+			)
+			if err != nil {
+				return err
+			}
+			n++
+			return nil
+		})
+		return n, err
+	}
+}
+
+func migrateStepFunction(btx *bolt.Tx, tx *sql.Tx) (uint64, error) {
+	b := btx.Bucket([]byte(stepFunctionBucket))
+	if b == nil {
+		return 0, nil
+	}
+	v := b.Get([]byte(stepFunctionLatestDataKey))
+	if v == nil {
+		return 0, nil
+	}
+	if err := storeStepFunction(tx, v); err != nil {
+		return 0, err
+	}
+	return 1, nil
+}
+
+func migrateWithdrawalApprovals(btx *bolt.Tx, tx *sql.Tx) (uint64, error) {
+	var n uint64
+	b := btx.Bucket([]byte(withdrawalApprovals))
+	if b == nil {
+		return 0, nil
+	}
+	err := b.ForEach(func(k, v []byte) error {
+		var approval common.WithdrawalApproval
+		if err := json.Unmarshal(v, &approval); err != nil {
+			return err
+		}
+		if err := storeWithdrawalApproval(tx, approval, v); err != nil {
+			return err
+		}
+		n++
+		return nil
+	})
+	return n, err
+}
+
+func migrateExecutions(btx *bolt.Tx, tx *sql.Tx) (uint64, error) {
+	var n uint64
+	b := btx.Bucket([]byte(executions))
+	if b == nil {
+		return 0, nil
+	}
+	err := b.ForEach(func(k, v []byte) error {
+		var execution common.Execution
+		if err := json.Unmarshal(v, &execution); err != nil {
+			return err
+		}
+		if err := storeExecution(tx, execution, v); err != nil {
+			return err
+		}
+		n++
+		return nil
+	})
+	return n, err
+}
@@ -0,0 +1,1400 @@
+package storage
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"reflect"
+	"strconv"
+
+	"github.com/KyberNetwork/reserve-data/common"
+	// register postgres driver
+	_ "github.com/lib/pq"
+)
+
+const (
+	pgPriceTable          = "prices"
+	pgRateTable           = "rates"
+	pgAuthDataTable       = "auth_data"
+	pgGoldTable           = "gold_feeds"
+	pgMetricTable         = "metrics"
+	pgTargetQuantityTable = "target_quantity"
+	pgPWIEquationTable    = "pwi_equation"
+	postgresDriverName    = "postgres"
+)
+
+// postgresSchema creates all tables used by PostgresStorage. Time series
+// data is keyed by its timepoint, like the bolt buckets. Pending and
+// confirmed settings are stored in generic tables keyed by the name of the
+// corresponding bolt bucket.
+const postgresSchema = `
+CREATE TABLE IF NOT EXISTS prices (
+	timepoint BIGINT PRIMARY KEY,
+	data      JSONB  NOT NULL
+);
+CREATE TABLE IF NOT EXISTS rates (
+	timepoint    BIGINT PRIMARY KEY,
+	block_number BIGINT NOT NULL,
+	data         JSONB  NOT NULL
+);
+CREATE TABLE IF NOT EXISTS auth_data (
+	timepoint BIGINT PRIMARY KEY,
+	data      JSONB  NOT NULL
+);
+CREATE TABLE IF NOT EXISTS gold_feeds (
+	timepoint BIGINT PRIMARY KEY,
+	data      JSONB  NOT NULL
+);
+CREATE TABLE IF NOT EXISTS activities (
+	timepoint   BIGINT  NOT NULL,
+	eid         TEXT    NOT NULL,
+	action      TEXT    NOT NULL,
+	destination TEXT    NOT NULL,
+	is_pending  BOOLEAN NOT NULL DEFAULT FALSE,
+	data        JSONB   NOT NULL,
+	PRIMARY KEY (timepoint, eid)
+);
+CREATE INDEX IF NOT EXISTS activities_pending_idx ON activities (is_pending) WHERE is_pending;
+CREATE TABLE IF NOT EXISTS metrics (
+	timepoint BIGINT PRIMARY KEY,
+	data      JSONB  NOT NULL
+);
+CREATE TABLE IF NOT EXISTS target_quantity (
+	timepoint BIGINT PRIMARY KEY,
+	data      JSONB  NOT NULL
+);
+CREATE TABLE IF NOT EXISTS pwi_equation (
+	timepoint BIGINT PRIMARY KEY,
+	data      JSONB  NOT NULL
+);
+CREATE TABLE IF NOT EXISTS controls (
+	name      TEXT   PRIMARY KEY,
+	timepoint BIGINT NOT NULL,
+	data      JSONB  NOT NULL
+);
+CREATE TABLE IF NOT EXISTS pending_settings (
+	name      TEXT   PRIMARY KEY,
+	timepoint BIGINT NOT NULL,
+	data      JSONB  NOT NULL
+);
+CREATE TABLE IF NOT EXISTS settings_history (
+	name      TEXT   NOT NULL,
+	timepoint BIGINT NOT NULL,
+	data      JSONB  NOT NULL,
+	PRIMARY KEY (name, timepoint)
+);
+CREATE TABLE IF NOT EXISTS step_function (
+	name TEXT  PRIMARY KEY,
+	data JSONB NOT NULL
+);
+CREATE TABLE IF NOT EXISTS withdrawal_approvals (
+	id          TEXT   PRIMARY KEY,
+	proposed_at BIGINT NOT NULL,
+	status      TEXT   NOT NULL,
+	data        JSONB  NOT NULL
+);
+CREATE INDEX IF NOT EXISTS withdrawal_approvals_proposed_at_idx ON withdrawal_approvals (proposed_at);
+CREATE TABLE IF NOT EXISTS executions (
+	id         TEXT   PRIMARY KEY,
+	created_at BIGINT NOT NULL,
+	status     TEXT   NOT NULL,
+	data       JSONB  NOT NULL
+);
+CREATE INDEX IF NOT EXISTS executions_status_idx ON executions (status);
+`
+
+// pgExecutor is the common interface of *sql.DB and *sql.Tx.
+type pgExecutor interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+	Query(query string, args ...interface{}) (*sql.Rows, error)
+	QueryRow(query string, args ...interface{}) *sql.Row
+}
+
+// PostgresStorage is the storage implementation of data.Storage,
+// fetcher.Storage, core.ActivityStorage and metric.MetricStorage interfaces
+// that uses PostgreSQL as its storage engine.
+type PostgresStorage struct {
+	db *sql.DB
+}
+
+// NewPostgresStorage creates a new PostgresStorage instance connecting to
+// the database with given data source name, the schema is created if it does
+// not exist.
+func NewPostgresStorage(dataSourceName string) (*PostgresStorage, error) {
+	db, err := sql.Open(postgresDriverName, dataSourceName)
+	if err != nil {
+		return nil, err
+	}
+	if err = db.Ping(); err != nil {
+		return nil, err
+	}
+	if _, err = db.Exec(postgresSchema); err != nil {
+		return nil, err
+	}
+	return &PostgresStorage{db: db}, nil
+}
+
+// Close closes the underlying database connections.
+func (self *PostgresStorage) Close() error {
+	return self.db.Close()
+}
+
+// inTx runs f in a database transaction, the transaction is committed if f
+// returns no error and rolled back otherwise.
+func (self *PostgresStorage) inTx(f func(tx *sql.Tx) error) error {
+	tx, err := self.db.Begin()
+	if err != nil {
+		return err
+	}
+	if err = f(tx); err != nil {
+		if rErr := tx.Rollback(); rErr != nil {
+			log.Printf("Rollback postgres transaction error: %s", rErr.Error())
+		}
+		return err
+	}
+	return tx.Commit()
+}
+
+// currentVersion returns the most recent time point to the given one in
+// parameter in the given table.
+func (self *PostgresStorage) currentVersion(table string, timepoint uint64) (common.Version, error) {
+	var result int64
+	err := self.db.QueryRow(
+		fmt.Sprintf(`SELECT timepoint FROM %s WHERE timepoint <= $1 ORDER BY timepoint DESC LIMIT 1`, table),
+		int64(timepoint),
+	).Scan(&result)
+	if err == sql.ErrNoRows {
+		return 0, fmt.Errorf("There is no data before timepoint %d", timepoint)
+	}
+	return common.Version(result), err
+}
+
+// getVersion decodes the record of given version in the given table to result.
+func (self *PostgresStorage) getVersion(table string, version common.Version, result interface{}) error {
+	var data []byte
+	err := self.db.QueryRow(
+		fmt.Sprintf(`SELECT data FROM %s WHERE timepoint = $1`, table),
+		int64(version),
+	).Scan(&data)
+	if err == sql.ErrNoRows {
+		return fmt.Errorf("version %d doesn't exist", uint64(version))
+	}
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, result)
+}
+
+// storeVersion stores the given data at the given time point in the given table.
+func storeVersion(exec pgExecutor, table string, timepoint uint64, data interface{}) error {
+	dataJSON, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	return storeRawVersion(exec, table, timepoint, dataJSON)
+}
+
+func storeRawVersion(exec pgExecutor, table string, timepoint uint64, dataJSON []byte) error {
+	_, err := exec.Exec(
+		fmt.Sprintf(`INSERT INTO %s (timepoint, data) VALUES ($1, $2)
+ON CONFLICT (timepoint) DO UPDATE SET data = EXCLUDED.data`, table),
+		int64(timepoint), string(dataJSON),
+	)
+	return err
+}
+
+// latestRaw returns the most recent record of the given table.
+// It returns sql.ErrNoRows if the table is empty.
+func latestRaw(exec pgExecutor, table string) ([]byte, error) {
+	var data []byte
+	err := exec.QueryRow(fmt.Sprintf(`SELECT data FROM %s ORDER BY timepoint DESC LIMIT 1`, table)).Scan(&data)
+	return data, err
+}
+
+// CurrentGoldInfoVersion returns the most recent time point of gold info record.
+// It implements data.GlobalStorage interface.
+func (self *PostgresStorage) CurrentGoldInfoVersion(timepoint uint64) (common.Version, error) {
+	return self.currentVersion(pgGoldTable, timepoint)
+}
+
+// GetGoldInfo returns gold info at given time point. It implements data.GlobalStorage interface.
+func (self *PostgresStorage) GetGoldInfo(version common.Version) (common.GoldData, error) {
+	result := common.GoldData{}
+	err := self.getVersion(pgGoldTable, version, &result)
+	return result, err
+}
+
+// StoreGoldInfo stores the given gold information to database. It implements fetcher.GlobalStorage interface.
+func (self *PostgresStorage) StoreGoldInfo(data common.GoldData) error {
+	return storeVersion(self.db, pgGoldTable, data.Timestamp, data)
+}
+
+// ExportExpiredAuthData writes all expired auth data records to the given
+// file, one JSON record per line.
+func (self *PostgresStorage) ExportExpiredAuthData(currentTime uint64, fileName string) (nRecord uint64, err error) {
+	outFile, err := os.Create(fileName)
+	if err != nil {
+		return 0, err
+	}
+	defer func() {
+		if cErr := outFile.Close(); cErr != nil {
+			log.Printf("Close file error: %s", cErr.Error())
+		}
+	}()
+
+	rows, err := self.db.Query(
+		`SELECT timepoint, data FROM auth_data WHERE timepoint <= $1 ORDER BY timepoint`,
+		int64(currentTime-authDataExpiredDuration),
+	)
+	if err != nil {
+		return 0, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var (
+			timestamp int64
+			data      []byte
+			temp      common.AuthDataSnapshot
+			output    []byte
+		)
+		if err = rows.Scan(&timestamp, &data); err != nil {
+			return nRecord, err
+		}
+		if err = json.Unmarshal(data, &temp); err != nil {
+			return nRecord, err
+		}
+		record := common.NewAuthDataRecord(
+			common.Timestamp(strconv.FormatInt(timestamp, 10)),
+			temp,
+		)
+		if output, err = json.Marshal(record); err != nil {
+			return nRecord, err
+		}
+		if _, err = outFile.WriteString(string(output) + "\n"); err != nil {
+			return nRecord, err
+		}
+		nRecord++
+	}
+	return nRecord, rows.Err()
+}
+
+// PruneExpiredAuthData removes all expired auth data records.
+func (self *PostgresStorage) PruneExpiredAuthData(currentTime uint64) (uint64, error) {
+	res, err := self.db.Exec(
+		`DELETE FROM auth_data WHERE timepoint <= $1`,
+		int64(currentTime-authDataExpiredDuration),
+	)
+	if err != nil {
+		return 0, err
+	}
+	nRecord, err := res.RowsAffected()
+	return uint64(nRecord), err
+}
+
+// CurrentPriceVersion returns the most recent time point of prices.
+func (self *PostgresStorage) CurrentPriceVersion(timepoint uint64) (common.Version, error) {
+	return self.currentVersion(pgPriceTable, timepoint)
+}
+
+// GetAllPrices returns the corresponding AllPriceEntry to a particular Version.
+func (self *PostgresStorage) GetAllPrices(version common.Version) (common.AllPriceEntry, error) {
+	result := common.AllPriceEntry{}
+	err := self.getVersion(pgPriceTable, version, &result)
+	return result, err
+}
+
+// GetOnePrice returns the prices of the given token pair at a particular Version.
+func (self *PostgresStorage) GetOnePrice(pair common.TokenPairID, version common.Version) (common.OnePrice, error) {
+	result, err := self.GetAllPrices(version)
+	if err != nil {
+		return common.OnePrice{}, err
+	}
+	dataPair, exist := result.Data[pair]
+	if exist {
+		return dataPair, nil
+	}
+	return common.OnePrice{}, errors.New("Pair of token is not supported")
+}
+
+// StorePrice stores the prices and keeps only the latest maxNumberVersion versions.
+func (self *PostgresStorage) StorePrice(data common.AllPriceEntry, timepoint uint64) error {
+	return self.inTx(func(tx *sql.Tx) error {
+		if err := storeVersion(tx, pgPriceTable, timepoint, data); err != nil {
+			return err
+		}
+		// remove outdated data from table
+		_, err := tx.Exec(
+			`DELETE FROM prices WHERE timepoint <= (SELECT timepoint FROM prices ORDER BY timepoint DESC OFFSET $1 LIMIT 1)`,
+			maxNumberVersion,
+		)
+		return err
+	})
+}
+
+// CurrentAuthDataVersion returns the most recent time point of auth data.
+func (self *PostgresStorage) CurrentAuthDataVersion(timepoint uint64) (common.Version, error) {
+	return self.currentVersion(pgAuthDataTable, timepoint)
+}
+
+// GetAuthData returns the auth data snapshot at a particular Version.
+func (self *PostgresStorage) GetAuthData(version common.Version) (common.AuthDataSnapshot, error) {
+	result := common.AuthDataSnapshot{}
+	err := self.getVersion(pgAuthDataTable, version, &result)
+	return result, err
+}
+
+// StoreAuthSnapshot stores the auth data snapshot at the given time point.
+func (self *PostgresStorage) StoreAuthSnapshot(data *common.AuthDataSnapshot, timepoint uint64) error {
+	return storeVersion(self.db, pgAuthDataTable, timepoint, data)
+}
+
+// CurrentRateVersion returns the most recent time point of rates.
+func (self *PostgresStorage) CurrentRateVersion(timepoint uint64) (common.Version, error) {
+	return self.currentVersion(pgRateTable, timepoint)
+}
+
+// GetRates returns rates history in the given time range, newest first.
+func (self *PostgresStorage) GetRates(fromTime, toTime uint64) ([]common.AllRateEntry, error) {
+	result := []common.AllRateEntry{}
+	if toTime-fromTime > maxGetRatesPeriod {
+		return result, fmt.Errorf("Time range is too broad, it must be smaller or equal to %d miliseconds", maxGetRatesPeriod)
+	}
+	rows, err := self.db.Query(
+		`SELECT data FROM rates WHERE timepoint >= $1 AND timepoint <= $2 ORDER BY timepoint DESC`,
+		int64(fromTime), int64(toTime),
+	)
+	if err != nil {
+		return result, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var (
+			data  []byte
+			entry common.AllRateEntry
+		)
+		if err = rows.Scan(&data); err != nil {
+			return result, err
+		}
+		if err = json.Unmarshal(data, &entry); err != nil {
+			return result, err
+		}
+		result = append(result, entry)
+	}
+	return result, rows.Err()
+}
+
+// GetRate returns the rates at a particular Version.
+func (self *PostgresStorage) GetRate(version common.Version) (common.AllRateEntry, error) {
+	result := common.AllRateEntry{}
+	err := self.getVersion(pgRateTable, version, &result)
+	return result, err
+}
+
+// StoreRate stores the rates, it rejects rates of a smaller block number
+// than the last stored one.
+func (self *PostgresStorage) StoreRate(data common.AllRateEntry, timepoint uint64) error {
+	log.Printf("Storing rate data to postgres: data(%v), timespoint(%v)", data, timepoint)
+	return self.inTx(func(tx *sql.Tx) error {
+		var lastBlock int64
+		// serialize writers so the block number check is consistent
+		if _, err := tx.Exec(`LOCK TABLE rates IN SHARE ROW EXCLUSIVE MODE`); err != nil {
+			return err
+		}
+		err := tx.QueryRow(`SELECT block_number FROM rates ORDER BY timepoint DESC LIMIT 1`).Scan(&lastBlock)
+		switch {
+		case err == sql.ErrNoRows:
+			log.Printf("Table %s is empty", pgRateTable)
+		case err != nil:
+			return err
+		}
+		// we still update when blocknumber is not changed because we want
+		// to update the version and timestamp so api users will get
+		// the newest data even it is identical to the old one.
+		if uint64(lastBlock) > data.BlockNumber {
+			return fmt.Errorf("rejected storing rates with smaller block number: %d, stored: %d",
+				data.BlockNumber,
+				lastBlock)
+		}
+		dataJSON, err := json.Marshal(data)
+		if err != nil {
+			return err
+		}
+		_, err = tx.Exec(
+			`INSERT INTO rates (timepoint, block_number, data) VALUES ($1, $2, $3)
+ON CONFLICT (timepoint) DO UPDATE SET block_number = EXCLUDED.block_number, data = EXCLUDED.data`,
+			int64(timepoint), int64(data.BlockNumber), string(dataJSON),
+		)
+		return err
+	})
+}
+
+// Record saves the activity, it is also marked as pending if its status is pending.
+func (self *PostgresStorage) Record(
+	action string,
+	id common.ActivityID,
+	destination string,
+	params map[string]interface{}, result map[string]interface{},
+	estatus string,
+	mstatus string,
+	timepoint uint64) error {
+	record := common.NewActivityRecord(
+		action,
+		id,
+		destination,
+		params,
+		result,
+		estatus,
+		mstatus,
+		common.Timestamp(strconv.FormatUint(timepoint, 10)),
+	)
+	return storeActivity(self.db, record, record.IsPending())
+}
+
+// storeActivity upserts the given activity record. An activity is pending
+// if it was pending already or pending is true.
+func storeActivity(exec pgExecutor, record common.ActivityRecord, pending bool) error {
+	dataJSON, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	_, err = exec.Exec(
+		`INSERT INTO activities (timepoint, eid, action, destination, is_pending, data) VALUES ($1, $2, $3, $4, $5, $6)
+ON CONFLICT (timepoint, eid) DO UPDATE SET
+	action = EXCLUDED.action,
+	destination = EXCLUDED.destination,
+	is_pending = activities.is_pending OR EXCLUDED.is_pending,
+	data = EXCLUDED.data`,
+		int64(record.ID.Timepoint), record.ID.EID, record.Action, record.Destination, pending, string(dataJSON),
+	)
+	return err
+}
+
+// GetActivity returns the activity of given id.
+func (self *PostgresStorage) GetActivity(id common.ActivityID) (common.ActivityRecord, error) {
+	result := common.ActivityRecord{}
+	var data []byte
+	err := self.db.QueryRow(
+		`SELECT data FROM activities WHERE timepoint = $1 AND eid = $2`,
+		int64(id.Timepoint), id.EID,
+	).Scan(&data)
+	if err == sql.ErrNoRows {
+		return result, errors.New("Cannot find that activity")
+	}
+	if err != nil {
+		return result, err
+	}
+	err = json.Unmarshal(data, &result)
+	return result, err
+}
+
+// queryActivities returns all activities returned by the given query, the
+// query must select the data column only.
+func (self *PostgresStorage) queryActivities(query string, args ...interface{}) ([]common.ActivityRecord, error) {
+	result := []common.ActivityRecord{}
+	rows, err := self.db.Query(query, args...)
+	if err != nil {
+		return result, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var (
+			data   []byte
+			record common.ActivityRecord
+		)
+		if err = rows.Scan(&data); err != nil {
+			return result, err
+		}
+		if err = json.Unmarshal(data, &record); err != nil {
+			return result, err
+		}
+		result = append(result, record)
+	}
+	return result, rows.Err()
+}
+
+// GetAllRecords returns all activities in the given time range, newest first.
+// A zero fromTime or toTime means no lower or upper bound.
+func (self *PostgresStorage) GetAllRecords(fromTime, toTime uint64) ([]common.ActivityRecord, error) {
+	if (toTime-fromTime)/1000000 > maxGetRatesPeriod {
+		return []common.ActivityRecord{}, fmt.Errorf("Time range is too broad, it must be smaller or equal to %d miliseconds", maxGetRatesPeriod)
+	}
+	return self.queryActivities(
+		`SELECT data FROM activities
+WHERE ($1::BIGINT = 0 OR timepoint >= $1) AND ($2::BIGINT = 0 OR timepoint <= $2)
+ORDER BY timepoint DESC, eid DESC`,
+		int64(fromTime), int64(toTime),
+	)
+}
+
+// PendingSetrate returns the pending set rate activity with the lowest nonce
+// and number of pending set rate activities with that nonce.
+func (self *PostgresStorage) PendingSetrate(minedNonce uint64) (*common.ActivityRecord, uint64, error) {
+	pendings, err := self.GetPendingActivities()
+	if err != nil {
+		return nil, 0, err
+	}
+	return getFirstAndCountPendingSetrate(pendings, minedNonce)
+}
+
+// GetPendingActivities returns pending activities, newest first.
+func (self *PostgresStorage) GetPendingActivities() ([]common.ActivityRecord, error) {
+	return self.queryActivities(`SELECT data FROM activities WHERE is_pending ORDER BY timepoint DESC, eid DESC`)
+}
+
+// UpdateActivity updates the activity info, it is no longer pending if its
+// status is not pending anymore.
+func (self *PostgresStorage) UpdateActivity(id common.ActivityID, activity common.ActivityRecord) error {
+	dataJSON, err := json.Marshal(activity)
+	if err != nil {
+		return err
+	}
+	// only keep pending flag when it is pending already because
+	// it might be removed if it is replaced by another activity
+	_, err = self.db.Exec(
+		`INSERT INTO activities (timepoint, eid, action, destination, is_pending, data) VALUES ($1, $2, $3, $4, FALSE, $5)
+ON CONFLICT (timepoint, eid) DO UPDATE SET
+	action = EXCLUDED.action,
+	destination = EXCLUDED.destination,
+	is_pending = activities.is_pending AND $6,
+	data = EXCLUDED.data`,
+		int64(id.Timepoint), id.EID, activity.Action, activity.Destination, string(dataJSON), activity.IsPending(),
+	)
+	return err
+}
+
+// HasPendingDeposit checks if a deposit of given token to given exchange is pending.
+func (self *PostgresStorage) HasPendingDeposit(token common.Token, exchange common.Exchange) (bool, error) {
+	pendings, err := self.queryActivities(
+		`SELECT data FROM activities WHERE is_pending AND action = $1 AND destination = $2`,
+		common.ActionDeposit, string(exchange.ID()),
+	)
+	if err != nil {
+		return false, err
+	}
+	for _, record := range pendings {
+		tokenID, ok := record.Params["token"].(string)
+		if !ok {
+			log.Printf("ERROR: record Params token (%v) can not be converted to string", record.Params["token"])
+			continue
+		}
+		if tokenID == token.ID {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// StoreMetric stores metric info.
+func (self *PostgresStorage) StoreMetric(data *common.MetricEntry, timepoint uint64) error {
+	return storeVersion(self.db, pgMetricTable, data.Timestamp, data)
+}
+
+// GetMetric returns metric data of given tokens in the given time range.
+func (self *PostgresStorage) GetMetric(tokens []common.Token, fromTime, toTime uint64) (map[string]common.MetricList, error) {
+	result := map[string]common.MetricList{}
+	for _, tok := range tokens {
+		result[tok.ID] = common.MetricList{}
+	}
+
+	rows, err := self.db.Query(
+		`SELECT data FROM metrics WHERE timepoint >= $1 AND timepoint <= $2 ORDER BY timepoint`,
+		int64(fromTime), int64(toTime),
+	)
+	if err != nil {
+		return result, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var (
+			raw  []byte
+			data common.MetricEntry
+		)
+		if err = rows.Scan(&raw); err != nil {
+			return result, err
+		}
+		if err = json.Unmarshal(raw, &data); err != nil {
+			return result, err
+		}
+		for tok, m := range data.Data {
+			metricList, found := result[tok]
+			if found {
+				result[tok] = append(metricList, common.TokenMetricResponse{
+					Timestamp: data.Timestamp,
+					AfpMid:    m.AfpMid,
+					Spread:    m.Spread,
+				})
+			}
+		}
+	}
+	return result, rows.Err()
+}
+
+// GetTokenTargetQty returns the current target quantity.
+func (self *PostgresStorage) GetTokenTargetQty() (common.TokenTargetQty, error) {
+	tokenTargetQty := common.TokenTargetQty{}
+	version, err := self.currentVersion(pgTargetQuantityTable, common.GetTimepoint())
+	if err != nil {
+		return tokenTargetQty, err
+	}
+	err = self.getVersion(pgTargetQuantityTable, version, &tokenTargetQty)
+	return tokenTargetQty, err
+}
+
+// getControl returns the stored control status of given name. If there is
+// no stored status, it is initialized as disabled.
+func (self *PostgresStorage) getControl(name string, result interface{}) (bool, error) {
+	var data []byte
+	err := self.db.QueryRow(`SELECT data FROM controls WHERE name = $1`, name).Scan(&data)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, json.Unmarshal(data, result)
+}
+
+func (self *PostgresStorage) storeControl(name string, data interface{}) error {
+	dataJSON, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	_, err = self.db.Exec(
+		`INSERT INTO controls (name, timepoint, data) VALUES ($1, $2, $3)
+ON CONFLICT (name) DO UPDATE SET timepoint = EXCLUDED.timepoint, data = EXCLUDED.data`,
+		name, int64(common.GetTimepoint()), string(dataJSON),
+	)
+	return err
+}
+
+// GetRebalanceControl returns the rebalance status, it is disabled by default.
+func (self *PostgresStorage) GetRebalanceControl() (common.RebalanceControl, error) {
+	var result common.RebalanceControl
+	found, err := self.getControl(enableRebalance, &result)
+	if err != nil || found {
+		return result, err
+	}
+	return common.RebalanceControl{Status: false}, self.StoreRebalanceControl(false)
+}
+
+// StoreRebalanceControl stores the rebalance status.
+func (self *PostgresStorage) StoreRebalanceControl(status bool) error {
+	return self.storeControl(enableRebalance, common.RebalanceControl{Status: status})
+}
+
+// GetSetrateControl returns the set rate status, it is disabled by default.
+func (self *PostgresStorage) GetSetrateControl() (common.SetrateControl, error) {
+	var result common.SetrateControl
+	found, err := self.getControl(setrateControl, &result)
+	if err != nil || found {
+		return result, err
+	}
+	return common.SetrateControl{Status: false}, self.StoreSetrateControl(false)
+}
+
+// StoreSetrateControl stores the set rate status.
+func (self *PostgresStorage) StoreSetrateControl(status bool) error {
+	return self.storeControl(setrateControl, common.SetrateControl{Status: status})
+}
+
+// GetPWIEquation returns the latest PWI equation v1.
+func (self *PostgresStorage) GetPWIEquation() (common.PWIEquation, error) {
+	var result common.PWIEquation
+	data, err := latestRaw(self.db, pgPWIEquationTable)
+	if err == sql.ErrNoRows {
+		return result, errors.New("There is no equation")
+	}
+	if err != nil {
+		return result, err
+	}
+	err = json.Unmarshal(data, &result)
+	return result, err
+}
+
+// getPending returns the pending setting of given name.
+// It returns sql.ErrNoRows if there is no pending setting.
+func getPending(exec pgExecutor, name string) ([]byte, error) {
+	var data []byte
+	err := exec.QueryRow(`SELECT data FROM pending_settings WHERE name = $1`, name).Scan(&data)
+	return data, err
+}
+
+// storePending stores the pending setting of given name, it returns false
+// if there is a pending setting already.
+func storePending(exec pgExecutor, name string, data []byte) (bool, error) {
+	res, err := exec.Exec(
+		`INSERT INTO pending_settings (name, timepoint, data) VALUES ($1, $2, $3) ON CONFLICT (name) DO NOTHING`,
+		name, int64(common.GetTimepoint()), string(data),
+	)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
+// removePending removes the pending setting of given name, it returns false
+// if there is no pending setting.
+func removePending(exec pgExecutor, name string) (bool, error) {
+	res, err := exec.Exec(`DELETE FROM pending_settings WHERE name = $1`, name)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
+// storeSetting adds the given confirmed setting to its history.
+func storeSetting(exec pgExecutor, name string, data []byte) error {
+	_, err := exec.Exec(
+		`INSERT INTO settings_history (name, timepoint, data) VALUES ($1, $2, $3)
+ON CONFLICT (name, timepoint) DO UPDATE SET data = EXCLUDED.data`,
+		name, int64(common.GetTimepoint()), string(data),
+	)
+	return err
+}
+
+// latestSetting returns the latest confirmed setting of given name.
+// It returns sql.ErrNoRows if the setting is never confirmed.
+func latestSetting(exec pgExecutor, name string) ([]byte, error) {
+	var data []byte
+	err := exec.QueryRow(
+		`SELECT data FROM settings_history WHERE name = $1 ORDER BY timepoint DESC LIMIT 1`,
+		name,
+	).Scan(&data)
+	return data, err
+}
+
+// SetStableTokenParams stores the pending stable token params.
+func (self *PostgresStorage) SetStableTokenParams(value []byte) error {
+	temp := make(map[string]interface{})
+	if err := json.Unmarshal(value, &temp); err != nil {
+		return fmt.Errorf("Rejected: Data could not be unmarshalled to defined format: %s", err)
+	}
+	stored, err := storePending(self.db, pendingStatbleTokenParamsBucket, value)
+	if err != nil {
+		return err
+	}
+	if !stored {
+		return errors.New("Currently there is a pending record")
+	}
+	return nil
+}
+
+// ConfirmStableTokenParams confirms the pending stable token params if the
+// given value matches it.
+func (self *PostgresStorage) ConfirmStableTokenParams(value []byte) error {
+	temp := make(map[string]interface{})
+	if err := json.Unmarshal(value, &temp); err != nil {
+		return fmt.Errorf("Rejected: Data could not be unmarshalled to defined format: %s", err)
+	}
+	pending, err := self.GetPendingStableTokenParams()
+	if err != nil {
+		return err
+	}
+	if eq := reflect.DeepEqual(pending, temp); !eq {
+		return errors.New("Rejected: confiming data isn't consistent")
+	}
+	return self.inTx(func(tx *sql.Tx) error {
+		if err := storeSetting(tx, stableTokenParamsBucket, value); err != nil {
+			return err
+		}
+		_, err := removePending(tx, pendingStatbleTokenParamsBucket)
+		return err
+	})
+}
+
+// getSettingMap returns the setting decoded as map, result is empty if there
+// is no such setting.
+func getSettingMap(data []byte, err error) (map[string]interface{}, error) {
+	result := make(map[string]interface{})
+	if err == sql.ErrNoRows {
+		return result, nil
+	}
+	if err != nil {
+		return result, err
+	}
+	return result, json.Unmarshal(data, &result)
+}
+
+// GetStableTokenParams returns the confirmed stable token params.
+func (self *PostgresStorage) GetStableTokenParams() (map[string]interface{}, error) {
+	return getSettingMap(latestSetting(self.db, stableTokenParamsBucket))
+}
+
+// GetPendingStableTokenParams returns the pending stable token params.
+func (self *PostgresStorage) GetPendingStableTokenParams() (map[string]interface{}, error) {
+	return getSettingMap(getPending(self.db, pendingStatbleTokenParamsBucket))
+}
+
+// RemovePendingStableTokenParams removes the pending stable token params.
+func (self *PostgresStorage) RemovePendingStableTokenParams() error {
+	removed, err := removePending(self.db, pendingStatbleTokenParamsBucket)
+	if err != nil {
+		return err
+	}
+	if !removed {
+		return errors.New("Bucket is empty")
+	}
+	return nil
+}
+
+// StorePendingTargetQtyV2 stores the pending target quantity v2.
+func (self *PostgresStorage) StorePendingTargetQtyV2(value []byte) error {
+	var pendingData common.TokenTargetQtyV2
+	if err := json.Unmarshal(value, &pendingData); err != nil {
+		return fmt.Errorf("Rejected: Data could not be unmarshalled to defined format: %s", err.Error())
+	}
+	stored, err := storePending(self.db, pendingTargetQuantityV2, value)
+	if err != nil {
+		return err
+	}
+	if !stored {
+		return errors.New("Currently there is a pending record")
+	}
+	return nil
+}
+
+// GetPendingTargetQtyV2 returns the current pending target quantity.
+func (self *PostgresStorage) GetPendingTargetQtyV2() (common.TokenTargetQtyV2, error) {
+	result := common.TokenTargetQtyV2{}
+	data, err := getPending(self.db, pendingTargetQuantityV2)
+	if err == sql.ErrNoRows {
+		return result, errors.New("There is no pending target qty")
+	}
+	if err != nil {
+		return result, err
+	}
+	err = json.Unmarshal(data, &result)
+	return result, err
+}
+
+// ConfirmTargetQtyV2 checks if confirm data matches pending data, saves it as
+// the current target quantity and removes the pending data.
+func (self *PostgresStorage) ConfirmTargetQtyV2(value []byte) error {
+	confirmTargetQty := common.TokenTargetQtyV2{}
+	if err := json.Unmarshal(value, &confirmTargetQty); err != nil {
+		return fmt.Errorf("Rejected: Data could not be unmarshalled to defined format: %s", err)
+	}
+	pending, err := self.GetPendingTargetQtyV2()
+	if err != nil {
+		return err
+	}
+	if eq := reflect.DeepEqual(pending, confirmTargetQty); !eq {
+		return errors.New("Rejected: confiming data isn't consistent")
+	}
+	return self.inTx(func(tx *sql.Tx) error {
+		if err := storeSetting(tx, targetQuantityV2, value); err != nil {
+			return err
+		}
+		_, err := removePending(tx, pendingTargetQuantityV2)
+		return err
+	})
+}
+
+// RemovePendingTargetQtyV2 removes the pending target quantity.
+func (self *PostgresStorage) RemovePendingTargetQtyV2() error {
+	_, err := removePending(self.db, pendingTargetQuantityV2)
+	return err
+}
+
+// GetTargetQtyV2 returns the current target quantity, it falls back to
+// target quantity v1 if v2 is never set.
+func (self *PostgresStorage) GetTargetQtyV2() (common.TokenTargetQtyV2, error) {
+	result := common.TokenTargetQtyV2{}
+	data, err := latestSetting(self.db, targetQuantityV2)
+	switch {
+	case err == sql.ErrNoRows:
+	case err != nil:
+		return result, err
+	default:
+		if err = json.Unmarshal(data, &result); err != nil {
+			return result, err
+		}
+	}
+	if len(result) == 0 {
+		targetQty, err := self.GetTokenTargetQty()
+		if err != nil {
+			return result, err
+		}
+		result = convertTargetQtyV1toV2(targetQty)
+	}
+	return result, nil
+}
+
+// StorePendingPWIEquationV2 stores the given PWIs equation data for later approval.
+func (self *PostgresStorage) StorePendingPWIEquationV2(data []byte) error {
+	stored, err := storePending(self.db, pendingPWIEquationV2, data)
+	if err != nil {
+		return err
+	}
+	if !stored {
+		return errors.New("pending PWI equation exists")
+	}
+	return nil
+}
+
+// GetPendingPWIEquationV2 returns the pending PWIEquationRequestV2.
+func (self *PostgresStorage) GetPendingPWIEquationV2() (common.PWIEquationRequestV2, error) {
+	var result common.PWIEquationRequestV2
+	data, err := getPending(self.db, pendingPWIEquationV2)
+	if err == sql.ErrNoRows {
+		return result, errors.New("There is no pending equation")
+	}
+	if err != nil {
+		return result, err
+	}
+	err = json.Unmarshal(data, &result)
+	return result, err
+}
+
+// RemovePendingPWIEquationV2 deletes the pending equation request.
+func (self *PostgresStorage) RemovePendingPWIEquationV2() error {
+	removed, err := removePending(self.db, pendingPWIEquationV2)
+	if err != nil {
+		return err
+	}
+	if !removed {
+		return errors.New("There is no pending data")
+	}
+	return nil
+}
+
+// confirmPending moves the pending setting of pendingName to history of
+// name if confirmData matches it, both are decoded to values created by
+// newValue before comparing.
+func (self *PostgresStorage) confirmPending(pendingName, name string, confirmData []byte, newValue func() interface{}, noPendingErr, mismatchErr error) error {
+	return self.inTx(func(tx *sql.Tx) error {
+		pending, err := getPending(tx, pendingName)
+		if err == sql.ErrNoRows {
+			return noPendingErr
+		}
+		if err != nil {
+			return err
+		}
+		confirmValue, currentValue := newValue(), newValue()
+		if err = json.Unmarshal(confirmData, confirmValue); err != nil {
+			return err
+		}
+		if err = json.Unmarshal(pending, currentValue); err != nil {
+			return err
+		}
+		if eq := reflect.DeepEqual(currentValue, confirmValue); !eq {
+			return mismatchErr
+		}
+		if err = storeSetting(tx, name, pending); err != nil {
+			return err
+		}
+		_, err = removePending(tx, pendingName)
+		return err
+	})
+}
+
+// StorePWIEquationV2 moves the pending equation request to confirmed
+// equations if the given data matches what stored.
+func (self *PostgresStorage) StorePWIEquationV2(data string) error {
+	return self.confirmPending(
+		pendingPWIEquationV2, pwiEquationV2, []byte(data),
+		func() interface{} { return &common.PWIEquationRequestV2{} },
+		errors.New("There is no pending equation"),
+		errors.New("Confirm data does not match pending data"),
+	)
+}
+
+// GetPWIEquationV2 returns the current PWI equations, it falls back to PWI
+// equation v1 if v2 is never set.
+func (self *PostgresStorage) GetPWIEquationV2() (common.PWIEquationRequestV2, error) {
+	var result common.PWIEquationRequestV2
+	data, err := latestSetting(self.db, pwiEquationV2)
+	if err == sql.ErrNoRows {
+		log.Println("there no equation in pwiEquationV2, getting from pwiEquation")
+		eqv1, vErr := self.GetPWIEquation()
+		if vErr != nil {
+			return nil, vErr
+		}
+		return convertPWIEquationV1toV2(eqv1.Data)
+	}
+	if err != nil {
+		return result, err
+	}
+	err = json.Unmarshal(data, &result)
+	return result, err
+}
+
+// StorePendingRebalanceQuadratic stores pending rebalance quadratic equation.
+func (self *PostgresStorage) StorePendingRebalanceQuadratic(data []byte) error {
+	stored, err := storePending(self.db, pendingRebalanceQuadratic, data)
+	if err != nil {
+		return err
+	}
+	if !stored {
+		return errors.New("pending rebalance quadratic equation exists")
+	}
+	return nil
+}
+
+// GetPendingRebalanceQuadratic returns pending rebalance quadratic equation.
+func (self *PostgresStorage) GetPendingRebalanceQuadratic() (common.RebalanceQuadraticRequest, error) {
+	var result common.RebalanceQuadraticRequest
+	data, err := getPending(self.db, pendingRebalanceQuadratic)
+	if err == sql.ErrNoRows {
+		return result, errors.New("there is no pending rebalance quadratic equation")
+	}
+	if err != nil {
+		return result, err
+	}
+	err = json.Unmarshal(data, &result)
+	return result, err
+}
+
+// ConfirmRebalanceQuadratic confirms the pending equation and removes it.
+func (self *PostgresStorage) ConfirmRebalanceQuadratic(data []byte) error {
+	return self.confirmPending(
+		pendingRebalanceQuadratic, rebalanceQuadratic, data,
+		func() interface{} { return &common.RebalanceQuadraticRequest{} },
+		errors.New("there is no pending rebalance quadratic equation"),
+		errors.New("confirm data does not match rebalance quadratic pending data"),
+	)
+}
+
+// RemovePendingRebalanceQuadratic removes pending rebalance quadratic equation.
+func (self *PostgresStorage) RemovePendingRebalanceQuadratic() error {
+	removed, err := removePending(self.db, pendingRebalanceQuadratic)
+	if err != nil {
+		return err
+	}
+	if !removed {
+		return errors.New("there no pending rebalance quadratic equation to delete")
+	}
+	return nil
+}
+
+// GetRebalanceQuadratic returns current confirmed rebalance quadratic equation.
+func (self *PostgresStorage) GetRebalanceQuadratic() (common.RebalanceQuadraticRequest, error) {
+	var result common.RebalanceQuadraticRequest
+	data, err := latestSetting(self.db, rebalanceQuadratic)
+	if err == sql.ErrNoRows {
+		return result, errors.New("there is no rebalance quadratic equation")
+	}
+	if err != nil {
+		return result, err
+	}
+	err = json.Unmarshal(data, &result)
+	return result, err
+}
+
+// StorePendingTokenUpdateInfo stores pending target quantity v2, PWI equation
+// v2 and rebalance quadratic equation in one transaction.
+func (self *PostgresStorage) StorePendingTokenUpdateInfo(tarQty common.TokenTargetQtyV2, pwi common.PWIEquationRequestV2, quadEq common.RebalanceQuadraticRequest) error {
+	pendings := []struct {
+		name  string
+		value interface{}
+	}{
+		{pendingTargetQuantityV2, tarQty},
+		{pendingPWIEquationV2, pwi},
+		{pendingRebalanceQuadratic, quadEq},
+	}
+	return self.inTx(func(tx *sql.Tx) error {
+		for _, pending := range pendings {
+			dataJSON, err := json.Marshal(pending.value)
+			if err != nil {
+				return err
+			}
+			stored, err := storePending(tx, pending.name, dataJSON)
+			if err != nil {
+				return err
+			}
+			if !stored {
+				return fmt.Errorf("Bucket %s has a pending record", pending.name)
+			}
+		}
+		return nil
+	})
+}
+
+// RemovePendingTokenUpdateInfo removes all pending token update info.
+func (self *PostgresStorage) RemovePendingTokenUpdateInfo() error {
+	return self.inTx(func(tx *sql.Tx) error {
+		for _, name := range []string{pendingTargetQuantityV2, pendingPWIEquationV2, pendingRebalanceQuadratic} {
+			removed, err := removePending(tx, name)
+			if err != nil {
+				return err
+			}
+			if !removed {
+				return fmt.Errorf("Bucket %s is empty", name)
+			}
+		}
+		return nil
+	})
+}
+
+// ConfirmTokenUpdateInfo stores target quantity v2, PWI equation v2 and
+// rebalance quadratic equation as confirmed in one transaction.
+func (self *PostgresStorage) ConfirmTokenUpdateInfo(tarQty common.TokenTargetQtyV2, pwi common.PWIEquationRequestV2, quadEq common.RebalanceQuadraticRequest) error {
+	settings := []struct {
+		name  string
+		value interface{}
+	}{
+		{targetQuantityV2, tarQty},
+		{pwiEquationV2, pwi},
+		{rebalanceQuadratic, quadEq},
+	}
+	return self.inTx(func(tx *sql.Tx) error {
+		for _, setting := range settings {
+			dataJSON, err := json.Marshal(setting.value)
+			if err != nil {
+				return err
+			}
+			if err = storeSetting(tx, setting.name, dataJSON); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// StoreStepFunctionData stores step function data got from blockchain.
+func (self *PostgresStorage) StoreStepFunctionData(data common.StepFunctionData) error {
+	dataJSON, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	return storeStepFunction(self.db, dataJSON)
+}
+
+func storeStepFunction(exec pgExecutor, dataJSON []byte) error {
+	_, err := exec.Exec(
+		`INSERT INTO step_function (name, data) VALUES ($1, $2) ON CONFLICT (name) DO UPDATE SET data = EXCLUDED.data`,
+		stepFunctionLatestDataKey, string(dataJSON),
+	)
+	return err
+}
+
+// GetStepFunctionData returns current step function data.
+func (self *PostgresStorage) GetStepFunctionData() (common.StepFunctionData, error) {
+	var (
+		result common.StepFunctionData
+		data   []byte
+	)
+	err := self.db.QueryRow(`SELECT data FROM step_function WHERE name = $1`, stepFunctionLatestDataKey).Scan(&data)
+	if err == sql.ErrNoRows {
+		return result, nil
+	}
+	if err != nil {
+		return result, err
+	}
+	err = json.Unmarshal(data, &result)
+	return result, err
+}
+
+// GetWithdrawThresholds returns the withdraw thresholds of tokens, it is
+// empty if never stored.
+func (self *PostgresStorage) GetWithdrawThresholds() (common.WithdrawThresholds, error) {
+	result := common.WithdrawThresholds{}
+	_, err := self.getControl(withdrawThresholds, &result)
+	return result, err
+}
+
+// StoreWithdrawThresholds replaces the withdraw thresholds of tokens.
+func (self *PostgresStorage) StoreWithdrawThresholds(thresholds common.WithdrawThresholds) error {
+	return self.storeControl(withdrawThresholds, thresholds)
+}
+
+func storeWithdrawalApproval(exec pgExecutor, approval common.WithdrawalApproval, dataJSON []byte) error {
+	_, err := exec.Exec(
+		`INSERT INTO withdrawal_approvals (id, proposed_at, status, data) VALUES ($1, $2, $3, $4)
+ON CONFLICT (id) DO UPDATE SET proposed_at = EXCLUDED.proposed_at, status = EXCLUDED.status, data = EXCLUDED.data`,
+		approval.ID, int64(approval.ProposedAt), approval.Status, string(dataJSON),
+	)
+	return err
+}
+
+// StoreWithdrawalApproval stores a new withdrawal approval.
+func (self *PostgresStorage) StoreWithdrawalApproval(approval common.WithdrawalApproval) error {
+	dataJSON, err := json.Marshal(approval)
+	if err != nil {
+		return err
+	}
+	res, err := self.db.Exec(
+		`INSERT INTO withdrawal_approvals (id, proposed_at, status, data) VALUES ($1, $2, $3, $4) ON CONFLICT (id) DO NOTHING`,
+		approval.ID, int64(approval.ProposedAt), approval.Status, string(dataJSON),
+	)
+	if err != nil {
+		return err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return fmt.Errorf("withdrawal %s exists already", approval.ID)
+	}
+	return nil
+}
+
+// UpdateWithdrawalApproval replaces the stored withdrawal approval if its
+// status is the given one.
+func (self *PostgresStorage) UpdateWithdrawalApproval(approval common.WithdrawalApproval, status string) error {
+	dataJSON, err := json.Marshal(approval)
+	if err != nil {
+		return err
+	}
+	res, err := self.db.Exec(
+		`UPDATE withdrawal_approvals SET status = $1, data = $2 WHERE id = $3 AND status = $4`,
+		approval.Status, string(dataJSON), approval.ID, status,
+	)
+	if err != nil {
+		return err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n > 0 {
+		return nil
+	}
+	current, err := self.GetWithdrawalApproval(approval.ID)
+	if err != nil {
+		return err
+	}
+	return fmt.Errorf("withdrawal %s is %s already", approval.ID, current.Status)
+}
+
+// GetWithdrawalApproval returns the withdrawal approval of given id.
+func (self *PostgresStorage) GetWithdrawalApproval(id string) (common.WithdrawalApproval, error) {
+	var (
+		result common.WithdrawalApproval
+		data   []byte
+	)
+	err := self.db.QueryRow(`SELECT data FROM withdrawal_approvals WHERE id = $1`, id).Scan(&data)
+	if err == sql.ErrNoRows {
+		return result, fmt.Errorf("withdrawal %s doesn't exist", id)
+	}
+	if err != nil {
+		return result, err
+	}
+	err = json.Unmarshal(data, &result)
+	return result, err
+}
+
+func (self *PostgresStorage) queryWithdrawalApprovals(query string, args ...interface{}) ([]common.WithdrawalApproval, error) {
+	rows, err := self.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	result := []common.WithdrawalApproval{}
+	for rows.Next() {
+		var (
+			approval common.WithdrawalApproval
+			data     []byte
+		)
+		if err = rows.Scan(&data); err != nil {
+			return nil, err
+		}
+		if err = json.Unmarshal(data, &approval); err != nil {
+			return nil, err
+		}
+		result = append(result, approval)
+	}
+	return result, rows.Err()
+}
+
+// GetWithdrawalApprovals returns withdrawal approvals proposed in [fromTime, toTime].
+func (self *PostgresStorage) GetWithdrawalApprovals(fromTime, toTime uint64) ([]common.WithdrawalApproval, error) {
+	return self.queryWithdrawalApprovals(
+		`SELECT data FROM withdrawal_approvals WHERE proposed_at >= $1 AND proposed_at <= $2 ORDER BY proposed_at`,
+		int64(fromTime), int64(toTime),
+	)
+}
+
+// GetPendingWithdrawalApprovals returns withdrawal approvals of pending status.
+func (self *PostgresStorage) GetPendingWithdrawalApprovals() ([]common.WithdrawalApproval, error) {
+	return self.queryWithdrawalApprovals(
+		`SELECT data FROM withdrawal_approvals WHERE status = $1 ORDER BY proposed_at`,
+		common.WithdrawalApprovalPending,
+	)
+}
+
+func storeExecution(exec pgExecutor, execution common.Execution, dataJSON []byte) error {
+	_, err := exec.Exec(
+		`INSERT INTO executions (id, created_at, status, data) VALUES ($1, $2, $3, $4)
+ON CONFLICT (id) DO UPDATE SET created_at = EXCLUDED.created_at, status = EXCLUDED.status, data = EXCLUDED.data`,
+		execution.ID.String(), int64(execution.CreatedAt), execution.Status, string(dataJSON),
+	)
+	return err
+}
+
+// StoreExecution stores the execution, replacing the stored one of the same id.
+func (self *PostgresStorage) StoreExecution(execution common.Execution) error {
+	dataJSON, err := json.Marshal(execution)
+	if err != nil {
+		return err
+	}
+	return storeExecution(self.db, execution, dataJSON)
+}
+
+// GetExecution returns the execution of given id.
+func (self *PostgresStorage) GetExecution(id common.ActivityID) (common.Execution, error) {
+	var (
+		result common.Execution
+		data   []byte
+	)
+	err := self.db.QueryRow(`SELECT data FROM executions WHERE id = $1`, id.String()).Scan(&data)
+	if err == sql.ErrNoRows {
+		return result, fmt.Errorf("execution %s doesn't exist", id)
+	}
+	if err != nil {
+		return result, err
+	}
+	err = json.Unmarshal(data, &result)
+	return result, err
+}
+
+// GetRunningExecutions returns executions of running status ordered by creation time.
+func (self *PostgresStorage) GetRunningExecutions() ([]common.Execution, error) {
+	rows, err := self.db.Query(
+		`SELECT data FROM executions WHERE status = $1 ORDER BY created_at, id`,
+		common.ExecutionRunning,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	result := []common.Execution{}
+	for rows.Next() {
+		var (
+			execution common.Execution
+			data      []byte
+		)
+		if err = rows.Scan(&data); err != nil {
+			return nil, err
+		}
+		if err = json.Unmarshal(data, &execution); err != nil {
+			return nil, err
+		}
+		result = append(result, execution)
+	}
+	return result, rows.Err()
+}
+
+// MarkReplay marks the storage used by replays. It returns error if the
+// storage holds activities and is not marked already, as they are live.
+func (self *PostgresStorage) MarkReplay() error {
+	var timepoint uint64
+	found, err := self.getControl(replayBucket, &timepoint)
+	if err != nil || found {
+		return err
+	}
+	var hasActivities bool
+	if err = self.db.QueryRow(`SELECT EXISTS (SELECT 1 FROM activities)`).Scan(&hasActivities); err != nil {
+		return err
+	}
+	if hasActivities {
+		return errors.New("storage holds live activities, replays require a separate storage")
+	}
+	return self.storeControl(replayBucket, common.GetTimepoint())
+}
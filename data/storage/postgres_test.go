@@ -0,0 +1,132 @@
+package storage
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/KyberNetwork/reserve-data/common"
+	"github.com/KyberNetwork/reserve-data/data/testutil"
+)
+
+// postgresTestDSNEnv is the environment variable of the data source name of
+// the database used to test PostgresStorage, all its tables used by
+// PostgresStorage are truncated. Tests are skipped if it is not set, CI sets
+// it to a postgres service (see .travis.yml).
+const postgresTestDSNEnv = "KYBER_TEST_POSTGRES_DSN"
+
+func newTestPostgresStorage(t *testing.T) *PostgresStorage {
+	t.Helper()
+	dsn, ok := os.LookupEnv(postgresTestDSNEnv)
+	if !ok || dsn == "" {
+		t.Skipf("%s is not set", postgresTestDSNEnv)
+	}
+	storage, err := NewPostgresStorage(dsn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = storage.db.Exec(`TRUNCATE prices, rates, auth_data, gold_feeds, activities, metrics,
+target_quantity, pwi_equation, controls, pending_settings, settings_history, step_function,
+withdrawal_approvals, executions`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return storage
+}
+
+func TestPostgresStorageConformance(t *testing.T) {
+	storage := newTestPostgresStorage(t)
+	defer storage.Close()
+	testutil.NewStorageTestSuite(t, storage).Run()
+}
+
+func TestMigrateBoltToPostgres(t *testing.T) {
+	pgStorage := newTestPostgresStorage(t)
+	defer pgStorage.Close()
+
+	tmpDir, err := ioutil.TempDir("", "test_migrate_storage")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err = os.RemoveAll(tmpDir); err != nil {
+			t.Error(err)
+		}
+	}()
+	boltStorage, err := NewBoltStorage(filepath.Join(tmpDir, "test_bolt.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err = boltStorage.StoreRate(common.AllRateEntry{BlockNumber: 10}, 1000); err != nil {
+		t.Fatal(err)
+	}
+	activityID := common.NewActivityID(1000, "deposit")
+	err = boltStorage.Record(common.ActionDeposit, activityID, "binance",
+		map[string]interface{}{"token": "OMG"}, map[string]interface{}{},
+		"", common.MiningStatusSubmitted, 1000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = boltStorage.StorePendingPWIEquationV2([]byte(`{"OMG": {"bid": {"a": 1}}}`)); err != nil {
+		t.Fatal(err)
+	}
+	stableParams := []byte(`{"DGX": {"id": "DGX", "price_update_threshold": 0.1}}`)
+	if err = boltStorage.SetStableTokenParams(stableParams); err != nil {
+		t.Fatal(err)
+	}
+	if err = boltStorage.ConfirmStableTokenParams(stableParams); err != nil {
+		t.Fatal(err)
+	}
+
+	migrated, err := MigrateBoltToPostgres(boltStorage, pgStorage)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if migrated[rateBucket] != 1 || migrated[activityBucket] != 1 || migrated[pendingPWIEquationV2] != 1 {
+		t.Errorf("unexpected number of migrated records: %+v", migrated)
+	}
+
+	rate, err := pgStorage.GetRate(1000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rate.BlockNumber != 10 {
+		t.Errorf("expected migrated rate of block 10, got %d", rate.BlockNumber)
+	}
+	pendings, err := pgStorage.GetPendingActivities()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pendings) != 1 || pendings[0].ID != activityID {
+		t.Errorf("expected migrated pending activity, got %+v", pendings)
+	}
+	equation, err := pgStorage.GetPendingPWIEquationV2()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if equation["OMG"]["bid"].A != 1 {
+		t.Errorf("expected migrated pending PWI equation, got %+v", equation)
+	}
+
+	// migration can be run again safely
+	if _, err = MigrateBoltToPostgres(boltStorage, pgStorage); err != nil {
+		t.Fatal(err)
+	}
+	var count int
+	err = pgStorage.db.QueryRow(`SELECT COUNT(*) FROM settings_history WHERE name = $1`, stableTokenParamsBucket).Scan(&count)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count != 1 {
+		t.Errorf("expected migrating twice keeps 1 stable token params record, got %d", count)
+	}
+	params, err := pgStorage.GetStableTokenParams()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := params["DGX"]; !ok {
+		t.Errorf("expected migrated stable token params, got %+v", params)
+	}
+}
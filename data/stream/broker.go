@@ -0,0 +1,174 @@
+package stream
+
+import (
+	"fmt"
+	"log"
+	"sync"
+
+	"github.com/KyberNetwork/reserve-data/common"
+)
+
+const (
+	// PriceTopic is the topic of new AllPriceEntry stored by fetcher.
+	PriceTopic = "prices"
+	// RateTopic is the topic of new AllRateEntry stored by fetcher.
+	RateTopic = "rates"
+	// AuthDataTopic is the topic of new AuthDataSnapshot stored by fetcher.
+	AuthDataTopic = "authdata"
+
+	// DefaultHistorySize is the default number of latest events kept per
+	// topic for resuming subscriptions.
+	DefaultHistorySize = 100
+
+	// subscriptionBuffer is the number of live events a subscriber can lag
+	// behind before it is dropped.
+	subscriptionBuffer = 64
+)
+
+// Topics is the list of all available topics.
+var Topics = []string{PriceTopic, RateTopic, AuthDataTopic}
+
+// Event is a new version of data published to a topic.
+type Event struct {
+	Topic   string         `json:"topic"`
+	Version common.Version `json:"version"`
+	Data    interface{}    `json:"data"`
+}
+
+// Subscription receives the events of its topics. It is closed by the broker
+// when the subscriber could not keep up with published events.
+type Subscription struct {
+	topics map[string]bool
+	events chan Event
+	closed bool
+}
+
+// Events returns the channel of subscribed events.
+func (self *Subscription) Events() <-chan Event {
+	return self.events
+}
+
+// Broker publishes the data stored by fetcher to subscribers. It keeps the
+// latest events of every topic in memory, so a reconnecting subscriber can
+// resume from the last version it received.
+type Broker struct {
+	historySize int
+
+	mu            sync.Mutex
+	history       map[string][]Event
+	subscriptions map[*Subscription]struct{}
+}
+
+// NewBroker creates a broker keeping historySize latest events per topic.
+func NewBroker(historySize int) *Broker {
+	return &Broker{
+		historySize:   historySize,
+		history:       map[string][]Event{},
+		subscriptions: map[*Subscription]struct{}{},
+	}
+}
+
+// PublishPrice publishes new price data stored at timepoint.
+func (self *Broker) PublishPrice(data common.AllPriceEntry, timepoint uint64) {
+	self.publish(PriceTopic, common.Version(timepoint), data)
+}
+
+// PublishRate publishes new rates stored at timepoint.
+func (self *Broker) PublishRate(data common.AllRateEntry, timepoint uint64) {
+	self.publish(RateTopic, common.Version(timepoint), data)
+}
+
+// PublishAuthSnapshot publishes new auth data snapshot stored at timepoint.
+func (self *Broker) PublishAuthSnapshot(data *common.AuthDataSnapshot, timepoint uint64) {
+	self.publish(AuthDataTopic, common.Version(timepoint), *data)
+}
+
+func (self *Broker) publish(topic string, version common.Version, data interface{}) {
+	event := Event{Topic: topic, Version: version, Data: data}
+	self.mu.Lock()
+	defer self.mu.Unlock()
+
+	history := append(self.history[topic], event)
+	if len(history) > self.historySize {
+		history = history[len(history)-self.historySize:]
+	}
+	self.history[topic] = history
+
+	for sub := range self.subscriptions {
+		if !sub.topics[topic] {
+			continue
+		}
+		select {
+		case sub.events <- event:
+		default:
+			log.Printf("Event stream: subscriber is too slow, dropping it")
+			self.unsubscribe(sub)
+		}
+	}
+}
+
+// Subscribe subscribes to the given topics. For every topic in
+// fromVersions, the kept events newer than the given version are delivered
+// first. It returns an error if a topic is unknown or the given version is
+// older than the oldest kept event, as the events in between may be evicted
+// or published before the broker started. The subscriber should get the
+// latest data with REST api and subscribe without version instead.
+func (self *Broker) Subscribe(topics []string, fromVersions map[string]common.Version) (*Subscription, error) {
+	sub := &Subscription{topics: map[string]bool{}}
+	for _, topic := range topics {
+		if !isTopic(topic) {
+			return nil, fmt.Errorf("unknown topic: %s", topic)
+		}
+		sub.topics[topic] = true
+	}
+
+	self.mu.Lock()
+	defer self.mu.Unlock()
+
+	missed := []Event{}
+	for topic, version := range fromVersions {
+		if !sub.topics[topic] {
+			return nil, fmt.Errorf("resuming topic %s which is not subscribed", topic)
+		}
+		history := self.history[topic]
+		if len(history) == 0 || version < history[0].Version {
+			return nil, fmt.Errorf("version %d of topic %s is no longer available", version, topic)
+		}
+		for _, event := range history {
+			if event.Version > version {
+				missed = append(missed, event)
+			}
+		}
+	}
+	sub.events = make(chan Event, len(missed)+subscriptionBuffer)
+	for _, event := range missed {
+		sub.events <- event
+	}
+	self.subscriptions[sub] = struct{}{}
+	return sub, nil
+}
+
+// Unsubscribe stops delivering events to the subscription and closes it.
+func (self *Broker) Unsubscribe(sub *Subscription) {
+	self.mu.Lock()
+	defer self.mu.Unlock()
+	self.unsubscribe(sub)
+}
+
+func (self *Broker) unsubscribe(sub *Subscription) {
+	if sub.closed {
+		return
+	}
+	sub.closed = true
+	delete(self.subscriptions, sub)
+	close(sub.events)
+}
+
+func isTopic(topic string) bool {
+	for _, t := range Topics {
+		if t == topic {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,111 @@
+package stream
+
+import (
+	"testing"
+
+	"github.com/KyberNetwork/reserve-data/common"
+)
+
+func receive(t *testing.T, sub *Subscription, expected ...common.Version) {
+	t.Helper()
+	for _, version := range expected {
+		select {
+		case event := <-sub.Events():
+			if event.Version != version {
+				t.Errorf("expected version %d, got %d", version, event.Version)
+			}
+		default:
+			t.Fatalf("expected event of version %d, got nothing", version)
+		}
+	}
+	select {
+	case event := <-sub.Events():
+		t.Errorf("unexpected event: %+v", event)
+	default:
+	}
+}
+
+func TestBrokerPerTopicSubscription(t *testing.T) {
+	broker := NewBroker(DefaultHistorySize)
+	prices, err := broker.Subscribe([]string{PriceTopic}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	all, err := broker.Subscribe(Topics, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	broker.PublishPrice(common.AllPriceEntry{}, 1)
+	broker.PublishRate(common.AllRateEntry{}, 2)
+	broker.PublishAuthSnapshot(&common.AuthDataSnapshot{}, 3)
+	receive(t, prices, 1)
+	receive(t, all, 1, 2, 3)
+
+	if _, err = broker.Subscribe([]string{"unknown"}, nil); err == nil {
+		t.Error("expected error subscribing to unknown topic")
+	}
+}
+
+func TestBrokerResume(t *testing.T) {
+	broker := NewBroker(3)
+	for i := uint64(1); i <= 4; i++ {
+		broker.PublishRate(common.AllRateEntry{}, i)
+	}
+
+	sub, err := broker.Subscribe([]string{RateTopic}, map[string]common.Version{RateTopic: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	receive(t, sub, 3, 4)
+	broker.PublishRate(common.AllRateEntry{}, 5)
+	receive(t, sub, 5)
+
+	// version 2 is evicted, resuming from version 1 would miss it
+	if _, err = broker.Subscribe([]string{RateTopic}, map[string]common.Version{RateTopic: 1}); err == nil {
+		t.Error("expected error resuming from evicted version")
+	}
+	if _, err = broker.Subscribe([]string{PriceTopic}, map[string]common.Version{RateTopic: 1}); err == nil {
+		t.Error("expected error resuming not subscribed topic")
+	}
+}
+
+func TestBrokerResumeAcrossPartialHistory(t *testing.T) {
+	// a restarted broker only keeps the events published since it started
+	broker := NewBroker(DefaultHistorySize)
+	if _, err := broker.Subscribe([]string{RateTopic}, map[string]common.Version{RateTopic: 5}); err == nil {
+		t.Error("expected error resuming before any event is kept")
+	}
+	broker.PublishRate(common.AllRateEntry{}, 10)
+	broker.PublishRate(common.AllRateEntry{}, 11)
+
+	// versions between 5 and 10 were published before the restart
+	if _, err := broker.Subscribe([]string{RateTopic}, map[string]common.Version{RateTopic: 5}); err == nil {
+		t.Error("expected error resuming from version older than partially filled history")
+	}
+	sub, err := broker.Subscribe([]string{RateTopic}, map[string]common.Version{RateTopic: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	receive(t, sub, 11)
+}
+
+func TestBrokerDropsSlowSubscriber(t *testing.T) {
+	broker := NewBroker(DefaultHistorySize)
+	sub, err := broker.Subscribe([]string{PriceTopic}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := uint64(1); i <= subscriptionBuffer+1; i++ {
+		broker.PublishPrice(common.AllPriceEntry{}, i)
+	}
+	count := 0
+	for range sub.Events() {
+		count++
+	}
+	if count != subscriptionBuffer {
+		t.Errorf("expected %d buffered events before closed, got %d", subscriptionBuffer, count)
+	}
+	// unsubscribing a dropped subscription is a no-op
+	broker.Unsubscribe(sub)
+}
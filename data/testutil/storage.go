@@ -0,0 +1,678 @@
+package testutil
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/KyberNetwork/reserve-data/common"
+	"github.com/KyberNetwork/reserve-data/core"
+	"github.com/KyberNetwork/reserve-data/data"
+	"github.com/KyberNetwork/reserve-data/data/fetcher"
+	"github.com/KyberNetwork/reserve-data/data/fetcher/replay"
+	"github.com/KyberNetwork/reserve-data/metric"
+)
+
+// Storage is the set of storage interfaces that a data storage
+// implementation must satisfy to be used by core.
+type Storage interface {
+	data.Storage
+	data.GlobalStorage
+	data.StepFunctionDataStorage
+	fetcher.Storage
+	fetcher.GlobalStorage
+	core.ActivityStorage
+	core.WithdrawalApprovalStorage
+	core.ExecutionStorage
+	metric.MetricStorage
+	replay.Storage
+}
+
+// StorageTestSuite is a conformance test suite that all implementations of Storage must pass.
+// It expects the given storage to be empty.
+type StorageTestSuite struct {
+	t  *testing.T
+	st Storage
+}
+
+// NewStorageTestSuite creates a new test suite for the given storage implementation.
+func NewStorageTestSuite(t *testing.T, st Storage) *StorageTestSuite {
+	t.Helper()
+	return &StorageTestSuite{t: t, st: st}
+}
+
+// Run executes test suite.
+func (ts *StorageTestSuite) Run() {
+	ts.t.Helper()
+	ts.t.Run("prices", ts.testPrices)
+	ts.t.Run("rates", ts.testRates)
+	ts.t.Run("auth_data", ts.testAuthData)
+	ts.t.Run("activities", ts.testActivities)
+	ts.t.Run("metrics", ts.testMetrics)
+	ts.t.Run("controls", ts.testControls)
+	ts.t.Run("target_qty_v2", ts.testTargetQtyV2)
+	ts.t.Run("pwi_equation_v2", ts.testPWIEquationV2)
+	ts.t.Run("rebalance_quadratic", ts.testRebalanceQuadratic)
+	ts.t.Run("token_update_info", ts.testTokenUpdateInfo)
+	ts.t.Run("stable_token_params", ts.testStableTokenParams)
+	ts.t.Run("step_function", ts.testStepFunction)
+	ts.t.Run("withdrawal_approvals", ts.testWithdrawalApprovals)
+	ts.t.Run("executions", ts.testExecutions)
+	ts.t.Run("replay", ts.testReplay)
+	ts.t.Run("gold_info", func(t *testing.T) {
+		NewGlobalStorageTestSuite(t, ts.st, ts.st).Run()
+	})
+}
+
+func (ts *StorageTestSuite) testPrices(t *testing.T) {
+	pair := common.NewTokenPairID("OMG", "ETH")
+	for i, timepoint := range []uint64{1000, 2000} {
+		entry := common.AllPriceEntry{
+			Block: uint64(i + 1),
+			Data: map[common.TokenPairID]common.OnePrice{
+				pair: {"binance": common.ExchangePrice{Valid: true, Timestamp: "1"}},
+			},
+		}
+		if err := ts.st.StorePrice(entry, timepoint); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if _, err := ts.st.CurrentPriceVersion(999); err == nil {
+		t.Error("expected error getting price version before the first record")
+	}
+	version, err := ts.st.CurrentPriceVersion(2500)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if version != 2000 {
+		t.Errorf("expected price version 2000, got %d", version)
+	}
+	all, err := ts.st.GetAllPrices(version)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if all.Block != 2 {
+		t.Errorf("expected prices of block 2, got %d", all.Block)
+	}
+	one, err := ts.st.GetOnePrice(pair, version)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !one["binance"].Valid {
+		t.Errorf("expected valid binance price, got %+v", one)
+	}
+	if _, err = ts.st.GetOnePrice(common.NewTokenPairID("KNC", "ETH"), version); err == nil {
+		t.Error("expected error getting price of unsupported pair")
+	}
+	if _, err = ts.st.GetAllPrices(1500); err == nil {
+		t.Error("expected error getting price of not existing version")
+	}
+}
+
+func (ts *StorageTestSuite) testRates(t *testing.T) {
+	for _, rate := range []struct {
+		block     uint64
+		timepoint uint64
+	}{{10, 1000}, {11, 2000}} {
+		if err := ts.st.StoreRate(common.AllRateEntry{BlockNumber: rate.block}, rate.timepoint); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := ts.st.StoreRate(common.AllRateEntry{BlockNumber: 9}, 3000); err == nil {
+		t.Error("expected error storing rates of smaller block number")
+	}
+
+	version, err := ts.st.CurrentRateVersion(3000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if version != 2000 {
+		t.Errorf("expected rate version 2000, got %d", version)
+	}
+	rate, err := ts.st.GetRate(version)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rate.BlockNumber != 11 {
+		t.Errorf("expected rate of block 11, got %d", rate.BlockNumber)
+	}
+	rates, err := ts.st.GetRates(0, 2000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rates) != 2 || rates[0].BlockNumber != 11 || rates[1].BlockNumber != 10 {
+		t.Errorf("expected rates of block 11 and 10, got %+v", rates)
+	}
+}
+
+func (ts *StorageTestSuite) testAuthData(t *testing.T) {
+	now := common.GetTimepoint()
+	for _, timepoint := range []uint64{1000, now} {
+		snapshot := &common.AuthDataSnapshot{Valid: true, Timestamp: "1", Block: timepoint}
+		if err := ts.st.StoreAuthSnapshot(snapshot, timepoint); err != nil {
+			t.Fatal(err)
+		}
+	}
+	version, err := ts.st.CurrentAuthDataVersion(now + 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	snapshot, err := ts.st.GetAuthData(version)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if snapshot.Block != now {
+		t.Errorf("expected latest auth data, got %+v", snapshot)
+	}
+
+	pruned, err := ts.st.PruneExpiredAuthData(now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pruned != 1 {
+		t.Errorf("expected 1 expired auth data pruned, got %d", pruned)
+	}
+	if _, err = ts.st.GetAuthData(1000); err == nil {
+		t.Error("expected expired auth data is pruned")
+	}
+}
+
+func (ts *StorageTestSuite) testActivities(t *testing.T) {
+	token := common.NewToken("OMG", "Omise-go", "0x1111111111111111111111111111111111111111", 18, true, true, 0)
+	exchange := common.TestExchange{}
+	depositID := common.NewActivityID(1000, "deposit")
+	err := ts.st.Record(common.ActionDeposit, depositID, string(exchange.ID()),
+		map[string]interface{}{"token": token, "amount": "1.0"},
+		map[string]interface{}{"tx": "0x1"},
+		"", common.MiningStatusSubmitted, 1000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, gasPrice := range []string{"20", "10"} {
+		err = ts.st.Record(common.ActionSetrate, common.NewActivityID(uint64(2000+i), "setrate"), "blockchain",
+			map[string]interface{}{},
+			map[string]interface{}{"nonce": "5", "gasPrice": gasPrice},
+			"", common.MiningStatusSubmitted, uint64(2000+i))
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	pendings, err := ts.st.GetPendingActivities()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pendings) != 3 || pendings[0].ID.Timepoint != 2001 || pendings[2].ID != depositID {
+		t.Errorf("expected 3 pending activities newest first, got %+v", pendings)
+	}
+	pending, err := ts.st.HasPendingDeposit(token, exchange)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !pending {
+		t.Error("expected pending deposit")
+	}
+	setrate, count, err := ts.st.PendingSetrate(4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if setrate == nil || count != 2 || setrate.Result["gasPrice"] != "10" {
+		t.Errorf("expected lowest gas price pending set rate of 2, got %+v, count %d", setrate, count)
+	}
+
+	activity, err := ts.st.GetActivity(depositID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	activity.MiningStatus = common.MiningStatusMined
+	activity.ExchangeStatus = common.ExchangeStatusDone
+	if err = ts.st.UpdateActivity(depositID, activity); err != nil {
+		t.Fatal(err)
+	}
+	if pending, err = ts.st.HasPendingDeposit(token, exchange); err != nil {
+		t.Fatal(err)
+	}
+	if pending {
+		t.Error("expected no pending deposit after it is done")
+	}
+	if activity, err = ts.st.GetActivity(depositID); err != nil {
+		t.Fatal(err)
+	}
+	if activity.MiningStatus != common.MiningStatusMined {
+		t.Errorf("expected updated activity, got %+v", activity)
+	}
+	if _, err = ts.st.GetActivity(common.NewActivityID(999, "missing")); err == nil {
+		t.Error("expected error getting not existing activity")
+	}
+
+	records, err := ts.st.GetAllRecords(1000, 2000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 2 || records[0].ID.Timepoint != 2000 || records[1].ID != depositID {
+		t.Errorf("expected 2 activities in time range newest first, got %+v", records)
+	}
+}
+
+func (ts *StorageTestSuite) testMetrics(t *testing.T) {
+	for _, timepoint := range []uint64{1000, 2000, 3000} {
+		entry := &common.MetricEntry{
+			Timestamp: timepoint,
+			Data:      map[string]common.TokenMetric{"OMG": {AfpMid: float64(timepoint), Spread: 1}},
+		}
+		if err := ts.st.StoreMetric(entry, timepoint); err != nil {
+			t.Fatal(err)
+		}
+	}
+	omg := common.NewToken("OMG", "Omise-go", "0x1111111111111111111111111111111111111111", 18, true, true, 0)
+	knc := common.NewToken("KNC", "Kyber", "0x2222222222222222222222222222222222222222", 18, true, true, 0)
+	metrics, err := ts.st.GetMetric([]common.Token{omg, knc}, 1000, 2000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(metrics["OMG"]) != 2 || metrics["OMG"][0].Timestamp != 1000 || metrics["OMG"][1].AfpMid != 2000 {
+		t.Errorf("expected 2 OMG metrics in time range, got %+v", metrics["OMG"])
+	}
+	if len(metrics["KNC"]) != 0 {
+		t.Errorf("expected no KNC metric, got %+v", metrics["KNC"])
+	}
+}
+
+func (ts *StorageTestSuite) testControls(t *testing.T) {
+	for _, status := range []bool{true, false, true} {
+		if err := ts.st.StoreRebalanceControl(status); err != nil {
+			t.Fatal(err)
+		}
+		rebalance, err := ts.st.GetRebalanceControl()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if rebalance.Status != status {
+			t.Errorf("expected rebalance status %t, got %t", status, rebalance.Status)
+		}
+		if err = ts.st.StoreSetrateControl(!status); err != nil {
+			t.Fatal(err)
+		}
+		setrate, err := ts.st.GetSetrateControl()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if setrate.Status != !status {
+			t.Errorf("expected set rate status %t, got %t", !status, setrate.Status)
+		}
+	}
+}
+
+func (ts *StorageTestSuite) mustMarshal(v interface{}) []byte {
+	ts.t.Helper()
+	data, err := json.Marshal(v)
+	if err != nil {
+		ts.t.Fatal(err)
+	}
+	return data
+}
+
+func (ts *StorageTestSuite) testTargetQtyV2(t *testing.T) {
+	target := common.TokenTargetQtyV2{
+		"OMG": {SetTarget: common.TargetQtySet{TotalTarget: 1000, ReserveTarget: 500}},
+	}
+	data := ts.mustMarshal(target)
+	if err := ts.st.StorePendingTargetQtyV2(data); err != nil {
+		t.Fatal(err)
+	}
+	if err := ts.st.StorePendingTargetQtyV2(data); err == nil {
+		t.Error("expected error storing pending target quantity twice")
+	}
+	pending, err := ts.st.GetPendingTargetQtyV2()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(pending, target) {
+		t.Errorf("expected pending target quantity %+v, got %+v", target, pending)
+	}
+
+	other := ts.mustMarshal(common.TokenTargetQtyV2{"KNC": {}})
+	if err = ts.st.ConfirmTargetQtyV2(other); err == nil {
+		t.Error("expected error confirming mismatched target quantity")
+	}
+	if err = ts.st.ConfirmTargetQtyV2(data); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = ts.st.GetPendingTargetQtyV2(); err == nil {
+		t.Error("expected no pending target quantity after confirmed")
+	}
+	current, err := ts.st.GetTargetQtyV2()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(current, target) {
+		t.Errorf("expected target quantity %+v, got %+v", target, current)
+	}
+
+	if err = ts.st.StorePendingTargetQtyV2(other); err != nil {
+		t.Fatal(err)
+	}
+	if err = ts.st.RemovePendingTargetQtyV2(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = ts.st.GetPendingTargetQtyV2(); err == nil {
+		t.Error("expected no pending target quantity after removed")
+	}
+}
+
+func (ts *StorageTestSuite) testPWIEquationV2(t *testing.T) {
+	equation := common.PWIEquationRequestV2{
+		"OMG": {"bid": {A: 1, B: 2, C: 3}, "ask": {A: 4, B: 5, C: 6}},
+	}
+	data := ts.mustMarshal(equation)
+	if err := ts.st.StorePendingPWIEquationV2(data); err != nil {
+		t.Fatal(err)
+	}
+	if err := ts.st.StorePendingPWIEquationV2(data); err == nil {
+		t.Error("expected error storing pending PWI equation twice")
+	}
+	pending, err := ts.st.GetPendingPWIEquationV2()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(pending, equation) {
+		t.Errorf("expected pending PWI equation %+v, got %+v", equation, pending)
+	}
+	if err = ts.st.StorePWIEquationV2(`{"KNC": {}}`); err == nil {
+		t.Error("expected error confirming mismatched PWI equation")
+	}
+	if err = ts.st.StorePWIEquationV2(string(data)); err != nil {
+		t.Fatal(err)
+	}
+	current, err := ts.st.GetPWIEquationV2()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(current, equation) {
+		t.Errorf("expected PWI equation %+v, got %+v", equation, current)
+	}
+	if err = ts.st.RemovePendingPWIEquationV2(); err == nil {
+		t.Error("expected error removing not existing pending PWI equation")
+	}
+}
+
+func (ts *StorageTestSuite) testRebalanceQuadratic(t *testing.T) {
+	equation := common.RebalanceQuadraticRequest{}
+	eq := common.RebalanceQuadraticEquation{}
+	eq.RebalanceQuadratic.A = 1
+	eq.RebalanceQuadratic.B = 2
+	eq.RebalanceQuadratic.C = 3
+	equation["OMG"] = eq
+	data := ts.mustMarshal(equation)
+	if err := ts.st.StorePendingRebalanceQuadratic(data); err != nil {
+		t.Fatal(err)
+	}
+	if err := ts.st.StorePendingRebalanceQuadratic(data); err == nil {
+		t.Error("expected error storing pending rebalance quadratic twice")
+	}
+	pending, err := ts.st.GetPendingRebalanceQuadratic()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(pending, equation) {
+		t.Errorf("expected pending rebalance quadratic %+v, got %+v", equation, pending)
+	}
+	if err = ts.st.ConfirmRebalanceQuadratic([]byte(`{"KNC": {}}`)); err == nil {
+		t.Error("expected error confirming mismatched rebalance quadratic")
+	}
+	if err = ts.st.ConfirmRebalanceQuadratic(data); err != nil {
+		t.Fatal(err)
+	}
+	current, err := ts.st.GetRebalanceQuadratic()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(current, equation) {
+		t.Errorf("expected rebalance quadratic %+v, got %+v", equation, current)
+	}
+	if err = ts.st.RemovePendingRebalanceQuadratic(); err == nil {
+		t.Error("expected error removing not existing pending rebalance quadratic")
+	}
+}
+
+func (ts *StorageTestSuite) testTokenUpdateInfo(t *testing.T) {
+	target := common.TokenTargetQtyV2{"KNC": {SetTarget: common.TargetQtySet{TotalTarget: 10}}}
+	pwi := common.PWIEquationRequestV2{"KNC": {"bid": {A: 7}, "ask": {A: 8}}}
+	quadratic := common.RebalanceQuadraticRequest{"KNC": common.RebalanceQuadraticEquation{}}
+
+	if err := ts.st.StorePendingTokenUpdateInfo(target, pwi, quadratic); err != nil {
+		t.Fatal(err)
+	}
+	if err := ts.st.StorePendingTokenUpdateInfo(target, pwi, quadratic); err == nil {
+		t.Error("expected error storing pending token update info twice")
+	}
+	if err := ts.st.RemovePendingTokenUpdateInfo(); err != nil {
+		t.Fatal(err)
+	}
+	if err := ts.st.RemovePendingTokenUpdateInfo(); err == nil {
+		t.Error("expected error removing not existing token update info")
+	}
+
+	if err := ts.st.ConfirmTokenUpdateInfo(target, pwi, quadratic); err != nil {
+		t.Fatal(err)
+	}
+	currentTarget, err := ts.st.GetTargetQtyV2()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(currentTarget, target) {
+		t.Errorf("expected target quantity %+v, got %+v", target, currentTarget)
+	}
+	currentPWI, err := ts.st.GetPWIEquationV2()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(currentPWI, pwi) {
+		t.Errorf("expected PWI equation %+v, got %+v", pwi, currentPWI)
+	}
+	currentQuadratic, err := ts.st.GetRebalanceQuadratic()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(currentQuadratic, quadratic) {
+		t.Errorf("expected rebalance quadratic %+v, got %+v", quadratic, currentQuadratic)
+	}
+}
+
+func (ts *StorageTestSuite) testStableTokenParams(t *testing.T) {
+	params := []byte(`{"DGX": {"ask_spread": 10}}`)
+	if err := ts.st.SetStableTokenParams(params); err != nil {
+		t.Fatal(err)
+	}
+	if err := ts.st.SetStableTokenParams(params); err == nil {
+		t.Error("expected error storing pending stable token params twice")
+	}
+	pending, err := ts.st.GetPendingStableTokenParams()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := pending["DGX"]; !ok {
+		t.Errorf("expected pending DGX params, got %+v", pending)
+	}
+	if err = ts.st.ConfirmStableTokenParams([]byte(`{"DGX": {"ask_spread": 11}}`)); err == nil {
+		t.Error("expected error confirming mismatched stable token params")
+	}
+	if err = ts.st.ConfirmStableTokenParams(params); err != nil {
+		t.Fatal(err)
+	}
+	current, err := ts.st.GetStableTokenParams()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(current, pending) {
+		t.Errorf("expected stable token params %+v, got %+v", pending, current)
+	}
+	if err = ts.st.RemovePendingStableTokenParams(); err == nil {
+		t.Error("expected error removing not existing pending stable token params")
+	}
+}
+
+func (ts *StorageTestSuite) testStepFunction(t *testing.T) {
+	empty, err := ts.st.GetStepFunctionData()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if empty.BlockNumber != 0 {
+		t.Errorf("expected empty step function data, got %+v", empty)
+	}
+	stepFunction := common.StepFunctionData{BlockNumber: 100}
+	if err = ts.st.StoreStepFunctionData(stepFunction); err != nil {
+		t.Fatal(err)
+	}
+	current, err := ts.st.GetStepFunctionData()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if current.BlockNumber != 100 {
+		t.Errorf("expected step function data of block 100, got %+v", current)
+	}
+}
+
+func (ts *StorageTestSuite) testWithdrawalApprovals(t *testing.T) {
+	thresholds, err := ts.st.GetWithdrawThresholds()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(thresholds) != 0 {
+		t.Errorf("expected no withdraw thresholds, got %+v", thresholds)
+	}
+	for _, value := range []float64{100, 200} {
+		if err = ts.st.StoreWithdrawThresholds(common.WithdrawThresholds{"KNC": value}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if thresholds, err = ts.st.GetWithdrawThresholds(); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(thresholds, common.WithdrawThresholds{"KNC": 200}) {
+		t.Errorf("expected the latest withdraw thresholds, got %+v", thresholds)
+	}
+
+	for i, id := range []string{"2", "1"} {
+		approval := common.WithdrawalApproval{
+			ID:         id,
+			Exchange:   "binance",
+			Token:      "KNC",
+			Amount:     "1000",
+			Status:     common.WithdrawalApprovalPending,
+			ProposedBy: "rebalance",
+			ProposedAt: uint64(2000 - i*1000),
+			ExpiresAt:  3000,
+		}
+		if err = ts.st.StoreWithdrawalApproval(approval); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err = ts.st.StoreWithdrawalApproval(common.WithdrawalApproval{ID: "1", Status: common.WithdrawalApprovalPending}); err == nil {
+		t.Error("expected error storing withdrawal approval of existing id")
+	}
+
+	approval, err := ts.st.GetWithdrawalApproval("1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	activityID := common.NewActivityID(1500, "withdrawid")
+	approval.Status = common.WithdrawalApprovalConfirmed
+	approval.ReviewedBy = "confirm_configuration"
+	approval.ActivityID = &activityID
+	if err = ts.st.UpdateWithdrawalApproval(approval, common.WithdrawalApprovalPending); err != nil {
+		t.Fatal(err)
+	}
+	if err = ts.st.UpdateWithdrawalApproval(approval, common.WithdrawalApprovalPending); err == nil {
+		t.Error("expected error updating withdrawal approval of different status")
+	}
+	stored, err := ts.st.GetWithdrawalApproval("1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(stored, approval) {
+		t.Errorf("expected stored withdrawal approval %+v, got %+v", approval, stored)
+	}
+	if _, err = ts.st.GetWithdrawalApproval("3"); err == nil {
+		t.Error("expected error getting not existing withdrawal approval")
+	}
+
+	pendings, err := ts.st.GetPendingWithdrawalApprovals()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pendings) != 1 || pendings[0].ID != "2" {
+		t.Errorf("expected pending withdrawal approval 2, got %+v", pendings)
+	}
+	approvals, err := ts.st.GetWithdrawalApprovals(0, 2000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(approvals) != 2 || approvals[0].ID != "1" || approvals[1].ID != "2" {
+		t.Errorf("expected withdrawal approvals ordered by proposed time, got %+v", approvals)
+	}
+	if approvals, err = ts.st.GetWithdrawalApprovals(1500, 2500); err != nil {
+		t.Fatal(err)
+	}
+	if len(approvals) != 1 || approvals[0].ID != "2" {
+		t.Errorf("expected withdrawal approval 2 in time range, got %+v", approvals)
+	}
+}
+
+func (ts *StorageTestSuite) testExecutions(t *testing.T) {
+	first := common.Execution{
+		ID:        common.NewActivityID(1000, "binance|twap"),
+		Exchange:  "binance",
+		Type:      "buy",
+		Base:      "KNC",
+		Quote:     "ETH",
+		Rate:      0.001,
+		Amount:    1000,
+		Strategy:  common.ExecutionStrategy{Name: common.ExecutionTWAP, Duration: 600000, Slices: 10, RepriceInterval: 60000},
+		Status:    common.ExecutionRunning,
+		CreatedAt: 1000,
+		UpdatedAt: 1000,
+	}
+	second := first
+	second.ID = common.NewActivityID(2000, "binance|iceberg")
+	second.Strategy = common.ExecutionStrategy{Name: common.ExecutionIceberg, VisibleAmount: 100, RepriceInterval: 60000}
+	second.CreatedAt = 2000
+	for _, execution := range []common.Execution{second, first} {
+		if err := ts.st.StoreExecution(execution); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	first.Slices = []common.ExecutionSlice{{ID: common.NewActivityID(1500, "123"), Rate: 0.001, Amount: 100, Done: 100, PlacedAt: 1500, Finished: true}}
+	first.Done = 100
+	first.Status = common.ExecutionCancelled
+	if err := ts.st.StoreExecution(first); err != nil {
+		t.Fatal(err)
+	}
+	stored, err := ts.st.GetExecution(first.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(stored, first) {
+		t.Errorf("expected stored execution %+v, got %+v", first, stored)
+	}
+	if _, err = ts.st.GetExecution(common.NewActivityID(3000, "missing")); err == nil {
+		t.Error("expected error getting not existing execution")
+	}
+
+	running, err := ts.st.GetRunningExecutions()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(running) != 1 || running[0].ID != second.ID {
+		t.Errorf("expected running execution %s, got %+v", second.ID, running)
+	}
+}
+
+// testReplay expects activities are stored by previous tests.
+func (ts *StorageTestSuite) testReplay(t *testing.T) {
+	if err := ts.st.MarkReplay(); err == nil {
+		t.Error("expected error of marking storage holding live activities used by replays")
+	}
+}
@@ -22,13 +22,21 @@ const (
 )
 
 type Binance struct {
+	name    settings.ExchangeName
 	interf  BinanceInterface
 	storage BinanceStorage
 	setting Setting
+
+	depthStream        DepthStream
+	depthStaleDuration time.Duration
+
+	// subAccounts are the emails of the sub-accounts of which balances are
+	// fetched and which funds can be transferred between.
+	subAccounts []string
 }
 
 func (self *Binance) TokenAddresses() (map[string]ethereum.Address, error) {
-	addresses, err := self.setting.GetDepositAddresses(settings.Binance)
+	addresses, err := self.setting.GetDepositAddresses(self.name)
 	if err != nil {
 		return nil, err
 	}
@@ -45,7 +53,7 @@ func (self *Binance) Address(token common.Token) (ethereum.Address, bool) {
 	liveAddress, err := self.interf.GetDepositAddress(token.ID)
 	if err != nil || liveAddress.Address == "" {
 		log.Printf("WARNING: Get Binance live deposit address for token %s failed: err: (%v) or the address repplied is empty . Use the currently available address instead", token.ID, err)
-		addrs, uErr := self.setting.GetDepositAddresses(settings.Binance)
+		addrs, uErr := self.setting.GetDepositAddresses(self.name)
 		if uErr != nil {
 			log.Printf("WARNING: get address of token %s in Binance exchange failed:(%s), it will be considered as not supported", token.ID, err.Error())
 			return ethereum.Address{}, false
@@ -55,7 +63,7 @@ func (self *Binance) Address(token common.Token) (ethereum.Address, bool) {
 	log.Printf("Got Binance live deposit address for token %s, attempt to update it to current setting", token.ID)
 	addrs := common.NewExchangeAddresses()
 	addrs.Update(token.ID, ethereum.HexToAddress(liveAddress.Address))
-	if err = self.setting.UpdateDepositAddress(settings.Binance, *addrs, common.GetTimepoint()); err != nil {
+	if err = self.setting.UpdateDepositAddress(self.name, *addrs, common.GetTimepoint()); err != nil {
 		log.Printf("WARNING: cannot update deposit address for token %s on Binance: (%s)", token.ID, err.Error())
 	}
 	return ethereum.HexToAddress(liveAddress.Address), true
@@ -67,12 +75,12 @@ func (self *Binance) UpdateDepositAddress(token common.Token, address string) er
 		log.Printf("WARNING: Get Binance live deposit address for token %s failed: err: (%v) or the address repplied is empty . Use the currently available address instead", token.ID, err)
 		addrs := common.NewExchangeAddresses()
 		addrs.Update(token.ID, ethereum.HexToAddress(address))
-		return self.setting.UpdateDepositAddress(settings.Binance, *addrs, common.GetTimepoint())
+		return self.setting.UpdateDepositAddress(self.name, *addrs, common.GetTimepoint())
 	}
 	log.Printf("Got Binance live deposit address for token %s, attempt to update it to current setting", token.ID)
 	addrs := common.NewExchangeAddresses()
 	addrs.Update(token.ID, ethereum.HexToAddress(liveAddress.Address))
-	return self.setting.UpdateDepositAddress(settings.Binance, *addrs, common.GetTimepoint())
+	return self.setting.UpdateDepositAddress(self.name, *addrs, common.GetTimepoint())
 }
 
 func (self *Binance) precisionFromStepSize(stepSize string) int {
@@ -166,15 +174,15 @@ func (self *Binance) UpdatePairsPrecision() error {
 		}
 		exInfo[pair] = exchangePrecisionLimit
 	}
-	return self.setting.UpdateExchangeInfo(settings.Binance, exInfo, common.GetTimepoint())
+	return self.setting.UpdateExchangeInfo(self.name, exInfo, common.GetTimepoint())
 }
 
 func (self *Binance) GetInfo() (common.ExchangeInfo, error) {
-	return self.setting.GetExchangeInfo(settings.Binance)
+	return self.setting.GetExchangeInfo(self.name)
 }
 
 func (self *Binance) GetExchangeInfo(pair common.TokenPairID) (common.ExchangePrecisionLimit, error) {
-	exInfo, err := self.setting.GetExchangeInfo(settings.Binance)
+	exInfo, err := self.setting.GetExchangeInfo(self.name)
 	if err != nil {
 		return common.ExchangePrecisionLimit{}, err
 	}
@@ -182,21 +190,71 @@ func (self *Binance) GetExchangeInfo(pair common.TokenPairID) (common.ExchangePr
 }
 
 func (self *Binance) GetFee() (common.ExchangeFees, error) {
-	return self.setting.GetFee(settings.Binance)
+	return self.setting.GetFee(self.name)
 }
 
 func (self *Binance) GetMinDeposit() (common.ExchangesMinDeposit, error) {
-	return self.setting.GetMinDeposit(settings.Binance)
+	return self.setting.GetMinDeposit(self.name)
+}
+
+// FetchLiveFees returns the trading fees of the account and the withdrawal
+// fees of known tokens from Binance API. Binance doesn't report min deposits.
+func (self *Binance) FetchLiveFees() (common.ExchangeFees, common.ExchangesMinDeposit, error) {
+	fee := common.NewExchangeFee(common.TradingFee{}, common.NewFundingFee(map[string]float64{}, map[string]float64{}))
+	info, err := self.interf.GetInfo()
+	if err != nil {
+		return fee, nil, err
+	}
+	// commissions are in basis points
+	fee.Trading["maker"] = float64(info.MakerCommission) / 10000
+	fee.Trading["taker"] = float64(info.TakerCommission) / 10000
+	assets, err := self.interf.GetAssetDetail()
+	if err != nil {
+		return fee, nil, err
+	}
+	for asset, detail := range assets {
+		if _, err := self.setting.GetTokenByID(asset); err != nil {
+			continue
+		}
+		fee.Funding.Withdraw[asset] = detail.WithdrawFee
+	}
+	return fee, common.ExchangesMinDeposit{}, nil
+}
+
+// SystemStatus returns whether Binance is under maintenance and the deposit
+// and withdrawal statuses of known tokens.
+func (self *Binance) SystemStatus() (common.ExchangeSystemStatus, error) {
+	result := common.ExchangeSystemStatus{Wallets: map[string]common.TokenWalletStatus{}}
+	status, err := self.interf.GetSystemStatus()
+	if err != nil {
+		return result, err
+	}
+	result.Maintenance = status.Status != 0
+	result.Message = status.Msg
+	assets, err := self.interf.GetAssetDetail()
+	if err != nil {
+		return result, err
+	}
+	for asset, detail := range assets {
+		if _, err := self.setting.GetTokenByID(asset); err != nil {
+			continue
+		}
+		result.Wallets[asset] = common.TokenWalletStatus{
+			DepositEnabled:  detail.DepositStatus,
+			WithdrawEnabled: detail.WithdrawStatus,
+		}
+	}
+	return result, nil
 }
 
 // ID must return the exact string or else simulation will fail
 func (self *Binance) ID() common.ExchangeID {
-	return common.ExchangeID(settings.Binance.String())
+	return common.ExchangeID(self.name)
 }
 
 func (self *Binance) TokenPairs() ([]common.TokenPair, error) {
 	result := []common.TokenPair{}
-	exInfo, err := self.setting.GetExchangeInfo(settings.Binance)
+	exInfo, err := self.setting.GetExchangeInfo(self.name)
 	if err != nil {
 		return nil, err
 	}
@@ -257,6 +315,16 @@ func (self *Binance) Withdraw(token common.Token, amount *big.Int, address ether
 	return tx, err
 }
 
+// BuildTradeRequest returns the unsigned request Trade would send.
+func (self *Binance) BuildTradeRequest(tradeType string, base, quote common.Token, rate, amount float64) (common.DryRunRequest, error) {
+	return self.interf.BuildTrade(tradeType, base, quote, rate, amount)
+}
+
+// BuildWithdrawRequest returns the unsigned request Withdraw would send.
+func (self *Binance) BuildWithdrawRequest(token common.Token, amount *big.Int, address ethereum.Address) (common.DryRunRequest, error) {
+	return self.interf.BuildWithdraw(token, amount, address)
+}
+
 func (self *Binance) CancelOrder(id string, base, quote string) error {
 	idNo, err := strconv.ParseUint(id, 10, 64)
 	if err != nil {
@@ -323,10 +391,13 @@ func (self *Binance) FetchOnePairData(
 func (self *Binance) FetchPriceData(timepoint uint64) (map[common.TokenPairID]common.ExchangePrice, error) {
 	wait := sync.WaitGroup{}
 	data := sync.Map{}
-	pairs, err := self.TokenPairs()
+	allPairs, err := self.TokenPairs()
 	if err != nil {
 		return nil, err
 	}
+	result := map[common.TokenPairID]common.ExchangePrice{}
+	// pairs not available from depth stream are polled with REST
+	pairs := restPairs(self.depthStream, self.depthStaleDuration, allPairs, result, timepoint)
 	var i int = 0
 	var x int = 0
 	for i < len(pairs) {
@@ -338,7 +409,6 @@ func (self *Binance) FetchPriceData(timepoint uint64) (map[common.TokenPairID]co
 		wait.Wait()
 		i = x
 	}
-	result := map[common.TokenPairID]common.ExchangePrice{}
 	data.Range(func(key, value interface{}) bool {
 		//if there is conversion error, continue to next key,val
 		tokenPairID, ok := key.(common.TokenPairID)
@@ -427,6 +497,7 @@ func (self *Binance) FetchEBalanceData(timepoint uint64) (common.EBalanceEntry,
 					result.DepositBalance[tokenID] = 0
 				}
 			}
+			result.SubAccounts = self.fetchSubAccountBalances()
 		}
 	}
 	return result, nil
@@ -586,14 +657,72 @@ func (self *Binance) OrderStatus(id string, base, quote string) (string, error)
 	}
 }
 
+// OrderFill returns the filled and remaining amounts of the order.
+func (self *Binance) OrderFill(id string, base, quote string) (float64, float64, bool, error) {
+	orderID, err := strconv.ParseUint(id, 10, 64)
+	if err != nil {
+		return 0, 0, false, fmt.Errorf("Can not parse orderID (val %s) to uint", id)
+	}
+	order, err := self.interf.OrderStatus(base+quote, orderID)
+	if err != nil {
+		return 0, 0, false, err
+	}
+	done, err := strconv.ParseFloat(order.ExecutedQty, 64)
+	if err != nil {
+		return 0, 0, false, err
+	}
+	total, err := strconv.ParseFloat(order.OrigQty, 64)
+	if err != nil {
+		return 0, 0, false, err
+	}
+	finished := order.Status != "NEW" && order.Status != "PARTIALLY_FILLED" && order.Status != "PENDING_CANCEL"
+	return done, total - done, finished, nil
+}
+
+// BinanceOption is the option to setup the Binance exchange on creation.
+type BinanceOption func(b *Binance)
+
+// WithBinanceDepthStream setups Binance to read order books from the given
+// depth stream, falling back to REST polling for pairs not available from it.
+// Pairs of a stream without any message for staleDuration are polled too.
+func WithBinanceDepthStream(stream DepthStream, staleDuration time.Duration) BinanceOption {
+	return func(b *Binance) {
+		b.depthStream = stream
+		b.depthStaleDuration = staleDuration
+	}
+}
+
+// WithBinanceName setups Binance to run as the exchange account of the given
+// name instead of the default one, eg: a sub-account.
+func WithBinanceName(name settings.ExchangeName) BinanceOption {
+	return func(b *Binance) {
+		b.name = name
+	}
+}
+
+// WithBinanceSubAccounts setups Binance to fetch balances of the sub-accounts
+// of the given emails and allow internal transfers between them, the API key
+// must be of the master account.
+func WithBinanceSubAccounts(emails []string) BinanceOption {
+	return func(b *Binance) {
+		b.subAccounts = emails
+	}
+}
+
 func NewBinance(
 	interf BinanceInterface,
 	storage BinanceStorage,
-	setting Setting) (*Binance, error) {
+	setting Setting,
+	options ...BinanceOption) (*Binance, error) {
 	binance := &Binance{
-		interf,
-		storage,
-		setting,
+		name:               settings.Binance,
+		interf:             interf,
+		storage:            storage,
+		setting:            setting,
+		depthStaleDuration: DefaultDepthStaleDuration,
+	}
+	for _, option := range options {
+		option(binance)
 	}
 	binance.FetchTradeHistory()
 	return binance, nil
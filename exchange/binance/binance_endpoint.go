@@ -15,6 +15,7 @@ import (
 	"time"
 
 	"github.com/KyberNetwork/reserve-data/common"
+	"github.com/KyberNetwork/reserve-data/common/instrument"
 	"github.com/KyberNetwork/reserve-data/exchange"
 	ethereum "github.com/ethereum/go-ethereum/common"
 )
@@ -27,6 +28,8 @@ type BinanceEndpoint struct {
 	signer    Signer
 	interf    Interface
 	timeDelta int64
+	// name is the exchange ID labelling the request metrics.
+	name string
 }
 
 func (self *BinanceEndpoint) fillRequest(req *http.Request, signNeeded bool, timepoint uint64) {
@@ -50,6 +53,34 @@ func (self *BinanceEndpoint) fillRequest(req *http.Request, signNeeded bool, tim
 	}
 }
 
+// newRequest creates the request to url with the params, signed if needed.
+func (self *BinanceEndpoint) newRequest(
+	method string, url string,
+	params map[string]string, signNeeded bool, timepoint uint64) (*http.Request, error) {
+	req, err := http.NewRequest(method, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Add("Accept", "application/json")
+	q := req.URL.Query()
+	for k, v := range params {
+		q.Add(k, v)
+	}
+	req.URL.RawQuery = q.Encode()
+	self.fillRequest(req, signNeeded, timepoint)
+	return req, nil
+}
+
+// buildRequest creates the unsigned request to url with the params without
+// sending it.
+func (self *BinanceEndpoint) buildRequest(method string, url string, params map[string]string) (common.DryRunRequest, error) {
+	req, err := self.newRequest(method, url, params, false, common.GetTimepoint())
+	if err != nil {
+		return common.DryRunRequest{}, err
+	}
+	return common.NewDryRunRequest(method, req.URL, req.URL.Query()), nil
+}
+
 func (self *BinanceEndpoint) GetResponse(
 	method string, url string,
 	params map[string]string, signNeeded bool, timepoint uint64) ([]byte, error) {
@@ -60,18 +91,13 @@ func (self *BinanceEndpoint) GetResponse(
 	client := &http.Client{
 		Timeout: time.Duration(30 * time.Second),
 	}
-	req, err := http.NewRequest(method, url, nil)
+	req, err := self.newRequest(method, url, params, signNeeded, timepoint)
 	if err != nil {
 		return nil, err
 	}
-	req.Header.Add("Accept", "application/json")
-
-	q := req.URL.Query()
-	for k, v := range params {
-		q.Add(k, v)
-	}
-	req.URL.RawQuery = q.Encode()
-	self.fillRequest(req, signNeeded, timepoint)
+	defer func(start time.Time) {
+		instrument.ObserveExchangeRequest(self.name, req.URL.Path, start, err)
+	}(time.Now())
 
 	log.Printf("request to binance: %s\n", req.URL)
 	resp, err := client.Do(req)
@@ -113,12 +139,21 @@ func (self *BinanceEndpoint) GetResponse(
 }
 
 func (self *BinanceEndpoint) GetDepthOnePair(pair common.TokenPair) (exchange.Binaresp, error) {
+	return self.getDepth(pair, depthLimit)
+}
 
+// GetDepthSnapshot returns a deep order book snapshot of the pair, it is
+// used to resync the order book maintained from depth stream.
+func (self *BinanceEndpoint) GetDepthSnapshot(pair common.TokenPair) (exchange.Binaresp, error) {
+	return self.getDepth(pair, depthSnapshotLimit)
+}
+
+func (self *BinanceEndpoint) getDepth(pair common.TokenPair, limit int) (exchange.Binaresp, error) {
 	respBody, err := self.GetResponse(
 		"GET", self.interf.PublicEndpoint()+"/api/v1/depth",
 		map[string]string{
 			"symbol": fmt.Sprintf("%s%s", pair.Base.ID, pair.Quote.ID),
-			"limit":  "100",
+			"limit":  strconv.Itoa(limit),
 		},
 		false,
 		common.GetTimepoint(),
@@ -147,8 +182,7 @@ func (self *BinanceEndpoint) GetDepthOnePair(pair common.TokenPair) (exchange.Bi
 //
 // In this version, we only support LIMIT order which means only buy/sell with acceptable price,
 // and GTC time in force which means that the order will be active until it's implicitly canceled
-func (self *BinanceEndpoint) Trade(tradeType string, base, quote common.Token, rate, amount float64) (exchange.Binatrade, error) {
-	result := exchange.Binatrade{}
+func tradeParams(tradeType string, base, quote common.Token, rate, amount float64) map[string]string {
 	symbol := base.ID + quote.ID
 	orderType := "LIMIT"
 	params := map[string]string{
@@ -161,10 +195,15 @@ func (self *BinanceEndpoint) Trade(tradeType string, base, quote common.Token, r
 	if orderType == "LIMIT" {
 		params["price"] = strconv.FormatFloat(rate, 'f', -1, 64)
 	}
+	return params
+}
+
+func (self *BinanceEndpoint) Trade(tradeType string, base, quote common.Token, rate, amount float64) (exchange.Binatrade, error) {
+	result := exchange.Binatrade{}
 	respBody, err := self.GetResponse(
 		"POST",
 		self.interf.AuthenticatedEndpoint()+"/api/v3/order",
-		params,
+		tradeParams(tradeType, base, quote, rate, amount),
 		true,
 		common.GetTimepoint(),
 	)
@@ -175,6 +214,15 @@ func (self *BinanceEndpoint) Trade(tradeType string, base, quote common.Token, r
 	return result, err
 }
 
+// BuildTrade returns the signed request Trade would send.
+func (self *BinanceEndpoint) BuildTrade(tradeType string, base, quote common.Token, rate, amount float64) (common.DryRunRequest, error) {
+	return self.buildRequest(
+		"POST",
+		self.interf.AuthenticatedEndpoint()+"/api/v3/order",
+		tradeParams(tradeType, base, quote, rate, amount),
+	)
+}
+
 func (self *BinanceEndpoint) GetTradeHistory(symbol string) (exchange.BinanceTradeHistory, error) {
 	result := exchange.BinanceTradeHistory{}
 	timepoint := common.GetTimepoint()
@@ -314,17 +362,21 @@ func (self *BinanceEndpoint) OrderStatus(symbol string, id uint64) (exchange.Bin
 	return result, err
 }
 
+func withdrawParams(token common.Token, amount *big.Int, address ethereum.Address) map[string]string {
+	return map[string]string{
+		"asset":   token.ID,
+		"address": address.Hex(),
+		"name":    "reserve",
+		"amount":  strconv.FormatFloat(common.BigToFloat(amount, token.Decimals), 'f', -1, 64),
+	}
+}
+
 func (self *BinanceEndpoint) Withdraw(token common.Token, amount *big.Int, address ethereum.Address) (string, error) {
 	result := exchange.Binawithdraw{}
 	respBody, err := self.GetResponse(
 		"POST",
 		self.interf.AuthenticatedEndpoint()+"/wapi/v3/withdraw.html",
-		map[string]string{
-			"asset":   token.ID,
-			"address": address.Hex(),
-			"name":    "reserve",
-			"amount":  strconv.FormatFloat(common.BigToFloat(amount, token.Decimals), 'f', -1, 64),
-		},
+		withdrawParams(token, amount, address),
 		true,
 		common.GetTimepoint(),
 	)
@@ -340,6 +392,15 @@ func (self *BinanceEndpoint) Withdraw(token common.Token, amount *big.Int, addre
 	return "", fmt.Errorf("withdraw rejected by Binnace: %s", common.ErrorToString(err))
 }
 
+// BuildWithdraw returns the signed request Withdraw would send.
+func (self *BinanceEndpoint) BuildWithdraw(token common.Token, amount *big.Int, address ethereum.Address) (common.DryRunRequest, error) {
+	return self.buildRequest(
+		"POST",
+		self.interf.AuthenticatedEndpoint()+"/wapi/v3/withdraw.html",
+		withdrawParams(token, amount, address),
+	)
+}
+
 func (self *BinanceEndpoint) GetInfo() (exchange.Binainfo, error) {
 	result := exchange.Binainfo{}
 	respBody, err := self.GetResponse(
@@ -403,6 +464,72 @@ func (self *BinanceEndpoint) GetDepositAddress(asset string) (exchange.Binadepos
 	return result, err
 }
 
+// GetSubAccountAssets returns the balances of the sub-account of the email,
+// the API key must be of the master account.
+func (self *BinanceEndpoint) GetSubAccountAssets(email string) (exchange.BinanceSubAccountAssets, error) {
+	result := exchange.BinanceSubAccountAssets{}
+	respBody, err := self.GetResponse(
+		"GET",
+		self.interf.AuthenticatedEndpoint()+"/sapi/v3/sub-account/assets",
+		map[string]string{
+			"email": email,
+		},
+		true,
+		common.GetTimepoint(),
+	)
+	if err == nil {
+		err = json.Unmarshal(respBody, &result)
+	}
+	return result, err
+}
+
+// SubAccountTransfer transfers token between the spot wallets of
+// sub-accounts, the master account is given by an empty email.
+func (self *BinanceEndpoint) SubAccountTransfer(fromEmail, toEmail string, token common.Token, amount *big.Int) (exchange.BinanceSubAccountTransfer, error) {
+	result := exchange.BinanceSubAccountTransfer{}
+	params := map[string]string{
+		"fromAccountType": "SPOT",
+		"toAccountType":   "SPOT",
+		"asset":           token.ID,
+		"amount":          strconv.FormatFloat(common.BigToFloat(amount, token.Decimals), 'f', -1, 64),
+	}
+	if fromEmail != "" {
+		params["fromEmail"] = fromEmail
+	}
+	if toEmail != "" {
+		params["toEmail"] = toEmail
+	}
+	respBody, err := self.GetResponse(
+		"POST",
+		self.interf.AuthenticatedEndpoint()+"/sapi/v1/sub-account/universalTransfer",
+		params,
+		true,
+		common.GetTimepoint(),
+	)
+	if err != nil {
+		return result, fmt.Errorf("transfer rejected by Binance: %s", err.Error())
+	}
+	err = json.Unmarshal(respBody, &result)
+	return result, err
+}
+
+func (self *BinanceEndpoint) SubAccountTransferHistory(tranID int64) (exchange.BinanceSubAccountTransferHistory, error) {
+	result := exchange.BinanceSubAccountTransferHistory{}
+	respBody, err := self.GetResponse(
+		"GET",
+		self.interf.AuthenticatedEndpoint()+"/sapi/v1/sub-account/universalTransfer",
+		map[string]string{
+			"tranId": strconv.FormatInt(tranID, 10),
+		},
+		true,
+		common.GetTimepoint(),
+	)
+	if err == nil {
+		err = json.Unmarshal(respBody, &result)
+	}
+	return result, err
+}
+
 func (self *BinanceEndpoint) GetExchangeInfo() (exchange.BinanceExchangeInfo, error) {
 	result := exchange.BinanceExchangeInfo{}
 	respBody, err := self.GetResponse(
@@ -418,6 +545,37 @@ func (self *BinanceEndpoint) GetExchangeInfo() (exchange.BinanceExchangeInfo, er
 	return result, err
 }
 
+// GetAssetDetail returns the withdrawal fees and limits of all assets.
+func (self *BinanceEndpoint) GetAssetDetail() (exchange.BinanceAssetDetails, error) {
+	result := exchange.BinanceAssetDetails{}
+	respBody, err := self.GetResponse(
+		"GET",
+		self.interf.AuthenticatedEndpoint()+"/sapi/v1/asset/assetDetail",
+		map[string]string{},
+		true,
+		common.GetTimepoint(),
+	)
+	if err == nil {
+		err = json.Unmarshal(respBody, &result)
+	}
+	return result, err
+}
+
+func (self *BinanceEndpoint) GetSystemStatus() (exchange.BinanceSystemStatus, error) {
+	result := exchange.BinanceSystemStatus{}
+	respBody, err := self.GetResponse(
+		"GET",
+		self.interf.PublicEndpoint()+"/sapi/v1/system/status",
+		map[string]string{},
+		false,
+		common.GetTimepoint(),
+	)
+	if err == nil {
+		err = json.Unmarshal(respBody, &result)
+	}
+	return result, err
+}
+
 func (self *BinanceEndpoint) getServerTime() (uint64, error) {
 	result := exchange.BinaServerTime{}
 	respBody, err := self.GetResponse(
@@ -450,9 +608,23 @@ func (self *BinanceEndpoint) UpdateTimeDelta() error {
 	return nil
 }
 
+// EndpointOption is the option of NewBinanceEndpoint.
+type EndpointOption func(b *BinanceEndpoint)
+
+// WithEndpointName setups the endpoint to label its request metrics with the
+// given exchange ID instead of "binance", eg: of a sub-account.
+func WithEndpointName(name string) EndpointOption {
+	return func(b *BinanceEndpoint) {
+		b.name = name
+	}
+}
+
 //NewBinanceEndpoint return new endpoint instance for using binance
-func NewBinanceEndpoint(signer Signer, interf Interface) *BinanceEndpoint {
-	endpoint := &BinanceEndpoint{signer, interf, 0}
+func NewBinanceEndpoint(signer Signer, interf Interface, options ...EndpointOption) *BinanceEndpoint {
+	endpoint := &BinanceEndpoint{signer: signer, interf: interf, name: "binance"}
+	for _, option := range options {
+		option(endpoint)
+	}
 	switch interf.(type) {
 	case *SimulatedInterface:
 		log.Println("Simulate environment, no updateTime called...")
@@ -0,0 +1,135 @@
+package binance
+
+import (
+	"encoding/json"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/KyberNetwork/reserve-data/common"
+	"github.com/KyberNetwork/reserve-data/exchange"
+	"github.com/KyberNetwork/reserve-data/exchange/orderbook"
+	"golang.org/x/net/websocket"
+)
+
+const (
+	// StreamEndpoint is the Binance websocket market streams endpoint.
+	StreamEndpoint = "wss://stream.binance.com:9443"
+
+	// depthLimit is the number of levels of each side returned as price data.
+	depthLimit = 100
+	// depthSnapshotLimit is the number of levels of each side fetched to
+	// resync a streamed book, it is deeper than depthLimit so the top levels
+	// stay correct while the book is moving.
+	depthSnapshotLimit = 1000
+)
+
+// DepthSnapshotter returns the order book snapshot of a pair from REST api.
+type DepthSnapshotter interface {
+	GetDepthSnapshot(pair common.TokenPair) (exchange.Binaresp, error)
+}
+
+// depthEvent is a diff depth event received from Binance combined streams.
+type depthEvent struct {
+	Stream string `json:"stream"`
+	Data   struct {
+		Symbol        string               `json:"s"`
+		FirstUpdateID uint64               `json:"U"`
+		FinalUpdateID uint64               `json:"u"`
+		Bids          []exchange.Binaprice `json:"b"`
+		Asks          []exchange.Binaprice `json:"a"`
+	} `json:"data"`
+}
+
+// DepthStreamHandler maintains the books from Binance diff depth streams.
+// A book is resynced from REST snapshot on its first event and whenever an
+// event is missed, following the Binance guideline of managing a local
+// order book.
+type DepthStreamHandler struct {
+	endpoint    string
+	snapshotter DepthSnapshotter
+}
+
+// NewDepthStreamHandler creates a handler connecting to the given streams
+// endpoint and fetching snapshots from snapshotter.
+func NewDepthStreamHandler(endpoint string, snapshotter DepthSnapshotter) *DepthStreamHandler {
+	return &DepthStreamHandler{
+		endpoint:    endpoint,
+		snapshotter: snapshotter,
+	}
+}
+
+// NewDepthStream creates the order book stream of Binance.
+func NewDepthStream(endpoint string, snapshotter DepthSnapshotter, options ...orderbook.StreamOption) *orderbook.Stream {
+	options = append([]orderbook.StreamOption{orderbook.WithLimit(depthLimit)}, options...)
+	return orderbook.NewStream("Binance", NewDepthStreamHandler(endpoint, snapshotter), options...)
+}
+
+// Endpoint returns the combined streams url of the pairs.
+func (self *DepthStreamHandler) Endpoint(pairs []common.TokenPair) string {
+	streams := []string{}
+	for _, pair := range pairs {
+		streams = append(streams, self.Symbol(pair)+"@depth")
+	}
+	return self.endpoint + "/stream?streams=" + strings.Join(streams, "/")
+}
+
+// Symbol returns the lower case symbol of the pair.
+func (self *DepthStreamHandler) Symbol(pair common.TokenPair) string {
+	return strings.ToLower(pair.Base.ID + pair.Quote.ID)
+}
+
+// Subscribe does nothing as streams are subscribed by the connection url.
+func (self *DepthStreamHandler) Subscribe(conn *websocket.Conn, pairs []common.TokenPair) error {
+	return nil
+}
+
+// Handle applies a diff depth event to its book.
+func (self *DepthStreamHandler) Handle(conn *websocket.Conn, msg []byte, books map[string]*orderbook.Book) error {
+	var event depthEvent
+	if err := json.Unmarshal(msg, &event); err != nil {
+		return err
+	}
+	book, ok := books[strings.ToLower(event.Data.Symbol)]
+	if !ok {
+		return nil
+	}
+	if !book.Synced() {
+		if err := self.resync(book); err != nil {
+			log.Printf("Binance depth stream: resync %s failed: %s", event.Data.Symbol, err)
+			return nil
+		}
+	}
+	lastUpdateID := book.LastUpdateID()
+	if event.Data.FinalUpdateID <= lastUpdateID {
+		// the event is already included in the book
+		return nil
+	}
+	if event.Data.FirstUpdateID > lastUpdateID+1 {
+		log.Printf("Binance depth stream: %s missed updates from %d to %d, resyncing", event.Data.Symbol, lastUpdateID+1, event.Data.FirstUpdateID-1)
+		book.Invalidate()
+		return nil
+	}
+	book.Update(toPriceEntries(event.Data.Bids), toPriceEntries(event.Data.Asks), event.Data.FinalUpdateID, time.Now())
+	return nil
+}
+
+func (self *DepthStreamHandler) resync(book *orderbook.Book) error {
+	snapshot, err := self.snapshotter.GetDepthSnapshot(book.Pair())
+	if err != nil {
+		return err
+	}
+	book.Reset(toPriceEntries(snapshot.Bids), toPriceEntries(snapshot.Asks), uint64(snapshot.LastUpdatedId), time.Now())
+	return nil
+}
+
+func toPriceEntries(prices []exchange.Binaprice) []common.PriceEntry {
+	result := make([]common.PriceEntry, 0, len(prices))
+	for _, price := range prices {
+		quantity, _ := strconv.ParseFloat(price.Quantity, 64)
+		rate, _ := strconv.ParseFloat(price.Rate, 64)
+		result = append(result, common.NewPriceEntry(quantity, rate))
+	}
+	return result
+}
@@ -0,0 +1,191 @@
+package binance
+
+import (
+	"fmt"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/KyberNetwork/reserve-data/common"
+	"github.com/KyberNetwork/reserve-data/exchange"
+	"github.com/KyberNetwork/reserve-data/exchange/orderbook"
+	"golang.org/x/net/websocket"
+)
+
+type fakeSnapshotter struct {
+	mu        sync.Mutex
+	snapshots []exchange.Binaresp
+	calls     int
+}
+
+func (self *fakeSnapshotter) GetDepthSnapshot(pair common.TokenPair) (exchange.Binaresp, error) {
+	self.mu.Lock()
+	defer self.mu.Unlock()
+	snapshot := self.snapshots[self.calls]
+	self.calls++
+	return snapshot, nil
+}
+
+func (self *fakeSnapshotter) count() int {
+	self.mu.Lock()
+	defer self.mu.Unlock()
+	return self.calls
+}
+
+func depthEventMsg(first, final uint64, bids, asks string) string {
+	return fmt.Sprintf(
+		`{"stream":"kncbtc@depth","data":{"e":"depthUpdate","s":"KNCBTC","U":%d,"u":%d,"b":%s,"a":%s}}`,
+		first, final, bids, asks,
+	)
+}
+
+// newFakeStreamServer starts a websocket server sending the given messages
+// to each connection and keeping it open.
+func newFakeStreamServer(t *testing.T, messages []string) (*httptest.Server, <-chan string) {
+	requested := make(chan string, 10)
+	server := httptest.NewServer(websocket.Handler(func(conn *websocket.Conn) {
+		requested <- conn.Request().URL.String()
+		for _, msg := range messages {
+			if err := websocket.Message.Send(conn, msg); err != nil {
+				t.Error(err)
+				return
+			}
+		}
+		var discard []byte
+		// blocks until the client closes the connection
+		_ = websocket.Message.Receive(conn, &discard)
+	}))
+	return server, requested
+}
+
+func waitForDepth(t *testing.T, stream *orderbook.Stream, pair common.TokenPair, check func(bids, asks []common.PriceEntry) bool) {
+	t.Helper()
+	timeout := time.After(2 * time.Second)
+	for {
+		bids, asks, _, err := stream.GetDepth(pair)
+		if err == nil && check(bids, asks) {
+			return
+		}
+		select {
+		case <-timeout:
+			t.Fatalf("order book is not as expected, bids: %v, asks: %v, err: %v", bids, asks, err)
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+}
+
+func testPair() common.TokenPair {
+	return common.TokenPair{Base: common.Token{ID: "KNC"}, Quote: common.Token{ID: "BTC"}}
+}
+
+func TestDepthStreamAppliesDiffAfterSnapshot(t *testing.T) {
+	server, requested := newFakeStreamServer(t, []string{
+		// already included in snapshot
+		depthEventMsg(5, 10, `[["0.0009","50"]]`, `[]`),
+		depthEventMsg(10, 12, `[["0.001","0"],["0.0008","3"]]`, `[["0.0012","7"]]`),
+		depthEventMsg(13, 13, `[]`, `[["0.0011","0"]]`),
+	})
+	defer server.Close()
+	snapshotter := &fakeSnapshotter{snapshots: []exchange.Binaresp{{
+		LastUpdatedId: 10,
+		Bids:          []exchange.Binaprice{{Rate: "0.001", Quantity: "1"}, {Rate: "0.0009", Quantity: "2"}},
+		Asks:          []exchange.Binaprice{{Rate: "0.0011", Quantity: "4"}},
+	}}}
+	stream := NewDepthStream("ws"+strings.TrimPrefix(server.URL, "http"), snapshotter,
+		orderbook.WithReconnectDuration(10*time.Millisecond))
+	defer stream.Stop()
+
+	pair := testPair()
+	stream.Watch([]common.TokenPair{pair})
+	if url := <-requested; url != "/stream?streams=kncbtc@depth" {
+		t.Errorf("unexpected stream url: %s", url)
+	}
+	waitForDepth(t, stream, pair, func(bids, asks []common.PriceEntry) bool {
+		return len(bids) == 2 && bids[0] == common.NewPriceEntry(2, 0.0009) && bids[1] == common.NewPriceEntry(3, 0.0008) &&
+			len(asks) == 1 && asks[0] == common.NewPriceEntry(7, 0.0012)
+	})
+}
+
+func TestDepthStreamResyncOnMissedEvent(t *testing.T) {
+	server, _ := newFakeStreamServer(t, []string{
+		depthEventMsg(11, 12, `[["0.001","5"]]`, `[]`),
+		// update 13 is missed
+		depthEventMsg(14, 15, `[["0.001","6"]]`, `[]`),
+		depthEventMsg(21, 22, `[["0.001","8"]]`, `[]`),
+	})
+	defer server.Close()
+	snapshotter := &fakeSnapshotter{snapshots: []exchange.Binaresp{
+		{LastUpdatedId: 10, Bids: []exchange.Binaprice{{Rate: "0.001", Quantity: "1"}}},
+		{LastUpdatedId: 20, Bids: []exchange.Binaprice{{Rate: "0.001", Quantity: "7"}}},
+	}}
+	stream := NewDepthStream("ws"+strings.TrimPrefix(server.URL, "http"), snapshotter)
+	defer stream.Stop()
+
+	pair := testPair()
+	stream.Watch([]common.TokenPair{pair})
+	waitForDepth(t, stream, pair, func(bids, asks []common.PriceEntry) bool {
+		return len(bids) == 1 && bids[0].Quantity == 8
+	})
+	if calls := snapshotter.count(); calls != 2 {
+		t.Errorf("expected 2 snapshots, got %d", calls)
+	}
+}
+
+func TestDepthStreamNotConnected(t *testing.T) {
+	stream := NewDepthStream("ws://127.0.0.1:1", &fakeSnapshotter{})
+	defer stream.Stop()
+	stream.Watch([]common.TokenPair{testPair()})
+	if _, _, _, err := stream.GetDepth(testPair()); err != orderbook.ErrNotConnected {
+		t.Errorf("expected not connected error, got %v", err)
+	}
+}
+
+func TestDepthStreamQuietPairFollowsConnection(t *testing.T) {
+	next := make(chan struct{})
+	server := httptest.NewServer(websocket.Handler(func(conn *websocket.Conn) {
+		if err := websocket.Message.Send(conn, depthEventMsg(11, 12, `[["0.001","5"]]`, `[]`)); err != nil {
+			t.Error(err)
+			return
+		}
+		<-next
+		// only the other pair is traded
+		msg := `{"stream":"omgbtc@depth","data":{"e":"depthUpdate","s":"OMGBTC","U":11,"u":12,"b":[],"a":[]}}`
+		if err := websocket.Message.Send(conn, msg); err != nil {
+			t.Error(err)
+			return
+		}
+		var discard []byte
+		// blocks until the client closes the connection
+		_ = websocket.Message.Receive(conn, &discard)
+	}))
+	defer server.Close()
+	snapshotter := &fakeSnapshotter{snapshots: []exchange.Binaresp{
+		{LastUpdatedId: 10, Bids: []exchange.Binaprice{{Rate: "0.001", Quantity: "1"}}},
+		{LastUpdatedId: 10},
+	}}
+	stream := NewDepthStream("ws"+strings.TrimPrefix(server.URL, "http"), snapshotter)
+	defer stream.Stop()
+
+	pair := testPair()
+	omg := common.TokenPair{Base: common.Token{ID: "OMG"}, Quote: common.Token{ID: "BTC"}}
+	stream.Watch([]common.TokenPair{pair, omg})
+	waitForDepth(t, stream, pair, func(bids, asks []common.PriceEntry) bool {
+		return len(bids) == 1 && bids[0].Quantity == 5
+	})
+	_, _, updated, err := stream.GetDepth(pair)
+	if err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(10 * time.Millisecond)
+	close(next)
+	waitForDepth(t, stream, omg, func(bids, asks []common.PriceEntry) bool { return true })
+	_, _, received, err := stream.GetDepth(pair)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !received.After(updated) {
+		t.Errorf("expected quiet book is kept alive by messages of the connection, updated %s, received %s", updated, received)
+	}
+}
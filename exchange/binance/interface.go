@@ -38,21 +38,37 @@ func NewRealInterface() *RealInterface {
 }
 
 type SimulatedInterface struct {
-	baseURL string
+	baseURL  string
+	endpoint string
 }
 
-func (self *SimulatedInterface) PublicEndpoint() string {
+// simulationURL returns the endpoint of the simulated Binance, or the default
+// simulator at base URL if the endpoint is not given.
+func (self *SimulatedInterface) simulationURL() string {
+	if len(self.endpoint) != 0 {
+		return self.endpoint
+	}
 	return getSimulationURL(self.baseURL)
 }
 
+func (self *SimulatedInterface) PublicEndpoint() string {
+	return self.simulationURL()
+}
+
 func (self *SimulatedInterface) AuthenticatedEndpoint() string {
-	return getSimulationURL(self.baseURL)
+	return self.simulationURL()
 }
 
 func NewSimulatedInterface(flagVariable string) *SimulatedInterface {
 	return &SimulatedInterface{baseURL: flagVariable}
 }
 
+// NewSimulatedEndpointInterface returns the interface of a simulated Binance
+// served at the given endpoint, eg: http://127.0.0.1:5101.
+func NewSimulatedEndpointInterface(endpoint string) *SimulatedInterface {
+	return &SimulatedInterface{endpoint: endpoint}
+}
+
 type RopstenInterface struct {
 	baseURL string
 }
@@ -225,3 +225,50 @@ type BinaAccountTradeHistory []struct {
 	IsBuyer bool   `json:"isBuyer"`
 	IsMaker bool   `json:"isMaker"`
 }
+
+// BinanceSubAccountAssets is the balances of a sub-account.
+type BinanceSubAccountAssets struct {
+	Balances []struct {
+		Asset  string  `json:"asset"`
+		Free   float64 `json:"free"`
+		Locked float64 `json:"locked"`
+	} `json:"balances"`
+}
+
+// BinanceSubAccountTransfer is the result of a transfer between sub-accounts.
+type BinanceSubAccountTransfer struct {
+	TranID int64 `json:"tranId"`
+}
+
+type BinanceSubAccountTransferRecord struct {
+	TranID          int64  `json:"tranId"`
+	FromEmail       string `json:"fromEmail"`
+	ToEmail         string `json:"toEmail"`
+	Asset           string `json:"asset"`
+	Amount          string `json:"amount"`
+	CreateTimeStamp uint64 `json:"createTimeStamp"`
+	Status          string `json:"status"`
+}
+
+type BinanceSubAccountTransferHistory struct {
+	Result     []BinanceSubAccountTransferRecord `json:"result"`
+	TotalCount int                               `json:"totalCount"`
+}
+
+// BinanceAssetDetail is the withdrawal fee and limits of an asset.
+type BinanceAssetDetail struct {
+	MinWithdrawAmount float64 `json:"minWithdrawAmount,string"`
+	DepositStatus     bool    `json:"depositStatus"`
+	WithdrawFee       float64 `json:"withdrawFee"`
+	WithdrawStatus    bool    `json:"withdrawStatus"`
+}
+
+// BinanceAssetDetails is the details of all assets by their names.
+type BinanceAssetDetails map[string]BinanceAssetDetail
+
+// BinanceSystemStatus is the status of Binance, 0 is normal and 1 is under
+// maintenance.
+type BinanceSystemStatus struct {
+	Status int    `json:"status"`
+	Msg    string `json:"msg"`
+}
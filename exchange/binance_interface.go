@@ -17,6 +17,11 @@ type BinanceInterface interface {
 
 	GetExchangeInfo() (BinanceExchangeInfo, error)
 
+	// GetAssetDetail returns the withdrawal fees and limits of all assets.
+	GetAssetDetail() (BinanceAssetDetails, error)
+
+	GetSystemStatus() (BinanceSystemStatus, error)
+
 	GetDepositAddress(tokenID string) (Binadepositaddress, error)
 
 	GetAccountTradeHistory(base, quote common.Token, fromID string) (BinaAccountTradeHistory, error)
@@ -31,6 +36,18 @@ type BinanceInterface interface {
 		base, quote common.Token,
 		rate, amount float64) (Binatrade, error)
 
+	// BuildWithdraw and BuildTrade return the unsigned requests of Withdraw
+	// and Trade without sending them.
+	BuildWithdraw(
+		token common.Token,
+		amount *big.Int,
+		address ethereum.Address) (common.DryRunRequest, error)
+
+	BuildTrade(
+		tradeType string,
+		base, quote common.Token,
+		rate, amount float64) (common.DryRunRequest, error)
+
 	CancelOrder(symbol string, id uint64) (Binacancel, error)
 
 	DepositHistory(startTime, endTime uint64) (Binadeposits, error)
@@ -38,4 +55,16 @@ type BinanceInterface interface {
 	WithdrawHistory(startTime, endTime uint64) (Binawithdrawals, error)
 
 	OrderStatus(symbol string, id uint64) (Binaorder, error)
+
+	// GetSubAccountAssets returns the balances of the sub-account of the email.
+	GetSubAccountAssets(email string) (BinanceSubAccountAssets, error)
+
+	// SubAccountTransfer transfers token between sub-accounts of their
+	// emails, an empty email is the master account.
+	SubAccountTransfer(
+		fromEmail, toEmail string,
+		token common.Token,
+		amount *big.Int) (BinanceSubAccountTransfer, error)
+
+	SubAccountTransferHistory(tranID int64) (BinanceSubAccountTransferHistory, error)
 }
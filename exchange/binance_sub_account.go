@@ -0,0 +1,99 @@
+package exchange
+
+import (
+	"fmt"
+	"log"
+	"math/big"
+	"strconv"
+
+	"github.com/KyberNetwork/reserve-data/common"
+)
+
+// isSubAccount returns true if the email is of the master account, given by
+// an empty email, or one of the sub-accounts.
+func (self *Binance) isSubAccount(email string) bool {
+	if email == "" {
+		return true
+	}
+	for _, subAccount := range self.subAccounts {
+		if subAccount == email {
+			return true
+		}
+	}
+	return false
+}
+
+// fetchSubAccountBalances returns the balances of the sub-accounts, nil if
+// there is none. Failing to fetch a sub-account only invalidates its balances.
+func (self *Binance) fetchSubAccountBalances() map[string]common.SubAccountBalance {
+	if len(self.subAccounts) == 0 {
+		return nil
+	}
+	result := make(map[string]common.SubAccountBalance)
+	for _, email := range self.subAccounts {
+		balance := common.SubAccountBalance{Valid: true}
+		assets, err := self.interf.GetSubAccountAssets(email)
+		if err != nil {
+			log.Printf("Binance cannot get balances of sub-account %s: %s", email, err.Error())
+			balance.Valid = false
+			balance.Error = err.Error()
+			result[email] = balance
+			continue
+		}
+		balance.AvailableBalance = map[string]float64{}
+		balance.LockedBalance = map[string]float64{}
+		for _, b := range assets.Balances {
+			if _, err := self.setting.GetTokenByID(b.Asset); err != nil {
+				continue
+			}
+			balance.AvailableBalance[b.Asset] = b.Free
+			balance.LockedBalance[b.Asset] = b.Locked
+		}
+		result[email] = balance
+	}
+	return result
+}
+
+// TransferSubAccount transfers amount of token from a sub-account to another
+// one, the master account is given by an empty email. It returns the ID of
+// the transfer.
+func (self *Binance) TransferSubAccount(token common.Token, amount *big.Int, from, to string, timepoint uint64) (string, error) {
+	if !self.isSubAccount(from) {
+		return "", fmt.Errorf("%s is not a sub-account of %s", from, self.ID())
+	}
+	if !self.isSubAccount(to) {
+		return "", fmt.Errorf("%s is not a sub-account of %s", to, self.ID())
+	}
+	result, err := self.interf.SubAccountTransfer(from, to, token, amount)
+	if err != nil {
+		return "", err
+	}
+	return strconv.FormatInt(result.TranID, 10), nil
+}
+
+// TransferStatus returns the exchange status of the transfer of the ID.
+func (self *Binance) TransferStatus(id string, timepoint uint64) (string, error) {
+	tranID, err := strconv.ParseInt(id, 10, 64)
+	if err != nil {
+		return "", fmt.Errorf("Can not parse transfer ID (val %s) to int", id)
+	}
+	history, err := self.interf.SubAccountTransferHistory(tranID)
+	if err != nil {
+		return "", err
+	}
+	for _, transfer := range history.Result {
+		if transfer.TranID != tranID {
+			continue
+		}
+		switch transfer.Status {
+		case "SUCCESS":
+			return common.ExchangeStatusDone, nil
+		case "FAILURE":
+			return common.ExchangeStatusFailed, nil
+		default:
+			return "", nil
+		}
+	}
+	log.Printf("Binance transfer %s is not found in transfer list returned from Binance.", id)
+	return "", nil
+}
@@ -0,0 +1,81 @@
+package exchange
+
+import (
+	"encoding/json"
+	"math/big"
+	"testing"
+
+	"github.com/KyberNetwork/reserve-data/common"
+	"github.com/KyberNetwork/reserve-data/settings"
+)
+
+type testBinanceSubAccountInterface struct {
+	BinanceInterface
+	transfers []BinanceSubAccountTransferRecord
+}
+
+func (self *testBinanceSubAccountInterface) GetSubAccountAssets(email string) (BinanceSubAccountAssets, error) {
+	result := BinanceSubAccountAssets{}
+	err := json.Unmarshal([]byte(`{"balances":[{"asset":"KNC","free":100.5,"locked":20},{"asset":"UNKNOWN","free":1,"locked":0}]}`), &result)
+	return result, err
+}
+
+func (self *testBinanceSubAccountInterface) SubAccountTransfer(fromEmail, toEmail string, token common.Token, amount *big.Int) (BinanceSubAccountTransfer, error) {
+	tranID := int64(11945860693 + len(self.transfers))
+	self.transfers = append(self.transfers, BinanceSubAccountTransferRecord{
+		TranID:    tranID,
+		FromEmail: fromEmail,
+		ToEmail:   toEmail,
+		Asset:     token.ID,
+		Status:    "PROCESS",
+	})
+	return BinanceSubAccountTransfer{TranID: tranID}, nil
+}
+
+func (self *testBinanceSubAccountInterface) SubAccountTransferHistory(tranID int64) (BinanceSubAccountTransferHistory, error) {
+	return BinanceSubAccountTransferHistory{Result: self.transfers, TotalCount: len(self.transfers)}, nil
+}
+
+func TestBinanceSubAccounts(t *testing.T) {
+	setting := getTestBittrex("", false).setting
+	knc := common.NewToken("KNC", "Kyber Network", "0xdd974d5c2e2928dea5f71b9825b8b646686bd200", 18, true, true, 0)
+	if err := setting.(*settings.Settings).UpdateToken(knc, 0); err != nil {
+		t.Fatal(err)
+	}
+	interf := &testBinanceSubAccountInterface{}
+	binance := &Binance{
+		interf:      interf,
+		setting:     setting,
+		subAccounts: []string{"market-making@example.com"},
+	}
+
+	balances := binance.fetchSubAccountBalances()
+	balance, ok := balances["market-making@example.com"]
+	if !ok || !balance.Valid {
+		t.Fatalf("Expected valid balances of the sub-account, got %+v", balances)
+	}
+	if balance.AvailableBalance["KNC"] != 100.5 || balance.LockedBalance["KNC"] != 20 {
+		t.Errorf("Got wrong KNC balance of the sub-account: %+v", balance)
+	}
+	if _, ok := balance.AvailableBalance["UNKNOWN"]; ok {
+		t.Error("Expected balance of unknown token to be ignored")
+	}
+
+	amount := common.FloatToBigInt(10, 18)
+	if _, err := binance.TransferSubAccount(knc, amount, "", "unknown@example.com", 0); err == nil {
+		t.Error("Expected error transferring to unknown sub-account")
+	}
+	id, err := binance.TransferSubAccount(knc, amount, "", "market-making@example.com", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	status, err := binance.TransferStatus(id, 0)
+	if err != nil || status != "" {
+		t.Errorf("Expected processing transfer to be pending, got %q, err: %v", status, err)
+	}
+	interf.transfers[0].Status = "SUCCESS"
+	status, err = binance.TransferStatus(id, 0)
+	if err != nil || status != common.ExchangeStatusDone {
+		t.Errorf("Expected transfer done, got %q, err: %v", status, err)
+	}
+}
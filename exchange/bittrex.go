@@ -18,13 +18,14 @@ import (
 const bittrexEpsilon float64 = 0.000001
 
 type Bittrex struct {
+	name    settings.ExchangeName
 	interf  BittrexInterface
 	storage BittrexStorage
 	setting Setting
 }
 
 func (self *Bittrex) TokenAddresses() (map[string]ethereum.Address, error) {
-	addrs, err := self.setting.GetDepositAddresses(settings.Bittrex)
+	addrs, err := self.setting.GetDepositAddresses(self.name)
 	if err != nil {
 		return nil, err
 	}
@@ -41,7 +42,7 @@ func (self *Bittrex) Address(token common.Token) (ethereum.Address, bool) {
 	liveAddress, err := self.interf.GetDepositAddress(token.ID)
 	if err != nil || liveAddress.Result.Address == "" {
 		log.Printf("WARNING: Get Bittrex live deposit address for token %s failed: err: (%v) or the address repplied is empty . Use the currently available address instead", token.ID, err)
-		addrs, uErr := self.setting.GetDepositAddresses(settings.Bittrex)
+		addrs, uErr := self.setting.GetDepositAddresses(self.name)
 		if uErr != nil {
 			log.Printf("WARNING: get address of token %s in Bittrex exchange failed:(%s), it will be considered as not supported", token.ID, err.Error())
 			return ethereum.Address{}, false
@@ -51,18 +52,56 @@ func (self *Bittrex) Address(token common.Token) (ethereum.Address, bool) {
 	log.Printf("Got Bittrex live deposit address for token %s, attempt to update it to current setting", token.ID)
 	addrs := common.NewExchangeAddresses()
 	addrs.Update(token.ID, ethereum.HexToAddress(liveAddress.Result.Address))
-	if err = self.setting.UpdateDepositAddress(settings.Bittrex, *addrs, common.GetTimepoint()); err != nil {
+	if err = self.setting.UpdateDepositAddress(self.name, *addrs, common.GetTimepoint()); err != nil {
 		log.Printf("WARNING: can not update deposit address for token %s on Bittrex: (%s)", token.ID, err.Error())
 	}
 	return ethereum.HexToAddress(liveAddress.Result.Address), true
 }
 
 func (self *Bittrex) GetFee() (common.ExchangeFees, error) {
-	return self.setting.GetFee(settings.Bittrex)
+	return self.setting.GetFee(self.name)
 }
 
 func (self *Bittrex) GetMinDeposit() (common.ExchangesMinDeposit, error) {
-	return self.setting.GetMinDeposit(settings.Bittrex)
+	return self.setting.GetMinDeposit(self.name)
+}
+
+// FetchLiveFees returns the withdrawal fees of known tokens from Bittrex
+// currencies. Bittrex reports neither trading fees nor min deposits.
+func (self *Bittrex) FetchLiveFees() (common.ExchangeFees, common.ExchangesMinDeposit, error) {
+	fee := common.NewExchangeFee(common.TradingFee{}, common.NewFundingFee(map[string]float64{}, map[string]float64{}))
+	currencies, err := self.interf.GetCurrencies()
+	if err != nil {
+		return fee, nil, err
+	}
+	for _, currency := range currencies.Result {
+		if _, err := self.setting.GetTokenByID(currency.Currency); err != nil {
+			continue
+		}
+		fee.Funding.Withdraw[currency.Currency] = currency.TxFee
+	}
+	return fee, common.ExchangesMinDeposit{}, nil
+}
+
+// SystemStatus returns the deposit and withdrawal statuses of known tokens,
+// both are disabled for inactive currencies. Bittrex doesn't report
+// maintenance.
+func (self *Bittrex) SystemStatus() (common.ExchangeSystemStatus, error) {
+	result := common.ExchangeSystemStatus{Wallets: map[string]common.TokenWalletStatus{}}
+	currencies, err := self.interf.GetCurrencies()
+	if err != nil {
+		return result, err
+	}
+	for _, currency := range currencies.Result {
+		if _, err := self.setting.GetTokenByID(currency.Currency); err != nil {
+			continue
+		}
+		result.Wallets[currency.Currency] = common.TokenWalletStatus{
+			DepositEnabled:  currency.IsActive,
+			WithdrawEnabled: currency.IsActive,
+		}
+	}
+	return result, nil
 }
 
 func (self *Bittrex) UpdateDepositAddress(token common.Token, address string) error {
@@ -71,12 +110,12 @@ func (self *Bittrex) UpdateDepositAddress(token common.Token, address string) er
 		log.Printf("WARNING: Get Bittrex live deposit address for token %s failed: err: (%v) or the address repplied is empty . Use the currently available address instead", token.ID, err)
 		addrs := common.NewExchangeAddresses()
 		addrs.Update(token.ID, ethereum.HexToAddress(address))
-		return self.setting.UpdateDepositAddress(settings.Bittrex, *addrs, common.GetTimepoint())
+		return self.setting.UpdateDepositAddress(self.name, *addrs, common.GetTimepoint())
 	}
 	log.Printf("Got Bittrex live deposit address for token %s, attempt to update it to current setting", token.ID)
 	addrs := common.NewExchangeAddresses()
 	addrs.Update(token.ID, ethereum.HexToAddress(liveAddress.Result.Address))
-	return self.setting.UpdateDepositAddress(settings.Bittrex, *addrs, common.GetTimepoint())
+	return self.setting.UpdateDepositAddress(self.name, *addrs, common.GetTimepoint())
 }
 
 // GetLiveExchangeInfos querry the Exchange Endpoint for exchange precision and limit of a list of tokenPairIDs
@@ -138,11 +177,11 @@ func (self *Bittrex) UpdatePairsPrecision() error {
 		}
 		exInfo[pair] = exchangePrecisionLimit
 	}
-	return self.setting.UpdateExchangeInfo(settings.Bittrex, exInfo, common.GetTimepoint())
+	return self.setting.UpdateExchangeInfo(self.name, exInfo, common.GetTimepoint())
 }
 
 func (self *Bittrex) GetExchangeInfo(pair common.TokenPairID) (common.ExchangePrecisionLimit, error) {
-	exInfo, err := self.setting.GetExchangeInfo(settings.Bittrex)
+	exInfo, err := self.setting.GetExchangeInfo(self.name)
 	if err != nil {
 		return common.ExchangePrecisionLimit{}, err
 	}
@@ -150,17 +189,17 @@ func (self *Bittrex) GetExchangeInfo(pair common.TokenPairID) (common.ExchangePr
 }
 
 func (self *Bittrex) GetInfo() (common.ExchangeInfo, error) {
-	return self.setting.GetExchangeInfo(settings.Bittrex)
+	return self.setting.GetExchangeInfo(self.name)
 }
 
 // ID must return the exact string or else simulation will fail
 func (self *Bittrex) ID() common.ExchangeID {
-	return common.ExchangeID(settings.Bittrex.String())
+	return common.ExchangeID(self.name)
 }
 
 func (self *Bittrex) TokenPairs() ([]common.TokenPair, error) {
 	result := []common.TokenPair{}
-	exInfo, err := self.setting.GetExchangeInfo(settings.Bittrex)
+	exInfo, err := self.setting.GetExchangeInfo(self.name)
 	if err != nil {
 		return nil, err
 	}
@@ -226,6 +265,16 @@ func (self *Bittrex) Withdraw(token common.Token, amount *big.Int, address ether
 	return "", errors.New(resp.Error)
 }
 
+// BuildTradeRequest returns the unsigned request Trade would send.
+func (self *Bittrex) BuildTradeRequest(tradeType string, base, quote common.Token, rate, amount float64) (common.DryRunRequest, error) {
+	return self.interf.BuildTrade(tradeType, base, quote, rate, amount)
+}
+
+// BuildWithdrawRequest returns the unsigned request Withdraw would send.
+func (self *Bittrex) BuildWithdrawRequest(token common.Token, amount *big.Int, address ethereum.Address) (common.DryRunRequest, error) {
+	return self.interf.BuildWithdraw(token, amount, address)
+}
+
 func bittrexTimestampToUint64(input string) (uint64, error) {
 	var t time.Time
 	var err error
@@ -331,6 +380,16 @@ func (self *Bittrex) OrderStatus(uuid string, base, quote string) (string, error
 	return common.ExchangeStatusDone, nil
 }
 
+// OrderFill returns the filled and remaining amounts of the order.
+func (self *Bittrex) OrderFill(uuid string, base, quote string) (float64, float64, bool, error) {
+	resp_data, err := self.interf.OrderStatus(uuid)
+	if err != nil {
+		return 0, 0, false, err
+	}
+	result := resp_data.Result
+	return result.Quantity - result.QuantityRemaining, result.QuantityRemaining, !result.IsOpen, nil
+}
+
 func (self *Bittrex) FetchOnePairData(wq *sync.WaitGroup, pair common.TokenPair, data *sync.Map, timepoint uint64) {
 	defer wq.Done()
 	result := common.ExchangePrice{}
@@ -428,7 +487,7 @@ func (self *Bittrex) FetchEBalanceData(timepoint uint64) (common.EBalanceEntry,
 			// check if bittrex returned balance for all of the
 			// supported token.
 			// If it didn't, it is considered invalid
-			depositAddresses, err := self.setting.GetDepositAddresses(settings.Bittrex)
+			depositAddresses, err := self.setting.GetDepositAddresses(self.name)
 			if err != nil {
 				return result, fmt.Errorf("Can't Get deposit addresses of Bittrex for validation (%s)", err)
 			}
@@ -528,14 +587,30 @@ func (self *Bittrex) GetTradeHistory(fromTime, toTime uint64) (common.ExchangeTr
 	return self.storage.GetTradeHistory(fromTime, toTime)
 }
 
+// BittrexOption is the option to setup the Bittrex exchange on creation.
+type BittrexOption func(b *Bittrex)
+
+// WithBittrexName setups Bittrex to run as the exchange account of the given
+// name instead of the default one.
+func WithBittrexName(name settings.ExchangeName) BittrexOption {
+	return func(b *Bittrex) {
+		b.name = name
+	}
+}
+
 func NewBittrex(
 	interf BittrexInterface,
 	storage BittrexStorage,
-	setting Setting) (*Bittrex, error) {
+	setting Setting,
+	options ...BittrexOption) (*Bittrex, error) {
 	bittrex := &Bittrex{
-		interf,
-		storage,
-		setting,
+		name:    settings.Bittrex,
+		interf:  interf,
+		storage: storage,
+		setting: setting,
+	}
+	for _, option := range options {
+		option(bittrex)
 	}
 	bittrex.FetchTradeHistory()
 	return bittrex, nil
@@ -13,6 +13,7 @@ import (
 	"time"
 
 	"github.com/KyberNetwork/reserve-data/common"
+	"github.com/KyberNetwork/reserve-data/common/instrument"
 	"github.com/KyberNetwork/reserve-data/exchange"
 	ethereum "github.com/ethereum/go-ethereum/common"
 )
@@ -20,6 +21,8 @@ import (
 type BittrexEndpoint struct {
 	signer Signer
 	interf Interface
+	// name is the exchange ID labelling the request metrics.
+	name string
 }
 
 func nonce() string {
@@ -51,14 +54,11 @@ func (self *BittrexEndpoint) fillRequest(req *http.Request, signNeeded bool) {
 	}
 }
 
-func (self *BittrexEndpoint) GetResponse(
-	url string, params map[string]string, signNeeded bool) ([]byte, error) {
-	client := &http.Client{
-		Timeout: time.Duration(30 * time.Second),
-	}
-	req, newHTTPErr := http.NewRequest("GET", url, nil)
-	if newHTTPErr != nil {
-		return nil, newHTTPErr
+// newRequest creates the request to url with the params, signed if needed.
+func (self *BittrexEndpoint) newRequest(url string, params map[string]string, signNeeded bool) (*http.Request, error) {
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return nil, err
 	}
 	req.Header.Add("Accept", "application/json")
 
@@ -68,8 +68,33 @@ func (self *BittrexEndpoint) GetResponse(
 	}
 	req.URL.RawQuery = q.Encode()
 	self.fillRequest(req, signNeeded)
+	return req, nil
+}
+
+// buildRequest creates the unsigned request to url with the params without
+// sending it.
+func (self *BittrexEndpoint) buildRequest(url string, params map[string]string) (common.DryRunRequest, error) {
+	req, err := self.newRequest(url, params, false)
+	if err != nil {
+		return common.DryRunRequest{}, err
+	}
+	return common.NewDryRunRequest(req.Method, req.URL, req.URL.Query()), nil
+}
+
+func (self *BittrexEndpoint) GetResponse(
+	url string, params map[string]string, signNeeded bool) ([]byte, error) {
+	client := &http.Client{
+		Timeout: time.Duration(30 * time.Second),
+	}
+	req, newHTTPErr := self.newRequest(url, params, signNeeded)
+	if newHTTPErr != nil {
+		return nil, newHTTPErr
+	}
 	var err error
 	var respBody []byte
+	defer func(start time.Time) {
+		instrument.ObserveExchangeRequest(self.name, req.URL.Path, start, err)
+	}(time.Now())
 	log.Printf("request to bittrex: %s\n", req.URL)
 	resp, err := client.Do(req)
 	if err != nil {
@@ -98,6 +123,26 @@ func (self *BittrexEndpoint) GetExchangeInfo() (exchange.BittExchangeInfo, error
 	return result, err
 }
 
+// GetCurrencies returns the listed currencies with their withdrawal fees.
+func (self *BittrexEndpoint) GetCurrencies() (exchange.BittCurrencies, error) {
+	result := exchange.BittCurrencies{}
+	respBody, err := self.GetResponse(
+		mustAddPath(self.interf.PublicEndpoint(), "getcurrencies"),
+		map[string]string{},
+		false,
+	)
+	if err != nil {
+		return result, err
+	}
+	if err = json.Unmarshal(respBody, &result); err != nil {
+		return result, err
+	}
+	if !result.Success {
+		return result, fmt.Errorf("Getting currencies from Bittrex failed: %s", result.Error)
+	}
+	return result, nil
+}
+
 func (self *BittrexEndpoint) FetchOnePairData(pair common.TokenPair) (exchange.Bittresp, error) {
 	data := exchange.Bittresp{}
 	respBody, err := self.GetResponse(
@@ -116,23 +161,31 @@ func (self *BittrexEndpoint) FetchOnePairData(pair common.TokenPair) (exchange.B
 	return data, err
 }
 
-func (self *BittrexEndpoint) Trade(
+// tradeRequest returns the url and params of the limit order.
+func (self *BittrexEndpoint) tradeRequest(
 	tradeType string,
 	base, quote common.Token,
-	rate, amount float64) (exchange.Bitttrade, error) {
-
-	result := exchange.Bitttrade{}
+	rate, amount float64) (string, map[string]string) {
 	var url string
 	if tradeType == "sell" {
 		url = mustAddPath(self.interf.MarketEndpoint(), "selllimit")
 	} else {
 		url = mustAddPath(self.interf.MarketEndpoint(), "buylimit")
 	}
-	params := map[string]string{
+	return url, map[string]string{
 		"market":   fmt.Sprintf("%s-%s", strings.ToUpper(quote.ID), strings.ToUpper(base.ID)),
 		"quantity": strconv.FormatFloat(amount, 'f', -1, 64),
 		"rate":     strconv.FormatFloat(rate, 'f', -1, 64),
 	}
+}
+
+func (self *BittrexEndpoint) Trade(
+	tradeType string,
+	base, quote common.Token,
+	rate, amount float64) (exchange.Bitttrade, error) {
+
+	result := exchange.Bitttrade{}
+	url, params := self.tradeRequest(tradeType, base, quote, rate, amount)
 	respBody, err := self.GetResponse(
 		url, params, true)
 
@@ -143,6 +196,15 @@ func (self *BittrexEndpoint) Trade(
 	return result, err
 }
 
+// BuildTrade returns the signed request Trade would send.
+func (self *BittrexEndpoint) BuildTrade(
+	tradeType string,
+	base, quote common.Token,
+	rate, amount float64) (common.DryRunRequest, error) {
+	url, params := self.tradeRequest(tradeType, base, quote, rate, amount)
+	return self.buildRequest(url, params)
+}
+
 func (self *BittrexEndpoint) OrderStatus(uuid string) (exchange.Bitttraderesult, error) {
 	result := exchange.Bitttraderesult{}
 	respBody, err := self.GetResponse(
@@ -206,15 +268,19 @@ func (self *BittrexEndpoint) DepositHistory(currency string) (exchange.Bittdepos
 	return result, err
 }
 
+func withdrawParams(token common.Token, amount *big.Int, address ethereum.Address) map[string]string {
+	return map[string]string{
+		"currency": strings.ToUpper(token.ID),
+		"quantity": strconv.FormatFloat(common.BigToFloat(amount, token.Decimals), 'f', -1, 64),
+		"address":  address.Hex(),
+	}
+}
+
 func (self *BittrexEndpoint) Withdraw(token common.Token, amount *big.Int, address ethereum.Address) (exchange.Bittwithdraw, error) {
 	result := exchange.Bittwithdraw{}
 	respBody, err := self.GetResponse(
 		mustAddPath(self.interf.AccountEndpoint(), "withdraw"),
-		map[string]string{
-			"currency": strings.ToUpper(token.ID),
-			"quantity": strconv.FormatFloat(common.BigToFloat(amount, token.Decimals), 'f', -1, 64),
-			"address":  address.Hex(),
-		},
+		withdrawParams(token, amount, address),
 		true,
 	)
 	if err != nil {
@@ -224,6 +290,14 @@ func (self *BittrexEndpoint) Withdraw(token common.Token, amount *big.Int, addre
 	return result, err
 }
 
+// BuildWithdraw returns the signed request Withdraw would send.
+func (self *BittrexEndpoint) BuildWithdraw(token common.Token, amount *big.Int, address ethereum.Address) (common.DryRunRequest, error) {
+	return self.buildRequest(
+		mustAddPath(self.interf.AccountEndpoint(), "withdraw"),
+		withdrawParams(token, amount, address),
+	)
+}
+
 func (self *BittrexEndpoint) GetInfo() (exchange.Bittinfo, error) {
 	result := exchange.Bittinfo{}
 	respBody, err := self.GetResponse(
@@ -277,6 +351,21 @@ func (self *BittrexEndpoint) GetAccountTradeHistory(base, quote common.Token) (e
 	return result, err
 }
 
-func NewBittrexEndpoint(signer Signer, interf Interface) *BittrexEndpoint {
-	return &BittrexEndpoint{signer, interf}
+// EndpointOption is the option of NewBittrexEndpoint.
+type EndpointOption func(b *BittrexEndpoint)
+
+// WithEndpointName setups the endpoint to label its request metrics with the
+// given exchange ID instead of "bittrex", eg: of a sub-account.
+func WithEndpointName(name string) EndpointOption {
+	return func(b *BittrexEndpoint) {
+		b.name = name
+	}
+}
+
+func NewBittrexEndpoint(signer Signer, interf Interface, options ...EndpointOption) *BittrexEndpoint {
+	endpoint := &BittrexEndpoint{signer: signer, interf: interf, name: "bittrex"}
+	for _, option := range options {
+		option(endpoint)
+	}
+	return endpoint
 }
@@ -44,25 +44,41 @@ func NewRealInterface() *RealInterface {
 }
 
 type SimulatedInterface struct {
-	baseURL string
+	baseURL  string
+	endpoint string
+}
+
+// simulationURL returns the endpoint of the simulated Bittrex, or the default
+// simulator at base URL if the endpoint is not given.
+func (self *SimulatedInterface) simulationURL() string {
+	if len(self.endpoint) != 0 {
+		return self.endpoint
+	}
+	return getSimulationURL(self.baseURL)
 }
 
 func (self *SimulatedInterface) PublicEndpoint() string {
-	return fmt.Sprintf("%s/api/%s/public", getSimulationURL(self.baseURL), apiVersion)
+	return fmt.Sprintf("%s/api/%s/public", self.simulationURL(), apiVersion)
 }
 
 func (self *SimulatedInterface) MarketEndpoint() string {
-	return fmt.Sprintf("%s/api/%s/market", getSimulationURL(self.baseURL), apiVersion)
+	return fmt.Sprintf("%s/api/%s/market", self.simulationURL(), apiVersion)
 }
 
 func (self *SimulatedInterface) AccountEndpoint() string {
-	return fmt.Sprintf("%s/api/%s/account", getSimulationURL(self.baseURL), apiVersion)
+	return fmt.Sprintf("%s/api/%s/account", self.simulationURL(), apiVersion)
 }
 
 func NewSimulatedInterface(flagVariable string) *SimulatedInterface {
 	return &SimulatedInterface{baseURL: flagVariable}
 }
 
+// NewSimulatedEndpointInterface returns the interface of a simulated Bittrex
+// served at the given endpoint, eg: http://127.0.0.1:5301.
+func NewSimulatedEndpointInterface(endpoint string) *SimulatedInterface {
+	return &SimulatedInterface{endpoint: endpoint}
+}
+
 type DevInterface struct{}
 
 func (self *DevInterface) PublicEndpoint() string {
@@ -136,3 +136,14 @@ type BittTradeHistory struct {
 		ImmediateOrCancel bool    `json:"ImmediateOrCancel"`
 	} `json:"result"`
 }
+
+// BittCurrencies is the currencies listed on Bittrex with their withdrawal fees.
+type BittCurrencies struct {
+	Success bool   `json:"success"`
+	Error   string `json:"message"`
+	Result  []struct {
+		Currency string
+		TxFee    float64
+		IsActive bool
+	} `json:"result"`
+}
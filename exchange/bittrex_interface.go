@@ -15,6 +15,9 @@ type BittrexInterface interface {
 
 	GetExchangeInfo() (BittExchangeInfo, error)
 
+	// GetCurrencies returns the listed currencies with their withdrawal fees.
+	GetCurrencies() (BittCurrencies, error)
+
 	GetDepositAddress(currency string) (BittrexDepositAddress, error)
 
 	GetAccountTradeHistory(base, quote common.Token) (BittTradeHistory, error)
@@ -29,6 +32,18 @@ type BittrexInterface interface {
 		base, quote common.Token,
 		rate, amount float64) (Bitttrade, error)
 
+	// BuildWithdraw and BuildTrade return the unsigned requests of Withdraw
+	// and Trade without sending them.
+	BuildWithdraw(
+		token common.Token,
+		amount *big.Int,
+		address ethereum.Address) (common.DryRunRequest, error)
+
+	BuildTrade(
+		tradeType string,
+		base, quote common.Token,
+		rate, amount float64) (common.DryRunRequest, error)
+
 	CancelOrder(uuid string) (Bittcancelorder, error)
 
 	DepositHistory(currency string) (Bittdeposithistory, error)
@@ -25,6 +25,9 @@ func (self testBittrexInterface) FetchOnePairData(pair common.TokenPair) (Bittre
 func (self testBittrexInterface) GetExchangeInfo() (BittExchangeInfo, error) {
 	return BittExchangeInfo{}, nil
 }
+func (self testBittrexInterface) GetCurrencies() (BittCurrencies, error) {
+	return BittCurrencies{}, nil
+}
 func (self testBittrexInterface) GetInfo() (Bittinfo, error) {
 	return Bittinfo{}, nil
 }
@@ -40,6 +43,18 @@ func (self testBittrexInterface) Trade(
 	rate, amount float64) (Bitttrade, error) {
 	return Bitttrade{}, nil
 }
+func (self testBittrexInterface) BuildWithdraw(
+	token common.Token,
+	amount *big.Int,
+	address ethereum.Address) (common.DryRunRequest, error) {
+	return common.DryRunRequest{}, nil
+}
+func (self testBittrexInterface) BuildTrade(
+	tradeType string,
+	base, quote common.Token,
+	rate, amount float64) (common.DryRunRequest, error) {
+	return common.DryRunRequest{}, nil
+}
 func (self testBittrexInterface) CancelOrder(uuid string) (Bittcancelorder, error) {
 	return Bittcancelorder{}, nil
 }
@@ -110,9 +125,10 @@ func getTestBittrex(depositHistory string, registered bool) *Bittrex {
 		log.Fatal(err)
 	}
 	return &Bittrex{
-		testBittrexInterface{depositHistory},
-		&testBittrexStorage{registered},
-		setting,
+		name:    settings.Bittrex,
+		interf:  testBittrexInterface{depositHistory},
+		storage: &testBittrexStorage{registered},
+		setting: setting,
 	}
 }
 
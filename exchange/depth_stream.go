@@ -0,0 +1,67 @@
+package exchange
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/KyberNetwork/reserve-data/common"
+)
+
+// DefaultDepthStaleDuration is the default maximum duration without any
+// message on the stream connection. Books of a stale stream are fetched with
+// REST instead.
+const DefaultDepthStaleDuration = 60 * time.Second
+
+// DepthStream maintains order books of token pairs locally from an exchange
+// streaming api, it is used instead of polling depth with REST requests.
+type DepthStream interface {
+	// Watch makes sure the given pairs are streamed.
+	Watch(pairs []common.TokenPair)
+	// GetDepth returns the local order book of the pair and the time of the
+	// last message received on its stream, a quiet pair is still up to date
+	// as long as the stream is alive. It returns an error if the book is not
+	// available, for example the stream is disconnected or the book is not
+	// synced yet.
+	GetDepth(pair common.TokenPair) (bids, asks []common.PriceEntry, updated time.Time, err error)
+}
+
+// streamPriceData returns the price data of the pair from the depth stream.
+// It returns false if the stream does not have the book of the pair or the
+// stream is stale, the caller should fetch it with REST instead.
+func streamPriceData(stream DepthStream, staleDuration time.Duration, pair common.TokenPair, timepoint uint64) (common.ExchangePrice, bool) {
+	bids, asks, updated, err := stream.GetDepth(pair)
+	if err != nil {
+		return common.ExchangePrice{}, false
+	}
+	if age := time.Since(updated); age > staleDuration {
+		log.Printf("Order book stream of %s is stale, last message %s ago, fetching with REST", pair.PairID(), age)
+		return common.ExchangePrice{}, false
+	}
+	return common.ExchangePrice{
+		Timestamp:  common.Timestamp(fmt.Sprintf("%d", timepoint)),
+		ReturnTime: common.GetTimestamp(),
+		Valid:      true,
+		Bids:       bids,
+		Asks:       asks,
+	}, true
+}
+
+// restPairs stores the streamed price data of pairs to data and returns the
+// pairs that have to be fetched with REST.
+func restPairs(stream DepthStream, staleDuration time.Duration, pairs []common.TokenPair, data map[common.TokenPairID]common.ExchangePrice, timepoint uint64) []common.TokenPair {
+	if stream == nil {
+		return pairs
+	}
+	stream.Watch(pairs)
+	result := []common.TokenPair{}
+	for _, pair := range pairs {
+		price, ok := streamPriceData(stream, staleDuration, pair, timepoint)
+		if !ok {
+			result = append(result, pair)
+			continue
+		}
+		data[pair.PairID()] = price
+	}
+	return result
+}
@@ -0,0 +1,57 @@
+package exchange
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/KyberNetwork/reserve-data/common"
+)
+
+type fakeDepthStream struct {
+	watched []common.TokenPair
+	updated map[common.TokenPairID]time.Time
+}
+
+func (self *fakeDepthStream) Watch(pairs []common.TokenPair) {
+	self.watched = pairs
+}
+
+func (self *fakeDepthStream) GetDepth(pair common.TokenPair) ([]common.PriceEntry, []common.PriceEntry, time.Time, error) {
+	updated, ok := self.updated[pair.PairID()]
+	if !ok {
+		return nil, nil, time.Time{}, errors.New("not synced")
+	}
+	return []common.PriceEntry{common.NewPriceEntry(1, 0.002)}, []common.PriceEntry{common.NewPriceEntry(1, 0.003)}, updated, nil
+}
+
+func TestRestPairs(t *testing.T) {
+	knc := common.TokenPair{Base: common.Token{ID: "KNC"}, Quote: common.Token{ID: "ETH"}}
+	omg := common.TokenPair{Base: common.Token{ID: "OMG"}, Quote: common.Token{ID: "ETH"}}
+	zrx := common.TokenPair{Base: common.Token{ID: "ZRX"}, Quote: common.Token{ID: "ETH"}}
+	pairs := []common.TokenPair{knc, omg, zrx}
+	stream := &fakeDepthStream{updated: map[common.TokenPairID]time.Time{
+		knc.PairID(): time.Now(),
+		omg.PairID(): time.Now().Add(-time.Minute),
+	}}
+
+	data := map[common.TokenPairID]common.ExchangePrice{}
+	rest := restPairs(stream, 30*time.Second, pairs, data, 1)
+	if len(stream.watched) != 3 {
+		t.Errorf("expected all pairs are watched, got %v", stream.watched)
+	}
+	if len(rest) != 2 || rest[0].PairID() != omg.PairID() || rest[1].PairID() != zrx.PairID() {
+		t.Errorf("expected stale and not synced pairs are fetched with REST, got %v", rest)
+	}
+	if price := data[knc.PairID()]; !price.Valid || len(price.Bids) != 1 || len(price.Asks) != 1 {
+		t.Errorf("expected valid streamed price, got %+v", price)
+	}
+	if _, ok := data[omg.PairID()]; ok {
+		t.Errorf("expected stale streamed price is not stored, got %+v", data[omg.PairID()])
+	}
+
+	data = map[common.TokenPairID]common.ExchangePrice{}
+	if rest = restPairs(nil, 30*time.Second, pairs, data, 1); len(rest) != 3 || len(data) != 0 {
+		t.Errorf("expected all pairs are fetched with REST without stream, got %v", rest)
+	}
+}
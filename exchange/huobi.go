@@ -24,10 +24,14 @@ const (
 )
 
 type Huobi struct {
+	name       settings.ExchangeName
 	interf     HuobiInterface
 	blockchain HuobiBlockchain
 	storage    HuobiStorage
 	setting    Setting
+
+	depthStream        DepthStream
+	depthStaleDuration time.Duration
 }
 
 func (self *Huobi) MarshalText() (text []byte, err error) {
@@ -35,7 +39,7 @@ func (self *Huobi) MarshalText() (text []byte, err error) {
 }
 
 func (self *Huobi) TokenAddresses() (map[string]ethereum.Address, error) {
-	addrs, err := self.setting.GetDepositAddresses(settings.Huobi)
+	addrs, err := self.setting.GetDepositAddresses(self.name)
 	if err != nil {
 		return nil, err
 	}
@@ -48,7 +52,7 @@ func (self *Huobi) RealDepositAddress(tokenID string) (ethereum.Address, error)
 	liveAddress, err := self.interf.GetDepositAddress(tokenID)
 	if err != nil || liveAddress.Address == "" {
 		log.Printf("WARNING: Get Huobi live deposit address for token %s failed: (%v) or the replied address is empty. Check the currently available address instead", tokenID, err)
-		addrs, uErr := self.setting.GetDepositAddresses(settings.Huobi)
+		addrs, uErr := self.setting.GetDepositAddresses(self.name)
 		if uErr != nil {
 			return ethereum.Address{}, uErr
 		}
@@ -82,12 +86,12 @@ func (self *Huobi) UpdateDepositAddress(token common.Token, address string) erro
 		log.Printf("WARNING: Get Huobi live deposit address for token %s failed: (%v) or the replied address is empty. Check the currently available address instead", token.ID, err)
 		addrs := common.NewExchangeAddresses()
 		addrs.Update(token.ID, ethereum.HexToAddress(address))
-		return self.setting.UpdateDepositAddress(settings.Huobi, *addrs, common.GetTimepoint())
+		return self.setting.UpdateDepositAddress(self.name, *addrs, common.GetTimepoint())
 	}
 	log.Printf("Got Huobi live deposit address for token %s, attempt to update it to current setting", token.ID)
 	addrs := common.NewExchangeAddresses()
 	addrs.Update(token.ID, ethereum.HexToAddress(liveAddress.Address))
-	return self.setting.UpdateDepositAddress(settings.Huobi, *addrs, common.GetTimepoint())
+	return self.setting.UpdateDepositAddress(self.name, *addrs, common.GetTimepoint())
 }
 
 // GetLiveExchangeInfos querry the Exchange Endpoint for exchange precision and limit of a list of tokenPairIDs
@@ -144,15 +148,15 @@ func (self *Huobi) UpdatePairsPrecision() error {
 		}
 		exInfo[pair] = exchangePrecisionLimit
 	}
-	return self.setting.UpdateExchangeInfo(settings.Huobi, exInfo, common.GetTimepoint())
+	return self.setting.UpdateExchangeInfo(self.name, exInfo, common.GetTimepoint())
 }
 
 func (self *Huobi) GetInfo() (common.ExchangeInfo, error) {
-	return self.setting.GetExchangeInfo(settings.Huobi)
+	return self.setting.GetExchangeInfo(self.name)
 }
 
 func (self *Huobi) GetExchangeInfo(pair common.TokenPairID) (common.ExchangePrecisionLimit, error) {
-	exInfo, err := self.setting.GetExchangeInfo(settings.Huobi)
+	exInfo, err := self.setting.GetExchangeInfo(self.name)
 	if err != nil {
 		return common.ExchangePrecisionLimit{}, err
 	}
@@ -161,21 +165,108 @@ func (self *Huobi) GetExchangeInfo(pair common.TokenPairID) (common.ExchangePrec
 }
 
 func (self *Huobi) GetFee() (common.ExchangeFees, error) {
-	return self.setting.GetFee(settings.Huobi)
+	return self.setting.GetFee(self.name)
 }
 
 func (self *Huobi) GetMinDeposit() (common.ExchangesMinDeposit, error) {
-	return self.setting.GetMinDeposit(settings.Huobi)
+	return self.setting.GetMinDeposit(self.name)
+}
+
+// huobiEthereumChain returns the chain of the currency on Ethereum, Huobi
+// names it after the currency.
+func huobiEthereumChain(currency string, chains []HuobiCurrencyChain) (HuobiCurrencyChain, bool) {
+	for _, chain := range chains {
+		if chain.Chain == currency {
+			return chain, true
+		}
+	}
+	for _, chain := range chains {
+		if strings.ToUpper(chain.BaseChain) == "ETH" {
+			return chain, true
+		}
+	}
+	return HuobiCurrencyChain{}, false
+}
+
+// FetchLiveFees returns the withdrawal fees and min deposits of known tokens
+// on Ethereum from Huobi currency references. Withdrawal fees not of fixed
+// amount are ignored. Huobi trading fees are not reported.
+func (self *Huobi) FetchLiveFees() (common.ExchangeFees, common.ExchangesMinDeposit, error) {
+	fee := common.NewExchangeFee(common.TradingFee{}, common.NewFundingFee(map[string]float64{}, map[string]float64{}))
+	minDeposit := common.ExchangesMinDeposit{}
+	references, err := self.interf.GetCurrencyReferences()
+	if err != nil {
+		return fee, minDeposit, err
+	}
+	for _, currency := range references.Data {
+		tokenID := strings.ToUpper(currency.Currency)
+		if _, err := self.setting.GetTokenByID(tokenID); err != nil {
+			continue
+		}
+		chain, ok := huobiEthereumChain(currency.Currency, currency.Chains)
+		if !ok {
+			continue
+		}
+		if chain.WithdrawFeeType == "fixed" {
+			withdrawFee, err := strconv.ParseFloat(chain.TransactFeeWithdraw, 64)
+			if err != nil {
+				return fee, minDeposit, fmt.Errorf("invalid withdrawal fee of %s: %s", tokenID, err)
+			}
+			fee.Funding.Withdraw[tokenID] = withdrawFee
+		}
+		if chain.MinDepositAmt != "" {
+			amount, err := strconv.ParseFloat(chain.MinDepositAmt, 64)
+			if err != nil {
+				return fee, minDeposit, fmt.Errorf("invalid min deposit of %s: %s", tokenID, err)
+			}
+			minDeposit[tokenID] = amount
+		}
+	}
+	return fee, minDeposit, nil
+}
+
+// SystemStatus returns whether Huobi market is halted and the deposit and
+// withdrawal statuses of known tokens on Ethereum.
+func (self *Huobi) SystemStatus() (common.ExchangeSystemStatus, error) {
+	result := common.ExchangeSystemStatus{Wallets: map[string]common.TokenWalletStatus{}}
+	status, err := self.interf.GetMarketStatus()
+	if err != nil {
+		return result, err
+	}
+	// 1 is normal, 2 is halted and 3 is cancel only
+	if status.Data.MarketStatus != 1 {
+		result.Maintenance = true
+		result.Message = fmt.Sprintf("market status %d, halt reason %d", status.Data.MarketStatus, status.Data.HaltReason)
+	}
+	references, err := self.interf.GetCurrencyReferences()
+	if err != nil {
+		return result, err
+	}
+	for _, currency := range references.Data {
+		tokenID := strings.ToUpper(currency.Currency)
+		if _, err := self.setting.GetTokenByID(tokenID); err != nil {
+			continue
+		}
+		chain, ok := huobiEthereumChain(currency.Currency, currency.Chains)
+		if !ok {
+			continue
+		}
+		result.Wallets[tokenID] = common.TokenWalletStatus{
+			DepositEnabled:  chain.DepositStatus == "allowed",
+			WithdrawEnabled: chain.WithdrawStatus == "allowed",
+		}
+	}
+	return result, nil
 }
 
 // ID must return the exact string or else simulation will fail
 func (self *Huobi) ID() common.ExchangeID {
-	return common.ExchangeID(settings.Huobi.String())
+	return common.ExchangeID(self.name)
 }
 
 func (self *Huobi) TokenPairs() ([]common.TokenPair, error) {
 	result := []common.TokenPair{}
-	exInfo, err := self.setting.GetExchangeInfo(settings.Huobi)
+	exInfo, err := self.setting.GetExchangeInfo(self.name)
 	if err != nil {
 		return nil, err
 	}
@@ -259,6 +350,16 @@ func (self *Huobi) Withdraw(token common.Token, amount *big.Int, address ethereu
 	return result, err
 }
 
+// BuildTradeRequest returns the unsigned request Trade would send.
+func (self *Huobi) BuildTradeRequest(tradeType string, base, quote common.Token, rate, amount float64) (common.DryRunRequest, error) {
+	return self.interf.BuildTrade(tradeType, base, quote, rate, amount)
+}
+
+// BuildWithdrawRequest returns the unsigned request Withdraw would send.
+func (self *Huobi) BuildWithdrawRequest(token common.Token, amount *big.Int, address ethereum.Address) (common.DryRunRequest, error) {
+	return self.interf.BuildWithdraw(token, amount, address)
+}
+
 func (self *Huobi) CancelOrder(id, base, quote string) error {
 	idNo, err := strconv.ParseUint(id, 10, 64)
 	if err != nil {
@@ -327,16 +428,18 @@ func (self *Huobi) FetchOnePairData(
 func (self *Huobi) FetchPriceData(timepoint uint64) (map[common.TokenPairID]common.ExchangePrice, error) {
 	wait := sync.WaitGroup{}
 	data := sync.Map{}
-	pairs, err := self.TokenPairs()
+	allPairs, err := self.TokenPairs()
 	if err != nil {
 		return nil, err
 	}
+	result := map[common.TokenPairID]common.ExchangePrice{}
+	// pairs not available from depth stream are polled with REST
+	pairs := restPairs(self.depthStream, self.depthStaleDuration, allPairs, result, timepoint)
 	for _, pair := range pairs {
 		wait.Add(1)
 		go self.FetchOnePairData(&wait, pair, &data, timepoint)
 	}
 	wait.Wait()
-	result := map[common.TokenPairID]common.ExchangePrice{}
 	data.Range(func(key, value interface{}) bool {
 		tokenPairID, ok := key.(common.TokenPairID)
 		//if there is conversion error, continue to next key,val
@@ -570,6 +673,20 @@ func (self *Huobi) FindTx2InPending(id common.ActivityID) (common.TXEntry, bool)
 	return common.TXEntry{}, false
 }
 
+// ReplacePendingIntermediateTx tracks the transaction of the given hash as
+// the intermediate transaction of the activity, in place of the pending one
+// it replaced with the same nonce.
+func (self *Huobi) ReplacePendingIntermediateTx(id common.ActivityID, hash string) error {
+	tx2Entry, found := self.FindTx2InPending(id)
+	if !found {
+		return fmt.Errorf("pending intermediate tx of activity %s is not found", id)
+	}
+	tx2Entry.ReplacedHashes = append(tx2Entry.ReplacedHashes, tx2Entry.Hash)
+	tx2Entry.Hash = hash
+	tx2Entry.Timestamp = common.GetTimestamp()
+	return self.storage.StorePendingIntermediateTx(id, tx2Entry)
+}
+
 //FindTx2 : find Tx2 Record associates with activity ID, return
 func (self *Huobi) FindTx2(id common.ActivityID) (Tx2 common.TXEntry, found bool) {
 	found = true
@@ -676,6 +793,21 @@ func (self *Huobi) DepositStatus(id common.ActivityID, tx1Hash, currency string,
 	if err != nil {
 		return "", err
 	}
+	if miningStatus == common.MiningStatusLost {
+		// a replaced tx might be mined before its replacement
+		for _, hash := range tx2Entry.ReplacedHashes {
+			status, _, sErr := self.blockchain.TxStatus(ethereum.HexToHash(hash))
+			if sErr != nil {
+				return "", sErr
+			}
+			if status == common.MiningStatusMined || status == common.MiningStatusFailed {
+				log.Printf("Huobi replaced 2nd transaction %s is %s instead of %s", hash, status, tx2Entry.Hash)
+				tx2Entry.Hash = hash
+				miningStatus = status
+				break
+			}
+		}
+	}
 	switch miningStatus {
 	case common.MiningStatusMined:
 		log.Println("Huobi 2nd Transaction is mined. Processed to store it and check the Huobi Deposit history")
@@ -770,6 +902,51 @@ func (self *Huobi) OrderStatus(id string, base, quote string) (string, error) {
 	return common.ExchangeStatusDone, nil
 }
 
+// OrderFill returns the filled and remaining amounts of the order.
+func (self *Huobi) OrderFill(id string, base, quote string) (float64, float64, bool, error) {
+	orderID, err := strconv.ParseUint(id, 10, 64)
+	if err != nil {
+		return 0, 0, false, err
+	}
+	order, err := self.interf.OrderStatus(base+quote, orderID)
+	if err != nil {
+		return 0, 0, false, err
+	}
+	done, err := strconv.ParseFloat(order.Data.ExecutedQty, 64)
+	if err != nil {
+		return 0, 0, false, err
+	}
+	total, err := strconv.ParseFloat(order.Data.OrigQty, 64)
+	if err != nil {
+		return 0, 0, false, err
+	}
+	state := order.Data.State
+	finished := state != "pre-submitted" && state != "submitting" && state != "submitted" &&
+		state != "partial-filled"
+	return done, total - done, finished, nil
+}
+
+// HuobiOption is the option to setup the Huobi exchange on creation.
+type HuobiOption func(h *Huobi)
+
+// WithHuobiDepthStream setups Huobi to read order books from the given
+// depth stream, falling back to REST polling for pairs not available from it.
+// Pairs of a stream without any message for staleDuration are polled too.
+func WithHuobiDepthStream(stream DepthStream, staleDuration time.Duration) HuobiOption {
+	return func(h *Huobi) {
+		h.depthStream = stream
+		h.depthStaleDuration = staleDuration
+	}
+}
+
+// WithHuobiName setups Huobi to run as the exchange account of the given
+// name instead of the default one.
+func WithHuobiName(name settings.ExchangeName) HuobiOption {
+	return func(h *Huobi) {
+		h.name = name
+	}
+}
+
 //NewHuobi creates new Huobi exchange instance
 func NewHuobi(
 	interf HuobiInterface,
@@ -777,7 +954,8 @@ func NewHuobi(
 	signer blockchain.Signer,
 	nonce blockchain.NonceCorpus,
 	storage HuobiStorage,
-	setting Setting) (*Huobi, error) {
+	setting Setting,
+	options ...HuobiOption) (*Huobi, error) {
 
 	bc, err := huobiblockchain.NewBlockchain(blockchain, signer, nonce)
 	if err != nil {
@@ -785,10 +963,15 @@ func NewHuobi(
 	}
 
 	huobiObj := Huobi{
-		interf,
-		bc,
-		storage,
-		setting,
+		name:               settings.Huobi,
+		interf:             interf,
+		blockchain:         bc,
+		storage:            storage,
+		setting:            setting,
+		depthStaleDuration: DefaultDepthStaleDuration,
+	}
+	for _, option := range options {
+		option(&huobiObj)
 	}
 	huobiObj.FetchTradeHistory()
 	huobiServer := huobihttp.NewHuobiHTTPServer(&huobiObj)
@@ -0,0 +1,129 @@
+package huobi
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/KyberNetwork/reserve-data/common"
+	"github.com/KyberNetwork/reserve-data/exchange/orderbook"
+	"golang.org/x/net/websocket"
+)
+
+// StreamEndpoint is the Huobi websocket market data endpoint.
+const StreamEndpoint = "wss://api.huobi.pro/ws"
+
+// streamMessage is a message received from Huobi market data stream. All
+// messages are gzip compressed.
+type streamMessage struct {
+	Ping      uint64 `json:"ping"`
+	Status    string `json:"status"`
+	Reason    string `json:"err-msg"`
+	Channel   string `json:"ch"`
+	Timestamp uint64 `json:"ts"`
+	Tick      struct {
+		Bids [][]float64 `json:"bids"`
+		Asks [][]float64 `json:"asks"`
+	} `json:"tick"`
+}
+
+// DepthStreamHandler maintains the books from Huobi depth channels. Huobi
+// pushes full depth snapshots, so every message resyncs its book.
+type DepthStreamHandler struct {
+	endpoint string
+}
+
+// NewDepthStreamHandler creates a handler connecting to the given endpoint.
+func NewDepthStreamHandler(endpoint string) *DepthStreamHandler {
+	return &DepthStreamHandler{endpoint: endpoint}
+}
+
+// NewDepthStream creates the order book stream of Huobi.
+func NewDepthStream(endpoint string, options ...orderbook.StreamOption) *orderbook.Stream {
+	return orderbook.NewStream("Huobi", NewDepthStreamHandler(endpoint), options...)
+}
+
+func depthChannel(symbol string) string {
+	return fmt.Sprintf("market.%s.depth.step0", symbol)
+}
+
+// Endpoint returns the stream endpoint, pairs are subscribed after connected.
+func (self *DepthStreamHandler) Endpoint(pairs []common.TokenPair) string {
+	return self.endpoint
+}
+
+// Symbol returns the lower case symbol of the pair.
+func (self *DepthStreamHandler) Symbol(pair common.TokenPair) string {
+	return strings.ToLower(pair.Base.ID + pair.Quote.ID)
+}
+
+// Subscribe subscribes to the depth channel of every pair.
+func (self *DepthStreamHandler) Subscribe(conn *websocket.Conn, pairs []common.TokenPair) error {
+	for _, pair := range pairs {
+		symbol := self.Symbol(pair)
+		req := map[string]string{
+			"sub": depthChannel(symbol),
+			"id":  symbol,
+		}
+		if err := websocket.JSON.Send(conn, req); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Handle answers heartbeat pings and replaces the books with pushed depth.
+func (self *DepthStreamHandler) Handle(conn *websocket.Conn, msg []byte, books map[string]*orderbook.Book) error {
+	reader, err := gzip.NewReader(bytes.NewReader(msg))
+	if err != nil {
+		return err
+	}
+	data, err := ioutil.ReadAll(reader)
+	if err != nil {
+		return err
+	}
+	var message streamMessage
+	if err = json.Unmarshal(data, &message); err != nil {
+		return err
+	}
+	if message.Ping != 0 {
+		return websocket.JSON.Send(conn, map[string]uint64{"pong": message.Ping})
+	}
+	if message.Status == "error" {
+		return fmt.Errorf("Huobi stream error: %s", message.Reason)
+	}
+	if message.Channel == "" {
+		return nil
+	}
+	parts := strings.Split(message.Channel, ".")
+	if len(parts) != 4 {
+		log.Printf("Huobi depth stream: unknown channel %s", message.Channel)
+		return nil
+	}
+	book, ok := books[parts[1]]
+	if !ok {
+		return nil
+	}
+	if book.Synced() && message.Timestamp <= book.LastUpdateID() {
+		// out of order message
+		return nil
+	}
+	book.Reset(toPriceEntries(message.Tick.Bids), toPriceEntries(message.Tick.Asks), message.Timestamp, time.Now())
+	return nil
+}
+
+func toPriceEntries(levels [][]float64) []common.PriceEntry {
+	result := make([]common.PriceEntry, 0, len(levels))
+	for _, level := range levels {
+		if len(level) < 2 {
+			continue
+		}
+		result = append(result, common.NewPriceEntry(level[1], level[0]))
+	}
+	return result
+}
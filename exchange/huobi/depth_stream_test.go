@@ -0,0 +1,96 @@
+package huobi
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/KyberNetwork/reserve-data/common"
+	"golang.org/x/net/websocket"
+)
+
+func sendGzip(conn *websocket.Conn, msg string) error {
+	var buf bytes.Buffer
+	writer := gzip.NewWriter(&buf)
+	if _, err := writer.Write([]byte(msg)); err != nil {
+		return err
+	}
+	if err := writer.Close(); err != nil {
+		return err
+	}
+	return websocket.Message.Send(conn, buf.Bytes())
+}
+
+func TestDepthStream(t *testing.T) {
+	subscribed := make(chan string, 1)
+	ponged := make(chan uint64, 1)
+	server := httptest.NewServer(websocket.Handler(func(conn *websocket.Conn) {
+		var req map[string]string
+		if err := websocket.JSON.Receive(conn, &req); err != nil {
+			t.Error(err)
+			return
+		}
+		subscribed <- req["sub"]
+		for _, msg := range []string{
+			`{"ping":1234}`,
+			`{"ch":"market.kncbtc.depth.step0","ts":2,"tick":{"bids":[[0.001,5],[0.0011,1]],"asks":[[0.0013,2],[0.0012,3]]}}`,
+			// out of order message is ignored
+			`{"ch":"market.kncbtc.depth.step0","ts":1,"tick":{"bids":[[0.0005,5]],"asks":[]}}`,
+		} {
+			if err := sendGzip(conn, msg); err != nil {
+				t.Error(err)
+				return
+			}
+		}
+		var pong map[string]uint64
+		if err := websocket.JSON.Receive(conn, &pong); err != nil {
+			t.Error(err)
+			return
+		}
+		ponged <- pong["pong"]
+		var discard []byte
+		// blocks until the client closes the connection
+		_ = websocket.Message.Receive(conn, &discard)
+	}))
+	defer server.Close()
+
+	stream := NewDepthStream("ws" + strings.TrimPrefix(server.URL, "http"))
+	defer stream.Stop()
+	pair := common.TokenPair{Base: common.Token{ID: "KNC"}, Quote: common.Token{ID: "BTC"}}
+	stream.Watch([]common.TokenPair{pair})
+
+	if sub := <-subscribed; sub != "market.kncbtc.depth.step0" {
+		t.Errorf("unexpected subscription: %s", sub)
+	}
+	select {
+	case pong := <-ponged:
+		if pong != 1234 {
+			t.Errorf("unexpected pong: %d", pong)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("ping is not answered")
+	}
+
+	expected := map[string][]common.PriceEntry{
+		"bids": {common.NewPriceEntry(1, 0.0011), common.NewPriceEntry(5, 0.001)},
+		"asks": {common.NewPriceEntry(3, 0.0012), common.NewPriceEntry(2, 0.0013)},
+	}
+	timeout := time.After(2 * time.Second)
+	for {
+		bids, asks, _, err := stream.GetDepth(pair)
+		got, _ := json.Marshal(map[string][]common.PriceEntry{"bids": bids, "asks": asks})
+		want, _ := json.Marshal(expected)
+		if err == nil && bytes.Equal(got, want) {
+			return
+		}
+		select {
+		case <-timeout:
+			t.Fatalf("order book is not as expected, got: %s, err: %v", got, err)
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+}
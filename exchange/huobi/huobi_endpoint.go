@@ -15,6 +15,7 @@ import (
 	"time"
 
 	"github.com/KyberNetwork/reserve-data/common"
+	"github.com/KyberNetwork/reserve-data/common/instrument"
 	"github.com/KyberNetwork/reserve-data/exchange"
 	ethereum "github.com/ethereum/go-ethereum/common"
 )
@@ -23,6 +24,8 @@ import (
 type HuobiEndpoint struct {
 	signer Signer
 	interf Interface
+	// name is the exchange ID labelling the request metrics.
+	name string
 }
 
 func (self *HuobiEndpoint) fillRequest(req *http.Request, signNeeded bool) {
@@ -45,13 +48,11 @@ func (self *HuobiEndpoint) fillRequest(req *http.Request, signNeeded bool) {
 	}
 }
 
-func (self *HuobiEndpoint) GetResponse(
+// newRequest creates the request to reqURL with the params, signed if
+// needed.
+func (self *HuobiEndpoint) newRequest(
 	method string, reqURL string,
-	params map[string]string, signNeeded bool) ([]byte, error) {
-
-	client := &http.Client{
-		Timeout: time.Duration(30 * time.Second),
-	}
+	params map[string]string, signNeeded bool) (*http.Request, error) {
 	reqBody, err := json.Marshal(params)
 	if err != nil {
 		return nil, err
@@ -83,6 +84,33 @@ func (self *HuobiEndpoint) GetResponse(
 	}
 	req.URL.RawQuery = q.Encode()
 	self.fillRequest(req, signNeeded)
+	return req, nil
+}
+
+// buildRequest creates the unsigned request to reqURL with the params
+// without sending it.
+func (self *HuobiEndpoint) buildRequest(method string, reqURL string, params map[string]string) (common.DryRunRequest, error) {
+	req, err := self.newRequest(method, reqURL, params, false)
+	if err != nil {
+		return common.DryRunRequest{}, err
+	}
+	return common.NewDryRunRequest(method, req.URL, req.URL.Query()), nil
+}
+
+func (self *HuobiEndpoint) GetResponse(
+	method string, reqURL string,
+	params map[string]string, signNeeded bool) ([]byte, error) {
+
+	client := &http.Client{
+		Timeout: time.Duration(30 * time.Second),
+	}
+	req, err := self.newRequest(method, reqURL, params, signNeeded)
+	if err != nil {
+		return nil, err
+	}
+	defer func(start time.Time) {
+		instrument.ObserveExchangeRequest(self.name, req.URL.Path, start, err)
+	}(time.Now())
 	var respBody []byte
 	//log.Printf("request to huobi: %s\n", req.URL)
 	resp, err := client.Do(req)
@@ -143,24 +171,33 @@ func (self *HuobiEndpoint) GetDepthOnePair(
 	return respData, err
 }
 
-func (self *HuobiEndpoint) Trade(tradeType string, base, quote common.Token, rate, amount float64, timepoint uint64) (exchange.HuobiTrade, error) {
-	result := exchange.HuobiTrade{}
+// tradeParams returns the params of the order, which is placed by the first
+// Huobi account.
+func (self *HuobiEndpoint) tradeParams(tradeType string, base, quote common.Token, rate, amount float64) (map[string]string, error) {
 	symbol := strings.ToLower(base.ID) + strings.ToLower(quote.ID)
 	orderType := tradeType + "-limit"
 	accounts, err := self.GetAccounts()
 	if err != nil {
-		return result, err
+		return nil, err
 	}
 	if len(accounts.Data) == 0 {
-		return result, errors.New("Cannot get Huobi account")
+		return nil, errors.New("Cannot get Huobi account")
 	}
-	params := map[string]string{
+	return map[string]string{
 		"account-id": strconv.FormatUint(accounts.Data[0].ID, 10),
 		"symbol":     symbol,
 		"source":     "api",
 		"type":       orderType,
 		"amount":     strconv.FormatFloat(amount, 'f', -1, 64),
 		"price":      strconv.FormatFloat(rate, 'f', -1, 64),
+	}, nil
+}
+
+func (self *HuobiEndpoint) Trade(tradeType string, base, quote common.Token, rate, amount float64, timepoint uint64) (exchange.HuobiTrade, error) {
+	result := exchange.HuobiTrade{}
+	params, err := self.tradeParams(tradeType, base, quote, rate, amount)
+	if err != nil {
+		return result, err
 	}
 	respBody, err := self.GetResponse(
 		"POST",
@@ -180,6 +217,19 @@ func (self *HuobiEndpoint) Trade(tradeType string, base, quote common.Token, rat
 	return result, nil
 }
 
+// BuildTrade returns the signed request Trade would send.
+func (self *HuobiEndpoint) BuildTrade(tradeType string, base, quote common.Token, rate, amount float64) (common.DryRunRequest, error) {
+	params, err := self.tradeParams(tradeType, base, quote, rate, amount)
+	if err != nil {
+		return common.DryRunRequest{}, err
+	}
+	return self.buildRequest(
+		"POST",
+		self.interf.AuthenticatedEndpoint()+"/v1/order/orders/place",
+		params,
+	)
+}
+
 func (self *HuobiEndpoint) WithdrawHistory(tokens []common.Token) (exchange.HuobiWithdraws, error) {
 	result := exchange.HuobiWithdraws{}
 	size := len(tokens) * 2
@@ -272,16 +322,20 @@ func (self *HuobiEndpoint) OrderStatus(symbol string, id uint64) (exchange.Huobi
 	return result, err
 }
 
+func withdrawParams(token common.Token, amount *big.Int, address ethereum.Address) map[string]string {
+	return map[string]string{
+		"address":  address.Hex(),
+		"amount":   strconv.FormatFloat(common.BigToFloat(amount, token.Decimals), 'f', -1, 64),
+		"currency": strings.ToLower(token.ID),
+	}
+}
+
 func (self *HuobiEndpoint) Withdraw(token common.Token, amount *big.Int, address ethereum.Address) (string, error) {
 	result := exchange.HuobiWithdraw{}
 	respBody, err := self.GetResponse(
 		"POST",
 		self.interf.AuthenticatedEndpoint()+"/v1/dw/withdraw/api/create",
-		map[string]string{
-			"address":  address.Hex(),
-			"amount":   strconv.FormatFloat(common.BigToFloat(amount, token.Decimals), 'f', -1, 64),
-			"currency": strings.ToLower(token.ID),
-		},
+		withdrawParams(token, amount, address),
 		true,
 	)
 	if err == nil {
@@ -297,6 +351,15 @@ func (self *HuobiEndpoint) Withdraw(token common.Token, amount *big.Int, address
 	return "", errors.New("Withdraw rejected by Huobi")
 }
 
+// BuildWithdraw returns the signed request Withdraw would send.
+func (self *HuobiEndpoint) BuildWithdraw(token common.Token, amount *big.Int, address ethereum.Address) (common.DryRunRequest, error) {
+	return self.buildRequest(
+		"POST",
+		self.interf.AuthenticatedEndpoint()+"/v1/dw/withdraw/api/create",
+		withdrawParams(token, amount, address),
+	)
+}
+
 func (self *HuobiEndpoint) GetInfo() (exchange.HuobiInfo, error) {
 	result := exchange.HuobiInfo{}
 	accounts, err := self.GetAccounts()
@@ -391,7 +454,64 @@ func (self *HuobiEndpoint) GetExchangeInfo() (exchange.HuobiExchangeInfo, error)
 	return result, err
 }
 
+// EndpointOption is the option of NewHuobiEndpoint.
+type EndpointOption func(h *HuobiEndpoint)
+
+// WithEndpointName setups the endpoint to label its request metrics with the
+// given exchange ID instead of "huobi", eg: of a sub-account.
+func WithEndpointName(name string) EndpointOption {
+	return func(h *HuobiEndpoint) {
+		h.name = name
+	}
+}
+
 //NewHuobiEndpoint return new endpoint instance
-func NewHuobiEndpoint(signer Signer, interf Interface) *HuobiEndpoint {
-	return &HuobiEndpoint{signer, interf}
+func NewHuobiEndpoint(signer Signer, interf Interface, options ...EndpointOption) *HuobiEndpoint {
+	endpoint := &HuobiEndpoint{signer: signer, interf: interf, name: "huobi"}
+	for _, option := range options {
+		option(endpoint)
+	}
+	return endpoint
+}
+
+// GetCurrencyReferences returns the deposit and withdrawal references of all
+// currencies.
+func (self *HuobiEndpoint) GetCurrencyReferences() (exchange.HuobiCurrencyReferences, error) {
+	result := exchange.HuobiCurrencyReferences{}
+	respBody, err := self.GetResponse(
+		"GET",
+		self.interf.PublicEndpoint()+"/v2/reference/currencies",
+		map[string]string{},
+		false,
+	)
+	if err != nil {
+		return result, err
+	}
+	if err = json.Unmarshal(respBody, &result); err != nil {
+		return result, err
+	}
+	if result.Code != 200 {
+		return result, fmt.Errorf("Getting currency references from Huobi failed: %s", result.Message)
+	}
+	return result, nil
+}
+
+func (self *HuobiEndpoint) GetMarketStatus() (exchange.HuobiMarketStatus, error) {
+	result := exchange.HuobiMarketStatus{}
+	respBody, err := self.GetResponse(
+		"GET",
+		self.interf.PublicEndpoint()+"/v2/market-status",
+		map[string]string{},
+		false,
+	)
+	if err != nil {
+		return result, err
+	}
+	if err = json.Unmarshal(respBody, &result); err != nil {
+		return result, err
+	}
+	if result.Code != 200 {
+		return result, fmt.Errorf("Getting market status from Huobi failed: %s", result.Message)
+	}
+	return result, nil
 }
@@ -134,3 +134,36 @@ type HuobiTradeHistory struct {
 		FinishedAt uint64 `json:"finished-at"`
 	} `json:"data"`
 }
+
+// HuobiCurrencyChain is the deposit and withdrawal references of a currency
+// on one of its chains.
+type HuobiCurrencyChain struct {
+	Chain               string `json:"chain"`
+	BaseChain           string `json:"baseChain"`
+	DepositStatus       string `json:"depositStatus"`
+	MinDepositAmt       string `json:"minDepositAmt"`
+	WithdrawStatus      string `json:"withdrawStatus"`
+	TransactFeeWithdraw string `json:"transactFeeWithdraw"`
+	WithdrawFeeType     string `json:"withdrawFeeType"`
+}
+
+// HuobiCurrencyReferences is the references of currencies from the v2 API.
+type HuobiCurrencyReferences struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+	Data    []struct {
+		Currency string               `json:"currency"`
+		Chains   []HuobiCurrencyChain `json:"chains"`
+	} `json:"data"`
+}
+
+// HuobiMarketStatus is the status of Huobi market, 1 is normal, 2 is halted
+// and 3 is cancel only.
+type HuobiMarketStatus struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+	Data    struct {
+		MarketStatus int `json:"marketStatus"`
+		HaltReason   int `json:"haltReason"`
+	} `json:"data"`
+}
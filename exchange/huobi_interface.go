@@ -17,6 +17,12 @@ type HuobiInterface interface {
 
 	GetExchangeInfo() (HuobiExchangeInfo, error)
 
+	// GetCurrencyReferences returns the deposit and withdrawal references
+	// of all currencies.
+	GetCurrencyReferences() (HuobiCurrencyReferences, error)
+
+	GetMarketStatus() (HuobiMarketStatus, error)
+
 	GetDepositAddress(token string) (HuobiDepositAddress, error)
 
 	GetAccountTradeHistory(base, quote common.Token) (HuobiTradeHistory, error)
@@ -32,6 +38,18 @@ type HuobiInterface interface {
 		rate, amount float64,
 		timepoint uint64) (HuobiTrade, error)
 
+	// BuildWithdraw and BuildTrade return the unsigned requests of Withdraw
+	// and Trade without sending them.
+	BuildWithdraw(
+		token common.Token,
+		amount *big.Int,
+		address ethereum.Address) (common.DryRunRequest, error)
+
+	BuildTrade(
+		tradeType string,
+		base, quote common.Token,
+		rate, amount float64) (common.DryRunRequest, error)
+
 	CancelOrder(symbol string, id uint64) (HuobiCancel, error)
 
 	DepositHistory(tokens []common.Token) (HuobiDeposits, error)
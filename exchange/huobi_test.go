@@ -0,0 +1,56 @@
+package exchange
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/KyberNetwork/reserve-data/common"
+	"github.com/KyberNetwork/reserve-data/settings"
+)
+
+type testHuobiFeeInterface struct {
+	HuobiInterface
+}
+
+func (self testHuobiFeeInterface) GetCurrencyReferences() (HuobiCurrencyReferences, error) {
+	result := HuobiCurrencyReferences{}
+	err := json.Unmarshal([]byte(`{"code":200,"data":[
+		{"currency":"knc","chains":[
+			{"chain":"trc20knc","baseChain":"TRX","minDepositAmt":"1","transactFeeWithdraw":"0.5","withdrawFeeType":"fixed"},
+			{"chain":"knc","baseChain":"ETH","minDepositAmt":"2","transactFeeWithdraw":"5.5","withdrawFeeType":"fixed"}]},
+		{"currency":"omg","chains":[
+			{"chain":"erc20omg","baseChain":"ETH","minDepositAmt":"0.5","transactFeeWithdraw":"0.001","withdrawFeeType":"ratio"}]},
+		{"currency":"unknown","chains":[
+			{"chain":"unknown","baseChain":"ETH","minDepositAmt":"1","transactFeeWithdraw":"1","withdrawFeeType":"fixed"}]}]}`), &result)
+	return result, err
+}
+
+func TestHuobiFetchLiveFees(t *testing.T) {
+	setting := getTestBittrex("", false).setting
+	for _, token := range []common.Token{
+		common.NewToken("KNC", "Kyber Network", "0xdd974d5c2e2928dea5f71b9825b8b646686bd200", 18, true, true, 0),
+		common.NewToken("OMG", "OmiseGO", "0xd26114cd6ee289accf82350c8d8487fedb8a0c07", 18, true, true, 0),
+	} {
+		if err := setting.(*settings.Settings).UpdateToken(token, 0); err != nil {
+			t.Fatal(err)
+		}
+	}
+	huobi := &Huobi{
+		name:    settings.Huobi,
+		interf:  testHuobiFeeInterface{},
+		setting: setting,
+	}
+	fee, minDeposit, err := huobi.FetchLiveFees()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fee.Funding.Withdraw["KNC"] != 5.5 || minDeposit["KNC"] != 2 {
+		t.Errorf("Expected the fees of KNC on Ethereum, got withdraw fee %f, min deposit %f", fee.Funding.Withdraw["KNC"], minDeposit["KNC"])
+	}
+	if _, ok := fee.Funding.Withdraw["OMG"]; ok || minDeposit["OMG"] != 0.5 {
+		t.Errorf("Expected only min deposit of OMG with ratio withdrawal fee, got %v, %v", fee.Funding.Withdraw, minDeposit)
+	}
+	if _, ok := minDeposit["UNKNOWN"]; ok {
+		t.Error("Expected fees of unknown token to be ignored")
+	}
+}
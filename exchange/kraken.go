@@ -0,0 +1,642 @@
+package exchange
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"math/big"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/KyberNetwork/reserve-data/common"
+	"github.com/KyberNetwork/reserve-data/settings"
+	ethereum "github.com/ethereum/go-ethereum/common"
+)
+
+const (
+	krakenEpsilon float64 = 0.00000001 // 10e-8
+	// krakenTradeHistoryPageSize is the number of trades Kraken returns per request.
+	krakenTradeHistoryPageSize = 50
+)
+
+// krakenAltNames are the Kraken asset names of tokens which are different
+// from their token IDs.
+var krakenAltNames = map[string]string{
+	"BTC": "XBT",
+}
+
+// krakenAltName returns the Kraken asset name of the token ID, eg: XBT of BTC.
+func krakenAltName(tokenID string) string {
+	if altName, ok := krakenAltNames[tokenID]; ok {
+		return altName
+	}
+	return tokenID
+}
+
+// krakenTokenID returns the token ID of the Kraken asset name.
+func krakenTokenID(altName string) string {
+	for tokenID, name := range krakenAltNames {
+		if name == altName {
+			return tokenID
+		}
+	}
+	return altName
+}
+
+// krakenPairName returns the Kraken name of the pair, eg: KNCETH.
+func krakenPairName(base, quote string) string {
+	return krakenAltName(strings.ToUpper(base)) + krakenAltName(strings.ToUpper(quote))
+}
+
+type Kraken struct {
+	name    settings.ExchangeName
+	interf  KrakenInterface
+	storage KrakenStorage
+	setting Setting
+
+	mu sync.RWMutex
+	// assets maps Kraken asset names, eg: XETH, to their alt names, eg: ETH.
+	assets map[string]string
+}
+
+func (self *Kraken) TokenAddresses() (map[string]ethereum.Address, error) {
+	addresses, err := self.setting.GetDepositAddresses(self.name)
+	if err != nil {
+		return nil, err
+	}
+	return addresses.GetData(), nil
+}
+
+func (self *Kraken) MarshalText() (text []byte, err error) {
+	return []byte(self.ID()), nil
+}
+
+// liveAddress returns the deposit address of the token from Kraken.
+func (self *Kraken) liveAddress(token common.Token) (string, error) {
+	addresses, err := self.interf.GetDepositAddress(krakenAltName(token.ID))
+	if err != nil {
+		return "", err
+	}
+	if len(addresses.Result) == 0 || addresses.Result[0].Address == "" {
+		return "", fmt.Errorf("Kraken replies no deposit address of %s", token.ID)
+	}
+	return addresses.Result[0].Address, nil
+}
+
+// Address returns the deposit address of a token on Kraken.
+// It will prioritize the live adress from Kraken over the current address in storage
+func (self *Kraken) Address(token common.Token) (ethereum.Address, bool) {
+	liveAddress, err := self.liveAddress(token)
+	if err != nil {
+		log.Printf("WARNING: Get Kraken live deposit address for token %s failed: err: (%v). Use the currently available address instead", token.ID, err)
+		addrs, uErr := self.setting.GetDepositAddresses(self.name)
+		if uErr != nil {
+			log.Printf("WARNING: get address of token %s in Kraken exchange failed:(%s), it will be considered as not supported", token.ID, uErr.Error())
+			return ethereum.Address{}, false
+		}
+		return addrs.Get(token.ID)
+	}
+	addrs := common.NewExchangeAddresses()
+	addrs.Update(token.ID, ethereum.HexToAddress(liveAddress))
+	if err = self.setting.UpdateDepositAddress(self.name, *addrs, common.GetTimepoint()); err != nil {
+		log.Printf("WARNING: cannot update deposit address for token %s on Kraken: (%s)", token.ID, err.Error())
+	}
+	return ethereum.HexToAddress(liveAddress), true
+}
+
+func (self *Kraken) UpdateDepositAddress(token common.Token, address string) error {
+	liveAddress, err := self.liveAddress(token)
+	if err != nil {
+		log.Printf("WARNING: Get Kraken live deposit address for token %s failed: err: (%v). Use the given address instead", token.ID, err)
+		liveAddress = address
+	}
+	addrs := common.NewExchangeAddresses()
+	addrs.Update(token.ID, ethereum.HexToAddress(liveAddress))
+	return self.setting.UpdateDepositAddress(self.name, *addrs, common.GetTimepoint())
+}
+
+// krakenAssets returns the alt names of Kraken assets by their Kraken names.
+// They are queried once and cached.
+func (self *Kraken) krakenAssets() (map[string]string, error) {
+	self.mu.RLock()
+	assets := self.assets
+	self.mu.RUnlock()
+	if assets != nil {
+		return assets, nil
+	}
+	resp, err := self.interf.GetAssets()
+	if err != nil {
+		return nil, err
+	}
+	assets = make(map[string]string)
+	for name, asset := range resp.Result {
+		assets[name] = asset.AltName
+	}
+	self.mu.Lock()
+	self.assets = assets
+	self.mu.Unlock()
+	return assets, nil
+}
+
+// GetLiveExchangeInfos queries the Exchange Endpoint for exchange precision and limit of a list of tokenPairIDs
+// It return error if occurs.
+func (self *Kraken) GetLiveExchangeInfos(tokenPairIDs []common.TokenPairID) (common.ExchangeInfo, error) {
+	result := make(common.ExchangeInfo)
+	assetPairs, err := self.interf.GetAssetPairs()
+	if err != nil {
+		return result, err
+	}
+	for _, pairID := range tokenPairIDs {
+		exchangePrecisionLimit, ok := self.getPrecisionLimitFromPairs(pairID, assetPairs.Result)
+		if !ok {
+			return result, fmt.Errorf("Kraken Asset Pairs reply doesn't contain token pair %s", string(pairID))
+		}
+		result[pairID] = exchangePrecisionLimit
+	}
+	return result, nil
+}
+
+// getPrecisionLimitFromPairs find the pairID amongs asset pairs from exchange,
+// return ExchangePrecisionLimit of that pair and true if the pairID exist amongs pairs, false if otherwise
+func (self *Kraken) getPrecisionLimitFromPairs(pair common.TokenPairID, assetPairs map[string]KrakenAssetPair) (common.ExchangePrecisionLimit, bool) {
+	var result common.ExchangePrecisionLimit
+	pairIDs := strings.Split(string(pair), "-")
+	if len(pairIDs) != 2 {
+		return result, false
+	}
+	pairName := krakenPairName(pairIDs[0], pairIDs[1])
+	for _, assetPair := range assetPairs {
+		if assetPair.AltName != pairName {
+			continue
+		}
+		result.Precision.Amount = assetPair.LotDecimals
+		result.Precision.Price = assetPair.PairDecimals
+		result.AmountLimit.Min, _ = strconv.ParseFloat(assetPair.OrderMin, 64)
+		result.MinNotional, _ = strconv.ParseFloat(assetPair.CostMin, 64)
+		return result, true
+	}
+	return result, false
+}
+
+func (self *Kraken) UpdatePairsPrecision() error {
+	assetPairs, err := self.interf.GetAssetPairs()
+	if err != nil {
+		return err
+	}
+	exInfo, err := self.GetInfo()
+	if err != nil {
+		return fmt.Errorf("Can't get Exchange Info for Kraken from persistent storage. (%s)", err)
+	}
+	if exInfo == nil {
+		return errors.New("Exchange info of Kraken is nil")
+	}
+	for pair := range exInfo.GetData() {
+		exchangePrecisionLimit, exist := self.getPrecisionLimitFromPairs(pair, assetPairs.Result)
+		if !exist {
+			return fmt.Errorf("Kraken Asset Pairs reply doesn't contain token pair %s", pair)
+		}
+		exInfo[pair] = exchangePrecisionLimit
+	}
+	return self.setting.UpdateExchangeInfo(self.name, exInfo, common.GetTimepoint())
+}
+
+func (self *Kraken) GetInfo() (common.ExchangeInfo, error) {
+	return self.setting.GetExchangeInfo(self.name)
+}
+
+func (self *Kraken) GetExchangeInfo(pair common.TokenPairID) (common.ExchangePrecisionLimit, error) {
+	exInfo, err := self.setting.GetExchangeInfo(self.name)
+	if err != nil {
+		return common.ExchangePrecisionLimit{}, err
+	}
+	return exInfo.Get(pair)
+}
+
+func (self *Kraken) GetFee() (common.ExchangeFees, error) {
+	return self.setting.GetFee(self.name)
+}
+
+func (self *Kraken) GetMinDeposit() (common.ExchangesMinDeposit, error) {
+	return self.setting.GetMinDeposit(self.name)
+}
+
+// SystemStatus returns whether Kraken is not online, eg: under maintenance
+// or in cancel only mode, and the deposit and withdrawal statuses of known
+// tokens.
+func (self *Kraken) SystemStatus() (common.ExchangeSystemStatus, error) {
+	result := common.ExchangeSystemStatus{Wallets: map[string]common.TokenWalletStatus{}}
+	status, err := self.interf.GetSystemStatus()
+	if err != nil {
+		return result, err
+	}
+	if status.Result.Status != "online" {
+		result.Maintenance = true
+		result.Message = status.Result.Status
+	}
+	assets, err := self.interf.GetAssets()
+	if err != nil {
+		return result, err
+	}
+	for _, asset := range assets.Result {
+		tokenID := krakenTokenID(asset.AltName)
+		if _, err := self.setting.GetTokenByID(tokenID); err != nil {
+			continue
+		}
+		wallet := common.TokenWalletStatus{DepositEnabled: true, WithdrawEnabled: true}
+		switch asset.Status {
+		case "deposit_only":
+			wallet.WithdrawEnabled = false
+		case "withdrawal_only":
+			wallet.DepositEnabled = false
+		case "funding_temporarily_disabled":
+			wallet = common.TokenWalletStatus{}
+		}
+		result.Wallets[tokenID] = wallet
+	}
+	return result, nil
+}
+
+// ID must return the exact string or else simulation will fail
+func (self *Kraken) ID() common.ExchangeID {
+	return common.ExchangeID(self.name)
+}
+
+func (self *Kraken) TokenPairs() ([]common.TokenPair, error) {
+	result := []common.TokenPair{}
+	exInfo, err := self.setting.GetExchangeInfo(self.name)
+	if err != nil {
+		return nil, err
+	}
+	for pair := range exInfo.GetData() {
+		pairIDs := strings.Split(string(pair), "-")
+		if len(pairIDs) != 2 {
+			return result, fmt.Errorf("Kraken PairID %s is malformed", string(pair))
+		}
+		tok1, uErr := self.setting.GetTokenByID(pairIDs[0])
+		if uErr != nil {
+			return result, fmt.Errorf("Kraken cant get Token %s, %s", pairIDs[0], uErr)
+		}
+		tok2, uErr := self.setting.GetTokenByID(pairIDs[1])
+		if uErr != nil {
+			return result, fmt.Errorf("Kraken cant get Token %s, %s", pairIDs[1], uErr)
+		}
+		result = append(result, common.NewTokenPair(tok1, tok2))
+	}
+	return result, nil
+}
+
+func (self *Kraken) Name() string {
+	return "kraken"
+}
+
+func (self *Kraken) QueryOrder(txID string) (done float64, remaining float64, finished bool, err error) {
+	result, err := self.interf.OrderStatus(txID)
+	if err != nil {
+		return 0, 0, false, err
+	}
+	order, ok := result.Result[txID]
+	if !ok {
+		return 0, 0, false, fmt.Errorf("Kraken order %s is not found", txID)
+	}
+	done, _ = strconv.ParseFloat(order.VolExec, 64)
+	total, _ := strconv.ParseFloat(order.Vol, 64)
+	return done, total - done, total-done < krakenEpsilon, nil
+}
+
+func (self *Kraken) Trade(tradeType string, base common.Token, quote common.Token, rate float64, amount float64, timepoint uint64) (id string, done float64, remaining float64, finished bool, err error) {
+	result, err := self.interf.Trade(tradeType, krakenPairName(base.ID, quote.ID), rate, amount)
+	if err != nil {
+		return "", 0, 0, false, err
+	}
+	if len(result.Result.TxID) == 0 {
+		return "", 0, 0, false, errors.New("Kraken replies no transaction ID of the order")
+	}
+	id = result.Result.TxID[0]
+	done, remaining, finished, err = self.QueryOrder(id)
+	return id, done, remaining, finished, err
+}
+
+func (self *Kraken) Withdraw(token common.Token, amount *big.Int, address ethereum.Address, timepoint uint64) (string, error) {
+	result, err := self.interf.Withdraw(krakenAltName(token.ID), token, amount, address)
+	if err != nil {
+		return "", err
+	}
+	return result.Result.RefID, nil
+}
+
+// BuildTradeRequest returns the unsigned request Trade would send.
+func (self *Kraken) BuildTradeRequest(tradeType string, base, quote common.Token, rate, amount float64) (common.DryRunRequest, error) {
+	return self.interf.BuildTrade(tradeType, krakenPairName(base.ID, quote.ID), rate, amount)
+}
+
+// BuildWithdrawRequest returns the unsigned request Withdraw would send.
+func (self *Kraken) BuildWithdrawRequest(token common.Token, amount *big.Int, address ethereum.Address) (common.DryRunRequest, error) {
+	return self.interf.BuildWithdraw(krakenAltName(token.ID), token, amount, address)
+}
+
+func (self *Kraken) CancelOrder(id string, base, quote string) error {
+	result, err := self.interf.CancelOrder(id)
+	if err != nil {
+		return err
+	}
+	if result.Result.Count == 0 {
+		return fmt.Errorf("Kraken canceled no order of ID %s", id)
+	}
+	return nil
+}
+
+func (self *Kraken) FetchOnePairData(
+	wg *sync.WaitGroup,
+	pair common.TokenPair,
+	data *sync.Map,
+	timepoint uint64) {
+
+	defer wg.Done()
+	result := common.ExchangePrice{}
+	result.Timestamp = common.Timestamp(fmt.Sprintf("%d", timepoint))
+	result.Valid = true
+	respData, err := self.interf.GetDepthOnePair(krakenPairName(pair.Base.ID, pair.Quote.ID))
+	result.ReturnTime = common.GetTimestamp()
+	if err != nil {
+		result.Valid = false
+		result.Error = err.Error()
+		data.Store(pair.PairID(), result)
+		return
+	}
+	// the order book is returned by the Kraken name of the pair, which can
+	// be different from the requested name, eg: XETHXXBT of ETHXBT
+	for _, book := range respData.Result {
+		for _, buy := range book.Bids {
+			quantity, _ := strconv.ParseFloat(buy.Quantity, 64)
+			rate, _ := strconv.ParseFloat(buy.Rate, 64)
+			result.Bids = append(result.Bids, common.NewPriceEntry(quantity, rate))
+		}
+		for _, sell := range book.Asks {
+			quantity, _ := strconv.ParseFloat(sell.Quantity, 64)
+			rate, _ := strconv.ParseFloat(sell.Rate, 64)
+			result.Asks = append(result.Asks, common.NewPriceEntry(quantity, rate))
+		}
+	}
+	data.Store(pair.PairID(), result)
+}
+
+func (self *Kraken) FetchPriceData(timepoint uint64) (map[common.TokenPairID]common.ExchangePrice, error) {
+	wait := sync.WaitGroup{}
+	data := sync.Map{}
+	pairs, err := self.TokenPairs()
+	if err != nil {
+		return nil, err
+	}
+	var i int
+	var x int
+	for i < len(pairs) {
+		for x = i; x < len(pairs) && x < i+batchSize; x++ {
+			wait.Add(1)
+			go self.FetchOnePairData(&wait, pairs[x], &data, timepoint)
+		}
+		wait.Wait()
+		i = x
+	}
+	result := map[common.TokenPairID]common.ExchangePrice{}
+	data.Range(func(key, value interface{}) bool {
+		tokenPairID, ok := key.(common.TokenPairID)
+		if !ok {
+			err = fmt.Errorf("Key (%v) cannot be asserted to TokenPairID", key)
+			return false
+		}
+		exPrice, ok := value.(common.ExchangePrice)
+		if !ok {
+			err = fmt.Errorf("Value (%v) cannot be asserted to ExchangePrice", value)
+			return false
+		}
+		result[tokenPairID] = exPrice
+		return true
+	})
+	return result, err
+}
+
+func (self *Kraken) FetchEBalanceData(timepoint uint64) (common.EBalanceEntry, error) {
+	result := common.EBalanceEntry{}
+	result.Timestamp = common.Timestamp(fmt.Sprintf("%d", timepoint))
+	result.Valid = true
+	assets, err := self.krakenAssets()
+	var respData KrakenBalances
+	if err == nil {
+		respData, err = self.interf.GetInfo()
+	}
+	result.ReturnTime = common.GetTimestamp()
+	if err != nil {
+		result.Valid = false
+		result.Error = err.Error()
+		result.Status = false
+		return result, nil
+	}
+	result.AvailableBalance = map[string]float64{}
+	result.LockedBalance = map[string]float64{}
+	result.DepositBalance = map[string]float64{}
+	result.Status = true
+	for name, b := range respData.Result {
+		altName, ok := assets[name]
+		if !ok {
+			altName = name
+		}
+		tokenID := krakenTokenID(altName)
+		if _, err := self.setting.GetTokenByID(tokenID); err != nil {
+			continue
+		}
+		balance, _ := strconv.ParseFloat(b.Balance, 64)
+		locked, _ := strconv.ParseFloat(b.HoldTrade, 64)
+		result.AvailableBalance[tokenID] = balance - locked
+		result.LockedBalance[tokenID] = locked
+		result.DepositBalance[tokenID] = 0
+	}
+	return result, nil
+}
+
+// krakenPairIDs returns the token pair IDs of the exchange by their Kraken pair names.
+func (self *Kraken) krakenPairIDs() (map[string]common.TokenPairID, error) {
+	pairs, err := self.TokenPairs()
+	if err != nil {
+		return nil, err
+	}
+	assetPairs, err := self.interf.GetAssetPairs()
+	if err != nil {
+		return nil, err
+	}
+	result := make(map[string]common.TokenPairID)
+	for _, pair := range pairs {
+		pairName := krakenPairName(pair.Base.ID, pair.Quote.ID)
+		for name, assetPair := range assetPairs.Result {
+			if assetPair.AltName == pairName {
+				result[name] = pair.PairID()
+				result[pairName] = pair.PairID()
+			}
+		}
+	}
+	return result, nil
+}
+
+// FetchTradeHistoryFrom fetches trades of all pairs of the exchange, made
+// after the given time in milliseconds.
+func (self *Kraken) FetchTradeHistoryFrom(fromTime uint64) (common.ExchangeTradeHistory, error) {
+	result := common.ExchangeTradeHistory{}
+	pairIDs, err := self.krakenPairIDs()
+	if err != nil {
+		return result, err
+	}
+	for offset := 0; ; offset += krakenTradeHistoryPageSize {
+		resp, err := self.interf.GetAccountTradeHistory(fromTime/1000, offset)
+		if err != nil {
+			return result, err
+		}
+		for id, trade := range resp.Result.Trades {
+			pairID, ok := pairIDs[trade.Pair]
+			if !ok {
+				continue
+			}
+			price, _ := strconv.ParseFloat(trade.Price, 64)
+			quantity, _ := strconv.ParseFloat(trade.Vol, 64)
+			result[pairID] = append(result[pairID], common.NewTradeHistory(
+				id,
+				price,
+				quantity,
+				trade.Type,
+				uint64(trade.Time*1000),
+			))
+		}
+		if len(resp.Result.Trades) == 0 || offset+krakenTradeHistoryPageSize >= resp.Result.Count {
+			return result, nil
+		}
+	}
+}
+
+// FetchTradeHistory get all trade history for all tokens in the exchange
+func (self *Kraken) FetchTradeHistory() {
+	t := time.NewTicker(10 * time.Minute)
+	go func() {
+		for {
+			fromTime, err := self.storage.GetLastTradeTimestamp()
+			if err != nil {
+				log.Printf("Kraken Cannot get last trade timestamp: %s", err.Error())
+			}
+			result, err := self.FetchTradeHistoryFrom(fromTime)
+			if err != nil {
+				log.Printf("Kraken fetch trade history failed (%s). Try again in 10 mins", err.Error())
+			} else if err = self.storage.StoreTradeHistory(result); err != nil {
+				log.Printf("Kraken Store trade history error: %s", err.Error())
+			}
+			<-t.C
+		}
+	}()
+}
+
+func (self *Kraken) GetTradeHistory(fromTime, toTime uint64) (common.ExchangeTradeHistory, error) {
+	return self.storage.GetTradeHistory(fromTime, toTime)
+}
+
+// krakenFundingStatus returns the exchange status of a Kraken deposit or withdrawal status.
+func krakenFundingStatus(status string) string {
+	switch status {
+	case "Success":
+		return common.ExchangeStatusDone
+	case "Failure":
+		return common.ExchangeStatusFailed
+	default:
+		return ""
+	}
+}
+
+func (self *Kraken) DepositStatus(id common.ActivityID, txHash, currency string, amount float64, timepoint uint64) (string, error) {
+	deposits, err := self.interf.DepositHistory(krakenAltName(currency))
+	if err != nil {
+		return "", err
+	}
+	for _, deposit := range deposits.Result {
+		if strings.EqualFold(deposit.TxID, txHash) {
+			return krakenFundingStatus(deposit.Status), nil
+		}
+	}
+	log.Printf("Kraken Deposit %s is not found in deposit list returned from Kraken.", txHash)
+	return "", nil
+}
+
+func (self *Kraken) WithdrawStatus(id, currency string, amount float64, timepoint uint64) (string, string, error) {
+	withdrawals, err := self.interf.WithdrawHistory(krakenAltName(currency))
+	if err != nil {
+		return "", "", err
+	}
+	for _, withdrawal := range withdrawals.Result {
+		if withdrawal.RefID == id {
+			return krakenFundingStatus(withdrawal.Status), withdrawal.TxID, nil
+		}
+	}
+	log.Printf("Kraken Withdrawal %s doesn't exist in withdrawal list returned from Kraken.", id)
+	return "", "", nil
+}
+
+func (self *Kraken) OrderStatus(id string, base, quote string) (string, error) {
+	result, err := self.interf.OrderStatus(id)
+	if err != nil {
+		return "", err
+	}
+	order, ok := result.Result[id]
+	if !ok {
+		return "", fmt.Errorf("Kraken order %s is not found", id)
+	}
+	if order.Status == "pending" || order.Status == "open" {
+		return "", nil
+	}
+	return common.ExchangeStatusDone, nil
+}
+
+// OrderFill returns the filled and remaining amounts of the order.
+func (self *Kraken) OrderFill(id string, base, quote string) (float64, float64, bool, error) {
+	result, err := self.interf.OrderStatus(id)
+	if err != nil {
+		return 0, 0, false, err
+	}
+	order, ok := result.Result[id]
+	if !ok {
+		return 0, 0, false, fmt.Errorf("Kraken order %s is not found", id)
+	}
+	done, err := strconv.ParseFloat(order.VolExec, 64)
+	if err != nil {
+		return 0, 0, false, err
+	}
+	total, err := strconv.ParseFloat(order.Vol, 64)
+	if err != nil {
+		return 0, 0, false, err
+	}
+	return done, total - done, order.Status != "pending" && order.Status != "open", nil
+}
+
+// KrakenOption is the option to setup the Kraken exchange on creation.
+type KrakenOption func(k *Kraken)
+
+// WithKrakenName setups Kraken to run as the exchange account of the given
+// name instead of the default one.
+func WithKrakenName(name settings.ExchangeName) KrakenOption {
+	return func(k *Kraken) {
+		k.name = name
+	}
+}
+
+func NewKraken(
+	interf KrakenInterface,
+	storage KrakenStorage,
+	setting Setting,
+	options ...KrakenOption) (*Kraken, error) {
+	kraken := &Kraken{
+		name:    settings.Kraken,
+		interf:  interf,
+		storage: storage,
+		setting: setting,
+	}
+	for _, option := range options {
+		option(kraken)
+	}
+	kraken.FetchTradeHistory()
+	return kraken, nil
+}
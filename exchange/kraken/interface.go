@@ -0,0 +1,100 @@
+package kraken
+
+import "fmt"
+
+const krakenAPIEndpoint = "https://api.kraken.com"
+
+// Interface is Kraken exchange API endpoints interface.
+type Interface interface {
+	// PublicEndpoint returns the endpoint that does not requires authentication.
+	PublicEndpoint() string
+	// AuthenticatedEndpoint returns the endpoint that requires authentication.
+	// In simulation mode, authenticated endpoint is the Kraken mock server.
+	AuthenticatedEndpoint() string
+}
+
+// getSimulationURL returns url of the simulated Kraken endpoint.
+// It returns the local default endpoint if given URL empty.
+func getSimulationURL(baseURL string) string {
+	const port = "5400"
+	if len(baseURL) == 0 {
+		baseURL = "http://127.0.0.1"
+	}
+	return fmt.Sprintf("%s:%s", baseURL, port)
+}
+
+type RealInterface struct{}
+
+func (self *RealInterface) PublicEndpoint() string {
+	return krakenAPIEndpoint
+}
+
+func (self *RealInterface) AuthenticatedEndpoint() string {
+	return krakenAPIEndpoint
+}
+
+func NewRealInterface() *RealInterface {
+	return &RealInterface{}
+}
+
+type SimulatedInterface struct {
+	baseURL  string
+	endpoint string
+}
+
+// simulationURL returns the endpoint of the simulated Kraken, or the default
+// simulator at base URL if the endpoint is not given.
+func (self *SimulatedInterface) simulationURL() string {
+	if len(self.endpoint) != 0 {
+		return self.endpoint
+	}
+	return getSimulationURL(self.baseURL)
+}
+
+func (self *SimulatedInterface) PublicEndpoint() string {
+	return self.simulationURL()
+}
+
+func (self *SimulatedInterface) AuthenticatedEndpoint() string {
+	return self.simulationURL()
+}
+
+func NewSimulatedInterface(flagVariable string) *SimulatedInterface {
+	return &SimulatedInterface{baseURL: flagVariable}
+}
+
+// NewSimulatedEndpointInterface returns the interface of a simulated Kraken
+// served at the given endpoint, eg: http://127.0.0.1:5401.
+func NewSimulatedEndpointInterface(endpoint string) *SimulatedInterface {
+	return &SimulatedInterface{endpoint: endpoint}
+}
+
+type RopstenInterface struct {
+	baseURL string
+}
+
+func (self *RopstenInterface) PublicEndpoint() string {
+	return krakenAPIEndpoint
+}
+
+func (self *RopstenInterface) AuthenticatedEndpoint() string {
+	return getSimulationURL(self.baseURL)
+}
+
+func NewRopstenInterface(flagVariable string) *RopstenInterface {
+	return &RopstenInterface{baseURL: flagVariable}
+}
+
+type DevInterface struct{}
+
+func (self *DevInterface) PublicEndpoint() string {
+	return krakenAPIEndpoint
+}
+
+func (self *DevInterface) AuthenticatedEndpoint() string {
+	return krakenAPIEndpoint
+}
+
+func NewDevInterface() *DevInterface {
+	return &DevInterface{}
+}
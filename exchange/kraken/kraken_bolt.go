@@ -0,0 +1,124 @@
+package kraken
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strconv"
+	"sync"
+
+	"github.com/KyberNetwork/reserve-data/common"
+	"github.com/boltdb/bolt"
+)
+
+const (
+	tradeHistory       string = "trade_history"
+	maxGetTradeHistory uint64 = 3 * 86400000
+)
+
+// KrakenStorage storage kraken information
+// including trade history
+type KrakenStorage struct {
+	mu sync.RWMutex
+	db *bolt.DB
+}
+
+// NewBoltStorage create database and related bucket for kraken storage
+func NewBoltStorage(path string) (*KrakenStorage, error) {
+	var err error
+	var db *bolt.DB
+	db, err = bolt.Open(path, 0600, nil)
+	if err != nil {
+		return nil, err
+	}
+	err = db.Update(func(tx *bolt.Tx) error {
+		_, err = tx.CreateBucketIfNotExists([]byte(tradeHistory))
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	storage := &KrakenStorage{sync.RWMutex{}, db}
+	return storage, nil
+}
+
+// StoreTradeHistory store kraken trade history
+func (ks *KrakenStorage) StoreTradeHistory(data common.ExchangeTradeHistory) error {
+	return ks.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket([]byte(tradeHistory))
+		for pair, pairHistory := range data {
+			pairBk, uErr := b.CreateBucketIfNotExists([]byte(pair))
+			if uErr != nil {
+				return uErr
+			}
+			for _, history := range pairHistory {
+				idBytes := []byte(fmt.Sprintf("%s%s", strconv.FormatUint(history.Timestamp, 10), history.ID))
+				dataJSON, uErr := json.Marshal(history)
+				if uErr != nil {
+					return uErr
+				}
+				if uErr = pairBk.Put(idBytes, dataJSON); uErr != nil {
+					return uErr
+				}
+			}
+		}
+		return nil
+	})
+}
+
+// GetTradeHistory return trade history from kraken from time to time
+func (ks *KrakenStorage) GetTradeHistory(fromTime, toTime uint64) (common.ExchangeTradeHistory, error) {
+	result := common.ExchangeTradeHistory{}
+	if toTime-fromTime > maxGetTradeHistory {
+		return result, fmt.Errorf("Time range is too broad, it must be smaller or equal to 3 days (miliseconds)")
+	}
+	min := []byte(strconv.FormatUint(fromTime, 10))
+	max := []byte(strconv.FormatUint(toTime, 10))
+	err := ks.db.View(func(tx *bolt.Tx) error {
+		b := tx.Bucket([]byte(tradeHistory))
+		c := b.Cursor()
+		for key, value := c.First(); key != nil && value == nil; key, value = c.Next() {
+			pairBk := b.Bucket(key)
+			pairsHistory := []common.TradeHistory{}
+			pairCursor := pairBk.Cursor()
+			for pairKey, history := pairCursor.Seek(min); pairKey != nil && bytes.Compare(pairKey, max) <= 0; pairKey, history = pairCursor.Next() {
+				pairHistory := common.TradeHistory{}
+				if err := json.Unmarshal(history, &pairHistory); err != nil {
+					log.Printf("Cannot unmarshal history: %s", err.Error())
+					return err
+				}
+				pairsHistory = append(pairsHistory, pairHistory)
+			}
+			result[common.TokenPairID(key)] = pairsHistory
+		}
+		return nil
+	})
+	return result, err
+}
+
+// GetLastTradeTimestamp return the timestamp of the latest trade of all pairs,
+// trade history is queried from kraken from that time on.
+func (ks *KrakenStorage) GetLastTradeTimestamp() (uint64, error) {
+	var last uint64
+	err := ks.db.View(func(tx *bolt.Tx) error {
+		b := tx.Bucket([]byte(tradeHistory))
+		c := b.Cursor()
+		for key, value := c.First(); key != nil && value == nil; key, value = c.Next() {
+			k, v := b.Bucket(key).Cursor().Last()
+			if k == nil {
+				continue
+			}
+			history := common.TradeHistory{}
+			if err := json.Unmarshal(v, &history); err != nil {
+				log.Printf("Cannot unmarshal history: %s", err.Error())
+				return err
+			}
+			if history.Timestamp > last {
+				last = history.Timestamp
+			}
+		}
+		return nil
+	})
+	return last, err
+}
@@ -0,0 +1,77 @@
+package kraken
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/KyberNetwork/reserve-data/common"
+)
+
+func TestKrakenStorage(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "kraken_storage")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if rErr := os.RemoveAll(tmpDir); rErr != nil {
+			t.Error(rErr)
+		}
+	}()
+
+	exchangeTradeHistory := common.ExchangeTradeHistory{
+		common.TokenPairID("KNC-ETH"): []common.TradeHistory{
+			{
+				ID:        "TCWJEG-FL4SZ-3FKGH6",
+				Price:     0.00312,
+				Qty:       120,
+				Type:      "buy",
+				Timestamp: 1528949872000,
+			},
+		},
+		common.TokenPairID("OMG-ETH"): []common.TradeHistory{
+			{
+				ID:        "THVRQM-33VKH-UCI7BS",
+				Price:     0.0121,
+				Qty:       30.5,
+				Type:      "sell",
+				Timestamp: 1528949990000,
+			},
+		},
+	}
+
+	storage, err := NewBoltStorage(filepath.Join(tmpDir, "test_kraken_bolt.db"))
+	if err != nil {
+		t.Fatalf("Could not init kraken bolt storage: %s", err.Error())
+	}
+
+	last, err := storage.GetLastTradeTimestamp()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if last != 0 {
+		t.Fatalf("Expected no last trade timestamp of empty storage, got %d", last)
+	}
+
+	if err = storage.StoreTradeHistory(exchangeTradeHistory); err != nil {
+		t.Fatal(err)
+	}
+
+	tradeHistory, err := storage.GetTradeHistory(1528934400000, 1529020800000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(tradeHistory, exchangeTradeHistory) {
+		t.Fatalf("Get wrong trade history: %+v", tradeHistory)
+	}
+
+	last, err = storage.GetLastTradeTimestamp()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if last != 1528949990000 {
+		t.Fatalf("Expected last trade timestamp 1528949990000, got %d", last)
+	}
+}
@@ -0,0 +1,339 @@
+package kraken
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"math/big"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/KyberNetwork/reserve-data/common"
+	"github.com/KyberNetwork/reserve-data/common/instrument"
+	"github.com/KyberNetwork/reserve-data/exchange"
+	ethereum "github.com/ethereum/go-ethereum/common"
+)
+
+// depthLimit is the number of levels of each side of order book to query.
+const depthLimit = 50
+
+// KrakenEndpoint object stand for Kraken endpoint
+// including signer for api call authentication and
+// interf for calling api in different env.
+type KrakenEndpoint struct {
+	signer Signer
+	interf Interface
+	// name is the exchange ID labelling the request metrics.
+	name string
+
+	mu sync.Mutex
+	// lastNonce is the nonce of the latest private request, Kraken rejects
+	// nonces which are not greater than the previous one.
+	lastNonce uint64
+}
+
+// nonce returns a strictly increasing nonce based on current time in milliseconds.
+func (self *KrakenEndpoint) nonce() string {
+	self.mu.Lock()
+	defer self.mu.Unlock()
+	nonce := common.GetTimepoint()
+	if nonce <= self.lastNonce {
+		nonce = self.lastNonce + 1
+	}
+	self.lastNonce = nonce
+	return strconv.FormatUint(nonce, 10)
+}
+
+// newRequest creates the request to url with the params. Public requests
+// have parameters in query, private requests are signed and have parameters
+// in form body, it also returns the form values of private requests.
+func (self *KrakenEndpoint) newRequest(
+	method string, url string,
+	params map[string]string, signNeeded bool) (*http.Request, url.Values, error) {
+	var (
+		err error
+		req *http.Request
+	)
+	values := urlValues(params)
+	if signNeeded {
+		values.Set("nonce", self.nonce())
+		postData := values.Encode()
+		req, err = http.NewRequest(method, url, strings.NewReader(postData))
+		if err != nil {
+			return nil, nil, err
+		}
+		req.Header.Add("Content-Type", "application/x-www-form-urlencoded")
+		req.Header.Add("API-Key", self.signer.GetKey())
+		req.Header.Add("API-Sign", self.signer.Sign(req.URL.Path, values.Get("nonce"), postData))
+	} else {
+		req, err = http.NewRequest(method, url, nil)
+		if err != nil {
+			return nil, nil, err
+		}
+		req.URL.RawQuery = values.Encode()
+	}
+	req.Header.Add("Accept", "application/json")
+	return req, values, nil
+}
+
+// buildPrivate creates the unsigned private request of the path without
+// sending it, it doesn't take a nonce.
+func (self *KrakenEndpoint) buildPrivate(path string, params map[string]string) (common.DryRunRequest, error) {
+	req, values, err := self.newRequest("POST", self.interf.AuthenticatedEndpoint()+path, params, false)
+	if err != nil {
+		return common.DryRunRequest{}, err
+	}
+	return common.NewDryRunRequest(req.Method, req.URL, values), nil
+}
+
+// GetResponse sends the request to Kraken and returns the response body.
+func (self *KrakenEndpoint) GetResponse(
+	method string, url string,
+	params map[string]string, signNeeded bool) ([]byte, error) {
+	var respBody []byte
+	client := &http.Client{
+		Timeout: time.Duration(30 * time.Second),
+	}
+	req, _, err := self.newRequest(method, url, params, signNeeded)
+	if err != nil {
+		return nil, err
+	}
+	defer func(start time.Time) {
+		instrument.ObserveExchangeRequest(self.name, req.URL.Path, start, err)
+	}(time.Now())
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return respBody, err
+	}
+	defer func() {
+		if cErr := resp.Body.Close(); cErr != nil {
+			log.Printf("Response body close error: %s", cErr.Error())
+		}
+	}()
+	switch resp.StatusCode {
+	case 429:
+		err = errors.New("breaking Kraken request rate limit")
+	case 500, 502, 503:
+		err = fmt.Errorf("%d from Kraken, its fault", resp.StatusCode)
+	case 200:
+		respBody, err = ioutil.ReadAll(resp.Body)
+	default:
+		err = fmt.Errorf("Kraken return with code: %d", resp.StatusCode)
+	}
+	if err != nil || len(respBody) == 0 {
+		log.Printf("request to %s, got response from Kraken: %s, err: %s", req.URL, common.TruncStr(respBody), common.ErrorToString(err))
+	}
+	return respBody, err
+}
+
+func urlValues(params map[string]string) url.Values {
+	values := url.Values{}
+	for k, v := range params {
+		values.Set(k, v)
+	}
+	return values
+}
+
+// krakenResponse is a Kraken response which may contains errors.
+type krakenResponse interface {
+	KrakenError() error
+}
+
+// query sends the request and decodes the response to result, it returns
+// error if the request failed or Kraken replies with errors.
+func (self *KrakenEndpoint) query(
+	method, url string,
+	params map[string]string, signNeeded bool, result krakenResponse) error {
+	respBody, err := self.GetResponse(method, url, params, signNeeded)
+	if err != nil {
+		return err
+	}
+	if err = json.Unmarshal(respBody, result); err != nil {
+		return err
+	}
+	return result.KrakenError()
+}
+
+func (self *KrakenEndpoint) public(path string, params map[string]string, result krakenResponse) error {
+	return self.query("GET", self.interf.PublicEndpoint()+path, params, false, result)
+}
+
+func (self *KrakenEndpoint) private(path string, params map[string]string, result krakenResponse) error {
+	return self.query("POST", self.interf.AuthenticatedEndpoint()+path, params, true, result)
+}
+
+func (self *KrakenEndpoint) GetDepthOnePair(pair string) (exchange.KrakenDepth, error) {
+	result := exchange.KrakenDepth{}
+	err := self.public("/0/public/Depth", map[string]string{
+		"pair":  pair,
+		"count": strconv.Itoa(depthLimit),
+	}, &result)
+	return result, err
+}
+
+func (self *KrakenEndpoint) GetAssets() (exchange.KrakenAssets, error) {
+	result := exchange.KrakenAssets{}
+	err := self.public("/0/public/Assets", nil, &result)
+	return result, err
+}
+
+func (self *KrakenEndpoint) GetAssetPairs() (exchange.KrakenAssetPairs, error) {
+	result := exchange.KrakenAssetPairs{}
+	err := self.public("/0/public/AssetPairs", nil, &result)
+	return result, err
+}
+
+func (self *KrakenEndpoint) GetSystemStatus() (exchange.KrakenSystemStatus, error) {
+	result := exchange.KrakenSystemStatus{}
+	err := self.public("/0/public/SystemStatus", nil, &result)
+	return result, err
+}
+
+func (self *KrakenEndpoint) GetServerTime() (exchange.KrakenServerTime, error) {
+	result := exchange.KrakenServerTime{}
+	err := self.public("/0/public/Time", nil, &result)
+	return result, err
+}
+
+func (self *KrakenEndpoint) GetInfo() (exchange.KrakenBalances, error) {
+	result := exchange.KrakenBalances{}
+	err := self.private("/0/private/BalanceEx", nil, &result)
+	return result, err
+}
+
+// GetDepositAddress returns the deposit addresses of the asset, using the
+// first deposit method Kraken supports for it.
+func (self *KrakenEndpoint) GetDepositAddress(asset string) (exchange.KrakenDepositAddresses, error) {
+	result := exchange.KrakenDepositAddresses{}
+	methods := exchange.KrakenDepositMethods{}
+	if err := self.private("/0/private/DepositMethods", map[string]string{"asset": asset}, &methods); err != nil {
+		return result, err
+	}
+	if len(methods.Result) == 0 {
+		return result, fmt.Errorf("Kraken has no deposit method of %s", asset)
+	}
+	err := self.private("/0/private/DepositAddresses", map[string]string{
+		"asset":  asset,
+		"method": methods.Result[0].Method,
+	}, &result)
+	return result, err
+}
+
+func (self *KrakenEndpoint) GetAccountTradeHistory(start uint64, offset int) (exchange.KrakenTradeHistory, error) {
+	result := exchange.KrakenTradeHistory{}
+	err := self.private("/0/private/TradesHistory", map[string]string{
+		"start": strconv.FormatUint(start, 10),
+		"ofs":   strconv.Itoa(offset),
+	}, &result)
+	return result, err
+}
+
+func (self *KrakenEndpoint) withdrawParams(asset string, token common.Token, amount *big.Int, address ethereum.Address) (map[string]string, error) {
+	key, ok := self.signer.WithdrawKey(token.ID)
+	if !ok {
+		return nil, fmt.Errorf("no Kraken withdraw key of %s", token.ID)
+	}
+	return map[string]string{
+		"asset":   asset,
+		"key":     key,
+		"address": address.Hex(),
+		"amount":  strconv.FormatFloat(common.BigToFloat(amount, token.Decimals), 'f', -1, 64),
+	}, nil
+}
+
+// Withdraw withdraws the token to the withdrawal address set up in Kraken
+// account under the withdraw key of the token, the address is sent for
+// Kraken to verify that it is the expected one.
+func (self *KrakenEndpoint) Withdraw(asset string, token common.Token, amount *big.Int, address ethereum.Address) (exchange.KrakenWithdraw, error) {
+	result := exchange.KrakenWithdraw{}
+	params, err := self.withdrawParams(asset, token, amount, address)
+	if err != nil {
+		return result, err
+	}
+	if err = self.private("/0/private/Withdraw", params, &result); err != nil {
+		return result, fmt.Errorf("withdraw rejected by Kraken: %s", err.Error())
+	}
+	return result, nil
+}
+
+// BuildWithdraw returns the signed request Withdraw would send.
+func (self *KrakenEndpoint) BuildWithdraw(asset string, token common.Token, amount *big.Int, address ethereum.Address) (common.DryRunRequest, error) {
+	params, err := self.withdrawParams(asset, token, amount, address)
+	if err != nil {
+		return common.DryRunRequest{}, err
+	}
+	return self.buildPrivate("/0/private/Withdraw", params)
+}
+
+func tradeParams(tradeType string, pair string, rate, amount float64) map[string]string {
+	return map[string]string{
+		"pair":      pair,
+		"type":      strings.ToLower(tradeType),
+		"ordertype": "limit",
+		"price":     strconv.FormatFloat(rate, 'f', -1, 64),
+		"volume":    strconv.FormatFloat(amount, 'f', -1, 64),
+	}
+}
+
+// Trade places a limit order of the pair on Kraken.
+func (self *KrakenEndpoint) Trade(tradeType string, pair string, rate, amount float64) (exchange.KrakenTrade, error) {
+	result := exchange.KrakenTrade{}
+	err := self.private("/0/private/AddOrder", tradeParams(tradeType, pair, rate, amount), &result)
+	return result, err
+}
+
+// BuildTrade returns the signed request Trade would send.
+func (self *KrakenEndpoint) BuildTrade(tradeType string, pair string, rate, amount float64) (common.DryRunRequest, error) {
+	return self.buildPrivate("/0/private/AddOrder", tradeParams(tradeType, pair, rate, amount))
+}
+
+func (self *KrakenEndpoint) CancelOrder(txID string) (exchange.KrakenCancel, error) {
+	result := exchange.KrakenCancel{}
+	err := self.private("/0/private/CancelOrder", map[string]string{"txid": txID}, &result)
+	return result, err
+}
+
+func (self *KrakenEndpoint) DepositHistory(asset string) (exchange.KrakenFundingStatus, error) {
+	result := exchange.KrakenFundingStatus{}
+	err := self.private("/0/private/DepositStatus", map[string]string{"asset": asset}, &result)
+	return result, err
+}
+
+func (self *KrakenEndpoint) WithdrawHistory(asset string) (exchange.KrakenFundingStatus, error) {
+	result := exchange.KrakenFundingStatus{}
+	err := self.private("/0/private/WithdrawStatus", map[string]string{"asset": asset}, &result)
+	return result, err
+}
+
+func (self *KrakenEndpoint) OrderStatus(txID string) (exchange.KrakenOrders, error) {
+	result := exchange.KrakenOrders{}
+	err := self.private("/0/private/QueryOrders", map[string]string{"txid": txID}, &result)
+	return result, err
+}
+
+// EndpointOption is the option of NewKrakenEndpoint.
+type EndpointOption func(k *KrakenEndpoint)
+
+// WithEndpointName setups the endpoint to label its request metrics with the
+// given exchange ID instead of "kraken", eg: of a sub-account.
+func WithEndpointName(name string) EndpointOption {
+	return func(k *KrakenEndpoint) {
+		k.name = name
+	}
+}
+
+// NewKrakenEndpoint return new endpoint instance for using Kraken
+func NewKrakenEndpoint(signer Signer, interf Interface, options ...EndpointOption) *KrakenEndpoint {
+	endpoint := &KrakenEndpoint{signer: signer, interf: interf, name: "kraken"}
+	for _, option := range options {
+		option(endpoint)
+	}
+	return endpoint
+}
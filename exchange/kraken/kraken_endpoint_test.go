@@ -0,0 +1,255 @@
+package kraken
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/KyberNetwork/reserve-data/common"
+	"github.com/KyberNetwork/reserve-data/exchange"
+	"github.com/KyberNetwork/reserve-data/settings"
+	"github.com/KyberNetwork/reserve-data/settings/storage"
+	ethereum "github.com/ethereum/go-ethereum/common"
+)
+
+// newFixtureServer returns a server replying Kraken API requests with the
+// recorded responses in testdata, by the method name of the request path.
+// Private requests must be signed.
+func newFixtureServer(t *testing.T) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == "POST" {
+			if err := r.ParseForm(); err != nil {
+				t.Fatal(err)
+			}
+			if r.Header.Get("API-Key") == "" || r.Header.Get("API-Sign") == "" || r.PostForm.Get("nonce") == "" {
+				_, _ = w.Write([]byte(`{"error":["EAPI:Invalid key"]}`))
+				return
+			}
+		}
+		fixture, err := ioutil.ReadFile(filepath.Join("testdata", path.Base(r.URL.Path)+".json"))
+		if err != nil {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_, _ = w.Write(fixture)
+	}))
+}
+
+func newTestKraken(t *testing.T, endpoint string, tmpDir string) *exchange.Kraken {
+	boltSettingStorage, err := storage.NewBoltSettingStorage(filepath.Join(tmpDir, "setting.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tokenSetting, err := settings.NewTokenSetting(boltSettingStorage)
+	if err != nil {
+		t.Fatal(err)
+	}
+	exchangeSetting, err := settings.NewExchangeSetting(boltSettingStorage)
+	if err != nil {
+		t.Fatal(err)
+	}
+	setting, err := settings.NewSetting(tokenSetting, &settings.AddressSetting{}, exchangeSetting)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, token := range []common.Token{
+		common.NewToken("ETH", "Ethereum", "0xeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeee", 18, true, true, 0),
+		common.NewToken("KNC", "Kyber Network", "0xdd974d5c2e2928dea5f71b9825b8b646686bd200", 18, true, true, 0),
+	} {
+		if err = setting.UpdateToken(token, 0); err != nil {
+			t.Fatal(err)
+		}
+	}
+	exInfo := common.ExchangeInfo{"KNC-ETH": common.ExchangePrecisionLimit{}}
+	if err = setting.UpdateExchangeInfo(settings.Kraken, exInfo, 0); err != nil {
+		t.Fatal(err)
+	}
+	krakenStorage, err := NewBoltStorage(filepath.Join(tmpDir, "kraken.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	signer := NewSigner("key", "c2VjcmV0", map[string]string{"KNC": "reserve"})
+	kraken, err := exchange.NewKraken(
+		NewKrakenEndpoint(*signer, NewSimulatedEndpointInterface(endpoint)),
+		krakenStorage,
+		setting,
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return kraken
+}
+
+func TestKrakenWithRecordedResponses(t *testing.T) {
+	server := newFixtureServer(t)
+	defer server.Close()
+	tmpDir, err := ioutil.TempDir("", "kraken_test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if rErr := os.RemoveAll(tmpDir); rErr != nil {
+			t.Error(rErr)
+		}
+	}()
+	kraken := newTestKraken(t, server.URL, tmpDir)
+	timepoint := common.GetTimepoint()
+
+	prices, err := kraken.FetchPriceData(timepoint)
+	if err != nil {
+		t.Fatal(err)
+	}
+	price := prices["KNC-ETH"]
+	if !price.Valid || len(price.Bids) != 2 || len(price.Asks) != 2 {
+		t.Fatalf("Expected valid order book of KNC-ETH, got %+v", price)
+	}
+	if price.Bids[0] != common.NewPriceEntry(80, 0.0031) || price.Asks[1] != common.NewPriceEntry(300.5, 0.00315) {
+		t.Errorf("Got wrong order book: %+v", price)
+	}
+
+	balances, err := kraken.FetchEBalanceData(timepoint)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !balances.Valid {
+		t.Fatalf("Expected valid balances, got error: %s", balances.Error)
+	}
+	if balances.AvailableBalance["KNC"] != 1380 || balances.LockedBalance["KNC"] != 120 {
+		t.Errorf("Got wrong KNC balance, available: %f, locked: %f", balances.AvailableBalance["KNC"], balances.LockedBalance["KNC"])
+	}
+	if balances.AvailableBalance["ETH"] != 25.5 {
+		t.Errorf("Got wrong ETH balance: %f", balances.AvailableBalance["ETH"])
+	}
+	if _, ok := balances.AvailableBalance["USD"]; ok {
+		t.Error("Expected balance of unknown token to be ignored")
+	}
+
+	knc, err := kraken.TokenPairs()
+	if err != nil || len(knc) != 1 {
+		t.Fatalf("Expected 1 token pair, got %v, err: %v", knc, err)
+	}
+	id, done, remaining, finished, err := kraken.Trade("buy", knc[0].Base, knc[0].Quote, 0.00312, 120, timepoint)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if id != "OUF4EM-FRGI2-MQMWZD" || done != 20 || remaining != 100 || finished {
+		t.Errorf("Got wrong trade result: %s %f %f %t", id, done, remaining, finished)
+	}
+	status, err := kraken.OrderStatus(id, "KNC", "ETH")
+	if err != nil || status != "" {
+		t.Errorf("Expected open order to be pending, got %s, err: %v", status, err)
+	}
+	if err = kraken.CancelOrder(id, "KNC", "ETH"); err != nil {
+		t.Error(err)
+	}
+
+	refID, err := kraken.Withdraw(knc[0].Base, common.FloatToBigInt(10, 18), ethereum.HexToAddress("0x3f105f78359ad80562b4c34296a87b8e66c584c5"), timepoint)
+	if err != nil || refID != "AGBSO6T-UFMTTQ-I7KGS6" {
+		t.Fatalf("Expected withdrawal AGBSO6T-UFMTTQ-I7KGS6, got %s, err: %v", refID, err)
+	}
+	status, txHash, err := kraken.WithdrawStatus(refID, "KNC", 10, timepoint)
+	if err != nil || status != common.ExchangeStatusDone || txHash != "0x8a345f58910b99843e3ccd852f15cbb2601d455f39038de2dc08589e7c39e0a8" {
+		t.Errorf("Got wrong withdrawal status: %s %s, err: %v", status, txHash, err)
+	}
+
+	activityID := common.NewActivityID(timepoint, "deposit")
+	for txHash, expected := range map[string]string{
+		"0x15CCAAB008F161EFEEE0FEBC3E32242846CEA1FC93995E5ABC6FB88D94AE7D21": common.ExchangeStatusDone,
+		"0x32fae94e542b36a409c0d602e342743f8bcda3d1e1e1e26022abe050cfaf80a6": "",
+		"0x4e3c6c5e5c56ef2f65867e0dac874f3e2ec2f66e05793b7a52281549c02e68d9": "",
+	} {
+		status, err = kraken.DepositStatus(activityID, txHash, "KNC", 5, timepoint)
+		if err != nil || status != expected {
+			t.Errorf("Expected deposit %s status %q, got %q, err: %v", txHash, expected, status, err)
+		}
+	}
+
+	address, supported := kraken.Address(knc[0].Base)
+	if !supported || address != ethereum.HexToAddress("0x9db6e8d2d133448dbcf755f19d540253da4ba043") {
+		t.Errorf("Got wrong deposit address: %s", address.Hex())
+	}
+
+	exInfo, err := kraken.GetLiveExchangeInfos([]common.TokenPairID{"KNC-ETH"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectedInfo := common.ExchangePrecisionLimit{}
+	expectedInfo.Precision.Amount = 8
+	expectedInfo.Precision.Price = 7
+	expectedInfo.AmountLimit.Min = 5
+	expectedInfo.MinNotional = 0.002
+	if !reflect.DeepEqual(exInfo["KNC-ETH"], expectedInfo) {
+		t.Errorf("Got wrong precision and limit: %+v", exInfo["KNC-ETH"])
+	}
+
+	history, err := kraken.FetchTradeHistoryFrom(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectedHistory := common.ExchangeTradeHistory{
+		"KNC-ETH": {common.NewTradeHistory("TCWJEG-FL4SZ-3FKGH6", 0.00312, 20, "buy", 1528949872500)},
+	}
+	if !reflect.DeepEqual(history, expectedHistory) {
+		t.Errorf("Got wrong trade history: %+v", history)
+	}
+}
+
+func TestKrakenRejectsUnsignedRequest(t *testing.T) {
+	server := newFixtureServer(t)
+	defer server.Close()
+	endpoint := NewKrakenEndpoint(Signer{}, NewSimulatedEndpointInterface(server.URL))
+	if _, err := endpoint.GetInfo(); err == nil {
+		t.Error("Expected error of Kraken response to be returned")
+	}
+}
+
+func TestKrakenSign(t *testing.T) {
+	// the example of Kraken API documentation
+	signer := NewSigner(
+		"",
+		"kQH5HW/8p1uGOVjbgWA7FunAmGO8lsSUXNsu3eow76sz84Q18fWxnyRzBHCd3pd5nE9qa99HAZtuZuj6F1huXg==",
+		nil,
+	)
+	sign := signer.Sign(
+		"/0/private/AddOrder",
+		"1616492376594",
+		"nonce=1616492376594&ordertype=limit&pair=XBTUSD&price=37500&type=buy&volume=1.25",
+	)
+	expected := "4/dpxb3iT4tp/ZCVEwSnEsLxx0bqyhLpdfOpc6fn7OR8+UClSV5n9E6aSS8MPtnRfp32bAb0nmbRn6H8ndwLUQ=="
+	if sign != expected {
+		t.Errorf("Expected signature %s, got %s", expected, sign)
+	}
+}
+
+func TestKrakenBuildTrade(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+	}))
+	defer server.Close()
+	signer := NewSigner("key", "c2VjcmV0", nil)
+	endpoint := NewKrakenEndpoint(*signer, NewSimulatedEndpointInterface(server.URL))
+
+	request, err := endpoint.BuildTrade("buy", "KNCETH", 0.00312, 120)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if requests != 0 {
+		t.Errorf("Expected no request sent to Kraken, got %d", requests)
+	}
+	if request.Method != "POST" || request.URL != server.URL+"/0/private/AddOrder" {
+		t.Errorf("Got wrong request: %s %s", request.Method, request.URL)
+	}
+	if request.Params["pair"] != "KNCETH" || request.Params["volume"] != "120" {
+		t.Errorf("Got wrong params: %v", request.Params)
+	}
+	// the request is unsigned and doesn't take a nonce, so it can't be replayed
+	if _, ok := request.Params["nonce"]; ok {
+		t.Errorf("Expected no nonce in unsigned request, got %v", request.Params)
+	}
+}
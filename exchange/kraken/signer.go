@@ -0,0 +1,65 @@
+package kraken
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/json"
+	"io/ioutil"
+	"log"
+)
+
+type Signer struct {
+	Key    string `json:"kraken_key"`
+	Secret string `json:"kraken_secret"`
+	// WithdrawKeys are the names of withdrawal addresses set up in Kraken
+	// account by token ID, Kraken only withdraws to such addresses.
+	WithdrawKeys map[string]string `json:"kraken_withdraw_keys"`
+}
+
+func (self Signer) GetKey() string {
+	return self.Key
+}
+
+// WithdrawKey returns the name of the withdrawal address of the token.
+func (self Signer) WithdrawKey(tokenID string) (string, bool) {
+	key, ok := self.WithdrawKeys[tokenID]
+	return key, ok
+}
+
+// Sign returns the signature of a private request to path, which is
+// HMAC-SHA512 of path and SHA256 of nonce and post data, using the base64
+// decoded secret.
+func (self Signer) Sign(path, nonce, postData string) string {
+	secret, err := base64.StdEncoding.DecodeString(self.Secret)
+	if err != nil {
+		log.Printf("Decode Kraken secret error: %s", err.Error())
+	}
+	sha := sha256.New()
+	if _, err = sha.Write([]byte(nonce + postData)); err != nil {
+		log.Printf("Encode message error: %s", err.Error())
+	}
+	mac := hmac.New(sha512.New, secret)
+	if _, err = mac.Write(append([]byte(path), sha.Sum(nil)...)); err != nil {
+		log.Printf("Encode message error: %s", err.Error())
+	}
+	return base64.StdEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func NewSigner(key, secret string, withdrawKeys map[string]string) *Signer {
+	return &Signer{key, secret, withdrawKeys}
+}
+
+func NewSignerFromFile(path string) Signer {
+	raw, err := ioutil.ReadFile(path)
+	if err != nil {
+		panic(err)
+	}
+	signer := Signer{}
+	err = json.Unmarshal(raw, &signer)
+	if err != nil {
+		panic(err)
+	}
+	return signer
+}
@@ -0,0 +1,212 @@
+package exchange
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// KrakenResponse is the envelope of every Kraken API response, Error is
+// not empty if the request failed.
+type KrakenResponse struct {
+	Error []string `json:"error"`
+}
+
+// KrakenError returns the error of the response, nil if there is none.
+func (self KrakenResponse) KrakenError() error {
+	if len(self.Error) == 0 {
+		return nil
+	}
+	return fmt.Errorf("Kraken returns error: %v", self.Error)
+}
+
+// KrakenPrice is a level of Kraken order book, in the form of
+// [price, volume, timestamp].
+type KrakenPrice struct {
+	Rate     string
+	Quantity string
+}
+
+func (self *KrakenPrice) UnmarshalJSON(text []byte) error {
+	temp := []interface{}{}
+	if err := json.Unmarshal(text, &temp); err != nil {
+		return err
+	}
+	if len(temp) < 2 {
+		return fmt.Errorf("Unmarshal err: order book level %s is malformed", string(text))
+	}
+	rate, ok := temp[0].(string)
+	if !ok {
+		return fmt.Errorf("Unmarshal err: interface %v can't be converted to string", temp[0])
+	}
+	self.Rate = rate
+	qty, ok := temp[1].(string)
+	if !ok {
+		return fmt.Errorf("Unmarshal err: interface %v can't be converted to string", temp[1])
+	}
+	self.Quantity = qty
+	return nil
+}
+
+type KrakenOrderBook struct {
+	Asks []KrakenPrice `json:"asks"`
+	Bids []KrakenPrice `json:"bids"`
+}
+
+// KrakenDepth is the order books of pairs, by Kraken pair name.
+type KrakenDepth struct {
+	KrakenResponse
+	Result map[string]KrakenOrderBook `json:"result"`
+}
+
+type KrakenAsset struct {
+	AltName  string `json:"altname"`
+	Decimals int    `json:"decimals"`
+	// Status is one of enabled, deposit_only, withdrawal_only and
+	// funding_temporarily_disabled.
+	Status string `json:"status"`
+}
+
+// KrakenAssets is the assets of Kraken by their Kraken names, eg: XETH.
+type KrakenAssets struct {
+	KrakenResponse
+	Result map[string]KrakenAsset `json:"result"`
+}
+
+type KrakenAssetPair struct {
+	AltName      string `json:"altname"`
+	Base         string `json:"base"`
+	Quote        string `json:"quote"`
+	PairDecimals int    `json:"pair_decimals"`
+	LotDecimals  int    `json:"lot_decimals"`
+	OrderMin     string `json:"ordermin"`
+	CostMin      string `json:"costmin"`
+}
+
+// KrakenAssetPairs is the tradable pairs of Kraken by their Kraken names.
+type KrakenAssetPairs struct {
+	KrakenResponse
+	Result map[string]KrakenAssetPair `json:"result"`
+}
+
+type KrakenBalance struct {
+	Balance   string `json:"balance"`
+	HoldTrade string `json:"hold_trade"`
+}
+
+// KrakenBalances is the balances of the account by Kraken asset names,
+// HoldTrade of each balance is locked in open orders.
+type KrakenBalances struct {
+	KrakenResponse
+	Result map[string]KrakenBalance `json:"result"`
+}
+
+type KrakenOrderDescription struct {
+	Pair  string `json:"pair"`
+	Type  string `json:"type"`
+	Price string `json:"price"`
+	Order string `json:"order"`
+}
+
+type KrakenOrder struct {
+	Status  string                 `json:"status"`
+	OpenTm  float64                `json:"opentm"`
+	Descr   KrakenOrderDescription `json:"descr"`
+	Vol     string                 `json:"vol"`
+	VolExec string                 `json:"vol_exec"`
+	Price   string                 `json:"price"`
+}
+
+// KrakenOrders is the orders queried by their transaction IDs.
+type KrakenOrders struct {
+	KrakenResponse
+	Result map[string]KrakenOrder `json:"result"`
+}
+
+type KrakenTrade struct {
+	KrakenResponse
+	Result struct {
+		Descr struct {
+			Order string `json:"order"`
+		} `json:"descr"`
+		TxID []string `json:"txid"`
+	} `json:"result"`
+}
+
+type KrakenCancel struct {
+	KrakenResponse
+	Result struct {
+		Count int `json:"count"`
+	} `json:"result"`
+}
+
+type KrakenAccountTrade struct {
+	OrderTxID string  `json:"ordertxid"`
+	Pair      string  `json:"pair"`
+	Time      float64 `json:"time"`
+	Type      string  `json:"type"`
+	Price     string  `json:"price"`
+	Vol       string  `json:"vol"`
+}
+
+// KrakenTradeHistory is the trades of the account by their trade IDs.
+type KrakenTradeHistory struct {
+	KrakenResponse
+	Result struct {
+		Trades map[string]KrakenAccountTrade `json:"trades"`
+		Count  int                           `json:"count"`
+	} `json:"result"`
+}
+
+type KrakenDepositMethods struct {
+	KrakenResponse
+	Result []struct {
+		Method string `json:"method"`
+	} `json:"result"`
+}
+
+type KrakenDepositAddresses struct {
+	KrakenResponse
+	Result []struct {
+		Address string `json:"address"`
+	} `json:"result"`
+}
+
+type KrakenWithdraw struct {
+	KrakenResponse
+	Result struct {
+		RefID string `json:"refid"`
+	} `json:"result"`
+}
+
+// KrakenFunding is a deposit or withdrawal of the account.
+type KrakenFunding struct {
+	Asset  string `json:"asset"`
+	RefID  string `json:"refid"`
+	TxID   string `json:"txid"`
+	Info   string `json:"info"`
+	Amount string `json:"amount"`
+	Time   uint64 `json:"time"`
+	Status string `json:"status"`
+}
+
+type KrakenFundingStatus struct {
+	KrakenResponse
+	Result []KrakenFunding `json:"result"`
+}
+
+type KrakenServerTime struct {
+	KrakenResponse
+	Result struct {
+		UnixTime uint64 `json:"unixtime"`
+	} `json:"result"`
+}
+
+// KrakenSystemStatus is the status of Kraken, one of online, maintenance,
+// cancel_only and post_only.
+type KrakenSystemStatus struct {
+	KrakenResponse
+	Result struct {
+		Status    string `json:"status"`
+		Timestamp string `json:"timestamp"`
+	} `json:"result"`
+}
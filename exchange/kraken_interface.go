@@ -0,0 +1,60 @@
+package exchange
+
+import (
+	"math/big"
+
+	"github.com/KyberNetwork/reserve-data/common"
+	ethereum "github.com/ethereum/go-ethereum/common"
+)
+
+// KrakenInterface contains the methods to interact with Kraken centralized exchange.
+// Pairs and assets are given with their Kraken names, eg: KNCETH, XETH.
+type KrakenInterface interface {
+	GetDepthOnePair(pair string) (KrakenDepth, error)
+
+	GetAssets() (KrakenAssets, error)
+
+	GetAssetPairs() (KrakenAssetPairs, error)
+
+	GetSystemStatus() (KrakenSystemStatus, error)
+
+	GetInfo() (KrakenBalances, error)
+
+	GetDepositAddress(asset string) (KrakenDepositAddresses, error)
+
+	// GetAccountTradeHistory returns trades after start, in seconds, skipping
+	// the first offset of them.
+	GetAccountTradeHistory(start uint64, offset int) (KrakenTradeHistory, error)
+
+	Withdraw(
+		asset string,
+		token common.Token,
+		amount *big.Int,
+		address ethereum.Address) (KrakenWithdraw, error)
+
+	Trade(
+		tradeType string,
+		pair string,
+		rate, amount float64) (KrakenTrade, error)
+
+	// BuildWithdraw and BuildTrade return the unsigned requests of Withdraw
+	// and Trade without sending them.
+	BuildWithdraw(
+		asset string,
+		token common.Token,
+		amount *big.Int,
+		address ethereum.Address) (common.DryRunRequest, error)
+
+	BuildTrade(
+		tradeType string,
+		pair string,
+		rate, amount float64) (common.DryRunRequest, error)
+
+	CancelOrder(txID string) (KrakenCancel, error)
+
+	DepositHistory(asset string) (KrakenFundingStatus, error)
+
+	WithdrawHistory(asset string) (KrakenFundingStatus, error)
+
+	OrderStatus(txID string) (KrakenOrders, error)
+}
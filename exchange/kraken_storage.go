@@ -0,0 +1,13 @@
+package exchange
+
+import "github.com/KyberNetwork/reserve-data/common"
+
+// KrakenStorage is the interface that wraps all database operation of Kraken exchange.
+type KrakenStorage interface {
+	StoreTradeHistory(data common.ExchangeTradeHistory) error
+
+	GetTradeHistory(fromTime, toTime uint64) (common.ExchangeTradeHistory, error)
+	// GetLastTradeTimestamp returns the timestamp of the latest stored trade
+	// in milliseconds, trade history is fetched from it on.
+	GetLastTradeTimestamp() (uint64, error)
+}
@@ -0,0 +1,127 @@
+package orderbook
+
+import (
+	"errors"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/KyberNetwork/reserve-data/common"
+)
+
+// ErrNotSynced is returned when reading a book that is not synchronized with
+// the exchange yet.
+var ErrNotSynced = errors.New("order book is not synced")
+
+// Book is the order book of a token pair maintained locally from streamed
+// updates. It is safe for concurrent use.
+type Book struct {
+	pair common.TokenPair
+
+	mu           sync.RWMutex
+	bids         map[float64]float64
+	asks         map[float64]float64
+	lastUpdateID uint64
+	synced       bool
+	updated      time.Time
+}
+
+// NewBook creates an empty, not synced book of the given pair.
+func NewBook(pair common.TokenPair) *Book {
+	return &Book{
+		pair: pair,
+		bids: map[float64]float64{},
+		asks: map[float64]float64{},
+	}
+}
+
+// Pair returns the token pair of the book.
+func (self *Book) Pair() common.TokenPair {
+	return self.pair
+}
+
+// Reset replaces the whole book with the given snapshot and marks it synced.
+func (self *Book) Reset(bids, asks []common.PriceEntry, lastUpdateID uint64, timestamp time.Time) {
+	self.mu.Lock()
+	defer self.mu.Unlock()
+	self.bids = map[float64]float64{}
+	self.asks = map[float64]float64{}
+	applyLevels(self.bids, bids)
+	applyLevels(self.asks, asks)
+	self.lastUpdateID = lastUpdateID
+	self.synced = true
+	self.updated = timestamp
+}
+
+// Update applies the given changed levels to the book, a level with zero
+// quantity is removed.
+func (self *Book) Update(bids, asks []common.PriceEntry, lastUpdateID uint64, timestamp time.Time) {
+	self.mu.Lock()
+	defer self.mu.Unlock()
+	applyLevels(self.bids, bids)
+	applyLevels(self.asks, asks)
+	self.lastUpdateID = lastUpdateID
+	self.updated = timestamp
+}
+
+// Invalidate marks the book as not synced, it has to be Reset before
+// being read again.
+func (self *Book) Invalidate() {
+	self.mu.Lock()
+	defer self.mu.Unlock()
+	self.synced = false
+}
+
+// Synced returns true if the book is synchronized with the exchange.
+func (self *Book) Synced() bool {
+	self.mu.RLock()
+	defer self.mu.RUnlock()
+	return self.synced
+}
+
+// LastUpdateID returns the id of the last applied snapshot or update.
+func (self *Book) LastUpdateID() uint64 {
+	self.mu.RLock()
+	defer self.mu.RUnlock()
+	return self.lastUpdateID
+}
+
+// Depth returns at most limit best levels of each side: bids sorted by
+// descending rate and asks sorted by ascending rate, and the time of the last
+// update. A non positive limit returns all levels.
+func (self *Book) Depth(limit int) ([]common.PriceEntry, []common.PriceEntry, time.Time, error) {
+	self.mu.RLock()
+	defer self.mu.RUnlock()
+	if !self.synced {
+		return nil, nil, time.Time{}, ErrNotSynced
+	}
+	bids := sortedLevels(self.bids, limit, func(a, b float64) bool { return a > b })
+	asks := sortedLevels(self.asks, limit, func(a, b float64) bool { return a < b })
+	return bids, asks, self.updated, nil
+}
+
+func applyLevels(side map[float64]float64, levels []common.PriceEntry) {
+	for _, level := range levels {
+		if level.Quantity == 0 {
+			delete(side, level.Rate)
+			continue
+		}
+		side[level.Rate] = level.Quantity
+	}
+}
+
+func sortedLevels(side map[float64]float64, limit int, less func(a, b float64) bool) []common.PriceEntry {
+	rates := make([]float64, 0, len(side))
+	for rate := range side {
+		rates = append(rates, rate)
+	}
+	sort.Slice(rates, func(i, j int) bool { return less(rates[i], rates[j]) })
+	if limit > 0 && len(rates) > limit {
+		rates = rates[:limit]
+	}
+	result := make([]common.PriceEntry, 0, len(rates))
+	for _, rate := range rates {
+		result = append(result, common.NewPriceEntry(side[rate], rate))
+	}
+	return result
+}
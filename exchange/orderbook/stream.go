@@ -0,0 +1,269 @@
+package orderbook
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/KyberNetwork/reserve-data/common"
+	"golang.org/x/net/websocket"
+)
+
+const (
+	defaultReadTimeout       = 30 * time.Second
+	defaultReconnectDuration = 5 * time.Second
+	dialTimeout              = 10 * time.Second
+	origin                   = "http://localhost/"
+)
+
+var (
+	// ErrNotConnected is returned when reading a book while the stream is disconnected.
+	ErrNotConnected = errors.New("order book stream is not connected")
+	// ErrNotWatched is returned when reading a book of a pair that is not streamed.
+	ErrNotWatched = errors.New("order book of pair is not streamed")
+)
+
+// Handler implements the depth streaming protocol of an exchange.
+type Handler interface {
+	// Endpoint returns the websocket url streaming depth of the given pairs.
+	Endpoint(pairs []common.TokenPair) string
+	// Symbol returns the identifier of the pair in stream messages.
+	Symbol(pair common.TokenPair) string
+	// Subscribe is called right after connected to send subscription
+	// messages, if the exchange requires them.
+	Subscribe(conn *websocket.Conn, pairs []common.TokenPair) error
+	// Handle applies a received message to the books, keyed by Symbol.
+	// Returning an error closes the connection, it will be reconnected later.
+	Handle(conn *websocket.Conn, msg []byte, books map[string]*Book) error
+}
+
+// Stream keeps a websocket connection to an exchange and maintains the
+// order books of the watched pairs from it. The connection is reestablished
+// automatically, all books are discarded and synced again on reconnect.
+type Stream struct {
+	handler Handler
+	name    string
+
+	limit             int
+	readTimeout       time.Duration
+	reconnectDuration time.Duration
+
+	mu        sync.RWMutex
+	pairs     []common.TokenPair
+	watched   map[common.TokenPairID]string
+	books     map[string]*Book
+	conn      *websocket.Conn
+	connected bool
+	received  time.Time
+	started   bool
+	stop      chan struct{}
+	done      chan struct{}
+}
+
+// StreamOption is the option to setup the Stream on creation.
+type StreamOption func(s *Stream)
+
+// WithLimit setups the maximum number of levels of each side returned by GetDepth.
+func WithLimit(limit int) StreamOption {
+	return func(s *Stream) {
+		s.limit = limit
+	}
+}
+
+// WithReadTimeout setups the duration without any message after which the
+// connection is considered dead and reconnected.
+func WithReadTimeout(duration time.Duration) StreamOption {
+	return func(s *Stream) {
+		s.readTimeout = duration
+	}
+}
+
+// WithReconnectDuration setups the waiting duration before reconnecting.
+func WithReconnectDuration(duration time.Duration) StreamOption {
+	return func(s *Stream) {
+		s.reconnectDuration = duration
+	}
+}
+
+// NewStream creates a new Stream using the given handler, name is used in logs.
+// The connection is opened on the first call of Watch.
+func NewStream(name string, handler Handler, options ...StreamOption) *Stream {
+	stream := &Stream{
+		handler:           handler,
+		name:              name,
+		readTimeout:       defaultReadTimeout,
+		reconnectDuration: defaultReconnectDuration,
+		watched:           map[common.TokenPairID]string{},
+		stop:              make(chan struct{}),
+		done:              make(chan struct{}),
+	}
+	for _, option := range options {
+		option(stream)
+	}
+	return stream
+}
+
+// Watch makes sure the given pairs are streamed. If the pairs are different
+// from the current ones, the stream reconnects to subscribe to the new pairs.
+func (self *Stream) Watch(pairs []common.TokenPair) {
+	self.mu.Lock()
+	defer self.mu.Unlock()
+	if !self.samePairs(pairs) {
+		self.pairs = pairs
+		self.watched = map[common.TokenPairID]string{}
+		for _, pair := range pairs {
+			self.watched[pair.PairID()] = self.handler.Symbol(pair)
+		}
+		if self.conn != nil {
+			if err := self.conn.Close(); err != nil {
+				log.Printf("%s depth stream: closing connection failed: %s", self.name, err)
+			}
+		}
+	}
+	if !self.started {
+		self.started = true
+		go self.run()
+	}
+}
+
+func (self *Stream) samePairs(pairs []common.TokenPair) bool {
+	if len(pairs) != len(self.watched) {
+		return false
+	}
+	for _, pair := range pairs {
+		if _, ok := self.watched[pair.PairID()]; !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// Connected returns true if the stream is connected.
+func (self *Stream) Connected() bool {
+	self.mu.RLock()
+	defer self.mu.RUnlock()
+	return self.connected
+}
+
+// GetDepth returns the local order book of the given pair and the time of
+// the last message received on the connection. A book only changes when the
+// pair is traded, so the liveness of the connection tells whether a quiet
+// book is still up to date. It returns an error if the stream is
+// disconnected or the book is not synced.
+func (self *Stream) GetDepth(pair common.TokenPair) ([]common.PriceEntry, []common.PriceEntry, time.Time, error) {
+	self.mu.RLock()
+	defer self.mu.RUnlock()
+	if !self.connected {
+		return nil, nil, time.Time{}, ErrNotConnected
+	}
+	book, ok := self.books[self.watched[pair.PairID()]]
+	if !ok {
+		return nil, nil, time.Time{}, ErrNotWatched
+	}
+	bids, asks, _, err := book.Depth(self.limit)
+	return bids, asks, self.received, err
+}
+
+// Stop closes the connection and stops reconnecting.
+func (self *Stream) Stop() {
+	self.mu.Lock()
+	started := self.started
+	select {
+	case <-self.stop:
+	default:
+		close(self.stop)
+	}
+	if self.conn != nil {
+		if err := self.conn.Close(); err != nil {
+			log.Printf("%s depth stream: closing connection failed: %s", self.name, err)
+		}
+	}
+	self.mu.Unlock()
+	if started {
+		<-self.done
+	}
+}
+
+func (self *Stream) run() {
+	defer close(self.done)
+	for {
+		self.mu.RLock()
+		pairs := self.pairs
+		self.mu.RUnlock()
+		if len(pairs) != 0 {
+			if err := self.serve(pairs); err != nil {
+				log.Printf("%s depth stream: %s, reconnecting in %s", self.name, err, self.reconnectDuration)
+			}
+		}
+		select {
+		case <-self.stop:
+			return
+		case <-time.After(self.reconnectDuration):
+		}
+	}
+}
+
+// serve connects to the exchange and processes messages until the
+// connection is closed.
+func (self *Stream) serve(pairs []common.TokenPair) error {
+	config, err := websocket.NewConfig(self.handler.Endpoint(pairs), origin)
+	if err != nil {
+		return err
+	}
+	config.Dialer = &net.Dialer{Timeout: dialTimeout}
+	conn, err := websocket.DialConfig(config)
+	if err != nil {
+		return fmt.Errorf("connecting failed: %s", err)
+	}
+	books := map[string]*Book{}
+	for _, pair := range pairs {
+		books[self.handler.Symbol(pair)] = NewBook(pair)
+	}
+
+	self.mu.Lock()
+	select {
+	case <-self.stop:
+		self.mu.Unlock()
+		return conn.Close()
+	default:
+	}
+	self.conn = conn
+	self.books = books
+	self.connected = true
+	self.received = time.Now()
+	self.mu.Unlock()
+	log.Printf("%s depth stream: connected, streaming %d pairs", self.name, len(pairs))
+
+	defer func() {
+		self.mu.Lock()
+		self.conn = nil
+		self.books = nil
+		self.connected = false
+		self.mu.Unlock()
+		if cErr := conn.Close(); cErr != nil {
+			log.Printf("%s depth stream: closing connection failed: %s", self.name, cErr)
+		}
+	}()
+
+	if err = self.handler.Subscribe(conn, pairs); err != nil {
+		return fmt.Errorf("subscribing failed: %s", err)
+	}
+	for {
+		if err = conn.SetReadDeadline(time.Now().Add(self.readTimeout)); err != nil {
+			return err
+		}
+		var msg []byte
+		if err = websocket.Message.Receive(conn, &msg); err != nil {
+			return fmt.Errorf("receiving message failed: %s", err)
+		}
+		self.mu.Lock()
+		self.received = time.Now()
+		self.mu.Unlock()
+		if err = self.handler.Handle(conn, msg, books); err != nil {
+			return err
+		}
+	}
+}
@@ -0,0 +1,343 @@
+package simulator
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/KyberNetwork/reserve-data/exchange"
+	"github.com/gin-gonic/gin"
+)
+
+// binance status codes of deposit and withdrawal history.
+const (
+	binanceDepositSuccess     = 1
+	binanceWithdrawProcessing = 4
+	binanceWithdrawCompleted  = 6
+)
+
+type binanceHandler struct {
+	engine *Engine
+}
+
+// NewBinanceHandler returns the handler serving Binance REST API from engine.
+// Signatures of authenticated requests are not checked.
+func NewBinanceHandler(engine *Engine) http.Handler {
+	r, g := newRouter(engine)
+	h := binanceHandler{engine}
+	g.GET("/api/v1/depth", h.depth)
+	g.GET("/api/v1/trades", h.trades)
+	g.GET("/api/v1/exchangeInfo", h.exchangeInfo)
+	g.GET("/api/v1/time", h.serverTime)
+	g.POST("/api/v3/order", h.placeOrder)
+	g.GET("/api/v3/order", h.orderStatus)
+	g.DELETE("/api/v3/order", h.cancelOrder)
+	g.GET("/api/v3/openOrders", h.openOrders)
+	g.GET("/api/v3/myTrades", h.myTrades)
+	g.GET("/api/v3/account", h.account)
+	g.POST("/wapi/v3/withdraw.html", h.withdraw)
+	g.GET("/wapi/v3/withdrawHistory.html", h.withdrawHistory)
+	g.GET("/wapi/v3/depositHistory.html", h.depositHistory)
+	g.GET("/wapi/v3/depositAddress.html", h.depositAddress)
+	return r
+}
+
+func binanceTime(t time.Time) uint64 {
+	return uint64(t.UnixNano() / int64(time.Millisecond))
+}
+
+func (self binanceHandler) fail(c *gin.Context, code int, msg string) {
+	c.JSON(http.StatusBadRequest, gin.H{"code": code, "msg": msg})
+}
+
+// market returns the market of the symbol param, eg: KNCETH.
+func (self binanceHandler) market(c *gin.Context) (Market, bool) {
+	symbol := strings.ToUpper(c.Request.FormValue("symbol"))
+	for _, m := range self.engine.Markets() {
+		if m.Base+m.Quote == symbol {
+			return m, true
+		}
+	}
+	self.fail(c, -1121, "Invalid symbol.")
+	return Market{}, false
+}
+
+func (self binanceHandler) order(c *gin.Context) (Order, bool) {
+	id, err := strconv.ParseUint(c.Request.FormValue("orderId"), 10, 64)
+	if err != nil {
+		self.fail(c, -1102, "Mandatory parameter 'orderId' was not sent, was empty/null, or malformed.")
+		return Order{}, false
+	}
+	o, err := self.engine.Order(id)
+	if err != nil {
+		self.fail(c, -2013, "Order does not exist.")
+		return Order{}, false
+	}
+	return o, true
+}
+
+func binanceLevels(levels []Level, limit int) [][]string {
+	result := [][]string{}
+	for i, level := range levels {
+		if limit > 0 && i >= limit {
+			break
+		}
+		result = append(result, []string{formatFloat(level.Rate()), formatFloat(level.Quantity())})
+	}
+	return result
+}
+
+func (self binanceHandler) depth(c *gin.Context) {
+	m, ok := self.market(c)
+	if !ok {
+		return
+	}
+	limit, _ := strconv.Atoi(c.Request.FormValue("limit"))
+	c.JSON(http.StatusOK, gin.H{
+		"lastUpdateId": self.engine.Sequence(),
+		"bids":         binanceLevels(m.Bids, limit),
+		"asks":         binanceLevels(m.Asks, limit),
+	})
+}
+
+func (self binanceHandler) trades(c *gin.Context) {
+	m, ok := self.market(c)
+	if !ok {
+		return
+	}
+	result := []gin.H{}
+	for _, t := range self.engine.Trades(m.Base, m.Quote) {
+		result = append(result, gin.H{
+			"id":           t.ID,
+			"price":        formatFloat(t.Rate),
+			"qty":          formatFloat(t.Quantity),
+			"time":         binanceTime(t.Time),
+			"isBuyerMaker": t.Side == Sell,
+			"isBestMatch":  true,
+		})
+	}
+	c.JSON(http.StatusOK, result)
+}
+
+func (self binanceHandler) myTrades(c *gin.Context) {
+	m, ok := self.market(c)
+	if !ok {
+		return
+	}
+	result := []gin.H{}
+	for _, t := range self.engine.Trades(m.Base, m.Quote) {
+		result = append(result, gin.H{
+			"id":      t.ID,
+			"orderId": t.OrderID,
+			"price":   formatFloat(t.Rate),
+			"qty":     formatFloat(t.Quantity),
+			"time":    binanceTime(t.Time),
+			"isBuyer": t.Side == Buy,
+			"isMaker": false,
+		})
+	}
+	c.JSON(http.StatusOK, result)
+}
+
+func (self binanceHandler) exchangeInfo(c *gin.Context) {
+	info := exchange.BinanceExchangeInfo{Symbols: []exchange.BinanceSymbol{}}
+	for _, m := range self.engine.Markets() {
+		info.Symbols = append(info.Symbols, exchange.BinanceSymbol{
+			Symbol:             m.Base + m.Quote,
+			BaseAssetPrecision: m.AmountPrecision,
+			QuotePrecision:     m.PricePrecision,
+			Filters: []exchange.FilterLimit{
+				{FilterType: "PRICE_FILTER", MinPrice: "0", MaxPrice: "100000"},
+				{FilterType: "LOT_SIZE", MinQuantity: formatFloat(m.MinQuantity), MaxQuantity: "90000000"},
+				{FilterType: "MIN_NOTIONAL", MinNotional: formatFloat(m.MinNotional)},
+			},
+		})
+	}
+	c.JSON(http.StatusOK, info)
+}
+
+func (self binanceHandler) serverTime(c *gin.Context) {
+	c.JSON(http.StatusOK, exchange.BinaServerTime{ServerTime: binanceTime(time.Now())})
+}
+
+func binanceOrder(o Order) exchange.Binaorder {
+	status := "NEW"
+	switch {
+	case o.Status == OrderFilled:
+		status = "FILLED"
+	case o.Status == OrderCanceled:
+		status = "CANCELED"
+	case o.Executed > 0:
+		status = "PARTIALLY_FILLED"
+	}
+	return exchange.Binaorder{
+		Symbol:      o.Base + o.Quote,
+		OrderId:     o.ID,
+		Price:       formatFloat(o.Rate),
+		OrigQty:     formatFloat(o.Quantity),
+		ExecutedQty: formatFloat(o.Executed),
+		Status:      status,
+		TimeInForce: "GTC",
+		Type:        "LIMIT",
+		Side:        strings.ToUpper(o.Side),
+		Time:        binanceTime(o.Time),
+	}
+}
+
+func (self binanceHandler) placeOrder(c *gin.Context) {
+	m, ok := self.market(c)
+	if !ok {
+		return
+	}
+	rate, rErr := strconv.ParseFloat(c.Request.FormValue("price"), 64)
+	quantity, qErr := strconv.ParseFloat(c.Request.FormValue("quantity"), 64)
+	if rErr != nil || qErr != nil {
+		self.fail(c, -1102, "Mandatory parameter 'price' or 'quantity' was not sent, was empty/null, or malformed.")
+		return
+	}
+	o, err := self.engine.PlaceOrder(m.Base, m.Quote, strings.ToLower(c.Request.FormValue("side")), rate, quantity)
+	if err != nil {
+		self.fail(c, -2010, err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, exchange.Binatrade{
+		Symbol:        o.Base + o.Quote,
+		OrderID:       o.ID,
+		ClientOrderID: strconv.FormatUint(o.ID, 10),
+		TransactTime:  binanceTime(o.Time),
+	})
+}
+
+func (self binanceHandler) orderStatus(c *gin.Context) {
+	o, ok := self.order(c)
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, binanceOrder(o))
+}
+
+func (self binanceHandler) cancelOrder(c *gin.Context) {
+	o, ok := self.order(c)
+	if !ok {
+		return
+	}
+	o, err := self.engine.CancelOrder(o.ID)
+	if err != nil {
+		self.fail(c, -2011, "Unknown order sent.")
+		return
+	}
+	c.JSON(http.StatusOK, exchange.Binacancel{
+		Symbol:  o.Base + o.Quote,
+		OrderId: o.ID,
+	})
+}
+
+func (self binanceHandler) openOrders(c *gin.Context) {
+	m, ok := self.market(c)
+	if !ok {
+		return
+	}
+	result := exchange.Binaorders{}
+	for _, o := range self.engine.Orders(m.Base, m.Quote, true) {
+		result = append(result, binanceOrder(o))
+	}
+	c.JSON(http.StatusOK, result)
+}
+
+func (self binanceHandler) account(c *gin.Context) {
+	balances := []gin.H{}
+	for asset, b := range self.engine.Balances() {
+		balances = append(balances, gin.H{
+			"asset":  asset,
+			"free":   formatFloat(b.Free),
+			"locked": formatFloat(b.Locked),
+		})
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"canTrade":    true,
+		"canWithdraw": true,
+		"canDeposit":  true,
+		"balances":    balances,
+	})
+}
+
+func (self binanceHandler) withdraw(c *gin.Context) {
+	amount, err := strconv.ParseFloat(c.Request.FormValue("amount"), 64)
+	if err != nil {
+		c.JSON(http.StatusOK, exchange.Binawithdraw{Msg: "invalid amount"})
+		return
+	}
+	w, err := self.engine.Withdraw(c.Request.FormValue("asset"), amount, c.Request.FormValue("address"))
+	if err != nil {
+		c.JSON(http.StatusOK, exchange.Binawithdraw{Msg: err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, exchange.Binawithdraw{
+		Success: true,
+		Msg:     "success",
+		ID:      strconv.FormatUint(w.ID, 10),
+	})
+}
+
+// inTimeRange returns true if t is in the startTime and endTime params of
+// the request, missing params are not checked.
+func inTimeRange(c *gin.Context, t time.Time) bool {
+	ms := binanceTime(t)
+	if start, err := strconv.ParseUint(c.Request.FormValue("startTime"), 10, 64); err == nil && ms < start {
+		return false
+	}
+	if end, err := strconv.ParseUint(c.Request.FormValue("endTime"), 10, 64); err == nil && ms > end {
+		return false
+	}
+	return true
+}
+
+func (self binanceHandler) withdrawHistory(c *gin.Context) {
+	result := exchange.Binawithdrawals{Success: true, Withdrawals: []exchange.Binawithdrawal{}}
+	for _, w := range self.engine.Withdrawals() {
+		if !inTimeRange(c, w.Time) {
+			continue
+		}
+		status := binanceWithdrawProcessing
+		if w.Done {
+			status = binanceWithdrawCompleted
+		}
+		result.Withdrawals = append(result.Withdrawals, exchange.Binawithdrawal{
+			ID:        strconv.FormatUint(w.ID, 10),
+			Amount:    w.Amount,
+			Address:   w.Address,
+			Asset:     w.Asset,
+			TxID:      w.TxHash,
+			ApplyTime: binanceTime(w.Time),
+			Status:    status,
+		})
+	}
+	c.JSON(http.StatusOK, result)
+}
+
+func (self binanceHandler) depositHistory(c *gin.Context) {
+	result := exchange.Binadeposits{Success: true, Deposits: []exchange.Binadeposit{}}
+	for _, d := range self.engine.Deposits() {
+		if !inTimeRange(c, d.Time) {
+			continue
+		}
+		result.Deposits = append(result.Deposits, exchange.Binadeposit{
+			InsertTime: binanceTime(d.Time),
+			Amount:     d.Amount,
+			Asset:      d.Asset,
+			Address:    d.Address,
+			TxID:       d.TxHash,
+			Status:     binanceDepositSuccess,
+		})
+	}
+	c.JSON(http.StatusOK, result)
+}
+
+func (self binanceHandler) depositAddress(c *gin.Context) {
+	c.JSON(http.StatusOK, exchange.Binadepositaddress{
+		Success: true,
+		Address: self.engine.DepositAddress(),
+		Asset:   strings.ToUpper(c.Request.FormValue("asset")),
+	})
+}
@@ -0,0 +1,281 @@
+package simulator
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// bittrexTimeLayout is the layout of timestamps returned by Bittrex.
+const bittrexTimeLayout = "2006-01-02T15:04:05.000"
+
+type bittrexHandler struct {
+	engine *Engine
+}
+
+// NewBittrexHandler returns the handler serving Bittrex REST API from engine.
+// Signatures of authenticated requests are not checked.
+func NewBittrexHandler(engine *Engine) http.Handler {
+	r, g := newRouter(engine)
+	h := bittrexHandler{engine}
+	api := g.Group("/api/v1.1")
+	api.GET("/public/getmarkets", h.markets)
+	api.GET("/public/getorderbook", h.orderbook)
+	api.GET("/market/buylimit", h.limit(Buy))
+	api.GET("/market/selllimit", h.limit(Sell))
+	api.GET("/market/cancel", h.cancel)
+	api.GET("/account/getorder", h.order)
+	api.GET("/account/getorderhistory", h.orderHistory)
+	api.GET("/account/getbalances", h.balances)
+	api.GET("/account/withdraw", h.withdraw)
+	api.GET("/account/getwithdrawalhistory", h.withdrawalHistory)
+	api.GET("/account/getdeposithistory", h.depositHistory)
+	api.GET("/account/getdepositaddress", h.depositAddress)
+	return r
+}
+
+func bittrexTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format(bittrexTimeLayout)
+}
+
+// bittrexUUID formats an id of the engine as uuid.
+func bittrexUUID(id uint64) string {
+	return fmt.Sprintf("00000000-0000-4000-8000-%012d", id)
+}
+
+func parseBittrexUUID(uuid string) (uint64, error) {
+	parts := strings.Split(uuid, "-")
+	return strconv.ParseUint(parts[len(parts)-1], 10, 64)
+}
+
+func (self bittrexHandler) success(c *gin.Context, result interface{}) {
+	c.JSON(http.StatusOK, gin.H{"success": true, "message": "", "result": result})
+}
+
+func (self bittrexHandler) fail(c *gin.Context, msg string) {
+	c.JSON(http.StatusOK, gin.H{"success": false, "message": msg, "result": nil})
+}
+
+// market returns the market of the market param, eg: ETH-KNC.
+func (self bittrexHandler) market(c *gin.Context) (Market, bool) {
+	name := strings.ToUpper(c.Query("market"))
+	for _, m := range self.engine.Markets() {
+		if m.Quote+"-"+m.Base == name {
+			return m, true
+		}
+	}
+	self.fail(c, "INVALID_MARKET")
+	return Market{}, false
+}
+
+func (self bittrexHandler) markets(c *gin.Context) {
+	result := []gin.H{}
+	for _, m := range self.engine.Markets() {
+		result = append(result, gin.H{
+			"MarketCurrency": m.Base,
+			"BaseCurrency":   m.Quote,
+			"MarketName":     m.Quote + "-" + m.Base,
+			"MinTradeSize":   m.MinQuantity,
+			"IsActive":       true,
+		})
+	}
+	self.success(c, result)
+}
+
+func bittrexLevels(levels []Level) []map[string]float64 {
+	result := []map[string]float64{}
+	for _, level := range levels {
+		result = append(result, map[string]float64{"Quantity": level.Quantity(), "Rate": level.Rate()})
+	}
+	return result
+}
+
+func (self bittrexHandler) orderbook(c *gin.Context) {
+	m, ok := self.market(c)
+	if !ok {
+		return
+	}
+	self.success(c, gin.H{"buy": bittrexLevels(m.Bids), "sell": bittrexLevels(m.Asks)})
+}
+
+func (self bittrexHandler) limit(side string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		m, ok := self.market(c)
+		if !ok {
+			return
+		}
+		rate, rErr := strconv.ParseFloat(c.Query("rate"), 64)
+		quantity, qErr := strconv.ParseFloat(c.Query("quantity"), 64)
+		if rErr != nil || qErr != nil {
+			self.fail(c, "RATE_OR_QUANTITY_INVALID")
+			return
+		}
+		o, err := self.engine.PlaceOrder(m.Base, m.Quote, side, rate, quantity)
+		if err != nil {
+			self.fail(c, err.Error())
+			return
+		}
+		self.success(c, map[string]string{"uuid": bittrexUUID(o.ID)})
+	}
+}
+
+func (self bittrexHandler) cancel(c *gin.Context) {
+	id, err := parseBittrexUUID(c.Query("uuid"))
+	if err != nil {
+		self.fail(c, "UUID_INVALID")
+		return
+	}
+	if _, err := self.engine.CancelOrder(id); err != nil {
+		self.fail(c, err.Error())
+		return
+	}
+	self.success(c, nil)
+}
+
+func bittrexOrder(o Order) gin.H {
+	orderType := "LIMIT_BUY"
+	if o.Side == Sell {
+		orderType = "LIMIT_SELL"
+	}
+	return gin.H{
+		"OrderUuid":         bittrexUUID(o.ID),
+		"Exchange":          o.Quote + "-" + o.Base,
+		"Type":              orderType,
+		"OrderType":         orderType,
+		"Quantity":          o.Quantity,
+		"QuantityRemaining": o.Remaining(),
+		"Limit":             o.Rate,
+		"Price":             o.Executed * o.Rate,
+		"PricePerUnit":      o.Rate,
+		"Opened":            bittrexTime(o.Time),
+		"TimeStamp":         bittrexTime(o.Time),
+		"Closed":            bittrexTime(o.Closed),
+		"IsOpen":            o.Status == OrderOpen,
+		"CancelInitiated":   o.Status == OrderCanceled,
+	}
+}
+
+func (self bittrexHandler) order(c *gin.Context) {
+	id, err := parseBittrexUUID(c.Query("uuid"))
+	if err != nil {
+		self.fail(c, "UUID_INVALID")
+		return
+	}
+	o, err := self.engine.Order(id)
+	if err != nil {
+		self.fail(c, "INVALID_ORDER")
+		return
+	}
+	self.success(c, bittrexOrder(o))
+}
+
+func (self bittrexHandler) orderHistory(c *gin.Context) {
+	var orders []Order
+	if c.Query("market") == "" {
+		orders = self.engine.Orders("", "", false)
+	} else {
+		m, ok := self.market(c)
+		if !ok {
+			return
+		}
+		orders = self.engine.Orders(m.Base, m.Quote, false)
+	}
+	result := []gin.H{}
+	for _, o := range orders {
+		if o.Status != OrderOpen {
+			result = append(result, bittrexOrder(o))
+		}
+	}
+	self.success(c, result)
+}
+
+func (self bittrexHandler) balances(c *gin.Context) {
+	result := []gin.H{}
+	for asset, b := range self.engine.Balances() {
+		result = append(result, gin.H{
+			"Currency":      asset,
+			"Balance":       b.Free + b.Locked,
+			"Available":     b.Free,
+			"Pending":       0,
+			"CryptoAddress": self.engine.DepositAddress(),
+		})
+	}
+	self.success(c, result)
+}
+
+func (self bittrexHandler) withdraw(c *gin.Context) {
+	amount, err := strconv.ParseFloat(c.Query("quantity"), 64)
+	if err != nil {
+		self.fail(c, "QUANTITY_INVALID")
+		return
+	}
+	w, err := self.engine.Withdraw(c.Query("currency"), amount, c.Query("address"))
+	if err != nil {
+		self.fail(c, err.Error())
+		return
+	}
+	self.success(c, map[string]string{"uuid": bittrexUUID(w.ID)})
+}
+
+func (self bittrexHandler) withdrawalHistory(c *gin.Context) {
+	currency := strings.ToUpper(c.Query("currency"))
+	result := []gin.H{}
+	for _, w := range self.engine.Withdrawals() {
+		if currency != "" && w.Asset != currency {
+			continue
+		}
+		result = append(result, gin.H{
+			"PaymentUuid":    bittrexUUID(w.ID),
+			"Currency":       w.Asset,
+			"Amount":         w.Amount,
+			"Address":        w.Address,
+			"Opened":         bittrexTime(w.Time),
+			"Authorized":     true,
+			"PendingPayment": !w.Done,
+			"TxCost":         0,
+			"TxId":           w.TxHash,
+			"Canceled":       false,
+			"InvalidAddress": false,
+		})
+	}
+	self.success(c, result)
+}
+
+func (self bittrexHandler) depositHistory(c *gin.Context) {
+	currency := strings.ToUpper(c.Query("currency"))
+	result := []gin.H{}
+	for _, d := range self.engine.Deposits() {
+		if currency != "" && d.Asset != currency {
+			continue
+		}
+		result = append(result, gin.H{
+			"Id":            d.ID,
+			"Currency":      d.Asset,
+			"Amount":        d.Amount,
+			"CryptoAddress": d.Address,
+			"TxId":          d.TxHash,
+			"Confirmations": 36,
+			"LastUpdated":   bittrexTime(d.Time),
+		})
+	}
+	self.success(c, result)
+}
+
+// depositAddress responses with success as string, as Bittrex does.
+func (self bittrexHandler) depositAddress(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"success": "true",
+		"message": "",
+		"result": gin.H{
+			"Currency": strings.ToUpper(c.Query("currency")),
+			"Address":  self.engine.DepositAddress(),
+		},
+	})
+}
@@ -0,0 +1,39 @@
+package simulator
+
+import (
+	"encoding/json"
+	"io/ioutil"
+)
+
+// ExchangeConfig is the initial state of a simulated exchange.
+type ExchangeConfig struct {
+	// Balances are the initial free balances by asset.
+	Balances map[string]float64 `json:"balances"`
+	// Markets are the tradable pairs and their order books.
+	Markets []Market `json:"markets"`
+	// DepositAddress is the address returned for deposit of every asset.
+	DepositAddress string `json:"deposit_address"`
+	// WithdrawDelayMS is the time a withdrawal takes to be done.
+	WithdrawDelayMS int64 `json:"withdraw_delay_ms"`
+	// Faults are the failures injected from the start.
+	Faults Faults `json:"faults"`
+}
+
+// Config is the configuration of the simulator, an exchange is not served
+// if its configuration is missing.
+type Config struct {
+	Binance *ExchangeConfig `json:"binance"`
+	Huobi   *ExchangeConfig `json:"huobi"`
+	Bittrex *ExchangeConfig `json:"bittrex"`
+}
+
+// LoadConfig reads the configuration from a json file.
+func LoadConfig(path string) (Config, error) {
+	result := Config{}
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return result, err
+	}
+	err = json.Unmarshal(data, &result)
+	return result, err
+}
@@ -0,0 +1,614 @@
+// Package simulator serves the subset of Binance, Huobi and Bittrex REST APIs
+// used by the reserve, backed by an in-memory matching engine. It lets core
+// and fetchers run end to end without the real exchanges.
+package simulator
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// epsilon is the quantity under which an order or a price level is
+// considered empty.
+const epsilon = 1e-12
+
+// Sides of an order.
+const (
+	Buy  = "buy"
+	Sell = "sell"
+)
+
+// Statuses of an order.
+const (
+	OrderOpen     = "open"
+	OrderFilled   = "filled"
+	OrderCanceled = "canceled"
+)
+
+var (
+	// ErrUnknownMarket is returned when the market is not configured.
+	ErrUnknownMarket = errors.New("unknown market")
+	// ErrInsufficientBalance is returned when the free balance can't cover an
+	// order or a withdrawal.
+	ErrInsufficientBalance = errors.New("insufficient balance")
+	// ErrOrderNotFound is returned when the order doesn't exist.
+	ErrOrderNotFound = errors.New("order not found")
+	// ErrOrderClosed is returned when canceling an order which is filled or
+	// canceled already.
+	ErrOrderClosed = errors.New("order is closed")
+)
+
+// Level is a price level of an order book: rate and quantity.
+type Level [2]float64
+
+// Rate returns the rate of the level.
+func (self Level) Rate() float64 { return self[0] }
+
+// Quantity returns the quantity of the level.
+func (self Level) Quantity() float64 { return self[1] }
+
+// Market is the order book and trading rules of a pair. The order book
+// stands for the liquidity of the other traders, orders placed to the
+// engine are matched against it.
+type Market struct {
+	Base            string  `json:"base"`
+	Quote           string  `json:"quote"`
+	Bids            []Level `json:"bids"`
+	Asks            []Level `json:"asks"`
+	MinQuantity     float64 `json:"min_quantity"`
+	MinNotional     float64 `json:"min_notional"`
+	PricePrecision  int     `json:"price_precision"`
+	AmountPrecision int     `json:"amount_precision"`
+}
+
+func (self *Market) sortBook() {
+	sort.SliceStable(self.Bids, func(i, j int) bool { return self.Bids[i].Rate() > self.Bids[j].Rate() })
+	sort.SliceStable(self.Asks, func(i, j int) bool { return self.Asks[i].Rate() < self.Asks[j].Rate() })
+}
+
+func (self Market) copy() Market {
+	self.Bids = append([]Level{}, self.Bids...)
+	self.Asks = append([]Level{}, self.Asks...)
+	return self
+}
+
+// Balance is the balance of an asset.
+type Balance struct {
+	Free   float64 `json:"free"`
+	Locked float64 `json:"locked"`
+}
+
+// Order is a limit order placed to the engine.
+type Order struct {
+	ID       uint64    `json:"id"`
+	Base     string    `json:"base"`
+	Quote    string    `json:"quote"`
+	Side     string    `json:"side"`
+	Rate     float64   `json:"rate"`
+	Quantity float64   `json:"quantity"`
+	Executed float64   `json:"executed"`
+	Status   string    `json:"status"`
+	Time     time.Time `json:"time"`
+	Closed   time.Time `json:"closed"`
+}
+
+// Remaining returns the quantity not filled yet.
+func (self Order) Remaining() float64 {
+	return self.Quantity - self.Executed
+}
+
+// Trade is a fill of an order.
+type Trade struct {
+	ID       uint64    `json:"id"`
+	OrderID  uint64    `json:"order_id"`
+	Base     string    `json:"base"`
+	Quote    string    `json:"quote"`
+	Side     string    `json:"side"`
+	Rate     float64   `json:"rate"`
+	Quantity float64   `json:"quantity"`
+	Time     time.Time `json:"time"`
+}
+
+// Withdrawal is a withdrawal requested to the engine.
+type Withdrawal struct {
+	ID      uint64    `json:"id"`
+	Asset   string    `json:"asset"`
+	Amount  float64   `json:"amount"`
+	Address string    `json:"address"`
+	TxHash  string    `json:"tx_hash"`
+	Time    time.Time `json:"time"`
+	Stuck   bool      `json:"stuck"`
+	Done    bool      `json:"done"`
+}
+
+// Deposit is a deposit credited to the engine.
+type Deposit struct {
+	ID      uint64    `json:"id"`
+	Asset   string    `json:"asset"`
+	Amount  float64   `json:"amount"`
+	Address string    `json:"address"`
+	TxHash  string    `json:"tx_hash"`
+	Time    time.Time `json:"time"`
+}
+
+// Faults are the failures injected to the exchange.
+type Faults struct {
+	// LatencyMS is the delay in millisecond added to every API request.
+	LatencyMS int64 `json:"latency_ms"`
+	// ErrorRate is the probability of an API request to fail with 500.
+	ErrorRate float64 `json:"error_rate"`
+	// PartialFill, if between 0 and 1, is the maximum fraction of an order
+	// filled on placement, the rest is left open.
+	PartialFill float64 `json:"partial_fill"`
+	// StuckWithdrawals makes new withdrawals pending until released.
+	StuckWithdrawals bool `json:"stuck_withdrawals"`
+}
+
+// State is a snapshot of everything in the engine.
+type State struct {
+	Balances    map[string]Balance `json:"balances"`
+	Markets     []Market           `json:"markets"`
+	Orders      []Order            `json:"orders"`
+	Withdrawals []Withdrawal       `json:"withdrawals"`
+	Deposits    []Deposit          `json:"deposits"`
+	Faults      Faults             `json:"faults"`
+}
+
+// Engine keeps the balances, order books, orders, deposits and withdrawals
+// of a simulated exchange account.
+type Engine struct {
+	mu             sync.Mutex
+	balances       map[string]*Balance
+	markets        []*Market
+	orders         []*Order
+	trades         []Trade
+	withdrawals    []*Withdrawal
+	deposits       []Deposit
+	faults         Faults
+	depositAddress string
+	withdrawDelay  time.Duration
+	nextID         uint64
+	sequence       int64
+	rand           *rand.Rand
+}
+
+// NewEngine creates an engine from config.
+func NewEngine(config ExchangeConfig) *Engine {
+	engine := &Engine{
+		balances:       map[string]*Balance{},
+		faults:         config.Faults,
+		depositAddress: config.DepositAddress,
+		withdrawDelay:  time.Duration(config.WithdrawDelayMS) * time.Millisecond,
+		nextID:         1,
+		sequence:       1,
+		rand:           rand.New(rand.NewSource(time.Now().UnixNano())),
+	}
+	for asset, amount := range config.Balances {
+		engine.balances[normalize(asset)] = &Balance{Free: amount}
+	}
+	for _, market := range config.Markets {
+		m := market.copy()
+		m.Base = normalize(m.Base)
+		m.Quote = normalize(m.Quote)
+		m.sortBook()
+		engine.markets = append(engine.markets, &m)
+	}
+	return engine
+}
+
+func normalize(asset string) string {
+	return strings.ToUpper(asset)
+}
+
+func (self *Engine) newID() uint64 {
+	id := self.nextID
+	self.nextID++
+	return id
+}
+
+func (self *Engine) balance(asset string) *Balance {
+	b, ok := self.balances[asset]
+	if !ok {
+		b = &Balance{}
+		self.balances[asset] = b
+	}
+	return b
+}
+
+func (self *Engine) market(base, quote string) (*Market, error) {
+	base, quote = normalize(base), normalize(quote)
+	for _, m := range self.markets {
+		if m.Base == base && m.Quote == quote {
+			return m, nil
+		}
+	}
+	return nil, fmt.Errorf("%s: %s-%s", ErrUnknownMarket, base, quote)
+}
+
+func (self *Engine) order(id uint64) (*Order, error) {
+	for _, o := range self.orders {
+		if o.ID == id {
+			return o, nil
+		}
+	}
+	return nil, ErrOrderNotFound
+}
+
+// Markets returns all markets of the engine.
+func (self *Engine) Markets() []Market {
+	self.mu.Lock()
+	defer self.mu.Unlock()
+	result := []Market{}
+	for _, m := range self.markets {
+		result = append(result, m.copy())
+	}
+	return result
+}
+
+// Market returns the market of given pair.
+func (self *Engine) Market(base, quote string) (Market, error) {
+	self.mu.Lock()
+	defer self.mu.Unlock()
+	m, err := self.market(base, quote)
+	if err != nil {
+		return Market{}, err
+	}
+	return m.copy(), nil
+}
+
+// Sequence returns a number increased on every change of the order books.
+func (self *Engine) Sequence() int64 {
+	self.mu.Lock()
+	defer self.mu.Unlock()
+	return self.sequence
+}
+
+// SetOrderbook replaces the order book of a market, the open orders are
+// matched against the new book.
+func (self *Engine) SetOrderbook(base, quote string, bids, asks []Level) error {
+	self.mu.Lock()
+	defer self.mu.Unlock()
+	m, err := self.market(base, quote)
+	if err != nil {
+		return err
+	}
+	m.Bids = append([]Level{}, bids...)
+	m.Asks = append([]Level{}, asks...)
+	m.sortBook()
+	self.sequence++
+	for _, o := range self.orders {
+		if o.Status == OrderOpen && o.Base == m.Base && o.Quote == m.Quote {
+			self.match(m, o, o.Remaining())
+		}
+	}
+	return nil
+}
+
+// Balances returns the balances of all assets.
+func (self *Engine) Balances() map[string]Balance {
+	self.mu.Lock()
+	defer self.mu.Unlock()
+	result := map[string]Balance{}
+	for asset, b := range self.balances {
+		result[asset] = *b
+	}
+	return result
+}
+
+// SetBalance sets the free balance of an asset.
+func (self *Engine) SetBalance(asset string, free float64) {
+	self.mu.Lock()
+	defer self.mu.Unlock()
+	self.balance(normalize(asset)).Free = free
+}
+
+// Faults returns the faults injected to the exchange.
+func (self *Engine) Faults() Faults {
+	self.mu.Lock()
+	defer self.mu.Unlock()
+	return self.faults
+}
+
+// SetFaults sets the faults injected to the exchange.
+func (self *Engine) SetFaults(faults Faults) {
+	self.mu.Lock()
+	defer self.mu.Unlock()
+	self.faults = faults
+}
+
+// injectedFault returns the latency to add to a request and whether the
+// request should fail.
+func (self *Engine) injectedFault() (time.Duration, bool) {
+	self.mu.Lock()
+	defer self.mu.Unlock()
+	latency := time.Duration(self.faults.LatencyMS) * time.Millisecond
+	return latency, self.faults.ErrorRate > 0 && self.rand.Float64() < self.faults.ErrorRate
+}
+
+// PlaceOrder places a limit order, it is filled right away as far as the
+// order book allows, the rest is left open.
+func (self *Engine) PlaceOrder(base, quote, side string, rate, quantity float64) (Order, error) {
+	self.mu.Lock()
+	defer self.mu.Unlock()
+	m, err := self.market(base, quote)
+	if err != nil {
+		return Order{}, err
+	}
+	if side != Buy && side != Sell {
+		return Order{}, fmt.Errorf("invalid side: %s", side)
+	}
+	if rate <= 0 || quantity <= 0 {
+		return Order{}, errors.New("rate and quantity must be positive")
+	}
+	if quantity < m.MinQuantity {
+		return Order{}, fmt.Errorf("quantity %f is less than minimum %f", quantity, m.MinQuantity)
+	}
+	if rate*quantity < m.MinNotional {
+		return Order{}, fmt.Errorf("notional %f is less than minimum %f", rate*quantity, m.MinNotional)
+	}
+	lockAsset, lockAmount := m.Base, quantity
+	if side == Buy {
+		lockAsset, lockAmount = m.Quote, rate*quantity
+	}
+	b := self.balance(lockAsset)
+	if b.Free+epsilon < lockAmount {
+		return Order{}, fmt.Errorf("%s: %s %f, required %f", ErrInsufficientBalance, lockAsset, b.Free, lockAmount)
+	}
+	b.Free -= lockAmount
+	b.Locked += lockAmount
+	o := &Order{
+		ID:       self.newID(),
+		Base:     m.Base,
+		Quote:    m.Quote,
+		Side:     side,
+		Rate:     rate,
+		Quantity: quantity,
+		Status:   OrderOpen,
+		Time:     time.Now(),
+	}
+	self.orders = append(self.orders, o)
+	limit := quantity
+	if self.faults.PartialFill > 0 && self.faults.PartialFill < 1 {
+		limit = quantity * self.faults.PartialFill
+	}
+	self.match(m, o, limit)
+	return *o, nil
+}
+
+// match fills at most limit of an open order against the order book at the
+// rates of the book, consuming its liquidity.
+func (self *Engine) match(m *Market, o *Order, limit float64) {
+	book := &m.Asks
+	crosses := func(rate float64) bool { return rate <= o.Rate }
+	if o.Side == Sell {
+		book = &m.Bids
+		crosses = func(rate float64) bool { return rate >= o.Rate }
+	}
+	filled := false
+	for limit > epsilon && o.Remaining() > epsilon && len(*book) > 0 && crosses((*book)[0].Rate()) {
+		level := (*book)[0]
+		quantity := minFloat(limit, o.Remaining(), level.Quantity())
+		self.fill(o, level.Rate(), quantity)
+		limit -= quantity
+		filled = true
+		if level.Quantity()-quantity > epsilon {
+			(*book)[0] = Level{level.Rate(), level.Quantity() - quantity}
+		} else {
+			*book = (*book)[1:]
+		}
+	}
+	if filled {
+		self.sequence++
+	}
+	if o.Remaining() <= epsilon {
+		o.Status = OrderFilled
+		o.Closed = time.Now()
+	}
+}
+
+// fill moves the balances of a fill of quantity at rate.
+func (self *Engine) fill(o *Order, rate, quantity float64) {
+	base, quote := self.balance(o.Base), self.balance(o.Quote)
+	if o.Side == Buy {
+		quote.Locked -= o.Rate * quantity
+		// buying below the limit rate gives the difference back
+		quote.Free += (o.Rate - rate) * quantity
+		base.Free += quantity
+	} else {
+		base.Locked -= quantity
+		quote.Free += rate * quantity
+	}
+	o.Executed += quantity
+	self.trades = append(self.trades, Trade{
+		ID:       self.newID(),
+		OrderID:  o.ID,
+		Base:     o.Base,
+		Quote:    o.Quote,
+		Side:     o.Side,
+		Rate:     rate,
+		Quantity: quantity,
+		Time:     time.Now(),
+	})
+}
+
+func minFloat(values ...float64) float64 {
+	result := values[0]
+	for _, v := range values[1:] {
+		if v < result {
+			result = v
+		}
+	}
+	return result
+}
+
+// Order returns the order of given id.
+func (self *Engine) Order(id uint64) (Order, error) {
+	self.mu.Lock()
+	defer self.mu.Unlock()
+	o, err := self.order(id)
+	if err != nil {
+		return Order{}, err
+	}
+	return *o, nil
+}
+
+// Orders returns the orders of a market, or of all markets if base and
+// quote are empty. Only open orders are returned if openOnly is true.
+func (self *Engine) Orders(base, quote string, openOnly bool) []Order {
+	self.mu.Lock()
+	defer self.mu.Unlock()
+	base, quote = normalize(base), normalize(quote)
+	result := []Order{}
+	for _, o := range self.orders {
+		if base != "" && (o.Base != base || o.Quote != quote) {
+			continue
+		}
+		if openOnly && o.Status != OrderOpen {
+			continue
+		}
+		result = append(result, *o)
+	}
+	return result
+}
+
+// CancelOrder cancels an open order and unlocks its remaining balance.
+func (self *Engine) CancelOrder(id uint64) (Order, error) {
+	self.mu.Lock()
+	defer self.mu.Unlock()
+	o, err := self.order(id)
+	if err != nil {
+		return Order{}, err
+	}
+	if o.Status != OrderOpen {
+		return *o, ErrOrderClosed
+	}
+	if o.Side == Buy {
+		b := self.balance(o.Quote)
+		b.Locked -= o.Remaining() * o.Rate
+		b.Free += o.Remaining() * o.Rate
+	} else {
+		b := self.balance(o.Base)
+		b.Locked -= o.Remaining()
+		b.Free += o.Remaining()
+	}
+	o.Status = OrderCanceled
+	o.Closed = time.Now()
+	return *o, nil
+}
+
+// Trades returns the fills of a market.
+func (self *Engine) Trades(base, quote string) []Trade {
+	self.mu.Lock()
+	defer self.mu.Unlock()
+	base, quote = normalize(base), normalize(quote)
+	result := []Trade{}
+	for _, t := range self.trades {
+		if t.Base == base && t.Quote == quote {
+			result = append(result, t)
+		}
+	}
+	return result
+}
+
+// DepositAddress returns the address to deposit to the exchange.
+func (self *Engine) DepositAddress() string {
+	return self.depositAddress
+}
+
+// Deposit credits a deposit of amount of asset sent by transaction txHash.
+func (self *Engine) Deposit(asset string, amount float64, txHash string) Deposit {
+	self.mu.Lock()
+	defer self.mu.Unlock()
+	asset = normalize(asset)
+	self.balance(asset).Free += amount
+	d := Deposit{
+		ID:      self.newID(),
+		Asset:   asset,
+		Amount:  amount,
+		Address: self.depositAddress,
+		TxHash:  txHash,
+		Time:    time.Now(),
+	}
+	self.deposits = append(self.deposits, d)
+	return d
+}
+
+// Deposits returns all deposits.
+func (self *Engine) Deposits() []Deposit {
+	self.mu.Lock()
+	defer self.mu.Unlock()
+	return append([]Deposit{}, self.deposits...)
+}
+
+// Withdraw takes amount of asset from the free balance and sends it to
+// address. The withdrawal is done after the configured delay, unless it is
+// stuck by the injected faults.
+func (self *Engine) Withdraw(asset string, amount float64, address string) (Withdrawal, error) {
+	self.mu.Lock()
+	defer self.mu.Unlock()
+	asset = normalize(asset)
+	if amount <= 0 {
+		return Withdrawal{}, errors.New("amount must be positive")
+	}
+	b := self.balance(asset)
+	if b.Free+epsilon < amount {
+		return Withdrawal{}, fmt.Errorf("%s: %s %f, required %f", ErrInsufficientBalance, asset, b.Free, amount)
+	}
+	b.Free -= amount
+	id := self.newID()
+	w := &Withdrawal{
+		ID:      id,
+		Asset:   asset,
+		Amount:  amount,
+		Address: address,
+		TxHash:  fmt.Sprintf("0x%064x", id),
+		Time:    time.Now(),
+		Stuck:   self.faults.StuckWithdrawals,
+	}
+	self.withdrawals = append(self.withdrawals, w)
+	return self.withdrawal(w), nil
+}
+
+func (self *Engine) withdrawal(w *Withdrawal) Withdrawal {
+	result := *w
+	result.Done = !w.Stuck && time.Since(w.Time) >= self.withdrawDelay
+	return result
+}
+
+// Withdrawals returns all withdrawals.
+func (self *Engine) Withdrawals() []Withdrawal {
+	self.mu.Lock()
+	defer self.mu.Unlock()
+	result := []Withdrawal{}
+	for _, w := range self.withdrawals {
+		result = append(result, self.withdrawal(w))
+	}
+	return result
+}
+
+// ReleaseWithdrawals lets the stuck withdrawals complete.
+func (self *Engine) ReleaseWithdrawals() {
+	self.mu.Lock()
+	defer self.mu.Unlock()
+	for _, w := range self.withdrawals {
+		w.Stuck = false
+	}
+}
+
+// State returns a snapshot of the engine.
+func (self *Engine) State() State {
+	state := State{
+		Balances:    self.Balances(),
+		Markets:     self.Markets(),
+		Orders:      self.Orders("", "", false),
+		Withdrawals: self.Withdrawals(),
+		Deposits:    self.Deposits(),
+		Faults:      self.Faults(),
+	}
+	return state
+}
@@ -0,0 +1,311 @@
+package simulator
+
+import (
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/KyberNetwork/reserve-data/exchange"
+	"github.com/gin-gonic/gin"
+)
+
+// huobiAccountID is the id of the only account of the simulated Huobi.
+const huobiAccountID uint64 = 1
+
+type huobiHandler struct {
+	engine *Engine
+}
+
+// NewHuobiHandler returns the handler serving Huobi REST API from engine.
+// Signatures of authenticated requests are not checked.
+func NewHuobiHandler(engine *Engine) http.Handler {
+	r, g := newRouter(engine)
+	h := huobiHandler{engine}
+	g.GET("/v1/account/accounts", h.accounts)
+	g.GET("/v1/account/accounts/:id/balance", h.balance)
+	g.GET("/market/depth", h.depth)
+	g.GET("/v1/common/symbols", h.symbols)
+	// place and :id/submitcancel can't be routed separately
+	g.POST("/v1/order/orders/*action", h.orderAction)
+	g.GET("/v1/order/orders", h.orderHistory)
+	g.GET("/v1/order/orders/:id", h.orderStatus)
+	g.GET("/v1/query/finances", h.finances)
+	g.POST("/v1/dw/withdraw/api/create", h.withdraw)
+	g.GET("/v1/dw/deposit-virtual/addresses", h.depositAddress)
+	return r
+}
+
+func huobiTime(t time.Time) uint64 {
+	return uint64(t.UnixNano() / int64(time.Millisecond))
+}
+
+func (self huobiHandler) fail(c *gin.Context, msg string) {
+	c.JSON(http.StatusOK, gin.H{"status": "error", "err-code": "simulator-error", "err-msg": msg})
+}
+
+// market returns the market of the symbol param, eg: knceth.
+func (self huobiHandler) market(c *gin.Context) (Market, bool) {
+	symbol := strings.ToLower(c.Request.FormValue("symbol"))
+	for _, m := range self.engine.Markets() {
+		if strings.ToLower(m.Base+m.Quote) == symbol {
+			return m, true
+		}
+	}
+	self.fail(c, "invalid symbol")
+	return Market{}, false
+}
+
+func (self huobiHandler) accounts(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"status": "ok",
+		"data": []gin.H{
+			{"id": huobiAccountID, "type": "spot", "state": "working", "user-id": huobiAccountID},
+		},
+	})
+}
+
+func (self huobiHandler) balance(c *gin.Context) {
+	if c.Param("id") != strconv.FormatUint(huobiAccountID, 10) {
+		self.fail(c, "account not found")
+		return
+	}
+	list := []gin.H{}
+	for asset, b := range self.engine.Balances() {
+		currency := strings.ToLower(asset)
+		list = append(list,
+			gin.H{"currency": currency, "type": "trade", "balance": formatFloat(b.Free)},
+			gin.H{"currency": currency, "type": "frozen", "balance": formatFloat(b.Locked)},
+		)
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"status": "ok",
+		"data": gin.H{
+			"id":    huobiAccountID,
+			"type":  "spot",
+			"state": "working",
+			"list":  list,
+		},
+	})
+}
+
+func huobiLevels(levels []Level) [][]float64 {
+	result := [][]float64{}
+	for _, level := range levels {
+		result = append(result, []float64{level.Rate(), level.Quantity()})
+	}
+	return result
+}
+
+func (self huobiHandler) depth(c *gin.Context) {
+	m, ok := self.market(c)
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"status": "ok",
+		"ts":     huobiTime(time.Now()),
+		"tick": gin.H{
+			"bids": huobiLevels(m.Bids),
+			"asks": huobiLevels(m.Asks),
+		},
+	})
+}
+
+func (self huobiHandler) symbols(c *gin.Context) {
+	data := []gin.H{}
+	for _, m := range self.engine.Markets() {
+		data = append(data, gin.H{
+			"base-currency":    strings.ToLower(m.Base),
+			"quote-currency":   strings.ToLower(m.Quote),
+			"price-precision":  m.PricePrecision,
+			"amount-precision": m.AmountPrecision,
+		})
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "ok", "data": data})
+}
+
+func (self huobiHandler) orderAction(c *gin.Context) {
+	parts := strings.Split(strings.Trim(c.Param("action"), "/"), "/")
+	switch {
+	case len(parts) == 1 && parts[0] == "place":
+		self.placeOrder(c)
+	case len(parts) == 2 && parts[1] == "submitcancel":
+		self.cancelOrder(c, parts[0])
+	default:
+		c.JSON(http.StatusNotFound, gin.H{"status": "error", "err-msg": "not found"})
+	}
+}
+
+func (self huobiHandler) placeOrder(c *gin.Context) {
+	m, ok := self.market(c)
+	if !ok {
+		return
+	}
+	rate, rErr := strconv.ParseFloat(c.Request.FormValue("price"), 64)
+	quantity, qErr := strconv.ParseFloat(c.Request.FormValue("amount"), 64)
+	if rErr != nil || qErr != nil {
+		self.fail(c, "invalid price or amount")
+		return
+	}
+	side := strings.TrimSuffix(c.Request.FormValue("type"), "-limit")
+	o, err := self.engine.PlaceOrder(m.Base, m.Quote, side, rate, quantity)
+	if err != nil {
+		self.fail(c, err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, exchange.HuobiTrade{Status: "ok", OrderID: strconv.FormatUint(o.ID, 10)})
+}
+
+func (self huobiHandler) cancelOrder(c *gin.Context, orderID string) {
+	id, err := strconv.ParseUint(orderID, 10, 64)
+	if err != nil {
+		self.fail(c, "invalid order id")
+		return
+	}
+	if _, err := self.engine.CancelOrder(id); err != nil {
+		self.fail(c, err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, exchange.HuobiCancel{Status: "ok", OrderID: orderID})
+}
+
+func huobiOrderState(o Order) string {
+	switch {
+	case o.Status == OrderFilled:
+		return "filled"
+	case o.Status == OrderCanceled && o.Executed > 0:
+		return "partial-canceled"
+	case o.Status == OrderCanceled:
+		return "canceled"
+	case o.Executed > 0:
+		return "partial-filled"
+	}
+	return "submitted"
+}
+
+func huobiOrder(o Order) gin.H {
+	return gin.H{
+		"id":           o.ID,
+		"symbol":       strings.ToLower(o.Base + o.Quote),
+		"account-id":   huobiAccountID,
+		"amount":       formatFloat(o.Quantity),
+		"price":        formatFloat(o.Rate),
+		"type":         o.Side + "-limit",
+		"state":        huobiOrderState(o),
+		"field-amount": formatFloat(o.Executed),
+		"created-at":   huobiTime(o.Time),
+		"finished-at":  huobiTime(o.Closed),
+	}
+}
+
+func (self huobiHandler) orderStatus(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		self.fail(c, "invalid order id")
+		return
+	}
+	o, err := self.engine.Order(id)
+	if err != nil {
+		self.fail(c, err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "ok", "data": huobiOrder(o)})
+}
+
+func (self huobiHandler) orderHistory(c *gin.Context) {
+	m, ok := self.market(c)
+	if !ok {
+		return
+	}
+	states := strings.Split(c.Request.FormValue("states"), ",")
+	data := []gin.H{}
+	for _, o := range self.engine.Orders(m.Base, m.Quote, false) {
+		state := huobiOrderState(o)
+		for _, s := range states {
+			if s == state {
+				data = append(data, huobiOrder(o))
+				break
+			}
+		}
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "ok", "data": data})
+}
+
+// finances returns the latest deposits or withdrawals, as many as the size
+// param.
+func (self huobiHandler) finances(c *gin.Context) {
+	size, err := strconv.Atoi(c.Request.FormValue("size"))
+	if err != nil || size <= 0 {
+		size = 100
+	}
+	switch c.Request.FormValue("types") {
+	case "deposit-virtual":
+		deposits := self.engine.Deposits()
+		sort.SliceStable(deposits, func(i, j int) bool { return deposits[i].ID > deposits[j].ID })
+		data := []exchange.HuobiDeposit{}
+		for i, d := range deposits {
+			if i >= size {
+				break
+			}
+			data = append(data, exchange.HuobiDeposit{
+				ID:       d.ID,
+				TxID:     d.ID,
+				Currency: strings.ToLower(d.Asset),
+				Amount:   formatFloat(d.Amount),
+				State:    "safe",
+				TxHash:   d.TxHash,
+				Address:  d.Address,
+			})
+		}
+		c.JSON(http.StatusOK, exchange.HuobiDeposits{Status: "ok", Data: data})
+	case "withdraw-virtual":
+		withdrawals := self.engine.Withdrawals()
+		sort.SliceStable(withdrawals, func(i, j int) bool { return withdrawals[i].ID > withdrawals[j].ID })
+		data := []exchange.HuobiWithdrawHistory{}
+		for i, w := range withdrawals {
+			if i >= size {
+				break
+			}
+			state := "pre-transfer"
+			if w.Done {
+				state = "confirmed"
+			}
+			data = append(data, exchange.HuobiWithdrawHistory{
+				ID:       w.ID,
+				TxID:     w.ID,
+				Currency: strings.ToLower(w.Asset),
+				Amount:   formatFloat(w.Amount),
+				State:    state,
+				TxHash:   w.TxHash,
+				Address:  w.Address,
+			})
+		}
+		c.JSON(http.StatusOK, exchange.HuobiWithdraws{Status: "ok", Data: data})
+	default:
+		self.fail(c, "invalid types")
+	}
+}
+
+func (self huobiHandler) withdraw(c *gin.Context) {
+	amount, err := strconv.ParseFloat(c.Request.FormValue("amount"), 64)
+	if err != nil {
+		self.fail(c, "invalid amount")
+		return
+	}
+	w, err := self.engine.Withdraw(c.Request.FormValue("currency"), amount, c.Request.FormValue("address"))
+	if err != nil {
+		self.fail(c, err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, exchange.HuobiWithdraw{Status: "ok", ID: w.ID})
+}
+
+func (self huobiHandler) depositAddress(c *gin.Context) {
+	c.JSON(http.StatusOK, exchange.HuobiDepositAddress{
+		Success: true,
+		Address: self.engine.DepositAddress(),
+		Asset:   c.Request.FormValue("currency"),
+	})
+}
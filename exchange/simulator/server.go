@@ -0,0 +1,175 @@
+package simulator
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/KyberNetwork/reserve-data/http/httputil"
+	"github.com/gin-gonic/gin"
+)
+
+// Ports the exchanges are served on, the same as the simulated interfaces of
+// the exchange packages expect.
+const (
+	BinancePort = 5100
+	HuobiPort   = 5200
+	BittrexPort = 5300
+)
+
+// faultInjector delays or fails the exchange API requests as configured in
+// the faults of engine.
+func faultInjector(engine *Engine) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		latency, fail := engine.injectedFault()
+		if latency > 0 {
+			time.Sleep(latency)
+		}
+		if fail {
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{
+				"success": false,
+				"message": "simulated internal error",
+				"msg":     "simulated internal error",
+			})
+		}
+	}
+}
+
+// newRouter creates the router of a simulated exchange, with the admin API
+// to control engine under /simulator and the exchange API in the returned
+// group, which is subject to the injected faults.
+func newRouter(engine *Engine) (*gin.Engine, *gin.RouterGroup) {
+	r := gin.Default()
+	admin := adminHandler{engine}
+	g := r.Group("/simulator")
+	g.GET("/state", admin.state)
+	g.POST("/balances", admin.setBalances)
+	g.POST("/deposits", admin.deposit)
+	g.POST("/orderbook", admin.setOrderbook)
+	g.POST("/faults", admin.setFaults)
+	g.POST("/withdrawals/release", admin.releaseWithdrawals)
+	return r, r.Group("/", faultInjector(engine))
+}
+
+type adminHandler struct {
+	engine *Engine
+}
+
+func (self adminHandler) state(c *gin.Context) {
+	httputil.ResponseSuccess(c, httputil.WithData(self.engine.State()))
+}
+
+func (self adminHandler) setBalances(c *gin.Context) {
+	balances := map[string]float64{}
+	if err := c.ShouldBindJSON(&balances); err != nil {
+		httputil.ResponseFailure(c, httputil.WithError(err))
+		return
+	}
+	for asset, free := range balances {
+		self.engine.SetBalance(asset, free)
+	}
+	httputil.ResponseSuccess(c, httputil.WithData(self.engine.Balances()))
+}
+
+func (self adminHandler) deposit(c *gin.Context) {
+	var request struct {
+		Asset  string  `json:"asset"`
+		Amount float64 `json:"amount"`
+		TxHash string  `json:"tx_hash"`
+	}
+	if err := c.ShouldBindJSON(&request); err != nil {
+		httputil.ResponseFailure(c, httputil.WithError(err))
+		return
+	}
+	if request.Asset == "" || request.Amount <= 0 {
+		httputil.ResponseFailure(c, httputil.WithReason("asset and positive amount are required"))
+		return
+	}
+	httputil.ResponseSuccess(c, httputil.WithData(
+		self.engine.Deposit(request.Asset, request.Amount, request.TxHash)))
+}
+
+func (self adminHandler) setOrderbook(c *gin.Context) {
+	var request Market
+	if err := c.ShouldBindJSON(&request); err != nil {
+		httputil.ResponseFailure(c, httputil.WithError(err))
+		return
+	}
+	if err := self.engine.SetOrderbook(request.Base, request.Quote, request.Bids, request.Asks); err != nil {
+		httputil.ResponseFailure(c, httputil.WithError(err))
+		return
+	}
+	httputil.ResponseSuccess(c)
+}
+
+func (self adminHandler) setFaults(c *gin.Context) {
+	var faults Faults
+	if err := c.ShouldBindJSON(&faults); err != nil {
+		httputil.ResponseFailure(c, httputil.WithError(err))
+		return
+	}
+	self.engine.SetFaults(faults)
+	httputil.ResponseSuccess(c, httputil.WithData(faults))
+}
+
+func (self adminHandler) releaseWithdrawals(c *gin.Context) {
+	self.engine.ReleaseWithdrawals()
+	httputil.ResponseSuccess(c)
+}
+
+// formatFloat formats numbers returned as string by exchange APIs.
+func formatFloat(value float64) string {
+	return strconv.FormatFloat(value, 'f', -1, 64)
+}
+
+// Server serves the configured simulated exchanges.
+type Server struct {
+	Binance *Engine
+	Huobi   *Engine
+	Bittrex *Engine
+}
+
+// NewServer creates the engines of the configured exchanges.
+func NewServer(config Config) *Server {
+	server := &Server{}
+	if config.Binance != nil {
+		server.Binance = NewEngine(*config.Binance)
+	}
+	if config.Huobi != nil {
+		server.Huobi = NewEngine(*config.Huobi)
+	}
+	if config.Bittrex != nil {
+		server.Bittrex = NewEngine(*config.Bittrex)
+	}
+	return server
+}
+
+// Run serves the exchanges on host at their simulation ports, it returns when
+// any of them stops.
+func (self *Server) Run(host string) error {
+	errCh := make(chan error, 3)
+	serve := func(name string, port int, handler http.Handler) {
+		addr := fmt.Sprintf("%s:%d", host, port)
+		log.Printf("Simulated %s is served at %s", name, addr)
+		errCh <- http.ListenAndServe(addr, handler)
+	}
+	running := 0
+	if self.Binance != nil {
+		go serve("binance", BinancePort, NewBinanceHandler(self.Binance))
+		running++
+	}
+	if self.Huobi != nil {
+		go serve("huobi", HuobiPort, NewHuobiHandler(self.Huobi))
+		running++
+	}
+	if self.Bittrex != nil {
+		go serve("bittrex", BittrexPort, NewBittrexHandler(self.Bittrex))
+		running++
+	}
+	if running == 0 {
+		return fmt.Errorf("no exchange is configured")
+	}
+	return <-errCh
+}
@@ -0,0 +1,289 @@
+package simulator
+
+import (
+	"bytes"
+	"encoding/json"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/KyberNetwork/reserve-data/common"
+	"github.com/KyberNetwork/reserve-data/exchange/binance"
+	"github.com/KyberNetwork/reserve-data/exchange/bittrex"
+	"github.com/KyberNetwork/reserve-data/exchange/huobi"
+	ethereum "github.com/ethereum/go-ethereum/common"
+)
+
+// testInterface points the exchange endpoints to a test server.
+type testInterface struct {
+	url string
+}
+
+func (self testInterface) PublicEndpoint() string        { return self.url }
+func (self testInterface) AuthenticatedEndpoint() string { return self.url }
+
+type testBittrexInterface struct {
+	url string
+}
+
+func (self testBittrexInterface) PublicEndpoint() string  { return self.url + "/api/v1.1/public" }
+func (self testBittrexInterface) MarketEndpoint() string  { return self.url + "/api/v1.1/market" }
+func (self testBittrexInterface) AccountEndpoint() string { return self.url + "/api/v1.1/account" }
+
+var (
+	testETH = common.NewToken("ETH", "Ethereum", "0xeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeee", 18, true, true, 0)
+	testKNC = common.NewToken("KNC", "KyberNetwork", "0xdd974d5c2e2928dea5f71b9825b8b646686bd200", 18, true, true, 0)
+)
+
+func testConfig() ExchangeConfig {
+	return ExchangeConfig{
+		Balances: map[string]float64{"ETH": 10, "KNC": 1000},
+		Markets: []Market{{
+			Base:        "KNC",
+			Quote:       "ETH",
+			Bids:        []Level{{0.0018, 500}, {0.0019, 300}},
+			Asks:        []Level{{0.002, 200}, {0.0021, 500}},
+			MinQuantity: 1,
+		}},
+		DepositAddress: "0x44d34a119ba21a42167ff8b77a88f0fc7bb2db90",
+	}
+}
+
+func assertFloat(t *testing.T, name string, expected, actual float64) {
+	if math.Abs(expected-actual) > 1e-9 {
+		t.Errorf("expected %s %f, got %f", name, expected, actual)
+	}
+}
+
+func TestBinanceSimulator(t *testing.T) {
+	engine := NewEngine(testConfig())
+	server := httptest.NewServer(NewBinanceHandler(engine))
+	defer server.Close()
+	endpoint := binance.NewBinanceEndpoint(binance.Signer{}, testInterface{server.URL})
+
+	depth, err := endpoint.GetDepthOnePair(common.NewTokenPair(testKNC, testETH))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(depth.Bids) != 2 || depth.Bids[0].Rate != "0.0019" || depth.Asks[0].Rate != "0.002" {
+		t.Errorf("unexpected depth: %+v", depth)
+	}
+
+	// buying walks the asks at their rates
+	trade, err := endpoint.Trade("buy", testKNC, testETH, 0.0021, 300)
+	if err != nil {
+		t.Fatal(err)
+	}
+	order, err := endpoint.OrderStatus("KNCETH", trade.OrderID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if order.Status != "FILLED" {
+		t.Errorf("expected order filled, got %s", order.Status)
+	}
+	balances := engine.Balances()
+	assertFloat(t, "ETH", 10-200*0.002-100*0.0021, balances["ETH"].Free)
+	assertFloat(t, "KNC", 1300, balances["KNC"].Free)
+
+	// selling more than the bids at the rate leaves the order open
+	trade, err = endpoint.Trade("sell", testKNC, testETH, 0.0019, 500)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if order, err = endpoint.OrderStatus("KNCETH", trade.OrderID); err != nil {
+		t.Fatal(err)
+	}
+	if order.Status != "PARTIALLY_FILLED" || order.ExecutedQty != "300" {
+		t.Errorf("expected order partially filled 300, got %s %s", order.Status, order.ExecutedQty)
+	}
+	if _, err = endpoint.CancelOrder("KNCETH", trade.OrderID); err != nil {
+		t.Fatal(err)
+	}
+	balances = engine.Balances()
+	assertFloat(t, "KNC", 1000, balances["KNC"].Free)
+	assertFloat(t, "KNC locked", 0, balances["KNC"].Locked)
+
+	if _, err = endpoint.Trade("buy", testKNC, testETH, 0.0021, 100000); err == nil {
+		t.Error("expected trade over balance to fail")
+	}
+
+	id, err := endpoint.Withdraw(testETH, common.FloatToBigInt(1, 18), ethereum.HexToAddress("0x1"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	engine.Deposit("KNC", 50, "0xabc")
+	timepoint := common.GetTimepoint() + 1000
+	withdrawals, err := endpoint.WithdrawHistory(timepoint-86400000, timepoint)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(withdrawals.Withdrawals) != 1 || withdrawals.Withdrawals[0].ID != id || withdrawals.Withdrawals[0].Status != binanceWithdrawCompleted {
+		t.Errorf("unexpected withdrawals: %+v", withdrawals)
+	}
+	deposits, err := endpoint.DepositHistory(timepoint-86400000, timepoint)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(deposits.Deposits) != 1 || deposits.Deposits[0].TxID != "0xabc" || deposits.Deposits[0].Status != binanceDepositSuccess {
+		t.Errorf("unexpected deposits: %+v", deposits)
+	}
+	info, err := endpoint.GetInfo()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(info.Balances) != 2 {
+		t.Errorf("expected 2 balances, got %+v", info.Balances)
+	}
+}
+
+func TestHuobiSimulatorFaults(t *testing.T) {
+	config := testConfig()
+	config.Faults = Faults{PartialFill: 0.5, StuckWithdrawals: true}
+	engine := NewEngine(config)
+	server := httptest.NewServer(NewHuobiHandler(engine))
+	defer server.Close()
+	endpoint := huobi.NewHuobiEndpoint(huobi.Signer{}, testInterface{server.URL})
+
+	trade, err := endpoint.Trade("sell", testKNC, testETH, 0.0018, 100, common.GetTimepoint())
+	if err != nil {
+		t.Fatal(err)
+	}
+	orderID, err := strconv.ParseUint(trade.OrderID, 10, 64)
+	if err != nil {
+		t.Fatal(err)
+	}
+	order, err := endpoint.OrderStatus("knceth", orderID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if order.Data.State != "partial-filled" || order.Data.ExecutedQty != "50" {
+		t.Errorf("expected order partially filled 50, got %s %s", order.Data.State, order.Data.ExecutedQty)
+	}
+	// the open order is matched against the new order book
+	if err = engine.SetOrderbook("KNC", "ETH", []Level{{0.0019, 1000}}, nil); err != nil {
+		t.Fatal(err)
+	}
+	if order, err = endpoint.OrderStatus("knceth", orderID); err != nil {
+		t.Fatal(err)
+	}
+	if order.Data.State != "filled" {
+		t.Errorf("expected order filled, got %s", order.Data.State)
+	}
+
+	id, err := endpoint.Withdraw(testETH, common.FloatToBigInt(1, 18), ethereum.HexToAddress("0x1"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tokens := []common.Token{testETH, testKNC}
+	withdrawals, err := endpoint.WithdrawHistory(tokens)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(withdrawals.Data) != 1 || withdrawals.Data[0].State == "confirmed" {
+		t.Errorf("expected stuck withdrawal, got %+v", withdrawals.Data)
+	}
+	engine.ReleaseWithdrawals()
+	if withdrawals, err = endpoint.WithdrawHistory(tokens); err != nil {
+		t.Fatal(err)
+	}
+	if len(withdrawals.Data) != 1 || strconv.FormatUint(withdrawals.Data[0].TxID, 10) != id || withdrawals.Data[0].State != "confirmed" {
+		t.Errorf("expected withdrawal %s confirmed, got %+v", id, withdrawals.Data)
+	}
+	info, err := endpoint.GetInfo()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(info.Data.List) != 4 {
+		t.Errorf("expected trade and frozen balances of 2 currencies, got %+v", info.Data.List)
+	}
+}
+
+func TestBittrexSimulatorAdmin(t *testing.T) {
+	engine := NewEngine(testConfig())
+	server := httptest.NewServer(NewBittrexHandler(engine))
+	defer server.Close()
+	endpoint := bittrex.NewBittrexEndpoint(bittrex.Signer{}, testBittrexInterface{server.URL})
+	pair := common.NewTokenPair(testKNC, testETH)
+
+	post := func(path string, body interface{}) {
+		data, err := json.Marshal(body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp, err := http.Post(server.URL+path, "application/json", bytes.NewReader(data))
+		if err != nil {
+			t.Fatal(err)
+		}
+		var result struct {
+			Success bool   `json:"success"`
+			Reason  string `json:"reason"`
+		}
+		if err = json.NewDecoder(resp.Body).Decode(&result); err != nil {
+			t.Fatal(err)
+		}
+		if err = resp.Body.Close(); err != nil {
+			t.Fatal(err)
+		}
+		if !result.Success {
+			t.Fatalf("admin request %s failed: %s", path, result.Reason)
+		}
+	}
+
+	post("/simulator/faults", Faults{ErrorRate: 1})
+	if book, err := endpoint.FetchOnePairData(pair); err == nil && book.Success {
+		t.Error("expected request to fail with injected error")
+	}
+	post("/simulator/faults", Faults{})
+	book, err := endpoint.FetchOnePairData(pair)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !book.Success || len(book.Result["buy"]) != 2 || book.Result["sell"][0]["Rate"] != 0.002 {
+		t.Errorf("unexpected order book: %+v", book)
+	}
+
+	post("/simulator/balances", map[string]float64{"OMG": 20})
+	post("/simulator/deposits", map[string]interface{}{"asset": "KNC", "amount": 5, "tx_hash": "0xdef"})
+	info, err := endpoint.GetInfo()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, b := range info.Result {
+		if b.Currency == "KNC" {
+			assertFloat(t, "KNC", 1005, b.Available)
+		}
+	}
+	deposits, err := endpoint.DepositHistory("KNC")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(deposits.Result) != 1 || deposits.Result[0].TxId != "0xdef" || len(deposits.Result[0].LastUpdated) != len(bittrexTimeLayout) {
+		t.Errorf("unexpected deposits: %+v", deposits.Result)
+	}
+
+	trade, err := endpoint.Trade("buy", testKNC, testETH, 0.002, 100)
+	if err != nil || !trade.Success {
+		t.Fatalf("trade failed: %v %+v", err, trade)
+	}
+	order, err := endpoint.OrderStatus(trade.Result["uuid"])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if order.Result.IsOpen || order.Result.QuantityRemaining != 0 {
+		t.Errorf("expected order filled, got %+v", order.Result)
+	}
+
+	withdraw, err := endpoint.Withdraw(testKNC, common.FloatToBigInt(10, 18), ethereum.HexToAddress("0x1"))
+	if err != nil || !withdraw.Success {
+		t.Fatalf("withdraw failed: %v %+v", err, withdraw)
+	}
+	withdrawals, err := endpoint.WithdrawHistory("KNC")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(withdrawals.Result) != 1 || withdrawals.Result[0].PaymentUuid != withdraw.Result["uuid"] || withdrawals.Result[0].PendingPayment {
+		t.Errorf("unexpected withdrawals: %+v", withdrawals.Result)
+	}
+}
@@ -12,6 +12,7 @@ import (
 )
 
 type StableEx struct {
+	name    settings.ExchangeName
 	setting Setting
 }
 
@@ -41,7 +42,7 @@ func (self *StableEx) UpdateDepositAddress(token common.Token, address string) e
 }
 
 func (self *StableEx) GetInfo() (common.ExchangeInfo, error) {
-	return self.setting.GetExchangeInfo(settings.StableExchange)
+	return self.setting.GetExchangeInfo(self.name)
 }
 
 func (self *StableEx) GetLiveExchangeInfos(tokenPairIDs []common.TokenPairID) (common.ExchangeInfo, error) {
@@ -49,7 +50,7 @@ func (self *StableEx) GetLiveExchangeInfos(tokenPairIDs []common.TokenPairID) (c
 }
 
 func (self *StableEx) GetExchangeInfo(pair common.TokenPairID) (common.ExchangePrecisionLimit, error) {
-	exInfo, err := self.setting.GetExchangeInfo(settings.StableExchange)
+	exInfo, err := self.setting.GetExchangeInfo(self.name)
 	if err != nil {
 		return common.ExchangePrecisionLimit{}, err
 	}
@@ -58,17 +59,17 @@ func (self *StableEx) GetExchangeInfo(pair common.TokenPairID) (common.ExchangeP
 }
 
 func (self *StableEx) GetFee() (common.ExchangeFees, error) {
-	return self.setting.GetFee(settings.StableExchange)
+	return self.setting.GetFee(self.name)
 }
 
 // ID must return the exact string or else simulation will fail
 func (self *StableEx) ID() common.ExchangeID {
-	return common.ExchangeID(settings.StableExchange.String())
+	return common.ExchangeID(self.name)
 }
 
 func (self *StableEx) TokenPairs() ([]common.TokenPair, error) {
 	result := []common.TokenPair{}
-	exInfo, err := self.setting.GetExchangeInfo(settings.StableExchange)
+	exInfo, err := self.setting.GetExchangeInfo(self.name)
 	if err != nil {
 		return nil, err
 	}
@@ -165,11 +166,27 @@ func (self *StableEx) OrderStatus(id string, base, quote string) (string, error)
 }
 
 func (self *StableEx) GetMinDeposit() (common.ExchangesMinDeposit, error) {
-	return self.setting.GetMinDeposit(settings.StableExchange)
+	return self.setting.GetMinDeposit(self.name)
 }
 
-func NewStableEx(setting Setting) (*StableEx, error) {
-	return &StableEx{
-		setting,
-	}, nil
+// StableExOption is the option to setup the stable token exchange on creation.
+type StableExOption func(s *StableEx)
+
+// WithStableExName setups the stable token exchange to run with the given
+// name instead of the default one.
+func WithStableExName(name settings.ExchangeName) StableExOption {
+	return func(s *StableEx) {
+		s.name = name
+	}
+}
+
+func NewStableEx(setting Setting, options ...StableExOption) (*StableEx, error) {
+	stableEx := &StableEx{
+		name:    settings.StableExchange,
+		setting: setting,
+	}
+	for _, option := range options {
+		option(stableEx)
+	}
+	return stableEx, nil
 }
@@ -0,0 +1,305 @@
+package http
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha512"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/KyberNetwork/reserve-data/common"
+	"github.com/KyberNetwork/reserve-data/http/httputil"
+	ethereum "github.com/ethereum/go-ethereum/common"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// apiKeyHeader is the header of the ID of the API key signing the
+	// request, requests without it are signed by the shared secrets.
+	apiKeyHeader = "apikey"
+	// keyIDContextKey is the context key of the authenticated key ID.
+	keyIDContextKey = "key_id"
+	// apiKeySecretSize is the number of random bytes of API key secrets.
+	apiKeySecretSize = 32
+	// maxAuditLogTimespan is the maximum time range of audit records
+	// returned at once, in milliseconds.
+	maxAuditLogTimespan uint64 = 7 * 24 * 60 * 60 * 1000
+)
+
+// APIKeyStorage is the storage of named API keys.
+type APIKeyStorage interface {
+	AddAPIKey(key common.APIKey) error
+	UpdateAPIKey(key common.APIKey) error
+	RemoveAPIKey(id string) error
+	GetAPIKey(id string) (common.APIKey, error)
+	GetAPIKeys() ([]common.APIKey, error)
+}
+
+// AuditLogStorage is the append only storage of audit records.
+type AuditLogStorage interface {
+	StoreAuditRecord(record common.AuditRecord) error
+	GetAuditRecords(fromTime, toTime uint64) ([]common.AuditRecord, error)
+}
+
+// APIKeyAuthentication authenticates requests signed by named API keys,
+// the key ID is in apikey header. Requests without the header are
+// authenticated by the fallback authentication.
+type APIKeyAuthentication struct {
+	fallback Authentication
+	storage  APIKeyStorage
+}
+
+// NewAPIKeyAuthentication creates a new APIKeyAuthentication.
+func NewAPIKeyAuthentication(fallback Authentication, storage APIKeyStorage) *APIKeyAuthentication {
+	return &APIKeyAuthentication{fallback: fallback, storage: storage}
+}
+
+// KNSign signs the message with the fallback authentication, it is used by
+// internal clients.
+func (self *APIKeyAuthentication) KNSign(message string) string {
+	return self.fallback.KNSign(message)
+}
+
+func (self *APIKeyAuthentication) GetPermission(keyID, signed, message string) (string, []Permission) {
+	if keyID == "" {
+		return self.fallback.GetPermission(keyID, signed, message)
+	}
+	key, err := self.storage.GetAPIKey(keyID)
+	if err != nil {
+		return "", nil
+	}
+	mac := hmac.New(sha512.New, []byte(key.Secret))
+	if _, err = mac.Write([]byte(message)); err != nil {
+		log.Printf("Encode message error: %s", err.Error())
+	}
+	if !hmac.Equal([]byte(signed), []byte(ethereum.Bytes2Hex(mac.Sum(nil)))) {
+		return "", nil
+	}
+	result := []Permission{}
+	for _, name := range key.Permissions {
+		perm, err := ParsePermission(name)
+		if err != nil {
+			log.Printf("API key %s has invalid permission: %s", keyID, err)
+			continue
+		}
+		result = append(result, perm)
+	}
+	return key.ID, result
+}
+
+// SetAPIKeys enables named API keys stored in storage, and the endpoints to
+// manage them.
+func (self *HTTPServer) SetAPIKeys(storage APIKeyStorage) {
+	self.apiKeys = storage
+	self.auth = NewAPIKeyAuthentication(self.auth, storage)
+}
+
+// SetAuditLog enables recording mutating API calls to the audit log.
+func (self *HTTPServer) SetAuditLog(storage AuditLogStorage) {
+	self.auditLog = storage
+}
+
+// requester returns the ID of the key which signed the request, it is
+// recorded as the proposer or reviewer of withdrawals.
+func (self *HTTPServer) requester(c *gin.Context) string {
+	if id := c.GetString(keyIDContextKey); id != "" {
+		return id
+	}
+	return "unauthenticated"
+}
+
+// auditResponseWriter keeps a copy of the response body to read its outcome.
+type auditResponseWriter struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (self *auditResponseWriter) Write(data []byte) (int, error) {
+	self.body.Write(data)
+	return self.ResponseWriter.Write(data)
+}
+
+// recordAudit is the middleware recording the key, endpoint, params and
+// outcome of mutating API calls to the audit log.
+func (self *HTTPServer) recordAudit(c *gin.Context) {
+	method := c.Request.Method
+	if !isMutating(method) {
+		c.Next()
+		return
+	}
+	writer := &auditResponseWriter{ResponseWriter: c.Writer}
+	c.Writer = writer
+	c.Next()
+
+	if c.Request.Form == nil {
+		if err := c.Request.ParseForm(); err != nil {
+			log.Printf("Audit log: parsing form of %s failed: %s", c.Request.URL.Path, err)
+		}
+	}
+	var response struct {
+		Success bool   `json:"success"`
+		Reason  string `json:"reason"`
+	}
+	if err := json.Unmarshal(writer.body.Bytes(), &response); err != nil {
+		response.Reason = fmt.Sprintf("response status %d", writer.Status())
+	}
+	record := common.AuditRecord{
+		Timestamp: common.GetTimepoint(),
+		KeyID:     c.GetString(keyIDContextKey),
+		ClientIP:  c.ClientIP(),
+		Method:    method,
+		Endpoint:  c.Request.URL.Path,
+		Params:    c.Request.Form,
+		Success:   response.Success,
+		Reason:    response.Reason,
+	}
+	if err := self.auditLog.StoreAuditRecord(record); err != nil {
+		log.Printf("Audit log: storing record of %s failed: %s", record.Endpoint, err)
+	}
+}
+
+// parsePermissions parses comma separated permission names.
+func parsePermissions(names string) ([]string, error) {
+	result := []string{}
+	for _, name := range strings.Split(names, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		if _, err := ParsePermission(name); err != nil {
+			return nil, err
+		}
+		result = append(result, name)
+	}
+	if len(result) == 0 {
+		return nil, fmt.Errorf("no permission is given")
+	}
+	return result, nil
+}
+
+// GetAPIKeys returns all API keys without their secrets.
+func (self *HTTPServer) GetAPIKeys(c *gin.Context) {
+	_, ok := self.Authenticated(c, []string{}, []Permission{AdminPermission})
+	if !ok {
+		return
+	}
+	keys, err := self.apiKeys.GetAPIKeys()
+	if err != nil {
+		httputil.ResponseFailure(c, httputil.WithError(err))
+		return
+	}
+	for i := range keys {
+		keys[i].Secret = ""
+	}
+	httputil.ResponseSuccess(c, httputil.WithData(keys))
+}
+
+// CreateAPIKey creates a new API key with a random secret, the secret is
+// only returned in this response.
+// Params:
+//   - id: ID of the key, it must not start with kn_ which is reserved for
+//     the shared secrets of config file
+//   - permissions: comma separated permissions, eg: read_only,rebalance
+func (self *HTTPServer) CreateAPIKey(c *gin.Context) {
+	postForm, ok := self.Authenticated(c, []string{"id", "permissions"}, []Permission{AdminPermission})
+	if !ok {
+		return
+	}
+	id := postForm.Get("id")
+	if id == "" || strings.HasPrefix(id, "kn_") {
+		httputil.ResponseFailure(c, httputil.WithReason(fmt.Sprintf("invalid API key ID %q", id)))
+		return
+	}
+	permissions, err := parsePermissions(postForm.Get("permissions"))
+	if err != nil {
+		httputil.ResponseFailure(c, httputil.WithError(err))
+		return
+	}
+	secret := make([]byte, apiKeySecretSize)
+	if _, err = rand.Read(secret); err != nil {
+		httputil.ResponseFailure(c, httputil.WithError(err))
+		return
+	}
+	now := common.GetTimepoint()
+	key := common.APIKey{
+		ID:          id,
+		Secret:      hex.EncodeToString(secret),
+		Permissions: permissions,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+	if err = self.apiKeys.AddAPIKey(key); err != nil {
+		httputil.ResponseFailure(c, httputil.WithError(err))
+		return
+	}
+	httputil.ResponseSuccess(c, httputil.WithData(key))
+}
+
+// UpdateAPIKey replaces the permissions of an API key.
+// Params:
+//   - id: ID of the key
+//   - permissions: comma separated permissions, eg: read_only,rebalance
+func (self *HTTPServer) UpdateAPIKey(c *gin.Context) {
+	postForm, ok := self.Authenticated(c, []string{"id", "permissions"}, []Permission{AdminPermission})
+	if !ok {
+		return
+	}
+	permissions, err := parsePermissions(postForm.Get("permissions"))
+	if err != nil {
+		httputil.ResponseFailure(c, httputil.WithError(err))
+		return
+	}
+	key, err := self.apiKeys.GetAPIKey(postForm.Get("id"))
+	if err != nil {
+		httputil.ResponseFailure(c, httputil.WithError(err))
+		return
+	}
+	key.Permissions = permissions
+	key.UpdatedAt = common.GetTimepoint()
+	if err = self.apiKeys.UpdateAPIKey(key); err != nil {
+		httputil.ResponseFailure(c, httputil.WithError(err))
+		return
+	}
+	httputil.ResponseSuccess(c)
+}
+
+// DeleteAPIKey revokes an API key.
+// Params:
+//   - id: ID of the key
+func (self *HTTPServer) DeleteAPIKey(c *gin.Context) {
+	postForm, ok := self.Authenticated(c, []string{"id"}, []Permission{AdminPermission})
+	if !ok {
+		return
+	}
+	if err := self.apiKeys.RemoveAPIKey(postForm.Get("id")); err != nil {
+		httputil.ResponseFailure(c, httputil.WithError(err))
+		return
+	}
+	httputil.ResponseSuccess(c)
+}
+
+// GetAuditLog returns the audit records of mutating API calls in a time range
+// of at most 7 days.
+// Params:
+//   - fromTime: from timepoint, unix millisecond
+//   - toTime: to timepoint, unix millisecond (optional, default now)
+func (self *HTTPServer) GetAuditLog(c *gin.Context) {
+	_, ok := self.Authenticated(c, []string{}, []Permission{AdminPermission})
+	if !ok {
+		return
+	}
+	fromTime, toTime, ok := self.ValidateTimeRange(c, maxAuditLogTimespan)
+	if !ok {
+		return
+	}
+	records, err := self.auditLog.GetAuditRecords(fromTime, toTime)
+	if err != nil {
+		httputil.ResponseFailure(c, httputil.WithError(err))
+		return
+	}
+	httputil.ResponseSuccess(c, httputil.WithData(records))
+}
@@ -0,0 +1,150 @@
+package http
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/KyberNetwork/reserve-data/common"
+	settingsstorage "github.com/KyberNetwork/reserve-data/settings/storage"
+	"github.com/gin-gonic/gin"
+)
+
+func TestAPIKeysAndAuditLog(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "test_api_keys")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if rErr := os.RemoveAll(tmpDir); rErr != nil {
+			t.Error(rErr)
+		}
+	}()
+	st, err := settingsstorage.NewBoltSettingStorage(filepath.Join(tmpDir, "setting.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := HTTPServer{
+		authEnabled: true,
+		auth:        KNAuthentication{KNAdmin: "admin secret"},
+		r:           gin.Default(),
+	}
+	s.SetAPIKeys(st)
+	s.SetAuditLog(st)
+	s.register()
+	server := httptest.NewServer(s.r)
+	defer server.Close()
+
+	type response struct {
+		Success bool            `json:"success"`
+		Reason  string          `json:"reason"`
+		Data    json.RawMessage `json:"data"`
+	}
+	request := func(method, path, keyID, secret string, params url.Values) response {
+		t.Helper()
+		params.Set("nonce", strconv.FormatUint(common.GetTimepoint(), 10))
+		message := params.Encode()
+		var req *http.Request
+		if method == http.MethodGet {
+			req, err = http.NewRequest(method, server.URL+path+"?"+message, nil)
+		} else {
+			req, err = http.NewRequest(method, server.URL+path, strings.NewReader(message))
+			req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Header.Set("signed", KNAuthentication{KNSecret: secret}.KNSign(message))
+		req.Header.Set(apiKeyHeader, keyID)
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		var result response
+		if err = json.NewDecoder(resp.Body).Decode(&result); err != nil {
+			t.Fatal(err)
+		}
+		return result
+	}
+
+	// shared admin secret creates a named key
+	resp := request(http.MethodPost, "/create-api-key", "", "admin secret", url.Values{
+		"id":          {"ops-bot"},
+		"permissions": {"read_only,admin"},
+	})
+	if !resp.Success {
+		t.Fatalf("expected API key is created, got %s", resp.Reason)
+	}
+	var key common.APIKey
+	if err = json.Unmarshal(resp.Data, &key); err != nil {
+		t.Fatal(err)
+	}
+	if key.Secret == "" {
+		t.Fatal("expected secret of created API key")
+	}
+
+	// the named key signs with its own secret
+	resp = request(http.MethodGet, "/api-keys", "ops-bot", key.Secret, url.Values{})
+	if !resp.Success || strings.Contains(string(resp.Data), key.Secret) {
+		t.Errorf("expected API keys without secret, got %+v", resp)
+	}
+	now := common.GetTimepoint()
+	resp = request(http.MethodGet, "/audit-log", "ops-bot", key.Secret, url.Values{})
+	if resp.Success {
+		t.Error("expected audit log requires fromTime")
+	}
+	resp = request(http.MethodGet, "/audit-log", "ops-bot", key.Secret, url.Values{
+		"fromTime": {strconv.FormatUint(now-maxAuditLogTimespan-1, 10)},
+		"toTime":   {strconv.FormatUint(now, 10)},
+	})
+	if resp.Success {
+		t.Error("expected audit log rejects time range longer than the max timespan")
+	}
+	resp = request(http.MethodGet, "/audit-log", "ops-bot", key.Secret, url.Values{
+		"fromTime": {strconv.FormatUint(now-maxAuditLogTimespan+60000, 10)},
+	})
+	if !resp.Success {
+		t.Errorf("expected audit log of the last 7 days, got %s", resp.Reason)
+	}
+	resp = request(http.MethodPost, "/delete-api-key", "ops-bot", "admin secret", url.Values{"id": {"ops-bot"}})
+	if resp.Success {
+		t.Error("expected failure signing with another secret than the named key one")
+	}
+	resp = request(http.MethodPost, "/update-api-key", "ops-bot", key.Secret, url.Values{
+		"id":          {"ops-bot"},
+		"permissions": {"rebalance"},
+	})
+	if !resp.Success {
+		t.Fatalf("expected permissions of API key is updated, got %s", resp.Reason)
+	}
+	resp = request(http.MethodGet, "/audit-log", "ops-bot", key.Secret, url.Values{})
+	if resp.Success {
+		t.Error("expected key without admin permission can't read audit log")
+	}
+
+	records, err := st.GetAuditRecords(0, common.GetTimepoint())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 3 {
+		t.Fatalf("expected audit records of 3 mutating calls, got %+v", records)
+	}
+	if records[0].KeyID != "kn_admin" || records[0].Endpoint != "/create-api-key" || !records[0].Success ||
+		url.Values(records[0].Params).Get("id") != "ops-bot" {
+		t.Errorf("unexpected audit record of created API key: %+v", records[0])
+	}
+	if records[1].KeyID != "" || records[1].Success || records[1].Reason != "Invalid signed token" {
+		t.Errorf("unexpected audit record of invalid signed call: %+v", records[1])
+	}
+	if records[2].KeyID != "ops-bot" || records[2].Endpoint != "/update-api-key" || !records[2].Success {
+		t.Errorf("unexpected audit record of updated API key: %+v", records[2])
+	}
+}
@@ -13,14 +13,21 @@ import (
 // Authentication is the authentication layer of HTTP APIs.
 type Authentication interface {
 	KNSign(message string) string
-	GetPermission(signed string, message string) []Permission
+	// GetPermission returns the ID and the permissions of the key signing
+	// the message. keyID is the key claimed by the request, it is empty for
+	// the shared secrets of config file.
+	GetPermission(keyID, signed, message string) (string, []Permission)
 }
 
+// KNAuthentication authenticates requests signed by the shared secrets of
+// config file. The ID of each secret is its field name in config file.
 type KNAuthentication struct {
 	KNSecret        string `json:"kn_secret"`
 	KNReadOnly      string `json:"kn_readonly"`
 	KNConfiguration string `json:"kn_configuration"`
 	KNConfirmConf   string `json:"kn_confirm_configuration"`
+	// KNAdmin is optional, it manages API keys and reads the audit log.
+	KNAdmin string `json:"kn_admin"`
 }
 
 func NewKNAuthenticationFromFile(path string) KNAuthentication {
@@ -67,23 +74,36 @@ func (self KNAuthentication) knConfirmConfSign(msg string) string {
 	return ethereum.Bytes2Hex(mac.Sum(nil))
 }
 
-func (self KNAuthentication) GetPermission(signed string, message string) []Permission {
-	result := []Permission{}
-	rebalanceSigned := self.KNSign(message)
-	if signed == rebalanceSigned {
-		result = append(result, RebalancePermission)
+func (self KNAuthentication) knAdminSign(msg string) string {
+	mac := hmac.New(sha512.New, []byte(self.KNAdmin))
+	if _, err := mac.Write([]byte(msg)); err != nil {
+		log.Printf("Encode message error: %s", err.Error())
 	}
-	readonlySigned := self.knReadonlySign(message)
-	if signed == readonlySigned {
-		result = append(result, ReadOnlyPermission)
+	return ethereum.Bytes2Hex(mac.Sum(nil))
+}
+
+func (self KNAuthentication) GetPermission(keyID, signed, message string) (string, []Permission) {
+	if keyID != "" {
+		return "", nil
 	}
-	configureSigned := self.knConfigurationSign(message)
-	if signed == configureSigned {
-		result = append(result, ConfigurePermission)
+	var (
+		id     string
+		result = []Permission{}
+	)
+	match := func(name string, sign func(string) string, perm Permission) {
+		if signed == sign(message) {
+			if id == "" {
+				id = name
+			}
+			result = append(result, perm)
+		}
 	}
-	confirmConfSigned := self.knConfirmConfSign(message)
-	if signed == confirmConfSigned {
-		result = append(result, ConfirmConfPermission)
+	match("kn_secret", self.KNSign, RebalancePermission)
+	match("kn_readonly", self.knReadonlySign, ReadOnlyPermission)
+	match("kn_configuration", self.knConfigurationSign, ConfigurePermission)
+	match("kn_confirm_configuration", self.knConfirmConfSign, ConfirmConfPermission)
+	if self.KNAdmin != "" {
+		match("kn_admin", self.knAdminSign, AdminPermission)
 	}
-	return result
+	return id, result
 }
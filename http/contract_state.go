@@ -0,0 +1,21 @@
+package http
+
+import (
+	"github.com/KyberNetwork/reserve-data/http/httputil"
+	"github.com/gin-gonic/gin"
+)
+
+// GetContractAdminState returns the admin state of the reserve and pricing
+// contracts, along with the mismatches against settings.
+func (self *HTTPServer) GetContractAdminState(c *gin.Context) {
+	_, ok := self.Authenticated(c, []string{}, []Permission{ReadOnlyPermission, ConfigurePermission, ConfirmConfPermission, RebalancePermission})
+	if !ok {
+		return
+	}
+	data, err := self.core.GetContractAdminState()
+	if err != nil {
+		httputil.ResponseFailure(c, httputil.WithError(err))
+		return
+	}
+	httputil.ResponseSuccess(c, httputil.WithData(data))
+}
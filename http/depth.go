@@ -0,0 +1,56 @@
+package http
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/KyberNetwork/reserve-data/common"
+	"github.com/KyberNetwork/reserve-data/http/httputil"
+	"github.com/gin-gonic/gin"
+)
+
+// PairDepth returns the VWAP of executing a quantity of the base token of a
+// pair on each exchange and across exchanges combined, the depth within
+// bands of bps of mid and the best exchanges, from the order books at the
+// optional timestamp.
+func (self *HTTPServer) PairDepth(c *gin.Context) {
+	pair, err := self.setting.NewTokenPairFromID(c.Param("base"), c.Param("quote"))
+	if err != nil {
+		httputil.ResponseFailure(c, httputil.WithReason("Token pair is not supported"))
+		return
+	}
+	quantity, err := strconv.ParseFloat(c.Query("quantity"), 64)
+	if err != nil || quantity <= 0 {
+		httputil.ResponseFailure(c, httputil.WithReason(fmt.Sprintf("invalid quantity: %s", c.Query("quantity"))))
+		return
+	}
+	bands, err := depthBands(c.Query("bands"))
+	if err != nil {
+		httputil.ResponseFailure(c, httputil.WithError(err))
+		return
+	}
+	data, err := self.app.GetPairDepth(pair.PairID(), quantity, bands, getTimePoint(c, true))
+	if err != nil {
+		httputil.ResponseFailure(c, httputil.WithError(err))
+		return
+	}
+	httputil.ResponseSuccess(c, httputil.WithData(data))
+}
+
+// depthBands returns the comma separated bands in bps, or the default ones
+// if it is empty.
+func depthBands(param string) ([]uint64, error) {
+	if param == "" {
+		return common.DefaultDepthBands, nil
+	}
+	var result []uint64
+	for _, band := range strings.Split(param, ",") {
+		bps, err := strconv.ParseUint(strings.TrimSpace(band), 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid band %s: %s", band, err)
+		}
+		result = append(result, bps)
+	}
+	return result, nil
+}
@@ -0,0 +1,27 @@
+package http
+
+import (
+	"net/url"
+	"strconv"
+
+	"github.com/KyberNetwork/reserve-data/common"
+	"github.com/KyberNetwork/reserve-data/http/httputil"
+	"github.com/gin-gonic/gin"
+)
+
+// dryRunParam is the optional param of core actions, when it is true the
+// action only returns what it would send.
+const dryRunParam = "dry_run"
+
+func isDryRun(params url.Values) bool {
+	dryRun, _ := strconv.ParseBool(params.Get(dryRunParam))
+	return dryRun
+}
+
+func responseDryRun(c *gin.Context, result common.DryRunResult, err error) {
+	if err != nil {
+		httputil.ResponseFailure(c, httputil.WithError(err))
+		return
+	}
+	httputil.ResponseSuccess(c, httputil.WithField("dry_run", result))
+}
@@ -0,0 +1,108 @@
+package http
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+
+	"github.com/KyberNetwork/reserve-data/common"
+	"github.com/KyberNetwork/reserve-data/http/httputil"
+	"github.com/gin-gonic/gin"
+)
+
+// emergencyTokens returns the internal tokens of ids separated by "-", no
+// token if ids is empty.
+func (self *HTTPServer) emergencyTokens(ids string) ([]common.Token, error) {
+	tokens := []common.Token{}
+	if ids == "" {
+		return tokens, nil
+	}
+	for _, id := range strings.Split(ids, "-") {
+		token, err := self.setting.GetInternalTokenByID(id)
+		if err != nil {
+			return nil, fmt.Errorf("token %s is not an internal token (%s)", id, err)
+		}
+		if token.IsETH() {
+			return nil, fmt.Errorf("trade of ETH can't be disabled or enabled on pricing")
+		}
+		tokens = append(tokens, token)
+	}
+	return tokens, nil
+}
+
+// DisableTrade disables trade on the reserve in emergency, and optionally of
+// tokens on the pricing contract, by the alerter operator.
+// input: tokens (optional) are token ids separated by "-", gas_price
+// (optional) is in gwei, an emergency gas price is used by default.
+func (self *HTTPServer) DisableTrade(c *gin.Context) {
+	postForm, ok := self.Authenticated(c, []string{}, []Permission{RebalancePermission, ConfigurePermission, ConfirmConfPermission})
+	if !ok {
+		return
+	}
+	tokens, err := self.emergencyTokens(postForm.Get("tokens"))
+	if err != nil {
+		httputil.ResponseFailure(c, httputil.WithError(err))
+		return
+	}
+	var gasPrice *big.Int
+	if gasPriceStr := postForm.Get("gas_price"); gasPriceStr != "" {
+		gwei, pErr := strconv.ParseFloat(gasPriceStr, 64)
+		if pErr != nil || gwei <= 0 {
+			httputil.ResponseFailure(c, httputil.WithReason(fmt.Sprintf("gas price %s is not a positive number", gasPriceStr)))
+			return
+		}
+		gasPrice = common.GweiToWei(gwei)
+	}
+	txs, err := self.core.DisableTrade(tokens, gasPrice, common.GetTimepoint())
+	if err != nil {
+		httputil.ResponseFailure(c, httputil.WithError(err), httputil.WithData(txs))
+		return
+	}
+	httputil.ResponseSuccess(c, httputil.WithData(txs))
+}
+
+// EnableTrade returns the unsigned transactions enabling trade on the reserve
+// and optionally of tokens on the pricing contract, to be sent by the admin
+// wallet.
+// input: tokens (optional) are token ids separated by "-"
+func (self *HTTPServer) EnableTrade(c *gin.Context) {
+	postForm, ok := self.Authenticated(c, []string{}, []Permission{ConfirmConfPermission})
+	if !ok {
+		return
+	}
+	tokens, err := self.emergencyTokens(postForm.Get("tokens"))
+	if err != nil {
+		httputil.ResponseFailure(c, httputil.WithError(err))
+		return
+	}
+	txs, err := self.core.EnableTrade(tokens)
+	if err != nil {
+		httputil.ResponseFailure(c, httputil.WithError(err))
+		return
+	}
+	httputil.ResponseSuccess(c, httputil.WithData(txs))
+}
+
+// SubmitEnableTradeTxs submits the transactions enabling trade sent by the
+// admin wallet, so they are tracked until mined.
+// input txs follow json: the transactions returned by enable trade, with
+// their hashes set.
+func (self *HTTPServer) SubmitEnableTradeTxs(c *gin.Context) {
+	postForm, ok := self.Authenticated(c, []string{"txs"}, []Permission{ConfirmConfPermission})
+	if !ok {
+		return
+	}
+	var txs []common.EmergencyTx
+	if err := json.Unmarshal([]byte(postForm.Get("txs")), &txs); err != nil {
+		httputil.ResponseFailure(c, httputil.WithError(err))
+		return
+	}
+	result, err := self.core.SubmitEnableTradeTxs(txs, common.GetTimepoint())
+	if err != nil {
+		httputil.ResponseFailure(c, httputil.WithError(err))
+		return
+	}
+	httputil.ResponseSuccess(c, httputil.WithData(result))
+}
@@ -0,0 +1,91 @@
+package http
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/KyberNetwork/reserve-data/common"
+	"github.com/KyberNetwork/reserve-data/data/stream"
+	"github.com/KyberNetwork/reserve-data/http/httputil"
+	"github.com/gin-contrib/sse"
+	"github.com/gin-gonic/gin"
+)
+
+// streamHeartbeatInterval is the interval of heartbeat events sent to keep
+// idle connections alive.
+const streamHeartbeatInterval = 15 * time.Second
+
+// EventStream is the interface of the publisher of data stored by fetcher.
+type EventStream interface {
+	Subscribe(topics []string, fromVersions map[string]common.Version) (*stream.Subscription, error)
+	Unsubscribe(sub *stream.Subscription)
+}
+
+// SetEventStream enables the /stream endpoint, pushing events of the given stream.
+func (self *HTTPServer) SetEventStream(eventStream EventStream) {
+	self.eventStream = eventStream
+}
+
+// StreamEvents pushes new prices, rates and auth data to the client as
+// Server-Sent Events, right after they are stored by fetcher. Event name is
+// the topic and event data is the json encoded stream.Event.
+// Params:
+//   - topics: comma separated list of subscribed topics: prices, rates, authdata
+//   - <topic>-version (optional): last version of the topic the client has,
+//     newer versions kept by server are sent before live events. The request
+//     fails if some of the newer versions are no longer kept, in that case the
+//     client should get the latest data with REST api and subscribe without version.
+func (self *HTTPServer) StreamEvents(c *gin.Context) {
+	params, ok := self.Authenticated(c, []string{"topics"}, []Permission{ReadOnlyPermission, RebalancePermission, ConfigurePermission, ConfirmConfPermission})
+	if !ok {
+		return
+	}
+	topics := strings.Split(params.Get("topics"), ",")
+	fromVersions := map[string]common.Version{}
+	for _, topic := range topics {
+		versionParam := params.Get(topic + "-version")
+		if versionParam == "" {
+			continue
+		}
+		version, err := strconv.ParseUint(versionParam, 10, 64)
+		if err != nil {
+			httputil.ResponseFailure(c, httputil.WithReason(fmt.Sprintf("Invalid %s-version: %s", topic, versionParam)))
+			return
+		}
+		fromVersions[topic] = common.Version(version)
+	}
+	sub, err := self.eventStream.Subscribe(topics, fromVersions)
+	if err != nil {
+		httputil.ResponseFailure(c, httputil.WithError(err))
+		return
+	}
+	defer self.eventStream.Unsubscribe(sub)
+	log.Printf("Event stream: client subscribed to %v, resuming from %v", topics, fromVersions)
+
+	heartbeat := time.NewTicker(streamHeartbeatInterval)
+	defer heartbeat.Stop()
+	c.Stream(func(w io.Writer) bool {
+		select {
+		case <-c.Request.Context().Done():
+			return false
+		case event, ok := <-sub.Events():
+			if !ok {
+				// subscriber is dropped by the broker, client has to resume
+				return false
+			}
+			c.Render(-1, sse.Event{
+				Id:    fmt.Sprintf("%s-%d", event.Topic, event.Version),
+				Event: event.Topic,
+				Data:  event,
+			})
+			return true
+		case <-heartbeat.C:
+			c.SSEvent("heartbeat", common.GetTimepoint())
+			return true
+		}
+	})
+}
@@ -0,0 +1,99 @@
+package http
+
+import (
+	"bufio"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/KyberNetwork/reserve-data/common"
+	"github.com/KyberNetwork/reserve-data/core"
+	"github.com/KyberNetwork/reserve-data/data"
+	"github.com/KyberNetwork/reserve-data/data/stream"
+	"github.com/gin-gonic/gin"
+)
+
+// readEvent reads the next Server-Sent Event, skipping heartbeats.
+func readEvent(t *testing.T, reader *bufio.Reader) (string, stream.Event) {
+	t.Helper()
+	var (
+		name  string
+		event stream.Event
+	)
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			t.Fatal(err)
+		}
+		line = strings.TrimSpace(line)
+		switch {
+		case strings.HasPrefix(line, "event:"):
+			name = strings.TrimSpace(strings.TrimPrefix(line, "event:"))
+		case strings.HasPrefix(line, "data:") && name != "heartbeat":
+			if err = json.Unmarshal([]byte(strings.TrimPrefix(line, "data:")), &event); err != nil {
+				t.Fatal(err)
+			}
+			return name, event
+		}
+	}
+}
+
+func TestStreamEvents(t *testing.T) {
+	broker := stream.NewBroker(stream.DefaultHistorySize)
+	s := HTTPServer{
+		app:         data.NewReserveData(nil, nil, nil, nil, nil, nil, nil, nil),
+		core:        core.NewReserveCore(nil, nil, nil),
+		authEnabled: false,
+		r:           gin.Default(),
+	}
+	s.SetEventStream(broker)
+	s.register()
+	server := httptest.NewServer(s.r)
+	defer server.Close()
+
+	broker.PublishPrice(common.AllPriceEntry{Block: 10}, 1)
+	broker.PublishPrice(common.AllPriceEntry{Block: 11}, 2)
+
+	resp, err := http.Get(server.URL + "/stream?topics=prices,rates&prices-version=1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	reader := bufio.NewReader(resp.Body)
+
+	// missed version is sent first
+	name, event := readEvent(t, reader)
+	if name != stream.PriceTopic || event.Version != 2 {
+		t.Errorf("expected missed price version 2, got %s version %d", name, event.Version)
+	}
+
+	broker.PublishRate(common.AllRateEntry{BlockNumber: 12}, 3)
+	broker.PublishAuthSnapshot(&common.AuthDataSnapshot{}, 4)
+	broker.PublishPrice(common.AllPriceEntry{Block: 12}, 5)
+	name, event = readEvent(t, reader)
+	if name != stream.RateTopic || event.Version != 3 {
+		t.Errorf("expected rate version 3, got %s version %d", name, event.Version)
+	}
+	// auth data is not subscribed
+	name, event = readEvent(t, reader)
+	if name != stream.PriceTopic || event.Version != 5 {
+		t.Errorf("expected price version 5, got %s version %d", name, event.Version)
+	}
+
+	resp, err = http.Get(server.URL + "/stream?topics=prices&prices-version=abc")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	var result struct {
+		Success bool `json:"success"`
+	}
+	if err = json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		t.Fatal(err)
+	}
+	if result.Success {
+		t.Error("expected failure with invalid version")
+	}
+}
@@ -0,0 +1,136 @@
+package http
+
+import (
+	"fmt"
+	"log"
+	"strconv"
+
+	"github.com/KyberNetwork/reserve-data/common"
+	"github.com/KyberNetwork/reserve-data/http/httputil"
+	"github.com/gin-gonic/gin"
+)
+
+// StartExecution starts executing a large order on an exchange in slices,
+// by TWAP over duration minutes or by iceberg of visible amount.
+func (self *HTTPServer) StartExecution(c *gin.Context) {
+	postForm, ok := self.Authenticated(c, []string{"base", "quote", "amount", "rate", "type", "strategy"}, []Permission{RebalancePermission})
+	if !ok {
+		return
+	}
+
+	exchange, err := common.GetExchange(c.Param("exchangeid"))
+	if err != nil {
+		httputil.ResponseFailure(c, httputil.WithError(err))
+		return
+	}
+	base, err := self.setting.GetInternalTokenByID(postForm.Get("base"))
+	if err != nil {
+		httputil.ResponseFailure(c, httputil.WithError(err))
+		return
+	}
+	quote, err := self.setting.GetInternalTokenByID(postForm.Get("quote"))
+	if err != nil {
+		httputil.ResponseFailure(c, httputil.WithError(err))
+		return
+	}
+	amount, err := strconv.ParseFloat(postForm.Get("amount"), 64)
+	if err != nil {
+		httputil.ResponseFailure(c, httputil.WithError(err))
+		return
+	}
+	rate, err := strconv.ParseFloat(postForm.Get("rate"), 64)
+	if err != nil {
+		httputil.ResponseFailure(c, httputil.WithError(err))
+		return
+	}
+	typeParam := postForm.Get("type")
+	if typeParam != "sell" && typeParam != "buy" {
+		httputil.ResponseFailure(c, httputil.WithReason(fmt.Sprintf("Trade type of %s is not supported.", typeParam)))
+		return
+	}
+	strategy, err := executionStrategy(postForm.Get)
+	if err != nil {
+		httputil.ResponseFailure(c, httputil.WithError(err))
+		return
+	}
+	log.Printf("Start %s execution: %s %f %s/%s at %f on %s", strategy.Name, typeParam, amount, base.ID, quote.ID, rate, exchange.ID())
+	execution, err := self.core.StartExecution(exchange, typeParam, base, quote, rate, amount, strategy, getTimePoint(c, false))
+	if err != nil {
+		httputil.ResponseFailure(c, httputil.WithError(err))
+		return
+	}
+	httputil.ResponseSuccess(c, httputil.WithData(execution))
+}
+
+// executionStrategy returns the strategy of the form params. Durations are
+// given in minutes and reprice intervals in seconds.
+func executionStrategy(get func(string) string) (common.ExecutionStrategy, error) {
+	strategy := common.ExecutionStrategy{Name: get("strategy")}
+	switch strategy.Name {
+	case common.ExecutionTWAP:
+		duration, err := strconv.ParseUint(get("duration"), 10, 64)
+		if err != nil {
+			return strategy, fmt.Errorf("invalid duration: %s", err)
+		}
+		strategy.Duration = duration * 60 * 1000
+		// a slice per minute by default
+		strategy.Slices = int(duration)
+		if slices := get("slices"); slices != "" {
+			if strategy.Slices, err = strconv.Atoi(slices); err != nil {
+				return strategy, fmt.Errorf("invalid slices: %s", err)
+			}
+		}
+	case common.ExecutionIceberg:
+		visible, err := strconv.ParseFloat(get("visible_amount"), 64)
+		if err != nil {
+			return strategy, fmt.Errorf("invalid visible_amount: %s", err)
+		}
+		strategy.VisibleAmount = visible
+		if interval := get("reprice_interval"); interval != "" {
+			seconds, err := strconv.ParseUint(interval, 10, 64)
+			if err != nil {
+				return strategy, fmt.Errorf("invalid reprice_interval: %s", err)
+			}
+			strategy.RepriceInterval = seconds * 1000
+		}
+	}
+	return strategy, nil
+}
+
+// CancelExecution stops a running execution and cancels its open order.
+func (self *HTTPServer) CancelExecution(c *gin.Context) {
+	_, ok := self.Authenticated(c, []string{}, []Permission{RebalancePermission})
+	if !ok {
+		return
+	}
+	id, err := common.StringToActivityID(c.Param("id"))
+	if err != nil {
+		httputil.ResponseFailure(c, httputil.WithError(err))
+		return
+	}
+	execution, err := self.core.CancelExecution(id)
+	if err != nil {
+		httputil.ResponseFailure(c, httputil.WithError(err))
+		return
+	}
+	httputil.ResponseSuccess(c, httputil.WithData(execution))
+}
+
+// GetExecution returns an execution with its slices.
+func (self *HTTPServer) GetExecution(c *gin.Context) {
+	_, ok := self.Authenticated(c, []string{}, []Permission{ReadOnlyPermission, RebalancePermission})
+	if !ok {
+		return
+	}
+	id, err := common.StringToActivityID(c.Param("id"))
+	if err != nil {
+		httputil.ResponseFailure(c, httputil.WithError(err))
+		return
+	}
+	execution, err := self.core.GetExecution(id)
+	if err != nil {
+		httputil.ResponseFailure(c, httputil.WithError(err))
+		return
+	}
+	httputil.ResponseSuccess(c, httputil.WithData(execution))
+}
@@ -27,6 +27,12 @@ type Setting interface {
 	ApplyTokenWithExchangeSetting([]common.Token, map[settings.ExchangeName]*common.ExchangeSetting, uint64) error
 	GetPendingTokenUpdates() (map[string]common.TokenUpdate, error)
 	RemovePendingTokenUpdates() error
+	GetPendingFeeDiffs() (map[settings.ExchangeName]common.ExchangeFeeDiff, error)
+	ApplyFeeDiff(ex settings.ExchangeName, diff common.ExchangeFeeDiff, timestamp uint64) error
+	RemovePendingFeeDiff(ex settings.ExchangeName) error
+	UpdatePendingStepFunctions(common.PendingStepFunctionUpdate) error
+	GetPendingStepFunctions() (common.PendingStepFunctionUpdate, error)
+	RemovePendingStepFunctions() error
 	GetAllAddresses() (map[string]interface{}, error)
 	GetTokenVersion() (uint64, error)
 	GetExchangeVersion() (uint64, error)
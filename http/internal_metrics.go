@@ -0,0 +1,17 @@
+package http
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// RunMetricsServer serves the operational metrics of fetchers, exchanges and
+// stat in Prometheus text format at addr/internal/metrics. The metrics are
+// not signed, so they are served on their own listener instead of the api
+// one, addr must only be reachable from the internal network.
+func RunMetricsServer(addr string) error {
+	r := gin.New()
+	r.Use(gin.Recovery())
+	r.GET("/internal/metrics", gin.WrapH(promhttp.Handler()))
+	return r.Run(addr)
+}
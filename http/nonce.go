@@ -0,0 +1,84 @@
+package http
+
+import (
+	"strconv"
+	"sync"
+
+	"github.com/KyberNetwork/reserve-data/common"
+)
+
+// nonceValidity is the max difference in millisecond between a nonce and
+// server time for the nonce to be in time.
+const nonceValidity int64 = 30000
+
+// NonceStorage is the storage of used nonces of signed requests.
+type NonceStorage interface {
+	// UseNonce marks the nonce of keyID as used, it returns false if it was
+	// used already. Used nonces below pruneBefore can be removed.
+	UseNonce(keyID string, nonce, pruneBefore uint64) (bool, error)
+}
+
+// memoryNonceStorage keeps used nonces in memory, it is the default storage
+// until a persistent one is set.
+type memoryNonceStorage struct {
+	mu   sync.Mutex
+	used map[string]map[uint64]struct{}
+}
+
+func newMemoryNonceStorage() *memoryNonceStorage {
+	return &memoryNonceStorage{used: map[string]map[uint64]struct{}{}}
+}
+
+func (self *memoryNonceStorage) UseNonce(keyID string, nonce, pruneBefore uint64) (bool, error) {
+	self.mu.Lock()
+	defer self.mu.Unlock()
+	for id, nonces := range self.used {
+		for n := range nonces {
+			if n < pruneBefore {
+				delete(nonces, n)
+			}
+		}
+		if len(nonces) == 0 {
+			delete(self.used, id)
+		}
+	}
+	nonces, ok := self.used[keyID]
+	if !ok {
+		nonces = map[uint64]struct{}{}
+		self.used[keyID] = nonces
+	}
+	if _, used := nonces[nonce]; used {
+		return false, nil
+	}
+	nonces[nonce] = struct{}{}
+	return true, nil
+}
+
+// SetNonceStorage replaces the storage of used nonces, a persistent storage
+// keeps rejecting replayed requests across restarts.
+func (self *HTTPServer) SetNonceStorage(storage NonceStorage) {
+	self.nonces = storage
+}
+
+// isMutating returns true if the request method can change the server state.
+func isMutating(method string) bool {
+	return method != "GET" && method != "HEAD" && method != "OPTIONS"
+}
+
+// useNonce marks the nonce of a mutating request signed by keyID as used, it
+// returns false if the request is a replay of a previous one.
+func (self *HTTPServer) useNonce(method, keyID, nonce string) (bool, error) {
+	if self.nonces == nil || !isMutating(method) {
+		return true, nil
+	}
+	nonceInt, err := strconv.ParseUint(nonce, 10, 64)
+	if err != nil {
+		return false, err
+	}
+	// nonces older than the validity window are rejected by IsIntime already
+	var pruneBefore uint64
+	if now := common.GetTimepoint(); now > uint64(nonceValidity) {
+		pruneBefore = now - uint64(nonceValidity)
+	}
+	return self.nonces.UseNonce(keyID, nonceInt, pruneBefore)
+}
@@ -0,0 +1,146 @@
+package http
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/KyberNetwork/reserve-data"
+	"github.com/KyberNetwork/reserve-data/common"
+	"github.com/KyberNetwork/reserve-data/core"
+	"github.com/KyberNetwork/reserve-data/data"
+	settingsstorage "github.com/KyberNetwork/reserve-data/settings/storage"
+	"github.com/gin-gonic/gin"
+)
+
+type testReserveStats struct {
+	reserve.ReserveStats
+}
+
+// sendSigned sends the request signed by the shared kn_secret key, and
+// returns its reason of failure.
+func sendSigned(t *testing.T, handler http.Handler, method, path string, params url.Values) string {
+	t.Helper()
+	message := params.Encode()
+	req, err := http.NewRequest(method, path, strings.NewReader(message))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("signed", KNAuthentication{KNSecret: "secret"}.KNSign(message))
+	resp := httptest.NewRecorder()
+	handler.ServeHTTP(resp, req)
+	var result struct {
+		Reason string `json:"reason"`
+	}
+	// handlers of nil storages panic and are recovered without json response
+	_ = json.Unmarshal(resp.Body.Bytes(), &result)
+	return result.Reason
+}
+
+func TestNonceReplay(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "test_nonce_replay")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if rErr := os.RemoveAll(tmpDir); rErr != nil {
+			t.Error(rErr)
+		}
+	}()
+	st, err := settingsstorage.NewBoltSettingStorage(filepath.Join(tmpDir, "setting.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	newServer := func() HTTPServer {
+		s := HTTPServer{
+			app:         data.NewReserveData(nil, nil, nil, nil, nil, nil, nil, nil),
+			core:        core.NewReserveCore(nil, nil, nil),
+			stat:        testReserveStats{},
+			authEnabled: true,
+			auth:        KNAuthentication{KNSecret: "secret"},
+			r:           gin.New(),
+		}
+		s.r.Use(gin.Recovery())
+		s.SetNonceStorage(st)
+		s.register()
+		return s
+	}
+	s := newServer()
+
+	// nonces are distinct as they are shared by all endpoints
+	nonce := common.GetTimepoint()
+	var mutating int
+	for _, route := range s.r.Routes() {
+		if !isMutating(route.Method) {
+			continue
+		}
+		mutating++
+		segments := strings.Split(route.Path, "/")
+		for i := range segments {
+			if strings.HasPrefix(segments[i], ":") {
+				segments[i] = "binance"
+			}
+		}
+		path := strings.Join(segments, "/")
+		nonce++
+		params := url.Values{"nonce": {strconv.FormatUint(nonce, 10)}}
+		if reason := sendSigned(t, s.r, route.Method, path, params); reason == "Your nonce is used already" {
+			t.Fatalf("%s %s: expected first request is not a replay", route.Method, path)
+		}
+		if reason := sendSigned(t, s.r, route.Method, path, params); reason != "Your nonce is used already" {
+			t.Errorf("%s %s: expected replayed request is rejected, got reason %q", route.Method, path, reason)
+		}
+	}
+	if mutating == 0 {
+		t.Fatal("expected mutating endpoints are registered")
+	}
+
+	// used nonces are kept across restarts
+	params := url.Values{"nonce": {strconv.FormatUint(nonce+1, 10)}}
+	sendSigned(t, s.r, http.MethodPost, "/holdrebalance", params)
+	restarted := newServer()
+	if reason := sendSigned(t, restarted.r, http.MethodPost, "/holdrebalance", params); reason != "Your nonce is used already" {
+		t.Errorf("expected replayed request is rejected after restart, got reason %q", reason)
+	}
+
+	// read only requests are not affected
+	readParams := url.Values{"nonce": {strconv.FormatUint(nonce+2, 10)}}
+	sendSigned(t, s.r, http.MethodGet, "/rebalancestatus?"+readParams.Encode(), url.Values{})
+	if reason := sendSigned(t, s.r, http.MethodGet, "/rebalancestatus?"+readParams.Encode(), url.Values{}); reason == "Your nonce is used already" {
+		t.Error("expected read only requests can reuse nonce")
+	}
+}
+
+func TestMemoryNonceStorage(t *testing.T) {
+	storage := newMemoryNonceStorage()
+	for _, tc := range []struct {
+		keyID       string
+		nonce       uint64
+		pruneBefore uint64
+		fresh       bool
+	}{
+		{"kn_secret", 100, 0, true},
+		{"kn_secret", 100, 0, false},
+		{"ops-bot", 100, 0, true},
+		{"kn_secret", 101, 0, true},
+		// pruned nonces are rejected by IsIntime
+		{"kn_secret", 100, 101, true},
+		{"kn_secret", 101, 101, false},
+	} {
+		fresh, err := storage.UseNonce(tc.keyID, tc.nonce, tc.pruneBefore)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if fresh != tc.fresh {
+			t.Errorf("key %s, nonce %d: expected fresh %t, got %t", tc.keyID, tc.nonce, tc.fresh, fresh)
+		}
+	}
+}
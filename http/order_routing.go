@@ -0,0 +1,107 @@
+package http
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/KyberNetwork/reserve-data/common"
+	"github.com/KyberNetwork/reserve-data/http/httputil"
+	"github.com/gin-gonic/gin"
+)
+
+// RouteTrade splits a trade across exchanges, all running exchanges unless
+// a comma separated list of exchanges is given.
+func (self *HTTPServer) RouteTrade(c *gin.Context) {
+	postForm, ok := self.Authenticated(c, []string{"base", "quote", "amount", "type", "max_slippage"}, []Permission{RebalancePermission})
+	if !ok {
+		return
+	}
+
+	typeParam := postForm.Get("type")
+	base, err := self.setting.GetInternalTokenByID(postForm.Get("base"))
+	if err != nil {
+		httputil.ResponseFailure(c, httputil.WithError(err))
+		return
+	}
+	quote, err := self.setting.GetInternalTokenByID(postForm.Get("quote"))
+	if err != nil {
+		httputil.ResponseFailure(c, httputil.WithError(err))
+		return
+	}
+	amount, err := strconv.ParseFloat(postForm.Get("amount"), 64)
+	if err != nil {
+		httputil.ResponseFailure(c, httputil.WithError(err))
+		return
+	}
+	maxSlippage, err := strconv.ParseFloat(postForm.Get("max_slippage"), 64)
+	if err != nil {
+		httputil.ResponseFailure(c, httputil.WithError(err))
+		return
+	}
+	if typeParam != "sell" && typeParam != "buy" {
+		httputil.ResponseFailure(c, httputil.WithReason(fmt.Sprintf("Trade type of %s is not supported.", typeParam)))
+		return
+	}
+	exchanges, err := routeExchanges(postForm.Get("exchanges"))
+	if err != nil {
+		httputil.ResponseFailure(c, httputil.WithError(err))
+		return
+	}
+	log.Printf("Route %s %f %s/%s across %d exchanges, max slippage: %f", typeParam, amount, base.ID, quote.ID, len(exchanges), maxSlippage)
+	if isDryRun(postForm) {
+		result, err := self.core.DryRunRouteTrade(typeParam, base, quote, amount, maxSlippage, exchanges)
+		responseDryRun(c, result, err)
+		return
+	}
+	result, err := self.core.RouteTrade(typeParam, base, quote, amount, maxSlippage, exchanges, getTimePoint(c, false))
+	if err != nil {
+		httputil.ResponseFailure(c, httputil.WithError(err), httputil.WithData(result))
+		return
+	}
+	httputil.ResponseSuccess(c, httputil.WithData(result))
+}
+
+// routeExchanges returns the exchanges of the comma separated list, all
+// running exchanges if it is empty.
+func routeExchanges(param string) ([]common.Exchange, error) {
+	var exchanges []common.Exchange
+	if param == "" {
+		for _, exchange := range common.SupportedExchanges {
+			exchanges = append(exchanges, exchange)
+		}
+	} else {
+		for _, id := range strings.Split(param, ",") {
+			exchange, err := common.GetExchange(strings.TrimSpace(id))
+			if err != nil {
+				return nil, err
+			}
+			exchanges = append(exchanges, exchange)
+		}
+	}
+	sort.Slice(exchanges, func(i, j int) bool {
+		return exchanges[i].ID() < exchanges[j].ID()
+	})
+	return exchanges, nil
+}
+
+// GetRoutedTrade returns a routed trade with the latest status of its orders.
+func (self *HTTPServer) GetRoutedTrade(c *gin.Context) {
+	_, ok := self.Authenticated(c, []string{}, []Permission{ReadOnlyPermission, RebalancePermission})
+	if !ok {
+		return
+	}
+	id, err := common.StringToActivityID(c.Param("id"))
+	if err != nil {
+		httputil.ResponseFailure(c, httputil.WithError(err))
+		return
+	}
+	result, err := self.core.GetRoutedTrade(id)
+	if err != nil {
+		httputil.ResponseFailure(c, httputil.WithError(err))
+		return
+	}
+	httputil.ResponseSuccess(c, httputil.WithData(result))
+}
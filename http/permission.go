@@ -1,5 +1,7 @@
 package http
 
+import "fmt"
+
 type Permission int
 
 const (
@@ -7,4 +9,32 @@ const (
 	RebalancePermission                     // can do everything except configure setting
 	ConfigurePermission                     // can read data and configure setting, cannot set rates, deposit, withdraw, trade, cancel activities
 	ConfirmConfPermission                   // can read data and confirm configuration proposal
+	AdminPermission                         // can manage API keys and read audit log
 )
+
+// String returns the name of the permission.
+func (self Permission) String() string {
+	switch self {
+	case ReadOnlyPermission:
+		return "read_only"
+	case RebalancePermission:
+		return "rebalance"
+	case ConfigurePermission:
+		return "configuration"
+	case ConfirmConfPermission:
+		return "confirm_configuration"
+	case AdminPermission:
+		return "admin"
+	}
+	return "unknown"
+}
+
+// ParsePermission returns the permission of given name.
+func ParsePermission(name string) (Permission, error) {
+	for _, perm := range []Permission{ReadOnlyPermission, RebalancePermission, ConfigurePermission, ConfirmConfPermission, AdminPermission} {
+		if perm.String() == name {
+			return perm, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown permission %s", name)
+}
@@ -47,6 +47,10 @@ type HTTPServer struct {
 	r           *gin.Engine
 	blockchain  Blockchain
 	setting     Setting
+	eventStream EventStream
+	apiKeys     APIKeyStorage
+	auditLog    AuditLogStorage
+	nonces      NonceStorage
 }
 
 func getTimePoint(c *gin.Context, useDefault bool) uint64 {
@@ -81,7 +85,7 @@ func IsIntime(nonce string) bool {
 		return false
 	}
 	difference := nonceInt - int64(serverTime)
-	if difference < -30000 || difference > 30000 {
+	if difference < -nonceValidity || difference > nonceValidity {
 		log.Printf("IsIntime returns false, nonce: %d, serverTime: %d, difference: %d", nonceInt, int64(serverTime), difference)
 		return false
 	}
@@ -102,7 +106,8 @@ func eligible(ups, allowedPerms []Permission) bool {
 // Authenticated signed message (message = url encoded both query params and post params, keys are sorted) in "signed" header
 // using HMAC512
 // params must contain "nonce" which is the unixtime in millisecond. The nonce will be invalid
-// if it differs from server time more than 10s
+// if it differs from server time more than 30s, or if it was used already by the same key
+// in a mutating request
 func (self *HTTPServer) Authenticated(c *gin.Context, requiredParams []string, perms []Permission) (url.Values, bool) {
 	err := c.Request.ParseForm()
 	if err != nil {
@@ -121,26 +126,37 @@ func (self *HTTPServer) Authenticated(c *gin.Context, requiredParams []string, p
 		return c.Request.Form, false
 	}
 
+	signed := c.GetHeader("signed")
+	message := c.Request.Form.Encode()
+	keyID, userPerms := self.auth.GetPermission(c.GetHeader(apiKeyHeader), signed, message)
+	if len(userPerms) == 0 {
+		httputil.ResponseFailure(c, httputil.WithReason("Invalid signed token"))
+		return params, false
+	}
+	// the nonce of a correctly signed request is used even if it is rejected
+	// later, so the request can't be replayed
+	fresh, err := self.useNonce(c.Request.Method, keyID, params.Get("nonce"))
+	if err != nil {
+		httputil.ResponseFailure(c, httputil.WithError(err))
+		return params, false
+	}
+	if !fresh {
+		httputil.ResponseFailure(c, httputil.WithReason("Your nonce is used already"))
+		return params, false
+	}
+	if !eligible(userPerms, perms) {
+		httputil.ResponseFailure(c, httputil.WithReason("You don't have permission to proceed"))
+		return params, false
+	}
+
 	for _, p := range requiredParams {
 		if params.Get(p) == "" {
 			httputil.ResponseFailure(c, httputil.WithReason(fmt.Sprintf("Required param (%s) is missing. Param name is case sensitive", p)))
 			return c.Request.Form, false
 		}
 	}
-
-	signed := c.GetHeader("signed")
-	message := c.Request.Form.Encode()
-	userPerms := self.auth.GetPermission(signed, message)
-	if eligible(userPerms, perms) {
-		return params, true
-	} else {
-		if len(userPerms) == 0 {
-			httputil.ResponseFailure(c, httputil.WithReason("Invalid signed token"))
-		} else {
-			httputil.ResponseFailure(c, httputil.WithReason("You don't have permission to proceed"))
-		}
-		return params, false
-	}
+	c.Set(keyIDContextKey, keyID)
+	return params, true
 }
 
 func (self *HTTPServer) AllPricesVersion(c *gin.Context) {
@@ -304,6 +320,11 @@ func (self *HTTPServer) SetRate(c *gin.Context) {
 		}
 		bigAfpMid = append(bigAfpMid, r)
 	}
+	if isDryRun(postForm) {
+		result, err := self.core.DryRunSetRates(tokens, bigBuys, bigSells, big.NewInt(intBlock), bigAfpMid)
+		responseDryRun(c, result, err)
+		return
+	}
 	id, err := self.core.SetRates(tokens, bigBuys, bigSells, big.NewInt(intBlock), bigAfpMid, msgs)
 	if err != nil {
 		httputil.ResponseFailure(c, httputil.WithError(err))
@@ -355,6 +376,11 @@ func (self *HTTPServer) Trade(c *gin.Context) {
 		httputil.ResponseFailure(c, httputil.WithReason(fmt.Sprintf("Trade type of %s is not supported.", typeParam)))
 		return
 	}
+	if isDryRun(postForm) {
+		result, err := self.core.DryRunTrade(exchange, typeParam, base, quote, rate, amount)
+		responseDryRun(c, result, err)
+		return
+	}
 	id, done, remaining, finished, err := self.core.Trade(
 		exchange, typeParam, base, quote, rate, amount, getTimePoint(c, false))
 	if err != nil {
@@ -389,6 +415,11 @@ func (self *HTTPServer) CancelOrder(c *gin.Context) {
 		httputil.ResponseFailure(c, httputil.WithError(err))
 		return
 	}
+	if isDryRun(postForm) {
+		result, err := self.core.DryRunCancelOrder(activityID, exchange)
+		responseDryRun(c, result, err)
+		return
+	}
 	err = self.core.CancelOrder(activityID, exchange)
 	if err != nil {
 		httputil.ResponseFailure(c, httputil.WithError(err))
@@ -423,7 +454,59 @@ func (self *HTTPServer) Withdraw(c *gin.Context) {
 		return
 	}
 	log.Printf("Withdraw %s %s from %s\n", amount.Text(10), token.ID, exchange.ID())
-	id, err := self.core.Withdraw(exchange, token, amount, getTimePoint(c, false))
+	if isDryRun(postForm) {
+		result, err := self.core.DryRunWithdraw(exchange, token, amount)
+		responseDryRun(c, result, err)
+		return
+	}
+	id, pending, err := self.core.Withdraw(exchange, token, amount, getTimePoint(c, false), self.requester(c))
+	if err != nil {
+		httputil.ResponseFailure(c, httputil.WithError(err))
+		return
+	}
+	if pending != nil {
+		httputil.ResponseSuccess(c, httputil.WithField("pending_withdrawal", pending))
+		return
+	}
+	httputil.ResponseSuccess(c, httputil.WithField("id", id))
+}
+
+// Transfer moves token between sub-accounts of the exchange, from and to are
+// the names of the sub-accounts, empty for the master account.
+func (self *HTTPServer) Transfer(c *gin.Context) {
+	postForm, ok := self.Authenticated(c, []string{"token", "amount"}, []Permission{RebalancePermission})
+	if !ok {
+		return
+	}
+
+	exchangeParam := c.Param("exchangeid")
+	tokenParam := postForm.Get("token")
+	amountParam := postForm.Get("amount")
+	from := postForm.Get("from")
+	to := postForm.Get("to")
+
+	exchange, err := common.GetExchange(exchangeParam)
+	if err != nil {
+		httputil.ResponseFailure(c, httputil.WithError(err))
+		return
+	}
+	token, err := self.setting.GetInternalTokenByID(tokenParam)
+	if err != nil {
+		httputil.ResponseFailure(c, httputil.WithError(err))
+		return
+	}
+	amount, err := hexutil.DecodeBig(amountParam)
+	if err != nil {
+		httputil.ResponseFailure(c, httputil.WithError(err))
+		return
+	}
+	log.Printf("Transfer %s %s on %s from %q to %q\n", amount.Text(10), token.ID, exchange.ID(), from, to)
+	if isDryRun(postForm) {
+		result, err := self.core.DryRunTransfer(exchange, token, amount, from, to)
+		responseDryRun(c, result, err)
+		return
+	}
+	id, err := self.core.Transfer(exchange, token, amount, from, to, getTimePoint(c, false))
 	if err != nil {
 		httputil.ResponseFailure(c, httputil.WithError(err))
 		return
@@ -457,6 +540,11 @@ func (self *HTTPServer) Deposit(c *gin.Context) {
 		return
 	}
 	log.Printf("Depositing %s %s to %s\n", amount.Text(10), token.ID, exchange.ID())
+	if isDryRun(postForm) {
+		result, err := self.core.DryRunDeposit(exchange, token, amount)
+		responseDryRun(c, result, err)
+		return
+	}
 	id, err := self.core.Deposit(exchange, token, amount, getTimePoint(c, false))
 	if err != nil {
 		httputil.ResponseFailure(c, httputil.WithError(err))
@@ -939,6 +1027,25 @@ func (self *HTTPServer) ValidateTimeInput(c *gin.Context) (uint64, uint64, bool)
 	return fromTime, toTime, true
 }
 
+// ValidateTimeRange validates the fromTime and toTime params like
+// ValidateTimeInput, toTime must not be before fromTime nor more than
+// maxTimespan milliseconds after it.
+func (self *HTTPServer) ValidateTimeRange(c *gin.Context, maxTimespan uint64) (uint64, uint64, bool) {
+	fromTime, toTime, ok := self.ValidateTimeInput(c)
+	if !ok {
+		return 0, 0, false
+	}
+	if toTime < fromTime {
+		httputil.ResponseFailure(c, httputil.WithReason("toTime must not be before fromTime"))
+		return 0, 0, false
+	}
+	if toTime-fromTime > maxTimespan {
+		httputil.ResponseFailure(c, httputil.WithReason(fmt.Sprintf("time range must not be longer than %d milliseconds", maxTimespan)))
+		return 0, 0, false
+	}
+	return fromTime, toTime, true
+}
+
 func (self *HTTPServer) GetTradeSummary(c *gin.Context) {
 	fromTime, toTime, ok := self.ValidateTimeInput(c)
 	if !ok {
@@ -1459,6 +1566,16 @@ func (self *HTTPServer) GetFeeSetRateByDay(c *gin.Context) {
 }
 
 func (self *HTTPServer) register() {
+	if self.auditLog != nil {
+		self.r.Use(self.recordAudit)
+		self.r.GET("/audit-log", self.GetAuditLog)
+	}
+	if self.apiKeys != nil {
+		self.r.GET("/api-keys", self.GetAPIKeys)
+		self.r.POST("/create-api-key", self.CreateAPIKey)
+		self.r.POST("/update-api-key", self.UpdateAPIKey)
+		self.r.POST("/delete-api-key", self.DeleteAPIKey)
+	}
 
 	if self.core != nil && self.app != nil {
 		stt := self.r.Group("/setting")
@@ -1466,9 +1583,14 @@ func (self *HTTPServer) register() {
 		stt.GET("/pending-token-update", self.GetPendingTokenUpdates)
 		stt.POST("/confirm-token-update", self.ConfirmTokenUpdate)
 		stt.POST("/reject-token-update", self.RejectTokenUpdate)
+		stt.GET("/token-listings", self.GetTokenListings)
+		stt.POST("/submit-token-listing-txs", self.SubmitTokenListingTxs)
 		stt.GET("/token-settings", self.TokenSettings)
 		stt.POST("/update-exchange-fee", self.UpdateExchangeFee)
 		stt.POST("/update-exchange-mindeposit", self.UpdateExchangeMinDeposit)
+		stt.GET("/pending-fee-diff", self.GetPendingFeeDiffs)
+		stt.POST("/confirm-fee-diff", self.ConfirmFeeDiff)
+		stt.POST("/reject-fee-diff", self.RejectFeeDiff)
 		stt.POST("/update-deposit-address", self.UpdateDepositAddress)
 		stt.POST("/update-exchange-info", self.UpdateExchangeInfo)
 		stt.GET("/all-settings", self.GetAllSetting)
@@ -1484,6 +1606,7 @@ func (self *HTTPServer) register() {
 		self.r.GET("/prices-version", self.AllPricesVersion)
 		self.r.GET("/prices", self.AllPrices)
 		self.r.GET("/prices/:base/:quote", self.Price)
+		self.r.GET("/depth/:base/:quote", self.PairDepth)
 		self.r.GET("/getrates", self.GetRate)
 		self.r.GET("/get-all-rates", self.GetRates)
 
@@ -1497,7 +1620,19 @@ func (self *HTTPServer) register() {
 		self.r.POST("/cancelorder/:exchangeid", self.CancelOrder)
 		self.r.POST("/deposit/:exchangeid", self.Deposit)
 		self.r.POST("/withdraw/:exchangeid", self.Withdraw)
+		self.r.POST("/transfer/:exchangeid", self.Transfer)
+		self.r.GET("/withdraw-thresholds", self.GetWithdrawThresholds)
+		self.r.POST("/set-withdraw-thresholds", self.SetWithdrawThresholds)
+		self.r.GET("/pending-withdrawals", self.GetPendingWithdrawals)
+		self.r.GET("/withdrawal-approvals", self.GetWithdrawalApprovals)
+		self.r.POST("/confirm-withdrawal/:id", self.ConfirmWithdrawal)
+		self.r.POST("/reject-withdrawal/:id", self.RejectWithdrawal)
 		self.r.POST("/trade/:exchangeid", self.Trade)
+		self.r.POST("/route-trade", self.RouteTrade)
+		self.r.GET("/route-trade/:id", self.GetRoutedTrade)
+		self.r.POST("/execute/:exchangeid", self.StartExecution)
+		self.r.POST("/cancel-execution/:id", self.CancelExecution)
+		self.r.GET("/executions/:id", self.GetExecution)
 		self.r.POST("/setrates", self.SetRate)
 		self.r.GET("/exchangeinfo", self.GetExchangeInfo)
 		self.r.GET("/exchangefees", self.GetFee)
@@ -1545,8 +1680,20 @@ func (self *HTTPServer) register() {
 		self.r.GET("/stable-token-params", self.GetStableTokenParams)
 
 		self.r.GET("/get-step-function-data", self.GetStepFunctionData)
+		self.r.POST("/set-step-functions", self.SetStepFunctions)
+		self.r.GET("/pending-step-functions", self.GetPendingStepFunctions)
+		self.r.POST("/confirm-step-functions", self.ConfirmStepFunctions)
+		self.r.POST("/reject-step-functions", self.RejectStepFunctions)
+		self.r.GET("/contract-admin-state", self.GetContractAdminState)
+		self.r.POST("/disable-trade", self.DisableTrade)
+		self.r.POST("/enable-trade", self.EnableTrade)
+		self.r.POST("/submit-enable-trade-txs", self.SubmitEnableTradeTxs)
 
 		self.r.GET("/gold-feed", self.GetGoldData)
+
+		if self.eventStream != nil {
+			self.r.GET("/stream", self.StreamEvents)
+		}
 	}
 
 	if self.stat != nil {
@@ -1611,12 +1758,12 @@ func NewHTTPServer(
 		false,
 	))
 	corsConfig := cors.DefaultConfig()
-	corsConfig.AddAllowHeaders("signed")
+	corsConfig.AddAllowHeaders("signed", apiKeyHeader)
 	corsConfig.AllowAllOrigins = true
 	corsConfig.MaxAge = 5 * time.Minute
 	r.Use(cors.New(corsConfig))
 
 	return &HTTPServer{
-		app, core, stat, metric, host, enableAuth, authEngine, r, bc, setting,
+		app, core, stat, metric, host, enableAuth, authEngine, r, bc, setting, nil, nil, nil, newMemoryNonceStorage(),
 	}
 }
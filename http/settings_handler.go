@@ -7,6 +7,7 @@ import (
 	"log"
 	"reflect"
 	"strconv"
+	"strings"
 
 	"github.com/KyberNetwork/reserve-data/common"
 	"github.com/KyberNetwork/reserve-data/http/httputil"
@@ -29,7 +30,9 @@ func (self *HTTPServer) updateInternalTokensIndices(tokenUpdates map[string]comm
 	}
 	for _, tokenUpdate := range tokenUpdates {
 		token := tokenUpdate.Token
-		if token.Internal {
+		// tokens being listed on chain have no indices yet, they are
+		// reloaded by core once listed
+		if token.Internal && tokenUpdate.Listing == nil {
 			tokens = append(tokens, token)
 		}
 	}
@@ -41,8 +44,8 @@ func (self *HTTPServer) updateInternalTokensIndices(tokenUpdates map[string]comm
 
 // ensureRunningExchange makes sure that the exchange input is avaialbe in current deployment
 func (self *HTTPServer) ensureRunningExchange(ex string) (settings.ExchangeName, error) {
-	exName, ok := settings.ExchangeTypeValues()[ex]
-	if !ok {
+	exName := settings.ExchangeName(ex)
+	if _, ok := common.SupportedExchanges[common.ExchangeID(ex)]; !ok {
 		return exName, fmt.Errorf("Exchange %s is not in current deployment", ex)
 	}
 	return exName, nil
@@ -165,6 +168,10 @@ func (self *HTTPServer) SetTokenUpdate(c *gin.Context) {
 			httputil.ResponseFailure(c, httputil.WithReason(fmt.Sprintf("Token %s's name is empty. Token field in token request might be empty ", tokenID)))
 			return
 		}
+		if tokenUpdate.Listing != nil && !token.Internal {
+			httputil.ResponseFailure(c, httputil.WithReason(fmt.Sprintf("Token %s is external, it can't be listed on chain", tokenID)))
+			return
+		}
 		// if the token is internal, it must come with PWIEq, targetQty and QuadraticEquation and exchange setting
 		if token.Internal {
 			if uErr := self.ensureInternalSetting(tokenUpdate); uErr != nil {
@@ -224,6 +231,14 @@ func (self *HTTPServer) ConfirmTokenUpdate(c *gin.Context) {
 	data := []byte(postForm.Get("data"))
 	//no need to handle error here, if timestamp==0 the program will use UNIX timestamp instead
 	timestamp, _ := strconv.ParseUint(postForm.Get("timestamp"), 10, 64)
+	listingMode := postForm.Get("listing_mode")
+	if listingMode == "" {
+		listingMode = common.TokenListingBundle
+	}
+	if listingMode != common.TokenListingSubmit && listingMode != common.TokenListingBundle {
+		httputil.ResponseFailure(c, httputil.WithReason(fmt.Sprintf("listing mode %s is not supported", listingMode)))
+		return
+	}
 	var tokenUpdates map[string]common.TokenUpdate
 	if err := json.Unmarshal(data, &tokenUpdates); err != nil {
 		httputil.ResponseFailure(c, httputil.WithReason(fmt.Sprintf("cant not unmarshall token request %s", err.Error())))
@@ -270,6 +285,10 @@ func (self *HTTPServer) ConfirmTokenUpdate(c *gin.Context) {
 	for tokenID, tokenUpdate := range tokenUpdates {
 		token := tokenUpdate.Token
 		token.LastActivationChange = common.GetTimepoint()
+		if tokenUpdate.Listing != nil {
+			// the token is activated by core once listed on chain
+			token.Active = false
+		}
 		preparedToken = append(preparedToken, token)
 		if token.Internal {
 			//set metrics data for the token
@@ -309,7 +328,29 @@ func (self *HTTPServer) ConfirmTokenUpdate(c *gin.Context) {
 		httputil.ResponseFailure(c, httputil.WithReason(fmt.Sprintf("Can not apply token and exchange setting for token listing (%s). Metric data and token indices changes has to be manually revert", err.Error())))
 		return
 	}
-
+	// list all tokens, the listings of the others are returned with the
+	// errors as their transactions may be broadcasted already
+	listings := []common.TokenListing{}
+	listingErrors := []string{}
+	for tokenID, tokenUpdate := range tokenUpdates {
+		if tokenUpdate.Listing == nil {
+			continue
+		}
+		listing, lErr := self.core.ListToken(tokenUpdate, listingMode, common.GetTimepoint())
+		if lErr != nil {
+			listingErrors = append(listingErrors, fmt.Sprintf("Can not list token %s on chain (%s). It is kept inactive, set and confirm its token update again to retry", tokenID, lErr.Error()))
+			continue
+		}
+		listings = append(listings, listing)
+	}
+	if len(listingErrors) != 0 {
+		httputil.ResponseFailure(c, httputil.WithReason(strings.Join(listingErrors, "; ")), httputil.WithData(listings))
+		return
+	}
+	if len(listings) != 0 {
+		httputil.ResponseSuccess(c, httputil.WithData(listings))
+		return
+	}
 	httputil.ResponseSuccess(c)
 }
 
@@ -397,7 +438,12 @@ func thereIsInternal(tokenUpdates map[string]common.TokenUpdate) bool {
 
 func (self *HTTPServer) ensureInternalSetting(tokenUpdate common.TokenUpdate) error {
 	token := tokenUpdate.Token
-	if uErr := self.blockchain.CheckTokenIndices(ethereum.HexToAddress(token.Address)); uErr != nil {
+	if tokenUpdate.Listing != nil {
+		// the token is not listed on chain yet
+		if uErr := tokenUpdate.Listing.Validate(); uErr != nil {
+			return fmt.Errorf("invalid listing params (%s)", uErr.Error())
+		}
+	} else if uErr := self.blockchain.CheckTokenIndices(ethereum.HexToAddress(token.Address)); uErr != nil {
 		return fmt.Errorf("cannot get token indice from smart contract (%s) ", uErr.Error())
 	}
 	if tokenUpdate.Exchanges == nil {
@@ -432,9 +478,9 @@ func (self *HTTPServer) UpdateExchangeFee(c *gin.Context) {
 		return
 	}
 	name := postForm.Get("name")
-	exName, ok := settings.ExchangeTypeValues()[name]
-	if !ok {
-		httputil.ResponseFailure(c, httputil.WithError(fmt.Errorf("Exchange %s is not in current deployment", name)))
+	exName, err := self.ensureRunningExchange(name)
+	if err != nil {
+		httputil.ResponseFailure(c, httputil.WithError(err))
 		return
 	}
 	data := []byte(postForm.Get("data"))
@@ -461,9 +507,9 @@ func (self *HTTPServer) UpdateExchangeMinDeposit(c *gin.Context) {
 		return
 	}
 	name := postForm.Get("name")
-	exName, ok := settings.ExchangeTypeValues()[name]
-	if !ok {
-		httputil.ResponseFailure(c, httputil.WithReason(fmt.Sprintf("Exchange %s is not in current deployment", name)))
+	exName, err := self.ensureRunningExchange(name)
+	if err != nil {
+		httputil.ResponseFailure(c, httputil.WithError(err))
 		return
 	}
 	data := []byte(postForm.Get("data"))
@@ -481,15 +527,95 @@ func (self *HTTPServer) UpdateExchangeMinDeposit(c *gin.Context) {
 	httputil.ResponseSuccess(c)
 }
 
+// GetPendingFeeDiffs returns the differences between the live fees and min
+// deposits reported by exchange APIs and the current setting, by exchange.
+func (self *HTTPServer) GetPendingFeeDiffs(c *gin.Context) {
+	_, ok := self.Authenticated(c, []string{}, []Permission{RebalancePermission, ConfigurePermission, ReadOnlyPermission, ConfirmConfPermission})
+	if !ok {
+		return
+	}
+	data, err := self.setting.GetPendingFeeDiffs()
+	if err != nil {
+		httputil.ResponseFailure(c, httputil.WithError(err))
+		return
+	}
+	httputil.ResponseSuccess(c, httputil.WithData(data))
+}
+
+// ConfirmFeeDiff applies the live fees and min deposits of the pending
+// difference of an exchange. The version must be the timestamp of the
+// pending difference, so a difference changed since it was reviewed is not
+// applied.
+func (self *HTTPServer) ConfirmFeeDiff(c *gin.Context) {
+	postForm, ok := self.Authenticated(c, []string{"name", "version"}, []Permission{ConfirmConfPermission})
+	if !ok {
+		return
+	}
+	exName, err := self.ensureRunningExchange(postForm.Get("name"))
+	if err != nil {
+		httputil.ResponseFailure(c, httputil.WithError(err))
+		return
+	}
+	version, err := strconv.ParseUint(postForm.Get("version"), 10, 64)
+	if err != nil {
+		httputil.ResponseFailure(c, httputil.WithError(err))
+		return
+	}
+	//no need to handle error here, if timestamp==0 the program will use UNIX timestamp instead
+	timestamp, _ := strconv.ParseUint(postForm.Get("timestamp"), 10, 64)
+	diffs, err := self.setting.GetPendingFeeDiffs()
+	if err != nil {
+		httputil.ResponseFailure(c, httputil.WithError(err))
+		return
+	}
+	diff, avail := diffs[exName]
+	if !avail {
+		httputil.ResponseFailure(c, httputil.WithReason(fmt.Sprintf("there is no pending fee diff of %s", exName)))
+		return
+	}
+	if diff.Timestamp != version {
+		httputil.ResponseFailure(c, httputil.WithReason(fmt.Sprintf("pending fee diff of %s is at version %d, not %d", exName, diff.Timestamp, version)))
+		return
+	}
+	if err = self.setting.ApplyFeeDiff(exName, diff, timestamp); err != nil {
+		httputil.ResponseFailure(c, httputil.WithError(err))
+		return
+	}
+	if err = self.setting.RemovePendingFeeDiff(exName); err != nil {
+		httputil.ResponseFailure(c, httputil.WithError(err))
+		return
+	}
+	httputil.ResponseSuccess(c)
+}
+
+// RejectFeeDiff removes the pending fee difference of an exchange, it is
+// raised again by the next sync if the live fees still differ.
+func (self *HTTPServer) RejectFeeDiff(c *gin.Context) {
+	postForm, ok := self.Authenticated(c, []string{"name"}, []Permission{ConfirmConfPermission})
+	if !ok {
+		return
+	}
+	exName, err := self.ensureRunningExchange(postForm.Get("name"))
+	if err != nil {
+		httputil.ResponseFailure(c, httputil.WithError(err))
+		return
+	}
+	if err = self.setting.RemovePendingFeeDiff(exName); err != nil {
+		httputil.ResponseFailure(c, httputil.WithError(err))
+		return
+	}
+	httputil.ResponseSuccess(c)
+}
+
 func (self *HTTPServer) UpdateDepositAddress(c *gin.Context) {
 	postForm, ok := self.Authenticated(c, []string{"name", "data"}, []Permission{RebalancePermission, ConfigurePermission})
 	if !ok {
 		return
 	}
 	name := postForm.Get("name")
-	exName, ok := settings.ExchangeTypeValues()[name]
-	if !ok {
-		httputil.ResponseFailure(c, httputil.WithReason(fmt.Sprintf("Exchange %s is not in current deployment", name)))
+	exName, err := self.ensureRunningExchange(name)
+	if err != nil {
+		httputil.ResponseFailure(c, httputil.WithError(err))
 		return
 	}
 	data := []byte(postForm.Get("data"))
@@ -519,9 +645,9 @@ func (self *HTTPServer) UpdateExchangeInfo(c *gin.Context) {
 		return
 	}
 	name := postForm.Get("name")
-	exName, ok := settings.ExchangeTypeValues()[name]
-	if !ok {
-		httputil.ResponseFailure(c, httputil.WithReason(fmt.Sprintf("Exchange %s is not in current deployment", name)))
+	exName, err := self.ensureRunningExchange(name)
+	if err != nil {
+		httputil.ResponseFailure(c, httputil.WithError(err))
 		return
 	}
 	data := []byte(postForm.Get("data"))
@@ -592,6 +718,19 @@ func (self *HTTPServer) GetAllSetting(c *gin.Context) {
 	}))
 }
 
+// runsHuobi returns true if any of the running exchanges is a Huobi account,
+// which deposits through the intermediate operator.
+func runsHuobi() bool {
+	for _, ex := range common.SupportedExchanges {
+		if named, ok := ex.(interface {
+			Name() string
+		}); ok && named.Name() == "huobi" {
+			return true
+		}
+	}
+	return false
+}
+
 func (self *HTTPServer) getAddressResponse() (*common.AddressesResponse, error) {
 	addressSettings, err := self.setting.GetAllAddresses()
 	if err != nil {
@@ -599,7 +738,7 @@ func (self *HTTPServer) getAddressResponse() (*common.AddressesResponse, error)
 	}
 	addressSettings[pricingOPAddressName] = self.blockchain.GetPricingOPAddress().Hex()
 	addressSettings[depositOPAddressName] = self.blockchain.GetDepositOPAddress().Hex()
-	if _, ok := common.SupportedExchanges["huobi"]; ok {
+	if runsHuobi() {
 		addressSettings[intermediateOPAddressName] = self.blockchain.GetIntermediatorOPAddress().Hex()
 	}
 	if err != nil {
@@ -1,6 +1,12 @@
 package http
 
 import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+
+	"github.com/KyberNetwork/reserve-data/common"
 	"github.com/KyberNetwork/reserve-data/http/httputil"
 	"github.com/gin-gonic/gin"
 )
@@ -14,3 +20,167 @@ func (h *HTTPServer) GetStepFunctionData(c *gin.Context) {
 	}
 	httputil.ResponseSuccess(c, httputil.WithData(data))
 }
+
+// SetStepFunctions sets the step function update waiting for confirmation,
+// replacing the previous one. Only the step functions differing from the
+// ones on chain, as last fetched, are kept.
+// input data follows json: {"KNC": {"quantity_step_function": {...}, "imbalance_step_function": {...}}}
+func (h *HTTPServer) SetStepFunctions(c *gin.Context) {
+	postForm, ok := h.Authenticated(c, []string{"data"}, []Permission{ConfigurePermission})
+	if !ok {
+		return
+	}
+	data := []byte(postForm.Get("data"))
+	if len(data) > maxDataSize {
+		httputil.ResponseFailure(c, httputil.WithError(errDataSizeExceed))
+		return
+	}
+	updates := map[string]common.StepFunctionUpdate{}
+	if err := json.Unmarshal(data, &updates); err != nil {
+		httputil.ResponseFailure(c, httputil.WithError(err))
+		return
+	}
+	onchain, err := h.app.GetStepFunctionData()
+	if err != nil {
+		httputil.ResponseFailure(c, httputil.WithError(err))
+		return
+	}
+	pending := common.PendingStepFunctionUpdate{
+		Tokens:      map[string]common.StepFunctionUpdate{},
+		Current:     map[string]common.StepFunctionResponse{},
+		BlockNumber: onchain.BlockNumber,
+		Timestamp:   common.GetTimepoint(),
+	}
+	for tokenID, update := range updates {
+		token, tErr := h.setting.GetInternalTokenByID(tokenID)
+		if tErr != nil {
+			httputil.ResponseFailure(c, httputil.WithReason(fmt.Sprintf("token %s is not an internal token (%s)", tokenID, tErr)))
+			return
+		}
+		if token.IsETH() {
+			httputil.ResponseFailure(c, httputil.WithReason("ETH has no step functions"))
+			return
+		}
+		if vErr := update.Validate(); vErr != nil {
+			httputil.ResponseFailure(c, httputil.WithReason(fmt.Sprintf("token %s: %s", tokenID, vErr)))
+			return
+		}
+		current, avail := onchain.Tokens[tokenID]
+		if !avail {
+			httputil.ResponseFailure(c, httputil.WithReason(fmt.Sprintf("on chain step functions of %s are not fetched yet", tokenID)))
+			return
+		}
+		diff := update.Diff(current)
+		if diff.IsEmpty() {
+			continue
+		}
+		pending.Tokens[tokenID] = diff
+		pending.Current[tokenID] = current
+	}
+	if len(pending.Tokens) == 0 {
+		httputil.ResponseFailure(c, httputil.WithReason(fmt.Sprintf("step functions are the same as on chain at block %d", onchain.BlockNumber)))
+		return
+	}
+	if err = h.setting.UpdatePendingStepFunctions(pending); err != nil {
+		httputil.ResponseFailure(c, httputil.WithError(err))
+		return
+	}
+	httputil.ResponseSuccess(c, httputil.WithData(pending))
+}
+
+// GetPendingStepFunctions returns the step function update waiting for
+// confirmation, along with the step functions on chain it was diffed against.
+func (h *HTTPServer) GetPendingStepFunctions(c *gin.Context) {
+	_, ok := h.Authenticated(c, []string{}, []Permission{ReadOnlyPermission, ConfigurePermission, ConfirmConfPermission, RebalancePermission})
+	if !ok {
+		return
+	}
+	data, err := h.setting.GetPendingStepFunctions()
+	if err != nil {
+		httputil.ResponseFailure(c, httputil.WithError(err))
+		return
+	}
+	httputil.ResponseSuccess(c, httputil.WithData(data))
+}
+
+// ConfirmStepFunctions submits the pending step function update to the
+// pricing contract. The version must be the timestamp of the pending update,
+// and the step functions on chain must not have changed since it was set.
+// The pending update is removed once submitting starts, as a partially
+// submitted update is stale.
+func (h *HTTPServer) ConfirmStepFunctions(c *gin.Context) {
+	postForm, ok := h.Authenticated(c, []string{"version"}, []Permission{ConfirmConfPermission})
+	if !ok {
+		return
+	}
+	version, err := strconv.ParseUint(postForm.Get("version"), 10, 64)
+	if err != nil {
+		httputil.ResponseFailure(c, httputil.WithError(err))
+		return
+	}
+	pending, err := h.setting.GetPendingStepFunctions()
+	if err != nil {
+		httputil.ResponseFailure(c, httputil.WithError(err))
+		return
+	}
+	if pending.Timestamp != version {
+		httputil.ResponseFailure(c, httputil.WithReason(fmt.Sprintf("pending step functions are at version %d, not %d", pending.Timestamp, version)))
+		return
+	}
+	onchain, err := h.app.GetStepFunctionData()
+	if err != nil {
+		httputil.ResponseFailure(c, httputil.WithError(err))
+		return
+	}
+	tokenIDs := []string{}
+	tokens := map[string]common.Token{}
+	for tokenID := range pending.Tokens {
+		token, tErr := h.setting.GetInternalTokenByID(tokenID)
+		if tErr != nil {
+			httputil.ResponseFailure(c, httputil.WithReason(fmt.Sprintf("token %s is not an internal token (%s)", tokenID, tErr)))
+			return
+		}
+		current, avail := onchain.Tokens[tokenID]
+		if !avail {
+			httputil.ResponseFailure(c, httputil.WithReason(fmt.Sprintf("on chain step functions of %s are not fetched yet", tokenID)))
+			return
+		}
+		proposed := pending.Current[tokenID]
+		if !current.QuantityStepResponse.Equal(proposed.QuantityStepResponse) ||
+			!current.ImbalanceStepResponse.Equal(proposed.ImbalanceStepResponse) {
+			httputil.ResponseFailure(c, httputil.WithReason(fmt.Sprintf("step functions of %s changed on chain since block %d, set them again", tokenID, pending.BlockNumber)))
+			return
+		}
+		tokenIDs = append(tokenIDs, tokenID)
+		tokens[tokenID] = token
+	}
+	sort.Strings(tokenIDs)
+	if err = h.setting.RemovePendingStepFunctions(); err != nil {
+		httputil.ResponseFailure(c, httputil.WithError(err))
+		return
+	}
+	result := map[string][]common.ActivityID{}
+	for _, tokenID := range tokenIDs {
+		ids, sErr := h.core.SetStepFunctions(tokens[tokenID], pending.Tokens[tokenID], common.GetTimepoint())
+		result[tokenID] = ids
+		if sErr != nil {
+			httputil.ResponseFailure(c, httputil.WithReason(fmt.Sprintf("setting step functions of %s failed: %s", tokenID, sErr)), httputil.WithData(result))
+			return
+		}
+	}
+	httputil.ResponseSuccess(c, httputil.WithData(result))
+}
+
+// RejectStepFunctions removes the step function update waiting for
+// confirmation.
+func (h *HTTPServer) RejectStepFunctions(c *gin.Context) {
+	_, ok := h.Authenticated(c, []string{}, []Permission{ConfirmConfPermission})
+	if !ok {
+		return
+	}
+	if err := h.setting.RemovePendingStepFunctions(); err != nil {
+		httputil.ResponseFailure(c, httputil.WithError(err))
+		return
+	}
+	httputil.ResponseSuccess(c)
+}
@@ -0,0 +1,45 @@
+package http
+
+import (
+	"encoding/json"
+
+	"github.com/KyberNetwork/reserve-data/common"
+	"github.com/KyberNetwork/reserve-data/http/httputil"
+	"github.com/gin-gonic/gin"
+)
+
+// GetTokenListings returns the on chain listings of tokens and their
+// transactions.
+func (self *HTTPServer) GetTokenListings(c *gin.Context) {
+	_, ok := self.Authenticated(c, []string{}, []Permission{ReadOnlyPermission, ConfigurePermission, ConfirmConfPermission, RebalancePermission})
+	if !ok {
+		return
+	}
+	data, err := self.core.GetTokenListings()
+	if err != nil {
+		httputil.ResponseFailure(c, httputil.WithError(err))
+		return
+	}
+	httputil.ResponseSuccess(c, httputil.WithData(data))
+}
+
+// SubmitTokenListingTxs submits the hashes of the transactions of a bundle
+// token listing, sent by the admin wallet.
+// input txs follow json: ["0x...", "0x..."], in the order of the listing transactions
+func (self *HTTPServer) SubmitTokenListingTxs(c *gin.Context) {
+	postForm, ok := self.Authenticated(c, []string{"token", "txs"}, []Permission{ConfirmConfPermission})
+	if !ok {
+		return
+	}
+	var hashes []string
+	if err := json.Unmarshal([]byte(postForm.Get("txs")), &hashes); err != nil {
+		httputil.ResponseFailure(c, httputil.WithError(err))
+		return
+	}
+	listing, err := self.core.SubmitTokenListingTxs(postForm.Get("token"), hashes, common.GetTimepoint())
+	if err != nil {
+		httputil.ResponseFailure(c, httputil.WithError(err))
+		return
+	}
+	httputil.ResponseSuccess(c, httputil.WithData(listing))
+}
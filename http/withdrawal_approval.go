@@ -0,0 +1,118 @@
+package http
+
+import (
+	"encoding/json"
+
+	"github.com/KyberNetwork/reserve-data/common"
+	"github.com/KyberNetwork/reserve-data/http/httputil"
+	"github.com/gin-gonic/gin"
+)
+
+// maxWithdrawalApprovalsTimespan is the maximum time range of withdrawal
+// approvals returned at once, in milliseconds.
+const maxWithdrawalApprovalsTimespan uint64 = 7 * 24 * 60 * 60 * 1000
+
+// GetWithdrawThresholds return the amounts above which withdrawals of tokens require approval
+func (self *HTTPServer) GetWithdrawThresholds(c *gin.Context) {
+	_, ok := self.Authenticated(c, []string{}, []Permission{ReadOnlyPermission, ConfigurePermission, ConfirmConfPermission, RebalancePermission})
+	if !ok {
+		return
+	}
+	data, err := self.core.GetWithdrawThresholds()
+	if err != nil {
+		httputil.ResponseFailure(c, httputil.WithError(err))
+		return
+	}
+	httputil.ResponseSuccess(c, httputil.WithData(data))
+}
+
+// SetWithdrawThresholds replace withdraw thresholds
+// input data follow json: {"KNC": 10000, "ETH": 100}
+func (self *HTTPServer) SetWithdrawThresholds(c *gin.Context) {
+	postForm, ok := self.Authenticated(c, []string{"value"}, []Permission{ConfigurePermission})
+	if !ok {
+		return
+	}
+	value := []byte(postForm.Get("value"))
+	if len(value) > maxDataSize {
+		httputil.ResponseFailure(c, httputil.WithReason(errDataSizeExceed.Error()))
+		return
+	}
+	var thresholds common.WithdrawThresholds
+	if err := json.Unmarshal(value, &thresholds); err != nil {
+		httputil.ResponseFailure(c, httputil.WithError(err))
+		return
+	}
+	for tokenID := range thresholds {
+		if _, err := self.setting.GetInternalTokenByID(tokenID); err != nil {
+			httputil.ResponseFailure(c, httputil.WithError(err))
+			return
+		}
+	}
+	if err := self.core.SetWithdrawThresholds(thresholds); err != nil {
+		httputil.ResponseFailure(c, httputil.WithError(err))
+		return
+	}
+	httputil.ResponseSuccess(c)
+}
+
+// GetPendingWithdrawals return withdrawals waiting for approval
+func (self *HTTPServer) GetPendingWithdrawals(c *gin.Context) {
+	_, ok := self.Authenticated(c, []string{}, []Permission{ReadOnlyPermission, ConfigurePermission, ConfirmConfPermission, RebalancePermission})
+	if !ok {
+		return
+	}
+	data, err := self.core.GetPendingWithdrawals()
+	if err != nil {
+		httputil.ResponseFailure(c, httputil.WithError(err))
+		return
+	}
+	httputil.ResponseSuccess(c, httputil.WithData(data))
+}
+
+// GetWithdrawalApprovals return all withdrawals requiring approval proposed in a time range
+// of at most 7 days with who proposed and reviewed them
+func (self *HTTPServer) GetWithdrawalApprovals(c *gin.Context) {
+	_, ok := self.Authenticated(c, []string{}, []Permission{ReadOnlyPermission, ConfigurePermission, ConfirmConfPermission, RebalancePermission})
+	if !ok {
+		return
+	}
+	fromTime, toTime, ok := self.ValidateTimeRange(c, maxWithdrawalApprovalsTimespan)
+	if !ok {
+		return
+	}
+	data, err := self.core.GetWithdrawalApprovals(fromTime, toTime)
+	if err != nil {
+		httputil.ResponseFailure(c, httputil.WithError(err))
+		return
+	}
+	httputil.ResponseSuccess(c, httputil.WithData(data))
+}
+
+// ConfirmWithdrawal execute a pending withdrawal, it must be confirmed by another key than its proposer
+func (self *HTTPServer) ConfirmWithdrawal(c *gin.Context) {
+	_, ok := self.Authenticated(c, []string{}, []Permission{ConfirmConfPermission})
+	if !ok {
+		return
+	}
+	data, err := self.core.ConfirmWithdrawal(c.Param("id"), self.requester(c), getTimePoint(c, false))
+	if err != nil {
+		httputil.ResponseFailure(c, httputil.WithError(err), httputil.WithData(data))
+		return
+	}
+	httputil.ResponseSuccess(c, httputil.WithData(data))
+}
+
+// RejectWithdrawal reject a pending withdrawal, the proposer can reject its own withdrawal
+func (self *HTTPServer) RejectWithdrawal(c *gin.Context) {
+	_, ok := self.Authenticated(c, []string{}, []Permission{ConfirmConfPermission, RebalancePermission})
+	if !ok {
+		return
+	}
+	data, err := self.core.RejectWithdrawal(c.Param("id"), self.requester(c))
+	if err != nil {
+		httputil.ResponseFailure(c, httputil.WithError(err))
+		return
+	}
+	httputil.ResponseSuccess(c, httputil.WithData(data))
+}
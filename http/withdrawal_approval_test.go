@@ -0,0 +1,91 @@
+package http
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"github.com/KyberNetwork/reserve-data/common"
+	"github.com/KyberNetwork/reserve-data/core"
+	"github.com/KyberNetwork/reserve-data/data"
+	"github.com/KyberNetwork/reserve-data/data/storage"
+	"github.com/gin-gonic/gin"
+)
+
+func TestGetWithdrawalApprovalsTimeRange(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "test_withdrawal_approvals")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if rErr := os.RemoveAll(tmpDir); rErr != nil {
+			t.Error(rErr)
+		}
+	}()
+	st, err := storage.NewBoltStorage(filepath.Join(tmpDir, "test.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := common.GetTimepoint()
+	if err = st.StoreWithdrawalApproval(common.WithdrawalApproval{
+		ID:         "1",
+		Exchange:   "binance",
+		Token:      "EOS",
+		Amount:     "20000000000000000000000",
+		Status:     common.WithdrawalApprovalPending,
+		ProposedBy: "kn_secret",
+		ProposedAt: now - 60000,
+		ExpiresAt:  now + 60000,
+	}); err != nil {
+		t.Fatal(err)
+	}
+	s := HTTPServer{
+		app:         data.NewReserveData(nil, nil, nil, nil, nil, nil, nil, nil),
+		core:        core.NewReserveCore(nil, nil, nil, core.WithWithdrawalApproval(st, core.DefaultWithdrawalApprovalExpiry)),
+		authEnabled: false,
+		r:           gin.Default(),
+	}
+	s.register()
+	server := httptest.NewServer(s.r)
+	defer server.Close()
+
+	type response struct {
+		Success bool                        `json:"success"`
+		Reason  string                      `json:"reason"`
+		Data    []common.WithdrawalApproval `json:"data"`
+	}
+	get := func(query string) response {
+		t.Helper()
+		resp, err := http.Get(server.URL + "/withdrawal-approvals?" + query)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		var result response
+		if err = json.NewDecoder(resp.Body).Decode(&result); err != nil {
+			t.Fatal(err)
+		}
+		return result
+	}
+
+	if resp := get(""); resp.Success {
+		t.Error("expected withdrawal approvals requires fromTime")
+	}
+	tooLong := "fromTime=" + strconv.FormatUint(now-maxWithdrawalApprovalsTimespan-1, 10) +
+		"&toTime=" + strconv.FormatUint(now, 10)
+	if resp := get(tooLong); resp.Success {
+		t.Error("expected withdrawal approvals rejects time range longer than the max timespan")
+	}
+	resp := get("fromTime=" + strconv.FormatUint(now-maxWithdrawalApprovalsTimespan+60000, 10))
+	if !resp.Success {
+		t.Fatalf("expected withdrawal approvals of the last 7 days, got %s", resp.Reason)
+	}
+	if len(resp.Data) != 1 || resp.Data[0].ID != "1" {
+		t.Errorf("expected the proposed withdrawal, got %+v", resp.Data)
+	}
+}
@@ -50,6 +50,9 @@ type ReserveData interface {
 	CurrentPriceVersion(timestamp uint64) (common.Version, error)
 	GetAllPrices(timestamp uint64) (common.AllPriceResponse, error)
 	GetOnePrice(id common.TokenPairID, timestamp uint64) (common.OnePriceResponse, error)
+	// GetPairDepth returns the VWAP and depth of executing quantity of the
+	// base token of a pair, by exchange and combined.
+	GetPairDepth(id common.TokenPairID, quantity float64, bands []uint64, timestamp uint64) (common.PairDepth, error)
 
 	CurrentAuthDataVersion(timestamp uint64) (common.Version, error)
 	GetAuthData(timestamp uint64) (common.AuthDataResponse, error)
@@ -98,14 +101,100 @@ type ReserveCore interface {
 		amount *big.Int,
 		timestamp uint64) (common.ActivityID, error)
 
+	// Withdraw returns the pending withdrawal instead of executing it if
+	// amount is above the withdraw threshold of token.
 	Withdraw(
 		exchange common.Exchange,
 		token common.Token,
 		amount *big.Int,
-		timestamp uint64) (common.ActivityID, error)
+		timestamp uint64,
+		proposer string) (common.ActivityID, *common.WithdrawalApproval, error)
 
 	CancelOrder(id common.ActivityID, exchange common.Exchange) error
 
+	// Transfer moves token between sub-accounts of the exchange, the master
+	// account is given by an empty name.
+	Transfer(
+		exchange common.Exchange,
+		token common.Token,
+		amount *big.Int,
+		from, to string,
+		timestamp uint64) (common.ActivityID, error)
+
+	// RouteTrade splits the trade across exchanges to minimise its cost
+	// including fees, within maxSlippage of the best rate.
+	RouteTrade(
+		tradeType string,
+		base, quote common.Token,
+		amount, maxSlippage float64,
+		exchanges []common.Exchange,
+		timestamp uint64) (common.RoutedTrade, error)
+
+	GetRoutedTrade(id common.ActivityID) (common.RoutedTrade, error)
+
+	// execution of large orders in slices by a strategy
+	StartExecution(
+		exchange common.Exchange,
+		tradeType string,
+		base, quote common.Token,
+		rate, amount float64,
+		strategy common.ExecutionStrategy,
+		timestamp uint64) (common.Execution, error)
+	CancelExecution(id common.ActivityID) (common.Execution, error)
+	GetExecution(id common.ActivityID) (common.Execution, error)
+	RunExecutionEngine() error
+
+	// on chain listing of tokens, activating them once listed
+	ListToken(update common.TokenUpdate, mode string, timestamp uint64) (common.TokenListing, error)
+	SubmitTokenListingTxs(tokenID string, hashes []string, timestamp uint64) (common.TokenListing, error)
+	GetTokenListings() ([]common.TokenListing, error)
+	RunTokenListing() error
+
+	// step functions of tokens on the pricing contract
+	SetStepFunctions(token common.Token, update common.StepFunctionUpdate, timestamp uint64) ([]common.ActivityID, error)
+
+	// admin state of the reserve and pricing contracts
+	GetContractAdminState() (common.ContractAdminState, error)
+
+	// emergency kill switch of on chain trade
+	DisableTrade(tokens []common.Token, gasPrice *big.Int, timestamp uint64) ([]common.EmergencyTx, error)
+	EnableTrade(tokens []common.Token) ([]common.EmergencyTx, error)
+	SubmitEnableTradeTxs(txs []common.EmergencyTx, timestamp uint64) ([]common.EmergencyTx, error)
+
+	// approval of withdrawals above withdraw thresholds
+	GetWithdrawThresholds() (common.WithdrawThresholds, error)
+	SetWithdrawThresholds(thresholds common.WithdrawThresholds) error
+	GetPendingWithdrawals() ([]common.WithdrawalApproval, error)
+	GetWithdrawalApprovals(fromTime, toTime uint64) ([]common.WithdrawalApproval, error)
+	ConfirmWithdrawal(id, confirmer string, timestamp uint64) (common.WithdrawalApproval, error)
+	RejectWithdrawal(id, rejecter string) (common.WithdrawalApproval, error)
+
 	// blockchain related action
 	SetRates(tokens []common.Token, buys, sells []*big.Int, block *big.Int, afpMid []*big.Int, msgs []string) (common.ActivityID, error)
+
+	// dry run of the actions above, returning what would be sent without
+	// sending it or recording an activity
+	DryRunTrade(
+		exchange common.Exchange,
+		tradeType string,
+		base common.Token,
+		quote common.Token,
+		rate float64,
+		amount float64) (common.DryRunResult, error)
+
+	DryRunDeposit(exchange common.Exchange, token common.Token, amount *big.Int) (common.DryRunResult, error)
+
+	DryRunWithdraw(exchange common.Exchange, token common.Token, amount *big.Int) (common.DryRunResult, error)
+
+	DryRunCancelOrder(id common.ActivityID, exchange common.Exchange) (common.DryRunResult, error)
+
+	DryRunTransfer(exchange common.Exchange, token common.Token, amount *big.Int, from, to string) (common.DryRunResult, error)
+
+	DryRunRouteTrade(
+		tradeType string,
+		base, quote common.Token,
+		amount, maxSlippage float64,
+		exchanges []common.Exchange) (common.DryRunResult, error)
+
+	DryRunSetRates(tokens []common.Token, buys, sells []*big.Int, block *big.Int, afpMid []*big.Int) (common.DryRunResult, error)
 }
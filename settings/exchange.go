@@ -132,6 +132,39 @@ func (setting *Settings) UpdateExchangeNotification(exchange, action, tokenPair
 	return setting.Exchange.Storage.StoreExchangeNotification(exchange, action, tokenPair, fromTime, toTime, isWarning, msg)
 }
 
+// UpdateExchangeNotificationContent stores the notification of the action and
+// token of an exchange, replacing the existing one.
+func (setting *Settings) UpdateExchangeNotificationContent(exchange, action, tokenPair string, noti common.ExchangeNotiContent) error {
+	return setting.Exchange.Storage.StoreExchangeNotificationContent(exchange, action, tokenPair, noti)
+}
+
 func (setting *Settings) GetExchangeVersion() (uint64, error) {
 	return setting.Exchange.Storage.GetExchangeVersion()
 }
+
+func (setting *Settings) UpdatePendingFeeDiff(ex ExchangeName, diff common.ExchangeFeeDiff) error {
+	return setting.Exchange.Storage.StorePendingFeeDiff(ex, diff)
+}
+
+func (setting *Settings) GetPendingFeeDiffs() (map[ExchangeName]common.ExchangeFeeDiff, error) {
+	return setting.Exchange.Storage.GetPendingFeeDiffs()
+}
+
+func (setting *Settings) RemovePendingFeeDiff(ex ExchangeName) error {
+	return setting.Exchange.Storage.RemovePendingFeeDiff(ex)
+}
+
+// ApplyFeeDiff merges the live fees and min deposits of the difference into
+// the current setting of the exchange.
+func (setting *Settings) ApplyFeeDiff(exName ExchangeName, diff common.ExchangeFeeDiff, timestamp uint64) error {
+	fee, minDeposit := diff.LiveFees()
+	if len(fee.Trading) != 0 || len(fee.Funding.Withdraw) != 0 {
+		if err := setting.UpdateFee(exName, fee, timestamp); err != nil {
+			return err
+		}
+	}
+	if len(minDeposit) != 0 {
+		return setting.UpdateMinDeposit(exName, minDeposit, timestamp)
+	}
+	return nil
+}
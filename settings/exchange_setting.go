@@ -13,17 +13,27 @@ import (
 	ethereum "github.com/ethereum/go-ethereum/common"
 )
 
-// ExchangeName is the name of exchanges of which core will use to rebalance.
-//go:generate stringer -type=ExchangeName -linecomment
-type ExchangeName int
+// ExchangeName is the ID of an exchange account of which core will use to
+// rebalance. Built-in IDs are the names of exchanges core supports, accounts
+// configured in the exchanges config file can have any other ID.
+type ExchangeName string
 
 const (
-	Binance        ExchangeName = iota //binance
-	Bittrex                            //bittrex
-	Huobi                              //huobi
-	StableExchange                     //stable_exchange
+	Binance        ExchangeName = "binance"
+	Bittrex        ExchangeName = "bittrex"
+	Huobi          ExchangeName = "huobi"
+	StableExchange ExchangeName = "stable_exchange"
+	Kraken         ExchangeName = "kraken"
+)
+
+func (ex ExchangeName) String() string {
+	return string(ex)
+}
+
+const (
+	exchangeEnv       string = "KYBER_EXCHANGES"
+	exchangeConfigEnv string = "KYBER_EXCHANGES_CONFIG"
 )
-const exchangeEnv string = "KYBER_EXCHANGES"
 
 var ErrExchangeRecordNotFound = errors.New("Exchange record not found")
 
@@ -31,28 +41,77 @@ type ExchangeFeesConfig struct {
 	Exchanges map[string]common.ExchangeFees `json:"exchanges"`
 }
 
-var exchangeNameValue = map[string]ExchangeName{
-	"binance":         Binance,
-	"bittrex":         Bittrex,
-	"huobi":           Huobi,
-	"stable_exchange": StableExchange,
+// ExchangeConfig is the config of an exchange account core runs.
+type ExchangeConfig struct {
+	// ID identifies the account in settings, storages and APIs.
+	ID ExchangeName `json:"id"`
+	// Type is the name the connector of the exchange is registered with, eg: binance.
+	// It defaults to ID.
+	Type string `json:"type"`
+	// SecretPath is the file of API keys of the account, it defaults to the
+	// secret file of the deployment.
+	SecretPath string `json:"secret_path"`
+	// StoragePath is the database file of the account, it defaults to <ID>.db
+	// in the cmd directory.
+	StoragePath string `json:"storage_path"`
+	// SimulationURL is the endpoint of the exchange in simulation mode, it
+	// defaults to the simulator of the exchange type.
+	SimulationURL string `json:"simulation_url"`
+	// SubAccounts are the names of the sub-accounts of the account, eg: the
+	// emails of Binance sub-accounts. Only Binance supports sub-accounts.
+	SubAccounts []string `json:"sub_accounts"`
+}
+
+// ExchangesConfig is the content of the exchanges config file.
+type ExchangesConfig struct {
+	Exchanges []ExchangeConfig `json:"exchanges"`
 }
 
-// Running Exchange get the exchangeEnvironment params and return the list of exchanges ID for the current run
-// It returns empty string slice if the ENV is empty string or not found
+// LoadExchangesConfig reads the exchanges config file at path.
+func LoadExchangesConfig(path string) ([]ExchangeConfig, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var config ExchangesConfig
+	if err = json.Unmarshal(data, &config); err != nil {
+		return nil, err
+	}
+	ids := make(map[ExchangeName]bool)
+	for i, ex := range config.Exchanges {
+		if ex.ID == "" {
+			return nil, fmt.Errorf("exchange %d in %s has no id", i, path)
+		}
+		if ids[ex.ID] {
+			return nil, fmt.Errorf("exchange %s is duplicated in %s", ex.ID, path)
+		}
+		ids[ex.ID] = true
+		if ex.Type == "" {
+			config.Exchanges[i].Type = string(ex.ID)
+		}
+	}
+	return config.Exchanges, nil
+}
+
+// RunningExchangeConfigs returns the configs of exchanges for the current run.
+// They are read from the file at KYBER_EXCHANGES_CONFIG if it is set, or from
+// the comma separated exchange names at KYBER_EXCHANGES otherwise.
+// It returns empty slice if none of them is set.
 // DO NOT CALL this once httpserver has ran.
-func RunningExchanges() []string {
+func RunningExchangeConfigs() ([]ExchangeConfig, error) {
+	if path, ok := os.LookupEnv(exchangeConfigEnv); ok && len(path) != 0 {
+		return LoadExchangesConfig(path)
+	}
 	exchangesStr, ok := os.LookupEnv(exchangeEnv)
 	if (!ok) || (len(exchangesStr) == 0) {
 		log.Print("WARNING: core is running without exchange")
-		return nil
+		return nil, nil
 	}
-	exchanges := strings.Split(exchangesStr, ",")
-	return exchanges
-}
-
-func ExchangeTypeValues() map[string]ExchangeName {
-	return exchangeNameValue
+	var result []ExchangeConfig
+	for _, ex := range strings.Split(exchangesStr, ",") {
+		result = append(result, ExchangeConfig{ID: ExchangeName(ex), Type: ex})
+	}
+	return result, nil
 }
 
 type ExchangeSetting struct {
@@ -72,30 +131,27 @@ func (setting *Settings) loadFeeFromFile(path string) error {
 	if err = json.Unmarshal(data, &exFeeConfig); err != nil {
 		return err
 	}
-	runningExs := RunningExchanges()
-
+	runningExs, err := RunningExchangeConfigs()
+	if err != nil {
+		return err
+	}
 	for _, ex := range runningExs {
-		//Check if the exchange is in current code deployment.
-		exName, ok := exchangeNameValue[ex]
-		if !ok {
-			return fmt.Errorf("Exchange %s is in KYBER_EXCHANGES, but not avail in current deployment", ex)
-		}
+		exName := ex.ID
 		//Check if the current database has a record for such exchange
 		if _, err := setting.Exchange.Storage.GetFee(exName); err != nil {
-			log.Printf("Exchange %s is in KYBER_EXCHANGES but can't load fee in Database (%s). atempt to load it from config file", exName.String(), err.Error())
-			//Check if the config file has config for such exchange
-			exFee, ok := exFeeConfig.Exchanges[ex]
+			log.Printf("Exchange %s is running but can't load fee in Database (%s). atempt to load it from config file", exName.String(), err.Error())
+			//Check if the config file has config for such exchange, by its ID or its type
+			exFee, ok := exFeeConfig.Exchanges[exName.String()]
 			if !ok {
-				log.Printf("Warning: Exchange %s is in KYBER_EXCHANGES, but not avail in Fee config file.", ex)
-				continue
-			}
-			//multiply all Funding fee by 2 to avoid fee increasing from exchanges
-			for tokenID, value := range exFee.Funding.Deposit {
-				exFee.Funding.Deposit[tokenID] = value * 2
+				exFee, ok = exFeeConfig.Exchanges[ex.Type]
 			}
-			for tokenID, value := range exFee.Funding.Withdraw {
-				exFee.Funding.Withdraw[tokenID] = value * 2
+			if !ok {
+				log.Printf("Warning: Exchange %s is running, but not avail in Fee config file.", exName.String())
+				continue
 			}
+			//multiply all Funding fee by 2 to avoid fee increasing from exchanges,
+			//into new maps as accounts of the same exchange type share the config
+			exFee.Funding = common.NewFundingFee(doubleValues(exFee.Funding.Withdraw), doubleValues(exFee.Funding.Deposit))
 			//version =1 means it is init from config file
 			if err = setting.Exchange.Storage.StoreFee(exName, exFee, 1); err != nil {
 				return err
@@ -105,6 +161,15 @@ func (setting *Settings) loadFeeFromFile(path string) error {
 	return nil
 }
 
+// doubleValues returns a copy of the given map with all values multiplied by 2.
+func doubleValues(values map[string]float64) map[string]float64 {
+	result := make(map[string]float64, len(values))
+	for key, value := range values {
+		result[key] = value * 2
+	}
+	return result
+}
+
 type ExchangesMinDepositConfig struct {
 	Exchanges map[string]common.ExchangesMinDeposit `json:"exchanges"`
 }
@@ -119,26 +184,26 @@ func (setting *Settings) loadMinDepositFromFile(path string) error {
 	if err = json.Unmarshal(data, &exMinDepositConfig); err != nil {
 		return err
 	}
-	runningExs := RunningExchanges()
+	runningExs, err := RunningExchangeConfigs()
+	if err != nil {
+		return err
+	}
 	for _, ex := range runningExs {
-		//Check if the exchange is in current code deployment.
-		exName, ok := exchangeNameValue[ex]
-		if !ok {
-			return fmt.Errorf("Exchange %s is in KYBER_EXCHANGES, but not avail in current deployment", ex)
-		}
+		exName := ex.ID
 		//Check if the current database has a record for such exchange
 		if _, err := setting.Exchange.Storage.GetMinDeposit(exName); err != nil {
-			log.Printf("Exchange %s is in KYBER_EXCHANGES but can't load MinDeposit in Database (%s). atempt to load it from config file", exName.String(), err.Error())
-			//Check if the config file has config for such exchange
-			minDepo, ok := exMinDepositConfig.Exchanges[ex]
+			log.Printf("Exchange %s is running but can't load MinDeposit in Database (%s). atempt to load it from config file", exName.String(), err.Error())
+			//Check if the config file has config for such exchange, by its ID or its type
+			minDepo, ok := exMinDepositConfig.Exchanges[exName.String()]
+			if !ok {
+				minDepo, ok = exMinDepositConfig.Exchanges[ex.Type]
+			}
 			if !ok {
-				log.Printf("Warning: Exchange %s is in KYBER_EXCHANGES, but not avail in MinDepositconfig file", exName.String())
+				log.Printf("Warning: Exchange %s is running, but not avail in MinDepositconfig file", exName.String())
 				continue
 			}
 			//multiply all minimum deposit by 2 to avoid min deposit increasing from Exchange
-			for token, value := range minDepo {
-				minDepo[token] = value * 2
-			}
+			minDepo = doubleValues(minDepo)
 			//version =1 means it is init from config file
 			if err = setting.Exchange.Storage.StoreMinDeposit(exName, minDepo, 1); err != nil {
 				return err
@@ -167,20 +232,20 @@ func (setting *Settings) loadDepositAddressFromFile(path string) error {
 	if err = json.Unmarshal(data, &exAddressConfig); err != nil {
 		return err
 	}
-	runningExs := RunningExchanges()
+	runningExs, err := RunningExchangeConfigs()
+	if err != nil {
+		return err
+	}
 	for _, ex := range runningExs {
-		//Check if the exchange is in current code deployment.
-		exName, ok := exchangeNameValue[ex]
-		if !ok {
-			return fmt.Errorf("Exchange %s is in KYBER_EXCHANGES, but not avail in current deployment", ex)
-		}
+		exName := ex.ID
 		//Check if the current database has a record for such exchange
 		if _, err := setting.Exchange.Storage.GetDepositAddresses(exName); err != nil {
-			log.Printf("Exchange %s is in KYBER_EXCHANGES but can't load DepositAddress in Database (%s). atempt to load it from config file", exName.String(), err.Error())
-			//Check if the config file has config for such exchange
-			exchangeAddressStr, ok := exAddressConfig.Exchanges[ex]
+			log.Printf("Exchange %s is running but can't load DepositAddress in Database (%s). atempt to load it from config file", exName.String(), err.Error())
+			//Check if the config file has config for such exchange, only by its ID as
+			//accounts of the same exchange type have their own deposit addresses
+			exchangeAddressStr, ok := exAddressConfig.Exchanges[exName.String()]
 			if !ok {
-				log.Printf("Warning: Exchange %s is in KYBER_EXCHANGES, but not avail in DepositAddress config file", ex)
+				log.Printf("Warning: Exchange %s is running, but not avail in DepositAddress config file", exName.String())
 				continue
 			}
 			exchangeAddresses := convertToAddressMap(exchangeAddressStr)
@@ -202,14 +267,14 @@ func convertToAddressMap(data exchangeDepositAddress) common.ExchangeAddresses {
 }
 
 func (setting *Settings) handleEmptyExchangeInfo() error {
-	runningExs := RunningExchanges()
+	runningExs, err := RunningExchangeConfigs()
+	if err != nil {
+		return err
+	}
 	for _, ex := range runningExs {
-		exName, ok := exchangeNameValue[ex]
-		if !ok {
-			return fmt.Errorf("Exchange %s is in KYBER_EXCHANGES, but not avail in current deployment", ex)
-		}
+		exName := ex.ID
 		if _, err := setting.Exchange.Storage.GetExchangeInfo(exName); err != nil {
-			log.Printf("Exchange %s is in KYBER_EXCHANGES but can't load its exchangeInfo in Database (%s). attempt to init it", exName.String(), err.Error())
+			log.Printf("Exchange %s is running but can't load its exchangeInfo in Database (%s). attempt to init it", exName.String(), err.Error())
 			exInfo, err := setting.NewExchangeInfo(exName)
 			if err != nil {
 				return err
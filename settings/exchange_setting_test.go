@@ -0,0 +1,216 @@
+package settings_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/KyberNetwork/reserve-data/common"
+	"github.com/KyberNetwork/reserve-data/settings"
+	settingsstorage "github.com/KyberNetwork/reserve-data/settings/storage"
+)
+
+const testExchangesConfig = `{
+	"exchanges": [
+		{"id": "binance", "type": "binance"},
+		{"id": "binance_2", "type": "binance", "secret_path": "binance_2.json", "simulation_url": "http://127.0.0.1:5101"},
+		{"id": "huobi"}
+	]
+}`
+
+func writeTestFile(t *testing.T, tmpDir, name, content string) string {
+	t.Helper()
+	path := filepath.Join(tmpDir, name)
+	if err := ioutil.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestRunningExchangeConfigs(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "test_exchange_config")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if rErr := os.RemoveAll(tmpDir); rErr != nil {
+			t.Error(rErr)
+		}
+	}()
+	defer os.Unsetenv("KYBER_EXCHANGES")
+	defer os.Unsetenv("KYBER_EXCHANGES_CONFIG")
+
+	if err = os.Setenv("KYBER_EXCHANGES", "binance,stable_exchange"); err != nil {
+		t.Fatal(err)
+	}
+	configs, err := settings.RunningExchangeConfigs()
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []settings.ExchangeConfig{
+		{ID: settings.Binance, Type: "binance"},
+		{ID: settings.StableExchange, Type: "stable_exchange"},
+	}
+	if !reflect.DeepEqual(expected, configs) {
+		t.Errorf("expected configs %+v from KYBER_EXCHANGES, got %+v", expected, configs)
+	}
+
+	// the config file takes precedence over KYBER_EXCHANGES
+	configPath := writeTestFile(t, tmpDir, "exchanges.json", testExchangesConfig)
+	if err = os.Setenv("KYBER_EXCHANGES_CONFIG", configPath); err != nil {
+		t.Fatal(err)
+	}
+	if configs, err = settings.RunningExchangeConfigs(); err != nil {
+		t.Fatal(err)
+	}
+	expected = []settings.ExchangeConfig{
+		{ID: settings.Binance, Type: "binance"},
+		{ID: "binance_2", Type: "binance", SecretPath: "binance_2.json", SimulationURL: "http://127.0.0.1:5101"},
+		{ID: settings.Huobi, Type: "huobi"},
+	}
+	if !reflect.DeepEqual(expected, configs) {
+		t.Errorf("expected configs %+v from config file, got %+v", expected, configs)
+	}
+
+	duplicatedPath := writeTestFile(t, tmpDir, "duplicated.json", `{"exchanges": [{"id": "binance"}, {"id": "binance"}]}`)
+	if _, err = settings.LoadExchangesConfig(duplicatedPath); err == nil {
+		t.Error("expected error loading config of duplicated exchange ID")
+	}
+}
+
+func TestLoadFeeOfExchangeAccounts(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "test_exchange_fee")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if rErr := os.RemoveAll(tmpDir); rErr != nil {
+			t.Error(rErr)
+		}
+	}()
+	defer os.Unsetenv("KYBER_EXCHANGES_CONFIG")
+
+	configPath := writeTestFile(t, tmpDir, "exchanges.json", testExchangesConfig)
+	if err = os.Setenv("KYBER_EXCHANGES_CONFIG", configPath); err != nil {
+		t.Fatal(err)
+	}
+	feePath := writeTestFile(t, tmpDir, "fee.json", `{
+		"exchanges": {
+			"binance": {"trading": {"taker": 0.001}, "funding": {"withdraw": {"ETH": 0.01}, "deposit": {}}},
+			"huobi": {"trading": {"taker": 0.002}, "funding": {"withdraw": {"ETH": 0.02}, "deposit": {}}}
+		}
+	}`)
+	storage, err := settingsstorage.NewBoltSettingStorage(filepath.Join(tmpDir, "setting.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tokenSetting, err := settings.NewTokenSetting(storage)
+	if err != nil {
+		t.Fatal(err)
+	}
+	exchangeSetting, err := settings.NewExchangeSetting(storage)
+	if err != nil {
+		t.Fatal(err)
+	}
+	setting, err := settings.NewSetting(tokenSetting, &settings.AddressSetting{}, exchangeSetting, settings.WithHandleEmptyFee(feePath))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// accounts without their own fee config use the fee of their exchange type
+	for _, tc := range []struct {
+		ex       settings.ExchangeName
+		withdraw float64
+	}{
+		{settings.Binance, 0.02},
+		{"binance_2", 0.02},
+		{settings.Huobi, 0.04},
+	} {
+		var fee common.ExchangeFees
+		if fee, err = setting.GetFee(tc.ex); err != nil {
+			t.Fatalf("expected fee of %s loaded, got error %s", tc.ex, err)
+		}
+		if fee.Funding.Withdraw["ETH"] != tc.withdraw {
+			t.Errorf("expected ETH withdraw fee of %s %f, got %f", tc.ex, tc.withdraw, fee.Funding.Withdraw["ETH"])
+		}
+	}
+}
+
+func TestPendingFeeDiff(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "test_fee_diff")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if rErr := os.RemoveAll(tmpDir); rErr != nil {
+			t.Error(rErr)
+		}
+	}()
+	storage, err := settingsstorage.NewBoltSettingStorage(filepath.Join(tmpDir, "setting.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tokenSetting, err := settings.NewTokenSetting(storage)
+	if err != nil {
+		t.Fatal(err)
+	}
+	exchangeSetting, err := settings.NewExchangeSetting(storage)
+	if err != nil {
+		t.Fatal(err)
+	}
+	setting, err := settings.NewSetting(tokenSetting, &settings.AddressSetting{}, exchangeSetting)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fee := common.NewExchangeFee(
+		common.TradingFee{"taker": 0.001, "maker": 0.001},
+		common.NewFundingFee(map[string]float64{"ETH": 0.01, "KNC": 3}, map[string]float64{}),
+	)
+	if err = setting.UpdateFee(settings.Binance, fee, 0); err != nil {
+		t.Fatal(err)
+	}
+
+	diff := common.ExchangeFeeDiff{
+		Trading:    map[string]common.FeeChange{"taker": {Current: 0.001, Live: 0.00075}},
+		Withdraw:   map[string]common.FeeChange{"KNC": {Current: 3, Live: 4.5}},
+		MinDeposit: map[string]common.FeeChange{"KNC": {Current: 0, Live: 10}},
+		Timestamp:  1000,
+	}
+	if err = setting.UpdatePendingFeeDiff(settings.Binance, diff); err != nil {
+		t.Fatal(err)
+	}
+	diffs, err := setting.GetPendingFeeDiffs()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(diffs[settings.Binance], diff) {
+		t.Errorf("expected pending fee diff %+v, got %+v", diff, diffs[settings.Binance])
+	}
+
+	if err = setting.ApplyFeeDiff(settings.Binance, diff, 0); err != nil {
+		t.Fatal(err)
+	}
+	if fee, err = setting.GetFee(settings.Binance); err != nil {
+		t.Fatal(err)
+	}
+	if fee.Trading["taker"] != 0.00075 || fee.Trading["maker"] != 0.001 ||
+		fee.Funding.Withdraw["KNC"] != 4.5 || fee.Funding.Withdraw["ETH"] != 0.01 {
+		t.Errorf("expected live fees merged into current fees, got %+v", fee)
+	}
+	minDeposit, err := setting.GetMinDeposit(settings.Binance)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if minDeposit["KNC"] != 10 {
+		t.Errorf("expected live min deposit applied, got %+v", minDeposit)
+	}
+
+	if err = setting.RemovePendingFeeDiff(settings.Binance); err != nil {
+		t.Fatal(err)
+	}
+	if diffs, err = setting.GetPendingFeeDiffs(); err != nil || len(diffs) != 0 {
+		t.Errorf("expected no pending fee diff, got %+v, err: %v", diffs, err)
+	}
+}
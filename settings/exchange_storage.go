@@ -29,5 +29,15 @@ type ExchangeStorage interface {
 	StoreExchangeStatus(data common.ExchangesStatus) error
 	GetExchangeNotifications() (common.ExchangeNotifications, error)
 	StoreExchangeNotification(exchange, action, tokenPair string, fromTime, toTime uint64, isWarning bool, msg string) error
+	// StoreExchangeNotificationContent stores the notification of the action
+	// and token of an exchange, replacing the existing one.
+	StoreExchangeNotificationContent(exchange, action, tokenPair string, noti common.ExchangeNotiContent) error
 	GetExchangeVersion() (uint64, error)
+	// StorePendingFeeDiff stores the difference between the live fees of an
+	// exchange and its setting, replacing the pending one of the exchange.
+	StorePendingFeeDiff(ex ExchangeName, diff common.ExchangeFeeDiff) error
+	// GetPendingFeeDiffs returns the pending fee differences by exchange.
+	GetPendingFeeDiffs() (map[ExchangeName]common.ExchangeFeeDiff, error)
+	// RemovePendingFeeDiff removes the pending fee difference of an exchange.
+	RemovePendingFeeDiff(ex ExchangeName) error
 }
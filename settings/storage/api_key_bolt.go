@@ -0,0 +1,120 @@
+package storage
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"github.com/KyberNetwork/reserve-data/boltutil"
+	"github.com/KyberNetwork/reserve-data/common"
+	"github.com/boltdb/bolt"
+)
+
+// AddAPIKey stores a new API key, it returns error if the key ID exists already.
+func (boltSettingStorage *BoltSettingStorage) AddAPIKey(key common.APIKey) error {
+	dataJSON, err := json.Marshal(key)
+	if err != nil {
+		return err
+	}
+	return boltSettingStorage.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket([]byte(API_KEY_BUCKET))
+		if b.Get([]byte(key.ID)) != nil {
+			return fmt.Errorf("API key %s exists already", key.ID)
+		}
+		return b.Put([]byte(key.ID), dataJSON)
+	})
+}
+
+// UpdateAPIKey replaces the stored API key of the same ID.
+func (boltSettingStorage *BoltSettingStorage) UpdateAPIKey(key common.APIKey) error {
+	dataJSON, err := json.Marshal(key)
+	if err != nil {
+		return err
+	}
+	return boltSettingStorage.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket([]byte(API_KEY_BUCKET))
+		if b.Get([]byte(key.ID)) == nil {
+			return fmt.Errorf("API key %s doesn't exist", key.ID)
+		}
+		return b.Put([]byte(key.ID), dataJSON)
+	})
+}
+
+// RemoveAPIKey removes the API key of given ID.
+func (boltSettingStorage *BoltSettingStorage) RemoveAPIKey(id string) error {
+	return boltSettingStorage.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket([]byte(API_KEY_BUCKET))
+		if b.Get([]byte(id)) == nil {
+			return fmt.Errorf("API key %s doesn't exist", id)
+		}
+		return b.Delete([]byte(id))
+	})
+}
+
+// GetAPIKey returns the API key of given ID.
+func (boltSettingStorage *BoltSettingStorage) GetAPIKey(id string) (common.APIKey, error) {
+	var result common.APIKey
+	err := boltSettingStorage.db.View(func(tx *bolt.Tx) error {
+		data := tx.Bucket([]byte(API_KEY_BUCKET)).Get([]byte(id))
+		if data == nil {
+			return fmt.Errorf("API key %s doesn't exist", id)
+		}
+		return json.Unmarshal(data, &result)
+	})
+	return result, err
+}
+
+// GetAPIKeys returns all API keys ordered by ID.
+func (boltSettingStorage *BoltSettingStorage) GetAPIKeys() ([]common.APIKey, error) {
+	result := []common.APIKey{}
+	err := boltSettingStorage.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket([]byte(API_KEY_BUCKET)).ForEach(func(k, v []byte) error {
+			var key common.APIKey
+			if uErr := json.Unmarshal(v, &key); uErr != nil {
+				return uErr
+			}
+			result = append(result, key)
+			return nil
+		})
+	})
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].ID < result[j].ID
+	})
+	return result, err
+}
+
+// StoreAuditRecord appends the record to the audit log. Records are keyed by
+// their timestamp followed by a sequence number, so records of the same
+// timestamp are kept in order.
+func (boltSettingStorage *BoltSettingStorage) StoreAuditRecord(record common.AuditRecord) error {
+	dataJSON, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	return boltSettingStorage.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket([]byte(AUDIT_LOG_BUCKET))
+		seq, uErr := b.NextSequence()
+		if uErr != nil {
+			return uErr
+		}
+		key := append(boltutil.Uint64ToBytes(record.Timestamp), boltutil.Uint64ToBytes(seq)...)
+		return b.Put(key, dataJSON)
+	})
+}
+
+// GetAuditRecords returns the audit records in [fromTime, toTime].
+func (boltSettingStorage *BoltSettingStorage) GetAuditRecords(fromTime, toTime uint64) ([]common.AuditRecord, error) {
+	result := []common.AuditRecord{}
+	err := boltSettingStorage.db.View(func(tx *bolt.Tx) error {
+		c := tx.Bucket([]byte(AUDIT_LOG_BUCKET)).Cursor()
+		for k, v := c.Seek(boltutil.Uint64ToBytes(fromTime)); k != nil && boltutil.BytesToUint64(k[:8]) <= toTime; k, v = c.Next() {
+			var record common.AuditRecord
+			if uErr := json.Unmarshal(v, &record); uErr != nil {
+				return uErr
+			}
+			result = append(result, record)
+		}
+		return nil
+	})
+	return result, err
+}
@@ -23,6 +23,43 @@ func updateExchangeVersion(tx *bolt.Tx, timestamp uint64) error {
 	return nil
 }
 
+// legacyExchangeKeys are the keys exchange records were stored with when
+// exchange names were an integer enum.
+var legacyExchangeKeys = []settings.ExchangeName{
+	settings.Binance,
+	settings.Bittrex,
+	settings.Huobi,
+	settings.StableExchange,
+}
+
+// migrateExchangeKeys renames exchange records stored with legacy integer keys
+// to be keyed by exchange name. It does nothing to already migrated buckets.
+func migrateExchangeKeys(tx *bolt.Tx) error {
+	for _, bucket := range []string{EXCHANGE_FEE_BUCKET, EXCHANGE_MIN_DEPOSIT_BUCKET, EXCHANGE_DEPOSIT_ADDRESS, EXCHANGE_TOKEN_PAIRS, EXCHANGE_INFO} {
+		b := tx.Bucket([]byte(bucket))
+		if b == nil {
+			continue
+		}
+		for i, ex := range legacyExchangeKeys {
+			legacyKey := boltutil.Uint64ToBytes(uint64(i))
+			data := b.Get(legacyKey)
+			if data == nil {
+				continue
+			}
+			if b.Get([]byte(ex)) == nil {
+				if err := b.Put([]byte(ex), data); err != nil {
+					return err
+				}
+				log.Printf("migrated %s record of exchange %s", bucket, ex)
+			}
+			if err := b.Delete(legacyKey); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
 // GetFee returns a map[tokenID]exchangeFees and error if occur
 func (boltSettingStorage *BoltSettingStorage) GetFee(ex settings.ExchangeName) (common.ExchangeFees, error) {
 	var result common.ExchangeFees
@@ -31,7 +68,7 @@ func (boltSettingStorage *BoltSettingStorage) GetFee(ex settings.ExchangeName) (
 		if b == nil {
 			return fmt.Errorf("bucket %s hasn't existed yet", EXCHANGE_FEE_BUCKET)
 		}
-		data := b.Get(boltutil.Uint64ToBytes(uint64(ex)))
+		data := b.Get([]byte(ex))
 		if data == nil {
 			log.Printf("key %s hasn't existed yet", ex.String())
 			return settings.ErrExchangeRecordNotFound
@@ -65,7 +102,7 @@ func putFee(tx *bolt.Tx, ex settings.ExchangeName, fee common.ExchangeFees) erro
 	if uErr != nil {
 		return uErr
 	}
-	return b.Put(boltutil.Uint64ToBytes(uint64(ex)), dataJSON)
+	return b.Put([]byte(ex), dataJSON)
 }
 
 // GetMinDeposit returns a map[tokenID]MinDeposit and error if occur
@@ -76,7 +113,7 @@ func (boltSettingStorage *BoltSettingStorage) GetMinDeposit(ex settings.Exchange
 		if b == nil {
 			return fmt.Errorf("bucket %s hasn't existed yet", EXCHANGE_MIN_DEPOSIT_BUCKET)
 		}
-		data := b.Get(boltutil.Uint64ToBytes(uint64(ex)))
+		data := b.Get([]byte(ex))
 		if data == nil {
 			log.Printf("key %s hasn't existed yet", ex.String())
 			return settings.ErrExchangeRecordNotFound
@@ -110,7 +147,7 @@ func putMinDeposit(tx *bolt.Tx, ex settings.ExchangeName, minDeposit common.Exch
 	if uErr != nil {
 		return uErr
 	}
-	return b.Put(boltutil.Uint64ToBytes(uint64(ex)), dataJSON)
+	return b.Put([]byte(ex), dataJSON)
 }
 
 // GetDepositAddresses returns a map[tokenID]DepositAddress and error if occur
@@ -121,7 +158,7 @@ func (boltSettingStorage *BoltSettingStorage) GetDepositAddresses(ex settings.Ex
 		if b == nil {
 			return fmt.Errorf("bucket %s hasn't existed yet", EXCHANGE_DEPOSIT_ADDRESS)
 		}
-		data := b.Get(boltutil.Uint64ToBytes(uint64(ex)))
+		data := b.Get([]byte(ex))
 		if data == nil {
 			log.Printf("key %s hasn't existed yet", ex.String())
 			return settings.ErrExchangeRecordNotFound
@@ -145,7 +182,7 @@ func putDepositAddress(tx *bolt.Tx, ex settings.ExchangeName, addrs common.Excha
 	if uErr != nil {
 		return uErr
 	}
-	return b.Put(boltutil.Uint64ToBytes(uint64(ex)), dataJSON)
+	return b.Put([]byte(ex), dataJSON)
 }
 
 // StoreDepositAddress stores the depositAddress with exchangeName as key into database and
@@ -169,7 +206,7 @@ func (boltSettingStorage *BoltSettingStorage) GetTokenPairs(ex settings.Exchange
 		if b == nil {
 			return fmt.Errorf("bucket %s hasn't existed yet", EXCHANGE_TOKEN_PAIRS)
 		}
-		data := b.Get(boltutil.Uint64ToBytes(uint64(ex)))
+		data := b.Get([]byte(ex))
 		if data == nil {
 			log.Printf("key %s hasn't existed yet", ex.String())
 			return settings.ErrExchangeRecordNotFound
@@ -197,7 +234,7 @@ func (boltSettingStorage *BoltSettingStorage) StoreTokenPairs(ex settings.Exchan
 		if uErr := updateExchangeVersion(tx, timestamp); uErr != nil {
 			return uErr
 		}
-		return b.Put(boltutil.Uint64ToBytes(uint64(ex)), dataJSON)
+		return b.Put([]byte(ex), dataJSON)
 	})
 	return err
 }
@@ -209,7 +246,7 @@ func (boltSettingStorage *BoltSettingStorage) GetExchangeInfo(ex settings.Exchan
 		if b == nil {
 			return fmt.Errorf("bucket %s hasn't existed yet", EXCHANGE_INFO)
 		}
-		data := b.Get(boltutil.Uint64ToBytes(uint64(ex)))
+		data := b.Get([]byte(ex))
 		if data == nil {
 			log.Printf("key %s hasn't existed yet", ex.String())
 			return settings.ErrExchangeRecordNotFound
@@ -228,7 +265,7 @@ func putExchangeInfo(tx *bolt.Tx, ex settings.ExchangeName, exInfo common.Exchan
 	if uErr != nil {
 		return uErr
 	}
-	return b.Put(boltutil.Uint64ToBytes(uint64(ex)), dataJSON)
+	return b.Put([]byte(ex), dataJSON)
 }
 
 func (boltSettingStorage *BoltSettingStorage) StoreExchangeInfo(ex settings.ExchangeName, exInfo common.ExchangeInfo, timestamp uint64) error {
@@ -289,6 +326,18 @@ func (boltSettingStorage *BoltSettingStorage) StoreExchangeStatus(data common.Ex
 
 func (boltSettingStorage *BoltSettingStorage) StoreExchangeNotification(
 	exchange, action, token string, fromTime, toTime uint64, isWarning bool, msg string) error {
+	return boltSettingStorage.StoreExchangeNotificationContent(exchange, action, token, common.ExchangeNotiContent{
+		FromTime:  fromTime,
+		ToTime:    toTime,
+		IsWarning: isWarning,
+		Message:   msg,
+	})
+}
+
+// StoreExchangeNotificationContent stores the notification of the action and
+// token of an exchange, replacing the existing one.
+func (boltSettingStorage *BoltSettingStorage) StoreExchangeNotificationContent(
+	exchange, action, token string, noti common.ExchangeNotiContent) error {
 	var err error
 	err = boltSettingStorage.db.Update(func(tx *bolt.Tx) error {
 		exchangeBk := tx.Bucket([]byte(EXCHANGE_NOTIFICATIONS))
@@ -297,12 +346,6 @@ func (boltSettingStorage *BoltSettingStorage) StoreExchangeNotification(
 			return uErr
 		}
 		key := fmt.Sprintf("%s_%s", action, token)
-		noti := common.ExchangeNotiContent{
-			FromTime:  fromTime,
-			ToTime:    toTime,
-			IsWarning: isWarning,
-			Message:   msg,
-		}
 		// update new value
 		dataJSON, uErr := json.Marshal(noti)
 		if uErr != nil {
@@ -361,3 +404,53 @@ func (boltSettingStorage *BoltSettingStorage) GetExchangeVersion() (uint64, erro
 	})
 	return result, err
 }
+
+// StorePendingFeeDiff stores the difference between the live fees of an
+// exchange and its setting, replacing the pending one of the exchange.
+func (boltSettingStorage *BoltSettingStorage) StorePendingFeeDiff(ex settings.ExchangeName, diff common.ExchangeFeeDiff) error {
+	err := boltSettingStorage.db.Update(func(tx *bolt.Tx) error {
+		b, uErr := tx.CreateBucketIfNotExists([]byte(PENDING_FEE_SYNC))
+		if uErr != nil {
+			return uErr
+		}
+		dataJSON, uErr := json.Marshal(diff)
+		if uErr != nil {
+			return uErr
+		}
+		return b.Put([]byte(ex), dataJSON)
+	})
+	return err
+}
+
+// GetPendingFeeDiffs returns the pending fee differences by exchange.
+func (boltSettingStorage *BoltSettingStorage) GetPendingFeeDiffs() (map[settings.ExchangeName]common.ExchangeFeeDiff, error) {
+	result := make(map[settings.ExchangeName]common.ExchangeFeeDiff)
+	err := boltSettingStorage.db.View(func(tx *bolt.Tx) error {
+		b := tx.Bucket([]byte(PENDING_FEE_SYNC))
+		if b == nil {
+			return fmt.Errorf("Bucket %s does not exist yet", PENDING_FEE_SYNC)
+		}
+		c := b.Cursor()
+		for k, v := c.First(); k != nil; k, v = c.Next() {
+			var diff common.ExchangeFeeDiff
+			if vErr := json.Unmarshal(v, &diff); vErr != nil {
+				return vErr
+			}
+			result[settings.ExchangeName(k)] = diff
+		}
+		return nil
+	})
+	return result, err
+}
+
+// RemovePendingFeeDiff removes the pending fee difference of an exchange.
+func (boltSettingStorage *BoltSettingStorage) RemovePendingFeeDiff(ex settings.ExchangeName) error {
+	err := boltSettingStorage.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket([]byte(PENDING_FEE_SYNC))
+		if b == nil {
+			return fmt.Errorf("Bucket %s does not exist yet", PENDING_FEE_SYNC)
+		}
+		return b.Delete([]byte(ex))
+	})
+	return err
+}
@@ -0,0 +1,34 @@
+package storage
+
+import (
+	"github.com/KyberNetwork/reserve-data/boltutil"
+	"github.com/boltdb/bolt"
+)
+
+// UseNonce marks the nonce of keyID as used, it returns false if it was used
+// already. Used nonces below pruneBefore are removed as they are not in time
+// anymore. Nonces are keyed by the nonce followed by the key ID, so the
+// oldest ones come first.
+func (boltSettingStorage *BoltSettingStorage) UseNonce(keyID string, nonce, pruneBefore uint64) (bool, error) {
+	var used bool
+	err := boltSettingStorage.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket([]byte(USED_NONCE_BUCKET))
+		var expired [][]byte
+		c := b.Cursor()
+		for k, _ := c.First(); k != nil && boltutil.BytesToUint64(k[:8]) < pruneBefore; k, _ = c.Next() {
+			expired = append(expired, k)
+		}
+		for _, k := range expired {
+			if dErr := b.Delete(k); dErr != nil {
+				return dErr
+			}
+		}
+		key := append(boltutil.Uint64ToBytes(nonce), []byte(keyID)...)
+		if b.Get(key) != nil {
+			used = true
+			return nil
+		}
+		return b.Put(key, []byte{})
+	})
+	return !used && err == nil, err
+}
@@ -16,6 +16,12 @@ const (
 	EXCHANGE_STATUS             string = "exchange_status"
 	EXCHANGE_NOTIFICATIONS      string = "exchange_notifications"
 	PENDING_TOKEN_REQUEST       string = "pending_token_request"
+	PENDING_FEE_SYNC            string = "pending_fee_sync"
+	TOKEN_LISTING               string = "token_listing"
+	PENDING_STEP_FUNCTION       string = "pending_step_function"
+	API_KEY_BUCKET              string = "api_keys"
+	AUDIT_LOG_BUCKET            string = "audit_log"
+	USED_NONCE_BUCKET           string = "used_nonces"
 )
 
 type FilterFunction func(common.Token) bool
@@ -78,13 +84,31 @@ func NewBoltSettingStorage(dbPath string) (*BoltSettingStorage, error) {
 		if _, uErr := tx.CreateBucketIfNotExists([]byte(PENDING_TOKEN_REQUEST)); uErr != nil {
 			return uErr
 		}
+		if _, uErr := tx.CreateBucketIfNotExists([]byte(PENDING_FEE_SYNC)); uErr != nil {
+			return uErr
+		}
+		if _, uErr := tx.CreateBucketIfNotExists([]byte(TOKEN_LISTING)); uErr != nil {
+			return uErr
+		}
+		if _, uErr := tx.CreateBucketIfNotExists([]byte(PENDING_STEP_FUNCTION)); uErr != nil {
+			return uErr
+		}
 		if _, uErr := tx.CreateBucketIfNotExists([]byte(token_version)); uErr != nil {
 			return uErr
 		}
 		if _, uErr := tx.CreateBucketIfNotExists([]byte(exchange_version)); uErr != nil {
 			return uErr
 		}
-		return nil
+		if _, uErr := tx.CreateBucketIfNotExists([]byte(API_KEY_BUCKET)); uErr != nil {
+			return uErr
+		}
+		if _, uErr := tx.CreateBucketIfNotExists([]byte(AUDIT_LOG_BUCKET)); uErr != nil {
+			return uErr
+		}
+		if _, uErr := tx.CreateBucketIfNotExists([]byte(USED_NONCE_BUCKET)); uErr != nil {
+			return uErr
+		}
+		return migrateExchangeKeys(tx)
 	})
 	if err != nil {
 		return nil, err
@@ -360,3 +360,108 @@ func (boltSettingStorage *BoltSettingStorage) GetTokenVersion() (uint64, error)
 	})
 	return result, err
 }
+
+// StoreTokenListing stores the on chain listing of a token, replacing the
+// previous one of the token.
+func (boltSettingStorage *BoltSettingStorage) StoreTokenListing(listing common.TokenListing) error {
+	err := boltSettingStorage.db.Update(func(tx *bolt.Tx) error {
+		b, uErr := tx.CreateBucketIfNotExists([]byte(TOKEN_LISTING))
+		if uErr != nil {
+			return uErr
+		}
+		dataJSON, uErr := json.Marshal(listing)
+		if uErr != nil {
+			return uErr
+		}
+		return b.Put([]byte(strings.ToLower(listing.Token)), dataJSON)
+	})
+	return err
+}
+
+// GetTokenListings returns the on chain listings of all tokens.
+func (boltSettingStorage *BoltSettingStorage) GetTokenListings() ([]common.TokenListing, error) {
+	result := []common.TokenListing{}
+	err := boltSettingStorage.db.View(func(tx *bolt.Tx) error {
+		b := tx.Bucket([]byte(TOKEN_LISTING))
+		if b == nil {
+			return fmt.Errorf("Bucket %s does not exist yet", TOKEN_LISTING)
+		}
+		c := b.Cursor()
+		for k, v := c.First(); k != nil; k, v = c.Next() {
+			var listing common.TokenListing
+			if vErr := json.Unmarshal(v, &listing); vErr != nil {
+				return vErr
+			}
+			result = append(result, listing)
+		}
+		return nil
+	})
+	return result, err
+}
+
+// GetTokenListing returns the on chain listing of a token.
+func (boltSettingStorage *BoltSettingStorage) GetTokenListing(id string) (common.TokenListing, error) {
+	var result common.TokenListing
+	err := boltSettingStorage.db.View(func(tx *bolt.Tx) error {
+		b := tx.Bucket([]byte(TOKEN_LISTING))
+		if b == nil {
+			return fmt.Errorf("Bucket %s does not exist yet", TOKEN_LISTING)
+		}
+		data := b.Get([]byte(strings.ToLower(id)))
+		if data == nil {
+			return settings.ErrTokenListingNotFound
+		}
+		return json.Unmarshal(data, &result)
+	})
+	return result, err
+}
+
+var pendingStepFunctionKey = []byte("pending")
+
+// StorePendingStepFunctions stores the step function update waiting for
+// confirmation, replacing the previous one.
+func (boltSettingStorage *BoltSettingStorage) StorePendingStepFunctions(update common.PendingStepFunctionUpdate) error {
+	err := boltSettingStorage.db.Update(func(tx *bolt.Tx) error {
+		b, uErr := tx.CreateBucketIfNotExists([]byte(PENDING_STEP_FUNCTION))
+		if uErr != nil {
+			return uErr
+		}
+		dataJSON, uErr := json.Marshal(update)
+		if uErr != nil {
+			return uErr
+		}
+		return b.Put(pendingStepFunctionKey, dataJSON)
+	})
+	return err
+}
+
+// GetPendingStepFunctions returns the step function update waiting for
+// confirmation.
+func (boltSettingStorage *BoltSettingStorage) GetPendingStepFunctions() (common.PendingStepFunctionUpdate, error) {
+	var result common.PendingStepFunctionUpdate
+	err := boltSettingStorage.db.View(func(tx *bolt.Tx) error {
+		b := tx.Bucket([]byte(PENDING_STEP_FUNCTION))
+		if b == nil {
+			return fmt.Errorf("Bucket %s does not exist yet", PENDING_STEP_FUNCTION)
+		}
+		data := b.Get(pendingStepFunctionKey)
+		if data == nil {
+			return settings.ErrNoPendingStepFunctions
+		}
+		return json.Unmarshal(data, &result)
+	})
+	return result, err
+}
+
+// RemovePendingStepFunctions removes the step function update waiting for
+// confirmation.
+func (boltSettingStorage *BoltSettingStorage) RemovePendingStepFunctions() error {
+	err := boltSettingStorage.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket([]byte(PENDING_STEP_FUNCTION))
+		if b == nil {
+			return fmt.Errorf("Bucket %s does not exist yet", PENDING_STEP_FUNCTION)
+		}
+		return b.Delete(pendingStepFunctionKey)
+	})
+	return err
+}
@@ -13,6 +13,14 @@ import (
 // token is not found in database.
 var ErrTokenNotFound = errors.New("token not found")
 
+// ErrTokenListingNotFound is the error returned when the on chain listing of
+// a token is not found in database.
+var ErrTokenListingNotFound = errors.New("token listing not found")
+
+// ErrNoPendingStepFunctions is the error returned when there is no step
+// function update waiting for confirmation.
+var ErrNoPendingStepFunctions = errors.New("there is no pending step function update")
+
 func (setting *Settings) GetAllTokens() ([]common.Token, error) {
 	return setting.Tokens.Storage.GetAllTokens()
 }
@@ -113,6 +121,40 @@ func (setting *Settings) RemovePendingTokenUpdates() error {
 	return setting.Tokens.Storage.RemovePendingTokenUpdates()
 }
 
+// StoreTokenListing stores the on chain listing of a token.
+func (setting *Settings) StoreTokenListing(listing common.TokenListing) error {
+	return setting.Tokens.Storage.StoreTokenListing(listing)
+}
+
+// GetTokenListings returns the on chain listings of all tokens.
+func (setting *Settings) GetTokenListings() ([]common.TokenListing, error) {
+	return setting.Tokens.Storage.GetTokenListings()
+}
+
+// GetTokenListing returns the on chain listing of a token, or
+// ErrTokenListingNotFound if it is not listed by core.
+func (setting *Settings) GetTokenListing(id string) (common.TokenListing, error) {
+	return setting.Tokens.Storage.GetTokenListing(id)
+}
+
+// UpdatePendingStepFunctions stores the step function update waiting for
+// confirmation.
+func (setting *Settings) UpdatePendingStepFunctions(update common.PendingStepFunctionUpdate) error {
+	return setting.Tokens.Storage.StorePendingStepFunctions(update)
+}
+
+// GetPendingStepFunctions returns the step function update waiting for
+// confirmation, or ErrNoPendingStepFunctions if there is none.
+func (setting *Settings) GetPendingStepFunctions() (common.PendingStepFunctionUpdate, error) {
+	return setting.Tokens.Storage.GetPendingStepFunctions()
+}
+
+// RemovePendingStepFunctions removes the step function update waiting for
+// confirmation.
+func (setting *Settings) RemovePendingStepFunctions() error {
+	return setting.Tokens.Storage.RemovePendingStepFunctions()
+}
+
 func (setting *Settings) GetTokenVersion() (uint64, error) {
 	return setting.Tokens.Storage.GetTokenVersion()
 }
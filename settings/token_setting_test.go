@@ -2,6 +2,7 @@ package settings_test
 
 import (
 	"io/ioutil"
+	"math/big"
 	"os"
 	"path/filepath"
 	"reflect"
@@ -186,3 +187,89 @@ func TestExternalTokenSetting(t *testing.T) {
 	testGetActiveToken(setting, testExternalToken, t)
 	testNegativeGetInternalToken(t, setting, testExternalToken)
 }
+
+func TestTokenListingSetting(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "test_setting")
+	if err != nil {
+		t.Fatal(err)
+	}
+	setting := newTestSetting(t, tmpDir)
+	defer func() {
+		if rErr := os.RemoveAll(tmpDir); rErr != nil {
+			t.Error(rErr)
+		}
+	}()
+	if _, err = setting.GetTokenListing("OMG"); err != settings.ErrTokenListingNotFound {
+		t.Fatalf("expect listing not found, got %v", err)
+	}
+	listing := common.TokenListing{
+		Token:  "OMG",
+		Mode:   common.TokenListingSubmit,
+		Status: common.TokenListingPending,
+		Txs:    []common.TokenListingTx{{Method: "addToken", GasLimit: 200000}},
+	}
+	if err = setting.StoreTokenListing(listing); err != nil {
+		t.Fatal(err)
+	}
+	listing.Status = common.TokenListingDone
+	if err = setting.StoreTokenListing(listing); err != nil {
+		t.Fatal(err)
+	}
+	stored, err := setting.GetTokenListing("omg")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(stored, listing) {
+		t.Fatalf("expect listing %+v, got %+v", listing, stored)
+	}
+	listings, err := setting.GetTokenListings()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(listings) != 1 {
+		t.Fatalf("expect 1 listing, got %d", len(listings))
+	}
+}
+
+func TestPendingStepFunctionSetting(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "test_setting")
+	if err != nil {
+		t.Fatal(err)
+	}
+	setting := newTestSetting(t, tmpDir)
+	defer func() {
+		if rErr := os.RemoveAll(tmpDir); rErr != nil {
+			t.Error(rErr)
+		}
+	}()
+	if _, err = setting.GetPendingStepFunctions(); err != settings.ErrNoPendingStepFunctions {
+		t.Fatalf("expect no pending step functions, got %v", err)
+	}
+	update := common.PendingStepFunctionUpdate{
+		Tokens: map[string]common.StepFunctionUpdate{
+			"OMG": {QtyStepFunction: &common.QuantityStepFunction{
+				XBuy: []*big.Int{big.NewInt(100)}, YBuy: []*big.Int{big.NewInt(-10)},
+				XSell: []*big.Int{}, YSell: []*big.Int{},
+			}},
+		},
+		Current:     map[string]common.StepFunctionResponse{},
+		BlockNumber: 10,
+		Timestamp:   1000,
+	}
+	if err = setting.UpdatePendingStepFunctions(update); err != nil {
+		t.Fatal(err)
+	}
+	stored, err := setting.GetPendingStepFunctions()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(stored, update) {
+		t.Fatalf("expect pending step functions %+v, got %+v", update, stored)
+	}
+	if err = setting.RemovePendingStepFunctions(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = setting.GetPendingStepFunctions(); err != settings.ErrNoPendingStepFunctions {
+		t.Fatalf("expect no pending step functions after removal, got %v", err)
+	}
+}
@@ -37,4 +37,12 @@ type TokenStorage interface {
 	UpdateTokenWithExchangeSetting(t []common.Token, exSetting map[ExchangeName]*common.ExchangeSetting, timestamp uint64) error
 	RemovePendingTokenUpdates() error
 	GetTokenVersion() (uint64, error)
+	StoreTokenListing(common.TokenListing) error
+	GetTokenListings() ([]common.TokenListing, error)
+	GetTokenListing(id string) (common.TokenListing, error)
+	// StorePendingStepFunctions stores the step function update waiting for
+	// confirmation, replacing the previous one.
+	StorePendingStepFunctions(common.PendingStepFunctionUpdate) error
+	GetPendingStepFunctions() (common.PendingStepFunctionUpdate, error)
+	RemovePendingStepFunctions() error
 }
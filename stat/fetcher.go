@@ -14,6 +14,7 @@ import (
 	"time"
 
 	"github.com/KyberNetwork/reserve-data/common"
+	"github.com/KyberNetwork/reserve-data/common/instrument"
 	"github.com/KyberNetwork/reserve-data/settings"
 	statutil "github.com/KyberNetwork/reserve-data/stat/util"
 	ethereum "github.com/ethereum/go-ethereum/common"
@@ -785,6 +786,11 @@ func (self *Fetcher) RunLogFetcher() {
 			lastBlock = self.deployBlock
 		}
 		if err == nil {
+			if self.currentBlock > lastBlock {
+				instrument.LogFetcherLag.Set(float64(self.currentBlock-lastBlock))
+			} else {
+				instrument.LogFetcherLag.Set(0)
+			}
 			toBlock := lastBlock + 1 + 1440 // 1440 is considered as 6 hours
 			if toBlock > self.currentBlock-reorgBlockSafe {
 				toBlock = self.currentBlock - reorgBlockSafe